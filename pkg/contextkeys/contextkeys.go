@@ -0,0 +1,92 @@
+// Package contextkeys хранит значения запроса в контексте под ключами приватных типов,
+// чтобы они не пересекались с ключами других пакетов, и дает типизированный доступ к ним
+package contextkeys
+
+import "context"
+
+type (
+	tenantKey      struct{}
+	userKey        struct{}
+	supplierKey    struct{}
+	requestIDKey   struct{}
+	traceIDKey     struct{}
+	rolesKey       struct{}
+	permissionsKey struct{}
+)
+
+// WithTenant возвращает контекст с ID тенанта
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext возвращает ID тенанта и признак его наличия в контексте
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok
+}
+
+// WithUser возвращает контекст с ID аутентифицированного пользователя
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFromContext возвращает ID пользователя и признак его наличия в контексте
+func UserFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userKey{}).(string)
+	return userID, ok
+}
+
+// WithSupplier возвращает контекст с ID поставщика
+func WithSupplier(ctx context.Context, supplierID string) context.Context {
+	return context.WithValue(ctx, supplierKey{}, supplierID)
+}
+
+// SupplierFromContext возвращает ID поставщика и признак его наличия в контексте
+func SupplierFromContext(ctx context.Context) (string, bool) {
+	supplierID, ok := ctx.Value(supplierKey{}).(string)
+	return supplierID, ok
+}
+
+// WithRequestID возвращает контекст с ID запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext возвращает ID запроса и признак его наличия в контексте
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok
+}
+
+// WithTraceID возвращает контекст с ID трассировки, переданным в заголовке запроса или сообщения
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext возвращает ID трассировки и признак его наличия в контексте
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(traceIDKey{}).(string)
+	return traceID, ok
+}
+
+// WithRoles возвращает контекст с ролями пользователя
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext возвращает роли пользователя и признак их наличия в контексте
+func RolesFromContext(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(rolesKey{}).([]string)
+	return roles, ok
+}
+
+// WithPermissions возвращает контекст с эффективными правами пользователя
+func WithPermissions(ctx context.Context, permissions []string) context.Context {
+	return context.WithValue(ctx, permissionsKey{}, permissions)
+}
+
+// PermissionsFromContext возвращает права пользователя и признак их наличия в контексте
+func PermissionsFromContext(ctx context.Context) ([]string, bool) {
+	permissions, ok := ctx.Value(permissionsKey{}).([]string)
+	return permissions, ok
+}
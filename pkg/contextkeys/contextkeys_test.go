@@ -0,0 +1,79 @@
+package contextkeys
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStringValues(t *testing.T) {
+	tests := []struct {
+		name string
+		with func(ctx context.Context, value string) context.Context
+		from func(ctx context.Context) (string, bool)
+	}{
+		{name: "tenant", with: WithTenant, from: TenantFromContext},
+		{name: "user", with: WithUser, from: UserFromContext},
+		{name: "supplier", with: WithSupplier, from: SupplierFromContext},
+		{name: "request ID", with: WithRequestID, from: RequestIDFromContext},
+		{name: "trace ID", with: WithTraceID, from: TraceIDFromContext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value, ok := tt.from(context.Background()); ok || value != "" {
+				t.Fatalf("empty context = %q, %v, want nothing", value, ok)
+			}
+
+			ctx := tt.with(context.Background(), "value-1")
+			if value, ok := tt.from(ctx); !ok || value != "value-1" {
+				t.Fatalf("value = %q, %v, want value-1", value, ok)
+			}
+
+			// Вложенный контекст переопределяет значение
+			if value, _ := tt.from(tt.with(ctx, "value-2")); value != "value-2" {
+				t.Fatalf("value = %q, want value-2", value)
+			}
+		})
+	}
+}
+
+func TestSliceValues(t *testing.T) {
+	ctx := WithRoles(context.Background(), []string{"admin"})
+	ctx = WithPermissions(ctx, []string{"products:read", "products:write"})
+
+	if roles, ok := RolesFromContext(ctx); !ok || !reflect.DeepEqual(roles, []string{"admin"}) {
+		t.Fatalf("roles = %v, %v, want [admin]", roles, ok)
+	}
+	if permissions, ok := PermissionsFromContext(ctx); !ok || !reflect.DeepEqual(permissions, []string{"products:read", "products:write"}) {
+		t.Fatalf("permissions = %v, %v, want products:read and products:write", permissions, ok)
+	}
+	if _, ok := RolesFromContext(context.Background()); ok {
+		t.Fatal("roles found in an empty context")
+	}
+}
+
+func TestKeysDoNotCollide(t *testing.T) {
+	// Значение под строковым ключом другого пакета не видно через типизированные функции
+	ctx := context.WithValue(context.Background(), "tenant_id", "foreign")
+	if tenantID, ok := TenantFromContext(ctx); ok {
+		t.Fatalf("tenant = %q from a string key, want nothing", tenantID)
+	}
+
+	// Ключи с одинаковым типом значения не пересекаются между собой
+	ctx = WithTenant(ctx, "tenant-1")
+	ctx = WithUser(ctx, "user-1")
+	ctx = WithSupplier(ctx, "supplier-1")
+	if tenantID, _ := TenantFromContext(ctx); tenantID != "tenant-1" {
+		t.Fatalf("tenant = %q, want tenant-1", tenantID)
+	}
+	if userID, _ := UserFromContext(ctx); userID != "user-1" {
+		t.Fatalf("user = %q, want user-1", userID)
+	}
+	if _, ok := RequestIDFromContext(ctx); ok {
+		t.Fatal("request ID found, want nothing")
+	}
+	if value, _ := ctx.Value("tenant_id").(string); value != "foreign" {
+		t.Fatalf("string key = %q, want the foreign value untouched", value)
+	}
+}
@@ -0,0 +1,26 @@
+package dto
+
+// SupplierProduct товар из каталога внешнего поставщика
+type SupplierProduct struct {
+	SKU         string                 `json:"sku"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Brand       string                 `json:"brand,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+	Images      []string               `json:"images,omitempty"`
+}
+
+// SupplierInventory остаток товара у поставщика
+type SupplierInventory struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// SupplierPrice цена товара у поставщика
+type SupplierPrice struct {
+	SKU          string  `json:"sku"`
+	Price        float64 `json:"price"`
+	SpecialPrice float64 `json:"special_price,omitempty"`
+	Currency     string  `json:"currency"`
+}
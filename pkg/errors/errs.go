@@ -3,5 +3,6 @@ package errors
 import "errors"
 
 var (
-	ErrCacheMiss = errors.New("cache miss")
+	ErrCacheMiss    = errors.New("cache miss")
+	ErrRateNotFound = errors.New("exchange rate not found")
 )
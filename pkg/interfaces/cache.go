@@ -21,6 +21,10 @@ type CachePort interface {
 	// Помогает обеспечить изоляцию данных в многоарендной системе
 	GetWithTenant(ctx context.Context, key string, tenantID string) ([]byte, error)
 
+	// MGetWithTenant получает несколько значений одним запросом с учетом ID арендатора.
+	// Возвращает только найденные ключи (без префикса арендатора), промахи ошибкой не считаются
+	MGetWithTenant(ctx context.Context, keys []string, tenantID string) (map[string][]byte, error)
+
 	// Set сохраняет значение в кэше с указанным сроком действия
 	// Если expiration равно 0, срок действия не устанавливается
 	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
@@ -28,6 +32,10 @@ type CachePort interface {
 	// SetWithTenant сохраняет значение в кэше с учетом ID арендатора
 	SetWithTenant(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration) error
 
+	// SetWithJitter сохраняет значение с учетом ID арендатора на expiration, случайно сдвинутый
+	// в пределах ±jitter (доля, например 0.1). Разносит истечение записей, сохраненных одновременно
+	SetWithJitter(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration, jitter float64) error
+
 	// Delete удаляет значение из кэша по ключу
 	Delete(ctx context.Context, key string) error
 
@@ -39,6 +47,24 @@ type CachePort interface {
 	DeleteByPattern(ctx context.Context, pattern string) error
 	DeleteByPatternWithTenant(ctx context.Context, pattern, tenantID string) error
 
+	// GetOrSet возвращает значение из кэша с учетом ID арендатора, а при промахе вызывает loader,
+	// сохраняет результат на expiration и возвращает его. Одновременные промахи по одному ключу
+	// в рамках процесса объединяются, loader вызывается один раз. Ошибка loader возвращается как есть
+	// и не кэшируется
+	GetOrSet(ctx context.Context, key string, tenantID string, expiration time.Duration, loader func() ([]byte, error)) ([]byte, error)
+
+	// Increment атомарно увеличивает счетчик по ключу и возвращает новое значение
+	// Срок действия счетчика обновляется до expiration при каждом вызове
+	Increment(ctx context.Context, key string, expiration time.Duration) (int64, error)
+
+	// Lock захватывает распределенную блокировку key на ttl. Если блокировку держит другой владелец,
+	// возвращает acquired == false без ошибки. unlock снимает блокировку, только если она все еще
+	// принадлежит вызывающему, поэтому истекшая и перехваченная блокировка не будет удалена
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error)
+
+	// Ping проверяет доступность системы кэширования
+	Ping(ctx context.Context) error
+
 	// Close закрывает соединение с системой кэширования
 	Close() error
 }
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// ExchangeRate курс пересчета валюты From в валюту To: 1 From = Rate To
+type ExchangeRate struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      float64   `json:"rate"`
+	Timestamp time.Time `json:"timestamp"` // момент, на который действует курс
+}
+
+// RatesProviderPort определяет источник курсов валют.
+// Реализация может обращаться к API банка, биржи или использовать фиксированные курсы
+type RatesProviderPort interface {
+	// GetRate возвращает текущий курс from к to. Коды валют - ISO 4217 в верхнем регистре.
+	// Если курс неизвестен, возвращает errors.ErrRateNotFound
+	GetRate(ctx context.Context, from, to string) (*ExchangeRate, error)
+}
+
+// CurrencyConverterPort определяет интерфейс пересчета сумм между валютами
+type CurrencyConverterPort interface {
+	// Convert пересчитывает amount из from в to и возвращает сумму вместе с использованным курсом
+	Convert(ctx context.Context, amount float64, from, to string) (float64, *ExchangeRate, error)
+}
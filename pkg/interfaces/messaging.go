@@ -2,6 +2,9 @@ package interfaces
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -31,6 +34,22 @@ type ConsumerConfig struct {
 	TenantID           string        // ID арендатора (для многоарендности)
 }
 
+// FailurePolicy определяет, что делать с сообщением, которое не удалось обработать после всех попыток
+type FailurePolicy string
+
+const (
+	FailureDeadLetter FailurePolicy = "dlq"  // отправить в Dead Letter Queue (по умолчанию)
+	FailureDrop       FailurePolicy = "drop" // записать в лог и пропустить
+)
+
+// SubscriptionConfig содержит настройки обработки сообщений одной подписки.
+// Нулевые поля означают настройки клиента сообщений
+type SubscriptionConfig struct {
+	MaxRetries   int           // Число попыток обработки сообщения
+	RetryBackoff time.Duration // Базовая задержка между попытками
+	OnFailure    FailurePolicy // Что делать с сообщением после исчерпания попыток
+}
+
 // ProducerConfig содержит настройки для отправителя сообщений
 type ProducerConfig struct {
 	Async        bool          // Асинхронная отправка сообщений
@@ -43,10 +62,56 @@ type ProducerConfig struct {
 	TenantID     string        // ID арендатора (для многоарендности)
 }
 
+// BatchPublishError возвращается PublishBatch, если часть сообщений не доставлена.
+// Failed содержит индексы недоставленных сообщений в переданном срезе и причины
+type BatchPublishError struct {
+	Failed map[int]error
+}
+
+func (e *BatchPublishError) Error() string {
+	indexes := make([]int, 0, len(e.Failed))
+	for i := range e.Failed {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	parts := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		parts = append(parts, fmt.Sprintf("#%d: %v", i, e.Failed[i]))
+	}
+	return fmt.Sprintf("failed to publish %d message(s): %s", len(e.Failed), strings.Join(parts, "; "))
+}
+
 type MessagingPort interface {
 	Publish(ctx context.Context, topic string, message []byte) error
 
-	Subscribe(ctx context.Context, topic string, handler MessageHandler) (func() error, error)
+	// PublishWithKey публикует сообщение с ключом партиционирования. Сообщения с одинаковым ключом
+	// (например, ID продукта) попадают в одну партицию и обрабатываются в порядке публикации
+	PublishWithKey(ctx context.Context, topic, key string, message []byte) error
+
+	// PublishSync публикует сообщение и ждет подтверждения брокера или отмены ctx.
+	// Возвращает ошибку доставки, которую Publish сообщает только асинхронно в лог
+	PublishSync(ctx context.Context, topic string, message []byte) error
+
+	// PublishBatch публикует сообщения в топик одной пачкой и ждет подтверждения брокера для каждого.
+	// Если часть сообщений не доставлена, возвращает *BatchPublishError с их индексами.
+	// Заголовки tenant_id и трассировки берутся из ctx и одинаковы для всей пачки
+	PublishBatch(ctx context.Context, topic string, messages [][]byte) error
+
+	// PublishBatchWithKeys как PublishBatch, но keys[i] задает ключ партиционирования messages[i].
+	// Пустой ключ означает публикацию без ключа
+	PublishBatchWithKeys(ctx context.Context, topic string, keys []string, messages [][]byte) error
+
+	// Subscribe подписывает handler на топик. Необязательный config задает политику повторов
+	// и обработки ошибок для этой подписки
+	Subscribe(ctx context.Context, topic string, handler MessageHandler, config ...SubscriptionConfig) (func() error, error)
+
+	// SubscribeMulti подписывает одного подписчика на несколько топиков. Сообщения всех топиков
+	// передаются в handler, топик сообщения доступен в msg.Topic
+	SubscribeMulti(ctx context.Context, topics []string, handler MessageHandler, config ...SubscriptionConfig) (func() error, error)
+
+	// Ping проверяет доступность брокера сообщений
+	Ping(ctx context.Context) error
 
 	Close() error
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// ObjectStoragePort определяет интерфейс для работы с объектным хранилищем файлов.
+// Реализация может использовать S3, MinIO или любое совместимое хранилище
+type ObjectStoragePort interface {
+	// PutObject загружает объект по ключу и возвращает его публичный URL.
+	// size равен -1, если размер заранее неизвестен
+	PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (string, error)
+
+	// DeleteObject удаляет объект по ключу. Удаление отсутствующего объекта не является ошибкой
+	DeleteObject(ctx context.Context, key string) error
+}
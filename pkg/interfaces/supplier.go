@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"github.com/athebyme/gomarket-platform/pkg/dto"
+)
+
+// SupplierPort определяет интерфейс для получения данных внешнего поставщика.
+// Реализация может обращаться к HTTP API, файлам выгрузки или другим источникам
+type SupplierPort interface {
+	// FetchCatalog возвращает товары каталога поставщика
+	FetchCatalog(ctx context.Context, supplierID string) ([]*dto.SupplierProduct, error)
+
+	// FetchInventory возвращает остатки товаров поставщика
+	FetchInventory(ctx context.Context, supplierID string) ([]*dto.SupplierInventory, error)
+
+	// FetchPrices возвращает цены товаров поставщика
+	FetchPrices(ctx context.Context, supplierID string) ([]*dto.SupplierPrice, error)
+}
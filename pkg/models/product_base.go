@@ -23,7 +23,7 @@ type Service interface {
 	ListProducts(ctx context.Context, filters map[string]interface{}, page, pageSize int, tenantID string) ([]*dto.ProductDTO, int, error)
 
 	// SyncProductsFromSupplier синхронизирует продукты от поставщика
-	SyncProductsFromSupplier(ctx context.Context, supplierID int, tenantID string) (int, error)
+	SyncProductsFromSupplier(ctx context.Context, supplierID string, tenantID string) (int, error)
 
 	// SyncProductToMarketplace синхронизирует продукт с маркетплейсом
 	SyncProductToMarketplace(ctx context.Context, productID string, marketplaceID int, tenantID string) error
@@ -0,0 +1,166 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"time"
+)
+
+// cachePort пропускает обращения к кэшу через автоматический выключатель.
+// Промах кэша не считается отказом.
+type cachePort struct {
+	cache   interfaces.CachePort
+	breaker *CircuitBreaker
+	retry   RetryConfig
+}
+
+// NewCachePort оборачивает кэш автоматическим выключателем.
+// При разомкнутой цепи операции сразу возвращают ErrCircuitOpen, а GetOrSet
+// вызывает loader напрямую, поэтому чтения уходят в источник данных без ожидания кэша.
+// Идемпотентные операции (чтение, запись, удаление) при временных ошибках повторяются по retry;
+// Increment и Lock не повторяются, так как повтор после таймаута может применить их дважды
+func NewCachePort(cache interfaces.CachePort, breaker *CircuitBreaker, retry RetryConfig) interfaces.CachePort {
+	return &cachePort{cache: cache, breaker: breaker, retry: retry}
+}
+
+// execute вызывает операцию кэша через выключатель, не засчитывая промах как ошибку
+func (c *cachePort) execute(fn func() error) error {
+	var opErr error
+	err := c.breaker.Execute(func() error {
+		opErr = fn()
+		if errors.Is(opErr, pkgerrors.ErrCacheMiss) {
+			return nil
+		}
+		return opErr
+	})
+	if err != nil {
+		return err
+	}
+	return opErr
+}
+
+// executeWithRetry вызывает операцию через execute, повторяя ее при временных ошибках.
+// Разомкнутая цепь прекращает повторы
+func (c *cachePort) executeWithRetry(ctx context.Context, fn func() error) error {
+	return Retry(ctx, c.retry, func() error {
+		return c.execute(fn)
+	})
+}
+
+func (c *cachePort) Get(ctx context.Context, key string) ([]byte, error) {
+	var val []byte
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		val, err = c.cache.Get(ctx, key)
+		return err
+	})
+	return val, err
+}
+
+func (c *cachePort) GetWithTenant(ctx context.Context, key string, tenantID string) ([]byte, error) {
+	var val []byte
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		val, err = c.cache.GetWithTenant(ctx, key, tenantID)
+		return err
+	})
+	return val, err
+}
+
+func (c *cachePort) MGetWithTenant(ctx context.Context, keys []string, tenantID string) (map[string][]byte, error) {
+	var vals map[string][]byte
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		vals, err = c.cache.MGetWithTenant(ctx, keys, tenantID)
+		return err
+	})
+	return vals, err
+}
+
+func (c *cachePort) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.Set(ctx, key, value, expiration)
+	})
+}
+
+func (c *cachePort) SetWithTenant(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.SetWithTenant(ctx, key, value, tenantID, expiration)
+	})
+}
+
+func (c *cachePort) SetWithJitter(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration, jitter float64) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.SetWithJitter(ctx, key, value, tenantID, expiration, jitter)
+	})
+}
+
+func (c *cachePort) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	unlock := func() {}
+	var acquired bool
+	err := c.execute(func() error {
+		var err error
+		unlock, acquired, err = c.cache.Lock(ctx, key, ttl)
+		return err
+	})
+	return unlock, acquired, err
+}
+
+func (c *cachePort) Delete(ctx context.Context, key string) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.Delete(ctx, key)
+	})
+}
+
+func (c *cachePort) DeleteWithTenant(ctx context.Context, key string, tenantID string) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.DeleteWithTenant(ctx, key, tenantID)
+	})
+}
+
+func (c *cachePort) DeleteByPattern(ctx context.Context, pattern string) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.DeleteByPattern(ctx, pattern)
+	})
+}
+
+func (c *cachePort) DeleteByPatternWithTenant(ctx context.Context, pattern, tenantID string) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.cache.DeleteByPatternWithTenant(ctx, pattern, tenantID)
+	})
+}
+
+// GetOrSet сначала проверяет кэш через выключатель. Если кэш недоступен или цепь
+// разомкнута, значение загружается напрямую без записи в кэш
+func (c *cachePort) GetOrSet(ctx context.Context, key string, tenantID string, expiration time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	val, err := c.GetWithTenant(ctx, key, tenantID)
+	if err == nil {
+		return val, nil
+	}
+	if !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		return loader()
+	}
+
+	return c.cache.GetOrSet(ctx, key, tenantID, expiration, loader)
+}
+
+func (c *cachePort) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	var count int64
+	err := c.execute(func() error {
+		var err error
+		count, err = c.cache.Increment(ctx, key, expiration)
+		return err
+	})
+	return count, err
+}
+
+// Ping проверяет кэш напрямую, минуя выключатель, чтобы проверка готовности видела реальное состояние
+func (c *cachePort) Ping(ctx context.Context) error {
+	return c.cache.Ping(ctx)
+}
+
+func (c *cachePort) Close() error {
+	return c.cache.Close()
+}
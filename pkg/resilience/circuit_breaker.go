@@ -0,0 +1,178 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается без вызова операции, пока цепь разомкнута
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State состояние автоматического выключателя
+type State int
+
+const (
+	// StateClosed вызовы проходят, ошибки подряд подсчитываются
+	StateClosed State = iota
+	// StateOpen вызовы отклоняются до истечения OpenTimeout
+	StateOpen
+	// StateHalfOpen пропускается ограниченное число пробных вызовов
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Settings параметры автоматического выключателя
+type Settings struct {
+	TripThreshold   int           // число ошибок подряд, после которого цепь размыкается
+	OpenTimeout     time.Duration // сколько цепь остается разомкнутой до пробных вызовов
+	HalfOpenMaxReqs int           // число успешных пробных вызовов для замыкания цепи
+	// Now источник текущего времени для отсчета OpenTimeout, по умолчанию time.Now
+	Now func() time.Time
+}
+
+// CircuitBreaker защищает вызовы внешней зависимости. После TripThreshold ошибок подряд
+// цепь размыкается на OpenTimeout, затем пропускает до HalfOpenMaxReqs пробных вызовов:
+// если все они успешны, цепь замыкается, при первой ошибке снова размыкается.
+type CircuitBreaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // меняется при каждой смене состояния, чтобы не учитывать результаты старых вызовов
+	failures   int
+	openedAt   time.Time
+	inFlight   int // пробные вызовы в полуоткрытом состоянии
+	successes  int // успешные пробные вызовы в полуоткрытом состоянии
+}
+
+// NewCircuitBreaker создает замкнутый автоматический выключатель
+func NewCircuitBreaker(name string, settings Settings) *CircuitBreaker {
+	if settings.TripThreshold <= 0 {
+		settings.TripThreshold = 1
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenMaxReqs <= 0 {
+		settings.HalfOpenMaxReqs = 1
+	}
+	if settings.Now == nil {
+		settings.Now = time.Now
+	}
+
+	return &CircuitBreaker{
+		name:     name,
+		settings: settings,
+		now:      settings.Now,
+	}
+}
+
+// Name возвращает имя защищаемой зависимости
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// State возвращает текущее состояние с учетом истекшего OpenTimeout
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refreshState()
+	return cb.state
+}
+
+// Execute вызывает fn, если цепь это допускает, и учитывает результат.
+// При разомкнутой цепи возвращает ErrCircuitOpen, не вызывая fn
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	generation, err := cb.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	cb.record(generation, err == nil)
+	return err
+}
+
+func (cb *CircuitBreaker) allow() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refreshState()
+
+	switch cb.state {
+	case StateOpen:
+		return 0, ErrCircuitOpen
+	case StateHalfOpen:
+		if cb.inFlight+cb.successes >= cb.settings.HalfOpenMaxReqs {
+			return 0, ErrCircuitOpen
+		}
+		cb.inFlight++
+	}
+
+	return cb.generation, nil
+}
+
+func (cb *CircuitBreaker) record(generation uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case StateClosed:
+		if success {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.settings.TripThreshold {
+			cb.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		cb.inFlight--
+		if !success {
+			cb.setState(StateOpen)
+			return
+		}
+		cb.successes++
+		if cb.successes >= cb.settings.HalfOpenMaxReqs {
+			cb.setState(StateClosed)
+		}
+	}
+}
+
+// refreshState переводит разомкнутую цепь в полуоткрытое состояние по истечении OpenTimeout
+func (cb *CircuitBreaker) refreshState() {
+	if cb.state == StateOpen && cb.now().Sub(cb.openedAt) >= cb.settings.OpenTimeout {
+		cb.setState(StateHalfOpen)
+	}
+}
+
+func (cb *CircuitBreaker) setState(state State) {
+	cb.state = state
+	cb.generation++
+	cb.failures = 0
+	cb.inFlight = 0
+	cb.successes = 0
+	if state == StateOpen {
+		cb.openedAt = cb.now()
+	}
+}
@@ -0,0 +1,119 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock ручные часы для отсчета OpenTimeout
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var errDependency = errors.New("dependency failed")
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker("redis", Settings{
+		TripThreshold:   3,
+		OpenTimeout:     30 * time.Second,
+		HalfOpenMaxReqs: 2,
+		Now:             clock.Now,
+	})
+
+	calls := 0
+	succeed := func() error { calls++; return nil }
+	fail := func() error { calls++; return errDependency }
+
+	expectState := func(t *testing.T, want State) {
+		t.Helper()
+		if got := cb.State(); got != want {
+			t.Fatalf("state = %s, want %s", got, want)
+		}
+	}
+
+	t.Run("closed counts consecutive failures", func(t *testing.T) {
+		cb.Execute(fail)
+		cb.Execute(fail)
+		cb.Execute(succeed)
+		cb.Execute(fail)
+		cb.Execute(fail)
+		expectState(t, StateClosed)
+	})
+
+	t.Run("closed to open", func(t *testing.T) {
+		if err := cb.Execute(fail); !errors.Is(err, errDependency) {
+			t.Fatalf("err = %v, want dependency error", err)
+		}
+		expectState(t, StateOpen)
+	})
+
+	t.Run("open rejects calls until timeout", func(t *testing.T) {
+		before := calls
+		if err := cb.Execute(succeed); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err = %v, want ErrCircuitOpen", err)
+		}
+		clock.Advance(30*time.Second - time.Nanosecond)
+		if err := cb.Execute(succeed); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("err before timeout = %v, want ErrCircuitOpen", err)
+		}
+		if calls != before {
+			t.Fatalf("operation called %d times while open", calls-before)
+		}
+	})
+
+	t.Run("open to half-open", func(t *testing.T) {
+		clock.Advance(time.Nanosecond)
+		expectState(t, StateHalfOpen)
+	})
+
+	t.Run("failed probe reopens", func(t *testing.T) {
+		if err := cb.Execute(fail); !errors.Is(err, errDependency) {
+			t.Fatalf("err = %v, want dependency error", err)
+		}
+		expectState(t, StateOpen)
+
+		// OpenTimeout отсчитывается заново от повторного размыкания
+		clock.Advance(29 * time.Second)
+		expectState(t, StateOpen)
+		clock.Advance(time.Second)
+		expectState(t, StateHalfOpen)
+	})
+
+	t.Run("half-open limits probes", func(t *testing.T) {
+		var second, third error
+		first := cb.Execute(func() error {
+			// Пока идут два пробных вызова, третий отклоняется
+			second = cb.Execute(func() error {
+				third = cb.Execute(succeed)
+				return nil
+			})
+			return nil
+		})
+		if first != nil || second != nil || !errors.Is(third, ErrCircuitOpen) {
+			t.Fatalf("probes = %v, %v, %v, want third probe rejected", first, second, third)
+		}
+	})
+
+	t.Run("half-open to closed", func(t *testing.T) {
+		expectState(t, StateClosed)
+		if err := cb.Execute(succeed); err != nil {
+			t.Fatalf("err = %v after closing", err)
+		}
+	})
+}
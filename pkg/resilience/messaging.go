@@ -0,0 +1,74 @@
+package resilience
+
+import (
+	"context"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// messagingPort пропускает публикацию сообщений через автоматический выключатель
+type messagingPort struct {
+	messaging interfaces.MessagingPort
+	breaker   *CircuitBreaker
+	retry     RetryConfig
+}
+
+// NewMessagingPort оборачивает брокер сообщений автоматическим выключателем.
+// Публикация при разомкнутой цепи сразу возвращает ErrCircuitOpen, при временных ошибках
+// повторяется по retry; подписки не оборачиваются
+func NewMessagingPort(messaging interfaces.MessagingPort, breaker *CircuitBreaker, retry RetryConfig) interfaces.MessagingPort {
+	return &messagingPort{messaging: messaging, breaker: breaker, retry: retry}
+}
+
+// publish вызывает публикацию через выключатель, повторяя ее при временных ошибках
+func (m *messagingPort) publish(ctx context.Context, fn func() error) error {
+	return Retry(ctx, m.retry, func() error {
+		return m.breaker.Execute(fn)
+	})
+}
+
+func (m *messagingPort) Publish(ctx context.Context, topic string, message []byte) error {
+	return m.publish(ctx, func() error {
+		return m.messaging.Publish(ctx, topic, message)
+	})
+}
+
+func (m *messagingPort) PublishWithKey(ctx context.Context, topic, key string, message []byte) error {
+	return m.publish(ctx, func() error {
+		return m.messaging.PublishWithKey(ctx, topic, key, message)
+	})
+}
+
+func (m *messagingPort) PublishSync(ctx context.Context, topic string, message []byte) error {
+	return m.publish(ctx, func() error {
+		return m.messaging.PublishSync(ctx, topic, message)
+	})
+}
+
+func (m *messagingPort) PublishBatch(ctx context.Context, topic string, messages [][]byte) error {
+	return m.publish(ctx, func() error {
+		return m.messaging.PublishBatch(ctx, topic, messages)
+	})
+}
+
+func (m *messagingPort) PublishBatchWithKeys(ctx context.Context, topic string, keys []string, messages [][]byte) error {
+	return m.publish(ctx, func() error {
+		return m.messaging.PublishBatchWithKeys(ctx, topic, keys, messages)
+	})
+}
+
+func (m *messagingPort) Subscribe(ctx context.Context, topic string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	return m.messaging.Subscribe(ctx, topic, handler, config...)
+}
+
+func (m *messagingPort) SubscribeMulti(ctx context.Context, topics []string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	return m.messaging.SubscribeMulti(ctx, topics, handler, config...)
+}
+
+// Ping проверяет брокер напрямую, минуя выключатель, чтобы проверка готовности видела реальное состояние
+func (m *messagingPort) Ping(ctx context.Context) error {
+	return m.messaging.Ping(ctx)
+}
+
+func (m *messagingPort) Close() error {
+	return m.messaging.Close()
+}
@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultRetryWaitTime базовая задержка между повторами, если она не задана
+	defaultRetryWaitTime = 100 * time.Millisecond
+	// defaultMaxRetryWait верхняя граница задержки между повторами
+	defaultMaxRetryWait = 5 * time.Second
+)
+
+// RetryConfig параметры повторов. Соответствует разделу resilience конфигурации сервисов
+type RetryConfig struct {
+	MaxRetries int           // число повторов после первой попытки, 0 - без повторов
+	WaitTime   time.Duration // задержка перед первым повтором, удваивается с каждым следующим
+	MaxWait    time.Duration // верхняя граница задержки, по умолчанию 5 секунд
+}
+
+// transientError помечает ошибку как временную
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient помечает err как временную, чтобы Retry повторил операцию
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// IsTransient сообщает, может ли повтор операции завершиться успешно: таймауты, разрывы и отказы соединения,
+// а также ошибки драйверов, которые сами сообщают о возможности повтора (Timeout, Temporary, IsRetriable,
+// SafeToRetry). Отмена контекста, разомкнутая цепь и логические ошибки (промах кэша, нарушение ограничений)
+// временными не считаются
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var marked *transientError
+	if errors.As(err, &marked) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	// Интерфейсы ошибок драйверов: kafka.Error (IsRetriable, IsTimeout), pgconn (SafeToRetry)
+	var retriable interface{ IsRetriable() bool }
+	if errors.As(err, &retriable) && retriable.IsRetriable() {
+		return true
+	}
+	var kafkaTimeout interface{ IsTimeout() bool }
+	if errors.As(err, &kafkaTimeout) && kafkaTimeout.IsTimeout() {
+		return true
+	}
+	var safeToRetry interface{ SafeToRetry() bool }
+	if errors.As(err, &safeToRetry) && safeToRetry.SafeToRetry() {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+
+	return false
+}
+
+// Retry вызывает fn и повторяет его до cfg.MaxRetries раз, пока ошибка временная (IsTransient).
+// Задержка растет экспоненциально от cfg.WaitTime со случайной составляющей, чтобы клиенты не повторяли
+// запросы одновременно. Невременная ошибка возвращается сразу, при отмене ctx возвращается последняя ошибка fn
+func Retry(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	wait := cfg.WaitTime
+	if wait <= 0 {
+		wait = defaultRetryWaitTime
+	}
+	maxWait := cfg.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxRetryWait
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= cfg.MaxRetries || !IsTransient(err) {
+			return err
+		}
+
+		timer := time.NewTimer(withJitter(wait))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		wait *= 2
+		if wait > maxWait || wait <= 0 {
+			wait = maxWait
+		}
+	}
+}
+
+// withJitter возвращает задержку в диапазоне [wait/2, wait]
+func withJitter(wait time.Duration) time.Duration {
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
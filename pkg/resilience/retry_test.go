@@ -0,0 +1,154 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// retriableError ошибка драйвера, сообщающая о возможности повтора, как kafka.Error
+type retriableError struct {
+	retriable bool
+}
+
+func (e retriableError) Error() string     { return "driver error" }
+func (e retriableError) IsRetriable() bool { return e.retriable }
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "marked", err: fmt.Errorf("save: %w", Transient(errors.New("lock timeout"))), want: true},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "retriable driver error", err: retriableError{retriable: true}, want: true},
+		{name: "fatal driver error", err: retriableError{retriable: false}, want: false},
+		{name: "context canceled", err: context.Canceled, want: false},
+		{name: "circuit open", err: ErrCircuitOpen, want: false},
+		{name: "cache miss", err: pkgerrors.ErrCacheMiss, want: false},
+		{name: "logical error", err: errors.New("duplicate key value violates unique constraint"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Fatalf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cfg := RetryConfig{MaxRetries: 3, WaitTime: time.Millisecond}
+	ctx := context.Background()
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		calls := 0
+		err := Retry(ctx, cfg, func() error {
+			calls++
+			if calls < 3 {
+				return syscall.ECONNRESET
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("calls = %d, err = %v, want success on the third attempt", calls, err)
+		}
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		calls := 0
+		err := Retry(ctx, cfg, func() error {
+			calls++
+			return context.DeadlineExceeded
+		})
+		if !errors.Is(err, context.DeadlineExceeded) || calls != cfg.MaxRetries+1 {
+			t.Fatalf("calls = %d, err = %v, want %d attempts", calls, err, cfg.MaxRetries+1)
+		}
+	})
+
+	t.Run("logical error is not retried", func(t *testing.T) {
+		calls := 0
+		err := Retry(ctx, cfg, func() error {
+			calls++
+			return pkgerrors.ErrCacheMiss
+		})
+		if !errors.Is(err, pkgerrors.ErrCacheMiss) || calls != 1 {
+			t.Fatalf("calls = %d, err = %v, want a single attempt", calls, err)
+		}
+	})
+
+	t.Run("no retries configured", func(t *testing.T) {
+		calls := 0
+		_ = Retry(ctx, RetryConfig{}, func() error {
+			calls++
+			return syscall.ECONNRESET
+		})
+		if calls != 1 {
+			t.Fatalf("calls = %d, want 1 with MaxRetries 0", calls)
+		}
+	})
+
+	t.Run("canceled context stops retries", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		calls := 0
+		err := Retry(canceled, RetryConfig{MaxRetries: 5, WaitTime: time.Hour}, func() error {
+			calls++
+			cancel()
+			return syscall.ECONNRESET
+		})
+		if !errors.Is(err, syscall.ECONNRESET) || calls != 1 {
+			t.Fatalf("calls = %d, err = %v, want the last error without waiting", calls, err)
+		}
+	})
+}
+
+// flakyCache кэш, первые failures вызовов которого завершаются временной ошибкой
+type flakyCache struct {
+	interfaces.CachePort
+	failures int
+	gets     int
+	incrs    int
+}
+
+func (c *flakyCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.gets++
+	if c.gets <= c.failures {
+		return nil, syscall.ECONNRESET
+	}
+	return nil, pkgerrors.ErrCacheMiss
+}
+
+func (c *flakyCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c.incrs++
+	return 0, syscall.ECONNRESET
+}
+
+func TestCachePortRetry(t *testing.T) {
+	breaker := NewCircuitBreaker("redis", Settings{TripThreshold: 10, OpenTimeout: time.Minute, HalfOpenMaxReqs: 1})
+	flaky := &flakyCache{failures: 2}
+	cache := NewCachePort(flaky, breaker, RetryConfig{MaxRetries: 3, WaitTime: time.Millisecond})
+
+	// Промах кэша после временных ошибок возвращается без дальнейших повторов
+	if _, err := cache.Get(context.Background(), "product:1"); !errors.Is(err, pkgerrors.ErrCacheMiss) || flaky.gets != 3 {
+		t.Fatalf("gets = %d, err = %v, want two retries and then a cache miss", flaky.gets, err)
+	}
+
+	// Increment не идемпотентен и не повторяется
+	if _, err := cache.Increment(context.Background(), "counter", time.Minute); err == nil || flaky.incrs != 1 {
+		t.Fatalf("increments = %d, err = %v, want a single attempt", flaky.incrs, err)
+	}
+}
@@ -2,11 +2,24 @@ package tx
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	// serializationFailureCode SQLSTATE конфликта сериализации (could not serialize access)
+	serializationFailureCode = "40001"
+	// MaxSerializationRetries число повторов транзакции после конфликта сериализации
+	MaxSerializationRetries = 3
+	// serializationRetryBackoff базовая задержка перед повтором, удваивается с каждой попыткой
+	serializationRetryBackoff = 10 * time.Millisecond
+)
+
 // txKey - ключ для хранения транзакции в контексте. Используем приватный тип, чтобы избежать коллизий.
 type txKeyType struct{}
 
@@ -18,7 +31,14 @@ type TxManager interface {
 	// Если `fn` возвращает ошибку, транзакция откатывается (Rollback).
 	// Если `fn` завершается успешно (возвращает nil), транзакция фиксируется (Commit).
 	// Контекст, передаваемый в `fn`, будет содержать саму транзакцию.
+	// Транзакция выполняется один раз: при конфликте сериализации ошибка возвращается вызывающему.
 	Do(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// DoWithOptions выполняет `fn` в транзакции с заданными уровнем изоляции и режимом доступа.
+	// В отличие от Do, при конфликте сериализации (SQLSTATE 40001) в `fn` или при Commit транзакция откатывается,
+	// и `fn` выполняется заново в новой транзакции, не более MaxSerializationRetries раз.
+	// Поэтому `fn` не должна иметь побочных эффектов вне транзакции.
+	DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error
 }
 
 // pgxTxManager - реализация TxManager для pgx.
@@ -33,8 +53,47 @@ func NewTxManager(pool *pgxpool.Pool) TxManager {
 
 // Do реализует метод интерфейса TxManager.
 func (m *pgxTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, pgx.TxOptions{}, fn)
+}
+
+// DoWithOptions реализует метод интерфейса TxManager.
+func (m *pgxTxManager) DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	return retrySerialization(ctx, func() error {
+		return m.run(ctx, opts, fn)
+	})
+}
+
+// retrySerialization вызывает run и повторяет его после конфликта сериализации не более
+// MaxSerializationRetries раз с удваивающейся задержкой. При отмене ctx во время ожидания
+// возвращается последняя ошибка run
+func retrySerialization(ctx context.Context, run func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := run()
+		if err == nil || !IsSerializationFailure(err) || attempt >= MaxSerializationRetries {
+			return err
+		}
+
+		// Конкурирующая транзакция уже зафиксирована, повтор с небольшой задержкой увидит ее результат
+		timer := time.NewTimer(serializationRetryBackoff << attempt)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// IsSerializationFailure сообщает, вызвана ли ошибка конфликтом сериализации транзакций (SQLSTATE 40001)
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == serializationFailureCode
+}
+
+// run выполняет одну попытку транзакции с опциями opts.
+func (m *pgxTxManager) run(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
 	// Начинаем транзакцию
-	tx, err := m.pool.Begin(ctx)
+	tx, err := m.pool.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("tx.Begin failed: %w", err)
 	}
@@ -0,0 +1,136 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDatabaseURLEnv переменная окружения с адресом тестовой БД; без нее тесты с БД пропускаются
+const testDatabaseURLEnv = "TX_TEST_DATABASE_URL"
+
+var errSerialization = &pgconn.PgError{Code: serializationFailureCode, Message: "could not serialize access"}
+
+func TestIsSerializationFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "serialization failure", err: errSerialization, want: true},
+		{name: "wrapped on commit", err: fmt.Errorf("tx.Commit failed: %w", errSerialization), want: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, want: false},
+		{name: "plain error", err: errors.New("40001"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSerializationFailure(tt.err); got != tt.want {
+				t.Fatalf("IsSerializationFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrySerialization(t *testing.T) {
+	errOther := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		failures  int
+		failWith  error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", failures: 0, wantCalls: 1},
+		{name: "retried until success", failures: 2, failWith: errSerialization, wantCalls: 3},
+		{name: "retries exhausted", failures: 10, failWith: errSerialization, wantCalls: MaxSerializationRetries + 1, wantErr: errSerialization},
+		{name: "other error not retried", failures: 10, failWith: errOther, wantCalls: 1, wantErr: errOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retrySerialization(context.Background(), func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failWith
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetrySerializationStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := retrySerialization(ctx, func() error {
+		calls++
+		cancel()
+		return errSerialization
+	})
+
+	if calls != 1 || !IsSerializationFailure(err) {
+		t.Fatalf("calls = %d, err = %v, want one call and the serialization failure", calls, err)
+	}
+}
+
+func TestDoRetryIsOptIn(t *testing.T) {
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s не задан", testDatabaseURLEnv)
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	manager := NewTxManager(pool)
+
+	// failTwice возвращает конфликт сериализации на первых двух вызовах
+	failTwice := func(calls *int) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			*calls++
+			if _, ok := GetTxFromContext(ctx); !ok {
+				t.Fatal("no transaction in context")
+			}
+			if *calls <= 2 {
+				return errSerialization
+			}
+			return nil
+		}
+	}
+
+	t.Run("Do runs once", func(t *testing.T) {
+		calls := 0
+		err := manager.Do(context.Background(), failTwice(&calls))
+		if calls != 1 || !IsSerializationFailure(err) {
+			t.Fatalf("calls = %d, err = %v, want one call and the serialization failure", calls, err)
+		}
+	})
+
+	t.Run("DoWithOptions retries", func(t *testing.T) {
+		calls := 0
+		err := manager.DoWithOptions(context.Background(), pgx.TxOptions{IsoLevel: pgx.Serializable}, failTwice(&calls))
+		if calls != 3 || err != nil {
+			t.Fatalf("calls = %d, err = %v, want success on the third call", calls, err)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"math/rand"
+	"time"
+)
+
+// JitterTTL случайно сдвигает срок действия в пределах ±fraction от ttl (0.1 - ±10%).
+// Записи, сохраненные одновременно, истекают в разное время, и перезагрузка из БД
+// не приходится на один момент. Нулевой ttl (без срока действия) и fraction <= 0 не меняются
+func JitterTTL(ttl time.Duration, fraction float64) time.Duration {
+	if ttl <= 0 || fraction <= 0 {
+		return ttl
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	spread := float64(ttl) * fraction
+	jittered := time.Duration(float64(ttl) + (rand.Float64()*2-1)*spread)
+	if jittered <= 0 {
+		return time.Millisecond
+	}
+	return jittered
+}
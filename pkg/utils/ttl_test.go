@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterTTL(t *testing.T) {
+	const ttl = 30 * time.Minute
+	low, high := 27*time.Minute, 33*time.Minute
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 1000; i++ {
+		got := JitterTTL(ttl, 0.1)
+		if got < low || got > high {
+			t.Fatalf("JitterTTL(%v, 0.1) = %v, want within [%v, %v]", ttl, got, low, high)
+		}
+		seen[got] = true
+	}
+	// Сдвиг действительно случайный, а не постоянный
+	if len(seen) < 100 {
+		t.Fatalf("only %d distinct TTLs in 1000 calls", len(seen))
+	}
+}
+
+func TestJitterTTLUnchanged(t *testing.T) {
+	tests := []struct {
+		ttl      time.Duration
+		fraction float64
+	}{
+		{ttl: 0, fraction: 0.1},
+		{ttl: -time.Second, fraction: 0.1},
+		{ttl: time.Minute, fraction: 0},
+		{ttl: time.Minute, fraction: -0.5},
+	}
+
+	for _, tt := range tests {
+		if got := JitterTTL(tt.ttl, tt.fraction); got != tt.ttl {
+			t.Errorf("JitterTTL(%v, %v) = %v, want it unchanged", tt.ttl, tt.fraction, got)
+		}
+	}
+}
+
+func TestJitterTTLStaysPositive(t *testing.T) {
+	// Доля больше 1 ограничивается единицей, и срок не становится нулевым, то есть бессрочным
+	for i := 0; i < 1000; i++ {
+		if got := JitterTTL(time.Second, 5); got <= 0 || got > 2*time.Second {
+			t.Fatalf("JitterTTL(1s, 5) = %v, want within (0, 2s]", got)
+		}
+	}
+}
@@ -5,14 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/pkg/resilience"
 	"github.com/athebyme/gomarket-platform/pkg/tx"
 	"github.com/athebyme/gomarket-platform/product-service/config"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/currency"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/marketplace"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/objectstorage"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/supplier"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/tracing"
 	"github.com/athebyme/gomarket-platform/product-service/internal/api"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/handlers"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/middleware"
 	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/lifecycle"
 	"github.com/athebyme/gomarket-platform/product-service/internal/security"
 	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,28 +32,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
 // метрики для Prometheus
 var (
-	httpDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    "http_durations_seconds",
-		Help:    "Длительность HTTP запросов",
-		Buckets: prometheus.DefBuckets,
-	}, []string{"path", "method", "status"})
-
-	requestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "http_requests_total",
-		Help: "Общее количество HTTP запросов",
-	}, []string{"path", "method", "status"})
-
-	activeRequests = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "http_active_requests",
-		Help: "Количество активных HTTP запросов",
-	})
-
 	cacheOperations = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "cache_operations_total",
 		Help: "Количество операций с кэшем",
@@ -73,16 +68,31 @@ func main() {
 		interfaces.LogField{Key: "env", Value: cfg.ENV},
 	)
 
-	connectionStr, err := utils.GenerateConnectionString(
-		cfg.Postgres.Host,
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.DBName,
-		cfg.Postgres.SSLMode,
-		cfg.Postgres.Port,
-		cfg.Postgres.PoolSize,
-		cfg.Postgres.Timeout,
-	)
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing.Enabled, cfg.Tracing.ServiceName, cfg.Tracing.Endpoint, cfg.Tracing.Probability)
+	if err != nil {
+		log.Fatal("Ошибка инициализации трассировки", interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error("Ошибка остановки трассировки", interfaces.LogField{Key: "error", Value: err.Error()})
+		}
+	}()
+
+	connectionStr, err := utils.GenerateConnectionString(utils.ConnectionOptions{
+		Host:        cfg.Postgres.Host,
+		Port:        cfg.Postgres.Port,
+		User:        cfg.Postgres.User,
+		Password:    cfg.Postgres.Password,
+		DBName:      cfg.Postgres.DBName,
+		SSLMode:     cfg.Postgres.SSLMode,
+		SSLRootCert: cfg.Postgres.SSLRootCert,
+		SSLCert:     cfg.Postgres.SSLCert,
+		SSLKey:      cfg.Postgres.SSLKey,
+		PoolSize:    cfg.Postgres.PoolSize,
+		Timeout:     cfg.Postgres.Timeout,
+	})
 	if err != nil {
 		fmt.Printf("Ошибка инициализации строки подключения базы: %v\n", err)
 		os.Exit(1)
@@ -92,13 +102,13 @@ func main() {
 	if err != nil {
 		log.Fatal("Ошибка инициализации пула соединений", interfaces.LogField{Key: "error", Value: err})
 	}
-	defer pool.Close()
 	if err := pool.Ping(ctx); err != nil {
 		log.Fatal("Не удалось подключиться к базе данных", interfaces.LogField{Key: "error", Value: err})
 	}
 	log.Info("Пул соединений с PostgreSQL инициализирован")
+	go postgres.ReportPoolStats(ctx, pool)
 
-	repo, err := postgres.NewPostgresStorageWithPool(ctx, pool)
+	repo, err := postgres.NewPostgresStorageWithPool(ctx, pool, cfg.Postgres.QueryTimeout)
 	if err != nil {
 		log.Fatal("Ошибка инициализации хранилища",
 			interfaces.LogField{Key: "error", Value: err.Error()})
@@ -114,17 +124,36 @@ func main() {
 	}
 	log.Info("Соединение с PostgreSQL проверено")
 
-	cacheClient, err := cache.NewRedisCache(
-		ctx,
-		cfg.Redis.Host,
-		cfg.Redis.Port,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-	)
-	if err != nil {
-		log.Fatal("Ошибка инициализации кэша", interfaces.LogField{Key: "error", Value: err.Error()})
+	var cacheClient interfaces.CachePort
+	if cfg.Cache.Backend == "memory" {
+		// Без Redis кэш, лимиты запросов и блокировки действуют только в пределах процесса
+		cacheClient = cache.NewInMemoryCache(cache.DefaultSweepInterval)
+		log.Warn("Используется кэш в памяти процесса, режим предназначен только для локальной разработки")
+	} else {
+		cacheClient, err = cache.NewRedisCacheWithConfig(ctx, cache.RedisConfig{
+			Host:            cfg.Redis.Host,
+			Port:            cfg.Redis.Port,
+			Password:        cfg.Redis.Password,
+			DB:              cfg.Redis.DB,
+			PoolSize:        cfg.Redis.PoolSize,
+			MinIdleConns:    cfg.Redis.MinIdleConns,
+			ConnectTimeout:  cfg.Redis.ConnectTimeout,
+			ReadTimeout:     cfg.Redis.ReadTimeout,
+			WriteTimeout:    cfg.Redis.WriteTimeout,
+			PoolTimeout:     cfg.Redis.PoolTimeout,
+			IdleTimeout:     cfg.Redis.IdleTimeout,
+			IdleCheckFreq:   cfg.Redis.IdleCheckFreq,
+			MaxRetries:      cfg.Redis.MaxRetries,
+			MinRetryBackoff: cfg.Redis.MinRetryBackoff,
+			MaxRetryBackoff: cfg.Redis.MaxRetryBackoff,
+
+			CompressionAlgorithm: cfg.Redis.CompressionAlgorithm,
+			CompressionThreshold: cfg.Redis.CompressionThreshold,
+		})
+		if err != nil {
+			log.Fatal("Ошибка инициализации кэша", interfaces.LogField{Key: "error", Value: err.Error()})
+		}
 	}
-	defer cacheClient.Close()
 	log.Info("Кэш инициализирован")
 
 	if err := checkRedisConnection(testCtx, cacheClient); err != nil {
@@ -135,21 +164,67 @@ func main() {
 
 	log.Info(cfg.Kafka.GroupID)
 
-	messagingClient, err := messaging.NewKafkaMessaging(
-		cfg.Kafka.Brokers,
-		cfg.Kafka.GroupID,
-		cfg.Kafka.DeadLetterTopic,
-		log,
-	)
+	messagingClient, err := messaging.NewKafkaMessagingWithConfig(messaging.KafkaConfig{
+		Brokers:         cfg.Kafka.Brokers,
+		GroupID:         cfg.Kafka.GroupID,
+		DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+		MaxRetries:      cfg.Kafka.MaxRetries,
+		RetryBackoff:    cfg.Kafka.RetryBackoff,
+		Dedup:           cacheClient,
+		DedupTTL:        cfg.Kafka.DedupTTL,
+		DrainTimeout:    cfg.Kafka.DrainTimeout,
+		Consumer: interfaces.ConsumerConfig{
+			AutoCommit:         cfg.Kafka.AutoCommit,
+			AutoCommitInterval: cfg.Kafka.AutoCommitInterval,
+		},
+	}, log)
 	if err != nil {
 		log.Fatal("Ошибка инициализации системы обмена сообщениями", interfaces.LogField{Key: "error", Value: err.Error()})
 	}
-	defer messagingClient.Close()
 	log.Info("Система обмена сообщениями инициализирована")
 
+	// Producer создается без обращения к брокерам, поэтому их доступность проверяется явно
+	pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+	err = messagingClient.Ping(pingCtx)
+	pingCancel()
+	if err != nil {
+		log.Fatal("Ошибка подключения к Kafka",
+			interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	log.Info("Соединение с Kafka проверено")
+
 	txManager := tx.NewTxManager(pool)
 
-	productService := services.NewProductService(repo, cacheClient, messagingClient, log, txManager)
+	// Обращения сервиса к Redis и Kafka защищены автоматическими выключателями, временные ошибки повторяются
+	breakerSettings := resilience.Settings{
+		TripThreshold:   cfg.Resilience.TripThreshold,
+		OpenTimeout:     cfg.Resilience.CircuitTimeout,
+		HalfOpenMaxReqs: cfg.Resilience.HalfOpenMaxReqs,
+	}
+	retryConfig := resilience.RetryConfig{
+		MaxRetries: cfg.Resilience.MaxRetries,
+		WaitTime:   cfg.Resilience.RetryWaitTime,
+	}
+	resilientCache := resilience.NewCachePort(cacheClient, resilience.NewCircuitBreaker("redis", breakerSettings), retryConfig)
+	resilientMessaging := resilience.NewMessagingPort(messagingClient, resilience.NewCircuitBreaker("kafka", breakerSettings), retryConfig)
+
+	supplierClient := supplier.NewHTTPSupplier(cfg.Supplier.BaseURL, cfg.Supplier.Timeout)
+	marketplaceClient := marketplace.NewHTTPMarketplace(cfg.Marketplace.BaseURL, cfg.Marketplace.Timeout)
+
+	objectStorage, err := objectstorage.NewS3Storage(ctx, cfg.ObjectStorage.Endpoint, cfg.ObjectStorage.AccessKey,
+		cfg.ObjectStorage.SecretKey, cfg.ObjectStorage.Bucket, cfg.ObjectStorage.UseSSL, cfg.ObjectStorage.PublicURL)
+	if err != nil {
+		log.Fatal("Ошибка инициализации объектного хранилища", interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+
+	// Пока курсы задаются в конфигурации; внешний источник подключается реализацией RatesProviderPort
+	ratesProvider, err := currency.NewStaticRatesProvider(cfg.Currency.Base, cfg.Currency.Rates)
+	if err != nil {
+		log.Fatal("Ошибка инициализации курсов валют", interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	currencyConverter := currency.NewCachedConverter(ratesProvider, resilientCache, cfg.Currency.RateTTL)
+
+	productService := services.NewProductService(repo, resilientCache, resilientMessaging, log, txManager, supplierClient, marketplaceClient, objectStorage, currencyConverter)
 	log.Info("Сервис продуктов инициализирован")
 
 	privateKeyPath := cfg.Security.JWTPrivateKeyPath
@@ -181,13 +256,54 @@ func main() {
 
 	// Создание JWT-менеджера
 	jwtManager, err := security.NewJWTManager(privateKeyPEM, publicKeyPEM,
-		cfg.Security.JWTExpirationMin, "gomarket-platform")
+		cfg.Security.JWTExpirationMin, cfg.Security.JWTRefreshExpiration, "gomarket-platform")
 	if err != nil {
 		log.Fatal("Ошибка инициализации JWT менеджера",
 			interfaces.LogField{Key: "error", Value: err.Error()})
 	}
+	jwtManager.SetRolePermissions(cfg.Security.RolePermissions)
+	if cfg.Security.JWKSURL != "" {
+		jwtManager.EnableJWKS(cfg.Security.JWKSURL, cfg.Security.JWKSTimeout)
+		log.Info("Включена проверка токенов по JWKS",
+			interfaces.LogField{Key: "jwks_url", Value: cfg.Security.JWKSURL})
+	}
+
+	// Если настроен Keycloak, токены API выпускает и подписывает realm
+	var keycloakClient *security.KeycloakClient
+	if cfg.Keycloak.URL != "" {
+		keycloakClient, err = security.NewKeycloakClient(security.KeycloakConfig{
+			URL:          cfg.Keycloak.URL,
+			Realm:        cfg.Keycloak.Realm,
+			ClientID:     cfg.Keycloak.ClientID,
+			ClientSecret: cfg.Keycloak.ClientSecret,
+			Timeout:      cfg.Keycloak.Timeout,
+		})
+		if err != nil {
+			log.Fatal("Ошибка инициализации клиента Keycloak",
+				interfaces.LogField{Key: "error", Value: err.Error()})
+		}
+		keycloakClient.SetRolePermissions(cfg.Security.RolePermissions)
+		log.Info("Токены API проверяются Keycloak",
+			interfaces.LogField{Key: "keycloak_url", Value: cfg.Keycloak.URL},
+			interfaces.LogField{Key: "realm", Value: cfg.Keycloak.Realm})
+	}
+
+	authService := security.NewAuthService(postgres.NewUserStorage(pool))
 
-	router := api.SetupRouter(productService, log, cfg.Security.CORSAllowOrigins, jwtManager)
+	refreshTokens := security.NewRefreshTokenService(jwtManager, cacheClient)
+	blacklist := security.NewTokenBlacklist(cacheClient)
+
+	readiness := handlers.NewReadinessHandler(handlers.DefaultReadinessTimeout,
+		handlers.DependencyCheck{Name: "postgres", Check: pool.Ping},
+		handlers.DependencyCheck{Name: "redis", Check: cacheClient.Ping},
+		handlers.DependencyCheck{Name: "kafka", Check: messagingClient.Ping},
+	)
+
+	// Обработчики запросов, включая продолжающие работу после ответа по таймауту
+	var inflight sync.WaitGroup
+
+	settings := middleware.NewRuntimeSettings(rateLimitsFromConfig(cfg), cfg.Security.CORSAllowOrigins)
+	router := api.SetupRouter(productService, log, cacheClient, settings, int64(cfg.Server.BodyLimit)<<20, int64(cfg.Media.MaxUploadSize)<<20, jwtManager, keycloakClient, authService, refreshTokens, blacklist, readiness, &inflight)
 	log.Info("Маршрутизатор настроен")
 
 	server := &http.Server{
@@ -198,6 +314,13 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// SIGHUP перечитывает конфигурацию без разрыва соединений
+	config.ReloadOnSIGHUP(ctx, "", cfg, log, func(next *config.Config) {
+		log.SetLevel(logger.GetLoggerLevel(strings.ToLower(next.LogLevel)))
+		settings.SetRateLimits(rateLimitsFromConfig(next))
+		settings.SetCORSOrigins(next.Security.CORSAllowOrigins)
+	})
+
 	done := make(chan bool, 1)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -213,31 +336,28 @@ func main() {
 		<-quit
 		log.Info("Получен сигнал завершения, выполняется graceful shutdown...")
 
-		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
-		defer cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer shutdownCancel()
 
-		if err := server.Shutdown(ctx); err != nil {
-			log.Fatal("Ошибка при graceful shutdown", interfaces.LogField{Key: "error", Value: err.Error()})
+		// Сервер перестает принимать соединения и дожидается текущих запросов
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Error("Ошибка при graceful shutdown", interfaces.LogField{Key: "error", Value: err.Error()})
 		}
 
 		log.Info("HTTP сервер остановлен")
 
-		log.Info("Закрытие соединений с зависимостями...")
-
-		if err := messagingClient.Close(); err != nil {
-			log.Error("Ошибка при закрытии Kafka",
-				interfaces.LogField{Key: "error", Value: err.Error()})
-		}
+		// Фоновые задачи (перечитывание конфигурации, метрики пула) останавливаются до закрытия зависимостей
+		cancel()
 
-		if err := cacheClient.Close(); err != nil {
-			log.Error("Ошибка при закрытии Redis",
-				interfaces.LogField{Key: "error", Value: err.Error()})
-		}
+		log.Info("Закрытие соединений с зависимостями...")
 
-		if err := repo.Close(); err != nil {
-			log.Error("Ошибка при закрытии БД",
-				interfaces.LogField{Key: "error", Value: err.Error()})
-		}
+		// Обработчики, пережившие таймаут запроса, дорабатывают до дедлайна, затем зависимости
+		// закрываются от использующих к используемым
+		lifecycle.Shutdown(shutdownCtx, log, &inflight,
+			lifecycle.Dependency{Name: "kafka", Close: messagingClient.Close},
+			lifecycle.Dependency{Name: "redis", Close: cacheClient.Close},
+			lifecycle.Dependency{Name: "postgres", Close: repo.Close},
+		)
 
 		close(done)
 	}()
@@ -247,6 +367,15 @@ func main() {
 	log.Info("Сервер корректно завершил работу")
 }
 
+// rateLimitsFromConfig возвращает лимиты запросов к API из конфигурации
+func rateLimitsFromConfig(cfg *config.Config) middleware.TenantRateLimits {
+	return middleware.TenantRateLimits{
+		Default: cfg.RateLimit.Requests,
+		Window:  cfg.RateLimit.Window,
+		Tenants: cfg.RateLimit.Tenants,
+	}
+}
+
 // Проверка соединения с PostgreSQL
 func checkPostgresConnection(ctx context.Context, db interfaces.StoragePort) error {
 	_, err := db.BeginTx(ctx)
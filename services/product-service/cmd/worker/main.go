@@ -3,23 +3,35 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/athebyme/gomarket-platform/pkg/tx"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	pkgmodels "github.com/athebyme/gomarket-platform/pkg/models"
+	"github.com/athebyme/gomarket-platform/pkg/resilience"
 	"github.com/athebyme/gomarket-platform/product-service/config"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/currency"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/marketplace"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/objectstorage"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/supplier"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/tracing"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/handlers"
 	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/lifecycle"
 	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -43,6 +55,26 @@ var (
 		Name: "worker_active_goroutines",
 		Help: "Количество активных горутин-обработчиков",
 	})
+
+	activeConsumers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "worker_active_consumers",
+		Help: "Количество подключенных к группе consumer'ов по топикам",
+	}, []string{"topic"})
+
+	outboxRelayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "worker_outbox_relayed_total",
+		Help: "Количество событий outbox, обработанных relay",
+	}, []string{"status"})
+
+	dlqWindowDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "worker_dlq_window_depth",
+		Help: "Количество сообщений в DLQ за текущее окно мониторинга",
+	})
+
+	dlqAlerts = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "worker_dlq_alerts_total",
+		Help: "Количество срабатываний алерта по превышению порога DLQ",
+	})
 )
 
 func main() {
@@ -66,10 +98,28 @@ func main() {
 		interfaces.LogField{Key: "env", Value: cfg.ENV},
 	)
 
-	// Запускаем HTTP сервер для метрик если они включены
+	if len(os.Args) > 1 && os.Args[1] == replayDLQCommand {
+		runReplayDLQ(cfg, log, os.Args[2:])
+		return
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing.Enabled, cfg.Tracing.ServiceName+"-worker", cfg.Tracing.Endpoint, cfg.Tracing.Probability)
+	if err != nil {
+		log.Fatal("Ошибка инициализации трассировки", interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error("Ошибка остановки трассировки", interfaces.LogField{Key: "error", Value: err.Error()})
+		}
+	}()
+
+	// Запускаем HTTP сервер для метрик если они включены.
+	// /readiness регистрируется в mux позже, когда будут созданы клиенты зависимостей
+	mux := http.NewServeMux()
 	if cfg.Metrics.Enabled {
 		go func() {
-			mux := http.NewServeMux()
 			mux.Handle("/metrics", promhttp.Handler())
 			mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
@@ -88,16 +138,19 @@ func main() {
 	}
 
 	// Генерируем строку подключения к PostgreSQL
-	connectionStr, err := utils.GenerateConnectionString(
-		cfg.Postgres.Host,
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.DBName,
-		cfg.Postgres.SSLMode,
-		cfg.Postgres.Port,
-		cfg.Postgres.PoolSize,
-		cfg.Postgres.Timeout,
-	)
+	connectionStr, err := utils.GenerateConnectionString(utils.ConnectionOptions{
+		Host:        cfg.Postgres.Host,
+		Port:        cfg.Postgres.Port,
+		User:        cfg.Postgres.User,
+		Password:    cfg.Postgres.Password,
+		DBName:      cfg.Postgres.DBName,
+		SSLMode:     cfg.Postgres.SSLMode,
+		SSLRootCert: cfg.Postgres.SSLRootCert,
+		SSLCert:     cfg.Postgres.SSLCert,
+		SSLKey:      cfg.Postgres.SSLKey,
+		PoolSize:    cfg.Postgres.PoolSize,
+		Timeout:     cfg.Postgres.Timeout,
+	})
 	if err != nil {
 		log.Fatal("Ошибка генерации строки подключения к PostgreSQL",
 			interfaces.LogField{Key: "error", Value: err.Error()})
@@ -107,54 +160,123 @@ func main() {
 	if err != nil {
 		log.Fatal("Ошибка инициализации пула соединений", interfaces.LogField{Key: "error", Value: err})
 	}
-	defer pool.Close()
 	if err := pool.Ping(ctx); err != nil {
 		log.Fatal("Не удалось подключиться к базе данных", interfaces.LogField{Key: "error", Value: err})
 	}
 	log.Info("Пул соединений с PostgreSQL инициализирован")
+	go postgres.ReportPoolStats(ctx, pool)
 
-	repo, err := postgres.NewPostgresStorageWithPool(ctx, pool)
+	repo, err := postgres.NewPostgresStorageWithPool(ctx, pool, cfg.Postgres.QueryTimeout)
 	if err != nil {
 		log.Fatal("Ошибка инициализации хранилища",
 			interfaces.LogField{Key: "error", Value: err.Error()})
 	}
 	log.Info("Хранилище инициализировано")
 
-	cacheClient, err := cache.NewRedisCache(
-		ctx,
-		cfg.Redis.Host,
-		cfg.Redis.Port,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
-	)
+	cacheClient, err := cache.NewRedisCacheWithConfig(ctx, cache.RedisConfig{
+		Host:            cfg.Redis.Host,
+		Port:            cfg.Redis.Port,
+		Password:        cfg.Redis.Password,
+		DB:              cfg.Redis.DB,
+		PoolSize:        cfg.Redis.PoolSize,
+		MinIdleConns:    cfg.Redis.MinIdleConns,
+		ConnectTimeout:  cfg.Redis.ConnectTimeout,
+		ReadTimeout:     cfg.Redis.ReadTimeout,
+		WriteTimeout:    cfg.Redis.WriteTimeout,
+		PoolTimeout:     cfg.Redis.PoolTimeout,
+		IdleTimeout:     cfg.Redis.IdleTimeout,
+		IdleCheckFreq:   cfg.Redis.IdleCheckFreq,
+		MaxRetries:      cfg.Redis.MaxRetries,
+		MinRetryBackoff: cfg.Redis.MinRetryBackoff,
+		MaxRetryBackoff: cfg.Redis.MaxRetryBackoff,
+
+		CompressionAlgorithm: cfg.Redis.CompressionAlgorithm,
+		CompressionThreshold: cfg.Redis.CompressionThreshold,
+	})
 	if err != nil {
 		log.Fatal("Ошибка инициализации кэша",
 			interfaces.LogField{Key: "error", Value: err.Error()})
 	}
-	defer cacheClient.Close()
 	log.Info("Кэш инициализирован")
 
 	// Инициализируем систему обмена сообщениями
-	messagingClient, err := messaging.NewKafkaMessaging(
-		cfg.Kafka.Brokers,
-		cfg.Kafka.GroupID,
-		cfg.Kafka.DeadLetterTopic,
-		log,
-	)
+	messagingClient, err := messaging.NewKafkaMessagingWithConfig(messaging.KafkaConfig{
+		Brokers:         cfg.Kafka.Brokers,
+		GroupID:         cfg.Kafka.GroupID,
+		DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+		MaxRetries:      cfg.Kafka.MaxRetries,
+		RetryBackoff:    cfg.Kafka.RetryBackoff,
+		Dedup:           cacheClient,
+		DedupTTL:        cfg.Kafka.DedupTTL,
+		DrainTimeout:    cfg.Kafka.DrainTimeout,
+		Consumer: interfaces.ConsumerConfig{
+			AutoCommit:         cfg.Kafka.AutoCommit,
+			AutoCommitInterval: cfg.Kafka.AutoCommitInterval,
+		},
+	}, log)
 	if err != nil {
 		log.Fatal("Ошибка инициализации системы обмена сообщениями",
 			interfaces.LogField{Key: "error", Value: err.Error()})
 	}
-	defer messagingClient.Close()
 	log.Info("Система обмена сообщениями инициализирована")
 
+	// Producer создается без обращения к брокерам, поэтому их доступность проверяется явно
+	pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+	err = messagingClient.Ping(pingCtx)
+	pingCancel()
+	if err != nil {
+		log.Fatal("Ошибка подключения к Kafka",
+			interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	log.Info("Соединение с Kafka проверено")
+
+	mux.Handle("/readiness", handlers.NewReadinessHandler(handlers.DefaultReadinessTimeout,
+		handlers.DependencyCheck{Name: "postgres", Check: pool.Ping},
+		handlers.DependencyCheck{Name: "redis", Check: cacheClient.Ping},
+		handlers.DependencyCheck{Name: "kafka", Check: messagingClient.Ping},
+	))
+
 	txManager := tx.NewTxManager(pool)
 	log.Info("Менеджер транзакций инициализирован")
 
+	// Обращения сервиса к Redis и Kafka защищены автоматическими выключателями, временные ошибки повторяются
+	breakerSettings := resilience.Settings{
+		TripThreshold:   cfg.Resilience.TripThreshold,
+		OpenTimeout:     cfg.Resilience.CircuitTimeout,
+		HalfOpenMaxReqs: cfg.Resilience.HalfOpenMaxReqs,
+	}
+	retryConfig := resilience.RetryConfig{
+		MaxRetries: cfg.Resilience.MaxRetries,
+		WaitTime:   cfg.Resilience.RetryWaitTime,
+	}
+	resilientCache := resilience.NewCachePort(cacheClient, resilience.NewCircuitBreaker("redis", breakerSettings), retryConfig)
+	resilientMessaging := resilience.NewMessagingPort(messagingClient, resilience.NewCircuitBreaker("kafka", breakerSettings), retryConfig)
+
+	supplierClient := supplier.NewHTTPSupplier(cfg.Supplier.BaseURL, cfg.Supplier.Timeout)
+	marketplaceClient := marketplace.NewHTTPMarketplace(cfg.Marketplace.BaseURL, cfg.Marketplace.Timeout)
+
+	objectStorage, err := objectstorage.NewS3Storage(ctx, cfg.ObjectStorage.Endpoint, cfg.ObjectStorage.AccessKey,
+		cfg.ObjectStorage.SecretKey, cfg.ObjectStorage.Bucket, cfg.ObjectStorage.UseSSL, cfg.ObjectStorage.PublicURL)
+	if err != nil {
+		log.Fatal("Ошибка инициализации объектного хранилища", interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+
 	// Инициализируем сервис продуктов
-	productService := services.NewProductService(repo, cacheClient, messagingClient, log, txManager)
+	// Пока курсы задаются в конфигурации; внешний источник подключается реализацией RatesProviderPort
+	ratesProvider, err := currency.NewStaticRatesProvider(cfg.Currency.Base, cfg.Currency.Rates)
+	if err != nil {
+		log.Fatal("Ошибка инициализации курсов валют", interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	currencyConverter := currency.NewCachedConverter(ratesProvider, resilientCache, cfg.Currency.RateTTL)
+
+	productService := services.NewProductService(repo, resilientCache, resilientMessaging, log, txManager, supplierClient, marketplaceClient, objectStorage, currencyConverter)
 	log.Info("Сервис продуктов инициализирован")
 
+	// SIGHUP перечитывает конфигурацию; воркеру из настроек, применяемых на лету, нужен только уровень логирования
+	config.ReloadOnSIGHUP(ctx, "", cfg, log, func(next *config.Config) {
+		log.SetLevel(logger.GetLoggerLevel(strings.ToLower(next.LogLevel)))
+	})
+
 	// Каналы для сигналов и завершения
 	done := make(chan bool, 1)
 	quit := make(chan os.Signal, 1)
@@ -163,15 +285,34 @@ func main() {
 	var wg sync.WaitGroup
 
 	// Подписываемся на команды и события
-	subscribeToProductCommands(ctx, messagingClient, productService, log, &wg)
-	subscribeToProductEvents(ctx, messagingClient, productService, log, &wg)
+	subscribeToProducts(ctx, messagingClient, productService,
+		cfg.Worker.CommandConsumers, cfg.Worker.EventConsumers, log, &wg)
+
+	outboxRelay := services.NewOutboxRelay(repo, resilientMessaging, log, txManager, cfg.Outbox.BatchSize)
+	runOutboxRelay(ctx, outboxRelay, cfg.Outbox.PollInterval, log, &wg)
+	runReservationSweeper(ctx, productService, cfg.Inventory.ReservationSweepInterval, log, &wg)
+
+	if cfg.Kafka.DeadLetterTopic != "" && cfg.Kafka.DLQAlertThreshold > 0 {
+		monitor := messaging.NewDLQMonitor(cfg.Kafka.DLQAlertThreshold, cfg.Kafka.DLQAlertWindow)
+		subscribeToDeadLetters(ctx, messagingClient, monitor, cfg.Kafka.DeadLetterTopic, cfg.Kafka.AlertTopic, log, &wg)
+	}
 
 	// Обработка сигналов завершения
 	go func() {
 		<-quit
 		log.Info("Получен сигнал завершения, выполняется graceful shutdown...")
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer shutdownCancel()
+
+		// Consumer'ы отписываются и дорабатывают текущие сообщения, периодические задачи останавливаются;
+		// только после этого зависимости закрываются от использующих к используемым
 		cancel()
-		wg.Wait()
+		lifecycle.Shutdown(shutdownCtx, log, &wg,
+			lifecycle.Dependency{Name: "kafka", Close: messagingClient.Close},
+			lifecycle.Dependency{Name: "redis", Close: cacheClient.Close},
+			lifecycle.Dependency{Name: "postgres", Close: repo.Close},
+		)
 		close(done)
 	}()
 
@@ -180,12 +321,57 @@ func main() {
 	log.Info("Воркер корректно завершил работу")
 }
 
-// Подписка на команды продуктов
-func subscribeToProductCommands(ctx context.Context, messagingClient interfaces.MessagingPort,
-	productService services.ProductServiceInterface,
+// consumerPool группа consumer'ов, подписанных на одни и те же топики
+type consumerPool struct {
+	topics []string
+	count  int
+}
+
+// productConsumerPools распределяет consumer'ы по топикам команд и событий.
+// Общие consumer'ы читают оба топика, недостающие до требуемого числа читают только свой топик,
+// так что каждый топик читают ровно commandConsumers и eventConsumers consumer'ов
+func productConsumerPools(commandConsumers, eventConsumers int) []consumerPool {
+	commandConsumers = max(commandConsumers, 1)
+	eventConsumers = max(eventConsumers, 1)
+	shared := min(commandConsumers, eventConsumers)
+
+	pools := []consumerPool{{topics: []string{"product-commands", "product-events"}, count: shared}}
+	if extra := commandConsumers - shared; extra > 0 {
+		pools = append(pools, consumerPool{topics: []string{"product-commands"}, count: extra})
+	}
+	if extra := eventConsumers - shared; extra > 0 {
+		pools = append(pools, consumerPool{topics: []string{"product-events"}, count: extra})
+	}
+	return pools
+}
+
+// Подписка на команды и события продуктов, сообщения разбираются по топику
+func subscribeToProducts(ctx context.Context, messagingClient interfaces.MessagingPort,
+	productService services.ProductServiceInterface, commandConsumers, eventConsumers int,
 	logger interfaces.LoggerPort, wg *sync.WaitGroup) {
 
-	commandHandler := func(ctx context.Context, msg *interfaces.Message) error {
+	handlers := map[string]interfaces.MessageHandler{
+		"product-commands": productCommandHandler(productService, logger),
+		"product-events":   productEventHandler(productService, logger),
+	}
+
+	dispatch := func(ctx context.Context, msg *interfaces.Message) error {
+		handler, ok := handlers[msg.Topic]
+		if !ok {
+			return fmt.Errorf("нет обработчика для топика %s", msg.Topic)
+		}
+		return handler(ctx, msg)
+	}
+
+	for _, pool := range productConsumerPools(commandConsumers, eventConsumers) {
+		startConsumers(ctx, messagingClient, pool.topics, dispatch,
+			interfaces.SubscriptionConfig{}, pool.count, logger, wg)
+	}
+}
+
+// Обработчик команд продуктов
+func productCommandHandler(productService services.ProductServiceInterface, logger interfaces.LoggerPort) interfaces.MessageHandler {
+	return func(ctx context.Context, msg *interfaces.Message) error {
 		startTime := time.Now()
 		activeWorkers.Inc()
 		defer activeWorkers.Dec()
@@ -210,7 +396,7 @@ func subscribeToProductCommands(ctx context.Context, messagingClient interfaces.
 		}
 
 		// Добавляем tenant_id в контекст
-		cmdCtx := context.WithValue(ctx, "tenant_id", command.TenantID)
+		cmdCtx := contextkeys.WithTenant(ctx, command.TenantID)
 		var err error
 
 		// Обрабатываем команду в зависимости от типа
@@ -221,18 +407,36 @@ func subscribeToProductCommands(ctx context.Context, messagingClient interfaces.
 				err = fmt.Errorf("неверный формат marketplace_id")
 				break
 			}
-			err = productService.SyncProductToMarketplace(cmdCtx, command.ProductID, int(marketplaceID), command.TenantID)
+			var status *pkgmodels.MarketplaceProduct
+			status, err = productService.PushProductToMarketplace(cmdCtx, command.ProductID, int(marketplaceID), command.TenantID)
+			if err == nil {
+				logger.InfoWithContext(cmdCtx, "Продукт отправлен в маркетплейс",
+					interfaces.LogField{Key: "product_id", Value: command.ProductID},
+					interfaces.LogField{Key: "marketplace_id", Value: int(marketplaceID)},
+					interfaces.LogField{Key: "status", Value: status.Status})
+			}
 
 		case "sync_supplier":
-			supplierID, ok := command.Payload["supplier_id"].(float64)
-			if !ok {
+			supplierID, ok := command.Payload["supplier_id"].(string)
+			if !ok || supplierID == "" {
 				err = fmt.Errorf("неверный формат supplier_id")
 				break
 			}
-			_, err = productService.SyncProductsFromSupplier(cmdCtx, int(supplierID), command.TenantID)
+			var synced int
+			synced, err = productService.SyncProductsFromSupplier(cmdCtx, supplierID, command.TenantID)
+			if errors.Is(err, utils.ErrSyncInProgress) {
+				// Ту же синхронизацию уже выполняет другая реплика, повтор не нужен
+				logger.InfoWithContext(cmdCtx, "Синхронизация поставщика уже выполняется, команда пропущена",
+					interfaces.LogField{Key: "supplier_id", Value: supplierID})
+				err = nil
+			} else if err == nil {
+				logger.InfoWithContext(cmdCtx, "Товары поставщика синхронизированы",
+					interfaces.LogField{Key: "supplier_id", Value: supplierID},
+					interfaces.LogField{Key: "synced", Value: synced})
+			}
 
 		case "invalidate_cache":
-			cacheKey := fmt.Sprintf("product:%s", command.ProductID)
+			cacheKey := services.ProductCachePattern(command.ProductID)
 			err = productService.InvalidateCache(cmdCtx, cacheKey, command.TenantID)
 
 		default:
@@ -260,33 +464,14 @@ func subscribeToProductCommands(ctx context.Context, messagingClient interfaces.
 
 		return nil
 	}
-
-	wg.Add(1)
-
-	go func() {
-		defer wg.Done()
-
-		unsubscribe, err := messagingClient.Subscribe(ctx, "product-commands", commandHandler)
-		if err != nil {
-			logger.Error("Ошибка подписки на команды продуктов",
-				interfaces.LogField{Key: "error", Value: err.Error()})
-			return
-		}
-		defer unsubscribe()
-
-		logger.Info("Подписка на команды продуктов установлена")
-
-		<-ctx.Done()
-		logger.Info("Отмена подписки на команды продуктов")
-	}()
 }
 
-// Подписка на события продуктов
-func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.MessagingPort,
-	productService services.ProductServiceInterface,
-	logger interfaces.LoggerPort, wg *sync.WaitGroup) {
+// Обработчик событий продуктов
+func productEventHandler(productService services.ProductServiceInterface, logger interfaces.LoggerPort) interfaces.MessageHandler {
+	// Последние обработанные номера событий по продуктам для отбрасывания устаревших доставок
+	sequences := messaging.NewSequenceTracker(messaging.DefaultSequenceTrackerSize)
 
-	eventHandler := func(ctx context.Context, msg *interfaces.Message) error {
+	return func(ctx context.Context, msg *interfaces.Message) error {
 		startTime := time.Now()
 		activeWorkers.Inc()
 		defer activeWorkers.Dec()
@@ -296,14 +481,8 @@ func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.Me
 			interfaces.LogField{Key: "topic", Value: msg.Topic},
 		)
 
-		var event struct {
-			EventType  string                 `json:"event_type"`
-			TenantID   string                 `json:"tenant_id"`
-			SupplierID string                 `json:"supplier_id,omitempty"`
-			Payload    map[string]interface{} `json:"payload,omitempty"`
-		}
-
-		if err := json.Unmarshal(msg.Value, &event); err != nil {
+		event, err := messaging.DecodeEvent(msg.Value)
+		if err != nil {
 			logger.ErrorWithContext(ctx, "Ошибка декодирования события",
 				interfaces.LogField{Key: "error", Value: err.Error()},
 				interfaces.LogField{Key: "message_id", Value: msg.ID},
@@ -312,19 +491,42 @@ func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.Me
 			return err
 		}
 
-		if event.Payload == nil {
-			logger.ErrorWithContext(ctx, "Поле payload отсутствует в событии" /* ... */)
-			return fmt.Errorf("missing payload in event")
+		switch event.SchemaVersion {
+		case messaging.LegacyEventSchemaVersion, messaging.EventSchemaVersion:
+		default:
+			// Событие новее воркера уходит в DLQ и может быть возвращено командой replay-dlq после обновления
+			logger.ErrorWithContext(ctx, "Неподдерживаемая версия схемы события",
+				interfaces.LogField{Key: "schema_version", Value: event.SchemaVersion},
+				interfaces.LogField{Key: "event_type", Value: event.EventType},
+				interfaces.LogField{Key: "message_id", Value: msg.ID},
+			)
+			messagesProcessed.WithLabelValues(msg.Topic, "unsupported").Inc()
+			return fmt.Errorf("unsupported event schema version %d", event.SchemaVersion)
+		}
+
+		// Общая часть payload проверяется до выбора обработчика, поля конкретного события - в нем самом.
+		// Некорректное событие не обрабатывается частично, а после повторов попадает в DLQ
+		var product messaging.ProductEventPayload
+		if err := decodeEventPayload(ctx, event, &product, msg, logger); err != nil {
+			return err
 		}
+		productID := product.ProductID
+		sequenceKey := event.TenantID + ":" + productID
 
-		productID, ok := event.Payload["product_id"].(string)
-		if !ok || productID == "" {
-			logger.ErrorWithContext(ctx, "Не найден или некорректный product_id в payload" /* ... */)
-			return fmt.Errorf("missing or invalid product_id in payload")
+		// Событие старше уже обработанного для этого продукта пропускаем. Номер запоминается только
+		// после успешной обработки, поэтому повтор события с некорректным payload не считается устаревшим
+		if sequences.Stale(sequenceKey, product.Sequence) {
+			logger.WarnWithContext(ctx, "Пропущено устаревшее событие продукта",
+				interfaces.LogField{Key: "event_type", Value: event.EventType},
+				interfaces.LogField{Key: "product_id", Value: productID},
+				interfaces.LogField{Key: "sequence", Value: product.Sequence},
+			)
+			messagesProcessed.WithLabelValues(msg.Topic, "stale").Inc()
+			return nil
 		}
 
 		// Добавляем tenant_id в контекст
-		evtCtx := context.WithValue(ctx, "tenant_id", event.TenantID)
+		evtCtx := contextkeys.WithTenant(ctx, event.TenantID)
 
 		// Обработка события в зависимости от типа
 		switch event.EventType {
@@ -341,7 +543,7 @@ func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.Me
 			)
 
 			// Инвалидация кэша для обновленного продукта
-			cacheKey := fmt.Sprintf("product:%s", productID)
+			cacheKey := services.ProductCachePattern(productID)
 			_ = productService.InvalidateCache(evtCtx, cacheKey, event.TenantID)
 
 		case messaging.ProductDeletedEvent:
@@ -351,33 +553,37 @@ func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.Me
 			)
 
 			// Инвалидация кэша для удаленного продукта
-			cacheKey := fmt.Sprintf("product:%s", productID)
+			cacheKey := services.ProductCachePattern(productID)
 			_ = productService.InvalidateCache(evtCtx, cacheKey, event.TenantID)
 
-		case "product_price_updated":
+		case messaging.ProductPriceUpdatedEvent:
 			// Обработка события обновления цены
-			productID, _ := event.Payload["product_id"].(string)
-			price, _ := event.Payload["price"].(float64)
+			var payload messaging.ProductPriceUpdatedPayload
+			if err := decodeEventPayload(ctx, event, &payload, msg, logger); err != nil {
+				return err
+			}
 
 			logger.InfoWithContext(evtCtx, "Обработка события обновления цены",
 				interfaces.LogField{Key: "product_id", Value: productID},
-				interfaces.LogField{Key: "price", Value: price},
+				interfaces.LogField{Key: "price", Value: payload.Price},
 			)
 
-			cacheKey := fmt.Sprintf("product:%s", productID)
+			cacheKey := services.ProductCachePattern(productID)
 			_ = productService.InvalidateCache(evtCtx, cacheKey, event.TenantID)
 
-		case "product_inventory_updated":
+		case messaging.ProductInventoryUpdatedEvent:
 			// Обработка события обновления инвентаря
-			productID, _ := event.Payload["product_id"].(string)
-			quantity, _ := event.Payload["quantity"].(float64)
+			var payload messaging.ProductInventoryUpdatedPayload
+			if err := decodeEventPayload(ctx, event, &payload, msg, logger); err != nil {
+				return err
+			}
 
 			logger.InfoWithContext(evtCtx, "Обработка события обновления инвентаря",
 				interfaces.LogField{Key: "product_id", Value: productID},
-				interfaces.LogField{Key: "quantity", Value: quantity},
+				interfaces.LogField{Key: "quantity", Value: payload.Quantity},
 			)
 
-			cacheKey := fmt.Sprintf("product:%s", productID)
+			cacheKey := services.ProductCachePattern(productID)
 			_ = productService.InvalidateCache(evtCtx, cacheKey, event.TenantID)
 
 		default:
@@ -388,6 +594,8 @@ func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.Me
 			return nil
 		}
 
+		sequences.Accept(sequenceKey, product.Sequence)
+
 		// Метрики успешной обработки
 		duration := time.Since(startTime).Seconds()
 		messageProcessingDuration.WithLabelValues(msg.Topic).Observe(duration)
@@ -401,23 +609,186 @@ func subscribeToProductEvents(ctx context.Context, messagingClient interfaces.Me
 
 		return nil
 	}
+}
+
+// decodeEventPayload разбирает payload события в v, логируя и учитывая в метриках некорректные события
+func decodeEventPayload(ctx context.Context, event *messaging.EventEnvelope, v interface{},
+	msg *interfaces.Message, logger interfaces.LoggerPort) error {
+
+	if err := event.DecodePayload(v); err != nil {
+		logger.ErrorWithContext(ctx, "Некорректный payload события",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "event_type", Value: event.EventType},
+			interfaces.LogField{Key: "message_id", Value: msg.ID},
+		)
+		messagesProcessed.WithLabelValues(msg.Topic, "error").Inc()
+		return err
+	}
+	return nil
+}
+
+// startConsumers запускает count consumer'ов топиков в одной группе, чтобы Kafka распределила
+// между ними партиции. Consumer'ы сверх числа партиций простаивают.
+func startConsumers(ctx context.Context, messagingClient interfaces.MessagingPort,
+	topics []string, handler interfaces.MessageHandler, config interfaces.SubscriptionConfig, count int,
+	logger interfaces.LoggerPort, wg *sync.WaitGroup) {
+
+	if count < 1 {
+		count = 1
+	}
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+
+		go func(index int) {
+			defer wg.Done()
+
+			unsubscribe, err := messagingClient.SubscribeMulti(ctx, topics, handler, config)
+			if err != nil {
+				logger.Error("Ошибка подписки на топик",
+					interfaces.LogField{Key: "topics", Value: topics},
+					interfaces.LogField{Key: "consumer", Value: index},
+					interfaces.LogField{Key: "error", Value: err.Error()})
+				return
+			}
+			defer unsubscribe()
+
+			for _, topic := range topics {
+				activeConsumers.WithLabelValues(topic).Inc()
+				defer activeConsumers.WithLabelValues(topic).Dec()
+			}
+
+			logger.Info("Подписка на топик установлена",
+				interfaces.LogField{Key: "topics", Value: topics},
+				interfaces.LogField{Key: "consumer", Value: index})
+
+			<-ctx.Done()
+			logger.Info("Отмена подписки на топик",
+				interfaces.LogField{Key: "topics", Value: topics},
+				interfaces.LogField{Key: "consumer", Value: index})
+		}(i)
+	}
+}
+
+// Мониторинг Dead Letter Queue: при превышении порога за окно поднимается алерт
+func subscribeToDeadLetters(ctx context.Context, messagingClient interfaces.MessagingPort,
+	monitor *messaging.DLQMonitor, dlqTopic, alertTopic string,
+	logger interfaces.LoggerPort, wg *sync.WaitGroup) {
+
+	dlqHandler := func(ctx context.Context, msg *interfaces.Message) error {
+		fired := monitor.Record()
+		depth := monitor.Depth()
+		dlqWindowDepth.Set(float64(depth))
+		messagesProcessed.WithLabelValues(msg.Topic, "success").Inc()
+
+		if !fired {
+			return nil
+		}
+
+		dlqAlerts.Inc()
+		logger.ErrorWithContext(ctx, "ВНИМАНИЕ: превышен порог сообщений в DLQ",
+			interfaces.LogField{Key: "topic", Value: dlqTopic},
+			interfaces.LogField{Key: "depth", Value: depth},
+			interfaces.LogField{Key: "message_id", Value: msg.ID},
+		)
+
+		if alertTopic == "" {
+			return nil
+		}
+
+		alertData, err := messaging.EncodeEvent(messaging.DLQThresholdExceededEvent, "", time.Now(),
+			&messaging.DLQAlertPayload{Topic: dlqTopic, Depth: depth})
+		if err != nil {
+			return err
+		}
+		if err := messagingClient.Publish(ctx, alertTopic, alertData); err != nil {
+			logger.ErrorWithContext(ctx, "Ошибка публикации алерта DLQ",
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+		}
+
+		return nil
+	}
+
+	// Сбой обработки сообщения DLQ не должен порождать новое сообщение в той же DLQ
+	startConsumers(ctx, messagingClient, []string{dlqTopic}, dlqHandler,
+		interfaces.SubscriptionConfig{OnFailure: interfaces.FailureDrop}, 1, logger, wg)
+}
+
+// Периодическая публикация событий из outbox
+func runOutboxRelay(ctx context.Context, relay *services.OutboxRelay, interval time.Duration,
+	logger interfaces.LoggerPort, wg *sync.WaitGroup) {
+
+	if interval <= 0 {
+		interval = time.Second
+	}
 
 	wg.Add(1)
 
 	go func() {
 		defer wg.Done()
 
-		unsubscribe, err := messagingClient.Subscribe(ctx, "product-events", eventHandler)
-		if err != nil {
-			logger.Error("Ошибка подписки на события продуктов",
-				interfaces.LogField{Key: "error", Value: err.Error()})
-			return
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.Info("Outbox relay запущен",
+			interfaces.LogField{Key: "interval", Value: interval.String()})
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Остановка outbox relay")
+				return
+			case <-ticker.C:
+				published, failed, err := relay.RelayOnce(ctx)
+				if err != nil {
+					logger.Error("Ошибка outbox relay",
+						interfaces.LogField{Key: "error", Value: err.Error()})
+					continue
+				}
+				outboxRelayed.WithLabelValues("published").Add(float64(published))
+				outboxRelayed.WithLabelValues("failed").Add(float64(failed))
+			}
 		}
-		defer unsubscribe()
+	}()
+}
 
-		logger.Info("Подписка на события продуктов установлена")
+// Периодическое удаление истекших резервов остатков
+func runReservationSweeper(ctx context.Context, productService services.ProductServiceInterface, interval time.Duration,
+	logger interfaces.LoggerPort, wg *sync.WaitGroup) {
 
-		<-ctx.Done()
-		logger.Info("Отмена подписки на события продуктов")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.Info("Очистка истекших резервов запущена",
+			interfaces.LogField{Key: "interval", Value: interval.String()})
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Остановка очистки истекших резервов")
+				return
+			case <-ticker.C:
+				expired, err := productService.ExpireReservations(ctx)
+				if err != nil {
+					logger.Error("Ошибка очистки истекших резервов",
+						interfaces.LogField{Key: "error", Value: err.Error()})
+					continue
+				}
+				if expired > 0 {
+					logger.Info("Истекшие резервы удалены",
+						interfaces.LogField{Key: "count", Value: expired})
+				}
+			}
+		}
 	}()
 }
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// invalidationService сервис продуктов, запоминающий инвалидации кэша
+type invalidationService struct {
+	services.ProductServiceInterface
+
+	mu          sync.Mutex
+	invalidated []string
+}
+
+func (s *invalidationService) InvalidateCache(ctx context.Context, key string, tenantID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidated = append(s.invalidated, key)
+	return nil
+}
+
+func (s *invalidationService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.invalidated)
+}
+
+func TestProductEventHandlerSequence(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	const topic = "product-events"
+	ctx := context.Background()
+	service := &invalidationService{}
+	bus := messaging.NewInMemoryMessaging()
+	if _, err := bus.Subscribe(ctx, topic, productEventHandler(service, log), interfaces.SubscriptionConfig{MaxRetries: 3}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	publish := func(t *testing.T, eventType messaging.KafkaEvent, payload interface{}) {
+		t.Helper()
+		data, err := messaging.EncodeEvent(eventType, "tenant-1", time.Now(), payload)
+		if err != nil {
+			t.Fatalf("EncodeEvent: %v", err)
+		}
+		if err := bus.Publish(ctx, topic, data); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	price := func(sequence int64, value float64) *messaging.ProductPriceUpdatedPayload {
+		return &messaging.ProductPriceUpdatedPayload{
+			ProductEventPayload: messaging.ProductEventPayload{ProductID: "product-1", Sequence: sequence},
+			Price:               value,
+		}
+	}
+
+	t.Run("bad payload is retried and dead-lettered", func(t *testing.T) {
+		publish(t, messaging.ProductPriceUpdatedEvent, price(5, -1))
+
+		deadLetters := bus.DeadLetters()
+		if len(deadLetters) != 1 || deadLetters[0].Attempts != 3 {
+			t.Fatalf("dead letters = %+v, want one message after 3 attempts", deadLetters)
+		}
+		if service.count() != 0 {
+			t.Fatalf("cache invalidated %d times for a bad event", service.count())
+		}
+	})
+
+	t.Run("sequence of a failed event is not consumed", func(t *testing.T) {
+		publish(t, messaging.ProductPriceUpdatedEvent, price(5, 120))
+
+		if len(bus.DeadLetters()) != 1 || service.count() != 1 {
+			t.Fatalf("dead letters = %d, invalidations = %d, want the corrected event processed",
+				len(bus.DeadLetters()), service.count())
+		}
+	})
+
+	t.Run("out-of-order event is skipped", func(t *testing.T) {
+		publish(t, messaging.ProductUpdatedEvent, &messaging.ProductEventPayload{ProductID: "product-1", Sequence: 4})
+		publish(t, messaging.ProductUpdatedEvent, &messaging.ProductEventPayload{ProductID: "product-1", Sequence: 5})
+
+		if len(bus.DeadLetters()) != 1 || service.count() != 1 {
+			t.Fatalf("dead letters = %d, invalidations = %d, want stale events skipped without errors",
+				len(bus.DeadLetters()), service.count())
+		}
+	})
+
+	t.Run("newer event is processed", func(t *testing.T) {
+		publish(t, messaging.ProductUpdatedEvent, &messaging.ProductEventPayload{ProductID: "product-1", Sequence: 6})
+		// Номера ведутся отдельно для каждого продукта
+		publish(t, messaging.ProductUpdatedEvent, &messaging.ProductEventPayload{ProductID: "product-2", Sequence: 1})
+
+		if service.count() != 3 {
+			t.Fatalf("invalidations = %d, want 3", service.count())
+		}
+	})
+}
+
+func TestProductConsumerPools(t *testing.T) {
+	both := []string{"product-commands", "product-events"}
+
+	tests := []struct {
+		name             string
+		commands, events int
+		want             []consumerPool
+	}{
+		{name: "equal", commands: 2, events: 2, want: []consumerPool{{topics: both, count: 2}}},
+		{name: "more events", commands: 1, events: 3, want: []consumerPool{
+			{topics: both, count: 1},
+			{topics: []string{"product-events"}, count: 2},
+		}},
+		{name: "more commands", commands: 4, events: 1, want: []consumerPool{
+			{topics: both, count: 1},
+			{topics: []string{"product-commands"}, count: 3},
+		}},
+		{name: "unset", commands: 0, events: 0, want: []consumerPool{{topics: both, count: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := productConsumerPools(tt.commands, tt.events)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("pools = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// countingRepository хранилище, считающее чтения продуктов поставщика
+type countingRepository struct {
+	postgres.ProductStoragePort
+	loads int
+}
+
+func (r *countingRepository) GetProductBySupplier(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error) {
+	r.loads++
+	return &models.Product{ID: productID, SupplierID: supplierID, TenantID: tenantID, Version: 1}, nil
+}
+
+func TestProductUpdatedEventClearsSupplierCache(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+	repo := &countingRepository{}
+	service := services.NewProductService(repo, memoryCache, nil, log, nil, nil, nil, nil, nil)
+
+	const topic = "product-events"
+	ctx := context.Background()
+	bus := messaging.NewInMemoryMessaging()
+	if _, err := bus.Subscribe(ctx, topic, productEventHandler(service, log), interfaces.SubscriptionConfig{MaxRetries: 1}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// Записи в кэше создаются GetProduct под ключами поставщиков
+	for _, productID := range []string{"product-1", "product-2"} {
+		for i := 0; i < 2; i++ {
+			if _, err := service.GetProduct(ctx, productID, "supplier-1", "tenant-1"); err != nil {
+				t.Fatalf("GetProduct: %v", err)
+			}
+		}
+	}
+	if repo.loads != 2 {
+		t.Fatalf("loads = %d, want each product read once and then served from cache", repo.loads)
+	}
+
+	// Событие не знает поставщика продукта
+	data, err := messaging.EncodeEvent(messaging.ProductUpdatedEvent, "tenant-1", time.Now(),
+		&messaging.ProductEventPayload{ProductID: "product-1", Sequence: 1})
+	if err != nil {
+		t.Fatalf("EncodeEvent: %v", err)
+	}
+	if err := bus.Publish(ctx, topic, data); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if _, err := memoryCache.GetWithTenant(ctx, services.ProductCacheKey("supplier-1", "product-1"), "tenant-1"); err == nil {
+		t.Fatal("supplier-scoped entry of the updated product is still cached")
+	}
+	if _, err := memoryCache.GetWithTenant(ctx, services.ProductCacheKey("supplier-1", "product-2"), "tenant-1"); err != nil {
+		t.Fatalf("entry of another product was cleared: %v", err)
+	}
+
+	if _, err := service.GetProduct(ctx, "product-1", "supplier-1", "tenant-1"); err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if repo.loads != 3 {
+		t.Fatalf("loads = %d, want the updated product read from storage again", repo.loads)
+	}
+}
+
+// subscriptionRecorder система обмена сообщениями, запоминающая подписки и отписки
+type subscriptionRecorder struct {
+	interfaces.MessagingPort
+
+	mu           sync.Mutex
+	subscribed   [][]string
+	unsubscribed int
+}
+
+func (r *subscriptionRecorder) SubscribeMulti(ctx context.Context, topics []string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribed = append(r.subscribed, topics)
+	return func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.unsubscribed++
+		return nil
+	}, nil
+}
+
+func (r *subscriptionRecorder) counts() (subscribed, unsubscribed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.subscribed), r.unsubscribed
+}
+
+func TestSubscribeToProductsStartsConfiguredConsumers(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recorder := &subscriptionRecorder{}
+	var wg sync.WaitGroup
+	subscribeToProducts(ctx, recorder, &invalidationService{}, 2, 3, log, &wg)
+
+	// Каждый consumer подписывается в своей горутине
+	deadline := time.Now().Add(time.Second)
+	for {
+		subscribed, _ := recorder.counts()
+		if subscribed == 3 && testutil.ToFloat64(activeConsumers.WithLabelValues("product-events")) == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribed = %d, active event consumers = %v, want 3",
+				subscribed, testutil.ToFloat64(activeConsumers.WithLabelValues("product-events")))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	perTopic := map[string]int{}
+	recorder.mu.Lock()
+	for _, topics := range recorder.subscribed {
+		for _, topic := range topics {
+			perTopic[topic]++
+		}
+	}
+	recorder.mu.Unlock()
+	if perTopic["product-commands"] != 2 || perTopic["product-events"] != 3 {
+		t.Fatalf("consumers per topic = %v, want 2 for commands and 3 for events", perTopic)
+	}
+	if active := testutil.ToFloat64(activeConsumers.WithLabelValues("product-commands")); active != 2 {
+		t.Fatalf("active command consumers = %v, want 2", active)
+	}
+
+	// При остановке все consumer'ы отписываются и завершаются
+	cancel()
+	wg.Wait()
+	if _, unsubscribed := recorder.counts(); unsubscribed != 3 {
+		t.Fatalf("unsubscribed = %d, want 3", unsubscribed)
+	}
+	if active := testutil.ToFloat64(activeConsumers.WithLabelValues("product-events")); active != 0 {
+		t.Fatalf("active event consumers = %v after shutdown, want 0", active)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/config"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+)
+
+// replayDLQCommand имя подкоманды воркера для повторной публикации сообщений из DLQ
+const replayDLQCommand = "replay-dlq"
+
+// runReplayDLQ выполняет подкоманду replay-dlq: возвращает сообщения из DLQ в исходные топики
+// и завершается, когда DLQ вычитана.
+//
+//	worker replay-dlq [-max-replays 3] [-idle 10s]
+func runReplayDLQ(cfg *config.Config, log interfaces.LoggerPort, args []string) {
+	flags := flag.NewFlagSet(replayDLQCommand, flag.ExitOnError)
+	maxReplays := flags.Int("max-replays", 3, "сколько раз одно сообщение может быть возвращено из DLQ")
+	idle := flags.Duration("idle", 10*time.Second, "завершить работу, если новых сообщений нет в течение этого времени")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := messaging.NewKafkaMessagingWithConfig(messaging.KafkaConfig{
+		Brokers:         cfg.Kafka.Brokers,
+		GroupID:         cfg.Kafka.GroupID,
+		DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+	}, log)
+	if err != nil {
+		log.Fatal("Ошибка инициализации системы обмена сообщениями",
+			interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+	defer client.Close()
+
+	result, err := client.(*messaging.KafkaMessaging).ReplayDeadLetters(ctx, *maxReplays, *idle)
+	if err != nil {
+		log.Error("Повторная публикация из DLQ прервана",
+			interfaces.LogField{Key: "replayed", Value: result.Replayed},
+			interfaces.LogField{Key: "skipped", Value: result.Skipped},
+			interfaces.LogField{Key: "error", Value: err.Error()})
+		client.Close()
+		os.Exit(1)
+	}
+	log.Info("Повторная публикация из DLQ завершена",
+		interfaces.LogField{Key: "replayed", Value: result.Replayed},
+		interfaces.LogField{Key: "skipped", Value: result.Skipped})
+}
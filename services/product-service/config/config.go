@@ -32,8 +32,14 @@ type Config struct {
 		Password string
 		DBName   string
 		SSLMode  string
-		Timeout  time.Duration
-		PoolSize int // размер пула соединений
+		// SSLRootCert, SSLCert и SSLKey пути к сертификату CA и клиентским сертификату и ключу для TLS
+		SSLRootCert string
+		SSLCert     string
+		SSLKey      string
+		Timeout     time.Duration
+		PoolSize    int // размер пула соединений
+		// QueryTimeout ограничивает время одного читающего запроса, 0 - без ограничения
+		QueryTimeout time.Duration
 	}
 
 	Redis struct {
@@ -53,31 +59,61 @@ type Config struct {
 		MinRetryBackoff   time.Duration // минимальное время между повторными попытками
 		MaxRetryBackoff   time.Duration // максимальное время между повторными попытками
 		DefaultExpiration time.Duration // срок действия кэша по умолчанию
+		// CompressionAlgorithm алгоритм сжатия значений: gzip, snappy или пусто (без сжатия)
+		CompressionAlgorithm string
+		// CompressionThreshold размер значения в байтах, начиная с которого оно сжимается
+		CompressionThreshold int
+	}
+
+	Cache struct {
+		Backend string // redis или memory; кэш в памяти процесса только для локальной разработки
 	}
 
 	Kafka struct {
-		Brokers           []string      `mapstructure:"brokers"`
-		GroupID           string        `mapstructure:"groupID"`
-		ProducerTopic     string        `mapstructure:"producer_topic"`
-		ConsumerTopic     string        `mapstructure:"consumer_topic"`
-		DeadLetterTopic   string        `mapstructure:"dead_letter_topic"`
-		AutoOffsetReset   string        `mapstructure:"auto_offset_reset"`
-		SessionTimeout    time.Duration `mapstructure:"session_timeout"`
-		HeartbeatTimeout  time.Duration `mapstructure:"heartbeat_timeout"`
-		ReadTimeout       time.Duration `mapstructure:"read_timeout"`
-		WriteTimeout      time.Duration `mapstructure:"write_timeout"`
-		MaxRetries        int           `mapstructure:"max_retries"`
-		RetryBackoff      time.Duration `mapstructure:"retry_backoff"`
-		BatchSize         int           `mapstructure:"batch_size"`
-		LingerMs          int           `mapstructure:"linger_ms"`
-		EnableIdempotence bool          `mapstructure:"enable_idempotence"`
-		CompressionType   string        `mapstructure:"compression_type"`
+		Brokers            []string      `mapstructure:"brokers"`
+		GroupID            string        `mapstructure:"groupID"`
+		ProducerTopic      string        `mapstructure:"producer_topic"`
+		ConsumerTopic      string        `mapstructure:"consumer_topic"`
+		DeadLetterTopic    string        `mapstructure:"dead_letter_topic"`
+		AutoOffsetReset    string        `mapstructure:"auto_offset_reset"`
+		SessionTimeout     time.Duration `mapstructure:"session_timeout"`
+		HeartbeatTimeout   time.Duration `mapstructure:"heartbeat_timeout"`
+		ReadTimeout        time.Duration `mapstructure:"read_timeout"`
+		WriteTimeout       time.Duration `mapstructure:"write_timeout"`
+		MaxRetries         int           `mapstructure:"max_retries"`
+		RetryBackoff       time.Duration `mapstructure:"retry_backoff"`
+		BatchSize          int           `mapstructure:"batch_size"`
+		LingerMs           int           `mapstructure:"linger_ms"`
+		EnableIdempotence  bool          `mapstructure:"enable_idempotence"`
+		CompressionType    string        `mapstructure:"compression_type"`
+		DLQAlertThreshold  int           `mapstructure:"dlq_alert_threshold"`  // число сообщений DLQ за окно, после которого поднимается алерт
+		DLQAlertWindow     time.Duration `mapstructure:"dlq_alert_window"`     // окно подсчета сообщений DLQ
+		AlertTopic         string        `mapstructure:"alert_topic"`          // топик для событий-алертов
+		DedupTTL           time.Duration `mapstructure:"dedup_ttl"`            // сколько хранится отметка об обработанном сообщении
+		AutoCommit         bool          `mapstructure:"auto_commit"`          // автофиксация смещений; при false смещение фиксируется после обработки сообщения
+		AutoCommitInterval time.Duration `mapstructure:"auto_commit_interval"` // интервал автоматической фиксации смещений
+		DrainTimeout       time.Duration `mapstructure:"drain_timeout"`        // ожидание текущих сообщений при остановке consumer'ов
+	}
+
+	// Consumer'ы воркера: общие читают оба топика, остальные только свой, пока каждый топик не получит свое число
+	Worker struct {
+		CommandConsumers int `mapstructure:"command_consumers"` // требуемое число consumer'ов топика команд
+		EventConsumers   int `mapstructure:"event_consumers"`   // требуемое число consumer'ов топика событий
+	}
+
+	Outbox struct {
+		BatchSize    int           `mapstructure:"batch_size"`    // число событий, публикуемых за один проход relay
+		PollInterval time.Duration `mapstructure:"poll_interval"` // интервал опроса outbox
+	}
+
+	Inventory struct {
+		ReservationSweepInterval time.Duration `mapstructure:"reservation_sweep_interval"` // интервал удаления истекших резервов
 	}
 
 	Tracing struct {
 		Enabled     bool
 		ServiceName string
-		Endpoint    string
+		Endpoint    string  // адрес OTLP/HTTP коллектора
 		Probability float64 // вероятность сэмплирования трассировки
 	}
 
@@ -89,12 +125,33 @@ type Config struct {
 	}
 
 	Security struct {
-		JWTSecret         string
-		JWTExpirationMin  time.Duration
-		CORSAllowOrigins  []string
-		JWTPublicKeyPath  string
-		JWTPrivateKeyPath string
-		CSRFSecret        string
+		JWTSecret            string
+		JWTExpirationMin     time.Duration
+		JWTRefreshExpiration time.Duration // время жизни refresh-токена
+		CORSAllowOrigins     []string
+		JWTPublicKeyPath     string
+		JWTPrivateKeyPath    string
+		CSRFSecret           string
+		JWKSURL              string        // адрес JWKS-документа внешнего провайдера ключей, пусто - только статический ключ
+		JWKSTimeout          time.Duration // таймаут загрузки JWKS-документа
+		// RolePermissions разрешения, которые дают роли, в дополнение к разрешениям из токена
+		RolePermissions map[string][]string
+	}
+
+	// Лимиты запросов к API: тенант определяется по токену, неаутентифицированные запросы считаются по IP
+	RateLimit struct {
+		Requests int            // лимит запросов за окно по умолчанию
+		Window   time.Duration  // окно подсчета запросов
+		Tenants  map[string]int // индивидуальные лимиты тенантов за окно
+	}
+
+	// Клиент Keycloak для получения сервисных токенов по client credentials и проверки токенов API
+	Keycloak struct {
+		URL          string // базовый адрес Keycloak
+		Realm        string
+		ClientID     string
+		ClientSecret string
+		Timeout      time.Duration // таймаут запроса токена
 	}
 
 	Resilience struct {
@@ -104,6 +161,36 @@ type Config struct {
 		HalfOpenMaxReqs int           // макс. запросов в полуоткрытом состоянии
 		TripThreshold   int           // порог ошибок для размыкания
 	}
+
+	Supplier struct {
+		BaseURL string        // адрес API каталогов поставщиков
+		Timeout time.Duration // таймаут запроса к API поставщика
+	}
+
+	Marketplace struct {
+		BaseURL string        // адрес API маркетплейсов
+		Timeout time.Duration // таймаут запроса к API маркетплейса
+	}
+
+	ObjectStorage struct {
+		Endpoint  string // адрес S3-совместимого хранилища (host:port)
+		AccessKey string
+		SecretKey string
+		Bucket    string
+		UseSSL    bool
+		PublicURL string // базовый адрес для ссылок на объекты, по умолчанию совпадает с Endpoint
+	}
+
+	Media struct {
+		MaxUploadSize int // максимальный размер загружаемого файла в МБ, не больше server.bodyLimit
+	}
+
+	// Пересчет цен в другие валюты
+	Currency struct {
+		Base    string             // базовая валюта фиксированных курсов
+		Rates   map[string]float64 // число единиц валюты за единицу базовой
+		RateTTL time.Duration      // время жизни курса в кэше
+	}
 }
 
 // Load загружает конфигурацию из файла и переменных окружения
@@ -156,6 +243,10 @@ func Load(configPath string) (*Config, error) {
 
 	log.Printf("Загружен файл конфигурации: %s", viper.ConfigFileUsed())
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
@@ -182,8 +273,12 @@ func setDefaults() {
 	viper.SetDefault("postgres.password", "postgres")
 	viper.SetDefault("postgres.dbname", "postgres")
 	viper.SetDefault("postgres.sslmode", "disable")
+	viper.SetDefault("postgres.sslrootcert", "")
+	viper.SetDefault("postgres.sslcert", "")
+	viper.SetDefault("postgres.sslkey", "")
 	viper.SetDefault("postgres.timeout", "5s")
 	viper.SetDefault("postgres.poolSize", 10)
+	viper.SetDefault("postgres.queryTimeout", "5s")
 
 	// настройки Redis
 	viper.SetDefault("redis.host", "localhost")
@@ -202,6 +297,11 @@ func setDefaults() {
 	viper.SetDefault("redis.minRetryBackoff", "8ms")
 	viper.SetDefault("redis.maxRetryBackoff", "512ms")
 	viper.SetDefault("redis.defaultExpiration", "10m")
+	viper.SetDefault("redis.compressionAlgorithm", "")
+	viper.SetDefault("redis.compressionThreshold", 1024)
+
+	// настройки кэша
+	viper.SetDefault("cache.backend", "redis")
 
 	// настройки Kafka
 	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
@@ -214,11 +314,32 @@ func setDefaults() {
 	viper.SetDefault("kafka.heartbeatTimeout", "3s")
 	viper.SetDefault("kafka.readTimeout", "10s")
 	viper.SetDefault("kafka.writeTimeout", "10s")
+	viper.SetDefault("kafka.dead_letter_topic", "product-dlq")
+	viper.SetDefault("kafka.max_retries", 3)
+	viper.SetDefault("kafka.retry_backoff", "100ms")
+	viper.SetDefault("kafka.dlq_alert_threshold", 10)
+	viper.SetDefault("kafka.dlq_alert_window", "5m")
+	viper.SetDefault("kafka.alert_topic", "product-alerts")
+	viper.SetDefault("kafka.dedup_ttl", "24h")
+	viper.SetDefault("kafka.auto_commit", true)
+	viper.SetDefault("kafka.auto_commit_interval", "5s")
+	viper.SetDefault("kafka.drain_timeout", "30s")
+
+	// настройки воркера
+	viper.SetDefault("worker.command_consumers", 1)
+	viper.SetDefault("worker.event_consumers", 1)
+
+	// настройки outbox
+	viper.SetDefault("outbox.batch_size", 100)
+	viper.SetDefault("outbox.poll_interval", "1s")
+
+	// настройки остатков
+	viper.SetDefault("inventory.reservation_sweep_interval", "1m")
 
 	// настройки трассировки
 	viper.SetDefault("tracing.enabled", true)
 	viper.SetDefault("tracing.serviceName", "product-service")
-	viper.SetDefault("tracing.endpoint", "jaeger:6831")
+	viper.SetDefault("tracing.endpoint", "jaeger:4318")
 	viper.SetDefault("tracing.probability", 0.1)
 
 	// настройки метрик
@@ -229,7 +350,21 @@ func setDefaults() {
 	// настройки безопасности
 	viper.SetDefault("security.jwtSecret", "your-secret-key")
 	viper.SetDefault("security.jwtExpirationMin", "60m")
+	viper.SetDefault("security.jwtRefreshExpiration", "720h")
 	viper.SetDefault("security.corsAllowOrigins", []string{"*"})
+	viper.SetDefault("security.jwksURL", "")
+	viper.SetDefault("security.jwksTimeout", "5s")
+
+	// Лимиты запросов
+	viper.SetDefault("rateLimit.requests", 1000)
+	viper.SetDefault("rateLimit.window", "1m")
+
+	// Настройки Keycloak
+	viper.SetDefault("keycloak.url", "")
+	viper.SetDefault("keycloak.realm", "gomarket")
+	viper.SetDefault("keycloak.clientID", "product-service")
+	viper.SetDefault("keycloak.clientSecret", "")
+	viper.SetDefault("keycloak.timeout", "10s")
 
 	// Настройки отказоустойчивости
 	viper.SetDefault("resilience.maxRetries", 3)
@@ -237,6 +372,29 @@ func setDefaults() {
 	viper.SetDefault("resilience.circuitTimeout", "30s")
 	viper.SetDefault("resilience.halfOpenMaxReqs", 5)
 	viper.SetDefault("resilience.tripThreshold", 10)
+
+	// Настройки API поставщиков
+	viper.SetDefault("supplier.baseURL", "http://localhost:8090")
+	viper.SetDefault("supplier.timeout", "30s")
+
+	// Настройки API маркетплейсов
+	viper.SetDefault("marketplace.baseURL", "http://localhost:8091")
+	viper.SetDefault("marketplace.timeout", "30s")
+
+	// Настройки объектного хранилища
+	viper.SetDefault("objectStorage.endpoint", "localhost:9000")
+	viper.SetDefault("objectStorage.accessKey", "minioadmin")
+	viper.SetDefault("objectStorage.secretKey", "minioadmin")
+	viper.SetDefault("objectStorage.bucket", "product-media")
+	viper.SetDefault("objectStorage.useSSL", false)
+	viper.SetDefault("objectStorage.publicURL", "")
+
+	// Настройки загрузки медиафайлов
+	viper.SetDefault("media.maxUploadSize", 8) // 8 МБ
+
+	// Настройки пересчета валют
+	viper.SetDefault("currency.base", "RUB")
+	viper.SetDefault("currency.rateTTL", "5m")
 }
 
 // bindEnvVariables привязывает переменные окружения к конфигурации
@@ -262,8 +420,12 @@ func bindEnvVariables() {
 	viper.BindEnv("postgres.password", "POSTGRES_PASSWORD")
 	viper.BindEnv("postgres.dbname", "POSTGRES_DBNAME")
 	viper.BindEnv("postgres.sslmode", "POSTGRES_SSLMODE")
+	viper.BindEnv("postgres.sslrootcert", "POSTGRES_SSLROOTCERT")
+	viper.BindEnv("postgres.sslcert", "POSTGRES_SSLCERT")
+	viper.BindEnv("postgres.sslkey", "POSTGRES_SSLKEY")
 	viper.BindEnv("postgres.timeout", "POSTGRES_TIMEOUT")
 	viper.BindEnv("postgres.poolSize", "POSTGRES_POOL_SIZE")
+	viper.BindEnv("postgres.queryTimeout", "POSTGRES_QUERY_TIMEOUT")
 
 	// Redis
 	viper.BindEnv("redis.host", "REDIS_HOST")
@@ -282,6 +444,11 @@ func bindEnvVariables() {
 	viper.BindEnv("redis.minRetryBackoff", "REDIS_MIN_RETRY_BACKOFF")
 	viper.BindEnv("redis.maxRetryBackoff", "REDIS_MAX_RETRY_BACKOFF")
 	viper.BindEnv("redis.defaultExpiration", "REDIS_DEFAULT_EXPIRATION")
+	viper.BindEnv("redis.compressionAlgorithm", "REDIS_COMPRESSION_ALGORITHM")
+	viper.BindEnv("redis.compressionThreshold", "REDIS_COMPRESSION_THRESHOLD")
+
+	// кэш
+	viper.BindEnv("cache.backend", "CACHE_BACKEND")
 
 	// Kafka
 	viper.BindEnv("kafka.brokers", "KAFKA_BROKERS")
@@ -294,6 +461,27 @@ func bindEnvVariables() {
 	viper.BindEnv("kafka.heartbeatTimeout", "KAFKA_HEARTBEAT_TIMEOUT")
 	viper.BindEnv("kafka.readTimeout", "KAFKA_READ_TIMEOUT")
 	viper.BindEnv("kafka.writeTimeout", "KAFKA_WRITE_TIMEOUT")
+	viper.BindEnv("kafka.dead_letter_topic", "KAFKA_DEAD_LETTER_TOPIC")
+	viper.BindEnv("kafka.max_retries", "KAFKA_MAX_RETRIES")
+	viper.BindEnv("kafka.retry_backoff", "KAFKA_RETRY_BACKOFF")
+	viper.BindEnv("kafka.dlq_alert_threshold", "KAFKA_DLQ_ALERT_THRESHOLD")
+	viper.BindEnv("kafka.dlq_alert_window", "KAFKA_DLQ_ALERT_WINDOW")
+	viper.BindEnv("kafka.alert_topic", "KAFKA_ALERT_TOPIC")
+	viper.BindEnv("kafka.dedup_ttl", "KAFKA_DEDUP_TTL")
+	viper.BindEnv("kafka.auto_commit", "KAFKA_AUTO_COMMIT")
+	viper.BindEnv("kafka.auto_commit_interval", "KAFKA_AUTO_COMMIT_INTERVAL")
+	viper.BindEnv("kafka.drain_timeout", "KAFKA_DRAIN_TIMEOUT")
+
+	// воркер
+	viper.BindEnv("worker.command_consumers", "WORKER_COMMAND_CONSUMERS")
+	viper.BindEnv("worker.event_consumers", "WORKER_EVENT_CONSUMERS")
+
+	// outbox
+	viper.BindEnv("outbox.batch_size", "OUTBOX_BATCH_SIZE")
+	viper.BindEnv("outbox.poll_interval", "OUTBOX_POLL_INTERVAL")
+
+	// остатки
+	viper.BindEnv("inventory.reservation_sweep_interval", "INVENTORY_RESERVATION_SWEEP_INTERVAL")
 
 	// трассировка
 	viper.BindEnv("tracing.enabled", "TRACING_ENABLED")
@@ -309,7 +497,23 @@ func bindEnvVariables() {
 	// настройки безопасности
 	viper.BindEnv("security.jwtSecret", "JWT_SECRET")
 	viper.BindEnv("security.jwtExpirationMin", "JWT_EXPIRATION_MIN")
+	viper.BindEnv("security.jwtRefreshExpiration", "JWT_REFRESH_EXPIRATION")
+	viper.BindEnv("security.jwtPrivateKeyPath", "JWT_PRIVATE_KEY_PATH")
+	viper.BindEnv("security.jwtPublicKeyPath", "JWT_PUBLIC_KEY_PATH")
 	viper.BindEnv("security.corsAllowOrigins", "CORS_ALLOW_ORIGINS")
+	viper.BindEnv("security.jwksURL", "JWKS_URL")
+	viper.BindEnv("security.jwksTimeout", "JWKS_TIMEOUT")
+
+	// лимиты запросов
+	viper.BindEnv("rateLimit.requests", "RATE_LIMIT_REQUESTS")
+	viper.BindEnv("rateLimit.window", "RATE_LIMIT_WINDOW")
+
+	// Keycloak
+	viper.BindEnv("keycloak.url", "KEYCLOAK_URL")
+	viper.BindEnv("keycloak.realm", "KEYCLOAK_REALM")
+	viper.BindEnv("keycloak.clientID", "KEYCLOAK_CLIENT_ID")
+	viper.BindEnv("keycloak.clientSecret", "KEYCLOAK_CLIENT_SECRET")
+	viper.BindEnv("keycloak.timeout", "KEYCLOAK_TIMEOUT")
 
 	// настройки отказоустойчивости
 	viper.BindEnv("resilience.maxRetries", "RESILIENCE_MAX_RETRIES")
@@ -317,4 +521,27 @@ func bindEnvVariables() {
 	viper.BindEnv("resilience.circuitTimeout", "RESILIENCE_CIRCUIT_TIMEOUT")
 	viper.BindEnv("resilience.halfOpenMaxReqs", "RESILIENCE_HALF_OPEN_MAX_REQS")
 	viper.BindEnv("resilience.tripThreshold", "RESILIENCE_TRIP_THRESHOLD")
+
+	// настройки API поставщиков
+	viper.BindEnv("supplier.baseURL", "SUPPLIER_BASE_URL")
+	viper.BindEnv("supplier.timeout", "SUPPLIER_TIMEOUT")
+
+	// настройки API маркетплейсов
+	viper.BindEnv("marketplace.baseURL", "MARKETPLACE_BASE_URL")
+	viper.BindEnv("marketplace.timeout", "MARKETPLACE_TIMEOUT")
+
+	// настройки объектного хранилища
+	viper.BindEnv("objectStorage.endpoint", "OBJECT_STORAGE_ENDPOINT")
+	viper.BindEnv("objectStorage.accessKey", "OBJECT_STORAGE_ACCESS_KEY")
+	viper.BindEnv("objectStorage.secretKey", "OBJECT_STORAGE_SECRET_KEY")
+	viper.BindEnv("objectStorage.bucket", "OBJECT_STORAGE_BUCKET")
+	viper.BindEnv("objectStorage.useSSL", "OBJECT_STORAGE_USE_SSL")
+	viper.BindEnv("objectStorage.publicURL", "OBJECT_STORAGE_PUBLIC_URL")
+
+	// настройки загрузки медиафайлов
+	viper.BindEnv("media.maxUploadSize", "MEDIA_MAX_UPLOAD_SIZE")
+
+	// настройки пересчета валют
+	viper.BindEnv("currency.base", "CURRENCY_BASE")
+	viper.BindEnv("currency.rateTTL", "CURRENCY_RATE_TTL")
 }
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// ReloadOnSIGHUP перечитывает конфигурацию configPath по сигналу SIGHUP и передает ее apply, пока не отменен ctx.
+// apply должен применить только настройки, которые можно менять на лету: уровень логирования, лимиты запросов
+// и CORS origins. Изменения остальных разделов (порты, подключения к БД, Redis, Kafka) требуют перезапуска,
+// поэтому не применяются, а только сопровождаются предупреждением. Некорректная конфигурация не применяется
+func ReloadOnSIGHUP(ctx context.Context, configPath string, running *Config, logger interfaces.LoggerPort, apply func(*Config)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				next, err := Load(configPath)
+				if err != nil {
+					logger.Error("Конфигурация не перезагружена",
+						interfaces.LogField{Key: "error", Value: err.Error()})
+					continue
+				}
+
+				if changed := restartRequired(running, next); len(changed) > 0 {
+					logger.Warn("Изменения этих разделов конфигурации вступят в силу только после перезапуска",
+						interfaces.LogField{Key: "sections", Value: strings.Join(changed, ", ")})
+				}
+
+				apply(next)
+				logger.Info("Конфигурация перезагружена",
+					interfaces.LogField{Key: "log_level", Value: next.LogLevel})
+			}
+		}
+	}()
+}
+
+// restartRequired возвращает разделы конфигурации, которые отличаются от работающей конфигурации
+// без учета настроек, применяемых на лету
+func restartRequired(running, next *Config) []string {
+	current, updated := *running, *next
+	updated.LogLevel = current.LogLevel
+	updated.RateLimit = current.RateLimit
+	updated.Security.CORSAllowOrigins = current.Security.CORSAllowOrigins
+
+	var changed []string
+	currentValue, updatedValue := reflect.ValueOf(current), reflect.ValueOf(updated)
+	for i := 0; i < currentValue.NumField(); i++ {
+		if !reflect.DeepEqual(currentValue.Field(i).Interface(), updatedValue.Field(i).Interface()) {
+			changed = append(changed, currentValue.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+)
+
+// writeReloadConfig записывает в dir файл reload.yaml: template с заменами replacements
+func writeReloadConfig(t *testing.T, dir, template string, replacements ...string) {
+	t.Helper()
+	content := strings.NewReplacer(replacements...).Replace(template)
+	if err := os.WriteFile(filepath.Join(dir, "reload.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestReloadOnSIGHUP(t *testing.T) {
+	data, err := os.ReadFile("config.yaml")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	template := string(data)
+	dir := t.TempDir()
+	writeReloadConfig(t, dir, template)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	running, err := Load("reload")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	log, err := logger.NewZapLogger(running.LogLevel, false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	applied := make(chan *Config, 1)
+	ReloadOnSIGHUP(ctx, "reload", running, log, func(next *Config) {
+		log.SetLevel(logger.GetLoggerLevel(strings.ToLower(next.LogLevel)))
+		applied <- next
+	})
+
+	reload := func(t *testing.T) {
+		t.Helper()
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatalf("Kill: %v", err)
+		}
+	}
+
+	t.Run("invalid config is not applied", func(t *testing.T) {
+		writeReloadConfig(t, dir, template, "port: 8081", "port: 0", "logLevel: info", "logLevel: debug")
+		reload(t)
+
+		select {
+		case next := <-applied:
+			t.Fatalf("invalid config applied: %+v", next.Server)
+		case <-time.After(200 * time.Millisecond):
+		}
+		if log.GetLevel() != interfaces.InfoLevel {
+			t.Fatalf("level = %v, want info kept", log.GetLevel())
+		}
+	})
+
+	t.Run("log level changed", func(t *testing.T) {
+		writeReloadConfig(t, dir, template, "logLevel: info", "logLevel: debug", "requests: 1000", "requests: 10")
+		reload(t)
+
+		select {
+		case next := <-applied:
+			if next.RateLimit.Requests != 10 {
+				t.Fatalf("rate limit = %d, want 10", next.RateLimit.Requests)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("config was not reloaded")
+		}
+		if log.GetLevel() != interfaces.DebugLevel {
+			t.Fatalf("level = %v, want debug after reload", log.GetLevel())
+		}
+	})
+}
+
+func TestRestartRequired(t *testing.T) {
+	running := loadTestConfig(t)
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   []string
+	}{
+		{name: "runtime settings", modify: func(cfg *Config) {
+			cfg.LogLevel = "debug"
+			cfg.RateLimit.Requests = 10
+			cfg.RateLimit.Tenants = map[string]int{"tenant-1": 100}
+			cfg.Security.CORSAllowOrigins = []string{"https://admin.example.com"}
+		}},
+		{name: "ports and connections", modify: func(cfg *Config) {
+			cfg.LogLevel = "debug"
+			cfg.Server.Port = 9090
+			cfg.Postgres.Host = "db.internal"
+		}, want: []string{"Server", "Postgres"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := loadTestConfig(t)
+			tt.modify(next)
+
+			if changed := restartRequired(running, next); !reflect.DeepEqual(changed, tt.want) {
+				t.Fatalf("restart required for %v, want %v", changed, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Validate проверяет согласованность конфигурации и возвращает одну ошибку со списком всех проблем,
+// чтобы неверные настройки обнаруживались при запуске, а не при инициализации зависимостей
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	checkPort := func(key string, port int) {
+		if port < 1 || port > 65535 {
+			addf("%s должен быть в диапазоне 1-65535, получено %d", key, port)
+		}
+	}
+	checkPositive := func(key string, d time.Duration) {
+		if d <= 0 {
+			addf("%s должен быть положительным, получено %s", key, d)
+		}
+	}
+
+	// Сервер
+	checkPort("server.port", c.Server.Port)
+	checkPositive("server.readTimeout", c.Server.ReadTimeout)
+	checkPositive("server.writeTimeout", c.Server.WriteTimeout)
+	checkPositive("server.shutdownTimeout", c.Server.ShutdownTimeout)
+	if c.Server.BodyLimit <= 0 {
+		addf("server.bodyLimit должен быть положительным, получено %d", c.Server.BodyLimit)
+	}
+	if c.Media.MaxUploadSize > c.Server.BodyLimit {
+		addf("media.maxUploadSize (%d МБ) не может превышать server.bodyLimit (%d МБ)", c.Media.MaxUploadSize, c.Server.BodyLimit)
+	}
+
+	// Postgres
+	if c.Postgres.Host == "" {
+		addf("postgres.host не задан")
+	}
+	checkPort("postgres.port", c.Postgres.Port)
+	checkPositive("postgres.timeout", c.Postgres.Timeout)
+	switch c.Postgres.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		addf("postgres.sslmode должен быть disable, allow, prefer, require, verify-ca или verify-full, получено %q", c.Postgres.SSLMode)
+	}
+	if (c.Postgres.SSLCert == "") != (c.Postgres.SSLKey == "") {
+		addf("postgres.sslcert и postgres.sslkey задаются только вместе")
+	}
+	if c.Postgres.QueryTimeout < 0 {
+		addf("postgres.queryTimeout не может быть отрицательным, получено %s", c.Postgres.QueryTimeout)
+	}
+
+	// Кэш: параметры Redis проверяются, только если он используется
+	switch c.Cache.Backend {
+	case "redis":
+		if c.Redis.Host == "" {
+			addf("redis.host не задан")
+		}
+		checkPort("redis.port", c.Redis.Port)
+		checkPositive("redis.connectTimeout", c.Redis.ConnectTimeout)
+		checkPositive("redis.readTimeout", c.Redis.ReadTimeout)
+		checkPositive("redis.writeTimeout", c.Redis.WriteTimeout)
+	case "memory":
+	default:
+		addf("cache.backend должен быть redis или memory, получено %q", c.Cache.Backend)
+	}
+
+	// Kafka используется и API (outbox, команды), и воркером
+	if len(c.Kafka.Brokers) == 0 {
+		addf("kafka.brokers не заданы")
+	}
+	for i, broker := range c.Kafka.Brokers {
+		if strings.TrimSpace(broker) == "" {
+			addf("kafka.brokers[%d] пустой", i)
+		}
+	}
+
+	// Порт 0 допустим: сервер метрик воркера займет свободный порт
+	if c.Metrics.Enabled && (c.Metrics.Port < 0 || c.Metrics.Port > 65535) {
+		addf("metrics.port должен быть в диапазоне 0-65535, получено %d", c.Metrics.Port)
+	}
+
+	// Безопасность: без Keycloak токены выпускает и проверяет сам сервис
+	checkPositive("security.jwtExpirationMin", c.Security.JWTExpirationMin)
+	checkPositive("security.jwtRefreshExpiration", c.Security.JWTRefreshExpiration)
+	if c.Keycloak.URL == "" {
+		if c.Security.JWTPrivateKeyPath == "" {
+			addf("security.jwtPrivateKeyPath не задан, а Keycloak не настроен (keycloak.url)")
+		}
+		if c.Security.JWTPublicKeyPath == "" {
+			addf("security.jwtPublicKeyPath не задан, а Keycloak не настроен (keycloak.url)")
+		}
+	} else {
+		if c.Keycloak.Realm == "" {
+			addf("keycloak.realm не задан")
+		}
+		if c.Keycloak.ClientID == "" || c.Keycloak.ClientSecret == "" {
+			addf("keycloak.clientID и keycloak.clientSecret обязательны, если задан keycloak.url")
+		}
+		checkPositive("keycloak.timeout", c.Keycloak.Timeout)
+	}
+	if c.Security.JWKSURL != "" {
+		checkPositive("security.jwksTimeout", c.Security.JWKSTimeout)
+	}
+
+	// Внешние API
+	checkPositive("supplier.timeout", c.Supplier.Timeout)
+	checkPositive("marketplace.timeout", c.Marketplace.Timeout)
+
+	if c.RateLimit.Requests <= 0 {
+		addf("rateLimit.requests должен быть положительным, получено %d", c.RateLimit.Requests)
+	}
+	checkPositive("rateLimit.window", c.RateLimit.Window)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("некорректная конфигурация:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
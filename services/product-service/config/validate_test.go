@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTestConfig загружает config.yaml из каталога пакета
+func loadTestConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load("config")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   []string
+	}{
+		{name: "valid", modify: func(cfg *Config) {}},
+		{name: "zero port", modify: func(cfg *Config) { cfg.Server.Port = 0 }, want: []string{"server.port"}},
+		{name: "port out of range", modify: func(cfg *Config) { cfg.Postgres.Port = 70000 }, want: []string{"postgres.port"}},
+		{name: "no brokers", modify: func(cfg *Config) { cfg.Kafka.Brokers = nil }, want: []string{"kafka.brokers не заданы"}},
+		{name: "blank broker", modify: func(cfg *Config) { cfg.Kafka.Brokers = []string{"localhost:9092", " "} }, want: []string{"kafka.brokers[1]"}},
+		{name: "negative timeout", modify: func(cfg *Config) { cfg.Server.ReadTimeout = -1 }, want: []string{"server.readTimeout"}},
+		{name: "no JWT keys without Keycloak", modify: func(cfg *Config) {
+			cfg.Keycloak.URL = ""
+			cfg.Security.JWTPrivateKeyPath = ""
+			cfg.Security.JWTPublicKeyPath = ""
+		}, want: []string{"security.jwtPrivateKeyPath", "security.jwtPublicKeyPath"}},
+		{name: "Keycloak without JWT keys", modify: func(cfg *Config) {
+			cfg.Keycloak.URL = "http://keycloak:8080"
+			cfg.Keycloak.Realm = "gomarket"
+			cfg.Keycloak.ClientID = "product-service"
+			cfg.Keycloak.ClientSecret = "secret"
+			cfg.Keycloak.Timeout = 5 * time.Second
+			cfg.Security.JWTPrivateKeyPath = ""
+			cfg.Security.JWTPublicKeyPath = ""
+		}},
+		{name: "redis settings ignored for memory cache", modify: func(cfg *Config) {
+			cfg.Cache.Backend = "memory"
+			cfg.Redis.Port = 0
+		}},
+		{name: "unknown cache backend", modify: func(cfg *Config) { cfg.Cache.Backend = "memcached" }, want: []string{"cache.backend"}},
+		{name: "all problems listed", modify: func(cfg *Config) {
+			cfg.Server.Port = 0
+			cfg.Kafka.Brokers = nil
+			cfg.RateLimit.Window = 0
+		}, want: []string{"server.port", "kafka.brokers", "rateLimit.window"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadTestConfig(t)
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate accepted an invalid config")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q does not mention %q", err, want)
+				}
+			}
+			if problems := strings.Count(err.Error(), "\n  - "); problems != len(tt.want) {
+				t.Fatalf("error lists %d problems, want %d: %v", problems, len(tt.want), err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	t.Setenv("SERVER_PORT", "0")
+
+	cfg, err := Load("config")
+	if err == nil || cfg != nil {
+		t.Fatalf("Load = %v, %v, want a validation error", cfg, err)
+	}
+	if !strings.Contains(err.Error(), "server.port") {
+		t.Fatalf("error %q does not mention server.port", err)
+	}
+}
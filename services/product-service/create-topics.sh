@@ -19,4 +19,6 @@ done
 echo "Creating topics..."
 kafka-topics --create --if-not-exists --topic product-events --bootstrap-server kafka:29092 --partitions 1 --replication-factor 1
 kafka-topics --create --if-not-exists --topic product-commands --bootstrap-server kafka:29092 --partitions 1 --replication-factor 1
+kafka-topics --create --if-not-exists --topic product-dlq --bootstrap-server kafka:29092 --partitions 1 --replication-factor 1
+kafka-topics --create --if-not-exists --topic product-alerts --bootstrap-server kafka:29092 --partitions 1 --replication-factor 1
 echo "Topics created successfully!"
\ No newline at end of file
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// Алгоритмы сжатия значений кэша
+const (
+	CompressionNone   = ""
+	CompressionGzip   = "gzip"
+	CompressionSnappy = "snappy"
+)
+
+// Маркеры в первом байте сохраненного значения. Значения без маркера хранятся как есть:
+// JSON и строковые значения с этих байтов не начинаются, поэтому записи, сохраненные
+// до включения сжатия, читаются без изменений. Несжатое значение, которое начинается
+// с байта маркера, получает префикс markerRaw
+const (
+	markerRaw    byte = 0x00
+	markerGzip   byte = 0x01
+	markerSnappy byte = 0x02
+)
+
+// codec сжимает значения не меньше threshold байт выбранным алгоритмом
+type codec struct {
+	algorithm string
+	threshold int
+}
+
+// newCodec проверяет алгоритм сжатия. threshold <= 0 или пустой алгоритм отключают сжатие
+func newCodec(algorithm string, threshold int) (codec, error) {
+	switch algorithm {
+	case CompressionNone, CompressionGzip, CompressionSnappy:
+	default:
+		return codec{}, fmt.Errorf("unsupported cache compression algorithm: %s", algorithm)
+	}
+	if threshold <= 0 {
+		algorithm = CompressionNone
+	}
+	return codec{algorithm: algorithm, threshold: threshold}, nil
+}
+
+// encode подготавливает значение к записи в Redis
+func (c codec) encode(value []byte) ([]byte, error) {
+	if c.algorithm != CompressionNone && len(value) >= c.threshold {
+		switch c.algorithm {
+		case CompressionGzip:
+			var buf bytes.Buffer
+			buf.WriteByte(markerGzip)
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(value); err != nil {
+				return nil, fmt.Errorf("failed to gzip cache value: %w", err)
+			}
+			if err := zw.Close(); err != nil {
+				return nil, fmt.Errorf("failed to gzip cache value: %w", err)
+			}
+			return buf.Bytes(), nil
+		case CompressionSnappy:
+			encoded := make([]byte, 1, 1+snappy.MaxEncodedLen(len(value)))
+			encoded[0] = markerSnappy
+			return append(encoded, snappy.Encode(nil, value)...), nil
+		}
+	}
+
+	if len(value) > 0 && value[0] <= markerSnappy {
+		return append([]byte{markerRaw}, value...), nil
+	}
+	return value, nil
+}
+
+// decode восстанавливает значение, прочитанное из Redis. Маркер определяет способ чтения
+// независимо от текущей настройки, поэтому смена алгоритма не ломает уже сохраненные записи
+func decode(stored []byte) ([]byte, error) {
+	if len(stored) == 0 {
+		return stored, nil
+	}
+
+	switch stored[0] {
+	case markerRaw:
+		return stored[1:], nil
+	case markerGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(stored[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip cache value: %w", err)
+		}
+		defer zr.Close()
+		value, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gunzip cache value: %w", err)
+		}
+		return value, nil
+	case markerSnappy:
+		value, err := snappy.Decode(nil, stored[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode snappy cache value: %w", err)
+		}
+		return value, nil
+	}
+	return stored, nil
+}
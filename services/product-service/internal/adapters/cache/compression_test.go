@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	large := []byte(`{"name":"Apple juice","description":"` + strings.Repeat("fresh apples ", 200) + `"}`)
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		value      []byte
+		wantMarker int // -1 значение хранится без маркера
+	}{
+		{name: "gzip", algorithm: CompressionGzip, value: large, wantMarker: int(markerGzip)},
+		{name: "snappy", algorithm: CompressionSnappy, value: large, wantMarker: int(markerSnappy)},
+		{name: "below threshold", algorithm: CompressionGzip, value: []byte(`{"name":"Apple juice"}`), wantMarker: -1},
+		{name: "disabled", algorithm: CompressionNone, value: large, wantMarker: -1},
+		{name: "raw value starting with a marker byte", algorithm: CompressionGzip, value: []byte{markerGzip, 'x'}, wantMarker: int(markerRaw)},
+		{name: "empty", algorithm: CompressionSnappy, value: []byte{}, wantMarker: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCodec(tt.algorithm, 256)
+			if err != nil {
+				t.Fatalf("newCodec: %v", err)
+			}
+
+			stored, err := c.encode(tt.value)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			switch {
+			case tt.wantMarker == -1 && !bytes.Equal(stored, tt.value):
+				t.Fatalf("stored = %q, want the value as is", stored)
+			case tt.wantMarker >= 0 && (len(stored) == 0 || stored[0] != byte(tt.wantMarker)):
+				t.Fatalf("stored starts with %v, want marker %d", stored[:min(1, len(stored))], tt.wantMarker)
+			case tt.wantMarker > int(markerRaw) && len(stored) >= len(tt.value):
+				t.Fatalf("stored %d bytes for a %d byte value, want it compressed", len(stored), len(tt.value))
+			}
+
+			got, err := decode(stored)
+			if err != nil || !bytes.Equal(got, tt.value) {
+				t.Fatalf("decode = %q, %v, want the original value", got, err)
+			}
+		})
+	}
+}
+
+func TestNewCodecUnsupportedAlgorithm(t *testing.T) {
+	if _, err := newCodec("lz4", 256); err == nil {
+		t.Fatal("newCodec accepted an unsupported algorithm")
+	}
+}
+
+func TestRedisCacheCompression(t *testing.T) {
+	for _, algorithm := range []string{CompressionGzip, CompressionSnappy} {
+		t.Run(algorithm, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			cache, err := NewRedisCacheWithConfig(context.Background(), RedisConfig{
+				Host:                 server.Host(),
+				Port:                 mustPort(t, server),
+				CompressionAlgorithm: algorithm,
+				CompressionThreshold: 1024,
+			})
+			if err != nil {
+				t.Fatalf("NewRedisCacheWithConfig: %v", err)
+			}
+			t.Cleanup(func() { cache.Close() })
+			ctx := context.Background()
+
+			value := []byte(`{"name":"Apple juice","description":"` + strings.Repeat("fresh apples ", 500) + `"}`)
+			if err := cache.SetWithTenant(ctx, "product:s1:p1", value, "tenant-1", time.Minute); err != nil {
+				t.Fatalf("SetWithTenant: %v", err)
+			}
+
+			raw, err := server.Get("tenant:tenant-1:product:s1:p1")
+			if err != nil {
+				t.Fatalf("raw value: %v", err)
+			}
+			if len(raw) >= len(value)/2 {
+				t.Fatalf("stored %d bytes for a %d byte value, want it compressed", len(raw), len(value))
+			}
+
+			got, err := cache.GetWithTenant(ctx, "product:s1:p1", "tenant-1")
+			if err != nil || !bytes.Equal(got, value) {
+				t.Fatalf("GetWithTenant returned %d bytes, err = %v, want the original value", len(got), err)
+			}
+
+			// Записи, сохраненные до включения сжатия, читаются без изменений
+			server.Set("tenant:tenant-1:product:s1:p2", `{"name":"Orange juice"}`)
+			if got, err := cache.GetWithTenant(ctx, "product:s1:p2", "tenant-1"); err != nil || string(got) != `{"name":"Orange juice"}` {
+				t.Fatalf("GetWithTenant = %q, %v, want the legacy value", got, err)
+			}
+		})
+	}
+}
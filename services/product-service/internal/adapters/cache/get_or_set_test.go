@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/google/uuid"
+)
+
+// testRedisAddrEnv переменная окружения с адресом тестового Redis (host:port).
+// Без нее тесты RedisCache выполняются на miniredis
+const testRedisAddrEnv = "PRODUCT_TEST_REDIS_ADDR"
+
+func newTestRedisCache(t *testing.T) interfaces.CachePort {
+	t.Helper()
+
+	addr := os.Getenv(testRedisAddrEnv)
+	if addr == "" {
+		addr = miniredis.RunT(t).Addr()
+	}
+	return newRedisCacheAt(t, addr)
+}
+
+// newRedisCacheAt подключает RedisCache к серверу addr и закрывает его по завершении теста
+func newRedisCacheAt(t *testing.T, addr string) interfaces.CachePort {
+	t.Helper()
+
+	host, portValue, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testRedisAddrEnv, err)
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testRedisAddrEnv, err)
+	}
+
+	cache, err := NewRedisCache(context.Background(), host, port, "", 0)
+	if err != nil {
+		t.Fatalf("NewRedisCache: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestGetOrSet(t *testing.T) {
+	caches := []struct {
+		name string
+		new  func(t *testing.T) interfaces.CachePort
+	}{
+		{name: "memory", new: func(t *testing.T) interfaces.CachePort {
+			cache := NewInMemoryCache(time.Minute)
+			t.Cleanup(func() { cache.Close() })
+			return cache
+		}},
+		{name: "redis", new: newTestRedisCache},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := tc.new(t)
+
+			t.Run("concurrent misses load once", func(t *testing.T) {
+				testGetOrSetLoadsOnce(t, cache)
+			})
+			t.Run("loader error is not cached", func(t *testing.T) {
+				testGetOrSetErrorNotCached(t, cache)
+			})
+		})
+	}
+}
+
+func testGetOrSetLoadsOnce(t *testing.T, cache interfaces.CachePort) {
+	const callers = 50
+
+	ctx := context.Background()
+	key := "product:" + uuid.NewString()
+	tenantID := uuid.NewString()
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func() ([]byte, error) {
+		loads.Add(1)
+		// Держим загрузку, пока остальные вызовы не придут за тем же ключом
+		<-release
+		return []byte("value"), nil
+	}
+
+	var (
+		wg      sync.WaitGroup
+		started sync.WaitGroup
+		results = make([][]byte, callers)
+		errs    = make([]error, callers)
+	)
+	started.Add(callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			started.Done()
+			results[i], errs[i] = cache.GetOrSet(ctx, key, tenantID, time.Minute, loader)
+		}(i)
+	}
+	started.Wait()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+	for i := range results {
+		if errs[i] != nil || string(results[i]) != "value" {
+			t.Fatalf("caller %d: value = %q, err = %v", i, results[i], errs[i])
+		}
+	}
+
+	cached, err := cache.GetWithTenant(ctx, key, tenantID)
+	if err != nil || string(cached) != "value" {
+		t.Fatalf("cached value = %q, err = %v", cached, err)
+	}
+}
+
+func testGetOrSetErrorNotCached(t *testing.T, cache interfaces.CachePort) {
+	ctx := context.Background()
+	key := "product:" + uuid.NewString()
+	tenantID := uuid.NewString()
+	errLoad := errors.New("database unavailable")
+
+	if _, err := cache.GetOrSet(ctx, key, tenantID, time.Minute, func() ([]byte, error) {
+		return nil, errLoad
+	}); !errors.Is(err, errLoad) {
+		t.Fatalf("err = %v, want loader error", err)
+	}
+
+	val, err := cache.GetOrSet(ctx, key, tenantID, time.Minute, func() ([]byte, error) {
+		return []byte("value"), nil
+	})
+	if err != nil || string(val) != "value" {
+		t.Fatalf("value = %q, err = %v, want loader called again", val, err)
+	}
+}
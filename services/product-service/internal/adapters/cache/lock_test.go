@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// lockBackends кэши с блокировками и способ досрочно истечь ключ в каждом из них
+func lockBackends(t *testing.T) map[string]struct {
+	cache  interfaces.CachePort
+	expire func()
+} {
+	t.Helper()
+
+	redisCache, server := newMiniredisCache(t)
+	memoryCache := NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	return map[string]struct {
+		cache  interfaces.CachePort
+		expire func()
+	}{
+		"redis":  {cache: redisCache, expire: func() { server.FastForward(2 * time.Minute) }},
+		"memory": {cache: memoryCache, expire: func() { time.Sleep(60 * time.Millisecond) }},
+	}
+}
+
+func TestLockAcquireContendRelease(t *testing.T) {
+	for name, backend := range lockBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			unlock, acquired, err := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute)
+			if err != nil || !acquired {
+				t.Fatalf("Lock: acquired = %v, err = %v", acquired, err)
+			}
+
+			// Второй претендент не получает блокировку, пока она удерживается
+			noop, acquired, err := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute)
+			if err != nil || acquired {
+				t.Fatalf("contended Lock: acquired = %v, err = %v, want it held", acquired, err)
+			}
+			noop() // unlock проигравшего ничего не снимает
+			if _, acquired, _ := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute); acquired {
+				t.Fatal("lock released by a contender that never held it")
+			}
+
+			// Другие ключи независимы
+			if _, acquired, _ := backend.cache.Lock(ctx, "lock:sync:supplier-2", time.Minute); !acquired {
+				t.Fatal("lock of another key is not independent")
+			}
+
+			unlock()
+			if _, acquired, err := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute); err != nil || !acquired {
+				t.Fatalf("Lock after release: acquired = %v, err = %v", acquired, err)
+			}
+		})
+	}
+}
+
+func TestLockUnlockKeepsAnotherHoldersLock(t *testing.T) {
+	for name, backend := range lockBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			ttl := time.Minute
+			if name == "memory" {
+				ttl = 50 * time.Millisecond
+			}
+
+			staleUnlock, acquired, err := backend.cache.Lock(ctx, "lock:sync:supplier-1", ttl)
+			if err != nil || !acquired {
+				t.Fatalf("Lock: acquired = %v, err = %v", acquired, err)
+			}
+
+			// Первый владелец не успел до истечения, блокировку получил второй
+			backend.expire()
+			unlock, acquired, err := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute)
+			if err != nil || !acquired {
+				t.Fatalf("Lock after expiry: acquired = %v, err = %v", acquired, err)
+			}
+
+			staleUnlock()
+			if _, acquired, _ := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute); acquired {
+				t.Fatal("stale unlock deleted the lock of the new holder")
+			}
+
+			unlock()
+			if _, acquired, _ := backend.cache.Lock(ctx, "lock:sync:supplier-1", time.Minute); !acquired {
+				t.Fatal("lock not released by its holder")
+			}
+		})
+	}
+}
+
+func TestRedisLockExpires(t *testing.T) {
+	cache, server := newMiniredisCache(t)
+
+	if _, acquired, err := cache.Lock(context.Background(), "lock:sync:supplier-1", 30*time.Second); err != nil || !acquired {
+		t.Fatalf("Lock: acquired = %v, err = %v", acquired, err)
+	}
+	// Ключ блокировки не привязан к тенанту и истекает сам, если владелец упал
+	if ttl := server.TTL("lock:sync:supplier-1"); ttl != 30*time.Second {
+		t.Fatalf("TTL = %v, want 30s", ttl)
+	}
+}
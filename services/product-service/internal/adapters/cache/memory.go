@@ -0,0 +1,305 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	pkgutils "github.com/athebyme/gomarket-platform/pkg/utils"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSweepInterval интервал удаления истекших записей InMemoryCache
+const DefaultSweepInterval = time.Minute
+
+// memoryItem хранит значение и момент истечения, нулевой expiresAt - запись без срока действия
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+// InMemoryCache реализует CachePort в памяти процесса для тестов и локальной разработки.
+// Ключи с тенантом строятся так же, как в RedisCache, шаблоны DeleteByPattern поддерживают
+// glob-синтаксис Redis (*, ?, [...]). Истекшие записи не возвращаются и удаляются фоновой очисткой
+type InMemoryCache struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	// loads объединяет одновременные загрузки одного ключа в GetOrSet
+	loads singleflight.Group
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewInMemoryCache создает кэш в памяти и запускает очистку истекших записей раз в sweepInterval
+func NewInMemoryCache(sweepInterval time.Duration) interfaces.CachePort {
+	if sweepInterval <= 0 {
+		sweepInterval = DefaultSweepInterval
+	}
+
+	c := &InMemoryCache{
+		items: make(map[string]memoryItem),
+		stop:  make(chan struct{}),
+	}
+	go c.sweep(sweepInterval)
+
+	return c
+}
+
+func (c *InMemoryCache) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			c.mu.Lock()
+			for key, item := range c.items {
+				if item.expired(now) {
+					delete(c.items, key)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *InMemoryCache) buildKey(key, tenantID string) string {
+	if tenantID != "" {
+		return fmt.Sprintf("tenant:%s:%s", tenantID, key)
+	}
+	return key
+}
+
+// lookup возвращает неистекшую запись. Вызывается под c.mu
+func (c *InMemoryCache) lookup(key string) ([]byte, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	if item.expired(time.Now()) {
+		delete(c.items, key)
+		return nil, false
+	}
+	return item.value, true
+}
+
+// store сохраняет копию значения. Вызывается под c.mu
+func (c *InMemoryCache) store(key string, value []byte, expiration time.Duration) {
+	item := memoryItem{value: append([]byte(nil), value...)}
+	if expiration > 0 {
+		item.expiresAt = time.Now().Add(expiration)
+	}
+	c.items[key] = item
+}
+
+func (c *InMemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.lookup(key)
+	if !ok {
+		return nil, errors.ErrCacheMiss
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (c *InMemoryCache) GetWithTenant(ctx context.Context, key string, tenantID string) ([]byte, error) {
+	return c.Get(ctx, c.buildKey(key, tenantID))
+}
+
+func (c *InMemoryCache) MGetWithTenant(ctx context.Context, keys []string, tenantID string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if value, ok := c.lookup(c.buildKey(key, tenantID)); ok {
+			result[key] = append([]byte(nil), value...)
+		}
+	}
+	return result, nil
+}
+
+func (c *InMemoryCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store(key, value, expiration)
+	return nil
+}
+
+func (c *InMemoryCache) SetWithTenant(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration) error {
+	return c.Set(ctx, c.buildKey(key, tenantID), value, expiration)
+}
+
+func (c *InMemoryCache) SetWithJitter(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration, jitter float64) error {
+	return c.SetWithTenant(ctx, key, value, tenantID, pkgutils.JitterTTL(expiration, jitter))
+}
+
+func (c *InMemoryCache) GetOrSet(ctx context.Context, key string, tenantID string, expiration time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	fullKey := c.buildKey(key, tenantID)
+
+	if val, err := c.Get(ctx, fullKey); err == nil {
+		return val, nil
+	}
+
+	val, err, _ := c.loads.Do(fullKey, func() (interface{}, error) {
+		if val, err := c.Get(ctx, fullKey); err == nil {
+			return val, nil
+		}
+
+		val, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		_ = c.Set(ctx, fullKey, val, expiration)
+		return val, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val.([]byte), nil
+}
+
+func (c *InMemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+	return nil
+}
+
+func (c *InMemoryCache) DeleteWithTenant(ctx context.Context, key string, tenantID string) error {
+	return c.Delete(ctx, c.buildKey(key, tenantID))
+}
+
+func (c *InMemoryCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	matcher, err := globToRegexp(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid cache key pattern %q: %w", pattern, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if matcher.MatchString(key) {
+			delete(c.items, key)
+		}
+	}
+	return nil
+}
+
+func (c *InMemoryCache) DeleteByPatternWithTenant(ctx context.Context, pattern, tenantID string) error {
+	return c.DeleteByPattern(ctx, c.buildKey(pattern, tenantID))
+}
+
+func (c *InMemoryCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var count int64
+	if value, ok := c.lookup(key); ok {
+		current, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value of %s is not an integer", key)
+		}
+		count = current
+	}
+	count++
+
+	c.store(key, []byte(strconv.FormatInt(count, 10)), expiration)
+	return count, nil
+}
+
+func (c *InMemoryCache) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, held := c.lookup(key); held {
+		return func() {}, false, nil
+	}
+
+	token := []byte(uuid.New().String())
+	c.store(key, token, ttl)
+
+	unlock := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// Блокировка могла истечь и перейти к другому владельцу
+		if value, ok := c.lookup(key); ok && string(value) == string(token) {
+			delete(c.items, key)
+		}
+	}
+	return unlock, true, nil
+}
+
+func (c *InMemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close останавливает фоновую очистку. Данные остаются доступными
+func (c *InMemoryCache) Close() error {
+	c.closeOnce.Do(func() { close(c.stop) })
+	return nil
+}
+
+// globToRegexp переводит glob-шаблон Redis в регулярное выражение для всего ключа:
+// * - любая последовательность, ? - один символ, [...] - класс символов, \ экранирует следующий символ
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "^") {
+				class = "^" + strings.ReplaceAll(class[1:], `\`, `\\`)
+			} else {
+				class = strings.ReplaceAll(class, `\`, `\\`)
+			}
+			b.WriteString("[" + class + "]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile("(?s)" + b.String())
+}
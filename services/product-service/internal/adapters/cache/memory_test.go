@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+)
+
+func TestInMemoryCacheExpiry(t *testing.T) {
+	port := NewInMemoryCache(10 * time.Millisecond)
+	defer port.Close()
+	memoryCache := port.(*InMemoryCache)
+
+	ctx := context.Background()
+	if err := memoryCache.Set(ctx, "short", []byte("value"), 50*time.Millisecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := memoryCache.Set(ctx, "forever", []byte("value"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if value, err := memoryCache.Get(ctx, "short"); err != nil || string(value) != "value" {
+		t.Fatalf("Get before expiry = %q, %v", value, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := memoryCache.Get(ctx, "short"); !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		t.Fatalf("Get after expiry: %v, want ErrCacheMiss", err)
+	}
+	if _, err := memoryCache.Get(ctx, "forever"); err != nil {
+		t.Fatalf("entry without expiration expired: %v", err)
+	}
+
+	// Фоновая очистка удаляет истекшие записи, даже если их никто не читает
+	if err := memoryCache.Set(ctx, "unread", []byte("value"), 20*time.Millisecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		memoryCache.mu.Lock()
+		_, stored := memoryCache.items["unread"]
+		memoryCache.mu.Unlock()
+		if !stored {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sweeper did not remove the expired entry")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInMemoryCacheReturnsCopies(t *testing.T) {
+	memoryCache := NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+
+	ctx := context.Background()
+	value := []byte("apple")
+	if err := memoryCache.Set(ctx, "key", value, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value[0] = 'A'
+
+	got, err := memoryCache.Get(ctx, "key")
+	if err != nil || string(got) != "apple" {
+		t.Fatalf("Get = %q, %v, want the stored value unaffected by the caller", got, err)
+	}
+	got[0] = 'A'
+	if again, _ := memoryCache.Get(ctx, "key"); string(again) != "apple" {
+		t.Fatalf("Get = %q after modifying a returned value", again)
+	}
+}
+
+func TestInMemoryCacheDeleteByPattern(t *testing.T) {
+	keys := []string{"product:s1:p1", "product:s1:p2", "product:s2:p1", "product:s10:p1", "category:c1", "product:s1:p1:media"}
+
+	tests := []struct {
+		pattern string
+		deleted []string
+	}{
+		{pattern: "product:*", deleted: []string{"product:s1:p1", "product:s1:p2", "product:s2:p1", "product:s10:p1", "product:s1:p1:media"}},
+		{pattern: "product:s1:*", deleted: []string{"product:s1:p1", "product:s1:p2", "product:s1:p1:media"}},
+		{pattern: "product:s?:p1", deleted: []string{"product:s1:p1", "product:s2:p1"}},
+		{pattern: "product:s[12]:p1", deleted: []string{"product:s1:p1", "product:s2:p1"}},
+		{pattern: "product:s[^1]:p1", deleted: []string{"product:s2:p1"}},
+		{pattern: "product:s1:p1", deleted: []string{"product:s1:p1"}},
+		{pattern: "*:media", deleted: []string{"product:s1:p1:media"}},
+		{pattern: "order:*", deleted: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			memoryCache := NewInMemoryCache(time.Minute)
+			defer memoryCache.Close()
+
+			ctx := context.Background()
+			for _, key := range keys {
+				if err := memoryCache.Set(ctx, key, []byte(key), time.Minute); err != nil {
+					t.Fatalf("Set: %v", err)
+				}
+			}
+
+			if err := memoryCache.DeleteByPattern(ctx, tt.pattern); err != nil {
+				t.Fatalf("DeleteByPattern: %v", err)
+			}
+
+			deleted := make(map[string]bool, len(tt.deleted))
+			for _, key := range tt.deleted {
+				deleted[key] = true
+			}
+			for _, key := range keys {
+				_, err := memoryCache.Get(ctx, key)
+				if gone := errors.Is(err, pkgerrors.ErrCacheMiss); gone != deleted[key] {
+					t.Fatalf("%s deleted = %v, want %v", key, gone, deleted[key])
+				}
+			}
+		})
+	}
+}
+
+func TestInMemoryCacheTenantIsolation(t *testing.T) {
+	memoryCache := NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+
+	ctx := context.Background()
+	for _, tenantID := range []string{"tenant-1", "tenant-2"} {
+		if err := memoryCache.SetWithTenant(ctx, "product:s1:p1", []byte(tenantID), tenantID, time.Minute); err != nil {
+			t.Fatalf("SetWithTenant: %v", err)
+		}
+	}
+
+	if value, err := memoryCache.GetWithTenant(ctx, "product:s1:p1", "tenant-2"); err != nil || string(value) != "tenant-2" {
+		t.Fatalf("GetWithTenant = %q, %v, want the value of tenant-2", value, err)
+	}
+	// Ключ тенанта совпадает с ключом RedisCache
+	if value, err := memoryCache.Get(ctx, "tenant:tenant-1:product:s1:p1"); err != nil || string(value) != "tenant-1" {
+		t.Fatalf("Get by full key = %q, %v", value, err)
+	}
+
+	if err := memoryCache.DeleteByPatternWithTenant(ctx, "product:*", "tenant-1"); err != nil {
+		t.Fatalf("DeleteByPatternWithTenant: %v", err)
+	}
+	if _, err := memoryCache.GetWithTenant(ctx, "product:s1:p1", "tenant-1"); !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		t.Fatalf("entry of tenant-1 not deleted: %v", err)
+	}
+	if _, err := memoryCache.GetWithTenant(ctx, "product:s1:p1", "tenant-2"); err != nil {
+		t.Fatalf("pattern delete of tenant-1 removed an entry of tenant-2: %v", err)
+	}
+
+	if err := memoryCache.DeleteWithTenant(ctx, "product:s1:p1", "tenant-1"); err != nil {
+		t.Fatalf("DeleteWithTenant: %v", err)
+	}
+	if _, err := memoryCache.GetWithTenant(ctx, "product:s1:p1", "tenant-2"); err != nil {
+		t.Fatalf("delete of tenant-1 removed an entry of tenant-2: %v", err)
+	}
+}
+
+func TestInMemoryCacheIncrement(t *testing.T) {
+	memoryCache := NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+
+	ctx := context.Background()
+	for want := int64(1); want <= 3; want++ {
+		if count, err := memoryCache.Increment(ctx, "counter", time.Minute); err != nil || count != want {
+			t.Fatalf("Increment = %d, %v, want %d", count, err, want)
+		}
+	}
+
+	if err := memoryCache.Set(ctx, "text", []byte("apple"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := memoryCache.Increment(ctx, "text", time.Minute); err == nil {
+		t.Fatal("Increment of a non-integer value succeeded")
+	}
+}
@@ -5,32 +5,120 @@ import (
 	"fmt"
 	"github.com/athebyme/gomarket-platform/pkg/errors"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	pkgutils "github.com/athebyme/gomarket-platform/pkg/utils"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 	"time"
 )
 
 type RedisCache struct {
 	client *redis.Client
+	codec  codec
+	// loads объединяет одновременные загрузки одного ключа в GetOrSet
+	loads singleflight.Group
+}
+
+// Значения по умолчанию для незаданных полей RedisConfig
+const (
+	defaultRedisPoolSize     = 10
+	defaultRedisMinIdleConns = 5
+	defaultRedisMaxRetries   = 3
+	defaultRedisDialTimeout  = 3 * time.Second
+	defaultRedisReadTimeout  = 2 * time.Second
+	defaultRedisWriteTimeout = 2 * time.Second
+)
+
+// RedisConfig представляет конфигурацию Redis клиента.
+// Нулевые поля заменяются значениями по умолчанию, PoolTimeout, IdleTimeout,
+// IdleCheckFreq и границы задержки повторов в этом случае выбирает go-redis
+type RedisConfig struct {
+	Host            string
+	Port            int
+	Password        string
+	DB              int
+	PoolSize        int
+	MinIdleConns    int
+	ConnectTimeout  time.Duration
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	PoolTimeout     time.Duration
+	IdleTimeout     time.Duration
+	IdleCheckFreq   time.Duration
+	MaxRetries      int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+	// CompressionAlgorithm алгоритм сжатия значений (gzip или snappy), пустая строка отключает сжатие
+	CompressionAlgorithm string
+	// CompressionThreshold минимальный размер значения в байтах, начиная с которого оно сжимается
+	CompressionThreshold int
 }
 
 func NewRedisCache(ctx context.Context, host string, port int, password string, db int) (interfaces.CachePort, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%d", host, port),
-		Password:     password,
-		DB:           db,
-		PoolSize:     10,
-		MinIdleConns: 5,
-		MaxRetries:   3,
-		DialTimeout:  3 * time.Second,
-		ReadTimeout:  2 * time.Second,
-		WriteTimeout: 2 * time.Second,
+	return NewRedisCacheWithConfig(ctx, RedisConfig{
+		Host:     host,
+		Port:     port,
+		Password: password,
+		DB:       db,
 	})
+}
+
+// NewRedisCacheWithConfig создает Redis клиент по конфигурации и проверяет соединение
+func NewRedisCacheWithConfig(ctx context.Context, cfg RedisConfig) (interfaces.CachePort, error) {
+	valueCodec, err := newCodec(cfg.CompressionAlgorithm, cfg.CompressionThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(redisOptions(cfg))
 
 	if _, err := client.Ping(ctx).Result(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisCache{client: client}, nil
+	return &RedisCache{client: client, codec: valueCodec}, nil
+}
+
+// redisOptions переносит конфигурацию в redis.Options, подставляя значения по умолчанию
+func redisOptions(cfg RedisConfig) *redis.Options {
+	opts := &redis.Options{
+		Addr:               fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Password:           cfg.Password,
+		DB:                 cfg.DB,
+		PoolSize:           cfg.PoolSize,
+		MinIdleConns:       cfg.MinIdleConns,
+		MaxRetries:         cfg.MaxRetries,
+		MinRetryBackoff:    cfg.MinRetryBackoff,
+		MaxRetryBackoff:    cfg.MaxRetryBackoff,
+		DialTimeout:        cfg.ConnectTimeout,
+		ReadTimeout:        cfg.ReadTimeout,
+		WriteTimeout:       cfg.WriteTimeout,
+		PoolTimeout:        cfg.PoolTimeout,
+		IdleTimeout:        cfg.IdleTimeout,
+		IdleCheckFrequency: cfg.IdleCheckFreq,
+	}
+
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultRedisPoolSize
+	}
+	if opts.MinIdleConns <= 0 {
+		opts.MinIdleConns = defaultRedisMinIdleConns
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultRedisMaxRetries
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultRedisDialTimeout
+	}
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = defaultRedisReadTimeout
+	}
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = defaultRedisWriteTimeout
+	}
+
+	return opts
 }
 
 func (r *RedisCache) buildKey(key, tenantID string) string {
@@ -48,21 +136,88 @@ func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 		}
 		return nil, err
 	}
-	return val, nil
+	return decode(val)
 }
 
 func (r *RedisCache) GetWithTenant(ctx context.Context, key string, tenantID string) ([]byte, error) {
 	return r.Get(ctx, r.buildKey(key, tenantID))
 }
 
+func (r *RedisCache) MGetWithTenant(ctx context.Context, keys []string, tenantID string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = r.buildKey(key, tenantID)
+	}
+
+	values, err := r.client.MGet(ctx, fullKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// MGET возвращает nil на месте отсутствующих ключей
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			decoded, err := decode([]byte(str))
+			if err != nil {
+				return nil, err
+			}
+			result[keys[i]] = decoded
+		}
+	}
+
+	return result, nil
+}
+
 func (r *RedisCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
-	return r.client.Set(ctx, key, value, expiration).Err()
+	encoded, err := r.codec.encode(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, encoded, expiration).Err()
 }
 
 func (r *RedisCache) SetWithTenant(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration) error {
 	return r.Set(ctx, r.buildKey(key, tenantID), value, expiration)
 }
 
+func (r *RedisCache) SetWithJitter(ctx context.Context, key string, value []byte, tenantID string, expiration time.Duration, jitter float64) error {
+	return r.SetWithTenant(ctx, key, value, tenantID, pkgutils.JitterTTL(expiration, jitter))
+}
+
+func (r *RedisCache) GetOrSet(ctx context.Context, key string, tenantID string, expiration time.Duration, loader func() ([]byte, error)) ([]byte, error) {
+	fullKey := r.buildKey(key, tenantID)
+
+	if val, err := r.Get(ctx, fullKey); err == nil {
+		return val, nil
+	}
+
+	// Ошибки Redis, кроме промаха, не мешают загрузке: источник данных важнее кэша
+	val, err, _ := r.loads.Do(fullKey, func() (interface{}, error) {
+		// Пока ждали своей очереди, значение мог записать другой процесс
+		if val, err := r.Get(ctx, fullKey); err == nil {
+			return val, nil
+		}
+
+		val, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		_ = r.Set(ctx, fullKey, val, expiration)
+		return val, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val.([]byte), nil
+}
+
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
@@ -71,6 +226,19 @@ func (r *RedisCache) DeleteWithTenant(ctx context.Context, key string, tenantID
 	return r.Delete(ctx, r.buildKey(key, tenantID))
 }
 
+func (r *RedisCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, expiration)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 func (r *RedisCache) DeleteByPattern(ctx context.Context, pattern string) error {
 	iter := r.client.Scan(ctx, 0, pattern, 100).Iterator()
 	var keys []string
@@ -120,6 +288,44 @@ func (r *RedisCache) DeleteByPatternWithTenant(ctx context.Context, pattern stri
 	return nil
 }
 
+// unlockScript удаляет ключ блокировки, только если в нем все еще токен владельца
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// lockReleaseTimeout ограничивает снятие блокировки, которое выполняется вне контекста запроса
+const lockReleaseTimeout = 2 * time.Second
+
+func (r *RedisCache) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token := uuid.New().String()
+	acquired, err := r.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return func() {}, false, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !acquired {
+		return func() {}, false, nil
+	}
+
+	unlock := func() {
+		// Контекст вызова к моменту снятия блокировки уже может быть отменен
+		releaseCtx, cancel := context.WithTimeout(context.Background(), lockReleaseTimeout)
+		defer cancel()
+		_ = unlockScript.Run(releaseCtx, r.client, []string{key}, token).Err()
+	}
+	return unlock, true, nil
+}
+
+// Ping проверяет соединение с Redis
+func (r *RedisCache) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", err)
+	}
+	return nil
+}
+
 func (r *RedisCache) Close() error {
 	return r.client.Close()
 }
@@ -0,0 +1,315 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// newMiniredisCache возвращает RedisCache на miniredis и сам сервер, чтобы проверять ключи напрямую
+func newMiniredisCache(t *testing.T) (interfaces.CachePort, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	return newRedisCacheAt(t, server.Addr()), server
+}
+
+func TestRedisCacheTenantKeys(t *testing.T) {
+	cache, server := newMiniredisCache(t)
+	ctx := context.Background()
+
+	if err := cache.SetWithTenant(ctx, "product:s1:p1", []byte("apple"), "tenant-1", time.Minute); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+
+	if !server.Exists("tenant:tenant-1:product:s1:p1") {
+		t.Fatalf("keys = %v, want the tenant-prefixed key", server.Keys())
+	}
+	if ttl := server.TTL("tenant:tenant-1:product:s1:p1"); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want 1m", ttl)
+	}
+
+	value, err := cache.GetWithTenant(ctx, "product:s1:p1", "tenant-1")
+	if err != nil || string(value) != "apple" {
+		t.Fatalf("GetWithTenant = %q, %v, want apple", value, err)
+	}
+	if _, err := cache.GetWithTenant(ctx, "product:s1:p1", "tenant-2"); !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		t.Fatalf("GetWithTenant for another tenant: err = %v, want ErrCacheMiss", err)
+	}
+	if _, err := cache.Get(ctx, "product:s1:p1"); !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		t.Fatalf("Get without tenant: err = %v, want ErrCacheMiss", err)
+	}
+
+	if err := cache.DeleteWithTenant(ctx, "product:s1:p1", "tenant-1"); err != nil {
+		t.Fatalf("DeleteWithTenant: %v", err)
+	}
+	if server.Exists("tenant:tenant-1:product:s1:p1") {
+		t.Fatal("key not deleted")
+	}
+}
+
+func TestRedisCacheMGetWithTenant(t *testing.T) {
+	cache, _ := newMiniredisCache(t)
+	ctx := context.Background()
+
+	values := map[string]string{"product:s1:p1": "apple", "product:s1:p2": "orange"}
+	for key, value := range values {
+		if err := cache.SetWithTenant(ctx, key, []byte(value), "tenant-1", time.Minute); err != nil {
+			t.Fatalf("SetWithTenant: %v", err)
+		}
+	}
+	if err := cache.SetWithTenant(ctx, "product:s1:p3", []byte("grape"), "tenant-2", time.Minute); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+
+	got, err := cache.MGetWithTenant(ctx, []string{"product:s1:p1", "product:s1:p2", "product:s1:p3"}, "tenant-1")
+	if err != nil {
+		t.Fatalf("MGetWithTenant: %v", err)
+	}
+	// Ключ другого тенанта - промах, его нет в результате
+	if len(got) != len(values) {
+		t.Fatalf("got %d values, want %d: %v", len(got), len(values), got)
+	}
+	for key, value := range values {
+		if string(got[key]) != value {
+			t.Fatalf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+
+	if got, err := cache.MGetWithTenant(ctx, nil, "tenant-1"); err != nil || len(got) != 0 {
+		t.Fatalf("MGetWithTenant(nil) = %v, %v, want an empty result", got, err)
+	}
+}
+
+func TestMGetWithTenantPartialHits(t *testing.T) {
+	redisCache, _ := newMiniredisCache(t)
+	memoryCache := NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+
+	caches := []struct {
+		name  string
+		cache interfaces.CachePort
+	}{
+		{name: "redis", cache: redisCache},
+		{name: "memory", cache: memoryCache},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range []string{"product:s1:p1", "product:s1:p3"} {
+				if err := tc.cache.SetWithTenant(ctx, key, []byte(key), "tenant-1", time.Minute); err != nil {
+					t.Fatalf("SetWithTenant: %v", err)
+				}
+			}
+
+			// Промахи вперемешку с попаданиями не сдвигают значения относительно ключей
+			keys := []string{"product:s1:p0", "product:s1:p1", "product:s1:p2", "product:s1:p3", "product:s1:p4"}
+			got, err := tc.cache.MGetWithTenant(ctx, keys, "tenant-1")
+			if err != nil {
+				t.Fatalf("MGetWithTenant: %v", err)
+			}
+			if len(got) != 2 || string(got["product:s1:p1"]) != "product:s1:p1" || string(got["product:s1:p3"]) != "product:s1:p3" {
+				t.Fatalf("got %q, want only p1 and p3", got)
+			}
+
+			got, err = tc.cache.MGetWithTenant(ctx, []string{"product:s1:p0", "product:s1:p4"}, "tenant-1")
+			if err != nil || len(got) != 0 {
+				t.Fatalf("MGetWithTenant of misses = %q, %v, want an empty result without error", got, err)
+			}
+		})
+	}
+}
+
+func TestRedisCacheMGetDecompresses(t *testing.T) {
+	server := miniredis.RunT(t)
+	cache, err := NewRedisCacheWithConfig(context.Background(), RedisConfig{
+		Host:                 server.Host(),
+		Port:                 mustPort(t, server),
+		CompressionAlgorithm: "gzip",
+		CompressionThreshold: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisCacheWithConfig: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	ctx := context.Background()
+
+	if err := cache.SetWithTenant(ctx, "product:s1:p1", []byte("apple juice"), "tenant-1", time.Minute); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+	if raw, _ := server.Get("tenant:tenant-1:product:s1:p1"); raw == "apple juice" {
+		t.Fatal("value stored uncompressed")
+	}
+
+	got, err := cache.MGetWithTenant(ctx, []string{"product:s1:p1"}, "tenant-1")
+	if err != nil || string(got["product:s1:p1"]) != "apple juice" {
+		t.Fatalf("MGetWithTenant = %q, %v, want the decompressed value", got["product:s1:p1"], err)
+	}
+}
+
+func TestRedisCacheDeleteByPatternWithTenant(t *testing.T) {
+	cache, server := newMiniredisCache(t)
+	ctx := context.Background()
+
+	set := func(key, tenantID string) {
+		t.Helper()
+		if err := cache.SetWithTenant(ctx, key, []byte("v"), tenantID, time.Minute); err != nil {
+			t.Fatalf("SetWithTenant: %v", err)
+		}
+	}
+
+	// Курсор SCAN в miniredis - смещение в отсортированном списке ключей, и удаление во время обхода
+	// сдвигает его, чего не бывает в Redis. Поэтому ключей меньше одной страницы SCAN
+	for i := 0; i < 50; i++ {
+		set(fmt.Sprintf("products:list:%d", i), "tenant-1")
+	}
+	set("product:s1:p1", "tenant-1")
+	set("product:s2:p1", "tenant-1")
+	set("product:s1:p2", "tenant-1")
+	set("product:s1:p1", "tenant-2")
+	set("products:list:0", "tenant-2")
+
+	// Шаблон продукта у всех поставщиков, как у инвалидации воркера
+	if err := cache.DeleteByPatternWithTenant(ctx, "product:*:p1", "tenant-1"); err != nil {
+		t.Fatalf("DeleteByPatternWithTenant: %v", err)
+	}
+	if server.Exists("tenant:tenant-1:product:s1:p1") || server.Exists("tenant:tenant-1:product:s2:p1") {
+		t.Fatal("product keys of tenant-1 not deleted")
+	}
+	if !server.Exists("tenant:tenant-1:product:s1:p2") || !server.Exists("tenant:tenant-2:product:s1:p1") {
+		t.Fatal("keys outside the pattern or tenant deleted")
+	}
+
+	if err := cache.DeleteByPatternWithTenant(ctx, "products:list:*", "tenant-1"); err != nil {
+		t.Fatalf("DeleteByPatternWithTenant: %v", err)
+	}
+	want := []string{
+		"tenant:tenant-1:product:s1:p2",
+		"tenant:tenant-2:product:s1:p1",
+		"tenant:tenant-2:products:list:0",
+	}
+	if keys := server.Keys(); fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+}
+
+func TestRedisCacheDeleteByPattern(t *testing.T) {
+	cache, server := newMiniredisCache(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"rate:a", "rate:b", "session:a"} {
+		if err := cache.Set(ctx, key, []byte("v"), time.Minute); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	if err := cache.DeleteByPattern(ctx, "rate:*"); err != nil {
+		t.Fatalf("DeleteByPattern: %v", err)
+	}
+	if keys := server.Keys(); len(keys) != 1 || keys[0] != "session:a" {
+		t.Fatalf("keys = %v, want only session:a", keys)
+	}
+}
+
+func mustPort(t *testing.T, server *miniredis.Miniredis) int {
+	t.Helper()
+
+	var port int
+	if _, err := fmt.Sscanf(server.Port(), "%d", &port); err != nil {
+		t.Fatalf("parse port %q: %v", server.Port(), err)
+	}
+	return port
+}
+
+func TestRedisCachePing(t *testing.T) {
+	cache, server := newMiniredisCache(t)
+	ctx := context.Background()
+
+	if err := cache.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	server.Close()
+	if err := cache.Ping(ctx); err == nil {
+		t.Fatal("Ping succeeded with Redis down")
+	}
+}
+
+func TestNewRedisCacheWithConfigAppliesOptions(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	cfg := RedisConfig{
+		Host:            server.Host(),
+		Port:            mustPort(t, server),
+		DB:              2,
+		PoolSize:        42,
+		MinIdleConns:    7,
+		ConnectTimeout:  4 * time.Second,
+		ReadTimeout:     500 * time.Millisecond,
+		WriteTimeout:    600 * time.Millisecond,
+		PoolTimeout:     5 * time.Second,
+		IdleTimeout:     time.Minute,
+		IdleCheckFreq:   30 * time.Second,
+		MaxRetries:      6,
+		MinRetryBackoff: 10 * time.Millisecond,
+		MaxRetryBackoff: 200 * time.Millisecond,
+	}
+	cache, err := NewRedisCacheWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewRedisCacheWithConfig: %v", err)
+	}
+	defer cache.Close()
+
+	opts := cache.(*RedisCache).client.Options()
+	if opts.Addr != server.Addr() || opts.DB != 2 || opts.PoolSize != 42 || opts.MinIdleConns != 7 ||
+		opts.DialTimeout != 4*time.Second || opts.ReadTimeout != 500*time.Millisecond || opts.WriteTimeout != 600*time.Millisecond ||
+		opts.PoolTimeout != 5*time.Second || opts.IdleTimeout != time.Minute || opts.IdleCheckFrequency != 30*time.Second ||
+		opts.MaxRetries != 6 || opts.MinRetryBackoff != 10*time.Millisecond || opts.MaxRetryBackoff != 200*time.Millisecond {
+		t.Fatalf("options = %+v, want every field of %+v applied", opts, cfg)
+	}
+}
+
+func TestRedisOptionsDefaults(t *testing.T) {
+	opts := redisOptions(RedisConfig{Host: "localhost", Port: 6379})
+
+	if opts.Addr != "localhost:6379" || opts.PoolSize != defaultRedisPoolSize || opts.MinIdleConns != defaultRedisMinIdleConns ||
+		opts.MaxRetries != defaultRedisMaxRetries || opts.DialTimeout != defaultRedisDialTimeout ||
+		opts.ReadTimeout != defaultRedisReadTimeout || opts.WriteTimeout != defaultRedisWriteTimeout {
+		t.Fatalf("options = %+v, want defaults for zero fields", opts)
+	}
+
+	// Отрицательные значения go-redis трактует как отключение, они не заменяются
+	opts = redisOptions(RedisConfig{MaxRetries: -1, ReadTimeout: -1})
+	if opts.MaxRetries != -1 || opts.ReadTimeout != -1 {
+		t.Fatalf("options = %+v, want negative values kept", opts)
+	}
+}
+
+func TestRedisCacheSetWithJitter(t *testing.T) {
+	cache, server := newMiniredisCache(t)
+	ctx := context.Background()
+
+	distinct := make(map[time.Duration]bool)
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("product:s1:p%d", i)
+		if err := cache.SetWithJitter(ctx, key, []byte("apple"), "tenant-1", 30*time.Minute, 0.1); err != nil {
+			t.Fatalf("SetWithJitter: %v", err)
+		}
+
+		ttl := server.TTL("tenant:tenant-1:" + key)
+		if ttl < 27*time.Minute || ttl > 33*time.Minute {
+			t.Fatalf("TTL = %v, want within 30m ±10%%", ttl)
+		}
+		distinct[ttl] = true
+	}
+	if len(distinct) < 20 {
+		t.Fatalf("only %d distinct TTLs, want expiry spread out", len(distinct))
+	}
+}
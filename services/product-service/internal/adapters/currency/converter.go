@@ -0,0 +1,69 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// DefaultRateTTL время жизни курса в кэше
+const DefaultRateTTL = 5 * time.Minute
+
+// CachedConverter пересчитывает суммы по курсам источника, кэшируя курсы на ttl,
+// чтобы чтение цен не обращалось к источнику курсов на каждый запрос
+type CachedConverter struct {
+	provider interfaces.RatesProviderPort
+	cache    interfaces.CachePort
+	ttl      time.Duration
+}
+
+var _ interfaces.CurrencyConverterPort = (*CachedConverter)(nil)
+
+// NewCachedConverter создает конвертер валют поверх источника курсов provider
+func NewCachedConverter(provider interfaces.RatesProviderPort, cache interfaces.CachePort, ttl time.Duration) *CachedConverter {
+	if ttl <= 0 {
+		ttl = DefaultRateTTL
+	}
+	return &CachedConverter{
+		provider: provider,
+		cache:    cache,
+		ttl:      ttl,
+	}
+}
+
+// Convert пересчитывает amount из from в to, результат округляется до копеек (центов)
+func (c *CachedConverter) Convert(ctx context.Context, amount float64, from, to string) (float64, *interfaces.ExchangeRate, error) {
+	rate, err := c.rate(ctx, from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+	return math.Round(amount*rate.Rate*100) / 100, rate, nil
+}
+
+// rate возвращает курс из кэша или источника. Курсы общие для всех тенантов.
+// Ошибки кэша не мешают пересчету: курс запрашивается у источника напрямую
+func (c *CachedConverter) rate(ctx context.Context, from, to string) (*interfaces.ExchangeRate, error) {
+	key := fmt.Sprintf("currency:rate:%s:%s", from, to)
+
+	if data, err := c.cache.Get(ctx, key); err == nil {
+		var rate interfaces.ExchangeRate
+		if err := json.Unmarshal(data, &rate); err == nil {
+			return &rate, nil
+		}
+	}
+
+	rate, err := c.provider.GetRate(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate %s/%s: %w", from, to, err)
+	}
+
+	if data, err := json.Marshal(rate); err == nil {
+		_ = c.cache.Set(ctx, key, data, c.ttl)
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,70 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+)
+
+// countingProvider источник курсов, считающий обращения
+type countingProvider struct {
+	interfaces.RatesProviderPort
+	calls int
+}
+
+func (p *countingProvider) GetRate(ctx context.Context, from, to string) (*interfaces.ExchangeRate, error) {
+	p.calls++
+	return p.RatesProviderPort.GetRate(ctx, from, to)
+}
+
+func TestCachedConverter(t *testing.T) {
+	static, err := NewStaticRatesProvider("RUB", map[string]float64{"usd": 0.011, "EUR": 0.01})
+	if err != nil {
+		t.Fatalf("NewStaticRatesProvider: %v", err)
+	}
+	provider := &countingProvider{RatesProviderPort: static}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+	converter := NewCachedConverter(provider, memoryCache, time.Minute)
+
+	ctx := context.Background()
+	amount, rate, err := converter.Convert(ctx, 1999, "RUB", "USD")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	// 1999 * 0.011 = 21.989, округляется до центов
+	if amount != 21.99 || rate.Rate != 0.011 || rate.From != "RUB" || rate.To != "USD" {
+		t.Fatalf("amount = %v, rate = %+v, want 21.99 at 0.011", amount, rate)
+	}
+
+	// Повторный пересчет использует курс из кэша
+	if amount, _, err := converter.Convert(ctx, 100, "RUB", "USD"); err != nil || amount != 1.1 {
+		t.Fatalf("Convert = %v, %v, want 1.1", amount, err)
+	}
+	if provider.calls != 1 {
+		t.Fatalf("provider calls = %d, want the rate cached after the first call", provider.calls)
+	}
+
+	// Курс между небазовыми валютами вычисляется через базовую
+	if amount, rate, err := converter.Convert(ctx, 100, "EUR", "USD"); err != nil || amount != 110 || math.Abs(rate.Rate-1.1) > 1e-9 {
+		t.Fatalf("Convert EUR/USD = %v, %+v, %v, want 110", amount, rate, err)
+	}
+
+	if _, _, err := converter.Convert(ctx, 100, "RUB", "XYZ"); !errors.Is(err, pkgerrors.ErrRateNotFound) {
+		t.Fatalf("Convert to unknown currency: %v, want ErrRateNotFound", err)
+	}
+}
+
+func TestStaticRatesProviderRejectsNonPositiveRates(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		if _, err := NewStaticRatesProvider("RUB", map[string]float64{"USD": rate}); err == nil {
+			t.Fatalf("rate %v accepted", rate)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package currency
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// StaticRatesProvider отдает фиксированные курсы, заданные относительно базовой валюты.
+// Используется, пока не подключен внешний источник курсов, и в тестах
+type StaticRatesProvider struct {
+	base string
+	// rates число единиц валюты за одну единицу базовой
+	rates     map[string]float64
+	updatedAt time.Time
+}
+
+var _ interfaces.RatesProviderPort = (*StaticRatesProvider)(nil)
+
+// NewStaticRatesProvider создает источник фиксированных курсов. rates задает, сколько единиц валюты
+// стоит одна единица base; курс между двумя небазовыми валютами вычисляется через базовую
+func NewStaticRatesProvider(base string, rates map[string]float64) (*StaticRatesProvider, error) {
+	base = strings.ToUpper(base)
+	normalized := make(map[string]float64, len(rates)+1)
+	for code, rate := range rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate of %s must be positive", code)
+		}
+		normalized[strings.ToUpper(code)] = rate
+	}
+	normalized[base] = 1
+
+	return &StaticRatesProvider{
+		base:      base,
+		rates:     normalized,
+		updatedAt: time.Now().UTC(),
+	}, nil
+}
+
+func (p *StaticRatesProvider) GetRate(ctx context.Context, from, to string) (*interfaces.ExchangeRate, error) {
+	fromRate, ok := p.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errors.ErrRateNotFound, from)
+	}
+	toRate, ok := p.rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errors.ErrRateNotFound, to)
+	}
+
+	return &interfaces.ExchangeRate{
+		From:      from,
+		To:        to,
+		Rate:      toRate / fromRate,
+		Timestamp: p.updatedAt,
+	}, nil
+}
@@ -2,7 +2,9 @@ package logger
 
 import (
 	"context"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
@@ -10,28 +12,38 @@ import (
 )
 
 var (
-	instance *ZapLogger
-	once     sync.Once
+	defaultLogger *ZapLogger
+	defaultOnce   sync.Once
 )
 
 // ZapLogger адаптер для Zap, реализующий LoggerPort
 type ZapLogger struct {
 	logger *zap.SugaredLogger
+	// level общий для логгера и всех производных от него, позволяет менять уровень без перезапуска
+	level zap.AtomicLevel
 }
 
-// NewZapLogger создает новый логгер на основе Zap
+// NewZapLogger создает новый независимый логгер на основе Zap с указанными уровнем и режимом
 func NewZapLogger(level string, isProduction bool) (interfaces.LoggerPort, error) {
-	var err error
-	once.Do(func() {
-		instance = &ZapLogger{}
-		err = instance.init(level, isProduction)
-	})
-
-	if err != nil {
+	l := &ZapLogger{}
+	if err := l.init(level, isProduction); err != nil {
 		return nil, err
 	}
 
-	return instance, nil
+	return l, nil
+}
+
+// Default возвращает общий логгер пакета уровня info, создаваемый при первом обращении.
+// Нужен только там, где логгер нельзя передать явно; компоненты должны получать свой логгер из NewZapLogger
+func Default() interfaces.LoggerPort {
+	defaultOnce.Do(func() {
+		defaultLogger = &ZapLogger{}
+		if err := defaultLogger.init("info", false); err != nil {
+			defaultLogger = &ZapLogger{logger: zap.NewNop().Sugar(), level: zap.NewAtomicLevel()}
+		}
+	})
+
+	return defaultLogger
 }
 
 // init инициализирует логгер
@@ -55,7 +67,8 @@ func (z *ZapLogger) init(levelStr string, isProduction bool) error {
 	if err := level.UnmarshalText([]byte(levelStr)); err != nil {
 		level = zapcore.InfoLevel
 	}
-	config.Level = zap.NewAtomicLevelAt(level)
+	z.level = zap.NewAtomicLevelAt(level)
+	config.Level = z.level
 
 	// Настройка вывода
 	config.OutputPaths = []string{"stdout"}
@@ -108,20 +121,28 @@ func (z *ZapLogger) extractFieldsFromContext(ctx context.Context) []interface{}
 	var fields []interface{}
 
 	// Пример: добавление request_id, если оно есть в контексте
-	if reqID, ok := ctx.Value("request_id").(string); ok {
+	if reqID, ok := contextkeys.RequestIDFromContext(ctx); ok {
 		fields = append(fields, zap.String("request_id", reqID))
 	}
 
 	// Пример: добавление tenant_id, если оно есть в контексте
-	if tenantID, ok := ctx.Value("tenant_id").(string); ok {
+	if tenantID, ok := contextkeys.TenantFromContext(ctx); ok {
 		fields = append(fields, zap.String("tenant_id", tenantID))
 	}
 
 	// Пример: добавление user_id, если оно есть в контексте
-	if userID, ok := ctx.Value("user_id").(string); ok {
+	if userID, ok := contextkeys.UserFromContext(ctx); ok {
 		fields = append(fields, zap.String("user_id", userID))
 	}
 
+	// Идентификаторы активного спана OpenTelemetry
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", spanCtx.TraceID().String()),
+			zap.String("span_id", spanCtx.SpanID().String()),
+		)
+	}
+
 	return fields
 }
 
@@ -195,7 +216,7 @@ func (z *ZapLogger) PanicWithContext(ctx context.Context, msg string, args ...in
 
 // WithFields реализация интерфейса LoggerPort
 func (z *ZapLogger) WithFields(fields ...interfaces.LogField) interfaces.LoggerPort {
-	newLogger := &ZapLogger{}
+	newLogger := &ZapLogger{level: z.level}
 	zapFields := make([]interface{}, 0, len(fields)*2)
 	for _, field := range fields {
 		zapFields = append(zapFields, field.Key, field.Value)
@@ -206,7 +227,7 @@ func (z *ZapLogger) WithFields(fields ...interfaces.LogField) interfaces.LoggerP
 
 // WithField реализация интерфейса LoggerPort
 func (z *ZapLogger) WithField(key string, value interface{}) interfaces.LoggerPort {
-	newLogger := &ZapLogger{}
+	newLogger := &ZapLogger{level: z.level}
 	newLogger.logger = z.logger.With(key, value)
 	return newLogger
 }
@@ -221,34 +242,46 @@ func (z *ZapLogger) WithTraceID(traceID string) interfaces.LoggerPort {
 	return z.WithField("trace_id", traceID)
 }
 
-// SetLevel реализация интерфейса LoggerPort
+// SetLevel реализация интерфейса LoggerPort.
+// Уровень меняется атомарно и сразу действует на логгер и все производные от него
 func (z *ZapLogger) SetLevel(level interfaces.LogLevel) {
-	var _ zapcore.Level
+	var zapLevel zapcore.Level
 	switch level {
 	case interfaces.DebugLevel:
-		_ = zapcore.DebugLevel
+		zapLevel = zapcore.DebugLevel
 	case interfaces.InfoLevel:
-		_ = zapcore.InfoLevel
+		zapLevel = zapcore.InfoLevel
 	case interfaces.WarnLevel:
-		_ = zapcore.WarnLevel
+		zapLevel = zapcore.WarnLevel
 	case interfaces.ErrorLevel:
-		_ = zapcore.ErrorLevel
+		zapLevel = zapcore.ErrorLevel
 	case interfaces.FatalLevel:
-		_ = zapcore.FatalLevel
+		zapLevel = zapcore.FatalLevel
 	case interfaces.PanicLevel:
-		_ = zapcore.PanicLevel
+		zapLevel = zapcore.PanicLevel
 	default:
-		_ = zapcore.InfoLevel
+		zapLevel = zapcore.InfoLevel
 	}
 
-	// Предполагается, что у logger есть встроенный level
-	// Это упрощение, в реальном коде необходимо получить атомический уровень из логгера и установить его
+	z.level.SetLevel(zapLevel)
 }
 
 // GetLevel реализация интерфейса LoggerPort
 func (z *ZapLogger) GetLevel() interfaces.LogLevel {
-	// Упрощение, в реальном коде необходимо получить атомический уровень из логгера
-	return interfaces.InfoLevel
+	switch z.level.Level() {
+	case zapcore.DebugLevel:
+		return interfaces.DebugLevel
+	case zapcore.WarnLevel:
+		return interfaces.WarnLevel
+	case zapcore.ErrorLevel:
+		return interfaces.ErrorLevel
+	case zapcore.FatalLevel:
+		return interfaces.FatalLevel
+	case zapcore.PanicLevel, zapcore.DPanicLevel:
+		return interfaces.PanicLevel
+	default:
+		return interfaces.InfoLevel
+	}
 }
 
 // Flush реализация интерфейса LoggerPort
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureStdout подменяет os.Stdout файлом до создания логгера: zap открывает "stdout" при сборке.
+// Возвращает функцию, читающую все, что записано в файл к моменту вызова
+func captureStdout(t *testing.T) func() string {
+	t.Helper()
+
+	file, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = file
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		file.Close()
+	})
+
+	return func() string {
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestZapLoggerSetLevel(t *testing.T) {
+	output := captureStdout(t)
+	log, err := NewZapLogger("info", true)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	derived := log.WithField("component", "worker")
+
+	log.Debug("hidden debug")
+	if log.GetLevel() != interfaces.InfoLevel || strings.Contains(output(), "hidden debug") {
+		t.Fatalf("level = %v, output = %q, want debug suppressed at info", log.GetLevel(), output())
+	}
+
+	log.SetLevel(interfaces.DebugLevel)
+	log.Debug("visible debug")
+	// Производные логгеры разделяют уровень с исходным
+	derived.Debug("derived debug")
+
+	if log.GetLevel() != interfaces.DebugLevel {
+		t.Fatalf("level = %v, want debug", log.GetLevel())
+	}
+	if out := output(); !strings.Contains(out, "visible debug") || !strings.Contains(out, "derived debug") {
+		t.Fatalf("output = %q, want debug messages after SetLevel", out)
+	}
+
+	log.SetLevel(interfaces.ErrorLevel)
+	log.Warn("hidden warning")
+	if log.GetLevel() != interfaces.ErrorLevel || strings.Contains(output(), "hidden warning") {
+		t.Fatalf("level = %v, output = %q, want warnings suppressed at error", log.GetLevel(), output())
+	}
+}
+
+func TestNewZapLoggerIndependentInstances(t *testing.T) {
+	output := captureStdout(t)
+	debugLog, err := NewZapLogger("debug", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	errorLog, err := NewZapLogger("error", true)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	if debugLog.GetLevel() != interfaces.DebugLevel || errorLog.GetLevel() != interfaces.ErrorLevel {
+		t.Fatalf("levels = %v, %v, want each logger at its own level", debugLog.GetLevel(), errorLog.GetLevel())
+	}
+
+	debugLog.Debug("debug from api")
+	errorLog.Info("info from worker")
+	errorLog.Error("error from worker")
+
+	out := output()
+	if !strings.Contains(out, "debug from api") || strings.Contains(out, "info from worker") || !strings.Contains(out, "error from worker") {
+		t.Fatalf("output = %q, want each logger filtered by its own level", out)
+	}
+	// Production-логгер пишет JSON, development - консольный формат
+	if !strings.Contains(out, `"msg":"error from worker"`) || strings.Contains(out, `"msg":"debug from api"`) {
+		t.Fatalf("output = %q, want JSON only from the production logger", out)
+	}
+
+	// Смена уровня одного логгера не затрагивает другой
+	errorLog.SetLevel(interfaces.InfoLevel)
+	if debugLog.GetLevel() != interfaces.DebugLevel {
+		t.Fatalf("level = %v after changing another logger, want debug", debugLog.GetLevel())
+	}
+}
+
+func TestZapLoggerSpanFields(t *testing.T) {
+	output := captureStdout(t)
+	log, err := NewZapLogger("info", true)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	log.InfoWithContext(ctx, "inside span")
+	log.InfoWithContext(context.Background(), "outside span")
+
+	lines := strings.Split(strings.TrimSpace(output()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output = %q, want two lines", output())
+	}
+	if !strings.Contains(lines[0], `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) || !strings.Contains(lines[0], `"span_id":"00f067aa0ba902b7"`) {
+		t.Fatalf("line = %q, want the span identifiers", lines[0])
+	}
+	if strings.Contains(lines[1], "trace_id") {
+		t.Fatalf("line = %q, want no trace fields without a span", lines[1])
+	}
+}
+
+func TestZapLoggerContextFields(t *testing.T) {
+	output := captureStdout(t)
+	log, err := NewZapLogger("info", true)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	ctx := contextkeys.WithRequestID(context.Background(), "request-1")
+	ctx = contextkeys.WithTenant(ctx, "tenant-1")
+	ctx = contextkeys.WithUser(ctx, "user-1")
+	log.InfoWithContext(ctx, "with keys")
+	// Строковый ключ другого пакета не попадает в лог
+	log.InfoWithContext(context.WithValue(context.Background(), "tenant_id", "foreign"), "with string key")
+
+	lines := strings.Split(strings.TrimSpace(output()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output = %q, want two lines", output())
+	}
+	for _, field := range []string{`"request_id":"request-1"`, `"tenant_id":"tenant-1"`, `"user_id":"user-1"`} {
+		if !strings.Contains(lines[0], field) {
+			t.Fatalf("line = %q, want %s", lines[0], field)
+		}
+	}
+	if strings.Contains(lines[1], "tenant_id") {
+		t.Fatalf("line = %q, want no tenant from a string key", lines[1])
+	}
+}
@@ -0,0 +1,89 @@
+package marketplace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	pkgmodels "github.com/athebyme/gomarket-platform/pkg/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MarketplacePort отправляет продукты во внешний маркетплейс
+type MarketplacePort interface {
+	// PushProduct публикует продукт в маркетплейсе и возвращает его статус на стороне маркетплейса.
+	// base_data продукта уже приведен к полям маркетплейса
+	PushProduct(ctx context.Context, product *models.Product, marketplaceID int) (*pkgmodels.MarketplaceProduct, error)
+}
+
+// HTTPMarketplace отправляет продукты в API маркетплейсов по HTTP
+type HTTPMarketplace struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ MarketplacePort = (*HTTPMarketplace)(nil)
+
+// NewHTTPMarketplace создает HTTP-клиент API маркетплейсов
+func NewHTTPMarketplace(baseURL string, timeout time.Duration) *HTTPMarketplace {
+	return &HTTPMarketplace{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// pushResponse ответ маркетплейса на публикацию продукта
+type pushResponse struct {
+	ExternalID    string `json:"external_id"`
+	Status        string `json:"status"`
+	StatusMessage string `json:"status_message"`
+}
+
+// PushProduct выполняет POST {baseURL}/marketplaces/{marketplaceID}/products
+func (m *HTTPMarketplace) PushProduct(ctx context.Context, product *models.Product, marketplaceID int) (*pkgmodels.MarketplaceProduct, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"product_id":  product.ID,
+		"supplier_id": product.SupplierID,
+		"data":        product.BaseData,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal marketplace request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/marketplaces/%d/products", m.baseURL, marketplaceID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build marketplace request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to push product to marketplace: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("marketplace push failed with status %d", resp.StatusCode)
+	}
+
+	var result pushResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode marketplace response: %w", err)
+	}
+	if result.Status == "" {
+		result.Status = models.MarketplaceStatusPending
+	}
+
+	return &pkgmodels.MarketplaceProduct{
+		MarketplaceID: marketplaceID,
+		CoreProductID: product.ID,
+		ExternalID:    result.ExternalID,
+		Status:        result.Status,
+		StatusMessage: result.StatusMessage,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package messaging
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+const (
+	// defaultMaxRetries число попыток обработки сообщения по умолчанию
+	defaultMaxRetries = 3
+	// defaultRetryBackoff базовая задержка между попытками по умолчанию
+	defaultRetryBackoff = 100 * time.Millisecond
+	// maxRetryBackoff верхняя граница задержки между попытками
+	maxRetryBackoff = 30 * time.Second
+	// retryJitterFraction доля задержки, на которую она случайно увеличивается
+	retryJitterFraction = 0.2
+)
+
+// subscriptionPolicy итоговые настройки повторов и обработки ошибок подписки
+type subscriptionPolicy struct {
+	maxRetries   int
+	retryBackoff time.Duration
+	onFailure    interfaces.FailurePolicy
+}
+
+// resolvePolicy дополняет необязательную конфигурацию подписки настройками клиента
+func (k *KafkaMessaging) resolvePolicy(config []interfaces.SubscriptionConfig) (subscriptionPolicy, error) {
+	policy := subscriptionPolicy{
+		maxRetries:   k.maxRetries,
+		retryBackoff: k.retryBackoff,
+		onFailure:    interfaces.FailureDeadLetter,
+	}
+	if len(config) == 0 {
+		return policy, nil
+	}
+
+	c := config[0]
+	if c.MaxRetries > 0 {
+		policy.maxRetries = c.MaxRetries
+	}
+	if c.RetryBackoff > 0 {
+		policy.retryBackoff = c.RetryBackoff
+	}
+	switch c.OnFailure {
+	case "":
+	case interfaces.FailureDeadLetter, interfaces.FailureDrop:
+		policy.onFailure = c.OnFailure
+	default:
+		return policy, fmt.Errorf("неизвестная политика обработки ошибок: %s", c.OnFailure)
+	}
+
+	return policy, nil
+}
+
+// retryBackoff возвращает задержку перед повтором с номером attempt (с нуля): base * 2^attempt,
+// ограниченную maxRetryBackoff
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	backoff := base
+	for i := 0; i < attempt; i++ {
+		backoff <<= 1
+		if backoff >= maxRetryBackoff || backoff <= 0 {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
+}
+
+// withJitter добавляет к задержке случайную составляющую до retryJitterFraction,
+// чтобы consumer'ы не повторяли попытки одновременно
+func withJitter(backoff time.Duration) time.Duration {
+	jitter := time.Duration(rand.Int63n(int64(float64(backoff)*retryJitterFraction) + 1))
+	return backoff + jitter
+}
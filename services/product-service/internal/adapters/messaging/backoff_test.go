@@ -0,0 +1,96 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first attempt", base: 100 * time.Millisecond, attempt: 0, want: 100 * time.Millisecond},
+		{name: "second attempt", base: 100 * time.Millisecond, attempt: 1, want: 200 * time.Millisecond},
+		{name: "third attempt", base: 100 * time.Millisecond, attempt: 2, want: 400 * time.Millisecond},
+		{name: "fifth attempt", base: 100 * time.Millisecond, attempt: 4, want: 1600 * time.Millisecond},
+		{name: "capped", base: 10 * time.Second, attempt: 2, want: maxRetryBackoff},
+		{name: "exactly at cap", base: 15 * time.Second, attempt: 1, want: maxRetryBackoff},
+		{name: "overflow", base: 100 * time.Millisecond, attempt: 100, want: maxRetryBackoff},
+		{name: "default base", base: 0, attempt: 1, want: 2 * defaultRetryBackoff},
+		{name: "negative attempt", base: 100 * time.Millisecond, attempt: -1, want: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryBackoff(tt.base, tt.attempt); got != tt.want {
+				t.Fatalf("retryBackoff(%s, %d) = %s, want %s", tt.base, tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoffGrowsMonotonically(t *testing.T) {
+	previous := time.Duration(0)
+	for attempt := 0; attempt < 20; attempt++ {
+		backoff := retryBackoff(defaultRetryBackoff, attempt)
+		if backoff < previous || backoff > maxRetryBackoff {
+			t.Fatalf("attempt %d: backoff = %s after %s, cap %s", attempt, backoff, previous, maxRetryBackoff)
+		}
+		previous = backoff
+	}
+	if previous != maxRetryBackoff {
+		t.Fatalf("backoff after 20 attempts = %s, want cap %s", previous, maxRetryBackoff)
+	}
+}
+
+func TestWithJitterBounds(t *testing.T) {
+	for _, backoff := range []time.Duration{0, time.Nanosecond, defaultRetryBackoff, maxRetryBackoff} {
+		upper := backoff + time.Duration(float64(backoff)*retryJitterFraction)
+		for i := 0; i < 1000; i++ {
+			got := withJitter(backoff)
+			if got < backoff || got > upper {
+				t.Fatalf("withJitter(%s) = %s, want within [%s, %s]", backoff, got, backoff, upper)
+			}
+		}
+	}
+}
+
+func TestResolvePolicy(t *testing.T) {
+	k := &KafkaMessaging{maxRetries: 3, retryBackoff: 100 * time.Millisecond}
+
+	tests := []struct {
+		name    string
+		config  []interfaces.SubscriptionConfig
+		want    subscriptionPolicy
+		wantErr bool
+	}{
+		{name: "defaults", want: subscriptionPolicy{maxRetries: 3, retryBackoff: 100 * time.Millisecond, onFailure: interfaces.FailureDeadLetter}},
+		{name: "zero values keep defaults", config: []interfaces.SubscriptionConfig{{}},
+			want: subscriptionPolicy{maxRetries: 3, retryBackoff: 100 * time.Millisecond, onFailure: interfaces.FailureDeadLetter}},
+		{name: "events drop", config: []interfaces.SubscriptionConfig{{MaxRetries: 1, RetryBackoff: time.Second, OnFailure: interfaces.FailureDrop}},
+			want: subscriptionPolicy{maxRetries: 1, retryBackoff: time.Second, onFailure: interfaces.FailureDrop}},
+		{name: "commands dlq", config: []interfaces.SubscriptionConfig{{MaxRetries: 5, OnFailure: interfaces.FailureDeadLetter}},
+			want: subscriptionPolicy{maxRetries: 5, retryBackoff: 100 * time.Millisecond, onFailure: interfaces.FailureDeadLetter}},
+		{name: "unknown policy", config: []interfaces.SubscriptionConfig{{OnFailure: "retry-forever"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k.resolvePolicy(tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("resolvePolicy accepted an unknown failure policy")
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("resolvePolicy = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
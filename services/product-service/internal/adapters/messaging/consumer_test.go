@@ -0,0 +1,214 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// recordingConsumer запоминает зафиксированные смещения и перемотки вместо обращения к Kafka
+type recordingConsumer struct {
+	commits []kafka.Offset
+	seeks   []kafka.Offset
+}
+
+func (c *recordingConsumer) CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error) {
+	c.commits = append(c.commits, m.TopicPartition.Offset)
+	return []kafka.TopicPartition{m.TopicPartition}, nil
+}
+
+func (c *recordingConsumer) StoreOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	return offsets, nil
+}
+
+func (c *recordingConsumer) Seek(partition kafka.TopicPartition, timeoutMs int) error {
+	c.seeks = append(c.seeks, partition.Offset)
+	return nil
+}
+
+// newTestKafkaMessaging создает клиент без подключения к брокерам: его handleMessage
+// работает с переданным consumer'ом, а DLQ отключена
+func newTestKafkaMessaging(t *testing.T, dedup interfaces.CachePort) *KafkaMessaging {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	return &KafkaMessaging{
+		groupID:        "product-service",
+		logger:         log,
+		dedup:          dedup,
+		dedupTTL:       time.Hour,
+		consumerConfig: interfaces.ConsumerConfig{AutoCommit: false},
+	}
+}
+
+func testKafkaMessage(messageID string, offset kafka.Offset) *kafka.Message {
+	topic := "product-events"
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 0, Offset: offset},
+		Value:          []byte(`{}`),
+		Headers:        []kafka.Header{{Key: "message_id", Value: []byte(messageID)}},
+	}
+}
+
+func TestHandleMessageSkipsDuplicates(t *testing.T) {
+	dedup := cache.NewInMemoryCache(time.Minute)
+	defer dedup.Close()
+
+	k := newTestKafkaMessaging(t, dedup)
+	consumer := &recordingConsumer{}
+	policy := subscriptionPolicy{maxRetries: 3, retryBackoff: time.Millisecond}
+
+	calls := 0
+	handler := func(ctx context.Context, msg *interfaces.Message) error {
+		calls++
+		return nil
+	}
+
+	// Сообщение доставлено повторно после ребалансировки, уже с другим смещением
+	k.handleMessage(context.Background(), consumer, handler, testKafkaMessage("message-1", 10), policy)
+	k.handleMessage(context.Background(), consumer, handler, testKafkaMessage("message-1", 11), policy)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want once", calls)
+	}
+	// Смещение дубликата тоже фиксируется, чтобы он не приходил снова
+	if len(consumer.commits) != 2 {
+		t.Fatalf("commits = %v, want both offsets committed", consumer.commits)
+	}
+
+	k.handleMessage(context.Background(), consumer, handler, testKafkaMessage("message-2", 12), policy)
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want another message processed", calls)
+	}
+}
+
+func TestHandleMessageWithoutDedup(t *testing.T) {
+	k := newTestKafkaMessaging(t, nil)
+	consumer := &recordingConsumer{}
+	policy := subscriptionPolicy{maxRetries: 1, retryBackoff: time.Millisecond}
+
+	calls := 0
+	handler := func(ctx context.Context, msg *interfaces.Message) error {
+		calls++
+		return nil
+	}
+
+	k.handleMessage(context.Background(), consumer, handler, testKafkaMessage("message-1", 10), policy)
+	k.handleMessage(context.Background(), consumer, handler, testKafkaMessage("message-1", 10), policy)
+
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want every delivery processed without a dedup store", calls)
+	}
+}
+
+func TestHandleMessageErrorPreventsCommit(t *testing.T) {
+	k := newTestKafkaMessaging(t, nil)
+	consumer := &recordingConsumer{}
+	policy := subscriptionPolicy{maxRetries: 2, retryBackoff: time.Millisecond}
+
+	attempts := 0
+	failing := func(ctx context.Context, msg *interfaces.Message) error {
+		attempts++
+		return errors.New("database is unavailable")
+	}
+
+	if !k.handleMessage(context.Background(), consumer, failing, testKafkaMessage("message-1", 10), policy) {
+		t.Fatal("consumer stopped after a handler error")
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+	// Без DLQ смещение не фиксируется, а partition перематывается к сообщению для повторной доставки
+	if len(consumer.commits) != 0 {
+		t.Fatalf("commits = %v, want none after a handler error", consumer.commits)
+	}
+	if len(consumer.seeks) != 1 || consumer.seeks[0] != 10 {
+		t.Fatalf("seeks = %v, want a seek back to offset 10", consumer.seeks)
+	}
+
+	// Повторно доставленное сообщение обработано, и только теперь смещение фиксируется
+	ok := func(ctx context.Context, msg *interfaces.Message) error { return nil }
+	k.handleMessage(context.Background(), consumer, ok, testKafkaMessage("message-1", 10), policy)
+	if len(consumer.commits) != 1 || consumer.commits[0] != 10 {
+		t.Fatalf("commits = %v, want offset 10 committed after success", consumer.commits)
+	}
+}
+
+func TestHandleMessageAutoCommitDoesNotCommitManually(t *testing.T) {
+	k := newTestKafkaMessaging(t, nil)
+	k.consumerConfig.AutoCommit = true
+	consumer := &recordingConsumer{}
+	policy := subscriptionPolicy{maxRetries: 1, retryBackoff: time.Millisecond}
+
+	failing := func(ctx context.Context, msg *interfaces.Message) error { return errors.New("failed") }
+	k.handleMessage(context.Background(), consumer, failing, testKafkaMessage("message-1", 10), policy)
+
+	if len(consumer.commits) != 0 || len(consumer.seeks) != 0 {
+		t.Fatalf("commits = %v, seeks = %v, want offsets left to auto-commit", consumer.commits, consumer.seeks)
+	}
+}
+
+func TestHandleMessageFailurePolicies(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	defer cluster.Close()
+
+	failing := func(ctx context.Context, msg *interfaces.Message) error { return errors.New("invalid payload") }
+
+	t.Run("drop", func(t *testing.T) {
+		k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+		k.deadLetterTopic = "drop-dlq"
+		consumer := &recordingConsumer{}
+		policy := subscriptionPolicy{maxRetries: 2, retryBackoff: time.Millisecond, onFailure: interfaces.FailureDrop}
+
+		if !k.handleMessage(context.Background(), consumer, failing, testKafkaMessage("message-1", 10), policy) {
+			t.Fatal("consumer stopped after a dropped message")
+		}
+		// Сообщение пропущено: смещение зафиксировано без перемотки и без DLQ
+		if len(consumer.commits) != 1 || consumer.commits[0] != 10 || len(consumer.seeks) != 0 {
+			t.Fatalf("commits = %v, seeks = %v, want offset 10 committed", consumer.commits, consumer.seeks)
+		}
+		// PublishSync синхронный: отправленное в DLQ уже было бы в топике
+		if _, high, err := k.producer.QueryWatermarkOffsets(k.deadLetterTopic, 0, 5000); err == nil && high != 0 {
+			t.Fatalf("dlq has %d messages, want a dropped message not dead-lettered", high)
+		}
+	})
+
+	t.Run("dlq", func(t *testing.T) {
+		k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+		k.deadLetterTopic = "commands-dlq"
+		consumer := &recordingConsumer{}
+		policy := subscriptionPolicy{maxRetries: 2, retryBackoff: time.Millisecond, onFailure: interfaces.FailureDeadLetter}
+
+		if !k.handleMessage(context.Background(), consumer, failing, testKafkaMessage("message-1", 10), policy) {
+			t.Fatal("consumer stopped after a dead-lettered message")
+		}
+		if len(consumer.commits) != 1 || consumer.commits[0] != 10 {
+			t.Fatalf("commits = %v, want offset 10 committed once the message is in the DLQ", consumer.commits)
+		}
+
+		letters := readMessages(t, cluster.BootstrapServers(), k.deadLetterTopic, 1)
+		if len(letters) != 1 {
+			t.Fatalf("dlq has %d messages, want 1", len(letters))
+		}
+		var letter DeadLetter
+		if err := json.Unmarshal(letters[0].Value, &letter); err != nil {
+			t.Fatalf("unmarshal dead letter: %v", err)
+		}
+		if letter.OriginalMessage.ID != "message-1" || letter.Error != "invalid payload" || letter.RetryCount != 2 {
+			t.Fatalf("dead letter = %+v, want message-1 after 2 attempts", letter)
+		}
+	})
+}
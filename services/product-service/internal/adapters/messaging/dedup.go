@@ -0,0 +1,44 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// defaultDedupTTL сколько хранится отметка об обработанном сообщении по умолчанию
+const defaultDedupTTL = 24 * time.Hour
+
+// processedMessageKey ключ отметки об успешной обработке сообщения группой consumer'ов.
+// Группа входит в ключ, чтобы разные сервисы, читающие один топик, не пропускали сообщения друг друга
+func processedMessageKey(groupID, topic, messageID string) string {
+	return fmt.Sprintf("kafka:processed:%s:%s:%s", groupID, topic, messageID)
+}
+
+// alreadyProcessed сообщает, было ли сообщение уже успешно обработано.
+// Сообщения без message_id и ошибки хранилища не считаются дубликатами: лучше обработать повторно, чем потерять
+func (k *KafkaMessaging) alreadyProcessed(ctx context.Context, msg *interfaces.Message) bool {
+	if k.dedup == nil || msg.ID == "" {
+		return false
+	}
+
+	_, err := k.dedup.Get(ctx, processedMessageKey(k.groupID, msg.Topic, msg.ID))
+	return err == nil
+}
+
+// markProcessed отмечает сообщение обработанным на dedupTTL
+func (k *KafkaMessaging) markProcessed(ctx context.Context, msg *interfaces.Message) {
+	if k.dedup == nil || msg.ID == "" {
+		return
+	}
+
+	if err := k.dedup.Set(ctx, processedMessageKey(k.groupID, msg.Topic, msg.ID), []byte("1"), k.dedupTTL); err != nil {
+		k.logger.WarnWithContext(ctx, "Не удалось отметить сообщение обработанным",
+			interfaces.LogField{Key: "topic", Value: msg.Topic},
+			interfaces.LogField{Key: "message_id", Value: msg.ID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+	}
+}
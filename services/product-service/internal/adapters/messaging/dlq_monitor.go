@@ -0,0 +1,70 @@
+package messaging
+
+import (
+	"sync"
+	"time"
+)
+
+// DLQMonitor считает сообщения, попавшие в Dead Letter Queue, в скользящем окне
+// и сообщает о превышении порога. Повторный сигнал возможен только после того,
+// как число сообщений в окне опустится ниже порога.
+type DLQMonitor struct {
+	threshold int
+	window    time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	received []time.Time
+	alerting bool
+}
+
+// NewDLQMonitor создает монитор DLQ с порогом threshold сообщений за окно window
+func NewDLQMonitor(threshold int, window time.Duration) *DLQMonitor {
+	return &DLQMonitor{
+		threshold: threshold,
+		window:    window,
+		now:       time.Now,
+	}
+}
+
+// Record учитывает новое сообщение DLQ и возвращает true, если порог был пересечен именно сейчас
+func (m *DLQMonitor) Record() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.received = append(m.received, now)
+	m.evict(now)
+
+	if len(m.received) < m.threshold {
+		m.alerting = false
+		return false
+	}
+	if m.alerting {
+		return false
+	}
+	m.alerting = true
+	return true
+}
+
+// Depth возвращает количество сообщений DLQ в текущем окне
+func (m *DLQMonitor) Depth() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evict(m.now())
+	if len(m.received) < m.threshold {
+		m.alerting = false
+	}
+	return len(m.received)
+}
+
+// evict удаляет отметки старше окна
+func (m *DLQMonitor) evict(now time.Time) {
+	cutoff := now.Add(-m.window)
+	i := 0
+	for i < len(m.received) && !m.received[i].After(cutoff) {
+		i++
+	}
+	m.received = m.received[i:]
+}
@@ -0,0 +1,55 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDLQMonitorAlertsOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	monitor := NewDLQMonitor(3, time.Minute)
+	monitor.now = func() time.Time { return now }
+
+	record := func() bool {
+		now = now.Add(time.Second)
+		return monitor.Record()
+	}
+
+	if record() || record() {
+		t.Fatal("alert fired below the threshold")
+	}
+	if !record() {
+		t.Fatal("alert did not fire when the threshold was crossed")
+	}
+	// Пока глубина выше порога, повторного сигнала нет
+	for i := 0; i < 5; i++ {
+		if record() {
+			t.Fatalf("alert fired again on message %d above the threshold", i+4)
+		}
+	}
+	if monitor.Depth() != 8 {
+		t.Fatalf("depth = %d, want 8", monitor.Depth())
+	}
+}
+
+func TestDLQMonitorRearmsAfterWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	monitor := NewDLQMonitor(2, time.Minute)
+	monitor.now = func() time.Time { return now }
+
+	monitor.Record()
+	if !monitor.Record() {
+		t.Fatal("alert did not fire")
+	}
+
+	// Сообщения вышли из окна, глубина опустилась ниже порога
+	now = now.Add(2 * time.Minute)
+	if monitor.Depth() != 0 {
+		t.Fatalf("depth = %d, want old messages evicted", monitor.Depth())
+	}
+
+	monitor.Record()
+	if !monitor.Record() {
+		t.Fatal("alert did not fire again after the depth dropped below the threshold")
+	}
+}
@@ -0,0 +1,141 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/google/uuid"
+)
+
+// replayCountHeader заголовок с числом повторных публикаций сообщения из DLQ
+const replayCountHeader = "dlq_replay_count"
+
+// DeadLetter конверт сообщения в DLQ: исходное сообщение и причина, по которой его не удалось обработать
+type DeadLetter struct {
+	OriginalMessage *interfaces.Message `json:"original_message"`
+	Error           string              `json:"error"`
+	RetryCount      int                 `json:"retry_count"`
+	Timestamp       time.Time           `json:"timestamp"`
+}
+
+// ReplayResult итог повторной публикации сообщений из DLQ
+type ReplayResult struct {
+	Replayed int // опубликовано обратно в исходные топики
+	Skipped  int // пропущено: некорректный конверт или исчерпан лимит повторов
+}
+
+// ReplayDeadLetters читает DLQ отдельной группой consumer'ов и публикует исходные сообщения
+// обратно в их топики. Сообщение, которое уже возвращалось из DLQ maxReplays раз, пропускается,
+// чтобы сообщение с неисправимой ошибкой не ходило по кругу. Чтение заканчивается, когда
+// в течение idleTimeout не приходит новых сообщений. Смещение фиксируется после публикации,
+// поэтому при ошибке недоставленное сообщение будет прочитано следующим запуском
+func (k *KafkaMessaging) ReplayDeadLetters(ctx context.Context, maxReplays int, idleTimeout time.Duration) (ReplayResult, error) {
+	var result ReplayResult
+	if k.deadLetterTopic == "" {
+		return result, fmt.Errorf("топик DLQ не настроен")
+	}
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  strings.Join(k.brokers, ","),
+		"group.id":           k.groupID + "-dlq-replay",
+		"auto.offset.reset":  "earliest",
+		"enable.auto.commit": false,
+		"isolation.level":    "read_committed",
+	})
+	if err != nil {
+		return result, fmt.Errorf("ошибка создания Kafka consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	if err := consumer.Subscribe(k.deadLetterTopic, nil); err != nil {
+		return result, fmt.Errorf("ошибка подписки на топик %s: %w", k.deadLetterTopic, err)
+	}
+
+	lastMessage := time.Now()
+	for time.Since(lastMessage) < idleTimeout {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		ev := consumer.Poll(100)
+		e, ok := ev.(*kafka.Message)
+		if !ok {
+			continue
+		}
+		lastMessage = time.Now()
+
+		var letter DeadLetter
+		var msg *kafka.Message
+		var reason string
+		if err := json.Unmarshal(e.Value, &letter); err != nil {
+			reason = "некорректный конверт DLQ: " + err.Error()
+		} else {
+			msg, reason = replayMessage(&letter, maxReplays)
+		}
+
+		if msg == nil {
+			result.Skipped++
+			k.logger.Warn("Сообщение DLQ пропущено",
+				interfaces.LogField{Key: "offset", Value: e.TopicPartition.Offset},
+				interfaces.LogField{Key: "reason", Value: reason})
+		} else {
+			if err := k.produceSync(ctx, msg); err != nil {
+				return result, fmt.Errorf("ошибка повторной публикации сообщения %s: %w", letter.OriginalMessage.ID, err)
+			}
+			result.Replayed++
+			k.logger.Info("Сообщение DLQ опубликовано повторно",
+				interfaces.LogField{Key: "message_id", Value: letter.OriginalMessage.ID},
+				interfaces.LogField{Key: "topic", Value: letter.OriginalMessage.Topic})
+		}
+
+		if _, err := consumer.CommitMessage(e); err != nil {
+			return result, fmt.Errorf("ошибка фиксации смещения DLQ: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// replayMessage восстанавливает исходное сообщение из конверта DLQ с увеличенным счетчиком повторов.
+// Сообщению выдается новый message_id, иначе дедупликация consumer'а могла бы его отбросить.
+// Если сообщение публиковать нельзя, возвращает nil и причину
+func replayMessage(letter *DeadLetter, maxReplays int) (*kafka.Message, string) {
+	original := letter.OriginalMessage
+	if original == nil || original.Topic == "" {
+		return nil, "в конверте DLQ нет исходного сообщения"
+	}
+
+	replays, _ := strconv.Atoi(original.Headers[replayCountHeader])
+	if replays >= maxReplays {
+		return nil, fmt.Sprintf("сообщение уже возвращалось из DLQ %d раз", replays)
+	}
+
+	topic := original.Topic
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Value:          original.Value,
+	}
+	if original.Key != "" {
+		msg.Key = []byte(original.Key)
+	}
+
+	for key, value := range original.Headers {
+		switch key {
+		case "message_id", replayCountHeader:
+			continue
+		}
+		msg.Headers = append(msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	msg.Headers = append(msg.Headers,
+		kafka.Header{Key: "message_id", Value: []byte(uuid.New().String())},
+		kafka.Header{Key: replayCountHeader, Value: []byte(strconv.Itoa(replays + 1))},
+	)
+
+	return msg, ""
+}
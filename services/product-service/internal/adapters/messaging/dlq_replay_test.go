@@ -0,0 +1,119 @@
+package messaging
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func TestReplayDeadLetters(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	defer cluster.Close()
+
+	k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+	k.brokers = []string{cluster.BootstrapServers()}
+	k.deadLetterTopic = "product-dlq"
+	ctx := context.Background()
+
+	// Сообщение, исчерпавшее попытки обработки, и сообщение, которое уже возвращалось из DLQ maxReplays раз
+	failed := &interfaces.Message{
+		ID:      "message-1",
+		Topic:   "product-commands",
+		Key:     "product-1",
+		Value:   []byte(`{"command":"sync"}`),
+		Headers: map[string]string{"message_id": "message-1", "tenant_id": "tenant-1"},
+	}
+	looping := &interfaces.Message{
+		ID:      "message-2",
+		Topic:   "product-commands",
+		Value:   []byte(`{"command":"broken"}`),
+		Headers: map[string]string{replayCountHeader: "2"},
+	}
+	for _, msg := range []*interfaces.Message{failed, looping} {
+		if err := k.sendToDLQ(ctx, msg, "handler failed", 3); err != nil {
+			t.Fatalf("sendToDLQ: %v", err)
+		}
+	}
+	if err := k.PublishSync(ctx, k.deadLetterTopic, []byte("not an envelope")); err != nil {
+		t.Fatalf("PublishSync: %v", err)
+	}
+
+	// Вступление в группу consumer'ов на mock-кластере занимает около 3 секунд
+	const idle = 5 * time.Second
+	result, err := k.ReplayDeadLetters(ctx, 2, idle)
+	if err != nil {
+		t.Fatalf("ReplayDeadLetters: %v", err)
+	}
+	if result.Replayed != 1 || result.Skipped != 2 {
+		t.Fatalf("result = %+v, want 1 replayed and 2 skipped", result)
+	}
+
+	replayed := readMessages(t, cluster.BootstrapServers(), "product-commands", 1)
+	if len(replayed) != 1 {
+		t.Fatalf("source topic has %d messages, want the replayed one", len(replayed))
+	}
+	msg := k.kafkaToInterfaceMessage(replayed[0])
+	if string(msg.Value) != `{"command":"sync"}` || msg.Key != "product-1" || msg.Headers["tenant_id"] != "tenant-1" {
+		t.Fatalf("replayed = %+v, want the original message", msg)
+	}
+	// Новый message_id, чтобы дедупликация не отбросила сообщение, и счетчик повторов
+	if msg.Headers["message_id"] == "message-1" || msg.Headers[replayCountHeader] != "1" {
+		t.Fatalf("headers = %v, want a new message_id and replay count 1", msg.Headers)
+	}
+
+	// Смещения DLQ зафиксированы, следующий запуск начнет с непрочитанных сообщений
+	if committed := committedCount(t, cluster.BootstrapServers(), k.groupID+"-dlq-replay", k.deadLetterTopic); committed != 3 {
+		t.Fatalf("committed offsets sum = %d, want all 3 dead letters", committed)
+	}
+}
+
+func TestReplayMessageRejectsEmptyEnvelope(t *testing.T) {
+	for _, letter := range []*DeadLetter{{}, {OriginalMessage: &interfaces.Message{ID: "message-1"}}} {
+		if msg, reason := replayMessage(letter, 3); msg != nil || !strings.Contains(reason, "нет исходного сообщения") {
+			t.Fatalf("replayMessage(%+v) = %v, %q, want it skipped", letter, msg, reason)
+		}
+	}
+}
+
+// committedCount возвращает сумму зафиксированных группой смещений по всем партициям топика
+func committedCount(t *testing.T, bootstrap, groupID, topic string) int64 {
+	t.Helper()
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": bootstrap,
+		"group.id":          groupID,
+		"log_level":         0,
+	})
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer consumer.Close()
+
+	metadata, err := consumer.GetMetadata(&topic, false, 5000)
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	var partitions []kafka.TopicPartition
+	for _, partition := range metadata.Topics[topic].Partitions {
+		partitions = append(partitions, kafka.TopicPartition{Topic: &topic, Partition: partition.ID})
+	}
+
+	committed, err := consumer.Committed(partitions, 5000)
+	if err != nil {
+		t.Fatalf("Committed: %v", err)
+	}
+	var total int64
+	for _, tp := range committed {
+		if tp.Offset > 0 {
+			total += int64(tp.Offset)
+		}
+	}
+	return total
+}
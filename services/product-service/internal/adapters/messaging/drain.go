@@ -0,0 +1,67 @@
+package messaging
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// defaultDrainTimeout сколько по умолчанию ждать завершения обработки текущих сообщений при остановке
+const defaultDrainTimeout = 30 * time.Second
+
+// stopConsumers отменяет циклы обработки consumer'ов и возвращает каналы их завершения.
+// Consumer'ы после этого не берут новых сообщений, но дорабатывают текущее
+func (k *KafkaMessaging) stopConsumers(ids []string) map[string]chan struct{} {
+	k.contextsMutex.Lock()
+	defer k.contextsMutex.Unlock()
+
+	done := make(map[string]chan struct{}, len(ids))
+	for _, id := range ids {
+		if cancel, exists := k.consumerContexts[id]; exists {
+			cancel()
+			delete(k.consumerContexts, id)
+		}
+		if ch, exists := k.consumerDone[id]; exists {
+			done[id] = ch
+			delete(k.consumerDone, id)
+		}
+	}
+	return done
+}
+
+// drainConsumers ждет завершения циклов обработки до deadline. Если обработчик не успел,
+// consumer все равно будет закрыт, а незафиксированное сообщение доставлено повторно
+func (k *KafkaMessaging) drainConsumers(done map[string]chan struct{}, deadline time.Time) {
+	for id, ch := range done {
+		select {
+		case <-ch:
+		case <-time.After(time.Until(deadline)):
+			k.logger.Warn("Обработка сообщения не завершилась за отведенное время, consumer будет закрыт",
+				interfaces.LogField{Key: "consumer_id", Value: id},
+				interfaces.LogField{Key: "drain_timeout", Value: k.drainTimeout.String()},
+			)
+		}
+	}
+}
+
+// closeConsumer удаляет обработчик и закрывает consumer. При закрытии consumer фиксирует
+// смещения (в режиме автофиксации) и покидает группу
+func (k *KafkaMessaging) closeConsumer(id string) error {
+	k.handlersMutex.Lock()
+	delete(k.handlers, id)
+	k.handlersMutex.Unlock()
+
+	k.consumersMutex.Lock()
+	consumer, exists := k.consumers[id]
+	delete(k.consumers, id)
+	k.consumersMutex.Unlock()
+
+	if !exists {
+		return nil
+	}
+	if err := consumer.Close(); err != nil {
+		return fmt.Errorf("ошибка закрытия consumer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package messaging
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func TestUnsubscribeDrainsInFlightMessage(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	// Кластер закрывается после клиента, иначе librdkafka сообщает о разрыве соединения
+	t.Cleanup(cluster.Close)
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	port, err := NewKafkaMessagingWithConfig(KafkaConfig{
+		Brokers:      []string{cluster.BootstrapServers()},
+		GroupID:      "drain-test",
+		DrainTimeout: 5 * time.Second,
+	}, log)
+	if err != nil {
+		t.Fatalf("NewKafkaMessagingWithConfig: %v", err)
+	}
+	k := port.(*KafkaMessaging)
+	t.Cleanup(func() { k.Close() })
+
+	const topic = "product-commands"
+	ctx := context.Background()
+	// Топик создается первой публикацией
+	if err := k.PublishSync(ctx, topic, []byte(`{}`)); err != nil {
+		t.Fatalf("PublishSync: %v", err)
+	}
+
+	started := make(chan struct{})
+	var calls, finished atomic.Int32
+	slow := func(ctx context.Context, msg *interfaces.Message) error {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		time.Sleep(500 * time.Millisecond)
+		finished.Add(1)
+		return nil
+	}
+	unsubscribe, err := k.Subscribe(ctx, topic, slow)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// Consumer читает с последнего смещения, поэтому публикуем, пока он не получит сообщение
+	deadline := time.After(15 * time.Second)
+waitStarted:
+	for {
+		if err := k.PublishSync(ctx, topic, []byte(`{}`)); err != nil {
+			t.Fatalf("PublishSync: %v", err)
+		}
+		select {
+		case <-started:
+			break waitStarted
+		case <-deadline:
+			t.Fatal("handler was never called")
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	if err := unsubscribe(); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	// Отписка вернулась только после того, как начатое сообщение обработано
+	if finished.Load() != calls.Load() {
+		t.Fatalf("finished = %d of %d handler calls when unsubscribe returned", finished.Load(), calls.Load())
+	}
+	if committed := committedCount(t, cluster.BootstrapServers(), "drain-test", topic); committed == 0 {
+		t.Fatal("offset of the drained message was not committed")
+	}
+}
+
+func TestDrainConsumersTimeout(t *testing.T) {
+	k := newTestKafkaMessaging(t, nil)
+	k.drainTimeout = 50 * time.Millisecond
+	k.consumerContexts = map[string]context.CancelFunc{"consumer-1": func() {}}
+	// Цикл обработки зависшего consumer'а не завершается
+	k.consumerDone = map[string]chan struct{}{"consumer-1": make(chan struct{})}
+
+	start := time.Now()
+	done := k.stopConsumers([]string{"consumer-1"})
+	k.drainConsumers(done, start.Add(k.drainTimeout))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("drain took %v, want it bounded by the drain timeout", elapsed)
+	}
+	if len(k.consumerContexts) != 0 || len(k.consumerDone) != 0 {
+		t.Fatalf("consumer state left after stop: %v, %v", k.consumerContexts, k.consumerDone)
+	}
+}
@@ -1,9 +1,171 @@
 package messaging
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrMalformedEvent возвращается, если конверт или payload события не соответствуют схеме:
+// некорректный JSON, поле другого типа или отсутствующее обязательное поле
+var ErrMalformedEvent = errors.New("malformed event")
+
 type KafkaEvent = string
 
 const (
-	ProductCreatedEvent = "product_created"
-	ProductUpdatedEvent = "product_updated"
-	ProductDeletedEvent = "product_deleted"
+	ProductCreatedEvent          = "product_created"
+	ProductUpdatedEvent          = "product_updated"
+	ProductDeletedEvent          = "product_deleted"
+	ProductPriceUpdatedEvent     = "product_price_updated"
+	ProductInventoryUpdatedEvent = "product_inventory_updated"
+
+	// DLQThresholdExceededEvent алерт о превышении порога сообщений в DLQ
+	DLQThresholdExceededEvent = "dlq_threshold_exceeded"
 )
+
+// Версии схемы конверта событий
+const (
+	// LegacyEventSchemaVersion события без поля schema_version, записанные до появления конверта.
+	// Поля их payload совпадают с версией 1, поэтому потребители обрабатывают их как версию 1
+	LegacyEventSchemaVersion = 0
+	// EventSchemaVersion текущая версия схемы, с которой публикуются события
+	EventSchemaVersion = 1
+)
+
+// EventEnvelope конверт всех публикуемых событий. Потребитель сначала проверяет SchemaVersion
+// и по EventType выбирает тип Payload, поэтому несовместимые изменения payload требуют новой версии
+type EventEnvelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	EventType     KafkaEvent      `json:"event_type"`
+	TenantID      string          `json:"tenant_id,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// ProductPayload payload событий продукта, которому outbox присваивает продукт и номер события
+type ProductPayload interface {
+	SetSequence(productID string, sequence int64)
+}
+
+// EventPayload payload, проверяющий себя после разбора в DecodePayload
+type EventPayload interface {
+	Validate() error
+}
+
+// ProductEventPayload payload событий создания, обновления и удаления продукта
+// и общая часть payload остальных событий продукта
+type ProductEventPayload struct {
+	ProductID  string `json:"product_id"`
+	Sequence   int64  `json:"sequence"`
+	SupplierID string `json:"supplier_id"`
+	// SKU заполняется, если продукт создан или обновлен по артикулу (upsert, импорт, синхронизация)
+	SKU string `json:"sku,omitempty"`
+}
+
+// SetSequence задает продукт и монотонный в рамках продукта номер события
+func (p *ProductEventPayload) SetSequence(productID string, sequence int64) {
+	p.ProductID = productID
+	p.Sequence = sequence
+}
+
+// Validate проверяет обязательные поля payload события продукта
+func (p *ProductEventPayload) Validate() error {
+	if p.ProductID == "" {
+		return errors.New("product_id is missing")
+	}
+	if p.Sequence < 0 {
+		return fmt.Errorf("sequence must not be negative, got %d", p.Sequence)
+	}
+	return nil
+}
+
+// ProductPriceUpdatedPayload payload события product_price_updated
+type ProductPriceUpdatedPayload struct {
+	ProductEventPayload
+	Price float64 `json:"price"`
+}
+
+// Validate проверяет payload события product_price_updated
+func (p *ProductPriceUpdatedPayload) Validate() error {
+	if err := p.ProductEventPayload.Validate(); err != nil {
+		return err
+	}
+	if p.Price <= 0 {
+		return fmt.Errorf("price must be positive, got %v", p.Price)
+	}
+	return nil
+}
+
+// ProductInventoryUpdatedPayload payload события product_inventory_updated
+type ProductInventoryUpdatedPayload struct {
+	ProductEventPayload
+	// Quantity фактический остаток после изменения
+	Quantity int `json:"quantity"`
+	// Delta изменение остатка при корректировке, отсутствует при установке остатка
+	Delta int `json:"delta,omitempty"`
+}
+
+// Validate проверяет payload события product_inventory_updated
+func (p *ProductInventoryUpdatedPayload) Validate() error {
+	if err := p.ProductEventPayload.Validate(); err != nil {
+		return err
+	}
+	if p.Quantity < 0 {
+		return fmt.Errorf("quantity must not be negative, got %d", p.Quantity)
+	}
+	return nil
+}
+
+// DLQAlertPayload payload события dlq_threshold_exceeded
+type DLQAlertPayload struct {
+	Topic string `json:"topic"`
+	Depth int    `json:"depth"`
+}
+
+// EncodeEvent сериализует payload в конверт текущей версии схемы
+func EncodeEvent(eventType KafkaEvent, tenantID string, occurredAt time.Time, payload interface{}) ([]byte, error) {
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	return json.Marshal(EventEnvelope{
+		SchemaVersion: EventSchemaVersion,
+		EventType:     eventType,
+		TenantID:      tenantID,
+		OccurredAt:    occurredAt.UTC(),
+		Payload:       payloadData,
+	})
+}
+
+// DecodeEvent разбирает конверт события. Версия схемы не проверяется: это делает потребитель,
+// который знает, какие версии поддерживает. Ошибки разбора оборачивают ErrMalformedEvent
+func DecodeEvent(data []byte) (*EventEnvelope, error) {
+	var envelope EventEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: envelope: %v", ErrMalformedEvent, err)
+	}
+	if envelope.EventType == "" {
+		return nil, fmt.Errorf("%w: event_type is missing", ErrMalformedEvent)
+	}
+	if len(envelope.Payload) == 0 || string(envelope.Payload) == "null" {
+		return nil, fmt.Errorf("%w: payload is missing in %s event", ErrMalformedEvent, envelope.EventType)
+	}
+	return &envelope, nil
+}
+
+// DecodePayload разбирает payload события в v, тип которого соответствует EventType.
+// Поле другого типа - ошибка, а не нулевое значение; если v реализует EventPayload,
+// после разбора проверяются обязательные поля. Ошибки оборачивают ErrMalformedEvent
+func (e *EventEnvelope) DecodePayload(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("%w: %s payload: %v", ErrMalformedEvent, e.EventType, err)
+	}
+	if payload, ok := v.(EventPayload); ok {
+		if err := payload.Validate(); err != nil {
+			return fmt.Errorf("%w: %s payload: %v", ErrMalformedEvent, e.EventType, err)
+		}
+	}
+	return nil
+}
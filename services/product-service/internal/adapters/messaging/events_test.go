@@ -0,0 +1,116 @@
+package messaging
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventEnvelopeRoundTrip(t *testing.T) {
+	product := ProductEventPayload{ProductID: "product-1", Sequence: 7, SupplierID: "supplier-1", SKU: "SKU-1"}
+
+	tests := []struct {
+		eventType KafkaEvent
+		payload   interface{}
+		decoded   interface{}
+	}{
+		{eventType: ProductCreatedEvent, payload: &product, decoded: &ProductEventPayload{}},
+		{eventType: ProductUpdatedEvent, payload: &product, decoded: &ProductEventPayload{}},
+		{eventType: ProductDeletedEvent, payload: &ProductEventPayload{ProductID: "product-1", Sequence: 8, SupplierID: "supplier-1"},
+			decoded: &ProductEventPayload{}},
+		{eventType: ProductPriceUpdatedEvent, payload: &ProductPriceUpdatedPayload{ProductEventPayload: product, Price: 129.9},
+			decoded: &ProductPriceUpdatedPayload{}},
+		{eventType: ProductInventoryUpdatedEvent, payload: &ProductInventoryUpdatedPayload{ProductEventPayload: product, Quantity: 5, Delta: -2},
+			decoded: &ProductInventoryUpdatedPayload{}},
+		{eventType: DLQThresholdExceededEvent, payload: &DLQAlertPayload{Topic: "product-events.dlq", Depth: 150},
+			decoded: &DLQAlertPayload{}},
+	}
+
+	occurredAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			data, err := EncodeEvent(tt.eventType, "tenant-1", occurredAt, tt.payload)
+			if err != nil {
+				t.Fatalf("EncodeEvent: %v", err)
+			}
+
+			event, err := DecodeEvent(data)
+			if err != nil {
+				t.Fatalf("DecodeEvent: %v", err)
+			}
+			if event.SchemaVersion != EventSchemaVersion || event.EventType != tt.eventType || event.TenantID != "tenant-1" {
+				t.Fatalf("envelope = %+v, want version %d, type %s, tenant-1", event, EventSchemaVersion, tt.eventType)
+			}
+			// Время события публикуется в UTC
+			if !event.OccurredAt.Equal(occurredAt) || event.OccurredAt.Location() != time.UTC {
+				t.Fatalf("occurred_at = %v, want %v in UTC", event.OccurredAt, occurredAt)
+			}
+
+			if err := event.DecodePayload(tt.decoded); err != nil {
+				t.Fatalf("DecodePayload: %v", err)
+			}
+			if !reflect.DeepEqual(tt.decoded, tt.payload) {
+				t.Fatalf("payload = %+v, want %+v", tt.decoded, tt.payload)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyEvent(t *testing.T) {
+	// Событие, записанное до появления конверта, не содержит schema_version
+	data := []byte(`{"event_type":"product_updated","tenant_id":"tenant-1","payload":{"product_id":"product-1","supplier_id":"supplier-1"}}`)
+
+	event, err := DecodeEvent(data)
+	if err != nil {
+		t.Fatalf("DecodeEvent: %v", err)
+	}
+	if event.SchemaVersion != LegacyEventSchemaVersion {
+		t.Fatalf("schema version = %d, want legacy %d", event.SchemaVersion, LegacyEventSchemaVersion)
+	}
+
+	var payload ProductEventPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if payload.ProductID != "product-1" || payload.SupplierID != "supplier-1" || payload.Sequence != 0 {
+		t.Fatalf("payload = %+v, want product-1 from supplier-1 without sequence", payload)
+	}
+}
+
+func TestDecodeMalformedEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		payload EventPayload
+	}{
+		{name: "invalid JSON", data: `{"event_type":`},
+		{name: "event type missing", data: `{"schema_version":1,"payload":{"product_id":"product-1"}}`},
+		{name: "payload missing", data: `{"schema_version":1,"event_type":"product_updated"}`},
+		{name: "null payload", data: `{"schema_version":1,"event_type":"product_updated","payload":null}`},
+		{name: "price of another type", data: `{"schema_version":1,"event_type":"product_price_updated","payload":{"product_id":"product-1","price":"129.90"}}`,
+			payload: &ProductPriceUpdatedPayload{}},
+		{name: "price missing", data: `{"schema_version":1,"event_type":"product_price_updated","payload":{"product_id":"product-1"}}`,
+			payload: &ProductPriceUpdatedPayload{}},
+		{name: "product missing", data: `{"schema_version":1,"event_type":"product_updated","payload":{"supplier_id":"supplier-1"}}`,
+			payload: &ProductEventPayload{}},
+		{name: "supplier of another type", data: `{"schema_version":1,"event_type":"product_updated","payload":{"product_id":"product-1","supplier_id":42}}`,
+			payload: &ProductEventPayload{}},
+		{name: "negative quantity", data: `{"schema_version":1,"event_type":"product_inventory_updated","payload":{"product_id":"product-1","quantity":-3}}`,
+			payload: &ProductInventoryUpdatedPayload{}},
+		{name: "payload is not an object", data: `{"schema_version":1,"event_type":"product_inventory_updated","payload":[1,2]}`,
+			payload: &ProductInventoryUpdatedPayload{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DecodeEvent([]byte(tt.data))
+			if err == nil && tt.payload != nil {
+				err = event.DecodePayload(tt.payload)
+			}
+			if !errors.Is(err, ErrMalformedEvent) {
+				t.Fatalf("decode error = %v, want ErrMalformedEvent", err)
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +24,15 @@ type KafkaConfig struct {
 	HeartbeatTimeout time.Duration
 	MaxRetries       int
 	RetryBackoff     time.Duration
+	// Dedup хранит message_id успешно обработанных сообщений, повторно они пропускаются.
+	// nil отключает дедупликацию
+	Dedup    interfaces.CachePort
+	DedupTTL time.Duration
+	// DrainTimeout сколько при отписке и закрытии ждать завершения обработки текущих сообщений
+	DrainTimeout time.Duration
+	// Consumer настройки подписчиков. При AutoCommit == false смещение фиксируется только после
+	// успешной обработки или надежной передачи сообщения в DLQ (доставка at-least-once)
+	Consumer interfaces.ConsumerConfig
 }
 
 type KafkaMessaging struct {
@@ -32,9 +44,21 @@ type KafkaMessaging struct {
 	brokers          []string
 	groupID          string
 	deadLetterTopic  string
+	maxRetries       int
+	retryBackoff     time.Duration
 	logger           interfaces.LoggerPort
+	dedup            interfaces.CachePort
+	dedupTTL         time.Duration
+	consumerConfig   interfaces.ConsumerConfig
 	consumerContexts map[string]context.CancelFunc
 	contextsMutex    sync.RWMutex
+	// consumerDone закрывается, когда цикл обработки consumer'а завершился; защищен contextsMutex
+	consumerDone map[string]chan struct{}
+	drainTimeout time.Duration
+	// producerStop останавливает обновление метрики длины очереди producer'а
+	producerStop chan struct{}
+	// closeOnce делает Close идемпотентным: повторный вызов не закрывает producer снова
+	closeOnce sync.Once
 }
 
 func NewKafkaMessaging(
@@ -43,6 +67,41 @@ func NewKafkaMessaging(
 	deadLetterTopic string,
 	logger interfaces.LoggerPort,
 ) (interfaces.MessagingPort, error) {
+	return NewKafkaMessagingWithConfig(KafkaConfig{
+		Brokers:         brokers,
+		GroupID:         groupID,
+		DeadLetterTopic: deadLetterTopic,
+	}, logger)
+}
+
+// NewKafkaMessagingWithConfig создает Kafka клиент по конфигурации.
+// MaxRetries и RetryBackoff задают число попыток обработки сообщения и базовую задержку между ними.
+func NewKafkaMessagingWithConfig(cfg KafkaConfig, logger interfaces.LoggerPort) (interfaces.MessagingPort, error) {
+	brokers := cfg.Brokers
+	groupID := cfg.GroupID
+	deadLetterTopic := cfg.DeadLetterTopic
+
+	maxRetries := cfg.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	retryBackoff := cfg.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = defaultRetryBackoff
+	}
+	dedupTTL := cfg.DedupTTL
+	if dedupTTL <= 0 {
+		dedupTTL = defaultDedupTTL
+	}
+	drainTimeout := cfg.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	consumerConfig := cfg.Consumer
+	if consumerConfig.AutoCommitInterval <= 0 {
+		consumerConfig.AutoCommitInterval = defaultAutoCommitInterval
+	}
+
 	if len(brokers) == 0 {
 		return nil, fmt.Errorf("не указаны брокеры Kafka")
 	}
@@ -86,7 +145,7 @@ func NewKafkaMessaging(
 		}
 	}()
 
-	return &KafkaMessaging{
+	k := &KafkaMessaging{
 		producer:         producer,
 		consumers:        make(map[string]*kafka.Consumer),
 		consumersMutex:   sync.RWMutex{},
@@ -95,14 +154,121 @@ func NewKafkaMessaging(
 		brokers:          brokers,
 		groupID:          groupID,
 		deadLetterTopic:  deadLetterTopic,
+		maxRetries:       maxRetries,
+		retryBackoff:     retryBackoff,
 		logger:           logger,
+		dedup:            cfg.Dedup,
+		dedupTTL:         dedupTTL,
+		consumerConfig:   consumerConfig,
 		consumerContexts: make(map[string]context.CancelFunc),
 		contextsMutex:    sync.RWMutex{},
-	}, nil
+		consumerDone:     make(map[string]chan struct{}),
+		drainTimeout:     drainTimeout,
+		producerStop:     make(chan struct{}),
+	}
+	go k.reportQueueLength(k.producerStop)
+
+	return k, nil
 }
 
 // Publish публикует сообщение в топик
 func (k *KafkaMessaging) Publish(ctx context.Context, topic string, message []byte) error {
+	return k.PublishWithKey(ctx, topic, "", message)
+}
+
+// PublishWithKey публикует сообщение с ключом. Партицию по ключу выбирает partitioner producer'а,
+// поэтому сообщения одного ключа сохраняют порядок
+func (k *KafkaMessaging) PublishWithKey(ctx context.Context, topic, key string, message []byte) error {
+	err := k.produce(ctx, k.newMessage(ctx, topic, key, message), nil)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки сообщения в Kafka: %w", err)
+	}
+
+	return nil
+}
+
+// PublishSync публикует сообщение и ждет подтверждения доставки от брокера.
+// Ошибка доставки возвращается вызывающему, а не только попадает в лог фоновой горутины
+func (k *KafkaMessaging) PublishSync(ctx context.Context, topic string, message []byte) error {
+	return k.produceSync(ctx, k.newMessage(ctx, topic, "", message))
+}
+
+// produceSync отправляет подготовленное сообщение и ждет отчета о доставке
+func (k *KafkaMessaging) produceSync(ctx context.Context, msg *kafka.Message) error {
+	delivery := make(chan kafka.Event, 1)
+	if err := k.produce(ctx, msg, delivery); err != nil {
+		return fmt.Errorf("ошибка отправки сообщения в Kafka: %w", err)
+	}
+
+	select {
+	case e := <-delivery:
+		if m, ok := e.(*kafka.Message); ok && m.TopicPartition.Error != nil {
+			return fmt.Errorf("ошибка доставки сообщения в Kafka: %w", m.TopicPartition.Error)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PublishBatch ставит все сообщения в очередь producer'а и один раз дожидается отчетов о доставке.
+// Сообщения без подтверждения к моменту отмены ctx считаются недоставленными
+func (k *KafkaMessaging) PublishBatch(ctx context.Context, topic string, messages [][]byte) error {
+	return k.PublishBatchWithKeys(ctx, topic, nil, messages)
+}
+
+// PublishBatchWithKeys публикует пачку сообщений с ключами партиционирования keys (может быть короче messages)
+func (k *KafkaMessaging) PublishBatchWithKeys(ctx context.Context, topic string, keys []string, messages [][]byte) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	failed := make(map[int]error)
+	delivery := make(chan kafka.Event, len(messages))
+	pending := make(map[int]bool, len(messages))
+	for i, message := range messages {
+		var key string
+		if i < len(keys) {
+			key = keys[i]
+		}
+		msg := k.newMessage(ctx, topic, key, message)
+		msg.Opaque = i
+		if err := k.produce(ctx, msg, delivery); err != nil {
+			failed[i] = fmt.Errorf("ошибка отправки сообщения в Kafka: %w", err)
+			continue
+		}
+		pending[i] = true
+	}
+
+	for len(pending) > 0 {
+		select {
+		case e := <-delivery:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				continue
+			}
+			i, _ := m.Opaque.(int)
+			delete(pending, i)
+			if m.TopicPartition.Error != nil {
+				failed[i] = fmt.Errorf("ошибка доставки сообщения в Kafka: %w", m.TopicPartition.Error)
+			}
+		case <-ctx.Done():
+			for i := range pending {
+				failed[i] = ctx.Err()
+			}
+			pending = nil
+		}
+	}
+
+	if len(failed) > 0 {
+		return &interfaces.BatchPublishError{Failed: failed}
+	}
+	return nil
+}
+
+// newMessage формирует сообщение Kafka со служебными заголовками и контекстом трассировки
+// Непустой key становится ключом сообщения
+func (k *KafkaMessaging) newMessage(ctx context.Context, topic, key string, message []byte) *kafka.Message {
 	msg := &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
 		Value:          message,
@@ -111,24 +277,40 @@ func (k *KafkaMessaging) Publish(ctx context.Context, topic string, message []by
 			{Key: "timestamp", Value: []byte(fmt.Sprintf("%d", time.Now().UnixNano()))},
 		},
 	}
+	if key != "" {
+		msg.Key = []byte(key)
+	}
 
-	if tenantID, ok := ctx.Value("tenant_id").(string); ok && tenantID != "" {
+	if tenantID, ok := contextkeys.TenantFromContext(ctx); ok && tenantID != "" {
 		msg.Headers = append(msg.Headers, kafka.Header{Key: "tenant_id", Value: []byte(tenantID)})
 	}
 
-	if traceID, ok := ctx.Value("trace_id").(string); ok && traceID != "" {
+	if traceID, ok := contextkeys.TraceIDFromContext(ctx); ok && traceID != "" {
 		msg.Headers = append(msg.Headers, kafka.Header{Key: "trace_id", Value: []byte(traceID)})
 	}
 
-	err := k.producer.Produce(msg, nil)
-	if err != nil {
-		return fmt.Errorf("ошибка отправки сообщения в Kafka: %w", err)
-	}
+	// W3C traceparent текущего спана
+	otel.GetTextMapPropagator().Inject(ctx, &headerCarrier{headers: &msg.Headers})
 
-	return nil
+	return msg
+}
+
+func (k *KafkaMessaging) Subscribe(ctx context.Context, topic string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	return k.SubscribeMulti(ctx, []string{topic}, handler, config...)
 }
 
-func (k *KafkaMessaging) Subscribe(ctx context.Context, topic string, handler interfaces.MessageHandler) (func() error, error) {
+// SubscribeMulti создает одного consumer'а группы для всех topics. Меньше consumer'ов -
+// меньше перебалансировок группы при запуске и остановке реплик
+func (k *KafkaMessaging) SubscribeMulti(ctx context.Context, topics []string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("не указаны топики для подписки")
+	}
+	policy, err := k.resolvePolicy(config)
+	if err != nil {
+		return nil, err
+	}
+	topic := strings.Join(topics, ",")
+
 	consumerID := uuid.New().String()
 
 	consumerCtx, cancel := context.WithCancel(context.Background())
@@ -141,8 +323,8 @@ func (k *KafkaMessaging) Subscribe(ctx context.Context, topic string, handler in
 		"bootstrap.servers":       strings.Join(k.brokers, ","),
 		"group.id":                k.groupID,
 		"auto.offset.reset":       "latest",
-		"enable.auto.commit":      true,
-		"auto.commit.interval.ms": 5000,
+		"enable.auto.commit":      k.consumerConfig.AutoCommit,
+		"auto.commit.interval.ms": int(k.consumerConfig.AutoCommitInterval.Milliseconds()),
 		"session.timeout.ms":      30000,
 		"max.poll.interval.ms":    300000,
 		"heartbeat.interval.ms":   10000,
@@ -165,7 +347,7 @@ func (k *KafkaMessaging) Subscribe(ctx context.Context, topic string, handler in
 	retryDelay := 5 * time.Second
 
 	for attempt := 0; attempt < maxRetries && !subscribed; attempt++ {
-		err = consumer.Subscribe(topic, nil)
+		err = consumer.SubscribeTopics(topics, nil)
 		if err == nil {
 			subscribed = true
 			break
@@ -215,38 +397,27 @@ func (k *KafkaMessaging) Subscribe(ctx context.Context, topic string, handler in
 	k.handlers[consumerID] = handler
 	k.handlersMutex.Unlock()
 
-	go k.consumeMessages(consumerCtx, consumer, consumerID)
-
-	unsubscribe := func() error {
-		k.contextsMutex.Lock()
-		if cancelFunc, exists := k.consumerContexts[consumerID]; exists {
-			cancelFunc()
-			delete(k.consumerContexts, consumerID)
-		}
-		k.contextsMutex.Unlock()
-
-		k.handlersMutex.Lock()
-		delete(k.handlers, consumerID)
-		k.handlersMutex.Unlock()
+	done := make(chan struct{})
+	k.contextsMutex.Lock()
+	k.consumerDone[consumerID] = done
+	k.contextsMutex.Unlock()
 
-		k.consumersMutex.Lock()
-		if c, exists := k.consumers[consumerID]; exists {
-			delete(k.consumers, consumerID)
-			if err := c.Close(); err != nil {
-				return fmt.Errorf("ошибка закрытия consumer: %w", err)
-			}
-		}
-		k.consumersMutex.Unlock()
+	go func() {
+		defer close(done)
+		k.consumeMessages(consumerCtx, consumer, consumerID, policy)
+	}()
+	go k.reportLag(consumerCtx, consumer)
 
-		return nil
+	unsubscribe := func() error {
+		done := k.stopConsumers([]string{consumerID})
+		k.drainConsumers(done, time.Now().Add(k.drainTimeout))
+		return k.closeConsumer(consumerID)
 	}
 
 	return unsubscribe, nil
 }
 
-func (k *KafkaMessaging) consumeMessages(ctx context.Context, consumer *kafka.Consumer, consumerID string) {
-	const maxRetries = 3
-
+func (k *KafkaMessaging) consumeMessages(ctx context.Context, consumer *kafka.Consumer, consumerID string, policy subscriptionPolicy) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -272,40 +443,8 @@ func (k *KafkaMessaging) consumeMessages(ctx context.Context, consumer *kafka.Co
 					continue
 				}
 
-				msg := k.kafkaToInterfaceMessage(e)
-
-				var processingErr error
-
-				for attempt := 0; attempt < maxRetries; attempt++ {
-					msg.Attempts++
-
-					msgCtx := ctx
-					if msg.TenantID != "" {
-						msgCtx = context.WithValue(ctx, "tenant_id", msg.TenantID)
-					}
-
-					if traceID, ok := msg.Headers["trace_id"]; ok {
-						msgCtx = context.WithValue(msgCtx, "trace_id", traceID)
-					}
-
-					processingErr = handler(msgCtx, msg)
-					if processingErr == nil {
-						break
-					}
-
-					k.logger.WarnWithContext(msgCtx, "Ошибка обработки сообщения, повторная попытка",
-						interfaces.LogField{Key: "topic", Value: msg.Topic},
-						interfaces.LogField{Key: "message_id", Value: msg.ID},
-						interfaces.LogField{Key: "attempt", Value: attempt + 1},
-						interfaces.LogField{Key: "error", Value: processingErr.Error()},
-					)
-
-					backoff := time.Duration(2^attempt) * 100 * time.Millisecond
-					time.Sleep(backoff)
-				}
-
-				if processingErr != nil && k.deadLetterTopic != "" {
-					k.sendToDLQ(ctx, msg, processingErr.Error(), maxRetries)
+				if !k.handleMessage(ctx, consumer, handler, e, policy) {
+					return
 				}
 
 			case kafka.Error:
@@ -328,14 +467,104 @@ func (k *KafkaMessaging) consumeMessages(ctx context.Context, consumer *kafka.Co
 	}
 }
 
-// sendToDLQ отправляет сообщение в Dead Letter Queue
-func (k *KafkaMessaging) sendToDLQ(ctx context.Context, originalMsg *interfaces.Message, errorMsg string, retryCount int) {
-	dlqMessage := struct {
-		OriginalMessage *interfaces.Message `json:"original_message"`
-		Error           string              `json:"error"`
-		RetryCount      int                 `json:"retry_count"`
-		Timestamp       time.Time           `json:"timestamp"`
-	}{
+// handleMessage обрабатывает сообщение с повторами, отправляет его в DLQ при неудаче и фиксирует смещение.
+// Возвращает false, если обработку нужно прекратить из-за отмены контекста
+func (k *KafkaMessaging) handleMessage(ctx context.Context, consumer offsetCommitter, handler interfaces.MessageHandler, e *kafka.Message, policy subscriptionPolicy) bool {
+	msg := k.kafkaToInterfaceMessage(e)
+
+	// При ребалансировке или повторной доставке сообщение может прийти еще раз
+	if k.alreadyProcessed(ctx, msg) {
+		k.logger.Debug("Сообщение уже обработано, пропускаем",
+			interfaces.LogField{Key: "topic", Value: msg.Topic},
+			interfaces.LogField{Key: "message_id", Value: msg.ID},
+		)
+		if !k.consumerConfig.AutoCommit {
+			k.settleOffset(ctx, consumer, e, policy, true)
+		}
+		return true
+	}
+
+	// Спан обработки продолжает трассу продюсера из заголовка traceparent.
+	// Отмена подписки не прерывает обработку: начатое сообщение дорабатывается,
+	// отписка ждет этого до drainTimeout
+	spanCtx, span := startConsumerSpan(context.WithoutCancel(ctx), msg)
+
+	var processingErr error
+
+	for attempt := 0; attempt < policy.maxRetries; attempt++ {
+		msg.Attempts++
+
+		msgCtx := spanCtx
+		if msg.TenantID != "" {
+			msgCtx = contextkeys.WithTenant(msgCtx, msg.TenantID)
+		}
+
+		if traceID, ok := msg.Headers["trace_id"]; ok {
+			msgCtx = contextkeys.WithTraceID(msgCtx, traceID)
+		}
+
+		processingErr = handler(msgCtx, msg)
+		if processingErr == nil {
+			break
+		}
+
+		if attempt == policy.maxRetries-1 {
+			break
+		}
+
+		backoff := withJitter(retryBackoff(policy.retryBackoff, attempt))
+		k.logger.WarnWithContext(msgCtx, "Ошибка обработки сообщения, повторная попытка",
+			interfaces.LogField{Key: "topic", Value: msg.Topic},
+			interfaces.LogField{Key: "message_id", Value: msg.ID},
+			interfaces.LogField{Key: "attempt", Value: attempt + 1},
+			interfaces.LogField{Key: "backoff", Value: backoff.String()},
+			interfaces.LogField{Key: "error", Value: processingErr.Error()},
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			// Подписка отменена во время паузы: сообщение не обработано и не отправлено в DLQ,
+			// поэтому его смещение не фиксируется и после перезапуска оно придет снова
+			span.RecordError(processingErr)
+			span.SetStatus(codes.Error, "обработка прервана остановкой consumer")
+			k.abandonMessage(spanCtx, consumer, e, msg)
+			span.End()
+			return false
+		}
+	}
+
+	handled := processingErr == nil
+	if handled {
+		k.markProcessed(spanCtx, msg)
+	} else {
+		span.RecordError(processingErr)
+		span.SetStatus(codes.Error, processingErr.Error())
+		switch {
+		case policy.onFailure == interfaces.FailureDrop:
+			// Подписка разрешает терять сообщения: смещение фиксируется, сообщение пропускается
+			handled = true
+			k.logger.WarnWithContext(spanCtx, "Сообщение не обработано и отброшено",
+				interfaces.LogField{Key: "topic", Value: msg.Topic},
+				interfaces.LogField{Key: "message_id", Value: msg.ID},
+				interfaces.LogField{Key: "error", Value: processingErr.Error()},
+			)
+		case k.deadLetterTopic != "":
+			handled = k.sendToDLQ(spanCtx, msg, processingErr.Error(), policy.maxRetries) == nil
+		}
+	}
+	span.End()
+
+	if !k.consumerConfig.AutoCommit {
+		return k.settleOffset(ctx, consumer, e, policy, handled)
+	}
+	return true
+}
+
+// sendToDLQ отправляет сообщение в DLQ и дожидается подтверждения доставки.
+// Ошибка означает, что сообщение не сохранено в DLQ
+func (k *KafkaMessaging) sendToDLQ(ctx context.Context, originalMsg *interfaces.Message, errorMsg string, retryCount int) error {
+	dlqMessage := DeadLetter{
 		OriginalMessage: originalMsg,
 		Error:           errorMsg,
 		RetryCount:      retryCount,
@@ -348,16 +577,16 @@ func (k *KafkaMessaging) sendToDLQ(ctx context.Context, originalMsg *interfaces.
 			interfaces.LogField{Key: "error", Value: err.Error()},
 			interfaces.LogField{Key: "message_id", Value: originalMsg.ID},
 		)
-		return
+		return err
 	}
 
-	err = k.Publish(ctx, k.deadLetterTopic, dlqData)
+	err = k.PublishSync(ctx, k.deadLetterTopic, dlqData)
 	if err != nil {
 		k.logger.Error("Ошибка отправки сообщения в DLQ",
 			interfaces.LogField{Key: "error", Value: err.Error()},
 			interfaces.LogField{Key: "message_id", Value: originalMsg.ID},
 		)
-		return
+		return err
 	}
 
 	k.logger.Info("Сообщение отправлено в DLQ",
@@ -365,6 +594,7 @@ func (k *KafkaMessaging) sendToDLQ(ctx context.Context, originalMsg *interfaces.
 		interfaces.LogField{Key: "topic", Value: originalMsg.Topic},
 		interfaces.LogField{Key: "error", Value: errorMsg},
 	)
+	return nil
 }
 
 // kafkaToInterfaceMessage преобразует Kafka сообщение в интерфейсное
@@ -392,37 +622,57 @@ func (k *KafkaMessaging) kafkaToInterfaceMessage(msg *kafka.Message) *interfaces
 	}
 }
 
+// defaultPingTimeout таймаут запроса метаданных, если у контекста нет дедлайна
+const defaultPingTimeout = 2 * time.Second
+
+// Ping запрашивает у брокеров метаданные через producer. Метаданные недоступны, если ни один брокер не отвечает
+func (k *KafkaMessaging) Ping(ctx context.Context) error {
+	timeout := defaultPingTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return ctx.Err()
+	}
+
+	if _, err := k.producer.GetMetadata(nil, false, int(timeout.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to get Kafka metadata: %w", err)
+	}
+	return nil
+}
+
 // Close закрывает соединения с Kafka
 func (k *KafkaMessaging) Close() error {
-	k.contextsMutex.Lock()
-	for _, cancel := range k.consumerContexts {
-		cancel()
+	k.closeOnce.Do(k.close)
+	return nil
+}
+
+// close останавливает consumer'ы, дожидаясь текущих сообщений, и отправляет накопленные сообщения producer'а
+func (k *KafkaMessaging) close() {
+	k.contextsMutex.RLock()
+	ids := make([]string, 0, len(k.consumerContexts))
+	for id := range k.consumerContexts {
+		ids = append(ids, id)
 	}
-	k.consumerContexts = make(map[string]context.CancelFunc)
-	k.contextsMutex.Unlock()
+	k.contextsMutex.RUnlock()
 
-	k.consumersMutex.Lock()
-	for id, consumer := range k.consumers {
-		if err := consumer.Close(); err != nil {
+	// Все consumer'ы останавливаются сразу и дорабатывают текущие сообщения параллельно
+	done := k.stopConsumers(ids)
+	k.drainConsumers(done, time.Now().Add(k.drainTimeout))
+	for _, id := range ids {
+		if err := k.closeConsumer(id); err != nil {
 			k.logger.Error("Ошибка закрытия consumer",
 				interfaces.LogField{Key: "error", Value: err.Error()},
 				interfaces.LogField{Key: "consumer_id", Value: id},
 			)
 		}
 	}
-	k.consumers = make(map[string]*kafka.Consumer)
-	k.consumersMutex.Unlock()
-
-	k.handlersMutex.Lock()
-	k.handlers = make(map[string]interfaces.MessageHandler)
-	k.handlersMutex.Unlock()
 
 	timeoutMS := 5000
 	k.logger.Info("Ожидание отправки всех сообщений в Kafka",
 		interfaces.LogField{Key: "timeout_ms", Value: timeoutMS},
 	)
+	close(k.producerStop)
 	k.producer.Flush(timeoutMS)
 	k.producer.Close()
-
-	return nil
 }
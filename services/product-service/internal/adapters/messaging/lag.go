@@ -0,0 +1,107 @@
+package messaging
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// lagReportInterval период обновления метрики отставания consumer'а
+	lagReportInterval = 15 * time.Second
+	// lagQueryTimeoutMs таймаут запросов смещений к брокеру
+	lagQueryTimeoutMs = 5000
+)
+
+// consumerLag число сообщений партиции, еще не зафиксированных группой consumer'ов
+var consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kafka_consumer_lag",
+	Help: "Отставание consumer'а: разница между high watermark и зафиксированным смещением партиции",
+}, []string{"topic", "partition"})
+
+// lagSeries метки серии consumerLag одной партиции
+type lagSeries struct {
+	topic     string
+	partition string
+}
+
+// partitionLag вычисляет отставание по high watermark и зафиксированному смещению.
+// Если группа еще ничего не зафиксировала, отставанием считаются все сообщения партиции (от low)
+func partitionLag(low, high int64, committed kafka.Offset) int64 {
+	start := int64(committed)
+	if committed < 0 {
+		start = low
+	}
+	if lag := high - start; lag > 0 {
+		return lag
+	}
+	return 0
+}
+
+// reportLag периодически обновляет consumerLag для партиций, назначенных consumer'у.
+// Останавливается вместе с ctx consumer'а и удаляет серии его партиций
+func (k *KafkaMessaging) reportLag(ctx context.Context, consumer *kafka.Consumer) {
+	ticker := time.NewTicker(lagReportInterval)
+	defer ticker.Stop()
+
+	reported := make(map[lagSeries]bool)
+	defer func() {
+		for series := range reported {
+			consumerLag.DeleteLabelValues(series.topic, series.partition)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reported = k.updateLag(consumer, reported)
+		}
+	}
+}
+
+// updateLag обновляет метрику по текущему назначению партиций и убирает серии отозванных партиций
+func (k *KafkaMessaging) updateLag(consumer *kafka.Consumer, previous map[lagSeries]bool) map[lagSeries]bool {
+	assigned, err := consumer.Assignment()
+	if err != nil {
+		k.logger.Warn("Не удалось получить назначенные партиции",
+			interfaces.LogField{Key: "error", Value: err.Error()})
+		return previous
+	}
+
+	committed, err := consumer.Committed(assigned, lagQueryTimeoutMs)
+	if err != nil {
+		k.logger.Warn("Не удалось получить зафиксированные смещения",
+			interfaces.LogField{Key: "error", Value: err.Error()})
+		return previous
+	}
+
+	current := make(map[lagSeries]bool, len(committed))
+	for _, tp := range committed {
+		low, high, err := consumer.QueryWatermarkOffsets(*tp.Topic, tp.Partition, lagQueryTimeoutMs)
+		if err != nil {
+			k.logger.Warn("Не удалось получить границы смещений партиции",
+				interfaces.LogField{Key: "topic", Value: *tp.Topic},
+				interfaces.LogField{Key: "partition", Value: tp.Partition},
+				interfaces.LogField{Key: "error", Value: err.Error()})
+			continue
+		}
+
+		series := lagSeries{topic: *tp.Topic, partition: strconv.Itoa(int(tp.Partition))}
+		consumerLag.WithLabelValues(series.topic, series.partition).Set(float64(partitionLag(low, high, tp.Offset)))
+		current[series] = true
+	}
+
+	for series := range previous {
+		if !current[series] {
+			consumerLag.DeleteLabelValues(series.topic, series.partition)
+		}
+	}
+	return current
+}
@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPartitionLag(t *testing.T) {
+	tests := []struct {
+		name      string
+		low, high int64
+		committed kafka.Offset
+		want      int64
+	}{
+		{name: "behind", low: 0, high: 10, committed: 3, want: 7},
+		{name: "caught up", low: 0, high: 10, committed: 10, want: 0},
+		{name: "nothing committed", low: 4, high: 10, committed: kafka.OffsetInvalid, want: 6},
+		{name: "committed past retention", low: 8, high: 10, committed: 12, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := partitionLag(tt.low, tt.high, tt.committed); got != tt.want {
+			t.Errorf("%s: partitionLag(%d, %d, %d) = %d, want %d", tt.name, tt.low, tt.high, tt.committed, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateLag(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	defer cluster.Close()
+
+	const topic = "lag-events"
+	k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+	delivery := make(chan kafka.Event, 10)
+	for i := 0; i < 10; i++ {
+		msg := k.newMessage(context.Background(), topic, "", []byte(`{}`))
+		msg.TopicPartition.Partition = 0
+		if err := k.produce(context.Background(), msg, delivery); err != nil {
+			t.Fatalf("produce: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if msg := (<-delivery).(*kafka.Message); msg.TopicPartition.Error != nil {
+			t.Fatalf("delivery: %v", msg.TopicPartition.Error)
+		}
+	}
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  cluster.BootstrapServers(),
+		"group.id":           "lag-test",
+		"enable.auto.commit": false,
+		"log_level":          0,
+	})
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer consumer.Close()
+
+	topicName := topic
+	partition := kafka.TopicPartition{Topic: &topicName, Partition: 0}
+	if err := consumer.Assign([]kafka.TopicPartition{partition}); err != nil {
+		t.Fatalf("Assign: %v", err)
+	}
+	// Группа обработала первые 3 сообщения
+	partition.Offset = 3
+	if _, err := consumer.CommitOffsets([]kafka.TopicPartition{partition}); err != nil {
+		t.Fatalf("CommitOffsets: %v", err)
+	}
+
+	// Серия партиции, которая больше не назначена consumer'у
+	consumerLag.WithLabelValues(topic, "9").Set(5)
+	previous := map[lagSeries]bool{{topic: topic, partition: "9"}: true}
+
+	reported := k.updateLag(consumer, previous)
+	t.Cleanup(func() { consumerLag.DeleteLabelValues(topic, "0") })
+
+	if lag := testutil.ToFloat64(consumerLag.WithLabelValues(topic, "0")); lag != 7 {
+		t.Fatalf("kafka_consumer_lag = %v, want 7", lag)
+	}
+	if len(reported) != 1 || !reported[lagSeries{topic: topic, partition: "0"}] {
+		t.Fatalf("reported = %v, want only partition 0", reported)
+	}
+	if consumerLag.DeleteLabelValues(topic, "9") {
+		t.Fatal("series of the revoked partition is still exported")
+	}
+}
@@ -0,0 +1,247 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/google/uuid"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memorySubscription подписчик InMemoryMessaging на набор топиков
+type memorySubscription struct {
+	topics  map[string]struct{}
+	handler interfaces.MessageHandler
+	config  interfaces.SubscriptionConfig
+}
+
+// InMemoryMessaging реализует MessagingPort в памяти процесса для тестов.
+// Опубликованные сообщения сохраняются и синхронно, в вызове Publish, передаются всем подписчикам
+// топика. Ошибка обработчика, как и в Kafka, не возвращается издателю: после MaxRetries попыток
+// без задержки сообщение попадает в DeadLetters, если политика подписки не FailureDrop
+type InMemoryMessaging struct {
+	mu            sync.Mutex
+	published     []interfaces.Message
+	deadLetters   []interfaces.Message
+	subscriptions map[int]*memorySubscription
+	nextID        int
+	closed        bool
+}
+
+var _ interfaces.MessagingPort = (*InMemoryMessaging)(nil)
+
+// NewInMemoryMessaging создает систему обмена сообщениями в памяти
+func NewInMemoryMessaging() *InMemoryMessaging {
+	return &InMemoryMessaging{
+		subscriptions: make(map[int]*memorySubscription),
+	}
+}
+
+// Published возвращает копию всех опубликованных сообщений в порядке публикации
+func (m *InMemoryMessaging) Published() []interfaces.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]interfaces.Message(nil), m.published...)
+}
+
+// PublishedTo возвращает сообщения, опубликованные в topic
+func (m *InMemoryMessaging) PublishedTo(topic string) []interfaces.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []interfaces.Message
+	for _, msg := range m.published {
+		if msg.Topic == topic {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// DeadLetters возвращает сообщения, которые подписчики не смогли обработать
+func (m *InMemoryMessaging) DeadLetters() []interfaces.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]interfaces.Message(nil), m.deadLetters...)
+}
+
+// Reset очищает опубликованные сообщения и DeadLetters, подписки сохраняются
+func (m *InMemoryMessaging) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = nil
+	m.deadLetters = nil
+}
+
+func (m *InMemoryMessaging) Publish(ctx context.Context, topic string, message []byte) error {
+	return m.PublishWithKey(ctx, topic, "", message)
+}
+
+func (m *InMemoryMessaging) PublishWithKey(ctx context.Context, topic, key string, message []byte) error {
+	msg, subscribers, err := m.record(ctx, topic, key, message)
+	if err != nil {
+		return err
+	}
+	m.dispatch(msg, subscribers)
+	return nil
+}
+
+func (m *InMemoryMessaging) PublishSync(ctx context.Context, topic string, message []byte) error {
+	return m.PublishWithKey(ctx, topic, "", message)
+}
+
+func (m *InMemoryMessaging) PublishBatch(ctx context.Context, topic string, messages [][]byte) error {
+	return m.PublishBatchWithKeys(ctx, topic, make([]string, len(messages)), messages)
+}
+
+func (m *InMemoryMessaging) PublishBatchWithKeys(ctx context.Context, topic string, keys []string, messages [][]byte) error {
+	if len(keys) != len(messages) {
+		return fmt.Errorf("число ключей (%d) не совпадает с числом сообщений (%d)", len(keys), len(messages))
+	}
+	for i, message := range messages {
+		if err := m.PublishWithKey(ctx, topic, keys[i], message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record сохраняет сообщение и возвращает подписчиков его топика. Заголовки заполняются так же, как в Kafka
+func (m *InMemoryMessaging) record(ctx context.Context, topic, key string, message []byte) (interfaces.Message, []*memorySubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return interfaces.Message{}, nil, fmt.Errorf("messaging is closed")
+	}
+
+	msg := interfaces.Message{
+		ID:    uuid.New().String(),
+		Topic: topic,
+		Key:   key,
+		Value: append([]byte(nil), message...),
+		Headers: map[string]string{
+			"timestamp": fmt.Sprintf("%d", time.Now().UnixNano()),
+		},
+		Metadata:    make(map[string]interface{}),
+		PublishedAt: time.Now(),
+	}
+	msg.Headers["message_id"] = msg.ID
+	if tenantID, ok := contextkeys.TenantFromContext(ctx); ok && tenantID != "" {
+		msg.TenantID = tenantID
+		msg.Headers["tenant_id"] = tenantID
+	}
+	if traceID, ok := contextkeys.TraceIDFromContext(ctx); ok && traceID != "" {
+		msg.Headers["trace_id"] = traceID
+	}
+	m.published = append(m.published, msg)
+
+	// Подписчики получают сообщение в порядке подписки
+	ids := make([]int, 0, len(m.subscriptions))
+	for id, sub := range m.subscriptions {
+		if _, ok := sub.topics[topic]; ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	subscribers := make([]*memorySubscription, 0, len(ids))
+	for _, id := range ids {
+		subscribers = append(subscribers, m.subscriptions[id])
+	}
+
+	return msg, subscribers, nil
+}
+
+// dispatch передает сообщение подписчикам. Блокировка не удерживается, чтобы обработчики могли публиковать
+func (m *InMemoryMessaging) dispatch(msg interfaces.Message, subscribers []*memorySubscription) {
+	for _, sub := range subscribers {
+		delivery := msg
+		delivery.Headers = make(map[string]string, len(msg.Headers))
+		for k, v := range msg.Headers {
+			delivery.Headers[k] = v
+		}
+
+		msgCtx := context.Background()
+		if delivery.TenantID != "" {
+			msgCtx = contextkeys.WithTenant(msgCtx, delivery.TenantID)
+		}
+		if traceID, ok := delivery.Headers["trace_id"]; ok {
+			msgCtx = contextkeys.WithTraceID(msgCtx, traceID)
+		}
+
+		attempts := max(sub.config.MaxRetries, 1)
+		var err error
+		for attempt := 0; attempt < attempts; attempt++ {
+			delivery.Attempts++
+			if err = sub.handler(msgCtx, &delivery); err == nil {
+				break
+			}
+		}
+
+		if err != nil && sub.config.OnFailure != interfaces.FailureDrop {
+			m.mu.Lock()
+			m.deadLetters = append(m.deadLetters, delivery)
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *InMemoryMessaging) Subscribe(ctx context.Context, topic string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	return m.SubscribeMulti(ctx, []string{topic}, handler, config...)
+}
+
+func (m *InMemoryMessaging) SubscribeMulti(ctx context.Context, topics []string, handler interfaces.MessageHandler, config ...interfaces.SubscriptionConfig) (func() error, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("не указаны топики для подписки")
+	}
+	if len(config) > 1 {
+		return nil, fmt.Errorf("передано более одной конфигурации подписки")
+	}
+
+	sub := &memorySubscription{
+		topics:  make(map[string]struct{}, len(topics)),
+		handler: handler,
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = struct{}{}
+	}
+	if len(config) == 1 {
+		sub.config = config[0]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, fmt.Errorf("messaging is closed")
+	}
+
+	id := m.nextID
+	m.nextID++
+	m.subscriptions[id] = sub
+
+	unsubscribe := func() error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.subscriptions, id)
+		return nil
+	}
+	return unsubscribe, nil
+}
+
+func (m *InMemoryMessaging) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close отменяет все подписки, после него публикация возвращает ошибку
+func (m *InMemoryMessaging) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.subscriptions = make(map[int]*memorySubscription)
+	return nil
+}
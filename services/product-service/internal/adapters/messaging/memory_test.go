@@ -0,0 +1,169 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+func TestInMemoryMessagingDeliversToSubscribers(t *testing.T) {
+	bus := NewInMemoryMessaging()
+	ctx := contextkeys.WithTenant(context.Background(), "tenant-1")
+
+	var events, multi []string
+	unsubscribe, err := bus.Subscribe(ctx, "product-events", func(ctx context.Context, msg *interfaces.Message) error {
+		if tenantID, _ := contextkeys.TenantFromContext(ctx); tenantID != msg.TenantID {
+			t.Errorf("handler context tenant = %q, want %q", tenantID, msg.TenantID)
+		}
+		events = append(events, string(msg.Value))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := bus.SubscribeMulti(ctx, []string{"product-events", "product-commands"}, func(ctx context.Context, msg *interfaces.Message) error {
+		multi = append(multi, msg.Topic+":"+string(msg.Value))
+		return nil
+	}); err != nil {
+		t.Fatalf("SubscribeMulti: %v", err)
+	}
+
+	if err := bus.PublishWithKey(ctx, "product-events", "product-1", []byte("created")); err != nil {
+		t.Fatalf("PublishWithKey: %v", err)
+	}
+	if err := bus.Publish(ctx, "product-commands", []byte("recache")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := bus.Publish(ctx, "audit", []byte("ignored")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Доставка синхронная: к возврату Publish обработчики уже вызваны
+	if len(events) != 1 || events[0] != "created" {
+		t.Fatalf("events = %v, want [created]", events)
+	}
+	if len(multi) != 2 || multi[0] != "product-events:created" || multi[1] != "product-commands:recache" {
+		t.Fatalf("multi-topic subscriber got %v", multi)
+	}
+
+	published := bus.PublishedTo("product-events")
+	if len(published) != 1 {
+		t.Fatalf("published to product-events = %d, want 1", len(published))
+	}
+	msg := published[0]
+	if msg.Key != "product-1" || msg.TenantID != "tenant-1" || msg.Headers["tenant_id"] != "tenant-1" || msg.Headers["message_id"] != msg.ID {
+		t.Fatalf("published message = %+v", msg)
+	}
+	if len(bus.Published()) != 3 {
+		t.Fatalf("published = %d, want all 3 messages captured", len(bus.Published()))
+	}
+
+	if err := unsubscribe(); err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+	if err := bus.Publish(ctx, "product-events", []byte("updated")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(events) != 1 || len(multi) != 3 {
+		t.Fatalf("events = %v, multi = %v, want delivery only to the remaining subscriber", events, multi)
+	}
+
+	bus.Reset()
+	if len(bus.Published()) != 0 {
+		t.Fatal("Reset kept published messages")
+	}
+}
+
+func TestInMemoryMessagingHandlerPublishes(t *testing.T) {
+	bus := NewInMemoryMessaging()
+	ctx := context.Background()
+
+	// Обработчик команды публикует событие: блокировка не удерживается во время доставки
+	if _, err := bus.Subscribe(ctx, "product-commands", func(ctx context.Context, msg *interfaces.Message) error {
+		return bus.Publish(ctx, "product-events", msg.Value)
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	var delivered int
+	if _, err := bus.Subscribe(ctx, "product-events", func(ctx context.Context, msg *interfaces.Message) error {
+		delivered++
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if err := bus.Publish(ctx, "product-commands", []byte("recache")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if delivered != 1 || len(bus.PublishedTo("product-events")) != 1 {
+		t.Fatalf("delivered = %d, want the event published by the handler", delivered)
+	}
+}
+
+func TestInMemoryMessagingFailures(t *testing.T) {
+	ctx := context.Background()
+	failing := func(calls *int) interfaces.MessageHandler {
+		return func(ctx context.Context, msg *interfaces.Message) error {
+			*calls++
+			return errors.New("invalid payload")
+		}
+	}
+
+	t.Run("dead letter", func(t *testing.T) {
+		bus := NewInMemoryMessaging()
+		var calls int
+		if _, err := bus.Subscribe(ctx, "product-commands", failing(&calls), interfaces.SubscriptionConfig{MaxRetries: 3}); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+
+		// Ошибка обработчика не возвращается издателю
+		if err := bus.Publish(ctx, "product-commands", []byte("bad")); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		deadLetters := bus.DeadLetters()
+		if calls != 3 || len(deadLetters) != 1 || deadLetters[0].Attempts != 3 {
+			t.Fatalf("calls = %d, dead letters = %+v, want 3 attempts and one dead letter", calls, deadLetters)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		bus := NewInMemoryMessaging()
+		var calls int
+		config := interfaces.SubscriptionConfig{MaxRetries: 2, OnFailure: interfaces.FailureDrop}
+		if _, err := bus.Subscribe(ctx, "product-events", failing(&calls), config); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+
+		if err := bus.Publish(ctx, "product-events", []byte("bad")); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if calls != 2 || len(bus.DeadLetters()) != 0 {
+			t.Fatalf("calls = %d, dead letters = %d, want 2 attempts and the message dropped", calls, len(bus.DeadLetters()))
+		}
+	})
+}
+
+func TestInMemoryMessagingClose(t *testing.T) {
+	bus := NewInMemoryMessaging()
+	ctx := context.Background()
+
+	if _, err := bus.Subscribe(ctx, "product-events", func(ctx context.Context, msg *interfaces.Message) error { return nil }); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := bus.Publish(ctx, "product-events", []byte("late")); err == nil {
+		t.Fatal("Publish after Close succeeded")
+	}
+	if _, err := bus.Subscribe(ctx, "product-events", func(ctx context.Context, msg *interfaces.Message) error { return nil }); err == nil {
+		t.Fatal("Subscribe after Close succeeded")
+	}
+	if _, err := bus.SubscribeMulti(ctx, nil, nil); err == nil {
+		t.Fatal("SubscribeMulti without topics succeeded")
+	}
+}
@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+const (
+	// defaultAutoCommitInterval интервал автоматической фиксации смещений по умолчанию
+	defaultAutoCommitInterval = 5 * time.Second
+	// seekTimeoutMs таймаут перемотки partition к необработанному сообщению
+	seekTimeoutMs = 5000
+)
+
+// offsetCommitter операции kafka.Consumer со смещениями, которые нужны обработке сообщения
+type offsetCommitter interface {
+	CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error)
+	StoreOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error)
+	Seek(partition kafka.TopicPartition, timeoutMs int) error
+}
+
+// settleOffset фиксирует смещение вручную, если сообщение обработано или сохранено в DLQ.
+// Иначе перематывает partition к этому сообщению, чтобы оно было доставлено повторно,
+// и следующее обработанное сообщение не зафиксировало смещение поверх него.
+// Возвращает false, если обработку нужно прекратить из-за отмены контекста
+func (k *KafkaMessaging) settleOffset(ctx context.Context, consumer offsetCommitter, msg *kafka.Message, policy subscriptionPolicy, handled bool) bool {
+	if handled {
+		if _, err := consumer.CommitMessage(msg); err != nil {
+			// Смещение зафиксирует следующий успешный коммит, в худшем случае сообщение придет повторно
+			k.logger.Warn("Ошибка фиксации смещения",
+				interfaces.LogField{Key: "topic", Value: *msg.TopicPartition.Topic},
+				interfaces.LogField{Key: "partition", Value: msg.TopicPartition.Partition},
+				interfaces.LogField{Key: "offset", Value: msg.TopicPartition.Offset},
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+		}
+		return true
+	}
+
+	// Пауза перед повторной доставкой, чтобы не нагружать зависимость, из-за которой обработка не удалась
+	select {
+	case <-time.After(withJitter(retryBackoff(policy.retryBackoff, policy.maxRetries))):
+	case <-ctx.Done():
+		return false
+	}
+
+	if err := consumer.Seek(msg.TopicPartition, seekTimeoutMs); err != nil {
+		k.logger.Error("Ошибка перемотки к необработанному сообщению",
+			interfaces.LogField{Key: "topic", Value: *msg.TopicPartition.Topic},
+			interfaces.LogField{Key: "partition", Value: msg.TopicPartition.Partition},
+			interfaces.LogField{Key: "offset", Value: msg.TopicPartition.Offset},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+	}
+	return true
+}
+
+// abandonMessage оставляет смещение сообщения, обработка которого прервана остановкой consumer,
+// незафиксированным. При ручной фиксации достаточно не вызывать CommitMessage. При автоматической
+// смещение уже сохранено при получении сообщения, поэтому оно возвращается к этому сообщению,
+// иначе автокоммит при закрытии consumer'а пропустил бы его
+func (k *KafkaMessaging) abandonMessage(ctx context.Context, consumer offsetCommitter, msg *kafka.Message, message *interfaces.Message) {
+	if k.consumerConfig.AutoCommit {
+		if _, err := consumer.StoreOffsets([]kafka.TopicPartition{msg.TopicPartition}); err != nil {
+			k.logger.ErrorWithContext(ctx, "Не удалось вернуть смещение к необработанному сообщению, оно может быть пропущено",
+				interfaces.LogField{Key: "topic", Value: *msg.TopicPartition.Topic},
+				interfaces.LogField{Key: "partition", Value: msg.TopicPartition.Partition},
+				interfaces.LogField{Key: "offset", Value: msg.TopicPartition.Offset},
+				interfaces.LogField{Key: "message_id", Value: message.ID},
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+			return
+		}
+	}
+
+	k.logger.WarnWithContext(ctx, "Обработка сообщения прервана остановкой consumer, смещение не зафиксировано",
+		interfaces.LogField{Key: "topic", Value: *msg.TopicPartition.Topic},
+		interfaces.LogField{Key: "partition", Value: msg.TopicPartition.Partition},
+		interfaces.LogField{Key: "offset", Value: msg.TopicPartition.Offset},
+		interfaces.LogField{Key: "message_id", Value: message.ID},
+		interfaces.LogField{Key: "attempts", Value: message.Attempts},
+	)
+}
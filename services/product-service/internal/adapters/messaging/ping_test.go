@@ -0,0 +1,51 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func TestPingUnreachableBroker(t *testing.T) {
+	// На порту 1 брокера нет, соединение отклоняется
+	k := newTestProducer(t, "127.0.0.1:1", time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := k.Ping(ctx); err == nil {
+		t.Fatal("Ping succeeded without a reachable broker")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Ping took %v, want it bounded by the context deadline", elapsed)
+	}
+}
+
+func TestPingExpiredContext(t *testing.T) {
+	k := newTestProducer(t, "127.0.0.1:1", time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	if err := k.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Ping = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPingReachableBroker(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+
+	k := newTestProducer(t, cluster.BootstrapServers(), time.Second)
+	if err := k.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// queueFullRetries число повторов Produce при переполненной очереди producer'а
+	queueFullRetries = 5
+	// queueFullBackoff базовая задержка перед повтором, удваивается с каждой попыткой
+	queueFullBackoff = 50 * time.Millisecond
+	// queueReportInterval период обновления метрики длины очереди producer'а
+	queueReportInterval = 5 * time.Second
+)
+
+// ErrProducerQueueFull возвращается, если очередь producer'а осталась переполненной после всех повторов
+var ErrProducerQueueFull = errors.New("kafka producer queue is full")
+
+var (
+	// producerQueueLength число сообщений в очереди producer'а, еще не подтвержденных брокером
+	producerQueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kafka_producer_queue_length",
+		Help: "Число сообщений и запросов в очереди Kafka producer'а, ожидающих отправки или подтверждения",
+	})
+
+	// producerQueueFull число отказов Produce из-за переполненной очереди
+	producerQueueFull = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_producer_queue_full_total",
+		Help: "Число отказов Produce из-за переполненной очереди producer'а: retried - повторено, rejected - ошибка вызывающему",
+	}, []string{"result"})
+)
+
+// isQueueFull сообщает, отклонил ли producer сообщение из-за переполненной локальной очереди
+func isQueueFull(err error) bool {
+	var kafkaErr kafka.Error
+	return errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrQueueFull
+}
+
+// produceWithRetry вызывает produce и при переполненной очереди повторяет его с экспоненциальной задержкой,
+// давая producer'у время отправить накопленные сообщения. Если очередь не освободилась за queueFullRetries
+// повторов, возвращает ErrProducerQueueFull. Остальные ошибки возвращаются сразу
+func produceWithRetry(ctx context.Context, produce func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := produce()
+		if err == nil || !isQueueFull(err) {
+			return err
+		}
+		if attempt >= queueFullRetries {
+			producerQueueFull.WithLabelValues("rejected").Inc()
+			return fmt.Errorf("%w: %v", ErrProducerQueueFull, err)
+		}
+		producerQueueFull.WithLabelValues("retried").Inc()
+
+		timer := time.NewTimer(withJitter(retryBackoff(queueFullBackoff, attempt)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// produce ставит сообщение в очередь producer'а, повторяя попытку при переполненной очереди
+func (k *KafkaMessaging) produce(ctx context.Context, msg *kafka.Message, delivery chan kafka.Event) error {
+	err := produceWithRetry(ctx, func() error {
+		return k.producer.Produce(msg, delivery)
+	})
+	if errors.Is(err, ErrProducerQueueFull) {
+		k.logger.Warn("Очередь Kafka producer'а переполнена, сообщение не отправлено",
+			interfaces.LogField{Key: "topic", Value: *msg.TopicPartition.Topic},
+			interfaces.LogField{Key: "queue_length", Value: k.producer.Len()},
+		)
+	}
+	return err
+}
+
+// reportQueueLength периодически обновляет producerQueueLength до закрытия stop
+func (k *KafkaMessaging) reportQueueLength(stop <-chan struct{}) {
+	ticker := time.NewTicker(queueReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			producerQueueLength.Set(float64(k.producer.Len()))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestProduceWithRetry(t *testing.T) {
+	queueFull := kafka.NewError(kafka.ErrQueueFull, "Local: Queue full", false)
+
+	t.Run("queue drains", func(t *testing.T) {
+		var calls int
+		err := produceWithRetry(context.Background(), func() error {
+			calls++
+			if calls <= 2 {
+				return queueFull
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("calls = %d, err = %v, want success on the third attempt", calls, err)
+		}
+	})
+
+	t.Run("queue stays full", func(t *testing.T) {
+		rejected := testutil.ToFloat64(producerQueueFull.WithLabelValues("rejected"))
+		var calls int
+		err := produceWithRetry(context.Background(), func() error {
+			calls++
+			return queueFull
+		})
+		if !errors.Is(err, ErrProducerQueueFull) || calls != queueFullRetries+1 {
+			t.Fatalf("calls = %d, err = %v, want ErrProducerQueueFull after %d retries", calls, err, queueFullRetries)
+		}
+		if got := testutil.ToFloat64(producerQueueFull.WithLabelValues("rejected")); got != rejected+1 {
+			t.Fatalf("rejected = %v, want %v", got, rejected+1)
+		}
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		var calls int
+		err := produceWithRetry(context.Background(), func() error {
+			calls++
+			return kafka.NewError(kafka.ErrMsgSizeTooLarge, "Broker: Message size too large", false)
+		})
+		if err == nil || errors.Is(err, ErrProducerQueueFull) || calls != 1 {
+			t.Fatalf("calls = %d, err = %v, want the error returned at once", calls, err)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var calls int
+		err := produceWithRetry(ctx, func() error {
+			calls++
+			cancel()
+			return queueFull
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Fatalf("calls = %d, err = %v, want context.Canceled", calls, err)
+		}
+	})
+}
+
+func TestPublishWithFullProducerQueue(t *testing.T) {
+	// Брокер недоступен, поэтому очередь на одно сообщение не освобождается
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers":            "127.0.0.1:1",
+		"queue.buffering.max.messages": 1,
+		"log_level":                    0,
+	})
+	if err != nil {
+		t.Fatalf("NewProducer: %v", err)
+	}
+	t.Cleanup(producer.Close)
+	k := newTestKafkaMessaging(t, nil)
+	k.producer = producer
+
+	ctx := context.Background()
+	if err := k.Publish(ctx, "product-events", []byte(`{}`)); err != nil {
+		t.Fatalf("first Publish: %v", err)
+	}
+
+	retried := testutil.ToFloat64(producerQueueFull.WithLabelValues("retried"))
+	err = k.Publish(ctx, "product-events", []byte(`{}`))
+	if !errors.Is(err, ErrProducerQueueFull) {
+		t.Fatalf("Publish = %v, want ErrProducerQueueFull", err)
+	}
+	if got := testutil.ToFloat64(producerQueueFull.WithLabelValues("retried")); got != retried+queueFullRetries {
+		t.Fatalf("retried = %v, want %d more", got, queueFullRetries)
+	}
+	// Len учитывает и служебные запросы, поэтому проверяется только, что первое сообщение не отправлено
+	if producer.Len() == 0 {
+		t.Fatal("queue is empty, want the first message still queued")
+	}
+}
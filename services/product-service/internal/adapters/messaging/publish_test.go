@@ -0,0 +1,212 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// newTestProducer создает KafkaMessaging с одним producer'ом на bootstrap.
+// Отчеты о доставке без канала вызывающего вычитываются, как в NewKafkaMessagingWithConfig
+func newTestProducer(t *testing.T, bootstrap string, messageTimeout time.Duration) *KafkaMessaging {
+	t.Helper()
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers":  bootstrap,
+		"message.timeout.ms": int(messageTimeout.Milliseconds()),
+		"linger.ms":          5,
+		"log_level":          0, // ошибки подключения к недоступному брокеру ожидаемы
+	})
+	if err != nil {
+		t.Fatalf("NewProducer: %v", err)
+	}
+	go func() {
+		for range producer.Events() {
+		}
+	}()
+	t.Cleanup(producer.Close)
+
+	k := newTestKafkaMessaging(t, nil)
+	k.producer = producer
+	return k
+}
+
+// readMessages читает из топика с начала, пока не получит count сообщений или не истечет таймаут
+func readMessages(t *testing.T, bootstrap, topic string, count int) []*kafka.Message {
+	t.Helper()
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": bootstrap,
+		"group.id":          "test-reader-" + topic,
+		"auto.offset.reset": "earliest",
+		"log_level":         0,
+	})
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer consumer.Close()
+	if err := consumer.Subscribe(topic, nil); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	var messages []*kafka.Message
+	deadline := time.Now().Add(10 * time.Second)
+	for len(messages) < count && time.Now().Before(deadline) {
+		if msg, err := consumer.ReadMessage(100 * time.Millisecond); err == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func TestPublishSync(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	defer cluster.Close()
+
+	k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+	if err := k.PublishSync(context.Background(), "product-events", []byte(`{"event_type":"product.updated"}`)); err != nil {
+		t.Fatalf("PublishSync: %v", err)
+	}
+}
+
+func TestPublishSyncDeliveryFailure(t *testing.T) {
+	// Брокер недоступен: сообщение не будет доставлено до истечения message.timeout.ms
+	k := newTestProducer(t, "127.0.0.1:1", 200*time.Millisecond)
+
+	err := k.PublishSync(context.Background(), "product-events", []byte(`{}`))
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) || kafkaErr.Code() != kafka.ErrMsgTimedOut {
+		t.Fatalf("err = %v, want the delivery timeout reported to the caller", err)
+	}
+}
+
+func TestPublishSyncContextCanceled(t *testing.T) {
+	k := newTestProducer(t, "127.0.0.1:1", time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := k.PublishSync(ctx, "product-events", []byte(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context deadline", err)
+	}
+}
+
+func TestPublishBatch(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	defer cluster.Close()
+
+	k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+
+	const count = 50
+	keys := make([]string, count)
+	messages := make([][]byte, count)
+	for i := range messages {
+		keys[i] = fmt.Sprintf("product-%d", i)
+		messages[i] = []byte(fmt.Sprintf(`{"n":%d}`, i))
+	}
+	if err := k.PublishBatchWithKeys(context.Background(), "product-events", keys, messages); err != nil {
+		t.Fatalf("PublishBatchWithKeys: %v", err)
+	}
+
+	received := make(map[string]string)
+	for _, msg := range readMessages(t, cluster.BootstrapServers(), "product-events", count) {
+		received[string(msg.Key)] = string(msg.Value)
+	}
+
+	if len(received) != count {
+		t.Fatalf("received %d messages, want %d", len(received), count)
+	}
+	for i := range messages {
+		if received[keys[i]] != string(messages[i]) {
+			t.Fatalf("message %s = %q, want %q", keys[i], received[keys[i]], messages[i])
+		}
+	}
+}
+
+func TestPublishBatchDeliveryFailure(t *testing.T) {
+	k := newTestProducer(t, "127.0.0.1:1", 200*time.Millisecond)
+
+	err := k.PublishBatch(context.Background(), "product-events", [][]byte{[]byte(`{"n":0}`), []byte(`{"n":1}`), []byte(`{"n":2}`)})
+
+	var batchErr *interfaces.BatchPublishError
+	if !errors.As(err, &batchErr) || len(batchErr.Failed) != 3 {
+		t.Fatalf("err = %v, want all 3 messages reported as failed", err)
+	}
+	for i := 0; i < 3; i++ {
+		var kafkaErr kafka.Error
+		if !errors.As(batchErr.Failed[i], &kafkaErr) || kafkaErr.Code() != kafka.ErrMsgTimedOut {
+			t.Fatalf("failed[%d] = %v, want a delivery timeout", i, batchErr.Failed[i])
+		}
+	}
+	if !strings.Contains(err.Error(), "failed to publish 3 message(s): #0: ") {
+		t.Fatalf("error = %q, want the failed indexes listed", err)
+	}
+}
+
+func TestPublishBatchEmpty(t *testing.T) {
+	k := newTestProducer(t, "127.0.0.1:1", time.Minute)
+
+	if err := k.PublishBatch(context.Background(), "product-events", nil); err != nil {
+		t.Fatalf("PublishBatch(nil) = %v, want nil", err)
+	}
+}
+
+func TestPublishWithKey(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster: %v", err)
+	}
+	defer cluster.Close()
+
+	k := newTestProducer(t, cluster.BootstrapServers(), 5*time.Second)
+	// Отчеты о доставке показывают ключ и партицию каждого сообщения
+	delivery := make(chan kafka.Event, 4)
+	for i := 0; i < 4; i++ {
+		if err := k.produce(context.Background(), k.newMessage(context.Background(), "product-events", "product-1", []byte(`{}`)), delivery); err != nil {
+			t.Fatalf("produce: %v", err)
+		}
+	}
+
+	partitions := make(map[int32]bool)
+	for i := 0; i < 4; i++ {
+		msg := (<-delivery).(*kafka.Message)
+		if msg.TopicPartition.Error != nil {
+			t.Fatalf("delivery: %v", msg.TopicPartition.Error)
+		}
+		if string(msg.Key) != "product-1" {
+			t.Fatalf("key = %q, want product-1", msg.Key)
+		}
+		partitions[msg.TopicPartition.Partition] = true
+	}
+	if len(partitions) != 1 {
+		t.Fatalf("events of one product landed in partitions %v, want one", partitions)
+	}
+
+	// PublishWithKey отправляет сообщение тем же путем
+	if err := k.PublishWithKey(context.Background(), "product-events", "product-1", []byte(`{}`)); err != nil {
+		t.Fatalf("PublishWithKey: %v", err)
+	}
+}
+
+func TestNewMessageKey(t *testing.T) {
+	k := newTestKafkaMessaging(t, nil)
+
+	if msg := k.newMessage(context.Background(), "product-events", "product-1", []byte(`{}`)); string(msg.Key) != "product-1" ||
+		msg.TopicPartition.Partition != kafka.PartitionAny {
+		t.Fatalf("message = %+v, want key product-1 and partition chosen by the partitioner", msg.TopicPartition)
+	}
+	if msg := k.newMessage(context.Background(), "product-events", "", []byte(`{}`)); msg.Key != nil {
+		t.Fatalf("key = %q, want no key", msg.Key)
+	}
+}
@@ -0,0 +1,102 @@
+package messaging
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultSequenceTrackerSize число ключей, которое SequenceTracker помнит по умолчанию
+const DefaultSequenceTrackerSize = 100_000
+
+// SequenceTracker отслеживает последний обработанный номер события по ключу (обычно tenant и product).
+// Позволяет потребителю отбрасывать события, доставленные не по порядку или повторно.
+// Хранит не более maxKeys ключей: при переполнении забывается ключ, который дольше всех не обновлялся,
+// и следующее событие по нему принимается без проверки порядка
+type SequenceTracker struct {
+	mu      sync.Mutex
+	maxKeys int
+	entries map[string]*list.Element
+	// order ключи от недавно обновленных к давно не обновлявшимся
+	order *list.List
+}
+
+// sequenceEntry последний принятый номер события по ключу
+type sequenceEntry struct {
+	key      string
+	sequence int64
+}
+
+// NewSequenceTracker создает SequenceTracker, помнящий не более maxKeys ключей.
+// Неположительный maxKeys заменяется DefaultSequenceTrackerSize
+func NewSequenceTracker(maxKeys int) *SequenceTracker {
+	if maxKeys <= 0 {
+		maxKeys = DefaultSequenceTrackerSize
+	}
+	return &SequenceTracker{
+		maxKeys: maxKeys,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Stale сообщает, что событие с номером sequence не новее последнего принятого для ключа.
+// Ничего не запоминает: потребитель вызывает Accept, только когда событие успешно обработано.
+// События без номера (sequence <= 0) устаревшими не считаются
+func (t *SequenceTracker) Stale(key string, sequence int64) bool {
+	if sequence <= 0 {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.entries[key]
+	return ok && sequence <= elem.Value.(*sequenceEntry).sequence
+}
+
+// Accept возвращает true, если событие с номером sequence новее последнего принятого для ключа,
+// и запоминает его. События без номера (sequence <= 0) принимаются всегда.
+func (t *SequenceTracker) Accept(key string, sequence int64) bool {
+	if sequence <= 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.entries[key]; ok {
+		entry := elem.Value.(*sequenceEntry)
+		if sequence <= entry.sequence {
+			return false
+		}
+		entry.sequence = sequence
+		t.order.MoveToFront(elem)
+		return true
+	}
+
+	t.entries[key] = t.order.PushFront(&sequenceEntry{key: key, sequence: sequence})
+	if t.order.Len() > t.maxKeys {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(*sequenceEntry).key)
+	}
+	return true
+}
+
+// Last возвращает последний принятый номер события для ключа
+func (t *SequenceTracker) Last(key string) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.entries[key]; ok {
+		return elem.Value.(*sequenceEntry).sequence
+	}
+	return 0
+}
+
+// Len возвращает число запомненных ключей
+func (t *SequenceTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.order.Len()
+}
@@ -0,0 +1,48 @@
+package messaging
+
+import "testing"
+
+func TestSequenceTracker(t *testing.T) {
+	tracker := NewSequenceTracker(10)
+
+	if tracker.Stale("tenant-1:product-1", 1) || !tracker.Accept("tenant-1:product-1", 1) {
+		t.Fatal("first event rejected")
+	}
+	if !tracker.Stale("tenant-1:product-1", 1) || tracker.Accept("tenant-1:product-1", 1) {
+		t.Fatal("duplicate event accepted")
+	}
+
+	// Stale ничего не запоминает
+	if tracker.Stale("tenant-1:product-1", 3) || tracker.Last("tenant-1:product-1") != 1 {
+		t.Fatalf("Stale changed the last sequence to %d", tracker.Last("tenant-1:product-1"))
+	}
+
+	if !tracker.Accept("tenant-1:product-1", 3) || tracker.Accept("tenant-1:product-1", 2) {
+		t.Fatal("out-of-order event accepted")
+	}
+	if tracker.Stale("tenant-1:product-1", 0) || !tracker.Accept("tenant-1:product-1", 0) {
+		t.Fatal("event without sequence rejected")
+	}
+	if !tracker.Accept("tenant-2:product-1", 1) {
+		t.Fatal("sequences of different keys are not independent")
+	}
+}
+
+func TestSequenceTrackerEvictsLeastRecentlyUpdated(t *testing.T) {
+	tracker := NewSequenceTracker(2)
+
+	tracker.Accept("a", 1)
+	tracker.Accept("b", 1)
+	tracker.Accept("a", 2) // "b" становится самым давним
+	tracker.Accept("c", 1)
+
+	if tracker.Len() != 2 {
+		t.Fatalf("len = %d, want 2", tracker.Len())
+	}
+	if tracker.Last("b") != 0 {
+		t.Fatalf("evicted key still tracked: %d", tracker.Last("b"))
+	}
+	if tracker.Last("a") != 2 || tracker.Last("c") != 1 {
+		t.Fatalf("last = a:%d c:%d, want a:2 c:1", tracker.Last("a"), tracker.Last("c"))
+	}
+}
@@ -0,0 +1,66 @@
+package messaging
+
+import (
+	"context"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier адаптирует заголовки сообщения Kafka к propagation.TextMapCarrier
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+// Get возвращает значение заголовка или пустую строку
+func (c *headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set заменяет значение заголовка или добавляет новый
+func (c *headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys возвращает имена всех заголовков
+func (c *headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// startConsumerSpan начинает спан обработки сообщения. Контекст продюсера, извлеченный
+// из заголовков, становится родительским, а сам спан продюсера добавляется как ссылка
+func startConsumerSpan(ctx context.Context, msg *interfaces.Message) (context.Context, trace.Span) {
+	producerCtx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.message.id", msg.ID),
+		),
+	}
+	if producerSpan := trace.SpanContextFromContext(producerCtx); producerSpan.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: producerSpan}))
+	}
+
+	return otel.Tracer("product-service/kafka").Start(producerCtx, msg.Topic+" process", opts...)
+}
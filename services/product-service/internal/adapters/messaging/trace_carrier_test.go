@@ -0,0 +1,74 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	k := newTestKafkaMessaging(t, nil)
+
+	// Продюсер публикует сообщение внутри спана запроса
+	producerCtx, producerSpan := provider.Tracer("test").Start(context.Background(), "PUT /products/{id}")
+	e := k.newMessage(producerCtx, "product-events", "product-1", []byte(`{}`))
+	producerSpan.End()
+	producer := producerSpan.SpanContext()
+
+	var traceparent string
+	for _, header := range e.Headers {
+		if header.Key == "traceparent" {
+			traceparent = string(header.Value)
+		}
+	}
+	if want := "00-" + producer.TraceID().String() + "-" + producer.SpanID().String() + "-01"; traceparent != want {
+		t.Fatalf("traceparent = %q, want %q", traceparent, want)
+	}
+
+	// Consumer получает те же заголовки
+	var handlerSpan trace.SpanContext
+	handler := func(ctx context.Context, msg *interfaces.Message) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil
+	}
+	e.TopicPartition.Offset = 1
+	k.handleMessage(context.Background(), &recordingConsumer{}, handler, e, subscriptionPolicy{maxRetries: 1, retryBackoff: time.Millisecond})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want the producer and the consumer span", len(spans))
+	}
+	consumer := spans[1]
+	if consumer.SpanKind() != trace.SpanKindConsumer || consumer.Name() != "product-events process" {
+		t.Fatalf("span = %q of kind %v, want the consumer span", consumer.Name(), consumer.SpanKind())
+	}
+	if consumer.SpanContext().TraceID() != producer.TraceID() || consumer.Parent().SpanID() != producer.SpanID() {
+		t.Fatalf("consumer trace = %s, parent = %s, want a child of the producer span %s",
+			consumer.SpanContext().TraceID(), consumer.Parent().SpanID(), producer.SpanID())
+	}
+	if links := consumer.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != producer.SpanID() {
+		t.Fatalf("links = %+v, want a link to the producer span", links)
+	}
+	// Обработчик работает внутри спана consumer'а
+	if handlerSpan.SpanID() != consumer.SpanContext().SpanID() {
+		t.Fatalf("handler span = %s, want %s", handlerSpan.SpanID(), consumer.SpanContext().SpanID())
+	}
+}
@@ -0,0 +1,77 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"strings"
+)
+
+// S3Storage реализует ObjectStoragePort для S3-совместимых хранилищ (MinIO, AWS S3)
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3Storage создает клиент хранилища и создает бакет, если он еще не существует.
+// publicURL задает базовый адрес, по которому объекты доступны клиентам;
+// если он пустой, используется адрес endpoint
+func NewS3Storage(ctx context.Context, endpoint, accessKey, secretKey, bucket string, useSSL bool, publicURL string) (*S3Storage, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object storage client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	if publicURL == "" {
+		scheme := "http"
+		if useSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s", scheme, endpoint)
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    bucket,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}, nil
+}
+
+var _ interfaces.ObjectStoragePort = (*S3Storage)(nil)
+
+// PutObject потоково загружает объект в бакет
+func (s *S3Storage) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (string, error) {
+	_, err := s.client.PutObject(ctx, s.bucket, key, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", s.publicURL, s.bucket, key), nil
+}
+
+// DeleteObject удаляет объект из бакета
+func (s *S3Storage) DeleteObject(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestListProductsByCategoryIncludesSubcategories(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	// saveCategory сохраняет категорию напрямую: у корневой категории parent_id равен NULL
+	saveCategory := func(parentID string) string {
+		t.Helper()
+		id := uuid.NewString()
+		if _, err := storage.pool.Exec(ctx, `
+			INSERT INTO product.categories (id, tenant_id, name, parent_id, level, path)
+			VALUES ($1, $2, $1, NULLIF($3, ''), 0, $1)`, id, tenantID, parentID); err != nil {
+			t.Fatalf("save category: %v", err)
+		}
+		return id
+	}
+	assign := func(productID string, categoryIDs ...string) {
+		t.Helper()
+		for _, categoryID := range categoryIDs {
+			if _, err := storage.pool.Exec(ctx, `
+				INSERT INTO product.product_categories (product_id, category_id, tenant_id)
+				VALUES ($1, $2, $3)`, productID, categoryID, tenantID); err != nil {
+				t.Fatalf("assign category: %v", err)
+			}
+		}
+	}
+
+	// drinks -> juices -> citrus; snacks отдельная ветка
+	drinks := saveCategory("")
+	juices := saveCategory(drinks)
+	citrus := saveCategory(juices)
+	snacks := saveCategory("")
+
+	water := saveTestProduct(t, storage, tenantID, "supplier-1", "Water", "")
+	orange := saveTestProduct(t, storage, tenantID, "supplier-1", "Orange juice", "")
+	apple := saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "")
+	chips := saveTestProduct(t, storage, tenantID, "supplier-1", "Chips", "")
+
+	assign(water.ID, drinks)
+	assign(orange.ID, juices, citrus) // в двух категориях одного поддерева
+	assign(apple.ID, juices)
+	assign(chips.ID, snacks)
+
+	tests := []struct {
+		name       string
+		categoryID string
+		want       []string
+	}{
+		{name: "root", categoryID: drinks, want: []string{water.ID, orange.ID, apple.ID}},
+		{name: "middle", categoryID: juices, want: []string{orange.ID, apple.ID}},
+		{name: "leaf", categoryID: citrus, want: []string{orange.ID}},
+		{name: "other branch", categoryID: snacks, want: []string{chips.ID}},
+		{name: "unknown", categoryID: uuid.NewString(), want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, total, err := storage.ListProductsByCategory(ctx, tenantID, tt.categoryID, 1, 10)
+			if err != nil {
+				t.Fatalf("ListProductsByCategory: %v", err)
+			}
+
+			var got []string
+			for _, product := range products {
+				got = append(got, product.ID)
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+
+			if total != len(want) || len(got) != len(want) {
+				t.Fatalf("got %v of %d, want %v", got, total, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+
+	// Категории другого тенанта не видны
+	if _, total, err := storage.ListProductsByCategory(ctx, uuid.NewString(), drinks, 1, 10); err != nil || total != 0 {
+		t.Fatalf("another tenant: total = %d, err = %v, want nothing", total, err)
+	}
+
+	// Пагинация считает продукт из нескольких категорий один раз
+	page, total, err := storage.ListProductsByCategory(ctx, tenantID, drinks, 2, 2)
+	if err != nil || total != 3 || len(page) != 1 {
+		t.Fatalf("second page: %d of %d, err = %v, want 1 of 3", len(page), total, err)
+	}
+}
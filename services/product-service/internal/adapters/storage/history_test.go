@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestProductHistoryRoundTrip(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	before := saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "")
+	after := *before
+	after.BaseData = json.RawMessage(`{"name":"Apple juice 1L","price":120}`)
+	after.Version = before.Version + 1
+
+	if err := storage.SaveHistoryRecord(ctx, &models.ProductHistoryRecord{
+		ProductID:  before.ID,
+		ChangeType: models.HistoryChangeUpdate,
+		Before:     before,
+		After:      &after,
+		ChangedBy:  "user-1",
+		ChangedAt:  time.Now().UTC().Unix(),
+	}, tenantID); err != nil {
+		t.Fatalf("SaveHistoryRecord: %v", err)
+	}
+
+	records, err := storage.GetProductHistory(ctx, before.ID, tenantID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetProductHistory: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %d, want 1", len(records))
+	}
+
+	record := records[0]
+	if record.ChangeType != models.HistoryChangeUpdate || record.ChangedBy != "user-1" || record.Before == nil || record.After == nil {
+		t.Fatalf("record = %+v, want the update by user-1 with both states", record)
+	}
+	var beforeData, afterData map[string]interface{}
+	if err := json.Unmarshal(record.Before.BaseData, &beforeData); err != nil {
+		t.Fatalf("unmarshal before: %v", err)
+	}
+	if err := json.Unmarshal(record.After.BaseData, &afterData); err != nil {
+		t.Fatalf("unmarshal after: %v", err)
+	}
+	if beforeData["name"] != "Apple juice" || afterData["name"] != "Apple juice 1L" || afterData["price"] != float64(120) {
+		t.Fatalf("before = %v, after = %v", beforeData, afterData)
+	}
+	if record.Before.Version != before.Version || record.After.Version != after.Version {
+		t.Fatalf("versions = %d -> %d, want %d -> %d", record.Before.Version, record.After.Version, before.Version, after.Version)
+	}
+}
+
+func TestProductHistoryNewestFirst(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	product := saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "")
+	for _, changedAt := range []int64{200, 100, 300} {
+		if err := storage.SaveHistoryRecord(ctx, &models.ProductHistoryRecord{
+			ProductID:  product.ID,
+			ChangeType: models.HistoryChangeUpdate,
+			After:      product,
+			ChangedAt:  changedAt,
+		}, tenantID); err != nil {
+			t.Fatalf("SaveHistoryRecord: %v", err)
+		}
+	}
+
+	total, err := storage.CountProductHistory(ctx, product.ID, tenantID)
+	if err != nil || total != 3 {
+		t.Fatalf("total = %d, err = %v, want 3", total, err)
+	}
+	// Записи другого тенанта не видны
+	if other, err := storage.CountProductHistory(ctx, product.ID, uuid.NewString()); err != nil || other != 0 {
+		t.Fatalf("foreign tenant total = %d, err = %v, want 0", other, err)
+	}
+
+	first, err := storage.GetProductHistory(ctx, product.ID, tenantID, 2, 0)
+	if err != nil {
+		t.Fatalf("GetProductHistory: %v", err)
+	}
+	second, err := storage.GetProductHistory(ctx, product.ID, tenantID, 2, 2)
+	if err != nil {
+		t.Fatalf("GetProductHistory: %v", err)
+	}
+	if len(first) != 2 || len(second) != 1 || first[0].ChangedAt != 300 || first[1].ChangedAt != 200 || second[0].ChangedAt != 100 {
+		t.Fatalf("pages = %d + %d records, want 300, 200 | 100", len(first), len(second))
+	}
+}
@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// adjustInTx списывает остаток в отдельной транзакции, как это делает сервис
+func adjustInTx(ctx context.Context, storage *ProductStorage, productID, tenantID, supplierID string, delta int) error {
+	txCtx, err := storage.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := storage.AdjustInventory(txCtx, productID, tenantID, supplierID, delta); err != nil {
+		_ = storage.RollbackTx(txCtx)
+		return err
+	}
+	return storage.CommitTx(txCtx)
+}
+
+func TestAdjustInventoryKeepsReservedStock(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	const (
+		stock       = 50
+		reserved    = 10
+		decrements  = 100
+		available   = stock - reserved
+		expiredHold = 5
+	)
+
+	tenantID := uuid.NewString()
+	supplierID := uuid.NewString()
+	product := saveTestProduct(t, storage, tenantID, supplierID, "Reserved product", "")
+
+	if err := storage.SaveInventory(ctx, &models.ProductInventory{
+		ProductID:  product.ID,
+		SupplierID: supplierID,
+		Quantity:   stock,
+	}, tenantID); err != nil {
+		t.Fatalf("SaveInventory: %v", err)
+	}
+
+	now := time.Now().UTC()
+	reservations := []*models.InventoryReservation{
+		{ID: uuid.NewString(), ProductID: product.ID, TenantID: tenantID, Quantity: reserved, ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+		// Истекший резерв не уменьшает доступный остаток
+		{ID: uuid.NewString(), ProductID: product.ID, TenantID: tenantID, Quantity: expiredHold, ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)},
+	}
+	for _, reservation := range reservations {
+		if _, err := storage.pool.Exec(ctx, `
+			INSERT INTO product.inventory_reservations (id, product_id, tenant_id, quantity, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, reservation.ID, reservation.ProductID, reservation.TenantID, reservation.Quantity, reservation.ExpiresAt, reservation.CreatedAt); err != nil {
+			t.Fatalf("insert reservation: %v", err)
+		}
+	}
+
+	t.Run("decrement into reserved stock", func(t *testing.T) {
+		err := adjustInTx(ctx, storage, product.ID, tenantID, supplierID, -(available + 1))
+		if !errors.Is(err, utils.ErrInsufficientStock) {
+			t.Fatalf("err = %v, want ErrInsufficientStock", err)
+		}
+	})
+
+	t.Run("concurrent decrements", func(t *testing.T) {
+		var (
+			wg           sync.WaitGroup
+			mu           sync.Mutex
+			succeeded    int
+			insufficient int
+			failures     []error
+		)
+		for i := 0; i < decrements; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := adjustInTx(ctx, storage, product.ID, tenantID, supplierID, -1)
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					succeeded++
+				case errors.Is(err, utils.ErrInsufficientStock):
+					insufficient++
+				default:
+					failures = append(failures, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(failures) > 0 {
+			t.Fatalf("unexpected errors: %v", failures)
+		}
+		if succeeded != available || insufficient != decrements-available {
+			t.Fatalf("succeeded = %d, insufficient = %d, want %d and %d", succeeded, insufficient, available, decrements-available)
+		}
+
+		inventory, err := storage.GetInventory(ctx, product.ID, tenantID)
+		if err != nil {
+			t.Fatalf("GetInventory: %v", err)
+		}
+		if inventory.Quantity != reserved || inventory.Reserved != reserved || inventory.Available != 0 {
+			t.Fatalf("inventory = %+v, want quantity %d fully reserved", inventory, reserved)
+		}
+	})
+}
+
+// reserveInTx сохраняет резерв в отдельной транзакции, как это делает сервис
+func reserveInTx(ctx context.Context, storage *ProductStorage, productID, tenantID string, quantity int, ttl time.Duration) (*models.InventoryReservation, error) {
+	now := time.Now().UTC()
+	reservation := &models.InventoryReservation{
+		ID:        uuid.NewString(),
+		ProductID: productID,
+		TenantID:  tenantID,
+		Quantity:  quantity,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	txCtx, err := storage.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.ReserveInventory(txCtx, reservation); err != nil {
+		_ = storage.RollbackTx(txCtx)
+		return nil, err
+	}
+	return reservation, storage.CommitTx(txCtx)
+}
+
+func TestInventoryReservations(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	supplierID := uuid.NewString()
+	product := saveTestProduct(t, storage, tenantID, supplierID, "Reserved product", "")
+
+	// Без записи об остатках резервировать нечего
+	if _, err := reserveInTx(ctx, storage, product.ID, tenantID, 1, time.Hour); !errors.Is(err, utils.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock without inventory", err)
+	}
+
+	if err := storage.SaveInventory(ctx, &models.ProductInventory{ProductID: product.ID, SupplierID: supplierID, Quantity: 10}, tenantID); err != nil {
+		t.Fatalf("SaveInventory: %v", err)
+	}
+
+	available := func(want int) {
+		t.Helper()
+		inventory, err := storage.GetInventory(ctx, product.ID, tenantID)
+		if err != nil {
+			t.Fatalf("GetInventory: %v", err)
+		}
+		if inventory.Quantity != 10 || inventory.Available != want || inventory.Reserved != 10-want {
+			t.Fatalf("inventory = %+v, want %d of 10 available", inventory, want)
+		}
+	}
+
+	held, err := reserveInTx(ctx, storage, product.ID, tenantID, 4, time.Hour)
+	if err != nil {
+		t.Fatalf("ReserveInventory: %v", err)
+	}
+	available(6)
+
+	if _, err := reserveInTx(ctx, storage, product.ID, tenantID, 7, time.Hour); !errors.Is(err, utils.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock", err)
+	}
+
+	released, err := storage.DeleteReservation(ctx, product.ID, held.ID, tenantID)
+	if err != nil || released.Quantity != 4 {
+		t.Fatalf("DeleteReservation = %+v, %v", released, err)
+	}
+	available(10)
+	if _, err := storage.DeleteReservation(ctx, product.ID, held.ID, tenantID); !errors.Is(err, utils.ErrReservationNotFound) {
+		t.Fatalf("second DeleteReservation: %v, want ErrReservationNotFound", err)
+	}
+
+	// Истекший резерв возвращает остаток еще до удаления очисткой
+	shortLived, err := reserveInTx(ctx, storage, product.ID, tenantID, 10, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ReserveInventory: %v", err)
+	}
+	available(0)
+	time.Sleep(200 * time.Millisecond)
+	available(10)
+
+	// Очистка общая для всех тенантов, поэтому могут удалиться и резервы других тестов
+	expired, err := storage.DeleteExpiredReservations(ctx, time.Now().UTC())
+	if err != nil || expired < 1 {
+		t.Fatalf("DeleteExpiredReservations = %d, %v, want at least 1", expired, err)
+	}
+	if _, err := storage.DeleteReservation(ctx, product.ID, shortLived.ID, tenantID); !errors.Is(err, utils.ErrReservationNotFound) {
+		t.Fatalf("expired reservation still stored: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	pkgmodels "github.com/athebyme/gomarket-platform/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestSaveMarketplaceProductUpsertsStatus(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+	productID := uuid.NewString()
+
+	save := func(status *pkgmodels.MarketplaceProduct) {
+		t.Helper()
+		status.CoreProductID = productID
+		if err := storage.SaveMarketplaceProduct(ctx, status, tenantID); err != nil {
+			t.Fatalf("SaveMarketplaceProduct: %v", err)
+		}
+	}
+
+	save(&pkgmodels.MarketplaceProduct{MarketplaceID: 7, ExternalID: "ext-1", Status: "pending"})
+	// Повторная отправка без внешнего ID сохраняет ранее полученный
+	save(&pkgmodels.MarketplaceProduct{MarketplaceID: 7, Status: "active"})
+	save(&pkgmodels.MarketplaceProduct{MarketplaceID: 3, Status: "error", StatusMessage: "rejected"})
+
+	statuses, err := storage.ListMarketplaceProducts(ctx, productID, tenantID)
+	if err != nil {
+		t.Fatalf("ListMarketplaceProducts: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("statuses = %d, want one per marketplace", len(statuses))
+	}
+	if statuses[0].MarketplaceID != 3 || statuses[0].Status != "error" || statuses[0].StatusMessage != "rejected" {
+		t.Fatalf("statuses[0] = %+v, want the error of marketplace 3", statuses[0])
+	}
+	if statuses[1].MarketplaceID != 7 || statuses[1].Status != "active" || statuses[1].ExternalID != "ext-1" {
+		t.Fatalf("statuses[1] = %+v, want marketplace 7 active with its external ID", statuses[1])
+	}
+
+	if other, err := storage.ListMarketplaceProducts(ctx, productID, uuid.NewString()); err != nil || len(other) != 0 {
+		t.Fatalf("statuses of another tenant = %+v, err = %v, want none", other, err)
+	}
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// poolStatsInterval период обновления метрик пула соединений
+const poolStatsInterval = 10 * time.Second
+
+var (
+	poolAcquiredConns = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pg_pool_acquired_conns",
+		Help: "Число соединений пула PostgreSQL, занятых запросами",
+	})
+
+	poolIdleConns = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pg_pool_idle_conns",
+		Help: "Число свободных соединений пула PostgreSQL",
+	})
+
+	poolTotalConns = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pg_pool_total_conns",
+		Help: "Общее число соединений пула PostgreSQL, включая устанавливаемые",
+	})
+
+	// poolWaitCount растет, когда запросам не хватает свободных соединений
+	poolWaitCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pg_pool_wait_count",
+		Help: "Число ожиданий свободного соединения пула PostgreSQL с момента запуска",
+	})
+
+	poolWaitDuration = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pg_pool_wait_duration_seconds",
+		Help: "Суммарное время получения соединений из пула PostgreSQL с момента запуска, включая ожидание",
+	})
+)
+
+// ReportPoolStats периодически публикует статистику пула в метрики pg_pool_* до отмены ctx.
+// Первое значение публикуется сразу, чтобы метрики не оставались нулевыми до первого тика
+func ReportPoolStats(ctx context.Context, pool *pgxpool.Pool) {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		stat := pool.Stat()
+		poolAcquiredConns.Set(float64(stat.AcquiredConns()))
+		poolIdleConns.Set(float64(stat.IdleConns()))
+		poolTotalConns.Set(float64(stat.TotalConns()))
+		poolWaitCount.Set(float64(stat.EmptyAcquireCount()))
+		poolWaitDuration.Set(stat.AcquireDuration().Seconds())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
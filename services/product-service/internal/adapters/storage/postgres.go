@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	pkgmodels "github.com/athebyme/gomarket-platform/pkg/models"
 	"github.com/athebyme/gomarket-platform/pkg/tx"
 	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
 	"time"
 
 	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -21,31 +24,67 @@ type ProductStorageInterface interface {
 	SaveProduct(ctx context.Context, product *models.Product) error
 	GetProduct(ctx context.Context, productID string, tenantID string) (*models.Product, error)
 	GetProductBySupplier(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error)
-	ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, page, pageSize int) ([]*models.Product, int, error)
+	GetProductsByIDs(ctx context.Context, ids []string, tenantID string) (map[string]*models.Product, error)
+	GetProductBySKU(ctx context.Context, sku, supplierID, tenantID string) (*models.Product, error)
+	UpsertProductBySKU(ctx context.Context, product *models.Product) (bool, error)
+	ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error)
+	CountProducts(ctx context.Context, tenantID string, filters map[string]interface{}) (int, error)
+	ListSuppliers(ctx context.Context, tenantID string) ([]string, error)
+	ListProductsAfter(ctx context.Context, tenantID string, filters map[string]interface{}, cursor string, limit int) ([]*models.Product, string, error)
+	AggregateFacets(ctx context.Context, tenantID string, filters map[string]interface{}, facetKeys []string) (map[string]map[string]int, error)
 	DeleteProduct(ctx context.Context, productID string, tenantID string) error
+	SaveProducts(ctx context.Context, products []*models.Product) error
+	DeleteProducts(ctx context.Context, productIDs []string, tenantID string) (int, error)
 
 	// ProductInventory методы
 	SaveInventory(ctx context.Context, inventory *models.ProductInventory, tenantID string) error
+	AdjustInventory(ctx context.Context, productID, tenantID, supplierID string, delta int) (int, error)
+	ReserveInventory(ctx context.Context, reservation *models.InventoryReservation) error
+	DeleteReservation(ctx context.Context, productID, reservationID, tenantID string) (*models.InventoryReservation, error)
+	DeleteExpiredReservations(ctx context.Context, now time.Time) (int, error)
 	GetInventory(ctx context.Context, productID string, tenantID string) (*models.ProductInventory, error)
 
 	// ProductPrice методы
 	SavePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error
 	GetPrice(ctx context.Context, productID string, tenantID string) (*models.ProductPrice, error)
+	GetPriceHistory(ctx context.Context, productID string, tenantID string, limit, offset int) ([]*models.PriceHistoryRecord, error)
+	CountPriceHistory(ctx context.Context, productID string, tenantID string) (int, error)
 
 	// ProductMedia методы
 	SaveMedia(ctx context.Context, media *models.ProductMedia, tenantID string) error
 	GetMediaByProductID(ctx context.Context, productID string, tenantID string) ([]*models.ProductMedia, error)
+	GetMediaByProductIDs(ctx context.Context, productIDs []string, tenantID string) (map[string][]*models.ProductMedia, error)
+	GetMedia(ctx context.Context, mediaID string, tenantID string) (*models.ProductMedia, error)
 	DeleteMedia(ctx context.Context, mediaID string, tenantID string) error
 
 	// ProductCategory методы
 	SaveCategory(ctx context.Context, category *models.ProductCategory, tenantID string) error
 	GetCategory(ctx context.Context, categoryID string, tenantID string) (*models.ProductCategory, error)
 	ListCategories(ctx context.Context, tenantID string, parentID string) ([]*models.ProductCategory, error)
+	ListProductsByCategory(ctx context.Context, tenantID, categoryID string, page, pageSize int) ([]*models.Product, int, error)
 	DeleteCategory(ctx context.Context, categoryID string, tenantID string) error
 
 	// ProductHistory методы
 	SaveHistoryRecord(ctx context.Context, record *models.ProductHistoryRecord, tenantID string) error
 	GetProductHistory(ctx context.Context, productID string, tenantID string, limit, offset int) ([]*models.ProductHistoryRecord, error)
+	CountProductHistory(ctx context.Context, productID string, tenantID string) (int, error)
+
+	// MarketplaceFieldMapping методы
+	SaveMarketplaceMapping(ctx context.Context, mapping *models.MarketplaceFieldMapping) error
+	GetMarketplaceMapping(ctx context.Context, marketplaceID int, tenantID string) (*models.MarketplaceFieldMapping, error)
+
+	// MarketplaceProduct методы
+	SaveMarketplaceProduct(ctx context.Context, product *pkgmodels.MarketplaceProduct, tenantID string) error
+	ListMarketplaceProducts(ctx context.Context, productID string, tenantID string) ([]*pkgmodels.MarketplaceProduct, error)
+
+	// NextEventSequence возвращает следующий номер события для продукта
+	NextEventSequence(ctx context.Context, productID string, tenantID string) (int64, error)
+
+	// Outbox методы
+	SaveOutboxMessage(ctx context.Context, message *models.OutboxMessage) error
+	FetchUnpublishedOutbox(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, id int64) error
+	MarkOutboxFailed(ctx context.Context, id int64, reason string) error
 }
 
 type ProductStoragePort interface {
@@ -71,21 +110,25 @@ const (
 // ProductStorage реализация интерфейса Repository для PostgreSQL
 type ProductStorage struct {
 	pool *pgxpool.Pool
+	// queryTimeout ограничивает время одного читающего запроса, 0 - без ограничения
+	queryTimeout time.Duration
 }
 
-// NewPostgresStorage создает новый экземпляр ProductStorage
-func NewPostgresStorage(ctx context.Context, connectionString string) (*ProductStorage, error) {
+// NewPostgresStorage создает новый экземпляр ProductStorage.
+// queryTimeout ограничивает время каждого читающего запроса, 0 отключает ограничение
+func NewPostgresStorage(ctx context.Context, connectionString string, queryTimeout time.Duration) (*ProductStorage, error) {
 	pool, err := pgxpool.New(ctx, connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
 	return &ProductStorage{
-		pool: pool,
+		pool:         pool,
+		queryTimeout: queryTimeout,
 	}, nil
 }
 
-func NewPostgresStorageWithPool(ctx context.Context, pool *pgxpool.Pool) (*ProductStorage, error) {
+func NewPostgresStorageWithPool(ctx context.Context, pool *pgxpool.Pool, queryTimeout time.Duration) (*ProductStorage, error) {
 	if pool == nil {
 		return nil, errors.New("pool is nil")
 	}
@@ -93,7 +136,8 @@ func NewPostgresStorageWithPool(ctx context.Context, pool *pgxpool.Pool) (*Produ
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 	return &ProductStorage{
-		pool: pool,
+		pool:         pool,
+		queryTimeout: queryTimeout,
 	}, nil
 }
 
@@ -117,6 +161,16 @@ func (r *ProductStorage) getExecutor(ctx context.Context) executor {
 	return r.pool // *pgxpool.Pool тоже реализует нужные методы
 }
 
+// withQueryTimeout возвращает дочерний контекст с дедлайном queryTimeout, чтобы зависший запрос
+// не удерживал обработчик до таймаута сервера. Более ранний дедлайн вызывающего сохраняется.
+// Отмена прерывает запрос и, внутри транзакции, делает ее непригодной для продолжения
+func (r *ProductStorage) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
 // getTx получает транзакцию из контекста
 func (r *ProductStorage) getTx(ctx context.Context) pgx.Tx {
 	txFromCtx, ok := ctx.Value(tx.GetKey()).(pgx.Tx)
@@ -153,19 +207,25 @@ func (r *ProductStorage) RollbackTx(ctx context.Context) error {
 	return tx.Rollback(ctx)
 }
 
-// SaveProduct сохраняет продукт в базу данных
+// SaveProduct сохраняет продукт в базу данных и записывает в product.Version новую версию.
+// Существующая строка обновляется только при совпадении product.Version с ее версией, иначе
+// возвращается utils.ErrVersionConflict. Нулевая версия только создает продукт: если он уже есть,
+// это тоже конфликт, поэтому продукт нельзя перезаписать, не прочитав его версию.
 func (r *ProductStorage) SaveProduct(ctx context.Context, product *models.Product) error {
 	executor := r.getExecutor(ctx)
 
 	query := `
-		INSERT INTO product.products (id, tenant_id, supplier_id, base_data, metadata, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO product.products AS p (id, tenant_id, supplier_id, base_data, metadata, created_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 1)
 		ON CONFLICT (id, tenant_id) 
 		DO UPDATE SET 
 			supplier_id = $3,
 			base_data = $4,
 			metadata = $5,
-			updated_at = $7
+			updated_at = $7,
+			version = p.version + 1
+		WHERE p.version = $8
+		RETURNING version
 	`
 
 	now := time.Now().UTC()
@@ -174,28 +234,174 @@ func (r *ProductStorage) SaveProduct(ctx context.Context, product *models.Produc
 	}
 	product.UpdatedAt = now
 
+	var version int
 	var err error
 	switch e := executor.(type) {
 	case pgx.Tx:
-		_, err = e.Exec(ctx, query, product.ID, product.TenantID, product.SupplierID, product.BaseData,
-			product.Metadata, product.CreatedAt, product.UpdatedAt)
+		err = e.QueryRow(ctx, query, product.ID, product.TenantID, product.SupplierID, product.BaseData,
+			product.Metadata, product.CreatedAt, product.UpdatedAt, product.Version).Scan(&version)
 	case *pgxpool.Pool:
-		_, err = e.Exec(ctx, query, product.ID, product.TenantID, product.SupplierID, product.BaseData,
-			product.Metadata, product.CreatedAt, product.UpdatedAt)
+		err = e.QueryRow(ctx, query, product.ID, product.TenantID, product.SupplierID, product.BaseData,
+			product.Metadata, product.CreatedAt, product.UpdatedAt, product.Version).Scan(&version)
 	}
 
 	if err != nil {
+		// Строка не возвращается, только когда условие версии отсекло обновление
+		if errors.Is(err, pgx.ErrNoRows) {
+			return utils.ErrVersionConflict
+		}
 		return fmt.Errorf("failed to save product: %w", err)
 	}
+
+	product.Version = version
+	return nil
+}
+
+// batchChunkSize максимальное число строк в одном пакетном запросе.
+// При 8 параметрах на строку держит запрос далеко от лимита PostgreSQL в 65535 параметров.
+const batchChunkSize = 1000
+
+// SaveProducts сохраняет продукты многострочными INSERT ... ON CONFLICT, разбивая список на части по batchChunkSize.
+// Версии проверяются так же, как в SaveProduct: существующая строка обновляется только при совпадении
+// product.Version, и в product.Version записывается новая версия. Продукты, не записанные из-за версии,
+// перечисляются в *utils.VersionConflictError; остальные к этому моменту уже записаны,
+// поэтому вызывающий откатывает транзакцию, если пакет должен примениться целиком.
+func (r *ProductStorage) SaveProducts(ctx context.Context, products []*models.Product) error {
+	executor := r.getExecutor(ctx)
+	now := time.Now().UTC()
+
+	var conflicts []string
+	for start := 0; start < len(products); start += batchChunkSize {
+		end := start + batchChunkSize
+		if end > len(products) {
+			end = len(products)
+		}
+		chunk := products[start:end]
+
+		values := make([]string, 0, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*8)
+		for i, product := range chunk {
+			if product.CreatedAt.IsZero() {
+				product.CreatedAt = now
+			}
+			product.UpdatedAt = now
+
+			pos := i * 8
+			values = append(values, fmt.Sprintf(
+				"($%d::varchar, $%d::varchar, $%d::varchar, $%d::jsonb, $%d::jsonb, $%d::timestamptz, $%d::timestamptz, $%d::integer)",
+				pos+1, pos+2, pos+3, pos+4, pos+5, pos+6, pos+7, pos+8))
+			args = append(args, product.ID, product.TenantID, product.SupplierID, product.BaseData,
+				product.Metadata, product.CreatedAt, product.UpdatedAt, product.Version)
+		}
+
+		// Ожидаемая версия не является столбцом таблицы, поэтому строки передаются через CTE,
+		// и условие обновления находит версию своей строки в нем
+		query := `
+			WITH input (id, tenant_id, supplier_id, base_data, metadata, created_at, updated_at, version) AS (
+				VALUES ` + strings.Join(values, ", ") + `
+			)
+			INSERT INTO product.products AS p (id, tenant_id, supplier_id, base_data, metadata, created_at, updated_at, version)
+			SELECT id, tenant_id, supplier_id, base_data, metadata, created_at, updated_at, 1 FROM input
+			ON CONFLICT (id, tenant_id)
+			DO UPDATE SET
+				supplier_id = EXCLUDED.supplier_id,
+				base_data = EXCLUDED.base_data,
+				metadata = EXCLUDED.metadata,
+				updated_at = EXCLUDED.updated_at,
+				version = p.version + 1
+			WHERE p.version = (
+				SELECT i.version FROM input i
+				WHERE i.id = EXCLUDED.id AND i.tenant_id = EXCLUDED.tenant_id
+			)
+			RETURNING p.id, p.tenant_id, p.version
+		`
+
+		var rows pgx.Rows
+		var err error
+		switch e := executor.(type) {
+		case pgx.Tx:
+			rows, err = e.Query(ctx, query, args...)
+		case *pgxpool.Pool:
+			rows, err = e.Query(ctx, query, args...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save products batch: %w", err)
+		}
+
+		saved := make(map[[2]string]int, len(chunk))
+		for rows.Next() {
+			var id, tenantID string
+			var version int
+			if err := rows.Scan(&id, &tenantID, &version); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan saved product: %w", err)
+			}
+			saved[[2]string{id, tenantID}] = version
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to save products batch: %w", err)
+		}
+
+		for _, product := range chunk {
+			version, ok := saved[[2]string{product.ID, product.TenantID}]
+			if !ok {
+				conflicts = append(conflicts, product.ID)
+				continue
+			}
+			product.Version = version
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &utils.VersionConflictError{ProductIDs: conflicts}
+	}
 	return nil
 }
 
-// GetProduct получает продукт по ID
+// DeleteProducts удаляет продукты тенанта по списку ID частями по batchChunkSize и возвращает число удаленных строк
+func (r *ProductStorage) DeleteProducts(ctx context.Context, productIDs []string, tenantID string) (int, error) {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		DELETE FROM product.products
+		WHERE id = ANY($1) AND tenant_id = $2
+	`
+
+	deleted := 0
+	for start := 0; start < len(productIDs); start += batchChunkSize {
+		end := start + batchChunkSize
+		if end > len(productIDs) {
+			end = len(productIDs)
+		}
+
+		var tag pgconn.CommandTag
+		var err error
+		switch e := executor.(type) {
+		case pgx.Tx:
+			tag, err = e.Exec(ctx, query, productIDs[start:end], tenantID)
+		case *pgxpool.Pool:
+			tag, err = e.Exec(ctx, query, productIDs[start:end], tenantID)
+		}
+
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete products batch: %w", err)
+		}
+		deleted += int(tag.RowsAffected())
+	}
+
+	return deleted, nil
+}
+
+// GetProduct получает продукт по ID. Если продукт не найден, возвращает utils.ErrProductNotFound
 func (r *ProductStorage) GetProduct(ctx context.Context, productID string, tenantID string) (*models.Product, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	query := `
-		SELECT id, supplier_id, base_data, metadata, created_at, updated_at
+		SELECT id, supplier_id, base_data, metadata, created_at, updated_at, version
 		FROM product.products
 		WHERE id = $1 AND tenant_id = $2
 	`
@@ -207,16 +413,16 @@ func (r *ProductStorage) GetProduct(ctx context.Context, productID string, tenan
 	case pgx.Tx:
 		row := e.QueryRow(ctx, query, productID, tenantID)
 		err = row.Scan(&product.ID, &product.SupplierID, &product.BaseData, &product.Metadata,
-			&product.CreatedAt, &product.UpdatedAt)
+			&product.CreatedAt, &product.UpdatedAt, &product.Version)
 	case *pgxpool.Pool:
 		row := e.QueryRow(ctx, query, productID, tenantID)
 		err = row.Scan(&product.ID, &product.SupplierID, &product.BaseData, &product.Metadata,
-			&product.CreatedAt, &product.UpdatedAt)
+			&product.CreatedAt, &product.UpdatedAt, &product.Version)
 	}
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil // Продукт не найден
+			return nil, utils.ErrProductNotFound
 		}
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
@@ -224,11 +430,15 @@ func (r *ProductStorage) GetProduct(ctx context.Context, productID string, tenan
 	return &product, nil
 }
 
+// GetProductBySupplier получает продукт поставщика по ID. Если продукт не найден, возвращает utils.ErrProductNotFound
 func (r *ProductStorage) GetProductBySupplier(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	query := `
-	SELECT id, supplier_id, base_data, metadata, created_at, updated_at
+	SELECT id, supplier_id, base_data, metadata, created_at, updated_at, version
 	FROM product.products
 	WHERE id = $1 AND tenant_id = $2 AND supplier_id = $3
 	`
@@ -239,54 +449,172 @@ func (r *ProductStorage) GetProductBySupplier(ctx context.Context, productID, su
 	case pgx.Tx:
 		row := e.QueryRow(ctx, query, productID, tenantID, supplierID)
 		err = row.Scan(&product.ID, &product.SupplierID, &product.BaseData, &product.Metadata,
-			&product.CreatedAt, &product.UpdatedAt)
+			&product.CreatedAt, &product.UpdatedAt, &product.Version)
 	case *pgxpool.Pool:
 		row := e.QueryRow(ctx, query, productID, tenantID, supplierID)
 		err = row.Scan(&product.ID, &product.SupplierID, &product.BaseData, &product.Metadata,
-			&product.CreatedAt, &product.UpdatedAt)
+			&product.CreatedAt, &product.UpdatedAt, &product.Version)
 	}
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+			return nil, utils.ErrProductNotFound
 		}
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 	return &product, nil
 }
 
-// ListProducts возвращает список продуктов с поддержкой пагинации и фильтрации
-func (r *ProductStorage) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, page, pageSize int) ([]*models.Product, int, error) {
-	baseQuery := `
+// GetProductsByIDs получает продукты тенанта по списку ID одним запросом на каждые batchChunkSize ID.
+// Возвращает только найденные продукты по их ID, отсутствующие ID ошибкой не считаются
+func (r *ProductStorage) GetProductsByIDs(ctx context.Context, ids []string, tenantID string) (map[string]*models.Product, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT id, supplier_id, base_data, metadata, created_at, updated_at, version
 		FROM product.products
-		WHERE tenant_id = $1
+		WHERE id = ANY($1) AND tenant_id = $2
 	`
 
-	args := []interface{}{tenantID}
-	argPos := 2
-	var filterConditions []string
+	products := make(map[string]*models.Product, len(ids))
+	for start := 0; start < len(ids); start += batchChunkSize {
+		end := start + batchChunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var rows pgx.Rows
+		var err error
+		switch e := executor.(type) {
+		case pgx.Tx:
+			rows, err = e.Query(ctx, query, ids[start:end], tenantID)
+		case *pgxpool.Pool:
+			rows, err = e.Query(ctx, query, ids[start:end], tenantID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get products by ids: %w", err)
+		}
+
+		for rows.Next() {
+			var product models.Product
+			if err := rows.Scan(&product.ID, &product.SupplierID, &product.BaseData,
+				&product.Metadata, &product.CreatedAt, &product.UpdatedAt, &product.Version); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan product row: %w", err)
+			}
+			products[product.ID] = &product
+		}
+		rows.Close()
+
+		if rows.Err() != nil {
+			return nil, fmt.Errorf("error while iterating product rows: %w", rows.Err())
+		}
+	}
 
-	// Здесь должна быть логика добавления фильтров
-	// Для упрощения опустим детали реализации фильтров
+	return products, nil
+}
 
-	// Строим итоговый запрос для подсчета
-	countQuery := "SELECT COUNT(*) " + baseQuery + " " + " AND " + genFilterConditions(filterConditions)
+// GetProductBySKU получает продукт поставщика по SKU. Если продукт не найден, возвращает utils.ErrProductNotFound
+func (r *ProductStorage) GetProductBySKU(ctx context.Context, sku, supplierID, tenantID string) (*models.Product, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 
-	// Получаем общее количество записей
-	var total int
 	executor := r.getExecutor(ctx)
 
+	query := `
+		SELECT id, supplier_id, base_data, metadata, created_at, updated_at, version
+		FROM product.products
+		WHERE tenant_id = $1 AND supplier_id = $2 AND base_data->>'sku' = $3
+	`
+
+	var product models.Product
+	var err error
 	switch e := executor.(type) {
 	case pgx.Tx:
-		err := e.QueryRow(ctx, countQuery, args...).Scan(&total)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count products: %w", err)
-		}
+		row := e.QueryRow(ctx, query, tenantID, supplierID, sku)
+		err = row.Scan(&product.ID, &product.SupplierID, &product.BaseData, &product.Metadata,
+			&product.CreatedAt, &product.UpdatedAt, &product.Version)
 	case *pgxpool.Pool:
-		err := e.QueryRow(ctx, countQuery, args...).Scan(&total)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count products: %w", err)
+		row := e.QueryRow(ctx, query, tenantID, supplierID, sku)
+		err = row.Scan(&product.ID, &product.SupplierID, &product.BaseData, &product.Metadata,
+			&product.CreatedAt, &product.UpdatedAt, &product.Version)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, utils.ErrProductNotFound
 		}
+		return nil, fmt.Errorf("failed to get product by sku: %w", err)
+	}
+	product.TenantID = tenantID
+	return &product, nil
+}
+
+// UpsertProductBySKU создает продукт или обновляет существующий с тем же SKU (base_data->>'sku')
+// в рамках поставщика. При обновлении product.ID и CreatedAt заменяются значениями из БД.
+// Возвращает true, если продукт был создан.
+func (r *ProductStorage) UpsertProductBySKU(ctx context.Context, product *models.Product) (bool, error) {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO product.products AS p (id, tenant_id, supplier_id, base_data, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant_id, supplier_id, (base_data->>'sku')) WHERE base_data->>'sku' IS NOT NULL
+		DO UPDATE SET
+			base_data = EXCLUDED.base_data,
+			metadata = EXCLUDED.metadata,
+			updated_at = EXCLUDED.updated_at,
+			version = p.version + 1
+		RETURNING id, created_at, version, (xmax = 0) AS inserted
+	`
+
+	now := time.Now().UTC()
+	if product.CreatedAt.IsZero() {
+		product.CreatedAt = now
+	}
+	product.UpdatedAt = now
+
+	var inserted bool
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		row := e.QueryRow(ctx, query, product.ID, product.TenantID, product.SupplierID, product.BaseData,
+			product.Metadata, product.CreatedAt, product.UpdatedAt)
+		err = row.Scan(&product.ID, &product.CreatedAt, &product.Version, &inserted)
+	case *pgxpool.Pool:
+		row := e.QueryRow(ctx, query, product.ID, product.TenantID, product.SupplierID, product.BaseData,
+			product.Metadata, product.CreatedAt, product.UpdatedAt)
+		err = row.Scan(&product.ID, &product.CreatedAt, &product.Version, &inserted)
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert product by sku: %w", err)
+	}
+	return inserted, nil
+}
+
+// ListProducts возвращает список продуктов с поддержкой пагинации и фильтрации
+func (r *ProductStorage) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	baseQuery := `
+		FROM product.products
+		WHERE tenant_id = $1
+	`
+
+	filterConditions, args, argPos := buildFilterConditions(filters, []interface{}{tenantID}, 2)
+	if len(filterConditions) > 0 {
+		baseQuery += " AND " + genFilterConditions(filterConditions)
+	}
+
+	// Получаем общее количество записей
+	total, err := r.CountProducts(ctx, tenantID, filters)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Если нет записей, возвращаем пустой результат
@@ -294,18 +622,19 @@ func (r *ProductStorage) ListProducts(ctx context.Context, tenantID string, filt
 		return []*models.Product{}, 0, nil
 	}
 
+	executor := r.getExecutor(ctx)
+
 	// Добавляем пагинацию и сортировку
 	args = append(args, pageSize, (page-1)*pageSize)
 
 	// Выполняем основной запрос
 	dataQuery := `
-		SELECT id, supplier_id, base_data, metadata, created_at, updated_at 
-	` + baseQuery + " " + genFilterConditions(filterConditions) + `
-		ORDER BY updated_at DESC
+		SELECT id, supplier_id, base_data, metadata, created_at, updated_at, version
+	` + baseQuery + `
+		` + buildOrderBy(sort, filters, 2) + `
 		LIMIT $` + fmt.Sprint(argPos) + ` OFFSET $` + fmt.Sprint(argPos+1)
 
 	var rows pgx.Rows
-	var err error
 
 	switch e := executor.(type) {
 	case pgx.Tx:
@@ -324,7 +653,7 @@ func (r *ProductStorage) ListProducts(ctx context.Context, tenantID string, filt
 	for rows.Next() {
 		var product models.Product
 		err := rows.Scan(&product.ID, &product.SupplierID, &product.BaseData,
-			&product.Metadata, &product.CreatedAt, &product.UpdatedAt)
+			&product.Metadata, &product.CreatedAt, &product.UpdatedAt, &product.Version)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan product row: %w", err)
 		}
@@ -338,137 +667,645 @@ func (r *ProductStorage) ListProducts(ctx context.Context, tenantID string, filt
 	return products, total, nil
 }
 
-// DeleteProduct удаляет продукт из хранилища
-func (r *ProductStorage) DeleteProduct(ctx context.Context, productID string, tenantID string) error {
-	executor := r.getExecutor(ctx)
+// CountProducts возвращает число продуктов тенанта, удовлетворяющих фильтрам (тем же, что у ListProducts)
+func (r *ProductStorage) CountProducts(ctx context.Context, tenantID string, filters map[string]interface{}) (int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 
 	query := `
-		DELETE FROM product.products 
-		WHERE id = $1 AND tenant_id = $2
+		SELECT COUNT(*)
+		FROM product.products
+		WHERE tenant_id = $1
 	`
+	filterConditions, args, _ := buildFilterConditions(filters, []interface{}{tenantID}, 2)
+	if len(filterConditions) > 0 {
+		query += " AND " + genFilterConditions(filterConditions)
+	}
 
+	var total int
 	var err error
+	executor := r.getExecutor(ctx)
+
 	switch e := executor.(type) {
 	case pgx.Tx:
-		_, err = e.Exec(ctx, query, productID, tenantID)
+		err = e.QueryRow(ctx, query, args...).Scan(&total)
 	case *pgxpool.Pool:
-		_, err = e.Exec(ctx, query, productID, tenantID)
+		err = e.QueryRow(ctx, query, args...).Scan(&total)
 	}
-
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %w", err)
+		return 0, fmt.Errorf("failed to count products: %w", err)
 	}
 
-	return nil
+	return total, nil
 }
 
-// SaveInventory сохраняет информацию об инвентаре продукта
-func (r *ProductStorage) SaveInventory(ctx context.Context, inventory *models.ProductInventory, tenantID string) error {
-	executor := r.getExecutor(ctx)
+// ListSuppliers возвращает отсортированные ID поставщиков, от которых у тенанта есть продукты.
+// Запрос обслуживается индексом idx_products_tenant_supplier
+func (r *ProductStorage) ListSuppliers(ctx context.Context, tenantID string) ([]string, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
 
 	query := `
-		INSERT INTO product.inventory (product_id, tenant_id, supplier_id, quantity, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (product_id, tenant_id) 
-		DO UPDATE SET 
-			supplier_id = $3,
-			quantity = $4,
-			updated_at = $5
+		SELECT DISTINCT supplier_id
+		FROM product.products
+		WHERE tenant_id = $1
+		ORDER BY supplier_id
 	`
 
-	now := time.Now().UTC()
-	inventory.UpdatedAt = now
+	executor := r.getExecutor(ctx)
 
+	var rows pgx.Rows
 	var err error
 	switch e := executor.(type) {
 	case pgx.Tx:
-		_, err = e.Exec(ctx, query, inventory.ProductID, tenantID, inventory.SupplierID,
-			inventory.Quantity, inventory.UpdatedAt)
+		rows, err = e.Query(ctx, query, tenantID)
 	case *pgxpool.Pool:
-		_, err = e.Exec(ctx, query, inventory.ProductID, tenantID, inventory.SupplierID,
-			inventory.Quantity, inventory.UpdatedAt)
+		rows, err = e.Query(ctx, query, tenantID)
 	}
-
 	if err != nil {
-		return fmt.Errorf("failed to save inventory: %w", err)
+		return nil, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	defer rows.Close()
+
+	suppliers := []string{}
+	for rows.Next() {
+		var supplierID string
+		if err := rows.Scan(&supplierID); err != nil {
+			return nil, fmt.Errorf("failed to scan supplier row: %w", err)
+		}
+		suppliers = append(suppliers, supplierID)
 	}
 
-	return nil
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error while iterating supplier rows: %w", rows.Err())
+	}
+
+	return suppliers, nil
 }
 
-// GetInventory получает информацию об инвентаре продукта
-func (r *ProductStorage) GetInventory(ctx context.Context, productID string, tenantID string) (*models.ProductInventory, error) {
-	executor := r.getExecutor(ctx)
+// ListProductsAfter возвращает до limit продуктов, следующих за курсором, в порядке (updated_at, id).
+// Фильтры те же, что у ListProducts. Keyset-пагинация не пропускает и не дублирует строки при вставках во время обхода.
+// Возвращает курсор следующей страницы или пустую строку, если страниц больше нет
+func (r *ProductStorage) ListProductsAfter(ctx context.Context, tenantID string, filters map[string]interface{}, cursor string, limit int) ([]*models.Product, string, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
 
 	query := `
-		SELECT product_id, supplier_id, quantity, updated_at
-		FROM product.inventory
-		WHERE product_id = $1 AND tenant_id = $2
+		SELECT id, supplier_id, base_data, metadata, created_at, updated_at, version
+		FROM product.products
+		WHERE tenant_id = $1
 	`
+	filterConditions, args, argPos := buildFilterConditions(filters, []interface{}{tenantID}, 2)
+	if len(filterConditions) > 0 {
+		query += " AND " + genFilterConditions(filterConditions)
+	}
+	if after != nil {
+		query += fmt.Sprintf(" AND (updated_at, id) > ($%d, $%d)", argPos, argPos+1)
+		args = append(args, after.UpdatedAt, after.ID)
+		argPos += 2
+	}
+	// Запрашиваем на одну строку больше, чтобы узнать, есть ли следующая страница
+	query += `
+		ORDER BY updated_at, id
+		LIMIT $` + fmt.Sprint(argPos)
+	args = append(args, limit+1)
 
-	var inventory models.ProductInventory
-	var err error
+	executor := r.getExecutor(ctx)
 
+	var rows pgx.Rows
 	switch e := executor.(type) {
 	case pgx.Tx:
-		row := e.QueryRow(ctx, query, productID, tenantID)
-		err = row.Scan(&inventory.ProductID, &inventory.SupplierID, &inventory.Quantity, &inventory.UpdatedAt)
+		rows, err = e.Query(ctx, query, args...)
 	case *pgxpool.Pool:
-		row := e.QueryRow(ctx, query, productID, tenantID)
-		err = row.Scan(&inventory.ProductID, &inventory.SupplierID, &inventory.Quantity, &inventory.UpdatedAt)
+		rows, err = e.Query(ctx, query, args...)
 	}
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil // Инвентарь не найден
+		return nil, "", fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	products := make([]*models.Product, 0, limit)
+	for rows.Next() {
+		var product models.Product
+		err := rows.Scan(&product.ID, &product.SupplierID, &product.BaseData,
+			&product.Metadata, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan product row: %w", err)
 		}
-		return nil, fmt.Errorf("failed to get inventory: %w", err)
+		products = append(products, &product)
 	}
 
-	return &inventory, nil
+	if rows.Err() != nil {
+		return nil, "", fmt.Errorf("error while iterating product rows: %w", rows.Err())
+	}
+
+	if len(products) <= limit {
+		return products, "", nil
+	}
+
+	products = products[:limit]
+	last := products[limit-1]
+	return products, utils.EncodeCursor(utils.Cursor{UpdatedAt: last.UpdatedAt, ID: last.ID}), nil
 }
 
-// SavePrice сохраняет информацию о цене продукта
-func (r *ProductStorage) SavePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error {
-	executor := r.getExecutor(ctx)
+// AggregateFacets считает продукты тенанта по значениям атрибутов facetKeys среди продуктов, прошедших фильтры
+// (те же, что у ListProducts). Атрибут ищется в корне base_data, затем во вложенном объекте attributes;
+// продукты без атрибута не учитываются. Возвращает для каждого ключа отображение значение -> число продуктов,
+// ключ без найденных значений присутствует с пустым отображением
+func (r *ProductStorage) AggregateFacets(ctx context.Context, tenantID string, filters map[string]interface{}, facetKeys []string) (map[string]map[string]int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	result := make(map[string]map[string]int, len(facetKeys))
+	for _, key := range facetKeys {
+		result[key] = make(map[string]int)
+	}
+	if len(facetKeys) == 0 {
+		return result, nil
+	}
 
+	// Все фасеты считаются одним проходом: каждая строка размножается по ключам фасетов
 	query := `
-		INSERT INTO product.prices (product_id, tenant_id, supplier_id, base_price, special_price, 
-			currency, start_date, end_date, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT (product_id, tenant_id) 
-		DO UPDATE SET 
-			supplier_id = $3,
-			base_price = $4,
-			special_price = $5,
-			currency = $6,
-			start_date = $7,
-			end_date = $8,
-			updated_at = $9
+		SELECT facet.key, COALESCE(base_data->>facet.key, base_data->'attributes'->>facet.key) AS value, COUNT(*)
+		FROM product.products
+		CROSS JOIN unnest($2::text[]) AS facet(key)
+		WHERE tenant_id = $1
+			AND COALESCE(base_data->>facet.key, base_data->'attributes'->>facet.key) IS NOT NULL
 	`
+	filterConditions, args, _ := buildFilterConditions(filters, []interface{}{tenantID, facetKeys}, 3)
+	if len(filterConditions) > 0 {
+		query += " AND " + genFilterConditions(filterConditions)
+	}
+	query += " GROUP BY facet.key, value"
 
-	now := time.Now().UTC()
-	price.UpdatedAt = now
+	executor := r.getExecutor(ctx)
 
+	var rows pgx.Rows
 	var err error
 	switch e := executor.(type) {
 	case pgx.Tx:
-		_, err = e.Exec(ctx, query, price.ProductID, tenantID, price.SupplierID, price.BasePrice,
-			price.SpecialPrice, price.Currency, price.StartDate, price.EndDate, price.UpdatedAt)
+		rows, err = e.Query(ctx, query, args...)
 	case *pgxpool.Pool:
-		_, err = e.Exec(ctx, query, price.ProductID, tenantID, price.SupplierID, price.BasePrice,
-			price.SpecialPrice, price.Currency, price.StartDate, price.EndDate, price.UpdatedAt)
+		rows, err = e.Query(ctx, query, args...)
 	}
-
 	if err != nil {
-		return fmt.Errorf("failed to save price: %w", err)
+		return nil, fmt.Errorf("failed to aggregate facets: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
-}
-
-// GetPrice получает информацию о цене продукта
+	for rows.Next() {
+		var key, value string
+		var count int
+		if err := rows.Scan(&key, &value, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet row: %w", err)
+		}
+		result[key][value] = count
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error while iterating facet rows: %w", rows.Err())
+	}
+
+	return result, nil
+}
+
+// ListProductsByCategory возвращает продукты категории и всех ее потомков.
+// Дерево категорий обходится рекурсивным CTE по parent_id в рамках тенанта; продукт,
+// привязанный к нескольким категориям поддерева, возвращается один раз.
+func (r *ProductStorage) ListProductsByCategory(ctx context.Context, tenantID, categoryID string, page, pageSize int) ([]*models.Product, int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	baseQuery := `
+		WITH RECURSIVE subtree AS (
+			SELECT id
+			FROM product.categories
+			WHERE id = $1 AND tenant_id = $2
+			UNION
+			SELECT c.id
+			FROM product.categories c
+			JOIN subtree s ON c.parent_id = s.id
+			WHERE c.tenant_id = $2
+		)
+		SELECT p.id, p.supplier_id, p.base_data, p.metadata, p.created_at, p.updated_at, p.version
+		FROM product.products p
+		WHERE p.tenant_id = $2 AND EXISTS (
+			SELECT 1
+			FROM product.product_categories pc
+			JOIN subtree s ON pc.category_id = s.id
+			WHERE pc.product_id = p.id AND pc.tenant_id = $2
+		)
+	`
+
+	countQuery := "SELECT COUNT(*) FROM (" + baseQuery + ") matched"
+
+	var total int
+	executor := r.getExecutor(ctx)
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err := e.QueryRow(ctx, countQuery, categoryID, tenantID).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count category products: %w", err)
+		}
+	case *pgxpool.Pool:
+		err := e.QueryRow(ctx, countQuery, categoryID, tenantID).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count category products: %w", err)
+		}
+	}
+
+	if total == 0 {
+		return []*models.Product{}, 0, nil
+	}
+
+	dataQuery := baseQuery + `
+		ORDER BY p.updated_at DESC, p.id DESC
+		LIMIT $3 OFFSET $4`
+
+	var rows pgx.Rows
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		rows, err = e.Query(ctx, dataQuery, categoryID, tenantID, pageSize, (page-1)*pageSize)
+	case *pgxpool.Pool:
+		rows, err = e.Query(ctx, dataQuery, categoryID, tenantID, pageSize, (page-1)*pageSize)
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list category products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		var product models.Product
+		err := rows.Scan(&product.ID, &product.SupplierID, &product.BaseData,
+			&product.Metadata, &product.CreatedAt, &product.UpdatedAt, &product.Version)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan product row: %w", err)
+		}
+		products = append(products, &product)
+	}
+
+	if rows.Err() != nil {
+		return nil, 0, fmt.Errorf("error while iterating product rows: %w", rows.Err())
+	}
+
+	return products, total, nil
+}
+
+// DeleteProduct удаляет продукт из хранилища
+func (r *ProductStorage) DeleteProduct(ctx context.Context, productID string, tenantID string) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		DELETE FROM product.products 
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, productID, tenantID)
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, productID, tenantID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	return nil
+}
+
+// SaveInventory сохраняет информацию об инвентаре продукта
+func (r *ProductStorage) SaveInventory(ctx context.Context, inventory *models.ProductInventory, tenantID string) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO product.inventory (product_id, tenant_id, supplier_id, quantity, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (product_id, tenant_id) 
+		DO UPDATE SET 
+			supplier_id = $3,
+			quantity = $4,
+			updated_at = $5
+	`
+
+	now := time.Now().UTC()
+	inventory.UpdatedAt = now
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, inventory.ProductID, tenantID, inventory.SupplierID,
+			inventory.Quantity, inventory.UpdatedAt)
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, inventory.ProductID, tenantID, inventory.SupplierID,
+			inventory.Quantity, inventory.UpdatedAt)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save inventory: %w", err)
+	}
+
+	return nil
+}
+
+// AdjustInventory атомарно изменяет остаток продукта на delta и возвращает новое количество.
+// Пополнение создает запись об остатках, если ее не было. Списание, как и ReserveInventory, сначала
+// блокирует строку остатков, а затем выполняется только если доступный остаток (остаток минус
+// неистекшие резервы) не уходит в минус, поэтому списания не продают зарезервированный товар;
+// при нехватке возвращается utils.ErrInsufficientStock. Списание должно вызываться в транзакции
+func (r *ProductStorage) AdjustInventory(ctx context.Context, productID, tenantID, supplierID string, delta int) (int, error) {
+	executor := r.getExecutor(ctx)
+
+	now := time.Now().UTC()
+	if delta >= 0 {
+		query := `
+			INSERT INTO product.inventory (product_id, tenant_id, supplier_id, quantity, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (product_id, tenant_id)
+			DO UPDATE SET
+				supplier_id = EXCLUDED.supplier_id,
+				quantity = product.inventory.quantity + EXCLUDED.quantity,
+				updated_at = EXCLUDED.updated_at
+			RETURNING quantity
+		`
+
+		var quantity int
+		var err error
+		switch e := executor.(type) {
+		case pgx.Tx:
+			err = e.QueryRow(ctx, query, productID, tenantID, supplierID, delta, now).Scan(&quantity)
+		case *pgxpool.Pool:
+			err = e.QueryRow(ctx, query, productID, tenantID, supplierID, delta, now).Scan(&quantity)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to adjust inventory: %w", err)
+		}
+		return quantity, nil
+	}
+
+	lockQuery := `
+		SELECT quantity
+		FROM product.inventory
+		WHERE product_id = $1 AND tenant_id = $2
+		FOR UPDATE
+	`
+
+	// Сумма резервов читается после блокировки, поэтому учитывает резервы, зафиксированные до нее
+	updateQuery := `
+		UPDATE product.inventory
+		SET quantity = quantity + $3, supplier_id = $4, updated_at = $5
+		WHERE product_id = $1 AND tenant_id = $2
+			AND quantity + $3 - COALESCE((
+				SELECT SUM(quantity)
+				FROM product.inventory_reservations
+				WHERE product_id = $1 AND tenant_id = $2 AND expires_at > $5
+			), 0) >= 0
+		RETURNING quantity
+	`
+
+	var quantity int
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		if err = e.QueryRow(ctx, lockQuery, productID, tenantID).Scan(&quantity); err == nil {
+			err = e.QueryRow(ctx, updateQuery, productID, tenantID, delta, supplierID, now).Scan(&quantity)
+		}
+	case *pgxpool.Pool:
+		if err = e.QueryRow(ctx, lockQuery, productID, tenantID).Scan(&quantity); err == nil {
+			err = e.QueryRow(ctx, updateQuery, productID, tenantID, delta, supplierID, now).Scan(&quantity)
+		}
+	}
+
+	if err != nil {
+		// Нет записи об остатках или доступного остатка не хватает
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, utils.ErrInsufficientStock
+		}
+		return 0, fmt.Errorf("failed to adjust inventory: %w", err)
+	}
+
+	return quantity, nil
+}
+
+// GetInventory получает информацию об инвентаре продукта
+func (r *ProductStorage) GetInventory(ctx context.Context, productID string, tenantID string) (*models.ProductInventory, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	// Истекшие резервы, еще не удаленные очисткой, не учитываются
+	query := `
+		SELECT i.product_id, i.supplier_id, i.quantity, i.updated_at,
+			COALESCE((
+				SELECT SUM(r.quantity)
+				FROM product.inventory_reservations r
+				WHERE r.product_id = i.product_id AND r.tenant_id = i.tenant_id AND r.expires_at > NOW()
+			), 0)
+		FROM product.inventory i
+		WHERE i.product_id = $1 AND i.tenant_id = $2
+	`
+
+	var inventory models.ProductInventory
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		row := e.QueryRow(ctx, query, productID, tenantID)
+		err = row.Scan(&inventory.ProductID, &inventory.SupplierID, &inventory.Quantity, &inventory.UpdatedAt, &inventory.Reserved)
+	case *pgxpool.Pool:
+		row := e.QueryRow(ctx, query, productID, tenantID)
+		err = row.Scan(&inventory.ProductID, &inventory.SupplierID, &inventory.Quantity, &inventory.UpdatedAt, &inventory.Reserved)
+	}
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // Инвентарь не найден
+		}
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+	inventory.Available = inventory.Quantity - inventory.Reserved
+
+	return &inventory, nil
+}
+
+// ReserveInventory сохраняет резерв, если доступного остатка (остаток минус неистекшие резервы) хватает,
+// иначе возвращает utils.ErrInsufficientStock. Должен вызываться в транзакции: строка остатков
+// блокируется первым запросом, поэтому одновременные резервы одного продукта выполняются по очереди,
+// а сумма резервов во втором запросе читается уже после снятия блокировки предыдущим резервом
+func (r *ProductStorage) ReserveInventory(ctx context.Context, reservation *models.InventoryReservation) error {
+	executor := r.getExecutor(ctx)
+
+	lockQuery := `
+		SELECT quantity
+		FROM product.inventory
+		WHERE product_id = $1 AND tenant_id = $2
+		FOR UPDATE
+	`
+
+	insertQuery := `
+		INSERT INTO product.inventory_reservations (id, product_id, tenant_id, quantity, expires_at, created_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE $7 - COALESCE((
+			SELECT SUM(quantity)
+			FROM product.inventory_reservations
+			WHERE product_id = $2 AND tenant_id = $3 AND expires_at > $6
+		), 0) >= $4
+	`
+
+	var quantity int
+	var tag pgconn.CommandTag
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		if err = e.QueryRow(ctx, lockQuery, reservation.ProductID, reservation.TenantID).Scan(&quantity); err == nil {
+			tag, err = e.Exec(ctx, insertQuery, reservation.ID, reservation.ProductID, reservation.TenantID,
+				reservation.Quantity, reservation.ExpiresAt, reservation.CreatedAt, quantity)
+		}
+	case *pgxpool.Pool:
+		if err = e.QueryRow(ctx, lockQuery, reservation.ProductID, reservation.TenantID).Scan(&quantity); err == nil {
+			tag, err = e.Exec(ctx, insertQuery, reservation.ID, reservation.ProductID, reservation.TenantID,
+				reservation.Quantity, reservation.ExpiresAt, reservation.CreatedAt, quantity)
+		}
+	}
+
+	if err != nil {
+		// Без записи об остатках резервировать нечего
+		if errors.Is(err, pgx.ErrNoRows) {
+			return utils.ErrInsufficientStock
+		}
+		return fmt.Errorf("failed to reserve inventory: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return utils.ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// DeleteReservation удаляет резерв продукта и возвращает его. Если резерва нет, возвращает utils.ErrReservationNotFound
+func (r *ProductStorage) DeleteReservation(ctx context.Context, productID, reservationID, tenantID string) (*models.InventoryReservation, error) {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		DELETE FROM product.inventory_reservations
+		WHERE id = $1 AND tenant_id = $2 AND product_id = $3
+		RETURNING id, product_id, tenant_id, quantity, expires_at, created_at
+	`
+
+	var reservation models.InventoryReservation
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err = e.QueryRow(ctx, query, reservationID, tenantID, productID).Scan(&reservation.ID, &reservation.ProductID,
+			&reservation.TenantID, &reservation.Quantity, &reservation.ExpiresAt, &reservation.CreatedAt)
+	case *pgxpool.Pool:
+		err = e.QueryRow(ctx, query, reservationID, tenantID, productID).Scan(&reservation.ID, &reservation.ProductID,
+			&reservation.TenantID, &reservation.Quantity, &reservation.ExpiresAt, &reservation.CreatedAt)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, utils.ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to delete reservation: %w", err)
+	}
+
+	return &reservation, nil
+}
+
+// DeleteExpiredReservations удаляет резервы всех тенантов, истекшие к now, и возвращает их число
+func (r *ProductStorage) DeleteExpiredReservations(ctx context.Context, now time.Time) (int, error) {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		DELETE FROM product.inventory_reservations
+		WHERE expires_at <= $1
+	`
+
+	var tag pgconn.CommandTag
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		tag, err = e.Exec(ctx, query, now)
+	case *pgxpool.Pool:
+		tag, err = e.Exec(ctx, query, now)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired reservations: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// SavePrice сохраняет информацию о цене продукта и добавляет ее в историю цен.
+// Оба изменения выполняются одним запросом, поэтому запись истории не может разойтись с ценой
+func (r *ProductStorage) SavePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		WITH saved AS (
+			INSERT INTO product.prices (product_id, tenant_id, supplier_id, base_price, special_price, 
+				currency, start_date, end_date, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (product_id, tenant_id) 
+			DO UPDATE SET 
+				supplier_id = $3,
+				base_price = $4,
+				special_price = $5,
+				currency = $6,
+				start_date = $7,
+				end_date = $8,
+				updated_at = $9
+			RETURNING product_id, tenant_id, supplier_id, base_price, special_price,
+				currency, start_date, end_date, updated_at
+		)
+		INSERT INTO product.price_history (id, product_id, tenant_id, supplier_id, base_price, special_price,
+			currency, start_date, end_date, changed_at)
+		SELECT $10, product_id, tenant_id, supplier_id, base_price, special_price,
+			currency, start_date, end_date, updated_at
+		FROM saved
+	`
+
+	now := time.Now().UTC()
+	price.UpdatedAt = now
+	historyID := uuid.New().String()
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, price.ProductID, tenantID, price.SupplierID, price.BasePrice,
+			price.SpecialPrice, price.Currency, price.StartDate, price.EndDate, price.UpdatedAt, historyID)
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, price.ProductID, tenantID, price.SupplierID, price.BasePrice,
+			price.SpecialPrice, price.Currency, price.StartDate, price.EndDate, price.UpdatedAt, historyID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save price: %w", err)
+	}
+
+	return nil
+}
+
+// GetPrice получает информацию о цене продукта
 func (r *ProductStorage) GetPrice(ctx context.Context, productID string, tenantID string) (*models.ProductPrice, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	query := `
@@ -501,6 +1338,94 @@ func (r *ProductStorage) GetPrice(ctx context.Context, productID string, tenantI
 	return &price, nil
 }
 
+// CountPriceHistory возвращает число записей в истории цен продукта
+func (r *ProductStorage) CountPriceHistory(ctx context.Context, productID string, tenantID string) (int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT COUNT(*)
+		FROM product.price_history
+		WHERE product_id = $1 AND tenant_id = $2
+	`
+
+	var total int
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err = e.QueryRow(ctx, query, productID, tenantID).Scan(&total)
+	case *pgxpool.Pool:
+		err = e.QueryRow(ctx, query, productID, tenantID).Scan(&total)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to count price history: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetPriceHistory получает историю цен продукта, новые записи первыми
+func (r *ProductStorage) GetPriceHistory(ctx context.Context, productID string, tenantID string, limit, offset int) ([]*models.PriceHistoryRecord, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT id, product_id, supplier_id, base_price, COALESCE(special_price, 0), currency,
+			start_date, end_date, changed_at
+		FROM product.price_history
+		WHERE product_id = $1 AND tenant_id = $2
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var rows pgx.Rows
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		rows, err = e.Query(ctx, query, productID, tenantID, limit, offset)
+	case *pgxpool.Pool:
+		rows, err = e.Query(ctx, query, productID, tenantID, limit, offset)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*models.PriceHistoryRecord
+	for rows.Next() {
+		var record models.PriceHistoryRecord
+		var startDate, endDate *time.Time
+
+		err := rows.Scan(&record.ID, &record.ProductID, &record.SupplierID, &record.BasePrice, &record.SpecialPrice,
+			&record.Currency, &startDate, &endDate, &record.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price history row: %w", err)
+		}
+		if startDate != nil {
+			record.StartDate = *startDate
+		}
+		if endDate != nil {
+			record.EndDate = *endDate
+		}
+
+		records = append(records, &record)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error while iterating price history rows: %w", rows.Err())
+	}
+
+	return records, nil
+}
+
 // SaveMedia сохраняет медиафайл продукта
 func (r *ProductStorage) SaveMedia(ctx context.Context, media *models.ProductMedia, tenantID string) error {
 	executor := r.getExecutor(ctx)
@@ -511,14 +1436,17 @@ func (r *ProductStorage) SaveMedia(ctx context.Context, media *models.ProductMed
 	}
 
 	query := `
-		INSERT INTO product.media (id, tenant_id, product_id, type, url, position, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO product.media (id, tenant_id, product_id, type, url, position, created_at, object_key, variant, parent_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id, tenant_id) 
 		DO UPDATE SET 
 			product_id = $3,
 			type = $4,
 			url = $5,
-			position = $6
+			position = $6,
+			object_key = $8,
+			variant = $9,
+			parent_id = $10
 	`
 
 	now := time.Now().UTC()
@@ -526,65 +1454,160 @@ func (r *ProductStorage) SaveMedia(ctx context.Context, media *models.ProductMed
 		media.CreatedAt = now
 	}
 
-	var err error
-	switch e := executor.(type) {
-	case pgx.Tx:
-		_, err = e.Exec(ctx, query, media.ID, tenantID, media.ProductID, media.Type,
-			media.URL, media.Position, media.CreatedAt)
-	case *pgxpool.Pool:
-		_, err = e.Exec(ctx, query, media.ID, tenantID, media.ProductID, media.Type,
-			media.URL, media.Position, media.CreatedAt)
-	}
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, media.ID, tenantID, media.ProductID, media.Type,
+			media.URL, media.Position, media.CreatedAt, media.ObjectKey, media.Variant, media.ParentID)
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, media.ID, tenantID, media.ProductID, media.Type,
+			media.URL, media.Position, media.CreatedAt, media.ObjectKey, media.Variant, media.ParentID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save media: %w", err)
+	}
+
+	return nil
+}
+
+// GetMediaByProductID получает все медиафайлы для продукта
+func (r *ProductStorage) GetMediaByProductID(ctx context.Context, productID string, tenantID string) ([]*models.ProductMedia, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT id, product_id, type, url, position, created_at, object_key, variant, parent_id
+		FROM product.media
+		WHERE product_id = $1 AND tenant_id = $2
+		ORDER BY position, variant
+	`
+
+	var rows pgx.Rows
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		rows, err = e.Query(ctx, query, productID, tenantID)
+	case *pgxpool.Pool:
+		rows, err = e.Query(ctx, query, productID, tenantID)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+	defer rows.Close()
+
+	var mediaList []*models.ProductMedia
+	for rows.Next() {
+		var media models.ProductMedia
+		err := rows.Scan(&media.ID, &media.ProductID, &media.Type, &media.URL,
+			&media.Position, &media.CreatedAt, &media.ObjectKey, &media.Variant, &media.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan media row: %w", err)
+		}
+		mediaList = append(mediaList, &media)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error while iterating media rows: %w", rows.Err())
+	}
+
+	return mediaList, nil
+}
+
+// GetMediaByProductIDs получает медиафайлы нескольких продуктов одним запросом на каждые batchChunkSize ID
+// и группирует их по продукту в порядке position, как GetMediaByProductID. Продукты без медиафайлов
+// в результат не попадают
+func (r *ProductStorage) GetMediaByProductIDs(ctx context.Context, productIDs []string, tenantID string) (map[string][]*models.ProductMedia, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT id, product_id, type, url, position, created_at, object_key, variant, parent_id
+		FROM product.media
+		WHERE product_id = ANY($1) AND tenant_id = $2
+		ORDER BY product_id, position, variant
+	`
+
+	mediaByProduct := make(map[string][]*models.ProductMedia, len(productIDs))
+	for start := 0; start < len(productIDs); start += batchChunkSize {
+		end := start + batchChunkSize
+		if end > len(productIDs) {
+			end = len(productIDs)
+		}
+
+		var rows pgx.Rows
+		var err error
+		switch e := executor.(type) {
+		case pgx.Tx:
+			rows, err = e.Query(ctx, query, productIDs[start:end], tenantID)
+		case *pgxpool.Pool:
+			rows, err = e.Query(ctx, query, productIDs[start:end], tenantID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query media by product ids: %w", err)
+		}
+
+		for rows.Next() {
+			var media models.ProductMedia
+			err := rows.Scan(&media.ID, &media.ProductID, &media.Type, &media.URL,
+				&media.Position, &media.CreatedAt, &media.ObjectKey, &media.Variant, &media.ParentID)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan media row: %w", err)
+			}
+			mediaByProduct[media.ProductID] = append(mediaByProduct[media.ProductID], &media)
+		}
+		rows.Close()
 
-	if err != nil {
-		return fmt.Errorf("failed to save media: %w", err)
+		if rows.Err() != nil {
+			return nil, fmt.Errorf("error while iterating media rows: %w", rows.Err())
+		}
 	}
 
-	return nil
+	return mediaByProduct, nil
 }
 
-// GetMediaByProductID получает все медиафайлы для продукта
-func (r *ProductStorage) GetMediaByProductID(ctx context.Context, productID string, tenantID string) ([]*models.ProductMedia, error) {
+// GetMedia получает медиафайл по ID. Если медиафайл не найден, возвращает nil
+func (r *ProductStorage) GetMedia(ctx context.Context, mediaID string, tenantID string) (*models.ProductMedia, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	query := `
-		SELECT id, product_id, type, url, position, created_at
+		SELECT id, product_id, type, url, position, created_at, object_key, variant, parent_id
 		FROM product.media
-		WHERE product_id = $1 AND tenant_id = $2
-		ORDER BY position
+		WHERE id = $1 AND tenant_id = $2
 	`
 
-	var rows pgx.Rows
+	var media models.ProductMedia
 	var err error
 
 	switch e := executor.(type) {
 	case pgx.Tx:
-		rows, err = e.Query(ctx, query, productID, tenantID)
+		row := e.QueryRow(ctx, query, mediaID, tenantID)
+		err = row.Scan(&media.ID, &media.ProductID, &media.Type, &media.URL,
+			&media.Position, &media.CreatedAt, &media.ObjectKey, &media.Variant, &media.ParentID)
 	case *pgxpool.Pool:
-		rows, err = e.Query(ctx, query, productID, tenantID)
+		row := e.QueryRow(ctx, query, mediaID, tenantID)
+		err = row.Scan(&media.ID, &media.ProductID, &media.Type, &media.URL,
+			&media.Position, &media.CreatedAt, &media.ObjectKey, &media.Variant, &media.ParentID)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to query media: %w", err)
-	}
-	defer rows.Close()
-
-	var mediaList []*models.ProductMedia
-	for rows.Next() {
-		var media models.ProductMedia
-		err := rows.Scan(&media.ID, &media.ProductID, &media.Type, &media.URL,
-			&media.Position, &media.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan media row: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
 		}
-		mediaList = append(mediaList, &media)
-	}
-
-	if rows.Err() != nil {
-		return nil, fmt.Errorf("error while iterating media rows: %w", rows.Err())
+		return nil, fmt.Errorf("failed to get media: %w", err)
 	}
 
-	return mediaList, nil
+	return &media, nil
 }
 
 // DeleteMedia удаляет медиафайл
@@ -652,6 +1675,9 @@ func (r *ProductStorage) SaveCategory(ctx context.Context, category *models.Prod
 
 // GetCategory получает категорию по ID
 func (r *ProductStorage) GetCategory(ctx context.Context, categoryID string, tenantID string) (*models.ProductCategory, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	query := `
@@ -720,6 +1746,9 @@ func (r *ProductStorage) GetCategory(ctx context.Context, categoryID string, ten
 
 // ListCategories возвращает список категорий с возможностью фильтрации по родительской категории
 func (r *ProductStorage) ListCategories(ctx context.Context, tenantID string, parentID string) ([]*models.ProductCategory, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	var query string
@@ -887,15 +1916,48 @@ func (r *ProductStorage) SaveHistoryRecord(ctx context.Context, record *models.P
 	return nil
 }
 
-// GetProductHistory получает историю изменений продукта
+// CountProductHistory возвращает число записей в истории изменений продукта
+func (r *ProductStorage) CountProductHistory(ctx context.Context, productID string, tenantID string) (int, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT COUNT(*)
+		FROM product.history
+		WHERE product_id = $1 AND tenant_id = $2
+	`
+
+	var total int
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err = e.QueryRow(ctx, query, productID, tenantID).Scan(&total)
+	case *pgxpool.Pool:
+		err = e.QueryRow(ctx, query, productID, tenantID).Scan(&total)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to count product history: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetProductHistory получает историю изменений продукта, новые записи первыми
 func (r *ProductStorage) GetProductHistory(ctx context.Context, productID string, tenantID string, limit, offset int) ([]*models.ProductHistoryRecord, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
 	executor := r.getExecutor(ctx)
 
 	query := `
 		SELECT id, product_id, change_type, before, after, changed_by, changed_at, change_comment
 		FROM product.history
 		WHERE product_id = $1 AND tenant_id = $2
-		ORDER BY changed_at DESC
+		ORDER BY changed_at DESC, id DESC
 		LIMIT $3 OFFSET $4
 	`
 
@@ -949,6 +2011,196 @@ func (r *ProductStorage) GetProductHistory(ctx context.Context, productID string
 	return records, nil
 }
 
+// SaveMarketplaceMapping сохраняет настройку маппинга полей для маркетплейса
+func (r *ProductStorage) SaveMarketplaceMapping(ctx context.Context, mapping *models.MarketplaceFieldMapping) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO product.marketplace_field_mappings (tenant_id, marketplace_id, fields, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, marketplace_id)
+		DO UPDATE SET
+			fields = $3,
+			updated_at = $4
+	`
+
+	fieldsJSON, err := json.Marshal(mapping.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mapping fields: %w", err)
+	}
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, mapping.TenantID, mapping.MarketplaceID, fieldsJSON, mapping.UpdatedAt)
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, mapping.TenantID, mapping.MarketplaceID, fieldsJSON, mapping.UpdatedAt)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save marketplace mapping: %w", err)
+	}
+
+	return nil
+}
+
+// GetMarketplaceMapping получает настройку маппинга полей для маркетплейса
+func (r *ProductStorage) GetMarketplaceMapping(ctx context.Context, marketplaceID int, tenantID string) (*models.MarketplaceFieldMapping, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT tenant_id, marketplace_id, fields, updated_at
+		FROM product.marketplace_field_mappings
+		WHERE marketplace_id = $1 AND tenant_id = $2
+	`
+
+	var mapping models.MarketplaceFieldMapping
+	var fieldsJSON []byte
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		row := e.QueryRow(ctx, query, marketplaceID, tenantID)
+		err = row.Scan(&mapping.TenantID, &mapping.MarketplaceID, &fieldsJSON, &mapping.UpdatedAt)
+	case *pgxpool.Pool:
+		row := e.QueryRow(ctx, query, marketplaceID, tenantID)
+		err = row.Scan(&mapping.TenantID, &mapping.MarketplaceID, &fieldsJSON, &mapping.UpdatedAt)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil // Маппинг не настроен
+		}
+		return nil, fmt.Errorf("failed to get marketplace mapping: %w", err)
+	}
+
+	if err := json.Unmarshal(fieldsJSON, &mapping.Fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mapping fields: %w", err)
+	}
+
+	return &mapping, nil
+}
+
+// SaveMarketplaceProduct создает или обновляет статус синхронизации продукта с маркетплейсом.
+// Пустой ExternalID не затирает уже известный идентификатор продукта в маркетплейсе
+func (r *ProductStorage) SaveMarketplaceProduct(ctx context.Context, product *pkgmodels.MarketplaceProduct, tenantID string) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO product.marketplace_products AS mp
+			(id, tenant_id, product_id, marketplace_id, external_id, status, status_message, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (tenant_id, product_id, marketplace_id)
+		DO UPDATE SET
+			external_id = COALESCE(NULLIF(EXCLUDED.external_id, ''), mp.external_id),
+			status = EXCLUDED.status,
+			status_message = EXCLUDED.status_message,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, external_id
+	`
+
+	if product.ID == "" {
+		product.ID = uuid.New().String()
+	}
+	if product.UpdatedAt.IsZero() {
+		product.UpdatedAt = time.Now().UTC()
+	}
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err = e.QueryRow(ctx, query, product.ID, tenantID, product.CoreProductID, product.MarketplaceID,
+			product.ExternalID, product.Status, product.StatusMessage, product.UpdatedAt).Scan(&product.ID, &product.ExternalID)
+	case *pgxpool.Pool:
+		err = e.QueryRow(ctx, query, product.ID, tenantID, product.CoreProductID, product.MarketplaceID,
+			product.ExternalID, product.Status, product.StatusMessage, product.UpdatedAt).Scan(&product.ID, &product.ExternalID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save marketplace product: %w", err)
+	}
+	return nil
+}
+
+// ListMarketplaceProducts возвращает статусы синхронизации продукта со всеми маркетплейсами
+func (r *ProductStorage) ListMarketplaceProducts(ctx context.Context, productID string, tenantID string) ([]*pkgmodels.MarketplaceProduct, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT id, marketplace_id, product_id, external_id, status, COALESCE(status_message, ''), updated_at
+		FROM product.marketplace_products
+		WHERE product_id = $1 AND tenant_id = $2
+		ORDER BY marketplace_id
+	`
+
+	var rows pgx.Rows
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		rows, err = e.Query(ctx, query, productID, tenantID)
+	case *pgxpool.Pool:
+		rows, err = e.Query(ctx, query, productID, tenantID)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query marketplace products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*pkgmodels.MarketplaceProduct
+	for rows.Next() {
+		var product pkgmodels.MarketplaceProduct
+		err := rows.Scan(&product.ID, &product.MarketplaceID, &product.CoreProductID, &product.ExternalID,
+			&product.Status, &product.StatusMessage, &product.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan marketplace product row: %w", err)
+		}
+		products = append(products, &product)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error while iterating marketplace product rows: %w", rows.Err())
+	}
+
+	return products, nil
+}
+
+// NextEventSequence атомарно увеличивает и возвращает номер последнего события продукта.
+// Вызывается внутри транзакции записи, поэтому номер фиксируется вместе с изменением продукта.
+func (r *ProductStorage) NextEventSequence(ctx context.Context, productID string, tenantID string) (int64, error) {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO product.event_sequences (product_id, tenant_id, last_sequence)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (product_id, tenant_id)
+		DO UPDATE SET last_sequence = product.event_sequences.last_sequence + 1
+		RETURNING last_sequence
+	`
+
+	var sequence int64
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err = e.QueryRow(ctx, query, productID, tenantID).Scan(&sequence)
+	case *pgxpool.Pool:
+		err = e.QueryRow(ctx, query, productID, tenantID).Scan(&sequence)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next event sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
 // Вспомогательная функция для генерации условий фильтрации
 func genFilterConditions(conditions []string) string {
 	if len(conditions) == 0 {
@@ -966,3 +2218,131 @@ func genFilterConditions(conditions []string) string {
 
 	return result
 }
+
+// SaveOutboxMessage записывает событие в outbox. Вызывается внутри транзакции изменения данных.
+func (r *ProductStorage) SaveOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		INSERT INTO product.outbox (tenant_id, aggregate_id, topic, payload, trace_parent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now().UTC()
+	}
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		err = e.QueryRow(ctx, query, message.TenantID, message.AggregateID, message.Topic,
+			message.Payload, message.TraceParent, message.CreatedAt).Scan(&message.ID)
+	case *pgxpool.Pool:
+		err = e.QueryRow(ctx, query, message.TenantID, message.AggregateID, message.Topic,
+			message.Payload, message.TraceParent, message.CreatedAt).Scan(&message.ID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save outbox message: %w", err)
+	}
+	return nil
+}
+
+// FetchUnpublishedOutbox возвращает неопубликованные события в порядке записи.
+// Строки блокируются до конца транзакции (SKIP LOCKED), поэтому несколько relay не публикуют одно событие дважды.
+func (r *ProductStorage) FetchUnpublishedOutbox(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	executor := r.getExecutor(ctx)
+
+	query := `
+		SELECT id, tenant_id, aggregate_id, topic, payload, attempts, trace_parent, created_at
+		FROM product.outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	var rows pgx.Rows
+	var err error
+
+	switch e := executor.(type) {
+	case pgx.Tx:
+		rows, err = e.Query(ctx, query, limit)
+	case *pgxpool.Pool:
+		rows, err = e.Query(ctx, query, limit)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*models.OutboxMessage
+	for rows.Next() {
+		var message models.OutboxMessage
+		err := rows.Scan(&message.ID, &message.TenantID, &message.AggregateID, &message.Topic,
+			&message.Payload, &message.Attempts, &message.TraceParent, &message.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+		messages = append(messages, &message)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error while iterating outbox rows: %w", rows.Err())
+	}
+
+	return messages, nil
+}
+
+// MarkOutboxPublished отмечает событие outbox как опубликованное
+func (r *ProductStorage) MarkOutboxPublished(ctx context.Context, id int64) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		UPDATE product.outbox
+		SET published_at = $2, attempts = attempts + 1, last_error = NULL
+		WHERE id = $1
+	`
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, id, time.Now().UTC())
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, id, time.Now().UTC())
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message published: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboxFailed увеличивает счетчик попыток и сохраняет причину неудачной публикации
+func (r *ProductStorage) MarkOutboxFailed(ctx context.Context, id int64, reason string) error {
+	executor := r.getExecutor(ctx)
+
+	query := `
+		UPDATE product.outbox
+		SET attempts = attempts + 1, last_error = $2
+		WHERE id = $1
+	`
+
+	var err error
+	switch e := executor.(type) {
+	case pgx.Tx:
+		_, err = e.Exec(ctx, query, id, reason)
+	case *pgxpool.Pool:
+		_, err = e.Exec(ctx, query, id, reason)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
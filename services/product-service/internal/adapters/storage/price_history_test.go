@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestSavePriceAppendsHistory(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	product := saveTestProduct(t, storage, tenantID, "supplier-1", "Priced product", "")
+
+	for _, basePrice := range []float64{100, 120, 90} {
+		if err := storage.SavePrice(ctx, &models.ProductPrice{
+			ProductID:  product.ID,
+			SupplierID: "supplier-1",
+			BasePrice:  basePrice,
+			Currency:   "RUB",
+		}, tenantID); err != nil {
+			t.Fatalf("SavePrice: %v", err)
+		}
+	}
+
+	// Текущая цена перезаписывается, а история хранит каждое изменение
+	current, err := storage.GetPrice(ctx, product.ID, tenantID)
+	if err != nil || current.BasePrice != 90 {
+		t.Fatalf("GetPrice = %+v, %v, want the last price", current, err)
+	}
+	total, err := storage.CountPriceHistory(ctx, product.ID, tenantID)
+	if err != nil || total != 3 {
+		t.Fatalf("CountPriceHistory = %d, %v, want a row per update", total, err)
+	}
+
+	records, err := storage.GetPriceHistory(ctx, product.ID, tenantID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3", len(records))
+	}
+	for i, want := range []float64{90, 120, 100} {
+		if records[i].BasePrice != want || records[i].Currency != "RUB" || records[i].SupplierID != "supplier-1" {
+			t.Fatalf("record %d = %+v, want base price %v", i, records[i], want)
+		}
+		if i > 0 && records[i].ChangedAt.After(records[i-1].ChangedAt) {
+			t.Fatalf("record %d changed at %v, after the newer record %v", i, records[i].ChangedAt, records[i-1].ChangedAt)
+		}
+	}
+
+	page, err := storage.GetPriceHistory(ctx, product.ID, tenantID, 2, 2)
+	if err != nil || len(page) != 1 || page[0].BasePrice != 100 {
+		t.Fatalf("second page = %+v, %v, want the first price", page, err)
+	}
+
+	if total, err := storage.CountPriceHistory(ctx, product.ID, uuid.NewString()); err != nil || total != 0 {
+		t.Fatalf("history of another tenant = %d, %v, want 0", total, err)
+	}
+}
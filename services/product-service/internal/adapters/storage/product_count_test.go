@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestCountProductsMatchesListTotal(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+	supplierID := uuid.NewString()
+
+	saveTestProduct(t, storage, tenantID, supplierID, "Apple juice", "fresh apple")
+	saveTestProduct(t, storage, tenantID, supplierID, "Orange juice", "citrus")
+	saveTestProduct(t, storage, tenantID, uuid.NewString(), "Apple pie", "dessert")
+	saveAttributeProduct(t, storage, tenantID, `{"name":"Red shoes","price":300,"color":"red"}`)
+	saveTestProduct(t, storage, uuid.NewString(), supplierID, "Apple juice", "other tenant")
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    int
+	}{
+		{name: "no filters", want: 4},
+		{name: "supplier", filters: map[string]interface{}{"supplier_id": supplierID}, want: 2},
+		{name: "name contains", filters: map[string]interface{}{"name": "apple"}, want: 2},
+		{name: "search", filters: map[string]interface{}{models.SearchQueryFilter: "juice"}, want: 2},
+		{name: "price range", filters: map[string]interface{}{"min_price": float64(200), "max_price": float64(400)}, want: 1},
+		{name: "attribute", filters: map[string]interface{}{models.AttributeFilterPrefix + "color": "red"}, want: 1},
+		{name: "nothing matches", filters: map[string]interface{}{"name": "banana"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := storage.CountProducts(ctx, tenantID, tt.filters)
+			if err != nil {
+				t.Fatalf("CountProducts: %v", err)
+			}
+			_, total, err := storage.ListProducts(ctx, tenantID, tt.filters, models.SortOption{}, 1, 1)
+			if err != nil {
+				t.Fatalf("ListProducts: %v", err)
+			}
+			if count != tt.want || count != total {
+				t.Fatalf("count = %d, list total = %d, want %d", count, total, tt.want)
+			}
+		})
+	}
+}
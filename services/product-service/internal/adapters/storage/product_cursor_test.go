@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestListProductsAfterStableUnderInserts(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	existing := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		existing[saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "").ID] = true
+	}
+
+	seen := make(map[string]int)
+	var inserted []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("iteration does not terminate")
+		}
+
+		products, next, err := storage.ListProductsAfter(ctx, tenantID, nil, cursor, 2)
+		if err != nil {
+			t.Fatalf("ListProductsAfter: %v", err)
+		}
+		for _, product := range products {
+			seen[product.ID]++
+		}
+
+		// После первой страницы появляются новые продукты, в том числе с id меньше прочитанных
+		if pages == 0 {
+			for i := 0; i < 2; i++ {
+				inserted = append(inserted, saveTestProduct(t, storage, tenantID, "supplier-1", "Orange juice", "").ID)
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	for id := range existing {
+		if seen[id] != 1 {
+			t.Fatalf("product %s seen %d times, want exactly once", id, seen[id])
+		}
+	}
+	// Новые строки обновлены позже курсора, поэтому попадают в конец выдачи
+	for _, id := range inserted {
+		if seen[id] != 1 {
+			t.Fatalf("inserted product %s seen %d times, want exactly once", id, seen[id])
+		}
+	}
+	if len(seen) != len(existing)+len(inserted) {
+		t.Fatalf("seen %d products, want %d", len(seen), len(existing)+len(inserted))
+	}
+}
+
+func TestListProductsAfterInvalidCursor(t *testing.T) {
+	storage := newTestStorage(t)
+
+	if _, _, err := storage.ListProductsAfter(context.Background(), uuid.NewString(), nil, "garbage", 2); !errors.Is(err, utils.ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestListProductsAfterFilters(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	for i := 0; i < 5; i++ {
+		saveTestProduct(t, storage, tenantID, []string{"supplier-1", "supplier-2"}[i%2], "Apple juice", "")
+	}
+
+	var products int
+	cursor := ""
+	for {
+		page, next, err := storage.ListProductsAfter(ctx, tenantID, map[string]interface{}{"supplier_id": "supplier-1"}, cursor, 2)
+		if err != nil {
+			t.Fatalf("ListProductsAfter: %v", err)
+		}
+		for _, product := range page {
+			if product.SupplierID != "supplier-1" {
+				t.Fatalf("product of %s exported with a supplier-1 filter", product.SupplierID)
+			}
+		}
+		products += len(page)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if products != 3 {
+		t.Fatalf("products = %d, want the 3 products of supplier-1", products)
+	}
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestAggregateFacets(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	saveAttributeProduct(t, storage, tenantID, `{"name":"Red Nike","price":100,"color":"red","brand":"Nike"}`)
+	saveAttributeProduct(t, storage, tenantID, `{"name":"Red Adidas","price":200,"attributes":{"color":"red","brand":"Adidas"}}`)
+	saveAttributeProduct(t, storage, tenantID, `{"name":"Blue Nike","price":300,"color":"blue","brand":"Nike"}`)
+	saveAttributeProduct(t, storage, tenantID, `{"name":"Plain","price":400}`)
+	saveAttributeProduct(t, storage, uuid.NewString(), `{"name":"Other tenant","price":100,"color":"red","brand":"Nike"}`)
+
+	attr := func(name string) string { return models.AttributeFilterPrefix + name }
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    map[string]map[string]int
+	}{
+		{name: "no filters", want: map[string]map[string]int{
+			"color":    {"red": 2, "blue": 1},
+			"brand":    {"Nike": 2, "Adidas": 1},
+			"material": {},
+		}},
+		{name: "brand selected", filters: map[string]interface{}{attr("brand"): "Nike"}, want: map[string]map[string]int{
+			"color":    {"red": 1, "blue": 1},
+			"brand":    {"Nike": 2},
+			"material": {},
+		}},
+		{name: "color and price", filters: map[string]interface{}{attr("color"): "red", "min_price": float64(150)}, want: map[string]map[string]int{
+			"color":    {"red": 1},
+			"brand":    {"Adidas": 1},
+			"material": {},
+		}},
+		{name: "nothing matches", filters: map[string]interface{}{attr("color"): "green"}, want: map[string]map[string]int{
+			"color":    {},
+			"brand":    {},
+			"material": {},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facets, err := storage.AggregateFacets(ctx, tenantID, tt.filters, []string{"color", "brand", "material"})
+			if err != nil {
+				t.Fatalf("AggregateFacets: %v", err)
+			}
+			if !reflect.DeepEqual(facets, tt.want) {
+				t.Fatalf("facets = %v, want %v", facets, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGetProductsByIDs(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	first := saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "")
+	second := saveTestProduct(t, storage, tenantID, "supplier-2", "Orange juice", "")
+	foreign := saveTestProduct(t, storage, uuid.NewString(), "supplier-1", "Grape juice", "")
+
+	// Отсутствующие ID и продукт другого тенанта перемешаны с найденными,
+	// а последний продукт попадает во второй пакет запроса
+	ids := []string{uuid.NewString(), first.ID, foreign.ID}
+	for len(ids) < batchChunkSize+1 {
+		ids = append(ids, uuid.NewString())
+	}
+	ids = append(ids, second.ID)
+
+	products, err := storage.GetProductsByIDs(ctx, ids, tenantID)
+	if err != nil {
+		t.Fatalf("GetProductsByIDs: %v", err)
+	}
+	if len(products) != 2 {
+		t.Fatalf("found %d products, want 2", len(products))
+	}
+	if got := products[first.ID]; got == nil || got.SupplierID != "supplier-1" || got.Version != first.Version {
+		t.Fatalf("products[%s] = %+v", first.ID, got)
+	}
+	if got := products[second.ID]; got == nil || got.SupplierID != "supplier-2" {
+		t.Fatalf("products[%s] = %+v", second.ID, got)
+	}
+
+	if products, err := storage.GetProductsByIDs(ctx, nil, tenantID); err != nil || len(products) != 0 {
+		t.Fatalf("GetProductsByIDs(nil) = %v, %v, want an empty result", products, err)
+	}
+}
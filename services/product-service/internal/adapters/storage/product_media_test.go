@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestGetMediaByProductIDs(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	first := saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "fresh")
+	second := saveTestProduct(t, storage, tenantID, "supplier-1", "Orange juice", "fresh")
+	withoutMedia := saveTestProduct(t, storage, tenantID, "supplier-1", "Water", "still")
+	other := saveTestProduct(t, storage, uuid.NewString(), "supplier-1", "Apple juice", "other tenant")
+
+	saveMedia := func(product *models.Product, position int) string {
+		t.Helper()
+		media := &models.ProductMedia{ProductID: product.ID, Type: "image", URL: "https://cdn.example.com/" + uuid.NewString(), Position: position}
+		if err := storage.SaveMedia(ctx, media, product.TenantID); err != nil {
+			t.Fatalf("SaveMedia: %v", err)
+		}
+		return media.ID
+	}
+	// Медиафайлы сохраняются не в порядке position
+	firstLast := saveMedia(first, 2)
+	firstFirst := saveMedia(first, 0)
+	firstMiddle := saveMedia(first, 1)
+	secondOnly := saveMedia(second, 5)
+	saveMedia(other, 0)
+
+	mediaByProduct, err := storage.GetMediaByProductIDs(ctx, []string{first.ID, second.ID, withoutMedia.ID, other.ID}, tenantID)
+	if err != nil {
+		t.Fatalf("GetMediaByProductIDs: %v", err)
+	}
+
+	ids := func(media []*models.ProductMedia) []string {
+		result := make([]string, 0, len(media))
+		for _, m := range media {
+			result = append(result, m.ID)
+		}
+		return result
+	}
+	if got := ids(mediaByProduct[first.ID]); !reflect.DeepEqual(got, []string{firstFirst, firstMiddle, firstLast}) {
+		t.Fatalf("media of the first product = %v, want ordered by position", got)
+	}
+	if got := ids(mediaByProduct[second.ID]); !reflect.DeepEqual(got, []string{secondOnly}) {
+		t.Fatalf("media of the second product = %v, want %v", got, []string{secondOnly})
+	}
+	// Продукты без медиафайлов и продукты другого тенанта в результат не попадают
+	if len(mediaByProduct) != 2 {
+		t.Fatalf("products in result = %d, want 2", len(mediaByProduct))
+	}
+
+	// Результат совпадает с выборкой по одному продукту
+	single, err := storage.GetMediaByProductID(ctx, first.ID, tenantID)
+	if err != nil {
+		t.Fatalf("GetMediaByProductID: %v", err)
+	}
+	if !reflect.DeepEqual(ids(single), ids(mediaByProduct[first.ID])) {
+		t.Fatalf("batch media = %v, single media = %v", ids(mediaByProduct[first.ID]), ids(single))
+	}
+}
+
+func TestGetMediaByProductIDsChunks(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	product := saveTestProduct(t, storage, tenantID, "supplier-1", "Apple juice", "fresh")
+	media := &models.ProductMedia{ProductID: product.ID, Type: "image", URL: "https://cdn.example.com/apple.jpg"}
+	if err := storage.SaveMedia(ctx, media, tenantID); err != nil {
+		t.Fatalf("SaveMedia: %v", err)
+	}
+
+	// Продукт с медиафайлом попадает во второй пакет запроса
+	productIDs := make([]string, batchChunkSize, batchChunkSize+1)
+	for i := range productIDs {
+		productIDs[i] = uuid.NewString()
+	}
+	productIDs = append(productIDs, product.ID)
+
+	mediaByProduct, err := storage.GetMediaByProductIDs(ctx, productIDs, tenantID)
+	if err != nil {
+		t.Fatalf("GetMediaByProductIDs: %v", err)
+	}
+	if len(mediaByProduct) != 1 || len(mediaByProduct[product.ID]) != 1 || mediaByProduct[product.ID][0].ID != media.ID {
+		t.Fatalf("media = %v, want the media of the product from the second chunk", mediaByProduct)
+	}
+}
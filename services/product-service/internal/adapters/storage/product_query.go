@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// queryField связывает публичное имя поля с SQL-выражением.
+// Только поля из белых списков ниже попадают в текст запроса, значения всегда передаются параметрами.
+type queryField struct {
+	models.SchemaField
+	column string
+}
+
+// priceColumn цена из base_data как число. Нечисловое значение дает NULL вместо ошибки приведения,
+// иначе один товар с некорректной ценой ломал бы весь запрос списка
+const priceColumn = `CASE WHEN base_data->>'price' ~ '^-?[0-9]+(\.[0-9]+)?$' THEN (base_data->>'price')::numeric END`
+
+// filterableFields белый список фильтров, поддерживаемых ListProducts
+var filterableFields = []queryField{
+	{SchemaField: models.SchemaField{Name: "supplier_id", Type: models.FieldTypeString, Operator: models.FilterOperatorEq}, column: "supplier_id"},
+	{SchemaField: models.SchemaField{Name: "name", Type: models.FieldTypeString, Operator: models.FilterOperatorContains}, column: "base_data->>'name'"},
+	{SchemaField: models.SchemaField{Name: "description", Type: models.FieldTypeString, Operator: models.FilterOperatorContains}, column: "base_data->>'description'"},
+	{SchemaField: models.SchemaField{Name: "min_price", Type: models.FieldTypeNumber, Operator: models.FilterOperatorGte}, column: priceColumn},
+	{SchemaField: models.SchemaField{Name: "max_price", Type: models.FieldTypeNumber, Operator: models.FilterOperatorLte}, column: priceColumn},
+}
+
+// sortableFields белый список полей сортировки, поддерживаемых ListProducts
+var sortableFields = []queryField{
+	{SchemaField: models.SchemaField{Name: "created_at", Type: models.FieldTypeTimestamp}, column: "created_at"},
+	{SchemaField: models.SchemaField{Name: "updated_at", Type: models.FieldTypeTimestamp}, column: "updated_at"},
+	{SchemaField: models.SchemaField{Name: "name", Type: models.FieldTypeString}, column: "base_data->>'name'"},
+	{SchemaField: models.SchemaField{Name: "price", Type: models.FieldTypeNumber}, column: priceColumn},
+}
+
+// searchVector выражение tsvector для полнотекстового поиска, совпадает с индексом idx_products_search.
+// Используется конфигурация simple: каталог многоязычный, стемминг одного языка портит поиск по остальным
+const searchVector = `to_tsvector('simple', coalesce(base_data->>'name', '') || ' ' || coalesce(base_data->>'description', ''))`
+
+// likeEscaper экранирует спецсимволы шаблона LIKE, чтобы фильтр "содержит" искал строку буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// defaultSortField поле сортировки по умолчанию
+const defaultSortField = "updated_at"
+
+// ProductSchema возвращает описание фильтров и сортировок, которые учитывает ListProducts
+func ProductSchema() *models.ProductSchema {
+	schema := &models.ProductSchema{
+		Filters:         make([]models.SchemaField, 0, len(filterableFields)),
+		Sortable:        make([]models.SchemaField, 0, len(sortableFields)),
+		DefaultSort:     defaultSortField,
+		AttributePrefix: models.AttributeFilterPrefix,
+	}
+
+	for _, f := range filterableFields {
+		schema.Filters = append(schema.Filters, f.SchemaField)
+	}
+	for _, f := range sortableFields {
+		schema.Sortable = append(schema.Sortable, f.SchemaField)
+	}
+
+	return schema
+}
+
+// buildOrderBy возвращает выражение ORDER BY для поля из белого списка сортировок.
+// Неизвестное поле заменяется сортировкой по умолчанию (updated_at DESC).
+// id добавляется вторым ключом, чтобы порядок страниц был стабильным при равных значениях.
+// models.SortByRelevance упорядочивает по ts_rank, если в filters есть поисковый запрос: buildFilterConditions
+// ставит его первым условием, поэтому он передается параметром firstArgPos
+func buildOrderBy(sort models.SortOption, filters map[string]interface{}, firstArgPos int) string {
+	if sort.Field == models.SortByRelevance {
+		if query, ok := filters[models.SearchQueryFilter].(string); ok && query != "" {
+			return fmt.Sprintf("ORDER BY ts_rank(%s, plainto_tsquery('simple', $%d)) DESC, %s DESC, id DESC", searchVector, firstArgPos, defaultSortField)
+		}
+	}
+
+	for _, f := range sortableFields {
+		if f.Name != sort.Field {
+			continue
+		}
+
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		return fmt.Sprintf("ORDER BY %s %s, id %s", f.column, direction, direction)
+	}
+
+	return fmt.Sprintf("ORDER BY %s DESC, id DESC", defaultSortField)
+}
+
+// buildFilterConditions строит параметризованные условия WHERE по белому списку фильтров и поисковому запросу.
+// Возвращает условия, дополненный список аргументов и следующую позицию параметра.
+func buildFilterConditions(filters map[string]interface{}, args []interface{}, argPos int) ([]string, []interface{}, int) {
+	var conditions []string
+
+	// Поисковый запрос всегда первое условие: на его параметр ссылается сортировка по релевантности
+	if query, ok := filters[models.SearchQueryFilter].(string); ok && query != "" {
+		conditions = append(conditions, fmt.Sprintf("%s @@ plainto_tsquery('simple', $%d)", searchVector, argPos))
+		args = append(args, query)
+		argPos++
+	}
+
+	for _, f := range filterableFields {
+		value, ok := filters[f.Name]
+		if !ok {
+			continue
+		}
+
+		switch f.Operator {
+		case models.FilterOperatorEq:
+			str, ok := value.(string)
+			if !ok || str == "" {
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", f.column, argPos))
+			args = append(args, str)
+		case models.FilterOperatorContains:
+			str, ok := value.(string)
+			if !ok || str == "" {
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf(`%s ILIKE '%%' || $%d || '%%' ESCAPE '\'`, f.column, argPos))
+			args = append(args, likeEscaper.Replace(str))
+		case models.FilterOperatorGte, models.FilterOperatorLte:
+			num, ok := value.(float64)
+			if !ok {
+				continue
+			}
+			op := ">="
+			if f.Operator == models.FilterOperatorLte {
+				op = "<="
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s $%d", f.column, op, argPos))
+			args = append(args, num)
+		default:
+			continue
+		}
+		argPos++
+	}
+
+	// Фильтры по атрибутам обходятся в порядке ключей, чтобы текст запроса не зависел от порядка map
+	var attributes []string
+	for key := range filters {
+		if strings.HasPrefix(key, models.AttributeFilterPrefix) && len(key) > len(models.AttributeFilterPrefix) {
+			attributes = append(attributes, key)
+		}
+	}
+	sort.Strings(attributes)
+
+	for _, key := range attributes {
+		var condition string
+		condition, args, argPos = buildAttributeCondition(strings.TrimPrefix(key, models.AttributeFilterPrefix), filters[key], args, argPos)
+		if condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return conditions, args, argPos
+}
+
+// buildAttributeCondition строит условие по атрибуту name, который ищется и в корне base_data, и во вложенном
+// объекте attributes. Имя атрибута, как и значение, передается параметром. Строка сравнивается с текстовым
+// значением атрибута (поэтому "42" совпадает и с числом 42), список строк - с любым из значений,
+// остальные JSON-значения (числа, логические) - по вхождению JSONB (@>)
+func buildAttributeCondition(name string, value interface{}, args []interface{}, argPos int) (string, []interface{}, int) {
+	switch v := value.(type) {
+	case string:
+		condition := fmt.Sprintf("(base_data->>$%[1]d = $%[2]d OR base_data->'attributes'->>$%[1]d = $%[2]d)", argPos, argPos+1)
+		return condition, append(args, name, v), argPos + 2
+	case []string:
+		if len(v) == 0 {
+			return "", args, argPos
+		}
+		condition := fmt.Sprintf("(base_data->>$%[1]d = ANY($%[2]d) OR base_data->'attributes'->>$%[1]d = ANY($%[2]d))", argPos, argPos+1)
+		return condition, append(args, name, v), argPos + 2
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return buildAttributeCondition(name, values, args, argPos)
+	case nil:
+		return "", args, argPos
+	default:
+		contained, err := json.Marshal(map[string]interface{}{name: v})
+		if err != nil {
+			return "", args, argPos
+		}
+		condition := fmt.Sprintf("(base_data @> $%[1]d::jsonb OR base_data->'attributes' @> $%[1]d::jsonb)", argPos)
+		return condition, append(args, string(contained)), argPos + 1
+	}
+}
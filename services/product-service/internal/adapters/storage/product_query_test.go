@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// schemaValue возвращает значение фильтра того типа, который объявлен в схеме
+func schemaValue(t *testing.T, field models.SchemaField) interface{} {
+	t.Helper()
+
+	switch field.Type {
+	case models.FieldTypeString:
+		return "apple"
+	case models.FieldTypeNumber:
+		return float64(100)
+	default:
+		t.Fatalf("filter %s has unsupported type %s", field.Name, field.Type)
+		return nil
+	}
+}
+
+func TestProductSchemaFiltersAreApplied(t *testing.T) {
+	schema := ProductSchema()
+	if len(schema.Filters) == 0 {
+		t.Fatal("schema has no filters")
+	}
+
+	for _, field := range schema.Filters {
+		t.Run(field.Name, func(t *testing.T) {
+			filters := map[string]interface{}{field.Name: schemaValue(t, field)}
+
+			conditions, args, next := buildFilterConditions(filters, nil, 2)
+			if len(conditions) != 1 || len(args) != 1 || next != 3 {
+				t.Fatalf("conditions = %v, args = %v, next = %d, want one parameterized condition", conditions, args, next)
+			}
+			if !strings.Contains(conditions[0], "$2") {
+				t.Fatalf("condition %q does not use its parameter", conditions[0])
+			}
+		})
+	}
+
+	// Фильтр, которого нет в схеме, не применяется
+	if conditions, _, _ := buildFilterConditions(map[string]interface{}{"unknown": "x"}, nil, 2); len(conditions) != 0 {
+		t.Fatalf("unknown filter applied: %v", conditions)
+	}
+}
+
+func TestProductSchemaSortsAreApplied(t *testing.T) {
+	schema := ProductSchema()
+	fallback := buildOrderBy(models.SortOption{Field: "unknown"}, nil, 2)
+
+	defaultListed := false
+	for _, field := range schema.Sortable {
+		defaultListed = defaultListed || field.Name == schema.DefaultSort
+
+		t.Run(field.Name, func(t *testing.T) {
+			// По возрастанию выражение не совпадает с сортировкой по умолчанию (DESC), даже для updated_at
+			orderBy := buildOrderBy(models.SortOption{Field: field.Name}, nil, 2)
+			if orderBy == fallback || !strings.Contains(orderBy, " ASC") {
+				t.Fatalf("sort by %s = %q, want the field's own ascending order", field.Name, orderBy)
+			}
+		})
+	}
+
+	if !defaultListed {
+		t.Fatalf("default sort %q is not listed as sortable", schema.DefaultSort)
+	}
+}
+
+func TestBuildOrderBy(t *testing.T) {
+	tests := []struct {
+		name string
+		sort models.SortOption
+		want string
+	}{
+		{name: "created_at asc", sort: models.SortOption{Field: "created_at"}, want: "ORDER BY created_at ASC, id ASC"},
+		{name: "updated_at desc", sort: models.SortOption{Field: "updated_at", Desc: true}, want: "ORDER BY updated_at DESC, id DESC"},
+		{name: "name", sort: models.SortOption{Field: "name", Desc: true}, want: "ORDER BY base_data->>'name' DESC, id DESC"},
+		{name: "empty", sort: models.SortOption{}, want: "ORDER BY updated_at DESC, id DESC"},
+		{name: "unknown", sort: models.SortOption{Field: "version"}, want: "ORDER BY updated_at DESC, id DESC"},
+		{name: "injection", sort: models.SortOption{Field: "name; DROP TABLE product.products"}, want: "ORDER BY updated_at DESC, id DESC"},
+		{name: "unknown ignores direction", sort: models.SortOption{Field: "tenant_id", Desc: false}, want: "ORDER BY updated_at DESC, id DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildOrderBy(tt.sort, nil, 2); got != tt.want {
+				t.Fatalf("buildOrderBy(%+v) = %q, want %q", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFilterConditionsEscapesLike(t *testing.T) {
+	conditions, args, _ := buildFilterConditions(map[string]interface{}{"name": `50%_off\`}, nil, 2)
+
+	if len(conditions) != 1 || !strings.HasSuffix(conditions[0], `ESCAPE '\'`) {
+		t.Fatalf("conditions = %v, want ILIKE with an escape character", conditions)
+	}
+	if args[0] != `50\%\_off\\` {
+		t.Fatalf("arg = %q, want the wildcards escaped", args[0])
+	}
+}
+
+func TestBuildAttributeConditions(t *testing.T) {
+	filters := map[string]interface{}{
+		models.AttributeFilterPrefix + "color":    "red",
+		models.AttributeFilterPrefix + "size":     []interface{}{"M", "L", 42},
+		models.AttributeFilterPrefix + "in_stock": true,
+		models.AttributeFilterPrefix + "weight":   float64(1.5),
+		models.AttributeFilterPrefix + "empty":    []string{},
+		models.AttributeFilterPrefix + "unset":    nil,
+		models.AttributeFilterPrefix:              "no name",
+	}
+
+	conditions, args, next := buildFilterConditions(filters, nil, 2)
+
+	// Атрибуты идут в порядке имен: color, in_stock, size, weight
+	want := []string{
+		"(base_data->>$2 = $3 OR base_data->'attributes'->>$2 = $3)",
+		"(base_data @> $4::jsonb OR base_data->'attributes' @> $4::jsonb)",
+		"(base_data->>$5 = ANY($6) OR base_data->'attributes'->>$5 = ANY($6))",
+		"(base_data @> $7::jsonb OR base_data->'attributes' @> $7::jsonb)",
+	}
+	if strings.Join(conditions, "\n") != strings.Join(want, "\n") || next != 8 {
+		t.Fatalf("conditions = %q, next = %d, want %q", conditions, next, want)
+	}
+
+	wantArgs := []interface{}{"color", "red", `{"in_stock":true}`, "size", []string{"M", "L"}, `{"weight":1.5}`}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("args = %v, want %v", args, wantArgs)
+	}
+	for i := range wantArgs {
+		if !reflect.DeepEqual(args[i], wantArgs[i]) {
+			t.Fatalf("arg %d = %#v, want %#v", i, args[i], wantArgs[i])
+		}
+	}
+}
+
+func TestPriceColumnIsGuarded(t *testing.T) {
+	// Цена и в фильтрах, и в сортировке приводится к numeric только после проверки формата
+	conditions, _, _ := buildFilterConditions(map[string]interface{}{"min_price": float64(10), "max_price": float64(20)}, nil, 2)
+	orderBy := buildOrderBy(models.SortOption{Field: "price"}, nil, 2)
+
+	for _, expr := range append(conditions, orderBy) {
+		if !strings.Contains(expr, "CASE WHEN base_data->>'price' ~ ") {
+			t.Fatalf("%q casts the price without a guard", expr)
+		}
+	}
+}
+
+// saveAttributeProduct сохраняет продукт с заданным base_data
+func saveAttributeProduct(t *testing.T, storage *ProductStorage, tenantID string, baseData string) *models.Product {
+	t.Helper()
+
+	product := &models.Product{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		SupplierID: "supplier-1",
+		BaseData:   json.RawMessage(baseData),
+	}
+	if err := storage.SaveProduct(context.Background(), product); err != nil {
+		t.Fatalf("SaveProduct: %v", err)
+	}
+	return product
+}
+
+func TestListProductsAttributeFilters(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	redNike := saveAttributeProduct(t, storage, tenantID, `{"name":"Red Nike","price":100,"color":"red","brand":"Nike","size":42}`)
+	redAdidas := saveAttributeProduct(t, storage, tenantID, `{"name":"Red Adidas","price":200,"attributes":{"color":"red","brand":"Adidas","size":44}}`)
+	blueNike := saveAttributeProduct(t, storage, tenantID, `{"name":"Blue Nike","price":"n/a","color":"blue","brand":"Nike","size":42}`)
+	saveAttributeProduct(t, storage, uuid.NewString(), `{"name":"Other tenant","price":100,"color":"red","brand":"Nike"}`)
+
+	attr := func(name string) string { return models.AttributeFilterPrefix + name }
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    []*models.Product
+	}{
+		{name: "color in root and nested attributes", filters: map[string]interface{}{attr("color"): "red"}, want: []*models.Product{redNike, redAdidas}},
+		{name: "color and brand", filters: map[string]interface{}{attr("color"): "red", attr("brand"): "Nike"}, want: []*models.Product{redNike}},
+		{name: "any of brands", filters: map[string]interface{}{attr("brand"): []interface{}{"Nike", "Adidas"}}, want: []*models.Product{redNike, redAdidas, blueNike}},
+		{name: "number by containment", filters: map[string]interface{}{attr("size"): float64(42)}, want: []*models.Product{redNike, blueNike}},
+		{name: "number as text", filters: map[string]interface{}{attr("size"): "44"}, want: []*models.Product{redAdidas}},
+		{name: "attribute and price", filters: map[string]interface{}{attr("brand"): "Nike", "min_price": float64(50)}, want: []*models.Product{redNike}},
+		{name: "no match", filters: map[string]interface{}{attr("color"): "green"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, total, err := storage.ListProducts(ctx, tenantID, tt.filters, models.SortOption{Field: "name"}, 1, 10)
+			if err != nil {
+				t.Fatalf("ListProducts: %v", err)
+			}
+
+			want := make(map[string]bool, len(tt.want))
+			for _, product := range tt.want {
+				want[product.ID] = true
+			}
+			if total != len(tt.want) || len(products) != len(tt.want) {
+				t.Fatalf("total = %d, products = %d, want %d", total, len(products), len(tt.want))
+			}
+			for _, product := range products {
+				if !want[product.ID] {
+					t.Fatalf("unexpected product %s", product.BaseData)
+				}
+			}
+		})
+	}
+
+	// Нечисловая цена не ломает сортировку по цене
+	if _, _, err := storage.ListProducts(ctx, tenantID, nil, models.SortOption{Field: "price"}, 1, 10); err != nil {
+		t.Fatalf("ListProducts sorted by price: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestBuildFilterConditionsSearchFirst(t *testing.T) {
+	filters := map[string]interface{}{
+		"supplier_id":            "supplier-1",
+		models.SearchQueryFilter: "apple",
+	}
+
+	conditions, args, argPos := buildFilterConditions(filters, []interface{}{"tenant-1"}, 2)
+
+	if len(conditions) != 2 || argPos != 4 {
+		t.Fatalf("conditions = %v, next arg = %d", conditions, argPos)
+	}
+	if !strings.Contains(conditions[0], "@@ plainto_tsquery('simple', $2)") {
+		t.Fatalf("first condition = %q, want search on $2", conditions[0])
+	}
+	if conditions[1] != "supplier_id = $3" {
+		t.Fatalf("second condition = %q, want supplier on $3", conditions[1])
+	}
+	if args[1] != "apple" || args[2] != "supplier-1" {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestBuildOrderByRelevance(t *testing.T) {
+	relevance := models.SortOption{Field: models.SortByRelevance, Desc: true}
+
+	orderBy := buildOrderBy(relevance, map[string]interface{}{models.SearchQueryFilter: "apple"}, 2)
+	if !strings.HasPrefix(orderBy, "ORDER BY ts_rank(") || !strings.Contains(orderBy, "plainto_tsquery('simple', $2)) DESC") {
+		t.Fatalf("orderBy = %q, want ts_rank on $2", orderBy)
+	}
+
+	// Без поискового запроса сортировка по релевантности заменяется сортировкой по умолчанию
+	if orderBy := buildOrderBy(relevance, nil, 2); orderBy != "ORDER BY updated_at DESC, id DESC" {
+		t.Fatalf("orderBy without query = %q", orderBy)
+	}
+
+	price := models.SortOption{Field: "price"}
+	if orderBy := buildOrderBy(price, map[string]interface{}{models.SearchQueryFilter: "apple"}, 2); orderBy != "ORDER BY "+priceColumn+" ASC, id ASC" {
+		t.Fatalf("orderBy with explicit sort = %q", orderBy)
+	}
+}
+
+func TestListProductsSearch(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	otherTenantID := uuid.NewString()
+	supplierID := uuid.NewString()
+	otherSupplierID := uuid.NewString()
+
+	best := saveTestProduct(t, storage, tenantID, supplierID, "Apple juice", "apple apple fresh apple")
+	weaker := saveTestProduct(t, storage, tenantID, supplierID, "Apple pie", "dessert")
+	otherSupplier := saveTestProduct(t, storage, tenantID, otherSupplierID, "Green apple", "fruit")
+	saveTestProduct(t, storage, tenantID, supplierID, "Orange", "citrus")
+	saveTestProduct(t, storage, otherTenantID, supplierID, "Apple apple apple", "apple apple apple apple")
+
+	relevance := models.SortOption{Field: models.SortByRelevance, Desc: true}
+
+	t.Run("relevance order within tenant", func(t *testing.T) {
+		products, total, err := storage.ListProducts(ctx, tenantID, map[string]interface{}{models.SearchQueryFilter: "apple"}, relevance, 1, 10)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		if total != 3 || len(products) != 3 {
+			t.Fatalf("total = %d, products = %d, want 3 products of the tenant", total, len(products))
+		}
+		if products[0].ID != best.ID {
+			t.Fatalf("first product = %s, want the most relevant %s", products[0].ID, best.ID)
+		}
+		for _, product := range products {
+			if product.TenantID != "" && product.TenantID != tenantID {
+				t.Fatalf("product %s of another tenant returned", product.ID)
+			}
+			if product.ID != best.ID && product.ID != weaker.ID && product.ID != otherSupplier.ID {
+				t.Fatalf("unexpected product %s", product.ID)
+			}
+		}
+	})
+
+	t.Run("search combined with supplier filter", func(t *testing.T) {
+		filters := map[string]interface{}{
+			models.SearchQueryFilter: "apple",
+			"supplier_id":            supplierID,
+		}
+		products, total, err := storage.ListProducts(ctx, tenantID, filters, relevance, 1, 10)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		if total != 2 || len(products) != 2 {
+			t.Fatalf("total = %d, products = %d, want 2 products of the supplier", total, len(products))
+		}
+		if products[0].ID != best.ID || products[1].ID != weaker.ID {
+			t.Fatalf("products = [%s %s], want [%s %s]", products[0].ID, products[1].ID, best.ID, weaker.ID)
+		}
+	})
+}
+
+func TestListProductsNameFilterIsLiteral(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	supplierID := uuid.NewString()
+	discounted := saveTestProduct(t, storage, tenantID, supplierID, "Juice 50% off", "")
+	saveTestProduct(t, storage, tenantID, supplierID, "Juice 500 ml", "")
+	underscored := saveTestProduct(t, storage, tenantID, supplierID, "juice_box", "")
+	saveTestProduct(t, storage, tenantID, supplierID, "juice-box", "")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "50%", want: discounted.ID},
+		{name: "e_b", want: underscored.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, total, err := storage.ListProducts(ctx, tenantID, map[string]interface{}{"name": tt.name}, models.SortOption{}, 1, 10)
+			if err != nil {
+				t.Fatalf("ListProducts: %v", err)
+			}
+			if total != 1 || len(products) != 1 || products[0].ID != tt.want {
+				t.Fatalf("name %q matched %d products, want only %s", tt.name, total, tt.want)
+			}
+		})
+	}
+}
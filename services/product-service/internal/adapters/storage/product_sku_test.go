@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+func TestUpsertProductBySKU(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	upsert := func(supplierID, name string) (*models.Product, bool) {
+		t.Helper()
+		product := &models.Product{
+			ID:         uuid.NewString(),
+			TenantID:   tenantID,
+			SupplierID: supplierID,
+			BaseData:   json.RawMessage(`{"name":"` + name + `","sku":"AJ-1","price":100}`),
+		}
+		inserted, err := storage.UpsertProductBySKU(ctx, product)
+		if err != nil {
+			t.Fatalf("UpsertProductBySKU: %v", err)
+		}
+		return product, inserted
+	}
+
+	created, inserted := upsert("supplier-1", "Apple juice")
+	if !inserted {
+		t.Fatal("first upsert did not create the product")
+	}
+
+	// Новый ID заменяется ID продукта с тем же SKU
+	updated, inserted := upsert("supplier-1", "Apple juice 1L")
+	if inserted || updated.ID != created.ID || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("inserted = %v, id = %s, want product %s updated", inserted, updated.ID, created.ID)
+	}
+
+	stored, err := storage.GetProductBySKU(ctx, "AJ-1", "supplier-1", tenantID)
+	if err != nil {
+		t.Fatalf("GetProductBySKU: %v", err)
+	}
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(stored.BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	if stored.ID != created.ID || baseData["name"] != "Apple juice 1L" {
+		t.Fatalf("stored = %s %v, want the updated product", stored.ID, baseData)
+	}
+
+	// SKU уникален только в рамках поставщика
+	other, inserted := upsert("supplier-2", "Apple juice")
+	if !inserted || other.ID == created.ID {
+		t.Fatalf("inserted = %v, id = %s, want a separate product for another supplier", inserted, other.ID)
+	}
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestListSuppliers(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+	tenantID := uuid.NewString()
+
+	suppliers := []string{uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	for i, supplierID := range suppliers {
+		// У поставщиков разное число продуктов, в ответе каждый встречается один раз
+		for j := 0; j <= i; j++ {
+			saveTestProduct(t, storage, tenantID, supplierID, "Apple juice", "fresh")
+		}
+	}
+	saveTestProduct(t, storage, uuid.NewString(), uuid.NewString(), "Apple juice", "other tenant")
+
+	got, err := storage.ListSuppliers(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("ListSuppliers: %v", err)
+	}
+	sort.Strings(suppliers)
+	if !reflect.DeepEqual(got, suppliers) {
+		t.Fatalf("suppliers = %v, want %v", got, suppliers)
+	}
+
+	empty, err := storage.ListSuppliers(ctx, uuid.NewString())
+	if err != nil {
+		t.Fatalf("ListSuppliers: %v", err)
+	}
+	if empty == nil || len(empty) != 0 {
+		t.Fatalf("suppliers of a tenant without products = %#v, want an empty list", empty)
+	}
+}
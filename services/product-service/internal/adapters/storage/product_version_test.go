@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/tx"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestSaveProductVersion(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	product := saveTestProduct(t, storage, tenantID, uuid.NewString(), "Apple juice", "fresh")
+	if product.Version != 1 {
+		t.Fatalf("version after create = %d, want 1", product.Version)
+	}
+
+	// Клиент прочитал версию 1 и обновляет продукт
+	current := *product
+	current.BaseData = json.RawMessage(`{"name":"Apple juice 1L","price":120}`)
+	if err := storage.SaveProduct(ctx, &current); err != nil {
+		t.Fatalf("SaveProduct with current version: %v", err)
+	}
+	if current.Version != 2 {
+		t.Fatalf("version after update = %d, want 2", current.Version)
+	}
+
+	t.Run("stale version", func(t *testing.T) {
+		stale := *product
+		stale.BaseData = json.RawMessage(`{"name":"Stale","price":1}`)
+		if err := storage.SaveProduct(ctx, &stale); !errors.Is(err, utils.ErrVersionConflict) {
+			t.Fatalf("err = %v, want ErrVersionConflict", err)
+		}
+	})
+
+	t.Run("no version does not overwrite", func(t *testing.T) {
+		blind := *product
+		blind.Version = 0
+		blind.BaseData = json.RawMessage(`{"name":"Blind","price":1}`)
+		if err := storage.SaveProduct(ctx, &blind); !errors.Is(err, utils.ErrVersionConflict) {
+			t.Fatalf("err = %v, want ErrVersionConflict", err)
+		}
+	})
+
+	stored, err := storage.GetProduct(ctx, product.ID, tenantID)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(stored.BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	if stored.Version != 2 || baseData["name"] != "Apple juice 1L" {
+		t.Fatalf("stored = version %d, name %v, want the accepted update", stored.Version, baseData["name"])
+	}
+}
+
+func TestSaveProductsVersion(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	first := saveTestProduct(t, storage, tenantID, uuid.NewString(), "Apple juice", "fresh")
+	second := saveTestProduct(t, storage, tenantID, uuid.NewString(), "Orange juice", "fresh")
+
+	update := func(product *models.Product, version int, name string) *models.Product {
+		updated := *product
+		updated.Version = version
+		updated.BaseData = json.RawMessage(`{"name":"` + name + `","price":120}`)
+		return &updated
+	}
+	created := &models.Product{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		SupplierID: uuid.NewString(),
+		BaseData:   json.RawMessage(`{"name":"Grape juice","price":90}`),
+	}
+
+	t.Run("conflict is reported and rolled back", func(t *testing.T) {
+		batch := []*models.Product{update(first, 1, "Apple juice 1L"), update(second, 0, "Blind"), created}
+
+		err := tx.NewTxManager(storage.pool).Do(ctx, func(txCtx context.Context) error {
+			return storage.SaveProducts(txCtx, batch)
+		})
+
+		var conflict *utils.VersionConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, utils.ErrVersionConflict) {
+			t.Fatalf("err = %v, want VersionConflictError", err)
+		}
+		if len(conflict.ProductIDs) != 1 || conflict.ProductIDs[0] != second.ID {
+			t.Fatalf("conflicts = %v, want only %s", conflict.ProductIDs, second.ID)
+		}
+
+		stored, err := storage.GetProduct(ctx, first.ID, tenantID)
+		if err != nil {
+			t.Fatalf("GetProduct: %v", err)
+		}
+		if stored.Version != 1 {
+			t.Fatalf("version = %d, want the written row rolled back", stored.Version)
+		}
+		if _, err := storage.GetProduct(ctx, created.ID, tenantID); !errors.Is(err, utils.ErrProductNotFound) {
+			t.Fatalf("GetProduct(created) err = %v, want the insert rolled back", err)
+		}
+	})
+
+	t.Run("current versions are saved", func(t *testing.T) {
+		batch := []*models.Product{update(first, 1, "Apple juice 1L"), update(second, 1, "Orange juice 1L"), created}
+		if err := storage.SaveProducts(ctx, batch); err != nil {
+			t.Fatalf("SaveProducts: %v", err)
+		}
+		if batch[0].Version != 2 || batch[1].Version != 2 || batch[2].Version != 1 {
+			t.Fatalf("versions = %d, %d, %d, want 2, 2, 1", batch[0].Version, batch[1].Version, batch[2].Version)
+		}
+	})
+}
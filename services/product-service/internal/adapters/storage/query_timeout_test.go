@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	parent := context.Background()
+
+	bounded := &ProductStorage{queryTimeout: time.Second}
+	ctx, cancel := bounded.withQueryTimeout(parent)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Second {
+		t.Fatalf("deadline = %v, %v, want at most 1s from now", deadline, ok)
+	}
+
+	// Более ранний дедлайн запроса сохраняется
+	early, cancelEarly := context.WithTimeout(parent, 10*time.Millisecond)
+	defer cancelEarly()
+	ctx, cancel = bounded.withQueryTimeout(early)
+	defer cancel()
+	if d, _ := ctx.Deadline(); time.Until(d) > 10*time.Millisecond {
+		t.Fatalf("deadline = %v, want the earlier request deadline", d)
+	}
+
+	unbounded := &ProductStorage{}
+	ctx, cancel = unbounded.withQueryTimeout(parent)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("deadline set with queryTimeout 0")
+	}
+}
+
+func TestSlowReadCancelledAtQueryTimeout(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	const queryTimeout = 200 * time.Millisecond
+	bounded, err := NewPostgresStorageWithPool(ctx, storage.pool, queryTimeout)
+	if err != nil {
+		t.Fatalf("NewPostgresStorageWithPool: %v", err)
+	}
+
+	// Эксклюзивная блокировка таблицы в другой транзакции заставляет чтение ждать
+	lock, err := storage.pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	defer lock.Rollback(ctx)
+	if _, err := lock.Exec(ctx, "LOCK TABLE product.products IN ACCESS EXCLUSIVE MODE"); err != nil {
+		t.Fatalf("LOCK TABLE: %v", err)
+	}
+
+	start := time.Now()
+	_, err = bounded.GetProduct(ctx, uuid.NewString(), uuid.NewString())
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetProduct = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed < queryTimeout || elapsed > queryTimeout+time.Second {
+		t.Fatalf("read cancelled after %v, want about %v", elapsed, queryTimeout)
+	}
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// testDatabaseURLEnv переменная окружения с адресом тестовой базы PostgreSQL.
+// Без нее тесты, которым нужна база, пропускаются
+const testDatabaseURLEnv = "PRODUCT_TEST_DATABASE_URL"
+
+// newTestStorage подключается к тестовой базе и применяет migrations/init.sql (миграция идемпотентна).
+// Тесты изолируются собственным тенантом, поэтому база между запусками не очищается
+func newTestStorage(t *testing.T) *ProductStorage {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s не задан", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	storage, err := NewPostgresStorage(ctx, url, 0)
+	if err != nil {
+		t.Fatalf("NewPostgresStorage: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	migration, err := os.ReadFile("../../../migrations/init.sql")
+	if err != nil {
+		t.Fatalf("read migration: %v", err)
+	}
+	if _, err := storage.pool.Exec(ctx, string(migration)); err != nil {
+		t.Fatalf("apply migration: %v", err)
+	}
+
+	return storage
+}
+
+// saveTestProduct сохраняет продукт поставщика с заданными именем и описанием
+func saveTestProduct(t *testing.T, storage *ProductStorage, tenantID, supplierID, name, description string) *models.Product {
+	t.Helper()
+
+	baseData, err := json.Marshal(map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"price":       100,
+	})
+	if err != nil {
+		t.Fatalf("marshal base_data: %v", err)
+	}
+
+	product := &models.Product{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		SupplierID: supplierID,
+		BaseData:   baseData,
+	}
+	if err := storage.SaveProduct(context.Background(), product); err != nil {
+		t.Fatalf("SaveProduct: %v", err)
+	}
+	return product
+}
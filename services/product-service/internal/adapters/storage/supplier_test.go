@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestInventoryAndPriceScopedBySupplier(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	tenantID := uuid.NewString()
+	// Строковые ID поставщиков, которые не приводятся к числу
+	suppliers := []string{uuid.NewString(), "supplier-" + uuid.NewString()[:8]}
+
+	products := make(map[string]*models.Product, len(suppliers))
+	for i, supplierID := range suppliers {
+		product := saveTestProduct(t, storage, tenantID, supplierID, "Juice", "")
+		products[supplierID] = product
+
+		if err := storage.SaveInventory(ctx, &models.ProductInventory{
+			ProductID:  product.ID,
+			SupplierID: supplierID,
+			Quantity:   10 * (i + 1),
+		}, tenantID); err != nil {
+			t.Fatalf("SaveInventory: %v", err)
+		}
+		if err := storage.SavePrice(ctx, &models.ProductPrice{
+			ProductID:  product.ID,
+			SupplierID: supplierID,
+			BasePrice:  float64(100 * (i + 1)),
+			Currency:   "RUB",
+			StartDate:  time.Now().UTC(),
+			EndDate:    time.Now().UTC().Add(24 * time.Hour),
+		}, tenantID); err != nil {
+			t.Fatalf("SavePrice: %v", err)
+		}
+	}
+
+	for i, supplierID := range suppliers {
+		product := products[supplierID]
+
+		inventory, err := storage.GetInventory(ctx, product.ID, tenantID)
+		if err != nil || inventory == nil {
+			t.Fatalf("GetInventory: %v, %v", inventory, err)
+		}
+		if inventory.SupplierID != product.SupplierID || inventory.Quantity != 10*(i+1) {
+			t.Fatalf("inventory = %+v, want supplier %s and its quantity", inventory, product.SupplierID)
+		}
+
+		price, err := storage.GetPrice(ctx, product.ID, tenantID)
+		if err != nil || price == nil {
+			t.Fatalf("GetPrice: %v, %v", price, err)
+		}
+		if price.SupplierID != product.SupplierID || price.BasePrice != float64(100*(i+1)) {
+			t.Fatalf("price = %+v, want supplier %s and its price", price, product.SupplierID)
+		}
+
+		listed, total, err := storage.ListProducts(ctx, tenantID, (&models.ProductFilter{SupplierID: supplierID}).ToMap(), models.SortOption{}, 1, 10)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		if total != 1 || len(listed) != 1 || listed[0].ID != product.ID {
+			t.Fatalf("listed %d of %d, want only the product of supplier %s", len(listed), total, supplierID)
+		}
+	}
+
+	// Продукт не находится по чужому поставщику
+	if _, err := storage.GetProductBySupplier(ctx, products[suppliers[0]].ID, suppliers[1], tenantID); !errors.Is(err, utils.ErrProductNotFound) {
+		t.Fatalf("GetProductBySupplier with another supplier: err = %v, want ErrProductNotFound", err)
+	}
+}
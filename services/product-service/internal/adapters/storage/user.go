@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UserStorage хранилище учетных записей пользователей
+type UserStorage struct {
+	pool *pgxpool.Pool
+}
+
+// NewUserStorage создает хранилище пользователей поверх общего пула соединений
+func NewUserStorage(pool *pgxpool.Pool) *UserStorage {
+	return &UserStorage{pool: pool}
+}
+
+// GetUserByUsername получает пользователя по имени. Если пользователь не найден, возвращает nil, nil
+func (s *UserStorage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	query := `
+		SELECT id, tenant_id, username, password_hash, roles, permissions, created_at
+		FROM auth.users
+		WHERE username = $1
+	`
+
+	var user models.User
+	err := s.pool.QueryRow(ctx, query, username).Scan(&user.ID, &user.TenantID, &user.Username,
+		&user.PasswordHash, &user.Roles, &user.Permissions, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
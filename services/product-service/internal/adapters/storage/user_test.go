@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGetUserByUsername(t *testing.T) {
+	storage := newTestStorage(t)
+	users := NewUserStorage(storage.pool)
+	ctx := context.Background()
+
+	userID := uuid.NewString()
+	username := "user-" + userID
+	_, err := storage.pool.Exec(ctx, `
+		INSERT INTO auth.users (id, tenant_id, username, password_hash, roles, permissions)
+		VALUES ($1, 'tenant-1', $2, 'hash', $3, $4)
+	`, userID, username, []string{"supplier"}, []string{"products:write"})
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	t.Cleanup(func() { storage.pool.Exec(context.Background(), `DELETE FROM auth.users WHERE id = $1`, userID) })
+
+	user, err := users.GetUserByUsername(ctx, username)
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %v", err)
+	}
+	if user == nil || user.ID != userID || user.TenantID != "tenant-1" || user.PasswordHash != "hash" ||
+		len(user.Roles) != 1 || user.Roles[0] != "supplier" || len(user.Permissions) != 1 || user.CreatedAt.IsZero() {
+		t.Fatalf("user = %+v, want the inserted user", user)
+	}
+
+	// Отсутствующий пользователь не ошибка: AuthService отвечает на него так же, как на неверный пароль
+	if user, err := users.GetUserByUsername(ctx, "missing-"+userID); user != nil || err != nil {
+		t.Fatalf("GetUserByUsername(missing) = %+v, %v, want nil, nil", user, err)
+	}
+}
@@ -0,0 +1,88 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/dto"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPSupplier получает данные поставщика по HTTP API
+type HTTPSupplier struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ interfaces.SupplierPort = (*HTTPSupplier)(nil)
+
+// NewHTTPSupplier создает HTTP-клиент API поставщиков
+func NewHTTPSupplier(baseURL string, timeout time.Duration) *HTTPSupplier {
+	return &HTTPSupplier{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// FetchCatalog запрашивает GET {baseURL}/suppliers/{supplierID}/catalog
+func (s *HTTPSupplier) FetchCatalog(ctx context.Context, supplierID string) ([]*dto.SupplierProduct, error) {
+	var resp struct {
+		Products []*dto.SupplierProduct `json:"products"`
+	}
+	if err := s.get(ctx, supplierID, "catalog", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Products, nil
+}
+
+// FetchInventory запрашивает GET {baseURL}/suppliers/{supplierID}/inventory
+func (s *HTTPSupplier) FetchInventory(ctx context.Context, supplierID string) ([]*dto.SupplierInventory, error) {
+	var resp struct {
+		Items []*dto.SupplierInventory `json:"items"`
+	}
+	if err := s.get(ctx, supplierID, "inventory", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// FetchPrices запрашивает GET {baseURL}/suppliers/{supplierID}/prices
+func (s *HTTPSupplier) FetchPrices(ctx context.Context, supplierID string) ([]*dto.SupplierPrice, error) {
+	var resp struct {
+		Items []*dto.SupplierPrice `json:"items"`
+	}
+	if err := s.get(ctx, supplierID, "prices", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// get выполняет запрос к ресурсу поставщика и декодирует JSON-ответ в out
+func (s *HTTPSupplier) get(ctx context.Context, supplierID, resource string, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/suppliers/%s/%s", s.baseURL, url.PathEscape(supplierID), resource)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build supplier request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch supplier %s: %w", resource, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("supplier %s request failed with status %d", resource, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode supplier %s: %w", resource, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package supplier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/dto"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// ToProduct преобразует товар поставщика в продукт. Описательные поля попадают в base_data,
+// TenantID и ID не заполняются
+func ToProduct(supplierID string, item *dto.SupplierProduct) (*models.Product, error) {
+	if item == nil {
+		return nil, errors.New("supplier product is empty")
+	}
+	if item.SKU == "" {
+		return nil, errors.New("sku is required")
+	}
+	if item.Name == "" {
+		return nil, fmt.Errorf("name is required for sku %s", item.SKU)
+	}
+
+	baseData := map[string]interface{}{
+		"sku":  item.SKU,
+		"name": item.Name,
+	}
+	if item.Description != "" {
+		baseData["description"] = item.Description
+	}
+	if item.Brand != "" {
+		baseData["brand"] = item.Brand
+	}
+	if item.Category != "" {
+		baseData["category"] = item.Category
+	}
+	if len(item.Attributes) > 0 {
+		baseData["attributes"] = item.Attributes
+	}
+	if len(item.Images) > 0 {
+		baseData["images"] = item.Images
+	}
+
+	data, err := json.Marshal(baseData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal base data: %w", err)
+	}
+
+	return &models.Product{
+		SupplierID: supplierID,
+		BaseData:   data,
+	}, nil
+}
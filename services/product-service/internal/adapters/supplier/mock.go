@@ -0,0 +1,83 @@
+package supplier
+
+import (
+	"context"
+	"github.com/athebyme/gomarket-platform/pkg/dto"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"sync"
+)
+
+// MockSupplier хранит данные поставщиков в памяти. Используется в тестах и локальной разработке
+type MockSupplier struct {
+	mu        sync.RWMutex
+	catalogs  map[string][]*dto.SupplierProduct
+	inventory map[string][]*dto.SupplierInventory
+	prices    map[string][]*dto.SupplierPrice
+	err       error
+}
+
+var _ interfaces.SupplierPort = (*MockSupplier)(nil)
+
+// NewMockSupplier создает пустой MockSupplier
+func NewMockSupplier() *MockSupplier {
+	return &MockSupplier{
+		catalogs:  make(map[string][]*dto.SupplierProduct),
+		inventory: make(map[string][]*dto.SupplierInventory),
+		prices:    make(map[string][]*dto.SupplierPrice),
+	}
+}
+
+// SetCatalog задает каталог поставщика
+func (m *MockSupplier) SetCatalog(supplierID string, products []*dto.SupplierProduct) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.catalogs[supplierID] = products
+}
+
+// SetInventory задает остатки поставщика
+func (m *MockSupplier) SetInventory(supplierID string, items []*dto.SupplierInventory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inventory[supplierID] = items
+}
+
+// SetPrices задает цены поставщика
+func (m *MockSupplier) SetPrices(supplierID string, items []*dto.SupplierPrice) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prices[supplierID] = items
+}
+
+// SetError задает ошибку, которую будут возвращать все методы; nil отключает ее
+func (m *MockSupplier) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+func (m *MockSupplier) FetchCatalog(_ context.Context, supplierID string) ([]*dto.SupplierProduct, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.catalogs[supplierID], nil
+}
+
+func (m *MockSupplier) FetchInventory(_ context.Context, supplierID string) ([]*dto.SupplierInventory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.inventory[supplierID], nil
+}
+
+func (m *MockSupplier) FetchPrices(_ context.Context, supplierID string) ([]*dto.SupplierPrice, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.prices[supplierID], nil
+}
@@ -0,0 +1,149 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/dto"
+)
+
+// sampleCatalog ответ API поставщика на запрос каталога
+const sampleCatalog = `{
+	"products": [
+		{
+			"sku": "AJ-1",
+			"name": "Apple juice",
+			"description": "Fresh pressed",
+			"brand": "Garden",
+			"category": "juices",
+			"attributes": {"volume_ml": 1000},
+			"images": ["https://cdn.example.com/aj-1.jpg"]
+		},
+		{"sku": "OJ-1", "name": "Orange juice"}
+	]
+}`
+
+func TestHTTPSupplier(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/suppliers/supplier 1/catalog":
+			w.Write([]byte(sampleCatalog))
+		case "/suppliers/supplier 1/inventory":
+			w.Write([]byte(`{"items":[{"sku":"AJ-1","quantity":12}]}`))
+		case "/suppliers/supplier 1/prices":
+			w.Write([]byte(`{"items":[{"sku":"AJ-1","price":150,"special_price":120,"currency":"RUB"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPSupplier(server.URL+"/", time.Second)
+	ctx := context.Background()
+
+	catalog, err := client.FetchCatalog(ctx, "supplier 1")
+	if err != nil {
+		t.Fatalf("FetchCatalog: %v", err)
+	}
+	if len(catalog) != 2 || catalog[0].SKU != "AJ-1" || catalog[0].Brand != "Garden" || len(catalog[0].Images) != 1 {
+		t.Fatalf("catalog = %+v, want the sample products", catalog)
+	}
+	// ID поставщика экранируется в пути
+	if paths[0] != "/suppliers/supplier%201/catalog" {
+		t.Fatalf("path = %q, want the escaped supplier ID", paths[0])
+	}
+
+	inventory, err := client.FetchInventory(ctx, "supplier 1")
+	if err != nil || len(inventory) != 1 || inventory[0].Quantity != 12 {
+		t.Fatalf("inventory = %+v, err = %v", inventory, err)
+	}
+	prices, err := client.FetchPrices(ctx, "supplier 1")
+	if err != nil || len(prices) != 1 || prices[0].Price != 150 || prices[0].SpecialPrice != 120 || prices[0].Currency != "RUB" {
+		t.Fatalf("prices = %+v, err = %v", prices, err)
+	}
+
+	if _, err := client.FetchCatalog(ctx, "unknown"); err == nil {
+		t.Fatal("FetchCatalog of an unknown supplier succeeded")
+	}
+}
+
+func TestToProduct(t *testing.T) {
+	var payload struct {
+		Products []*dto.SupplierProduct `json:"products"`
+	}
+	if err := json.Unmarshal([]byte(sampleCatalog), &payload); err != nil {
+		t.Fatalf("unmarshal catalog: %v", err)
+	}
+
+	product, err := ToProduct("supplier-1", payload.Products[0])
+	if err != nil {
+		t.Fatalf("ToProduct: %v", err)
+	}
+	if product.SupplierID != "supplier-1" || product.ID != "" || product.TenantID != "" {
+		t.Fatalf("product = %+v, want only the supplier set", product)
+	}
+
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(product.BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	want := map[string]interface{}{
+		"sku":         "AJ-1",
+		"name":        "Apple juice",
+		"description": "Fresh pressed",
+		"brand":       "Garden",
+		"category":    "juices",
+	}
+	for key, value := range want {
+		if baseData[key] != value {
+			t.Fatalf("base_data[%s] = %v, want %v", key, baseData[key], value)
+		}
+	}
+	if attrs, _ := baseData["attributes"].(map[string]interface{}); attrs["volume_ml"] != float64(1000) {
+		t.Fatalf("attributes = %v, want volume_ml", baseData["attributes"])
+	}
+	if images, _ := baseData["images"].([]interface{}); len(images) != 1 {
+		t.Fatalf("images = %v, want one image", baseData["images"])
+	}
+
+	// Пустые поля в base_data не попадают
+	minimal, err := ToProduct("supplier-1", payload.Products[1])
+	if err != nil {
+		t.Fatalf("ToProduct: %v", err)
+	}
+	if string(minimal.BaseData) != `{"name":"Orange juice","sku":"OJ-1"}` {
+		t.Fatalf("base_data = %s, want only sku and name", minimal.BaseData)
+	}
+
+	for _, item := range []*dto.SupplierProduct{nil, {Name: "No SKU"}, {SKU: "NN-1"}} {
+		if _, err := ToProduct("supplier-1", item); err == nil {
+			t.Fatalf("ToProduct(%+v) succeeded, want an error", item)
+		}
+	}
+}
+
+func TestMockSupplier(t *testing.T) {
+	mock := NewMockSupplier()
+	mock.SetCatalog("supplier-1", []*dto.SupplierProduct{{SKU: "AJ-1", Name: "Apple juice"}})
+	ctx := context.Background()
+
+	if catalog, err := mock.FetchCatalog(ctx, "supplier-1"); err != nil || len(catalog) != 1 {
+		t.Fatalf("catalog = %+v, err = %v", catalog, err)
+	}
+	if catalog, err := mock.FetchCatalog(ctx, "supplier-2"); err != nil || len(catalog) != 0 {
+		t.Fatalf("catalog of another supplier = %+v, err = %v, want empty", catalog, err)
+	}
+
+	failure := errors.New("supplier down")
+	mock.SetError(failure)
+	if _, err := mock.FetchPrices(ctx, "supplier-1"); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want the configured error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// maxRecacheProductIDs ограничивает количество ID в одном запросе на обновление кэша
+const maxRecacheProductIDs = 1000
+
+// RecacheProducts перечитывает продукты из БД и записывает свежие записи в кэш
+// @Summary Обновление кэша продуктов
+// @Description Перечитывает указанные продукты (или отобранные по фильтрам) из БД и записывает их в кэш
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param request body models.RecacheRequest true "Список ID продуктов или фильтры"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.RecacheResult} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /admin/products:recache [post]
+func (h *ProductHandler) RecacheProducts(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	var req models.RecacheRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат запроса"))
+		return
+	}
+
+	if len(req.ProductIDs) == 0 && req.Filters == nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Необходимо указать product_ids или filters"))
+		return
+	}
+
+	if len(req.ProductIDs) > maxRecacheProductIDs {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Слишком много ID продуктов в одном запросе"))
+		return
+	}
+
+	var filters map[string]interface{}
+	if req.Filters != nil {
+		filters = req.Filters.ToMap()
+	}
+
+	result, err := h.productService.RecacheProducts(r.Context(), tenantID, req.ProductIDs, filters)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// WarmCache прогревает кэш тенанта после развертывания
+// @Summary Прогрев кэша
+// @Description Записывает в кэш последние обновленные продукты и первые страницы списка продуктов. Одновременно кэш тенанта прогревает только одна реплика
+// @Tags admin
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.CacheWarmResult} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 409 {object} errorResponse "Прогрев уже выполняется"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /admin/cache:warm [post]
+func (h *ProductHandler) WarmCache(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	result, err := h.productService.WarmCache(r.Context(), tenantID)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    result,
+	})
+}
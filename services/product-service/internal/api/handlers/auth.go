@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/security"
+)
+
+// Внутренние ошибки выдачи и отзыва токенов. Причина присоединяется через fmt.Errorf("%w: %w", ...)
+// и записывается в лог render.Error, клиент получает только сообщение
+var (
+	errAuthenticate = models.NewError(models.ErrInternal, "internal_error", "Ошибка аутентификации")
+	errIssueToken   = models.NewError(models.ErrInternal, "internal_error", "Ошибка выпуска токена")
+	errRefreshToken = models.NewError(models.ErrInternal, "internal_error", "Ошибка обновления токена")
+	errRevokeToken  = models.NewError(models.ErrInternal, "internal_error", "Ошибка отзыва токена")
+)
+
+// AuthHandler обработчик запросов аутентификации
+type AuthHandler struct {
+	authService   security.AuthServiceInterface
+	jwtManager    *security.JWTManager
+	refreshTokens *security.RefreshTokenService
+	blacklist     *security.TokenBlacklist
+	logger        interfaces.LoggerPort
+}
+
+// NewAuthHandler создает новый обработчик аутентификации
+func NewAuthHandler(
+	authService security.AuthServiceInterface,
+	jwtManager *security.JWTManager,
+	refreshTokens *security.RefreshTokenService,
+	blacklist *security.TokenBlacklist,
+	logger interfaces.LoggerPort,
+) *AuthHandler {
+	return &AuthHandler{
+		authService:   authService,
+		jwtManager:    jwtManager,
+		refreshTokens: refreshTokens,
+		blacklist:     blacklist,
+		logger:        logger,
+	}
+}
+
+// loginRequest тело запроса на получение токена
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// refreshRequest тело запроса на обмен refresh-токена
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// loginResponse выданные токены
+type loginResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// newLoginResponse формирует ответ с парой токенов
+func (h *AuthHandler) newLoginResponse(pair *security.TokenPair) loginResponse {
+	return loginResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.jwtManager.Expiration().Seconds()),
+	}
+}
+
+// Login выдает JWT по имени пользователя и паролю
+// @Summary Получение токена
+// @Description Проверяет учетные данные и выдает JWT с ролями, правами и тенантом пользователя, а также refresh-токен
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body loginRequest true "Учетные данные"
+// @Success 200 {object} response{data=loginResponse} "Токен выдан"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Неверные учетные данные"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	user, err := h.authService.Authenticate(r.Context(), req.Username, req.Password)
+	if errors.Is(err, security.ErrInvalidCredentials) {
+		render.Error(w, r, err)
+		return
+	}
+	if err != nil {
+		render.Error(w, r, fmt.Errorf("%w: %w", errAuthenticate, err))
+		return
+	}
+
+	pair, err := h.refreshTokens.Issue(r.Context(), user.ID, user.TenantID, user.Roles, user.Permissions)
+	if err != nil {
+		render.Error(w, r, fmt.Errorf("%w: %w", errIssueToken, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    h.newLoginResponse(pair),
+	})
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов
+// @Summary Обновление токена
+// @Description Выдает новый токен доступа и новый refresh-токен; предъявленный refresh-токен становится недействительным.
+// @Description Повторное предъявление уже обмененного токена отзывает всю цепочку токенов этого входа
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body refreshRequest true "Refresh-токен"
+// @Success 200 {object} response{data=loginResponse} "Токены выданы"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Недействительный refresh-токен"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	pair, err := h.refreshTokens.Rotate(r.Context(), req.RefreshToken)
+	if errors.Is(err, security.ErrRefreshTokenReused) {
+		h.logger.WarnWithContext(r.Context(), "Повторное использование refresh-токена, цепочка отозвана")
+	}
+	if errors.Is(err, security.ErrInvalidToken) || errors.Is(err, security.ErrExpiredToken) || errors.Is(err, security.ErrRefreshTokenReused) {
+		render.Error(w, r, models.NewError(models.ErrUnauthorized, "invalid_token", "Недействительный refresh-токен"))
+		return
+	}
+	if err != nil {
+		render.Error(w, r, fmt.Errorf("%w: %w", errRefreshToken, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    h.newLoginResponse(pair),
+	})
+}
+
+// Logout отзывает токен доступа, которым подписан запрос
+// @Summary Выход
+// @Description Вносит текущий токен доступа в черный список до истечения его срока действия
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response{data=map[string]interface{}} "Токен отозван"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := security.ClaimsFromContext(r.Context())
+	if !ok || claims == nil || claims.ID == "" {
+		render.Error(w, r, models.NewError(models.ErrUnauthorized, "unauthorized", "Токен не может быть отозван"))
+		return
+	}
+
+	if err := h.blacklist.Revoke(r.Context(), claims); err != nil {
+		render.Error(w, r, fmt.Errorf("%w: %w", errRevokeToken, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data: map[string]interface{}{
+			"revoked": true,
+		},
+	})
+}
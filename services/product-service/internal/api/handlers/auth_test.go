@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/security"
+	"github.com/go-chi/chi/v5"
+)
+
+// userRepository учетные записи в памяти
+type userRepository struct {
+	users map[string]*models.User
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.users[username], nil
+}
+
+// newTestJWTManager создает JWTManager с новым ключом RSA
+func newTestJWTManager(t *testing.T) *security.JWTManager {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	manager, err := security.NewJWTManager(
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}),
+		time.Hour, 24*time.Hour, "product-service",
+	)
+	if err != nil {
+		t.Fatalf("NewJWTManager: %v", err)
+	}
+	return manager
+}
+
+// newAuthRouter создает маршруты аутентификации с пользователем alice и паролем s3cret
+func newAuthRouter(t *testing.T) (http.Handler, *security.JWTManager) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	hash, err := security.HashPassword("s3cret")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	users := &userRepository{users: map[string]*models.User{
+		"alice": {
+			ID:           "user-1",
+			TenantID:     "tenant-1",
+			Username:     "alice",
+			PasswordHash: hash,
+			Roles:        []string{"supplier"},
+			Permissions:  []string{"products:write"},
+		},
+	}}
+
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	manager := newTestJWTManager(t)
+	handler := NewAuthHandler(security.NewAuthService(users), manager,
+		security.NewRefreshTokenService(manager, memoryCache), security.NewTokenBlacklist(memoryCache), log)
+
+	router := chi.NewRouter()
+	router.Post("/auth/login", handler.Login)
+	router.Post("/auth/refresh", handler.Refresh)
+	return router, manager
+}
+
+// postJSON отправляет body в router и возвращает ответ
+func postJSON(router http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// loginResult тело успешного ответа с токенами
+type loginResult struct {
+	Data loginResponse `json:"data"`
+}
+
+func TestLogin(t *testing.T) {
+	router, manager := newAuthRouter(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		want     int
+		wantCode string
+	}{
+		{name: "valid", body: `{"username":"alice","password":"s3cret"}`, want: http.StatusOK},
+		{name: "wrong password", body: `{"username":"alice","password":"guess"}`, want: http.StatusUnauthorized, wantCode: "invalid_credentials"},
+		{name: "unknown user", body: `{"username":"bob","password":"s3cret"}`, want: http.StatusUnauthorized, wantCode: "invalid_credentials"},
+		{name: "no password", body: `{"username":"alice"}`, want: http.StatusUnauthorized, wantCode: "invalid_credentials"},
+		{name: "malformed", body: `{"username":`, want: http.StatusBadRequest, wantCode: "bad_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postJSON(router, "/auth/login", tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			if tt.wantCode != "" {
+				var resp render.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+				if resp.Error != tt.wantCode {
+					t.Fatalf("error = %q, want %q", resp.Error, tt.wantCode)
+				}
+				return
+			}
+
+			var resp loginResult
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Data.TokenType != "Bearer" || resp.Data.ExpiresIn != 3600 || resp.Data.RefreshToken == "" {
+				t.Fatalf("response = %+v, want a bearer token for an hour with a refresh token", resp.Data)
+			}
+
+			// Токен несет учетную запись, тенант, роли и права пользователя
+			claims, err := manager.Validate(resp.Data.AccessToken)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if claims.UserID != "user-1" || claims.TenantID != "tenant-1" ||
+				len(claims.Roles) != 1 || claims.Roles[0] != "supplier" ||
+				len(claims.Permissions) != 1 || claims.Permissions[0] != "products:write" {
+				t.Fatalf("claims = %+v, want the claims of alice", claims)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	router, manager := newAuthRouter(t)
+
+	login := postJSON(router, "/auth/login", `{"username":"alice","password":"s3cret"}`)
+	var issued loginResult
+	if err := json.NewDecoder(login.Body).Decode(&issued); err != nil {
+		t.Fatalf("decode login response: %v", err)
+	}
+
+	refresh := func(t *testing.T, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		return postJSON(router, "/auth/refresh", `{"refresh_token":"`+token+`"}`)
+	}
+	wantInvalid := func(t *testing.T, rec *httptest.ResponseRecorder) {
+		t.Helper()
+		var resp render.ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if rec.Code != http.StatusUnauthorized || resp.Error != "invalid_token" {
+			t.Fatalf("status = %d, error = %q, want 401 invalid_token", rec.Code, resp.Error)
+		}
+	}
+
+	rec := refresh(t, issued.Data.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var rotated loginResult
+	if err := json.NewDecoder(rec.Body).Decode(&rotated); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rotated.Data.RefreshToken == issued.Data.RefreshToken {
+		t.Fatal("refresh token not rotated")
+	}
+	if claims, err := manager.Validate(rotated.Data.AccessToken); err != nil || claims.UserID != "user-1" {
+		t.Fatalf("claims = %+v, err = %v, want an access token of user-1", claims, err)
+	}
+
+	// Повторное предъявление старого токена отзывает цепочку, и новый токен тоже перестает действовать
+	wantInvalid(t, refresh(t, issued.Data.RefreshToken))
+	wantInvalid(t, refresh(t, rotated.Data.RefreshToken))
+
+	// Токен доступа не обменивается на новую пару
+	wantInvalid(t, refresh(t, issued.Data.AccessToken))
+
+	if rec := postJSON(router, "/auth/refresh", `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d for an empty token, want 400", rec.Code)
+	}
+}
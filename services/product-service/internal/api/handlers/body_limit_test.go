@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/middleware"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCreateProductBodyTooLarge(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	service := &updateService{}
+	handler := NewProductHandler(service, log, 0)
+
+	router := chi.NewRouter()
+	router.Use(middleware.BodyLimit(64))
+	router.Post("/products", handler.CreateProduct)
+
+	// Размер не объявлен, поэтому лимит срабатывает при декодировании тела в обработчике
+	body := `{"base_data":{"name":"` + strings.Repeat("a", 128) + `"}}`
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	req.ContentLength = -1
+	req = req.WithContext(contextkeys.WithSupplier(contextkeys.WithTenant(req.Context(), "tenant-1"), "supplier-1"))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413: %s", rec.Code, rec.Body.String())
+	}
+	var resp render.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Error != "request_too_large" {
+		t.Fatalf("error = %q, want request_too_large", resp.Error)
+	}
+}
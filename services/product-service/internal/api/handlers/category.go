@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetProductsByCategory возвращает продукты категории и всех ее подкатегорий
+// @Summary Продукты категории
+// @Description Возвращает продукты, привязанные к категории или любой из ее подкатегорий, с пагинацией
+// @Tags categories
+// @Produce json
+// @Param category_id path string true "ID категории"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param page query int false "Номер страницы; смещение (page-1)*page_size не больше 10000" default(1) minimum(1)
+// @Param page_size query int false "Размер страницы, больший 100 уменьшается до 100" default(20) minimum(1) maximum(100)
+// @Security BearerAuth
+// @Success 200 {object} response{data=[]models.Product,meta=map[string]interface{}} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /categories/{category_id}/products [get]
+func (h *ProductHandler) GetProductsByCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "category_id")
+	if categoryID == "" {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "ID категории не указан"))
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	page, pageSize, err := parsePagination(r)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	products, total, err := h.productService.GetProductsByCategory(r.Context(), categoryID, tenantID, page, pageSize)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	pagination := utils.NewPagination(page, pageSize, "updated_at", true)
+	pagination.SetTotal(int64(total))
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    products,
+		Meta: map[string]interface{}{
+			"pagination": pagination,
+		},
+	})
+}
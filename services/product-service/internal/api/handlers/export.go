@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// Форматы экспорта продуктов
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+)
+
+// exportCSVHeader колонки CSV-экспорта. Первые колонки совпадают с колонками импорта,
+// base_data содержит исходный JSON целиком, включая атрибуты
+var exportCSVHeader = []string{"id", "supplier_id", "sku", "name", "description", "brand", "category", "images", "base_data", "created_at", "updated_at", "version"}
+
+// ExportProducts выгружает продукты тенанта файлом CSV или JSON
+// @Summary Экспорт продуктов
+// @Description Потоково выгружает все продукты тенанта, подходящие под фильтры списка, как вложение. Каталог читается страницами, поэтому размер выгрузки не ограничен памятью
+// @Tags products
+// @Produce text/csv
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param format query string false "Формат выгрузки: csv или json" default(csv)
+// @Param name query string false "Фильтр по имени продукта"
+// @Param description query string false "Фильтр по описанию продукта"
+// @Param supplier_id query string false "Фильтр по ID поставщика"
+// @Param min_price query number false "Минимальная цена"
+// @Param max_price query number false "Максимальная цена"
+// @Security BearerAuth
+// @Success 200 {file} file "Файл выгрузки"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Router /products/export [get]
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = exportFormatCSV
+	}
+	if format != exportFormatCSV && format != exportFormatJSON {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Формат должен быть csv или json"))
+		return
+	}
+
+	filters := parseSchemaFilters(r, h.productService.GetProductSchema())
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// После начала записи статус изменить нельзя, поэтому ошибка обхода только логируется и обрывает выгрузку
+	controller := http.NewResponseController(w)
+	var exported int
+	var err error
+	if format == exportFormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		exported, err = h.exportCSV(w, r, controller, tenantID, filters)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		exported, err = h.exportJSON(w, r, controller, tenantID, filters)
+	}
+	if err != nil {
+		h.logger.ErrorWithContext(r.Context(), "Ошибка экспорта продуктов",
+			interfaces.LogField{Key: "exported", Value: exported},
+			interfaces.LogField{Key: "error", Value: err.Error()})
+	}
+}
+
+// exportCSV пишет продукты в CSV построчно
+func (h *ProductHandler) exportCSV(w http.ResponseWriter, r *http.Request, controller *http.ResponseController, tenantID string, filters map[string]interface{}) (int, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportCSVHeader); err != nil {
+		return 0, err
+	}
+
+	exported, err := h.productService.ExportProducts(r.Context(), tenantID, filters, func(product *models.Product) error {
+		var baseData struct {
+			SKU         string   `json:"sku"`
+			Name        string   `json:"name"`
+			Description string   `json:"description"`
+			Brand       string   `json:"brand"`
+			Category    string   `json:"category"`
+			Images      []string `json:"images"`
+		}
+		// Нестандартный base_data выгружается только в колонке base_data
+		_ = json.Unmarshal(product.BaseData, &baseData)
+
+		return writer.Write([]string{
+			product.ID,
+			product.SupplierID,
+			baseData.SKU,
+			baseData.Name,
+			baseData.Description,
+			baseData.Brand,
+			baseData.Category,
+			strings.Join(baseData.Images, "|"),
+			string(product.BaseData),
+			product.CreatedAt.Format(time.RFC3339),
+			product.UpdatedAt.Format(time.RFC3339),
+			strconv.Itoa(product.Version),
+		})
+	}, func() error {
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		_ = controller.Flush()
+		return nil
+	})
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+	return exported, err
+}
+
+// exportJSON пишет продукты JSON-массивом, не собирая его в памяти
+func (h *ProductHandler) exportJSON(w http.ResponseWriter, r *http.Request, controller *http.ResponseController, tenantID string, filters map[string]interface{}) (int, error) {
+	if _, err := w.Write([]byte("[")); err != nil {
+		return 0, err
+	}
+
+	encoder := json.NewEncoder(w)
+	first := true
+	exported, err := h.productService.ExportProducts(r.Context(), tenantID, filters, func(product *models.Product) error {
+		if !first {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		first = false
+		return encoder.Encode(product)
+	}, func() error {
+		_ = controller.Flush()
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+
+	_, err = w.Write([]byte("]"))
+	return exported, err
+}
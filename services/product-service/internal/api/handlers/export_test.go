@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// exportRepository каталог в памяти с keyset-пагинацией по id и фильтром supplier_id
+type exportRepository struct {
+	postgres.ProductStoragePort
+	products []*models.Product
+	pages    int
+	maxPage  int
+}
+
+func (r *exportRepository) ListProductsAfter(ctx context.Context, tenantID string, filters map[string]interface{}, cursor string, limit int) ([]*models.Product, string, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var page []*models.Product
+	for _, product := range r.products {
+		if after != nil && product.ID <= after.ID {
+			continue
+		}
+		if supplierID, ok := filters["supplier_id"]; ok && product.SupplierID != supplierID {
+			continue
+		}
+		if len(page) == limit {
+			last := page[len(page)-1]
+			r.recordPage(len(page))
+			return page, utils.EncodeCursor(utils.Cursor{UpdatedAt: last.UpdatedAt, ID: last.ID}), nil
+		}
+		stored := *product
+		page = append(page, &stored)
+	}
+	r.recordPage(len(page))
+	return page, "", nil
+}
+
+func (r *exportRepository) recordPage(size int) {
+	r.pages++
+	r.maxPage = max(r.maxPage, size)
+}
+
+func newExportRouter(t *testing.T, count int) (*chi.Mux, *exportRepository) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &exportRepository{}
+	for i := 0; i < count; i++ {
+		repo.products = append(repo.products, &models.Product{
+			ID:         fmt.Sprintf("product-%05d", i),
+			SupplierID: fmt.Sprintf("supplier-%d", i%2+1),
+			BaseData:   json.RawMessage(fmt.Sprintf(`{"sku":"SKU-%d","name":"Juice %d","images":["a.jpg","b.jpg"]}`, i, i)),
+			UpdatedAt:  time.Now().UTC(),
+			Version:    1,
+		})
+	}
+	sort.Slice(repo.products, func(i, j int) bool { return repo.products[i].ID < repo.products[j].ID })
+
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+	service := services.NewProductService(repo, memoryCache, nil, log, nil, nil, nil, nil, nil)
+	handler := NewProductHandler(service, log, 0)
+
+	router := chi.NewRouter()
+	router.Get("/products/export", handler.ExportProducts)
+	return router, repo
+}
+
+func exportProducts(router http.Handler, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/products/export?"+query, nil)
+	req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestExportProductsCSV(t *testing.T) {
+	// Больше двух страниц exportPageSize
+	router, repo := newExportRouter(t, 1201)
+
+	rec := exportProducts(router, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" ||
+		!strings.HasPrefix(rec.Header().Get("Content-Disposition"), `attachment; filename="products-`) {
+		t.Fatalf("headers = %v, want a csv attachment", rec.Header())
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 1202 || strings.Join(records[0], ",") != strings.Join(exportCSVHeader, ",") {
+		t.Fatalf("rows = %d, header = %v, want the header and 1201 products", len(records), records[0])
+	}
+	if row := records[1]; row[0] != "product-00000" || row[2] != "SKU-0" || row[3] != "Juice 0" || row[7] != "a.jpg|b.jpg" || row[11] != "1" {
+		t.Fatalf("first row = %v", row)
+	}
+	// Каталог читается страницами, а не целиком
+	if repo.pages != 3 || repo.maxPage > 500 {
+		t.Fatalf("pages = %d, largest = %d, want 3 pages of at most 500", repo.pages, repo.maxPage)
+	}
+}
+
+func TestExportProductsJSONFilters(t *testing.T) {
+	router, _ := newExportRouter(t, 1201)
+
+	rec := exportProducts(router, "format=json&supplier_id=supplier-2")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type = %q, want json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var products []*models.Product
+	if err := json.NewDecoder(rec.Body).Decode(&products); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if len(products) != 600 {
+		t.Fatalf("products = %d, want the 600 products of supplier-2", len(products))
+	}
+	for _, product := range products {
+		if product.SupplierID != "supplier-2" || product.TenantID != "tenant-1" {
+			t.Fatalf("product = %+v, want supplier-2 of tenant-1", product)
+		}
+	}
+
+	if rec := exportProducts(router, "format=xml"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d for an unknown format, want 400", rec.Code)
+	}
+}
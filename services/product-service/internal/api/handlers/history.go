@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetProductHistory возвращает историю изменений продукта
+// @Summary История изменений продукта
+// @Description Возвращает записи истории с состояниями продукта до и после изменения, новые записи первыми
+// @Tags products
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param page query int false "Номер страницы; смещение (page-1)*page_size не больше 10000" default(1) minimum(1)
+// @Param page_size query int false "Размер страницы, больший 100 уменьшается до 100" default(20) minimum(1) maximum(100)
+// @Security BearerAuth
+// @Success 200 {object} response{data=[]models.ProductHistoryRecord,meta=map[string]interface{}} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/history [get]
+func (h *ProductHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	page, pageSize, err := parsePagination(r)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	records, total, err := h.productService.GetProductHistory(r.Context(), productID, tenantID, page, pageSize)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	pagination := utils.NewPagination(page, pageSize, "changed_at", true)
+	pagination.SetTotal(int64(total))
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    records,
+		Meta: map[string]interface{}{
+			"pagination": pagination,
+		},
+	})
+}
+
+// GetPriceHistory возвращает историю цен продукта
+// @Summary История цен продукта
+// @Description Возвращает все сохраненные состояния цены продукта, новые записи первыми.
+// @Description Подходит для показа предыдущей (зачеркнутой) цены на маркетплейсах
+// @Tags products
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param page query int false "Номер страницы; смещение (page-1)*page_size не больше 10000" default(1) minimum(1)
+// @Param page_size query int false "Размер страницы, больший 100 уменьшается до 100" default(20) minimum(1) maximum(100)
+// @Security BearerAuth
+// @Success 200 {object} response{data=[]models.PriceHistoryRecord,meta=map[string]interface{}} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/price/history [get]
+func (h *ProductHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	page, pageSize, err := parsePagination(r)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	records, total, err := h.productService.GetPriceHistory(r.Context(), productID, tenantID, page, pageSize)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	pagination := utils.NewPagination(page, pageSize, "changed_at", true)
+	pagination.SetTotal(int64(total))
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    records,
+		Meta: map[string]interface{}{
+			"pagination": pagination,
+		},
+	})
+}
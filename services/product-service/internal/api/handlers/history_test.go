@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// historyRepository история изменений в памяти, отдаваемая новыми записями первыми, как в хранилище
+type historyRepository struct {
+	postgres.ProductStoragePort
+	records []*models.ProductHistoryRecord
+}
+
+func (r *historyRepository) matching(productID, tenantID string) []*models.ProductHistoryRecord {
+	var records []*models.ProductHistoryRecord
+	for _, record := range r.records {
+		if record.ProductID == productID && record.After.TenantID == tenantID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ChangedAt > records[j].ChangedAt })
+	return records
+}
+
+func (r *historyRepository) CountProductHistory(ctx context.Context, productID string, tenantID string) (int, error) {
+	return len(r.matching(productID, tenantID)), nil
+}
+
+func (r *historyRepository) GetProductHistory(ctx context.Context, productID string, tenantID string, limit, offset int) ([]*models.ProductHistoryRecord, error) {
+	records := r.matching(productID, tenantID)
+	if offset >= len(records) {
+		return nil, nil
+	}
+	return records[offset:min(offset+limit, len(records))], nil
+}
+
+func historyRecord(id, tenantID string, changedAt int64, before, after string) *models.ProductHistoryRecord {
+	record := &models.ProductHistoryRecord{
+		ID:         id,
+		ProductID:  "product-1",
+		ChangeType: models.HistoryChangeUpdate,
+		After:      &models.Product{ID: "product-1", TenantID: tenantID, BaseData: json.RawMessage(`{"name":"` + after + `"}`)},
+		ChangedAt:  changedAt,
+	}
+	if before == "" {
+		record.ChangeType = models.HistoryChangeCreate
+	} else {
+		record.Before = &models.Product{ID: "product-1", TenantID: tenantID, BaseData: json.RawMessage(`{"name":"` + before + `"}`)}
+	}
+	return record
+}
+
+func TestGetProductHistory(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+	// Записи сохранены не по порядку, а у другого тенанта есть своя история того же продукта
+	repo := &historyRepository{records: []*models.ProductHistoryRecord{
+		historyRecord("history-2", "tenant-1", 200, "Apple juice", "Apple juice 1L"),
+		historyRecord("history-1", "tenant-1", 100, "", "Apple juice"),
+		historyRecord("history-3", "tenant-1", 300, "Apple juice 1L", "Apple juice 2L"),
+		historyRecord("history-x", "tenant-2", 400, "", "Foreign"),
+	}}
+	service := services.NewProductService(repo, memoryCache, nil, log, nil, nil, nil, nil, nil)
+	handler := NewProductHandler(service, log, 0)
+
+	router := chi.NewRouter()
+	router.Get("/products/{id}/history", handler.GetProductHistory)
+
+	tests := []struct {
+		query   string
+		wantIDs []string
+		want    utils.Pagination
+	}{
+		{query: "page_size=2", wantIDs: []string{"history-3", "history-2"},
+			want: utils.Pagination{Page: 1, PageSize: 2, TotalItems: 3, TotalPages: 2, SortBy: "changed_at", SortDesc: true, HasNext: true}},
+		{query: "page=2&page_size=2", wantIDs: []string{"history-1"},
+			want: utils.Pagination{Page: 2, PageSize: 2, TotalItems: 3, TotalPages: 2, SortBy: "changed_at", SortDesc: true, HasPrev: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/products/product-1/history?"+tt.query, nil)
+			req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+			}
+
+			var resp struct {
+				Data []*models.ProductHistoryRecord `json:"data"`
+				Meta struct {
+					Pagination utils.Pagination `json:"pagination"`
+				} `json:"meta"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+
+			var ids []string
+			for _, record := range resp.Data {
+				ids = append(ids, record.ID)
+				if record.After == nil || (record.ChangeType == models.HistoryChangeUpdate) != (record.Before != nil) {
+					t.Fatalf("record %s = %+v, want the before state for updates and the after state always", record.ID, record)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Fatalf("records = %v, want %v", ids, tt.wantIDs)
+			}
+			if resp.Meta.Pagination != tt.want {
+				t.Fatalf("pagination = %+v, want %+v", resp.Meta.Pagination, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/products/product-1/history?page=0", nil)
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", rec.Code)
+		}
+	})
+}
+
+// priceHistoryService сервис продуктов с историей цен в памяти, новые записи первыми
+type priceHistoryService struct {
+	services.ProductServiceInterface
+	records []*models.PriceHistoryRecord
+}
+
+func (s *priceHistoryService) GetPriceHistory(ctx context.Context, productID, tenantID string, page, pageSize int) ([]*models.PriceHistoryRecord, int, error) {
+	offset := (page - 1) * pageSize
+	if offset >= len(s.records) {
+		return nil, len(s.records), nil
+	}
+	return s.records[offset:min(offset+pageSize, len(s.records))], len(s.records), nil
+}
+
+func TestGetPriceHistory(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	changedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	service := &priceHistoryService{}
+	for i, basePrice := range []float64{90, 120, 100} {
+		service.records = append(service.records, &models.PriceHistoryRecord{
+			ID:        fmt.Sprintf("price-%d", 3-i),
+			ProductID: "product-1",
+			BasePrice: basePrice,
+			Currency:  "RUB",
+			ChangedAt: changedAt.Add(-time.Duration(i) * time.Hour),
+		})
+	}
+	handler := NewProductHandler(service, log, 0)
+
+	router := chi.NewRouter()
+	router.Get("/products/{id}/price/history", handler.GetPriceHistory)
+
+	request := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/products/product-1/price/history?"+query, nil)
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request("page=1&page_size=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data []*models.PriceHistoryRecord `json:"data"`
+		Meta struct {
+			Pagination utils.Pagination `json:"pagination"`
+		} `json:"meta"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	// Текущая цена первой, предыдущая (зачеркнутая) - второй
+	if len(resp.Data) != 2 || resp.Data[0].BasePrice != 90 || resp.Data[1].BasePrice != 120 ||
+		!resp.Data[0].ChangedAt.After(resp.Data[1].ChangedAt) {
+		t.Fatalf("records = %+v, want the newest two prices", resp.Data)
+	}
+	want := utils.Pagination{Page: 1, PageSize: 2, TotalItems: 3, TotalPages: 2, SortBy: "changed_at", SortDesc: true, HasNext: true}
+	if resp.Meta.Pagination != want {
+		t.Fatalf("pagination = %+v, want %+v", resp.Meta.Pagination, want)
+	}
+
+	if rec := request("page_size=abc"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d for an invalid page size, want 400", rec.Code)
+	}
+}
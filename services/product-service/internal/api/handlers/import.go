@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+// importFormField имя поля multipart-формы с CSV-файлом импорта
+const importFormField = "file"
+
+// ImportProducts импортирует продукты поставщика из CSV
+// @Summary Импорт продуктов из CSV
+// @Description Потоково читает CSV и создает или обновляет продукты поставщика по SKU пакетами в транзакциях.
+// @Description Первая строка — заголовок. Обязательные колонки: sku, name. Необязательные: description, brand, category,
+// @Description images (ссылки через |). Колонки вида attr.<имя> попадают в base_data.attributes, прочие игнорируются.
+// @Description Некорректные строки пропускаются и перечисляются в errors (не более 1000)
+// @Tags products
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param X-Supplier-ID header string true "ID поставщика"
+// @Param file formData file false "CSV-файл, если тело передается как multipart/form-data"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ImportSummary} "Итог импорта"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 413 {object} errorResponse "Файл слишком большой"
+// @Failure 500 {object} response{data=models.ImportSummary} "Импорт прерван, в data итог сохраненных пакетов"
+// @Router /products/import [post]
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
+		return
+	}
+
+	var file io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		var part *multipart.Part
+		if err == nil {
+			part, err = nextFilePart(reader, importFormField)
+		}
+		if err != nil || part == nil {
+			render.Error(w, r, invalidBody(err, "CSV-файл не передан в поле "+importFormField))
+			return
+		}
+		defer part.Close()
+		file = part
+	}
+
+	summary, err := h.productService.ImportProductsCSV(r.Context(), file, supplierID, tenantID)
+	if errors.Is(err, utils.ErrInvalidImportHeader) {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", err.Error()))
+		return
+	}
+	if err != nil {
+		// Сохраненные до сбоя пакеты остаются в базе, их итог нужен для повторного импорта
+		if summary != nil {
+			h.logger.WarnWithContext(r.Context(), "Импорт продуктов прерван",
+				interfaces.LogField{Key: "supplier_id", Value: supplierID},
+				interfaces.LogField{Key: "summary", Value: summary})
+		}
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    summary,
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// importService сервис продуктов, принимающий CSV с колонкой sku и помечающий строки "bad" ошибочными
+type importService struct {
+	services.ProductServiceInterface
+	supplierID string
+}
+
+func (s *importService) ImportProductsCSV(ctx context.Context, file io.Reader, supplierID, tenantID string) (*models.ImportSummary, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "sku,name" {
+		return nil, fmt.Errorf("%w: columns %q and %q are required", utils.ErrInvalidImportHeader, "sku", "name")
+	}
+
+	s.supplierID = supplierID
+	summary := &models.ImportSummary{}
+	for i, line := range lines[1:] {
+		if strings.Contains(line, "bad") {
+			summary.AddError(i+2, "", "name is required")
+			continue
+		}
+		summary.Created++
+	}
+	return summary, nil
+}
+
+func TestImportProducts(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	const file = "sku,name\nAJ-1,Apple juice\nbad\nOJ-1,Orange juice\n"
+	multipartBody := func(field string) (io.Reader, string) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile(field, "products.csv")
+		part.Write([]byte(file))
+		writer.Close()
+		return &body, writer.FormDataContentType()
+	}
+
+	tests := []struct {
+		name        string
+		body        func() (io.Reader, string)
+		noSupplier  bool
+		want        int
+		wantCreated int
+	}{
+		{name: "raw csv", body: func() (io.Reader, string) { return strings.NewReader(file), "text/csv" }, want: http.StatusOK, wantCreated: 2},
+		{name: "multipart", body: func() (io.Reader, string) { return multipartBody("file") }, want: http.StatusOK, wantCreated: 2},
+		{name: "multipart without file", body: func() (io.Reader, string) { return multipartBody("other") }, want: http.StatusBadRequest},
+		{name: "invalid header", body: func() (io.Reader, string) { return strings.NewReader("name\nApple juice\n"), "text/csv" }, want: http.StatusBadRequest},
+		{name: "no supplier", body: func() (io.Reader, string) { return strings.NewReader(file), "text/csv" }, noSupplier: true, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &importService{}
+			handler := NewProductHandler(service, log, 0)
+
+			router := chi.NewRouter()
+			router.Post("/products/import", handler.ImportProducts)
+
+			body, contentType := tt.body()
+			req := httptest.NewRequest(http.MethodPost, "/products/import", body)
+			req.Header.Set("Content-Type", contentType)
+			ctx := contextkeys.WithTenant(req.Context(), "tenant-1")
+			if !tt.noSupplier {
+				ctx = contextkeys.WithSupplier(ctx, "supplier-1")
+			}
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				var resp render.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Error != "bad_request" {
+					t.Fatalf("error = %+v (%v), want bad_request", resp, err)
+				}
+				return
+			}
+
+			var resp struct {
+				Data models.ImportSummary `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			// Ошибочная строка не прерывает импорт и попадает в итог
+			if resp.Data.Created != tt.wantCreated || resp.Data.Failed != 1 || len(resp.Data.Errors) != 1 || resp.Data.Errors[0].Line != 3 {
+				t.Fatalf("summary = %+v, want %d created and line 3 failed", resp.Data, tt.wantCreated)
+			}
+			if service.supplierID != "supplier-1" {
+				t.Fatalf("supplier = %q, want the supplier from context", service.supplierID)
+			}
+		})
+	}
+}
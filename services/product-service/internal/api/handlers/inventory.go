@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// inventoryRequest тело запроса на обновление остатков
+type inventoryRequest struct {
+	Quantity *int `json:"quantity"`
+}
+
+// inventoryAdjustRequest тело запроса на изменение остатков
+type inventoryAdjustRequest struct {
+	// Delta положительное значение пополняет остаток, отрицательное списывает
+	Delta *int `json:"delta"`
+}
+
+// GetInventory возвращает остатки продукта
+// @Summary Остатки продукта
+// @Description Возвращает текущие складские остатки продукта
+// @Tags inventory
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductInventory} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 404 {object} errorResponse "Остатки не найдены"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/inventory [get]
+func (h *ProductHandler) GetInventory(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	inventory, err := h.productService.GetInventory(r.Context(), productID, tenantID)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	if inventory == nil {
+		render.Error(w, r, models.NewError(models.ErrNotFound, "not_found", "Остатки продукта не найдены"))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    inventory,
+	})
+}
+
+// UpdateInventory обновляет остатки продукта
+// @Summary Обновление остатков продукта
+// @Description Устанавливает складские остатки продукта поставщика
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param X-Supplier-ID header string true "ID поставщика"
+// @Param inventory body inventoryRequest true "Количество на складе"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductInventory} "Остатки обновлены"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/inventory [put]
+func (h *ProductHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
+		return
+	}
+
+	var req inventoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Quantity == nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	if *req.Quantity < 0 {
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", "Количество не может быть отрицательным"))
+		return
+	}
+
+	inventory := &models.ProductInventory{
+		ProductID:  productID,
+		SupplierID: supplierID,
+		Quantity:   *req.Quantity,
+	}
+
+	if err := h.productService.UpdateInventory(r.Context(), inventory, tenantID); err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    inventory,
+	})
+}
+
+// AdjustInventory изменяет остатки продукта на заданную величину
+// @Summary Изменение остатков продукта
+// @Description Атомарно пополняет или списывает остатки продукта поставщика. В отличие от PUT /inventory
+// @Description одновременные списания не теряются. Списание не затрагивает активные резервы: если остатка за их вычетом
+// @Description не хватает, возвращается 409 и остаток не меняется
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param X-Supplier-ID header string true "ID поставщика"
+// @Param adjustment body inventoryAdjustRequest true "Изменение количества"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductInventory} "Остатки изменены"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 409 {object} errorResponse "Недостаточно остатков"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/inventory/adjust [post]
+func (h *ProductHandler) AdjustInventory(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
+		return
+	}
+
+	var req inventoryAdjustRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Delta == nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	if *req.Delta == 0 {
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", "Изменение количества не может быть нулевым"))
+		return
+	}
+
+	quantity, err := h.productService.AdjustInventory(r.Context(), productID, tenantID, supplierID, *req.Delta)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data: &models.ProductInventory{
+			ProductID:  productID,
+			SupplierID: supplierID,
+			Quantity:   quantity,
+			UpdatedAt:  time.Now().UTC(),
+		},
+	})
+}
+
+// reservationRequest тело запроса на резервирование остатков
+type reservationRequest struct {
+	Quantity int `json:"quantity"`
+	// TTLSeconds срок резерва в секундах, по умолчанию 15 минут, не больше суток
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+}
+
+// ReserveInventory резервирует остатки продукта
+// @Summary Резервирование остатков
+// @Description Временно удерживает количество продукта (например, на время оформления заказа), уменьшая доступный остаток.
+// @Description По истечении срока резерв перестает учитываться. Если доступного остатка не хватает, возвращается 409
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param reservation body reservationRequest true "Количество и срок резерва"
+// @Security BearerAuth
+// @Success 201 {object} response{data=models.InventoryReservation} "Резерв создан"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 409 {object} errorResponse "Недостаточно остатков"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/inventory/reservations [post]
+func (h *ProductHandler) ReserveInventory(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	var req reservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	reservation, err := h.productService.ReserveInventory(r.Context(), productID, tenantID, req.Quantity, ttl)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    reservation,
+	})
+}
+
+// ReleaseReservation снимает резерв остатков
+// @Summary Снятие резерва
+// @Description Снимает резерв, возвращая его количество в доступный остаток
+// @Tags inventory
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param reservationID path string true "ID резерва"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=map[string]interface{}} "Резерв снят"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 404 {object} errorResponse "Резерв не найден или истек"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/inventory/reservations/{reservationID} [delete]
+func (h *ProductHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	reservationID := chi.URLParam(r, "reservationID")
+	if productID == "" || reservationID == "" {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "ID продукта или резерва не указан"))
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	err := h.productService.ReleaseReservation(r.Context(), productID, reservationID, tenantID)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data: map[string]interface{}{
+			"id":       reservationID,
+			"released": true,
+		},
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// inventoryService сервис продуктов с остатками одного продукта в памяти
+type inventoryService struct {
+	services.ProductServiceInterface
+	inventory map[string]*models.ProductInventory
+}
+
+func (s *inventoryService) GetInventory(ctx context.Context, productID string, tenantID string) (*models.ProductInventory, error) {
+	return s.inventory[productID], nil
+}
+
+func (s *inventoryService) UpdateInventory(ctx context.Context, inventory *models.ProductInventory, tenantID string) error {
+	s.inventory[inventory.ProductID] = inventory
+	return nil
+}
+
+func newInventoryRouter(t *testing.T, service *inventoryService) http.Handler {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	handler := NewProductHandler(service, log, 0)
+
+	router := chi.NewRouter()
+	router.Get("/products/{id}/inventory", handler.GetInventory)
+	router.Put("/products/{id}/inventory", handler.UpdateInventory)
+	return router
+}
+
+func TestGetInventory(t *testing.T) {
+	service := &inventoryService{inventory: map[string]*models.ProductInventory{
+		"product-1": {ProductID: "product-1", SupplierID: "supplier-1", Quantity: 7},
+	}}
+	router := newInventoryRouter(t, service)
+
+	tests := []struct {
+		name      string
+		productID string
+		want      int
+	}{
+		{name: "found", productID: "product-1", want: http.StatusOK},
+		{name: "no inventory row", productID: "product-2", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/products/"+tt.productID+"/inventory", nil)
+			req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusNotFound {
+				var resp render.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Error != "not_found" {
+					t.Fatalf("response = %+v, err = %v, want not_found", resp, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateInventory(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		noSupplier bool
+		want       int
+	}{
+		{name: "update", body: `{"quantity":12}`, want: http.StatusOK},
+		{name: "zero", body: `{"quantity":0}`, want: http.StatusOK},
+		{name: "negative", body: `{"quantity":-1}`, want: http.StatusBadRequest},
+		{name: "no quantity", body: `{}`, want: http.StatusBadRequest},
+		{name: "no supplier", body: `{"quantity":12}`, noSupplier: true, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &inventoryService{inventory: map[string]*models.ProductInventory{}}
+			router := newInventoryRouter(t, service)
+
+			req := httptest.NewRequest(http.MethodPut, "/products/product-1/inventory", strings.NewReader(tt.body))
+			ctx := contextkeys.WithTenant(req.Context(), "tenant-1")
+			if !tt.noSupplier {
+				ctx = contextkeys.WithSupplier(ctx, "supplier-1")
+			}
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			saved := service.inventory["product-1"]
+			if tt.want != http.StatusOK {
+				if saved != nil {
+					t.Fatalf("inventory saved for a rejected request: %+v", saved)
+				}
+				return
+			}
+			if saved == nil || saved.SupplierID != "supplier-1" {
+				t.Fatalf("saved = %+v, want inventory of supplier-1", saved)
+			}
+
+			var resp struct {
+				Data models.ProductInventory `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Data.ProductID != "product-1" || resp.Data.Quantity != saved.Quantity {
+				t.Fatalf("response = %+v, want the saved inventory", resp.Data)
+			}
+		})
+	}
+}
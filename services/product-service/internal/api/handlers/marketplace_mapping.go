@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetMarketplaceMapping возвращает настройку маппинга полей для маркетплейса
+// @Summary Маппинг полей маркетплейса
+// @Description Возвращает соответствие ключей base_data ключам payload маркетплейса
+// @Tags marketplaces
+// @Produce json
+// @Param marketplace_id path int true "ID маркетплейса"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.MarketplaceFieldMapping} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 404 {object} errorResponse "Маппинг не настроен"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /marketplaces/{marketplace_id}/mapping [get]
+func (h *ProductHandler) GetMarketplaceMapping(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	marketplaceID, err := strconv.Atoi(chi.URLParam(r, "marketplace_id"))
+	if err != nil || marketplaceID <= 0 {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Некорректный ID маркетплейса"))
+		return
+	}
+
+	mapping, err := h.productService.GetMarketplaceMapping(r.Context(), marketplaceID, tenantID)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	if mapping == nil {
+		render.Error(w, r, models.NewError(models.ErrNotFound, "not_found", "Маппинг для маркетплейса не настроен"))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    mapping,
+	})
+}
+
+// SaveMarketplaceMapping сохраняет настройку маппинга полей для маркетплейса
+// @Summary Сохранение маппинга полей маркетплейса
+// @Description Создает или заменяет соответствие ключей base_data ключам payload маркетплейса
+// @Tags marketplaces
+// @Accept json
+// @Produce json
+// @Param marketplace_id path int true "ID маркетплейса"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param mapping body models.MarketplaceFieldMapping true "Маппинг полей"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.MarketplaceFieldMapping} "Маппинг сохранен"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /marketplaces/{marketplace_id}/mapping [put]
+func (h *ProductHandler) SaveMarketplaceMapping(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	marketplaceID, err := strconv.Atoi(chi.URLParam(r, "marketplace_id"))
+	if err != nil || marketplaceID <= 0 {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Некорректный ID маркетплейса"))
+		return
+	}
+
+	var mapping models.MarketplaceFieldMapping
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	mapping.TenantID = tenantID
+	mapping.MarketplaceID = marketplaceID
+
+	if err := mapping.Validate(); err != nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", err.Error()))
+		return
+	}
+
+	if err := h.productService.SaveMarketplaceMapping(r.Context(), &mapping); err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    &mapping,
+	})
+}
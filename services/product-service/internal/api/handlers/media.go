@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// mediaFormField имя поля multipart-формы с загружаемым файлом
+const mediaFormField = "file"
+
+// sizeLimitReader прерывает чтение, если файл оказался больше limit байт
+type sizeLimitReader struct {
+	r        io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+// errMediaTooLarge возвращается sizeLimitReader при превышении лимита
+var errMediaTooLarge = errors.New("media file is too large")
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		l.exceeded = true
+		return n, errMediaTooLarge
+	}
+	return n, err
+}
+
+// nextFilePart пропускает поля формы до файла в поле field. Возвращает nil, если такого файла нет
+func nextFilePart(reader *multipart.Reader, field string) (*multipart.Part, error) {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == field && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// UploadMedia загружает медиафайл продукта
+// @Summary Загрузка медиафайла
+// @Description Потоково загружает изображение или видео в объектное хранилище и добавляет его последним в медиафайлы продукта. Тип файла определяется по содержимому
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param file formData file true "Изображение (jpeg, png, webp, gif) или видео (mp4, webm)"
+// @Security BearerAuth
+// @Success 201 {object} response{data=models.ProductMedia} "Медиафайл загружен"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 404 {object} errorResponse "Продукт не найден"
+// @Failure 413 {object} errorResponse "Файл слишком большой"
+// @Failure 415 {object} errorResponse "Недопустимый тип файла"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/media [post]
+func (h *ProductHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Ожидается тело multipart/form-data"))
+		return
+	}
+
+	// Файл читается из тела напрямую, без буферизации формы в памяти или на диске
+	part, err := nextFilePart(reader, mediaFormField)
+	if err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректное тело multipart/form-data"))
+		return
+	}
+	if part == nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", fmt.Sprintf("Файл не передан в поле %s", mediaFormField)))
+		return
+	}
+	defer part.Close()
+
+	// Тип определяется по первым байтам файла, заголовку клиента не доверяем
+	buffered := bufio.NewReaderSize(part, 512)
+	head, _ := buffered.Peek(512)
+	contentType := http.DetectContentType(head)
+
+	limited := &sizeLimitReader{r: buffered, limit: h.mediaMaxSize}
+	media, err := h.productService.UploadProductMedia(r.Context(), productID, tenantID, limited, -1, contentType)
+	if limited.exceeded {
+		render.Error(w, r, models.NewError(models.ErrTooLarge, "request_too_large", fmt.Sprintf("Размер файла превышает %d байт", h.mediaMaxSize)))
+		return
+	}
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    media,
+	})
+}
+
+// DeleteMedia удаляет медиафайл продукта
+// @Summary Удаление медиафайла
+// @Description Удаляет медиафайл продукта и соответствующий объект в хранилище
+// @Tags media
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param mediaID path string true "ID медиафайла"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=map[string]interface{}} "Медиафайл удален"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 404 {object} errorResponse "Медиафайл не найден"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/media/{mediaID} [delete]
+func (h *ProductHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	mediaID := chi.URLParam(r, "mediaID")
+	if productID == "" || mediaID == "" {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "ID продукта или медиафайла не указан"))
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	err := h.productService.DeleteProductMedia(r.Context(), productID, mediaID, tenantID)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data: map[string]interface{}{
+			"id":      mediaID,
+			"deleted": true,
+		},
+	})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeObjectStore объектное хранилище в памяти
+type fakeObjectStore struct {
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func (s *fakeObjectStore) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	s.objects[key] = data
+	s.contentTypes[key] = contentType
+	return "https://cdn.example.com/" + key, nil
+}
+
+func (s *fakeObjectStore) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+// mediaRepository медиафайлы продукта product-1 в памяти
+type mediaRepository struct {
+	postgres.ProductStoragePort
+	media map[string]*models.ProductMedia
+}
+
+func (r *mediaRepository) GetProduct(ctx context.Context, productID string, tenantID string) (*models.Product, error) {
+	if productID != "product-1" {
+		return nil, utils.ErrProductNotFound
+	}
+	return &models.Product{ID: productID, TenantID: tenantID, SupplierID: "supplier-1"}, nil
+}
+
+func (r *mediaRepository) GetMediaByProductID(ctx context.Context, productID string, tenantID string) ([]*models.ProductMedia, error) {
+	var media []*models.ProductMedia
+	for _, m := range r.media {
+		if m.ProductID == productID {
+			media = append(media, m)
+		}
+	}
+	return media, nil
+}
+
+func (r *mediaRepository) SaveMedia(ctx context.Context, media *models.ProductMedia, tenantID string) error {
+	r.media[media.ID] = media
+	return nil
+}
+
+func (r *mediaRepository) GetMedia(ctx context.Context, mediaID string, tenantID string) (*models.ProductMedia, error) {
+	return r.media[mediaID], nil
+}
+
+func (r *mediaRepository) DeleteMedia(ctx context.Context, mediaID string, tenantID string) error {
+	delete(r.media, mediaID)
+	return nil
+}
+
+// gifFile минимальный GIF: тип определяется по сигнатуре, миниатюры для GIF не строятся
+var gifFile = append([]byte("GIF89a"), bytes.Repeat([]byte{0}, 32)...)
+
+// newMediaRouter создает маршруты медиафайлов с лимитом размера файла 1 КБ
+func newMediaRouter(t *testing.T) (http.Handler, *mediaRepository, *fakeObjectStore) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	repo := &mediaRepository{media: make(map[string]*models.ProductMedia)}
+	objects := &fakeObjectStore{objects: make(map[string][]byte), contentTypes: make(map[string]string)}
+	service := services.NewProductService(repo, memoryCache, nil, log, nil, nil, nil, objects, nil)
+	handler := NewProductHandler(service, log, 1024)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(contextkeys.WithTenant(r.Context(), "tenant-1")))
+		})
+	})
+	router.Post("/products/{id}/media", handler.UploadMedia)
+	router.Delete("/products/{id}/media/{mediaID}", handler.DeleteMedia)
+	return router, repo, objects
+}
+
+// uploadMedia отправляет файл data в поле field multipart-формы
+func uploadMedia(router http.Handler, productID, field string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("description", "front view")
+	part, _ := writer.CreateFormFile(field, "upload.bin")
+	part.Write(data)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/products/"+productID+"/media", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUploadMedia(t *testing.T) {
+	router, repo, objects := newMediaRouter(t)
+
+	for position := 0; position < 2; position++ {
+		rec := uploadMedia(router, "product-1", "file", gifFile)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body.String())
+		}
+
+		var resp struct {
+			Data models.ProductMedia `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		media := repo.media[resp.Data.ID]
+		if media == nil || media.Type != models.MediaTypeImage || media.Position != position {
+			t.Fatalf("media = %+v, want an image at position %d", media, position)
+		}
+		// Файл целиком попал в хранилище, URL строки указывает на объект
+		if !bytes.Equal(objects.objects[media.ObjectKey], gifFile) || objects.contentTypes[media.ObjectKey] != "image/gif" ||
+			media.URL != "https://cdn.example.com/"+media.ObjectKey || resp.Data.URL != media.URL {
+			t.Fatalf("object %q = %d bytes of %s, url = %q", media.ObjectKey, len(objects.objects[media.ObjectKey]),
+				objects.contentTypes[media.ObjectKey], media.URL)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		productID string
+		field     string
+		data      []byte
+		want      int
+		wantCode  string
+	}{
+		{name: "text file", productID: "product-1", field: "file", data: []byte("plain text, not an image"), want: http.StatusUnsupportedMediaType, wantCode: "unsupported_media_type"},
+		{name: "too large", productID: "product-1", field: "file", data: append(append([]byte{}, gifFile...), make([]byte, 1024)...), want: http.StatusRequestEntityTooLarge, wantCode: "request_too_large"},
+		{name: "no file field", productID: "product-1", field: "image", data: gifFile, want: http.StatusBadRequest, wantCode: "bad_request"},
+		{name: "unknown product", productID: "product-2", field: "file", data: gifFile, want: http.StatusNotFound, wantCode: "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := uploadMedia(router, tt.productID, tt.field, tt.data)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			var resp render.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Error != tt.wantCode {
+				t.Fatalf("error = %q, err = %v, want %q", resp.Error, err, tt.wantCode)
+			}
+		})
+	}
+
+	if len(repo.media) != 2 {
+		t.Fatalf("media rows = %d, want only the two successful uploads", len(repo.media))
+	}
+}
+
+func TestDeleteMedia(t *testing.T) {
+	router, repo, objects := newMediaRouter(t)
+
+	if rec := uploadMedia(router, "product-1", "file", gifFile); rec.Code != http.StatusCreated {
+		t.Fatalf("upload status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var media *models.ProductMedia
+	for _, m := range repo.media {
+		media = m
+	}
+
+	remove := func(productID, mediaID string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/products/"+productID+"/media/"+mediaID, nil))
+		return rec.Code
+	}
+
+	// Медиафайл другого продукта не удаляется
+	if code := remove("product-2", media.ID); code != http.StatusNotFound {
+		t.Fatalf("status = %d for another product, want 404", code)
+	}
+	if code := remove("product-1", media.ID); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if _, ok := objects.objects[media.ObjectKey]; ok || len(repo.media) != 0 {
+		t.Fatalf("object kept = %v, rows = %d, want both removed", ok, len(repo.media))
+	}
+	if code := remove("product-1", media.ID); code != http.StatusNotFound {
+		t.Fatalf("status = %d for a deleted media, want 404", code)
+	}
+}
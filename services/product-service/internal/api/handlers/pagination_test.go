@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+// paginationService сервис продуктов, запоминающий запрошенную страницу
+type paginationService struct {
+	services.ProductServiceInterface
+	page, pageSize int
+	called         bool
+}
+
+func (s *paginationService) GetProductSchema() *models.ProductSchema {
+	return postgres.ProductSchema()
+}
+
+func (s *paginationService) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
+	s.page, s.pageSize, s.called = page, pageSize, true
+	return nil, 1000, nil
+}
+
+func TestListProductsPagination(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	deepPage := strconv.Itoa(utils.MaxPageOffset/utils.MaxPageSize + 2)
+
+	tests := []struct {
+		name         string
+		query        string
+		wantPage     int
+		wantPageSize int
+	}{
+		{name: "defaults", query: "", wantPage: 1, wantPageSize: utils.DefaultPageSize},
+		{name: "explicit", query: "page=3&page_size=50", wantPage: 3, wantPageSize: 50},
+		{name: "over max page size is clamped", query: "page_size=1000", wantPage: 1, wantPageSize: utils.MaxPageSize},
+		{name: "last allowed offset", query: "page=" + strconv.Itoa(utils.MaxPageOffset/utils.MaxPageSize+1) + "&page_size=100",
+			wantPage: utils.MaxPageOffset/utils.MaxPageSize + 1, wantPageSize: utils.MaxPageSize},
+		{name: "negative page", query: "page=-1"},
+		{name: "zero page", query: "page=0"},
+		{name: "negative page size", query: "page_size=-5"},
+		{name: "zero page size", query: "page_size=0"},
+		{name: "non-numeric page", query: "page=first"},
+		{name: "offset beyond max", query: "page=" + deepPage + "&page_size=100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &paginationService{}
+			handler := NewProductHandler(service, log, 0)
+
+			req := httptest.NewRequest(http.MethodGet, "/products?"+tt.query, nil)
+			req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+			rec := httptest.NewRecorder()
+			handler.ListProducts(rec, req)
+
+			if tt.wantPage == 0 {
+				var resp render.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if rec.Code != http.StatusBadRequest || resp.Code != http.StatusBadRequest || service.called {
+					t.Fatalf("status = %d, response = %+v, called = %v, want 400 without a query", rec.Code, resp, service.called)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			if service.page != tt.wantPage || service.pageSize != tt.wantPageSize {
+				t.Fatalf("service got page %d, size %d, want %d and %d", service.page, service.pageSize, tt.wantPage, tt.wantPageSize)
+			}
+
+			// Фактические значения возвращаются в meta
+			var resp struct {
+				Meta struct {
+					Pagination utils.Pagination `json:"pagination"`
+				} `json:"meta"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Meta.Pagination.Page != tt.wantPage || resp.Meta.Pagination.PageSize != tt.wantPageSize || resp.Meta.Pagination.TotalItems != 1000 {
+				t.Fatalf("meta pagination = %+v, want page %d, size %d", resp.Meta.Pagination, tt.wantPage, tt.wantPageSize)
+			}
+		})
+	}
+}
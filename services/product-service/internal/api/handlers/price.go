@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetPrice возвращает цену продукта
+// @Summary Цена продукта
+// @Description Возвращает текущую цену продукта. С параметром currency цены пересчитываются
+// @Description по текущему курсу, а использованный курс и его время указываются в поле conversion
+// @Tags prices
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param currency query string false "Код валюты ISO 4217 для пересчета (например, USD)"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductPrice} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос или валюта без курса"
+// @Failure 404 {object} errorResponse "Цена не найдена"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/price [get]
+func (h *ProductHandler) GetPrice(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	var price *models.ProductPrice
+	var err error
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		price, err = h.productService.GetPriceInCurrency(r.Context(), productID, tenantID, currency)
+	} else {
+		price, err = h.productService.GetPrice(r.Context(), productID, tenantID)
+	}
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	if price == nil {
+		render.Error(w, r, models.NewError(models.ErrNotFound, "not_found", "Цена продукта не найдена"))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    price,
+	})
+}
+
+// UpdatePrice обновляет цену продукта
+// @Summary Обновление цены продукта
+// @Description Устанавливает цену продукта поставщика и публикует событие product_price_updated
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param X-Supplier-ID header string true "ID поставщика"
+// @Param price body models.ProductPrice true "Цена продукта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductPrice} "Цена обновлена"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/price [put]
+func (h *ProductHandler) UpdatePrice(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
+		return
+	}
+
+	var price models.ProductPrice
+	if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	price.ProductID = productID
+	price.SupplierID = supplierID
+
+	if err := price.Validate(); err != nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", err.Error()))
+		return
+	}
+
+	if err := h.productService.UpdatePrice(r.Context(), &price, tenantID); err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    price,
+	})
+}
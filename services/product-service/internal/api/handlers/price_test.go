@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// priceService сервис продуктов, запоминающий сохраненную цену
+type priceService struct {
+	services.ProductServiceInterface
+	saved *models.ProductPrice
+}
+
+func (s *priceService) UpdatePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error {
+	s.saved = price
+	return nil
+}
+
+func TestUpdatePriceValidation(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "valid", body: `{"base_price":100,"special_price":90,"currency":"RUB"}`, want: http.StatusOK},
+		{name: "special equals base", body: `{"base_price":100,"special_price":100,"currency":"RUB"}`, want: http.StatusOK},
+		{name: "dates in order", body: `{"base_price":100,"currency":"RUB","start_date":"2024-01-01T00:00:00Z","end_date":"2024-02-01T00:00:00Z"}`, want: http.StatusOK},
+		{name: "only start date", body: `{"base_price":100,"currency":"RUB","start_date":"2024-01-01T00:00:00Z"}`, want: http.StatusOK},
+		{name: "zero base", body: `{"base_price":0,"currency":"RUB"}`, want: http.StatusBadRequest},
+		{name: "negative base", body: `{"base_price":-5,"currency":"RUB"}`, want: http.StatusBadRequest},
+		{name: "special above base", body: `{"base_price":100,"special_price":101,"currency":"RUB"}`, want: http.StatusBadRequest},
+		{name: "negative special", body: `{"base_price":100,"special_price":-1,"currency":"RUB"}`, want: http.StatusBadRequest},
+		{name: "no currency", body: `{"base_price":100}`, want: http.StatusBadRequest},
+		{name: "end before start", body: `{"base_price":100,"currency":"RUB","start_date":"2024-02-01T00:00:00Z","end_date":"2024-01-01T00:00:00Z"}`, want: http.StatusBadRequest},
+		{name: "equal dates", body: `{"base_price":100,"currency":"RUB","start_date":"2024-01-01T00:00:00Z","end_date":"2024-01-01T00:00:00Z"}`, want: http.StatusBadRequest},
+		{name: "malformed", body: `{"base_price":`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &priceService{}
+			handler := NewProductHandler(service, log, 0)
+
+			router := chi.NewRouter()
+			router.Put("/products/{id}/price", handler.UpdatePrice)
+
+			req := httptest.NewRequest(http.MethodPut, "/products/product-1/price", strings.NewReader(tt.body))
+			ctx := contextkeys.WithSupplier(contextkeys.WithTenant(req.Context(), "tenant-1"), "supplier-1")
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				var resp render.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Code != http.StatusBadRequest {
+					t.Fatalf("response = %+v, err = %v", resp, err)
+				}
+				if service.saved != nil {
+					t.Fatal("invalid price passed to the service")
+				}
+				return
+			}
+			if service.saved == nil || service.saved.ProductID != "product-1" || service.saved.SupplierID != "supplier-1" {
+				t.Fatalf("saved = %+v, want the price of product-1 from supplier-1", service.saved)
+			}
+		})
+	}
+}
+
+// currencyPriceService сервис продуктов, пересчитывающий цену только в USD
+type currencyPriceService struct {
+	services.ProductServiceInterface
+}
+
+func (s *currencyPriceService) GetPrice(ctx context.Context, productID, tenantID string) (*models.ProductPrice, error) {
+	return &models.ProductPrice{ProductID: productID, BasePrice: 2000, Currency: "RUB"}, nil
+}
+
+func (s *currencyPriceService) GetPriceInCurrency(ctx context.Context, productID, tenantID, currency string) (*models.ProductPrice, error) {
+	if currency != "USD" {
+		return nil, utils.ErrUnsupportedCurrency
+	}
+	return &models.ProductPrice{
+		ProductID:  productID,
+		BasePrice:  22,
+		Currency:   "USD",
+		Conversion: &models.PriceConversion{SourceCurrency: "RUB", Rate: 0.011},
+	}, nil
+}
+
+func TestGetPriceInCurrency(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	handler := NewProductHandler(&currencyPriceService{}, log, 0)
+	router := chi.NewRouter()
+	router.Get("/products/{id}/price", handler.GetPrice)
+
+	get := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("converted", func(t *testing.T) {
+		rec := get(t, "/products/product-1/price?currency=USD")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Data models.ProductPrice `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.Data.BasePrice != 22 || resp.Data.Currency != "USD" || resp.Data.Conversion == nil || resp.Data.Conversion.SourceCurrency != "RUB" {
+			t.Fatalf("price = %+v, want 22 USD converted from RUB", resp.Data)
+		}
+	})
+
+	t.Run("without currency", func(t *testing.T) {
+		rec := get(t, "/products/product-1/price")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"currency":"RUB"`) {
+			t.Fatalf("status = %d, body = %s, want the stored price", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		rec := get(t, "/products/product-1/price?currency=XYZ")
+		var resp render.ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest || resp.Error != "unsupported_currency" {
+			t.Fatalf("status = %d, response = %+v, want 400 unsupported_currency", rec.Code, resp)
+		}
+	})
+}
@@ -2,35 +2,55 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
 	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
 	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
 	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // ProductHandler обработчик запросов для продуктов
 type ProductHandler struct {
 	productService services.ProductServiceInterface
 	logger         interfaces.LoggerPort
+	mediaMaxSize   int64
 }
 
-// NewProductHandler создает новый обработчик продуктов
-func NewProductHandler(productService services.ProductServiceInterface, logger interfaces.LoggerPort) *ProductHandler {
+// NewProductHandler создает новый обработчик продуктов.
+// mediaMaxSize ограничивает размер загружаемого медиафайла в байтах
+func NewProductHandler(productService services.ProductServiceInterface, logger interfaces.LoggerPort, mediaMaxSize int64) *ProductHandler {
 	return &ProductHandler{
 		productService: productService,
 		logger:         logger,
+		mediaMaxSize:   mediaMaxSize,
 	}
 }
 
-// errorResponse представляет структуру ответа с ошибкой
-type errorResponse struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
-	Message string `json:"message,omitempty"`
+// Ошибки обязательных параметров запроса, общие для обработчиков
+var (
+	errTenantRequired    = models.NewError(models.ErrValidation, "bad_request", "ID тенанта не указан")
+	errProductIDRequired = models.NewError(models.ErrValidation, "bad_request", "ID продукта не указан")
+	errSupplierRequired  = models.NewError(models.ErrValidation, "bad_request", "ID поставщика не указан")
+)
+
+// errorResponse тело ответа с ошибкой, которое пишет render.Error
+type errorResponse = render.ErrorResponse
+
+// invalidBody возвращает ошибку разбора тела запроса для render.Error: превышение лимита BodyLimit
+// передается как есть и дает 413, остальные ошибки считаются некорректным запросом с сообщением message
+func invalidBody(err error, message string) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	return models.NewError(models.ErrValidation, "bad_request", message)
 }
 
 // response представляет структуру успешного ответа
@@ -57,6 +77,7 @@ type response struct {
 // @Param id path string true "ID продукта"
 // @Param X-Tenant-ID header string true "ID тенанта"
 // @Param X-Supplier-ID header string true "ID поставщика"
+// @Param include query string false "Дополнительные поля через запятую: primary_image - primary_image_url"
 // @Security BearerAuth
 // @Success 200 {object} response{data=models.Product} "Успешный ответ"
 // @Failure 400 {object} errorResponse "Неверный запрос"
@@ -68,65 +89,76 @@ type response struct {
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "id")
 	if productID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID продукта не указан",
-		})
+		render.Error(w, r, errProductIDRequired)
 		return
 	}
 
-	tenantID, ok := r.Context().Value("tenant_id").(string)
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
 	if !ok || tenantID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID тенанта не указан",
-		})
+		render.Error(w, r, errTenantRequired)
 		return
 	}
 
-	supplierID := r.Header.Get("X-Supplier-ID")
-	if supplierID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID поставщика не указан",
-		})
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
 		return
 	}
 
 	product, err := h.productService.GetProduct(r.Context(), productID, supplierID, tenantID)
 	if err != nil {
-		h.logger.ErrorWithContext(r.Context(), "Ошибка получения продукта",
-			interfaces.LogField{Key: "error", Value: err.Error()})
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, errorResponse{
-			Error:   "internal_error",
-			Code:    http.StatusInternalServerError,
-			Message: "Ошибка получения продукта",
-		})
+		render.Error(w, r, err)
 		return
 	}
+	h.includePrimaryImages(r, []*models.Product{product}, tenantID)
 
-	if product == nil {
-		render.Status(r, http.StatusNotFound)
-		render.JSON(w, r, errorResponse{
-			Error:   "not_found",
-			Code:    http.StatusNotFound,
-			Message: "Продукт не найден",
-		})
+	// Возвращаем продукт
+	w.Header().Set("ETag", productETag(product))
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    product,
+	})
+}
+
+// GetProductDetails обрабатывает запрос на получение агрегата продукта
+// @Summary Детальная информация о продукте
+// @Description Возвращает продукт вместе с ценой, остатками и медиа. Медиа загружается по возможности: при ошибке секция равна null, а причина указана в section_errors
+// @Tags products
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductDetails} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 404 {object} errorResponse "Продукт не найден"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/details [get]
+func (h *ProductHandler) GetProductDetails(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	details, err := h.productService.GetProductDetails(r.Context(), productID, tenantID)
+	if err != nil {
+		render.Error(w, r, err)
 		return
 	}
 
-	// Возвращаем продукт
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, response{
 		Success: true,
-		Data:    product,
+		Data:    details,
 	})
 }
 
@@ -137,14 +169,18 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param X-Tenant-ID header string true "ID тенанта"
-// @Param page query int false "Номер страницы" default(1) minimum(1)
-// @Param page_size query int false "Размер страницы" default(20) minimum(1) maximum(100)
+// @Param page query int false "Номер страницы; смещение (page-1)*page_size не больше 10000" default(1) minimum(1)
+// @Param page_size query int false "Размер страницы, больший 100 уменьшается до 100" default(20) minimum(1) maximum(100)
 // @Param name query string false "Фильтр по имени продукта"
 // @Param description query string false "Фильтр по описанию продукта"
 // @Param supplier_id query string false "Фильтр по ID поставщика"
 // @Param min_price query number false "Минимальная цена"
 // @Param max_price query number false "Максимальная цена"
-// @Param q query string false "Поисковый запрос"
+// @Param q query string false "Полнотекстовый поиск по имени и описанию вместе с остальными фильтрами; без sort_by результаты упорядочены по релевантности"
+// @Param sort_by query string false "Поле сортировки: created_at, updated_at, name, price" default(updated_at)
+// @Param sort_desc query bool false "Сортировка по убыванию" default(true)
+// @Param include query string false "Дополнительные поля через запятую: primary_image - primary_image_url каждого продукта"
+// @Param cursor query string false "Курсор keyset-пагинации (пустой для первой страницы); page, q и сортировка при этом не применяются, курсор следующей страницы возвращается в meta.next_cursor"
 // @Security BearerAuth
 // @Success 200 {object} response{data=[]models.Product,meta=map[string]interface{}} "Успешный ответ"
 // @Failure 400 {object} errorResponse "Неверный запрос"
@@ -155,85 +191,344 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 
 // ListProducts обрабатывает запрос на получение списка продуктов
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
-	tenantID, ok := r.Context().Value("tenant_id").(string)
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
 	if !ok || tenantID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID тенанта не указан",
-		})
+		render.Error(w, r, errTenantRequired)
 		return
 	}
 
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil || page < 1 {
-		page = 1
+	page, pageSize, err := parsePagination(r)
+	if err != nil {
+		render.Error(w, r, err)
+		return
 	}
 
-	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
-	if err != nil || pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	filters := parseSchemaFilters(r, h.productService.GetProductSchema())
+
+	// При наличии параметра cursor используется keyset-пагинация с фиксированной сортировкой
+	if r.URL.Query().Has("cursor") {
+		h.listProductsByCursor(w, r, tenantID, filters, pageSize)
+		return
 	}
 
-	filters := make(map[string]interface{})
+	sort := parseSortOption(r, h.productService.GetProductSchema())
 
-	if name := r.URL.Query().Get("name"); name != "" {
-		filters["name"] = name
+	// Поиск сужает выборку вместе с остальными фильтрами; без явного sort_by результаты идут по релевантности
+	if query := r.URL.Query().Get("q"); query != "" {
+		filters[models.SearchQueryFilter] = query
+		if !r.URL.Query().Has("sort_by") {
+			sort = models.SortOption{Field: models.SortByRelevance, Desc: true}
+		}
 	}
 
-	if description := r.URL.Query().Get("description"); description != "" {
-		filters["description"] = description
+	products, total, err := h.productService.ListProducts(r.Context(), tenantID, filters, sort, page, pageSize)
+	if err != nil {
+		render.Error(w, r, err)
+		return
 	}
+	h.includePrimaryImages(r, products, tenantID)
 
-	if supplierID := r.URL.Query().Get("supplier_id"); supplierID != "" {
-		if id, err := strconv.Atoi(supplierID); err == nil {
-			filters["supplier_id"] = id
-		}
+	pagination := utils.NewPagination(page, pageSize, sort.Field, sort.Desc)
+	pagination.SetTotal(int64(total))
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    products,
+		Meta: map[string]interface{}{
+			"pagination": pagination,
+		},
+	})
+}
+
+// listProductsByCursor отвечает страницей продуктов после курсора с next_cursor в meta
+func (h *ProductHandler) listProductsByCursor(w http.ResponseWriter, r *http.Request, tenantID string, filters map[string]interface{}, pageSize int) {
+	products, nextCursor, err := h.productService.ListProductsAfter(r.Context(), tenantID, filters, r.URL.Query().Get("cursor"), pageSize)
+	if err != nil {
+		render.Error(w, r, err)
+		return
 	}
+	h.includePrimaryImages(r, products, tenantID)
 
-	if minPrice := r.URL.Query().Get("min_price"); minPrice != "" {
-		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
-			filters["min_price"] = price
-		}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    products,
+		Meta: map[string]interface{}{
+			"next_cursor": nextCursor,
+			"page_size":   pageSize,
+		},
+	})
+}
+
+// GetProductSchema возвращает схему фильтров и сортировок списка продуктов
+// @Summary Схема списка продуктов
+// @Description Возвращает допустимые ключи фильтрации, поля сортировки и их типы
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.ProductSchema} "Успешный ответ"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Router /products/schema [get]
+func (h *ProductHandler) GetProductSchema(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    h.productService.GetProductSchema(),
+	})
+}
+
+// CountProducts возвращает число продуктов, удовлетворяющих фильтрам списка, без загрузки страницы
+// @Summary Число продуктов
+// @Description Возвращает число продуктов тенанта с учетом тех же фильтров, что и список продуктов
+// @Tags products
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param name query string false "Фильтр по имени продукта"
+// @Param supplier_id query string false "Фильтр по ID поставщика"
+// @Param min_price query number false "Минимальная цена"
+// @Param max_price query number false "Максимальная цена"
+// @Security BearerAuth
+// @Success 200 {object} response{data=int} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/count [get]
+func (h *ProductHandler) CountProducts(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
 	}
 
-	if maxPrice := r.URL.Query().Get("max_price"); maxPrice != "" {
-		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
-			filters["max_price"] = price
-		}
+	filters := parseSchemaFilters(r, h.productService.GetProductSchema())
+
+	total, err := h.productService.CountProducts(r.Context(), tenantID, filters)
+	if err != nil {
+		render.Error(w, r, err)
+		return
 	}
 
-	if query := r.URL.Query().Get("q"); query != "" {
-		filters["search_query"] = query
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    total,
+	})
+}
+
+// ListSuppliers возвращает поставщиков, от которых у тенанта есть продукты
+// @Summary Поставщики тенанта
+// @Description Возвращает отсортированные ID поставщиков, от которых у тенанта есть хотя бы один продукт
+// @Tags products
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=[]string} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /suppliers [get]
+func (h *ProductHandler) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
 	}
 
-	products, total, err := h.productService.ListProducts(r.Context(), tenantID, filters, page, pageSize)
+	suppliers, err := h.productService.ListSuppliers(r.Context(), tenantID)
 	if err != nil {
-		h.logger.ErrorWithContext(r.Context(), "Ошибка получения списка продуктов",
-			interfaces.LogField{Key: "error", Value: err.Error()})
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, errorResponse{
-			Error:   "internal_error",
-			Code:    http.StatusInternalServerError,
-			Message: "Ошибка получения списка продуктов",
-		})
+		render.Error(w, r, err)
 		return
 	}
 
-	pagination := utils.NewPagination(page, pageSize, "created_at", true)
-	pagination.SetTotal(int64(total))
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    suppliers,
+	})
+}
+
+// AggregateFacets возвращает число продуктов по значениям атрибутов для фильтрующих панелей каталога
+// @Summary Фасеты продуктов
+// @Description Для каждого атрибута из facet возвращает отображение значение -> число продуктов с учетом фильтров списка
+// @Tags products
+// @Produce json
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param facet query []string true "Атрибуты base_data (повтор параметра или через запятую), не более 20" collectionFormat(multi)
+// @Param supplier_id query string false "Фильтр по ID поставщика"
+// @Security BearerAuth
+// @Success 200 {object} response{data=map[string]map[string]int} "Успешный ответ"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/facets [get]
+func (h *ProductHandler) AggregateFacets(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	var facetKeys []string
+	for _, value := range r.URL.Query()["facet"] {
+		facetKeys = append(facetKeys, strings.Split(value, ",")...)
+	}
+
+	filters := parseSchemaFilters(r, h.productService.GetProductSchema())
+
+	facets, err := h.productService.AggregateFacets(r.Context(), tenantID, filters, facetKeys)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
 
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, response{
 		Success: true,
-		Data:    products,
-		Meta: map[string]interface{}{
-			"pagination": pagination,
-		},
+		Data:    facets,
 	})
 }
 
+// parseSchemaFilters извлекает из query-параметров фильтры, описанные в схеме.
+// Значения, не соответствующие типу поля, игнорируются.
+func parseSchemaFilters(r *http.Request, schema *models.ProductSchema) map[string]interface{} {
+	filters := make(map[string]interface{})
+
+	for _, field := range schema.Filters {
+		raw := r.URL.Query().Get(field.Name)
+		if raw == "" {
+			continue
+		}
+
+		switch field.Type {
+		case models.FieldTypeNumber:
+			if value, err := strconv.ParseFloat(raw, 64); err == nil {
+				filters[field.Name] = value
+			}
+		default:
+			filters[field.Name] = raw
+		}
+	}
+
+	// Фильтры по атрибутам: attr_color=red; повторение параметра дает совпадение с любым из значений
+	for key, values := range r.URL.Query() {
+		if !strings.HasPrefix(key, schema.AttributePrefix) || len(key) == len(schema.AttributePrefix) {
+			continue
+		}
+		switch len(values) {
+		case 0:
+		case 1:
+			filters[key] = values[0]
+		default:
+			filters[key] = values
+		}
+	}
+
+	return filters
+}
+
+// parseSortOption читает sort_by и sort_desc из запроса.
+// Поле вне схемы заменяется сортировкой по умолчанию, направление по умолчанию - по убыванию.
+func parseSortOption(r *http.Request, schema *models.ProductSchema) models.SortOption {
+	sort := models.SortOption{Field: schema.DefaultSort, Desc: true}
+
+	sortBy := r.URL.Query().Get("sort_by")
+	for _, field := range schema.Sortable {
+		if field.Name == sortBy {
+			sort.Field = sortBy
+			if desc, err := strconv.ParseBool(r.URL.Query().Get("sort_desc")); err == nil {
+				sort.Desc = desc
+			}
+			break
+		}
+	}
+
+	return sort
+}
+
+// includePrimaryImage значение параметра include, добавляющее в ответ primary_image_url продуктов
+const includePrimaryImage = "primary_image"
+
+// wantsInclude сообщает, запрошено ли дополнительное поле в параметре include (через запятую или повтором)
+func wantsInclude(r *http.Request, name string) bool {
+	for _, value := range r.URL.Query()["include"] {
+		for _, item := range strings.Split(value, ",") {
+			if strings.TrimSpace(item) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// includePrimaryImages заполняет primary_image_url продуктов, если он запрошен. Ошибка загрузки медиа
+// не прерывает ответ: продукты возвращаются без поля, как и продукты без изображений
+func (h *ProductHandler) includePrimaryImages(r *http.Request, products []*models.Product, tenantID string) {
+	if !wantsInclude(r, includePrimaryImage) {
+		return
+	}
+	if err := h.productService.ResolvePrimaryImages(r.Context(), products, tenantID); err != nil {
+		h.logger.WarnWithContext(r.Context(), "Не удалось загрузить основные изображения продуктов",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+	}
+}
+
+// parsePagination извлекает page и page_size из запроса. Отсутствующие параметры заменяются на 1 и
+// utils.DefaultPageSize, page_size больше utils.MaxPageSize уменьшается до него, а нечисловые
+// или неположительные значения и смещение больше utils.MaxPageOffset дают utils.ErrInvalidPagination.
+// Фактические значения возвращаются клиенту в meta.pagination
+func parsePagination(r *http.Request) (int, int, error) {
+	page, pageSize := 1, utils.DefaultPageSize
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, utils.ErrInvalidPagination
+		}
+		page = parsed
+	}
+
+	if value := r.URL.Query().Get("page_size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, utils.ErrInvalidPagination
+		}
+		pageSize = min(parsed, utils.MaxPageSize)
+	}
+
+	if page-1 > utils.MaxPageOffset/pageSize {
+		return 0, 0, utils.ErrInvalidPagination
+	}
+
+	return page, pageSize, nil
+}
+
+// productETag возвращает ETag продукта, построенный по его версии
+func productETag(product *models.Product) string {
+	return fmt.Sprintf("\"%d\"", product.Version)
+}
+
+// parseIfMatchVersion извлекает ожидаемую версию продукта из заголовка If-Match.
+// Возвращает 0, если заголовок не задан.
+func parseIfMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), "\"")
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match header: %s", r.Header.Get("If-Match"))
+	}
+	return version, nil
+}
+
 // CreateProduct обрабатывает запрос на создание продукта
 // @Summary Создание продукта
 // @Description Создает новый продукт в системе
@@ -248,107 +543,143 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} errorResponse "Неверный запрос"
 // @Failure 401 {object} errorResponse "Не авторизован"
 // @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 409 {object} errorResponse "Продукт с таким ID уже существует"
+// @Failure 422 {object} errorResponse "Ошибки валидации base_data"
 // @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
 // @Router /products [post]
 // CreateProduct обрабатывает запрос на создание продукта
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
-	tenantID, ok := r.Context().Value("tenant_id").(string)
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
 	if !ok || tenantID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID тенанта не указан",
-		})
+		render.Error(w, r, errTenantRequired)
 		return
 	}
 
-	supplierID, ok := r.Context().Value("supplier_id").(string)
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
 	if !ok || supplierID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID поставщика не указан",
-		})
+		render.Error(w, r, errSupplierRequired)
 		return
 	}
 
 	var product models.Product
 	err := json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "Некорректный формат данных",
-		})
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	product.TenantID = tenantID
+	product.SupplierID = supplierID
+
+	if err := models.ValidateBaseData(product.BaseData); err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	createdProduct, err := h.productService.CreateProduct(r.Context(), &product)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	// Возвращаем созданный продукт
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    createdProduct,
+	})
+}
+
+// UpsertProductBySKU обрабатывает запрос на создание или обновление продукта по SKU
+// @Summary Создание или обновление продукта по SKU
+// @Description Создает продукт поставщика, если SKU еще не встречался, иначе обновляет существующий
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param sku path string true "SKU продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param X-Supplier-ID header string true "ID поставщика"
+// @Param product body models.Product true "Данные продукта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.Product,meta=map[string]interface{}} "Продукт обновлен"
+// @Success 201 {object} response{data=models.Product,meta=map[string]interface{}} "Продукт создан"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/by-sku/{sku} [put]
+func (h *ProductHandler) UpsertProductBySKU(w http.ResponseWriter, r *http.Request) {
+	sku := chi.URLParam(r, "sku")
+	if sku == "" {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "SKU продукта не указан"))
 		return
 	}
 
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
+		return
+	}
+
+	var product models.Product
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	// ID определяется по SKU, переданный в теле игнорируется
+	product.ID = ""
 	product.TenantID = tenantID
 	product.SupplierID = supplierID
 
 	var baseData map[string]interface{}
 	if err := json.Unmarshal(product.BaseData, &baseData); err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusBadRequest,
-			Message: "Некорректный формат базовых данных продукта",
-		})
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", "Некорректный формат базовых данных продукта"))
 		return
 	}
 
 	if name, ok := baseData["name"].(string); !ok || name == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusBadRequest,
-			Message: "Название продукта не может быть пустым",
-		})
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", "Название продукта не может быть пустым"))
 		return
 	}
 
-	if price, ok := baseData["price"].(float64); !ok || price <= 0 {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusBadRequest,
-			Message: "Цена продукта должна быть больше нуля",
-		})
+	result, created, err := h.productService.UpsertProductBySKU(r.Context(), &product, sku)
+	if err != nil {
+		render.Error(w, r, err)
 		return
 	}
 
-	createdProduct, err := h.productService.CreateProduct(r.Context(), &product)
-	if err != nil {
-		h.logger.ErrorWithContext(r.Context(), "Ошибка создания продукта",
-			interfaces.LogField{Key: "error", Value: err.Error()})
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, errorResponse{
-			Error:   "internal_error",
-			Code:    http.StatusInternalServerError,
-			Message: "Ошибка создания продукта",
-		})
-		return
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
 	}
 
-	// Возвращаем созданный продукт
-	render.Status(r, http.StatusCreated)
+	render.Status(r, status)
 	render.JSON(w, r, response{
 		Success: true,
-		Data:    createdProduct,
+		Data:    result,
+		Meta: map[string]interface{}{
+			"created": created,
+		},
 	})
 }
 
 // UpdateProduct обрабатывает запрос на обновление продукта
 // @Summary Обновление продукта
-// @Description Обновляет существующий продукт по его ID
+// @Description Обновляет существующий продукт по его ID. Версия обязательна (поле version или заголовок If-Match):
+// @Description без нее возвращается 428, при несовпадении с текущей версией - 409
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "ID продукта"
 // @Param X-Tenant-ID header string true "ID тенанта"
+// @Param If-Match header string false "ETag продукта, полученный при чтении"
 // @Param product body models.Product true "Данные продукта"
 // @Security BearerAuth
 // @Success 200 {object} response{data=models.Product} "Продукт обновлен"
@@ -356,90 +687,68 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 // @Failure 401 {object} errorResponse "Не авторизован"
 // @Failure 403 {object} errorResponse "Запрещено"
 // @Failure 404 {object} errorResponse "Продукт не найден"
+// @Failure 409 {object} errorResponse "Продукт изменен другим запросом"
+// @Failure 422 {object} errorResponse "Ошибки валидации base_data"
+// @Failure 428 {object} errorResponse "Не указана версия продукта"
 // @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
 // @Router /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "id")
 	if productID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID продукта не указан",
-		})
+		render.Error(w, r, errProductIDRequired)
 		return
 	}
 
-	tenantID, ok := r.Context().Value("tenant_id").(string)
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
 	if !ok || tenantID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID тенанта не указан",
-		})
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
 		return
 	}
 
 	var product models.Product
 	err := json.NewDecoder(r.Body).Decode(&product)
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "Некорректный формат данных",
-		})
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
 		return
 	}
 
+	// Поставщик берется из контекста запроса, supplier_id из тела игнорируется
 	product.ID = productID
 	product.TenantID = tenantID
+	product.SupplierID = supplierID
 
-	var baseData map[string]interface{}
-	if err := json.Unmarshal(product.BaseData, &baseData); err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusBadRequest,
-			Message: "Некорректный формат базовых данных продукта",
-		})
+	// If-Match имеет приоритет над версией из тела запроса
+	ifMatchVersion, err := parseIfMatchVersion(r)
+	if err != nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Некорректный заголовок If-Match"))
 		return
 	}
-
-	if name, ok := baseData["name"].(string); !ok || name == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusBadRequest,
-			Message: "Название продукта не может быть пустым",
-		})
+	if ifMatchVersion > 0 {
+		product.Version = ifMatchVersion
+	}
+	if product.Version <= 0 {
+		render.Error(w, r, utils.ErrVersionRequired)
 		return
 	}
 
-	if price, ok := baseData["price"].(float64); !ok || price <= 0 {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "validation_error",
-			Code:    http.StatusBadRequest,
-			Message: "Цена продукта должна быть больше нуля",
-		})
+	if err := models.ValidateBaseData(product.BaseData); err != nil {
+		render.Error(w, r, err)
 		return
 	}
 
 	updatedProduct, err := h.productService.UpdateProduct(r.Context(), &product)
 	if err != nil {
-		h.logger.ErrorWithContext(r.Context(), "Ошибка обновления продукта",
-			interfaces.LogField{Key: "error", Value: err.Error()})
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, errorResponse{
-			Error:   "internal_error",
-			Code:    http.StatusInternalServerError,
-			Message: "Ошибка обновления продукта",
-		})
+		render.Error(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", productETag(updatedProduct))
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, response{
 		Success: true,
@@ -447,6 +756,72 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// PatchProduct обрабатывает запрос на частичное обновление продукта
+// @Summary Частичное обновление продукта
+// @Description Сливает тело запроса с base_data продукта по правилам JSON Merge Patch (RFC 7386): указанные ключи
+// @Description заменяются, вложенные объекты сливаются, null удаляет ключ, остальные поля сохраняются.
+// @Description Если передан заголовок If-Match, продукт обновляется только при совпадении версии, иначе возвращается 409
+// @Tags products
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Param If-Match header string false "ETag продукта, полученный при чтении"
+// @Param patch body object true "Изменения base_data"
+// @Security BearerAuth
+// @Success 200 {object} response{data=models.Product} "Продукт обновлен"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 404 {object} errorResponse "Продукт не найден"
+// @Failure 409 {object} errorResponse "Продукт изменен другим запросом"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	var patch json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		render.Error(w, r, invalidBody(err, "Некорректный формат данных"))
+		return
+	}
+
+	expectedVersion, err := parseIfMatchVersion(r)
+	if err != nil {
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Некорректный заголовок If-Match"))
+		return
+	}
+
+	product, err := h.productService.PatchProduct(r.Context(), productID, tenantID, patch, expectedVersion)
+	// Ошибку применения patch клиент получает с причиной, которую сервис присоединил к ErrInvalidMergePatch
+	if errors.Is(err, models.ErrInvalidMergePatch) {
+		render.Error(w, r, models.NewError(models.ErrValidation, "validation_error", err.Error()))
+		return
+	}
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", productETag(product))
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    product,
+	})
+}
+
 // DeleteProduct обрабатывает запрос на удаление продукта
 // @Summary Удаление продукта
 // @Description Удаляет продукт по его ID
@@ -467,47 +842,25 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "id")
 	if productID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID продукта не указан",
-		})
+		render.Error(w, r, errProductIDRequired)
 		return
 	}
 
-	tenantID, ok := r.Context().Value("tenant_id").(string)
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
 	if !ok || tenantID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID тенанта не указан",
-		})
+		render.Error(w, r, errTenantRequired)
 		return
 	}
 
-	supplierID := r.Header.Get("X-Supplier-ID")
-	if supplierID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID поставщика не указан",
-		})
+	supplierID, ok := contextkeys.SupplierFromContext(r.Context())
+	if !ok || supplierID == "" {
+		render.Error(w, r, errSupplierRequired)
 		return
 	}
 
 	err := h.productService.DeleteProduct(r.Context(), productID, supplierID, tenantID)
 	if err != nil {
-		h.logger.ErrorWithContext(r.Context(), "Ошибка удаления продукта",
-			interfaces.LogField{Key: "error", Value: err.Error()})
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, errorResponse{
-			Error:   "internal_error",
-			Code:    http.StatusInternalServerError,
-			Message: "Ошибка удаления продукта",
-		})
+		render.Error(w, r, err)
 		return
 	}
 
@@ -531,68 +884,43 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 // @Param X-Tenant-ID header string true "ID тенанта"
 // @Param marketplace_id query int true "ID маркетплейса"
 // @Security BearerAuth
-// @Success 200 {object} response{data=map[string]interface{}} "Синхронизация запущена"
+// @Success 200 {object} response{data=map[string]interface{}} "Синхронизация поставлена в очередь"
 // @Failure 400 {object} errorResponse "Неверный запрос"
 // @Failure 401 {object} errorResponse "Не авторизован"
 // @Failure 403 {object} errorResponse "Запрещено"
 // @Failure 404 {object} errorResponse "Продукт не найден"
+// @Failure 422 {object} response{data=models.MissingFieldsError} "Не заполнены обязательные поля маркетплейса"
 // @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
 // @Router /products/{id}/sync [post]
 func (h *ProductHandler) SyncProductToMarketplace(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "id")
 	if productID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID продукта не указан",
-		})
+		render.Error(w, r, errProductIDRequired)
 		return
 	}
 
-	tenantID, ok := r.Context().Value("tenant_id").(string)
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
 	if !ok || tenantID == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID тенанта не указан",
-		})
+		render.Error(w, r, errTenantRequired)
 		return
 	}
 
 	marketplaceIDStr := r.URL.Query().Get("marketplace_id")
 	if marketplaceIDStr == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "ID маркетплейса не указан",
-		})
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "ID маркетплейса не указан"))
 		return
 	}
 
 	marketplaceID, err := strconv.Atoi(marketplaceIDStr)
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, errorResponse{
-			Error:   "bad_request",
-			Code:    http.StatusBadRequest,
-			Message: "Некорректный ID маркетплейса",
-		})
+		render.Error(w, r, models.NewError(models.ErrValidation, "bad_request", "Некорректный ID маркетплейса"))
 		return
 	}
 
+	// Отсутствующие обязательные поля маркетплейса (*models.MissingFieldsError) возвращаются как 422 с details
 	err = h.productService.SyncProductToMarketplace(r.Context(), productID, marketplaceID, tenantID)
 	if err != nil {
-		h.logger.ErrorWithContext(r.Context(), "Ошибка синхронизации продукта с маркетплейсом",
-			interfaces.LogField{Key: "error", Value: err.Error()})
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, errorResponse{
-			Error:   "internal_error",
-			Code:    http.StatusInternalServerError,
-			Message: "Ошибка синхронизации продукта с маркетплейсом",
-		})
+		render.Error(w, r, err)
 		return
 	}
 
@@ -602,7 +930,48 @@ func (h *ProductHandler) SyncProductToMarketplace(w http.ResponseWriter, r *http
 		Data: map[string]interface{}{
 			"product_id":     productID,
 			"marketplace_id": marketplaceID,
-			"synced":         true,
+			"status":         models.MarketplaceStatusPending,
 		},
 	})
 }
+
+// GetMarketplaceStatuses возвращает статусы синхронизации продукта с маркетплейсами
+// @Summary Статусы синхронизации с маркетплейсами
+// @Description Возвращает внешний ID и статус продукта в каждом маркетплейсе, куда он отправлялся
+// @Tags products
+// @Produce json
+// @Param id path string true "ID продукта"
+// @Param X-Tenant-ID header string true "ID тенанта"
+// @Security BearerAuth
+// @Success 200 {object} response{data=[]object} "Статусы синхронизации"
+// @Failure 400 {object} errorResponse "Неверный запрос"
+// @Failure 401 {object} errorResponse "Не авторизован"
+// @Failure 403 {object} errorResponse "Запрещено"
+// @Failure 404 {object} errorResponse "Продукт не найден"
+// @Failure 500 {object} errorResponse "Внутренняя ошибка сервера"
+// @Router /products/{id}/marketplaces [get]
+func (h *ProductHandler) GetMarketplaceStatuses(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "id")
+	if productID == "" {
+		render.Error(w, r, errProductIDRequired)
+		return
+	}
+
+	tenantID, ok := contextkeys.TenantFromContext(r.Context())
+	if !ok || tenantID == "" {
+		render.Error(w, r, errTenantRequired)
+		return
+	}
+
+	statuses, err := h.productService.GetMarketplaceStatuses(r.Context(), productID, tenantID)
+	if err != nil {
+		render.Error(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response{
+		Success: true,
+		Data:    statuses,
+	})
+}
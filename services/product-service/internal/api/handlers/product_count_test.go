@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+)
+
+// countService сервис продуктов, запоминающий фильтры подсчета и списка
+type countService struct {
+	services.ProductServiceInterface
+	countFilters map[string]interface{}
+	listFilters  map[string]interface{}
+}
+
+func (s *countService) GetProductSchema() *models.ProductSchema {
+	return postgres.ProductSchema()
+}
+
+func (s *countService) CountProducts(ctx context.Context, tenantID string, filters map[string]interface{}) (int, error) {
+	s.countFilters = filters
+	return 42, nil
+}
+
+func (s *countService) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
+	s.listFilters = filters
+	return nil, 42, nil
+}
+
+func TestCountProducts(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	service := &countService{}
+	handler := NewProductHandler(service, log, 0)
+	const query = "?supplier_id=supplier-1&name=apple&min_price=10&max_price=abc&attr_color=red&attr_color=blue"
+
+	serve := func(handle http.HandlerFunc, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+
+	rec := serve(handler.CountProducts, "/products/count"+query)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data int `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Data != 42 {
+		t.Fatalf("data = %d, err = %v, want 42", resp.Data, err)
+	}
+
+	// Подсчет учитывает те же фильтры, что и список
+	if rec := serve(handler.ListProducts, "/products"+query); rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d: %s", rec.Code, rec.Body.String())
+	}
+	want := map[string]interface{}{
+		"supplier_id": "supplier-1",
+		"name":        "apple",
+		"min_price":   float64(10),
+		"attr_color":  []string{"red", "blue"},
+	}
+	if !reflect.DeepEqual(service.countFilters, want) {
+		t.Fatalf("count filters = %v, want %v", service.countFilters, want)
+	}
+	if !reflect.DeepEqual(service.listFilters, service.countFilters) {
+		t.Fatalf("list filters = %v, count filters = %v, want the same", service.listFilters, service.countFilters)
+	}
+}
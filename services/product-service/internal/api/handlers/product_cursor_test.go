@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// cursorService сервис продуктов, отдающий страницы по курсору и запоминающий запрос
+type cursorService struct {
+	services.ProductServiceInterface
+	cursor string
+	limit  int
+}
+
+func (s *cursorService) GetProductSchema() *models.ProductSchema {
+	return postgres.ProductSchema()
+}
+
+func (s *cursorService) ListProductsAfter(ctx context.Context, tenantID string, filters map[string]interface{}, cursor string, limit int) ([]*models.Product, string, error) {
+	if _, err := utils.DecodeCursor(cursor); err != nil {
+		return nil, "", err
+	}
+	s.cursor, s.limit = cursor, limit
+	return []*models.Product{{ID: "product-1"}, {ID: "product-2"}}, "next-page", nil
+}
+
+func TestListProductsByCursor(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       int
+		wantCursor string
+	}{
+		// Пустой курсор означает первую страницу, page при этом не учитывается
+		{name: "first page", query: "cursor=&page=3&page_size=2", want: http.StatusOK},
+		{name: "next page", query: "cursor=" + utils.EncodeCursor(utils.Cursor{ID: "product-2"}) + "&page_size=2", want: http.StatusOK,
+			wantCursor: utils.EncodeCursor(utils.Cursor{ID: "product-2"})},
+		{name: "invalid cursor", query: "cursor=garbage", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &cursorService{}
+			handler := NewProductHandler(service, log, 0)
+
+			router := chi.NewRouter()
+			router.Get("/products", handler.ListProducts)
+
+			req := httptest.NewRequest(http.MethodGet, "/products?"+tt.query, nil)
+			req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data []*models.Product `json:"data"`
+				Meta struct {
+					NextCursor string `json:"next_cursor"`
+					PageSize   int    `json:"page_size"`
+				} `json:"meta"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if service.cursor != tt.wantCursor || service.limit != 2 {
+				t.Fatalf("service got cursor %q, limit %d, want %q and 2", service.cursor, service.limit, tt.wantCursor)
+			}
+			if len(resp.Data) != 2 || resp.Meta.NextCursor != "next-page" || resp.Meta.PageSize != 2 {
+				t.Fatalf("data = %d, meta = %+v, want 2 products and next_cursor", len(resp.Data), resp.Meta)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// failingService сервис продуктов, возвращающий заданную ошибку
+type failingService struct {
+	services.ProductServiceInterface
+	err error
+}
+
+func (s *failingService) GetProduct(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error) {
+	return nil, s.err
+}
+
+func TestProductHandlerMapsServiceErrors(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		want     int
+		wantCode string
+	}{
+		{name: "not found", err: fmt.Errorf("failed to get product: %w", utils.ErrProductNotFound), want: http.StatusNotFound, wantCode: "not_found"},
+		{name: "conflict", err: utils.ErrVersionConflict, want: http.StatusConflict, wantCode: "version_conflict"},
+		{name: "precondition", err: utils.ErrVersionRequired, want: http.StatusPreconditionRequired, wantCode: "version_required"},
+		{name: "validation", err: utils.ErrInvalidProductId, want: http.StatusBadRequest, wantCode: "bad_request"},
+		{name: "unknown", err: errors.New("connection refused"), want: http.StatusInternalServerError, wantCode: "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProductHandler(&failingService{err: tt.err}, log, 0)
+
+			router := chi.NewRouter()
+			router.Get("/products/{id}", handler.GetProduct)
+
+			req := httptest.NewRequest(http.MethodGet, "/products/product-1", nil)
+			ctx := contextkeys.WithSupplier(contextkeys.WithTenant(req.Context(), "tenant-1"), "supplier-1")
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			var resp render.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error != tt.wantCode || resp.Code != tt.want {
+				t.Fatalf("response = %+v, want %q with code %d", resp, tt.wantCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+)
+
+// facetsService сервис продуктов, запоминающий запрошенные фасеты и фильтры
+type facetsService struct {
+	services.ProductServiceInterface
+	keys    []string
+	filters map[string]interface{}
+}
+
+func (s *facetsService) GetProductSchema() *models.ProductSchema {
+	return postgres.ProductSchema()
+}
+
+func (s *facetsService) AggregateFacets(ctx context.Context, tenantID string, filters map[string]interface{}, facetKeys []string) (map[string]map[string]int, error) {
+	if len(facetKeys) == 0 {
+		return nil, services.ErrInvalidFacets
+	}
+	s.keys, s.filters = facetKeys, filters
+	return map[string]map[string]int{"color": {"red": 2, "blue": 1}}, nil
+}
+
+func TestAggregateFacets(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	request := func(service *facetsService, query string) *httptest.ResponseRecorder {
+		handler := NewProductHandler(service, log, 0)
+		req := httptest.NewRequest(http.MethodGet, "/products/facets?"+query, nil)
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+		rec := httptest.NewRecorder()
+		handler.AggregateFacets(rec, req)
+		return rec
+	}
+
+	t.Run("keys and filters", func(t *testing.T) {
+		service := &facetsService{}
+		rec := request(service, "facet=color,size&facet=brand&attr_brand=Nike&min_price=10&unknown=1")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		if !reflect.DeepEqual(service.keys, []string{"color", "size", "brand"}) {
+			t.Fatalf("keys = %q, want comma-separated and repeated facets", service.keys)
+		}
+		want := map[string]interface{}{"attr_brand": "Nike", "min_price": float64(10)}
+		if !reflect.DeepEqual(service.filters, want) {
+			t.Fatalf("filters = %v, want %v", service.filters, want)
+		}
+
+		var resp struct {
+			Data map[string]map[string]int `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Data["color"]["red"] != 2 {
+			t.Fatalf("data = %v, err = %v", resp.Data, err)
+		}
+	})
+
+	t.Run("no facets", func(t *testing.T) {
+		rec := request(&facetsService{}, "attr_brand=Nike")
+		var resp render.ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest || resp.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, response = %+v, want 400", rec.Code, resp)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// primaryImageService сервис продуктов, у первого продукта которого есть основное изображение
+type primaryImageService struct {
+	services.ProductServiceInterface
+	resolves int
+	err      error
+}
+
+func (s *primaryImageService) GetProductSchema() *models.ProductSchema {
+	return postgres.ProductSchema()
+}
+
+func (s *primaryImageService) GetProduct(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error) {
+	return &models.Product{ID: productID, SupplierID: supplierID, TenantID: tenantID, Version: 1}, nil
+}
+
+func (s *primaryImageService) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
+	return []*models.Product{{ID: "product-1"}, {ID: "product-2"}}, 2, nil
+}
+
+func (s *primaryImageService) ResolvePrimaryImages(ctx context.Context, products []*models.Product, tenantID string) error {
+	s.resolves++
+	if s.err != nil {
+		return s.err
+	}
+	products[0].PrimaryImageURL = "https://cdn.example.com/front.jpg"
+	return nil
+}
+
+func TestPrimaryImageInclude(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		query string
+		err   error
+		want  bool
+	}{
+		{name: "list requested", path: "/products", query: "include=primary_image", want: true},
+		{name: "list with other includes", path: "/products", query: "include=media,%20primary_image", want: true},
+		{name: "list not requested", path: "/products"},
+		{name: "list unknown include", path: "/products", query: "include=primary"},
+		{name: "get requested", path: "/products/product-1", query: "include=primary_image", want: true},
+		{name: "get not requested", path: "/products/product-1"},
+		// Ошибка загрузки медиа не прерывает ответ
+		{name: "media unavailable", path: "/products", query: "include=primary_image", err: errors.New("timeout")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &primaryImageService{err: tt.err}
+			handler := NewProductHandler(service, log, 0)
+			router := chi.NewRouter()
+			router.Get("/products", handler.ListProducts)
+			router.Get("/products/{id}", handler.GetProduct)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path+"?"+tt.query, nil)
+			ctx := contextkeys.WithTenant(req.Context(), "tenant-1")
+			req = req.WithContext(contextkeys.WithSupplier(ctx, "supplier-1"))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var resp struct {
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			var products []map[string]interface{}
+			if tt.path == "/products" {
+				err = json.Unmarshal(resp.Data, &products)
+			} else {
+				var product map[string]interface{}
+				err = json.Unmarshal(resp.Data, &product)
+				products = append(products, product)
+			}
+			if err != nil {
+				t.Fatalf("decode data: %v", err)
+			}
+
+			requested := tt.want || tt.err != nil
+			if (service.resolves == 1) != requested {
+				t.Fatalf("media resolved %d times, want it only when requested", service.resolves)
+			}
+			url, ok := products[0]["primary_image_url"]
+			if ok != tt.want || (tt.want && url != "https://cdn.example.com/front.jpg") {
+				t.Fatalf("primary_image_url = %v, present = %v, want present = %v", url, ok, tt.want)
+			}
+			// Продукт без изображения возвращается без поля
+			if len(products) > 1 {
+				if _, ok := products[1]["primary_image_url"]; ok {
+					t.Fatal("primary_image_url returned for a product without images")
+				}
+			}
+		})
+	}
+}
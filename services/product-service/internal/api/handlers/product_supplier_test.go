@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/middleware"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// supplierService сервис продуктов, запоминающий переданного поставщика
+type supplierService struct {
+	services.ProductServiceInterface
+	supplierID string
+}
+
+func (s *supplierService) GetProduct(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error) {
+	s.supplierID = supplierID
+	return &models.Product{ID: productID, SupplierID: supplierID, TenantID: tenantID, Version: 1}, nil
+}
+
+func (s *supplierService) DeleteProduct(ctx context.Context, productID, supplierID, tenantID string) error {
+	s.supplierID = supplierID
+	return nil
+}
+
+func TestProductHandlersReadSupplierFromContext(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		middleware bool
+		want       int
+	}{
+		{name: "get", method: http.MethodGet, middleware: true, want: http.StatusOK},
+		{name: "delete", method: http.MethodDelete, middleware: true, want: http.StatusOK},
+		// Без middleware заголовок не попадает в контекст, и обработчик его не читает
+		{name: "get header only", method: http.MethodGet, want: http.StatusBadRequest},
+		{name: "delete header only", method: http.MethodDelete, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &supplierService{}
+			handler := NewProductHandler(service, log, 0)
+
+			router := chi.NewRouter()
+			if tt.middleware {
+				router.Use(middleware.Supplier)
+			}
+			router.Get("/products/{id}", handler.GetProduct)
+			router.Delete("/products/{id}", handler.DeleteProduct)
+
+			req := httptest.NewRequest(tt.method, "/products/product-1", nil)
+			req.Header.Set("X-Supplier-ID", "supplier-1")
+			req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusOK && service.supplierID != "supplier-1" {
+				t.Fatalf("service got supplier %q, want supplier-1", service.supplierID)
+			}
+		})
+	}
+}
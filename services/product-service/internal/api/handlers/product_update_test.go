@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// updateService сервис продуктов, принимающий только обновления с версией 3
+type updateService struct {
+	services.ProductServiceInterface
+	updated *models.Product
+}
+
+func (s *updateService) UpdateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	if product.Version != 3 {
+		return nil, utils.ErrVersionConflict
+	}
+	s.updated = product
+	updated := *product
+	updated.Version++
+	return &updated, nil
+}
+
+func TestUpdateProductVersion(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	const body = `{"supplier_id":"supplier-1","base_data":{"name":"Apple juice","price":100}`
+
+	tests := []struct {
+		name       string
+		body       string
+		ifMatch    string
+		noSupplier bool
+		want       int
+		wantCode   string
+	}{
+		{name: "no supplier", body: body + `,"version":3}`, noSupplier: true, want: http.StatusBadRequest, wantCode: "bad_request"},
+		{name: "no version", body: body + `}`, want: http.StatusPreconditionRequired, wantCode: "version_required"},
+		{name: "version in body", body: body + `,"version":3}`, want: http.StatusOK},
+		{name: "version in If-Match", body: body + `}`, ifMatch: `"3"`, want: http.StatusOK},
+		{name: "If-Match overrides body", body: body + `,"version":2}`, ifMatch: `W/"3"`, want: http.StatusOK},
+		{name: "stale version", body: body + `,"version":2}`, want: http.StatusConflict, wantCode: "version_conflict"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &updateService{}
+			handler := NewProductHandler(service, log, 0)
+
+			router := chi.NewRouter()
+			router.Put("/products/{id}", handler.UpdateProduct)
+
+			req := httptest.NewRequest(http.MethodPut, "/products/product-1", strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			ctx := contextkeys.WithTenant(req.Context(), "tenant-1")
+			if !tt.noSupplier {
+				ctx = contextkeys.WithSupplier(ctx, "supplier-2")
+			}
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				if rec.Header().Get("ETag") != `"4"` {
+					t.Fatalf("ETag = %q, want the new version", rec.Header().Get("ETag"))
+				}
+				// supplier_id из тела не переопределяет поставщика из контекста
+				if service.updated.SupplierID != "supplier-2" {
+					t.Fatalf("supplier = %q, want the supplier from context", service.updated.SupplierID)
+				}
+				return
+			}
+
+			var resp render.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error != tt.wantCode {
+				t.Fatalf("error = %q, want %q", resp.Error, tt.wantCode)
+			}
+			if service.updated != nil {
+				t.Fatal("service called for a rejected request")
+			}
+		})
+	}
+}
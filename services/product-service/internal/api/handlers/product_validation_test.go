@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// savingService сервис продуктов, запоминающий число сохранений
+type savingService struct {
+	services.ProductServiceInterface
+	saves int
+}
+
+func (s *savingService) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	s.saves++
+	return product, nil
+}
+
+func (s *savingService) UpdateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
+	s.saves++
+	return product, nil
+}
+
+func TestProductBaseDataValidation(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		baseData    string
+		want        int
+		wantDetails []models.FieldError
+	}{
+		{name: "create valid", method: http.MethodPost, path: "/products", baseData: `{"name":"Apple juice","price":100}`, want: http.StatusCreated},
+		{name: "create invalid", method: http.MethodPost, path: "/products", baseData: `{"name":"","price":-1}`,
+			want: http.StatusUnprocessableEntity, wantDetails: []models.FieldError{
+				{Field: "name", Message: "must not be empty"},
+				{Field: "price", Message: "must not be negative"},
+			}},
+		{name: "update valid", method: http.MethodPut, path: "/products/product-1", baseData: `{"name":"Apple juice","price":0}`, want: http.StatusOK},
+		{name: "update invalid", method: http.MethodPut, path: "/products/product-1", baseData: `{"price":"free"}`,
+			want: http.StatusUnprocessableEntity, wantDetails: []models.FieldError{
+				{Field: "name", Message: "is required"},
+				{Field: "price", Message: "must be a number"},
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &savingService{}
+			handler := NewProductHandler(service, log, 0)
+
+			router := chi.NewRouter()
+			router.Post("/products", handler.CreateProduct)
+			router.Put("/products/{id}", handler.UpdateProduct)
+
+			body := `{"base_data":` + tt.baseData + `,"version":1}`
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+			ctx := contextkeys.WithSupplier(contextkeys.WithTenant(req.Context(), "tenant-1"), "supplier-1")
+			req = req.WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantDetails == nil {
+				if service.saves != 1 {
+					t.Fatalf("saves = %d, want the valid product saved", service.saves)
+				}
+				return
+			}
+
+			var resp render.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error != "validation_error" || !reflect.DeepEqual(resp.Details, tt.wantDetails) {
+				t.Fatalf("response = %+v, want field errors %+v", resp, tt.wantDetails)
+			}
+			if service.saves != 0 {
+				t.Fatal("service called for an invalid product")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+)
+
+// DefaultReadinessTimeout общее время на проверку зависимостей, чтобы проба не зависала на недоступном сервисе
+const DefaultReadinessTimeout = 2 * time.Second
+
+// DependencyCheck проверка доступности внешней зависимости сервиса
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// dependencyStatus результат проверки одной зависимости
+type dependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// readinessResponse ответ проверки готовности
+type readinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+}
+
+// ReadinessHandler проверяет зависимости сервиса и отвечает 503, если хотя бы одна недоступна.
+// В отличие от /health, который подтверждает только, что процесс жив, используется для readiness-проб оркестратора
+type ReadinessHandler struct {
+	checks  []DependencyCheck
+	timeout time.Duration
+}
+
+// NewReadinessHandler создает обработчик проверки готовности. timeout ограничивает все проверки вместе
+func NewReadinessHandler(timeout time.Duration, checks ...DependencyCheck) *ReadinessHandler {
+	return &ReadinessHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// ServeHTTP выполняет проверки параллельно и возвращает статус каждой зависимости
+// @Summary Проверка готовности
+// @Description Проверяет PostgreSQL, Redis и Kafka. Возвращает 503 и причину, если хотя бы одна зависимость недоступна
+// @Tags health
+// @Produce json
+// @Success 200 {object} readinessResponse "Сервис готов принимать запросы"
+// @Failure 503 {object} readinessResponse "Одна или несколько зависимостей недоступны"
+// @Router /readiness [get]
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	result := readinessResponse{
+		Status:       "ready",
+		Dependencies: make(map[string]dependencyStatus, len(h.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check DependencyCheck) {
+			defer wg.Done()
+
+			status := dependencyStatus{Status: "up"}
+			if err := check.Check(ctx); err != nil {
+				status = dependencyStatus{Status: "down", Error: err.Error()}
+			}
+
+			mu.Lock()
+			result.Dependencies[check.Name] = status
+			if status.Status != "up" {
+				result.Status = "not_ready"
+			}
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	if result.Status != "ready" {
+		render.Status(r, http.StatusServiceUnavailable)
+	} else {
+		render.Status(r, http.StatusOK)
+	}
+	render.JSON(w, r, result)
+}
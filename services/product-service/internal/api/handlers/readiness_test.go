@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	// hanging не отвечает, пока не истечет общий таймаут проверки
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name   string
+		checks []DependencyCheck
+		want   int
+		wantUp map[string]string
+	}{
+		{name: "all up", checks: []DependencyCheck{{Name: "postgres", Check: up}, {Name: "redis", Check: up}, {Name: "kafka", Check: up}},
+			want: http.StatusOK, wantUp: map[string]string{"postgres": "up", "redis": "up", "kafka": "up"}},
+		{name: "redis down", checks: []DependencyCheck{
+			{Name: "postgres", Check: up},
+			{Name: "redis", Check: func(ctx context.Context) error { return errors.New("connection refused") }},
+			{Name: "kafka", Check: up},
+		}, want: http.StatusServiceUnavailable, wantUp: map[string]string{"postgres": "up", "redis": "down", "kafka": "up"}},
+		{name: "kafka hangs", checks: []DependencyCheck{{Name: "postgres", Check: up}, {Name: "kafka", Check: hanging}},
+			want: http.StatusServiceUnavailable, wantUp: map[string]string{"postgres": "up", "kafka": "down"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewReadinessHandler(50*time.Millisecond, tt.checks...)
+
+			rec := httptest.NewRecorder()
+			started := time.Now()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Fatalf("readiness took %v, want it bounded by the timeout", elapsed)
+			}
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			var resp readinessResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if (resp.Status == "ready") != (tt.want == http.StatusOK) || len(resp.Dependencies) != len(tt.wantUp) {
+				t.Fatalf("response = %+v", resp)
+			}
+			for name, status := range tt.wantUp {
+				dependency := resp.Dependencies[name]
+				if dependency.Status != status || (status == "down") != (dependency.Error != "") {
+					t.Fatalf("%s = %+v, want %s with an error only when down", name, dependency, status)
+				}
+			}
+			if dependency, ok := resp.Dependencies["redis"]; ok && dependency.Status == "down" && dependency.Error != "connection refused" {
+				t.Fatalf("redis error = %q, want the check error", dependency.Error)
+			}
+		})
+	}
+}
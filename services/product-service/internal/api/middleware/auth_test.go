@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/security"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRequireProductPermission(t *testing.T) {
+	keycloakClaims := &security.Claims{TenantID: "tenant-1"}
+	keycloakClaims.RealmAccess.Roles = []string{"products:read"}
+
+	tests := []struct {
+		name   string
+		action string
+		ctx    func(ctx context.Context) context.Context
+		want   int
+	}{
+		{
+			name:   "permission from token",
+			action: "update",
+			ctx: func(ctx context.Context) context.Context {
+				return contextkeys.WithPermissions(ctx, []string{"products:*"})
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "permission as role",
+			action: "read",
+			ctx: func(ctx context.Context) context.Context {
+				return contextkeys.WithRoles(ctx, []string{"products:read"})
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "other permission",
+			action: "delete",
+			ctx: func(ctx context.Context) context.Context {
+				ctx = contextkeys.WithPermissions(ctx, []string{"products:read"})
+				return contextkeys.WithRoles(ctx, []string{"viewer"})
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "realm role from keycloak claims",
+			action: "read",
+			ctx: func(ctx context.Context) context.Context {
+				return security.WithClaims(ctx, keycloakClaims)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "keycloak claims without permission",
+			action: "delete",
+			ctx: func(ctx context.Context) context.Context {
+				return security.WithClaims(ctx, keycloakClaims)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "unauthenticated",
+			action: "read",
+			ctx:    func(ctx context.Context) context.Context { return ctx },
+			want:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			req = req.WithContext(tt.ctx(req.Context()))
+			rec := httptest.NewRecorder()
+
+			RequireProductPermission(tt.action)(okHandler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeycloakAuthWithProductPermission(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test-key",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client, err := security.NewKeycloakClient(security.KeycloakConfig{
+		URL:          server.URL,
+		Realm:        "gomarket",
+		ClientID:     "product-service",
+		ClientSecret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewKeycloakClient: %v", err)
+	}
+	client.SetRolePermissions(security.RolePermissions{"catalog-editor": {"products:update"}})
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	claims := &security.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    server.URL + "/realms/gomarket",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		TenantID: "tenant-1",
+	}
+	claims.RealmAccess.Roles = []string{"products:read", "catalog-editor"}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		action string
+		want   int
+	}{
+		{name: "realm role", token: signed, action: "read", want: http.StatusOK},
+		{name: "role permission", token: signed, action: "update", want: http.StatusOK},
+		{name: "forbidden action", token: signed, action: "delete", want: http.StatusForbidden},
+		{name: "invalid token", token: signed + "x", action: "read", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenantID string
+			handler := KeycloakAuth(client, log)(RequireProductPermission(tt.action)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tenantID, _ = contextkeys.TenantFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && tenantID != "tenant-1" {
+				t.Fatalf("tenant in context = %q, want tenant-1", tenantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	const limit = 16
+
+	tests := []struct {
+		name string
+		body string
+		// chunked скрывает размер тела, как при Transfer-Encoding: chunked
+		chunked     bool
+		want        int
+		wantHandler bool
+	}{
+		{name: "under limit", body: strings.Repeat("a", limit-1), want: http.StatusOK, wantHandler: true},
+		{name: "at limit", body: strings.Repeat("a", limit), want: http.StatusOK, wantHandler: true},
+		{name: "declared over limit", body: strings.Repeat("a", limit+1), want: http.StatusRequestEntityTooLarge},
+		{name: "streamed over limit", body: strings.Repeat("a", limit+1), chunked: true, want: http.StatusRequestEntityTooLarge, wantHandler: true},
+		{name: "streamed under limit", body: strings.Repeat("a", limit), chunked: true, want: http.StatusOK, wantHandler: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			handler := BodyLimit(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				data, err := io.ReadAll(r.Body)
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				if string(data) != tt.body {
+					t.Errorf("body = %q, want %q", data, tt.body)
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/products", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want || called != tt.wantHandler {
+				t.Fatalf("status = %d, handler called = %v, want %d and %v", rec.Code, called, tt.want, tt.wantHandler)
+			}
+			if tt.wantHandler {
+				return
+			}
+
+			var resp struct {
+				Error string `json:"error"`
+				Code  int    `json:"code"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error != "request_too_large" || resp.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("response = %+v, want request_too_large", resp)
+			}
+		})
+	}
+}
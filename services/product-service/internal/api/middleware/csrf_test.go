@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	settings := NewRuntimeSettings(TenantRateLimits{}, []string{"https://shop.example.com"})
+	handler := CSRF(settings)(okHandler)
+
+	tests := []struct {
+		name    string
+		method  string
+		bearer  bool
+		cookies []*http.Cookie
+		header  string
+		origin  string
+		want    int
+	}{
+		{
+			name:    "valid token",
+			method:  http.MethodPost,
+			cookies: []*http.Cookie{{Name: CSRFCookieName, Value: "token-1"}},
+			header:  "token-1",
+			want:    http.StatusOK,
+		},
+		{
+			name:    "missing token",
+			method:  http.MethodPost,
+			cookies: []*http.Cookie{{Name: CSRFCookieName, Value: "token-1"}},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:   "missing cookie",
+			method: http.MethodPost,
+			header: "token-1",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:    "mismatched token",
+			method:  http.MethodPut,
+			cookies: []*http.Cookie{{Name: CSRFCookieName, Value: "token-1"}},
+			header:  "token-2",
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "foreign origin",
+			method:  http.MethodPost,
+			cookies: []*http.Cookie{{Name: CSRFCookieName, Value: "token-1"}},
+			header:  "token-1",
+			origin:  "https://evil.example.com",
+			want:    http.StatusForbidden,
+		},
+		{
+			name:   "bearer without cookies",
+			method: http.MethodPost,
+			bearer: true,
+			want:   http.StatusOK,
+		},
+		{
+			name:    "bearer with csrf cookie only",
+			method:  http.MethodDelete,
+			bearer:  true,
+			cookies: []*http.Cookie{{Name: CSRFCookieName, Value: "token-1"}},
+			want:    http.StatusOK,
+		},
+		{
+			name:    "bearer with session cookie",
+			method:  http.MethodPost,
+			bearer:  true,
+			cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+			want:    http.StatusForbidden,
+		},
+		{
+			name:   "safe method",
+			method: http.MethodGet,
+			want:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/products", nil)
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer access-token")
+			}
+			for _, cookie := range tt.cookies {
+				req.AddCookie(cookie)
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeaderName, tt.header)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFIssuesTokenOnSafeRequest(t *testing.T) {
+	handler := CSRF(NewRuntimeSettings(TenantRateLimits{}, nil))(okHandler)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName || len(cookies[0].Value) != 64 {
+		t.Fatalf("cookies = %v, want a new %s", cookies, CSRFCookieName)
+	}
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"io"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader заголовок с ключом идемпотентности
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyLockTTL ограничивает время жизни блокировки, если обработчик не снял ее
+const idempotencyLockTTL = time.Minute
+
+var (
+	// errIdempotencyInProgress запрос с тем же ключом еще выполняется
+	errIdempotencyInProgress = models.NewError(models.ErrConflict, "idempotency_in_progress",
+		"Запрос с этим ключом идемпотентности еще выполняется")
+	// errIdempotencyKeyReused ключ уже использован для запроса с другим методом, путем или телом
+	errIdempotencyKeyReused = models.NewError(models.ErrUnprocessable, "idempotency_key_reused",
+		"Ключ идемпотентности уже использован для другого запроса")
+)
+
+// storedResponse сохраненный ответ на запрос с ключом идемпотентности.
+// Method, Path и BodyHash описывают исходный запрос: повтор ключа с другим запросом отклоняется
+type storedResponse struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	BodyHash    string `json:"body_hash"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// recordingResponseWriter дублирует ответ в буфер для последующего сохранения
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Idempotency повторяет сохраненный ответ для изменяющих запросов с заголовком Idempotency-Key.
+// Ответ хранится в кэше по тенанту и ключу в течение ttl вместе с методом, путем и SHA-256 тела запроса;
+// повтор ключа с другим запросом получает 422. Пока первый запрос выполняется, повторные запросы с тем же
+// ключом получают 409. Ответы 5xx не сохраняются, чтобы клиент мог повторить запрос.
+// При недоступности кэша запрос обрабатывается без гарантии идемпотентности
+func Idempotency(cache interfaces.CachePort, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Тело уже ограничено BodyLimit; после хеширования оно возвращается обработчику
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				render.Error(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			bodyHash := hex.EncodeToString(sum[:])
+
+			tenantID, _ := contextkeys.TenantFromContext(r.Context())
+			responseKey := fmt.Sprintf("idempotency:%s:%s", tenantID, key)
+			lockKey := responseKey + ":lock"
+
+			data, err := cache.Get(r.Context(), responseKey)
+			if err == nil {
+				var stored storedResponse
+				if err := json.Unmarshal(data, &stored); err == nil {
+					if stored.Method != r.Method || stored.Path != r.URL.Path || stored.BodyHash != bodyHash {
+						render.Error(w, r, errIdempotencyKeyReused)
+						return
+					}
+					replayResponse(w, &stored)
+					return
+				}
+			} else if !errors.Is(err, pkgerrors.ErrCacheMiss) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			count, err := cache.Increment(r.Context(), lockKey, idempotencyLockTTL)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if count > 1 {
+				render.Error(w, r, errIdempotencyInProgress)
+				return
+			}
+			defer cache.Delete(r.Context(), lockKey)
+
+			rw := &recordingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			if rw.status == 0 || rw.status >= http.StatusInternalServerError {
+				return
+			}
+
+			data, err = json.Marshal(storedResponse{
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				BodyHash:    bodyHash,
+				Status:      rw.status,
+				ContentType: rw.Header().Get("Content-Type"),
+				Body:        rw.body.Bytes(),
+			})
+			if err != nil {
+				return
+			}
+			cache.Set(r.Context(), responseKey, data, ttl)
+		})
+	}
+}
+
+// replayResponse отправляет клиенту ранее сохраненный ответ
+func replayResponse(w http.ResponseWriter, stored *storedResponse) {
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
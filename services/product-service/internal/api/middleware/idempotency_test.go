@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
+)
+
+// memoryCache кэш в памяти с методами, которые использует Idempotency
+type memoryCache struct {
+	interfaces.CachePort
+
+	mu       sync.Mutex
+	values   map[string][]byte
+	counters map[string]int64
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string][]byte{}, counters: map[string]int64{}}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return nil, pkgerrors.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *memoryCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[key]++
+	return c.counters[key], nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	delete(c.counters, key)
+	return nil
+}
+
+func idempotentRequest(method, path, key, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	return req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+}
+
+func TestIdempotency(t *testing.T) {
+	cache := newMemoryCache()
+	calls := 0
+	handler := Idempotency(cache, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `,"body":` + string(body) + `}`))
+	}))
+
+	const body = `{"name":"apple"}`
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest(http.MethodPost, "/api/v1/products", "key-1", body))
+	if first.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("first request: status = %d, calls = %d", first.Code, calls)
+	}
+
+	t.Run("stores request fingerprint", func(t *testing.T) {
+		var stored storedResponse
+		if err := json.Unmarshal(cache.values["idempotency:tenant-1:key-1"], &stored); err != nil {
+			t.Fatalf("decode stored response: %v", err)
+		}
+		if stored.Method != http.MethodPost || stored.Path != "/api/v1/products" || len(stored.BodyHash) != 64 {
+			t.Fatalf("stored = %+v", stored)
+		}
+		if stored.Status != http.StatusCreated || string(stored.Body) != first.Body.String() {
+			t.Fatalf("stored response = %d %s", stored.Status, stored.Body)
+		}
+	})
+
+	t.Run("replays same request", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest(http.MethodPost, "/api/v1/products", "key-1", body))
+		if calls != 1 {
+			t.Fatalf("handler called %d times, want 1", calls)
+		}
+		if rec.Code != http.StatusCreated || rec.Body.String() != first.Body.String() || rec.Header().Get("Idempotent-Replayed") != "true" {
+			t.Fatalf("replay = %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	mismatches := []struct {
+		name string
+		req  *http.Request
+	}{
+		{name: "other body", req: idempotentRequest(http.MethodPost, "/api/v1/products", "key-1", `{"name":"pear"}`)},
+		{name: "other path", req: idempotentRequest(http.MethodPost, "/api/v1/products/import", "key-1", body)},
+		{name: "other method", req: idempotentRequest(http.MethodPut, "/api/v1/products", "key-1", body)},
+	}
+	for _, tt := range mismatches {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+			if calls != 1 {
+				t.Fatalf("handler called %d times, want 1", calls)
+			}
+			assertErrorResponse(t, rec, http.StatusUnprocessableEntity, "idempotency_key_reused")
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	cache := newMemoryCache()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := Idempotency(cache, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(http.MethodPost, "/api/v1/products", "key-1", `{}`))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest(http.MethodPost, "/api/v1/products", "key-1", `{}`))
+	close(release)
+	<-done
+
+	assertErrorResponse(t, rec, http.StatusConflict, "idempotency_in_progress")
+}
+
+func assertErrorResponse(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d", rec.Code, status)
+	}
+	var resp render.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if resp.Code != status || resp.Error != code || resp.Message == "" {
+		t.Fatalf("response = %+v, want %d %q", resp, status, code)
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/security"
+)
+
+// newTestKey создает ключ RSA для подписи тестовых токенов
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// newTestJWTManager создает JWTManager с ключом key и заданным сроком жизни токена доступа
+func newTestJWTManager(t *testing.T, key *rsa.PrivateKey, expiration time.Duration) *security.JWTManager {
+	t.Helper()
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	manager, err := security.NewJWTManager(
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}),
+		expiration, time.Hour, "product-service",
+	)
+	if err != nil {
+		t.Fatalf("NewJWTManager: %v", err)
+	}
+	return manager
+}
+
+func TestJWTAuth(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	key := newTestKey(t)
+	manager := newTestJWTManager(t, key, time.Hour)
+	blacklist := security.NewTokenBlacklist(newMiniredisCache(t, miniredis.RunT(t)))
+
+	signed, err := manager.Generate("user-1", "tenant-1", []string{"editor"}, []string{"products:read"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	// Токен подписан тем же ключом, но его срок уже истек
+	expired, err := newTestJWTManager(t, key, -time.Minute).Generate("user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Generate expired: %v", err)
+	}
+	foreign, err := newTestJWTManager(t, newTestKey(t), time.Hour).Generate("user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Generate foreign: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "signed", header: "Bearer " + signed, want: http.StatusOK},
+		{name: "expired", header: "Bearer " + expired, want: http.StatusUnauthorized},
+		{name: "another key", header: "Bearer " + foreign, want: http.StatusUnauthorized},
+		{name: "no header", want: http.StatusUnauthorized},
+		{name: "not bearer", header: "Basic " + signed, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID, tenantID string
+			var permissions []string
+			handler := JWTAuth(manager, blacklist, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = contextkeys.UserFromContext(r.Context())
+				tenantID, _ = contextkeys.TenantFromContext(r.Context())
+				permissions, _ = contextkeys.PermissionsFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusOK && (userID != "user-1" || tenantID != "tenant-1" || len(permissions) == 0) {
+				t.Fatalf("context = user %q, tenant %q, permissions %v, want the token claims", userID, tenantID, permissions)
+			}
+		})
+	}
+}
+
+func TestJWTAuthRejectsRevokedToken(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	server := miniredis.RunT(t)
+	manager := newTestJWTManager(t, newTestKey(t), time.Hour)
+	blacklist := security.NewTokenBlacklist(newMiniredisCache(t, server))
+	handler := JWTAuth(manager, blacklist, log)(okHandler)
+
+	generate := func() (string, *security.Claims) {
+		t.Helper()
+		token, err := manager.Generate("user-1", "tenant-1", nil, nil)
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		claims, err := manager.Validate(token)
+		if err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		return token, claims
+	}
+	status := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	revoked, revokedClaims := generate()
+	other, otherClaims := generate()
+	if revokedClaims.ID == "" || revokedClaims.ID == otherClaims.ID {
+		t.Fatalf("jti = %q and %q, want unique ids", revokedClaims.ID, otherClaims.ID)
+	}
+
+	if err := blacklist.Revoke(context.Background(), revokedClaims); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if code := status(revoked); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status = %d, want 401", code)
+	}
+	if code := status(other); code != http.StatusOK {
+		t.Fatalf("other token of the same user: status = %d, want 200", code)
+	}
+
+	// Запись в черном списке живет не дольше самого токена
+	ttl := server.TTL("auth:revoked:" + revokedClaims.ID)
+	if ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("blacklist ttl = %v, want the remaining token lifetime", ttl)
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// метрики HTTP запросов для Prometheus
+var (
+	httpDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_durations_seconds",
+		Help:    "Длительность HTTP запросов",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"path", "method", "status"})
+
+	requestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Общее количество HTTP запросов",
+	}, []string{"path", "method", "status"})
+
+	activeRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_active_requests",
+		Help: "Количество активных HTTP запросов",
+	})
+)
+
+// Metrics записывает длительность, количество и число активных HTTP запросов.
+// В метку path попадает шаблон маршрута chi, а не сырой путь, чтобы число серий оставалось ограниченным
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		activeRequests.Inc()
+		defer activeRequests.Dec()
+
+		ww := NewResponseWriter(w)
+		next.ServeHTTP(ww, r)
+
+		path := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				path = pattern
+			}
+		}
+		status := strconv.Itoa(ww.Status())
+
+		httpDurations.WithLabelValues(path, r.Method, status).Observe(time.Since(start).Seconds())
+		requestsCounter.WithLabelValues(path, r.Method, status).Inc()
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	const pattern = "/metrics-test/products/{id}"
+
+	var activeDuringRequest float64
+	router := chi.NewRouter()
+	router.Use(Metrics)
+	router.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+		activeDuringRequest = testutil.ToFloat64(activeRequests)
+		if chi.URLParam(r, "id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	okBefore := testutil.ToFloat64(requestsCounter.WithLabelValues(pattern, http.MethodGet, "200"))
+	activeBefore := testutil.ToFloat64(activeRequests)
+
+	for _, path := range []string{"/metrics-test/products/p1", "/metrics-test/products/p2", "/metrics-test/products/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if activeDuringRequest != activeBefore+1 {
+		t.Fatalf("active requests during a request = %v, want %v", activeDuringRequest, activeBefore+1)
+	}
+	if active := testutil.ToFloat64(activeRequests); active != activeBefore {
+		t.Fatalf("active requests = %v after the requests, want %v", active, activeBefore)
+	}
+
+	// Снимаем метрики так же, как Prometheus
+	rec := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	scraped, _ := io.ReadAll(rec.Body)
+	metrics := string(scraped)
+
+	if got := testutil.ToFloat64(requestsCounter.WithLabelValues(pattern, http.MethodGet, "200")); got != okBefore+2 {
+		t.Fatalf("requests with 200 = %v, want %v", got, okBefore+2)
+	}
+	for _, series := range []string{
+		`http_requests_total{method="GET",path="` + pattern + `",status="404"} 1`,
+		`http_durations_seconds_count{method="GET",path="` + pattern + `",status="200"}`,
+	} {
+		if !strings.Contains(metrics, series) {
+			t.Fatalf("scrape has no %s", series)
+		}
+	}
+	// Сырые пути в метки не попадают
+	if strings.Contains(metrics, "/metrics-test/products/p1") {
+		t.Fatal("raw request path used as a label")
+	}
+}
@@ -2,11 +2,26 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/api/render"
 	"github.com/athebyme/gomarket-platform/product-service/internal/security"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -20,7 +35,7 @@ func RequestID(next http.Handler) http.Handler {
 			requestID = uuid.New().String()
 		}
 
-		ctx := context.WithValue(r.Context(), "request_id", requestID)
+		ctx := contextkeys.WithRequestID(r.Context(), requestID)
 		w.Header().Set("X-Request-ID", requestID)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -37,7 +52,7 @@ func Logger(logger interfaces.LoggerPort) func(next http.Handler) http.Handler {
 			ww := NewResponseWriter(w)
 
 			// Логируем входящий запрос
-			requestID, _ := r.Context().Value("request_id").(string)
+			requestID, _ := contextkeys.RequestIDFromContext(r.Context())
 			logger.InfoWithContext(r.Context(), "Входящий запрос",
 				interfaces.LogField{Key: "method", Value: r.Method},
 				interfaces.LogField{Key: "path", Value: r.URL.Path},
@@ -46,8 +61,8 @@ func Logger(logger interfaces.LoggerPort) func(next http.Handler) http.Handler {
 				interfaces.LogField{Key: "user_agent", Value: r.UserAgent()},
 			)
 
-			// Выполняем запрос
-			next.ServeHTTP(ww, r)
+			// Выполняем запрос; render.Error записывает внутренние ошибки этим же логгером
+			next.ServeHTTP(ww, r.WithContext(render.WithLogger(r.Context(), logger)))
 
 			// Рассчитываем время выполнения
 			duration := time.Since(start)
@@ -119,67 +134,44 @@ func Tenant(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "tenant_id", tenantID)
+		ctx := contextkeys.WithTenant(r.Context(), tenantID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// Supplier извлекает ID поставщика из заголовка и добавляет его в контекст
+// Supplier извлекает ID поставщика из заголовка X-Supplier-ID и добавляет его в контекст.
+// Заголовок нужен не всем маршрутам, поэтому без него запрос проходит дальше,
+// а обработчики, которым поставщик обязателен, отвечают ошибкой сами
 func Supplier(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		supplierID := r.Header.Get("X-Supplier-ID")
 		if supplierID == "" {
-			http.Error(w, "X-Supplier-ID header is required", http.StatusBadRequest)
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), "supplier_id", supplierID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
-// Auth проверяет аутентификацию по токену
-func Auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
-			return
-		}
-
-		// Проверяем формат токена
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
-			return
-		}
-
-		token := parts[1]
-
-		// В реальном приложении здесь была бы проверка токена
-		// Здесь приведен пример-заглушка
-		if token == "" {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Добавляем user_id в контекст (в реальном приложении получали бы из токена)
-		ctx := context.WithValue(r.Context(), "user_id", "user123")
+		ctx := contextkeys.WithSupplier(r.Context(), supplierID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// Timeout устанавливает таймаут для запроса
-func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
+// Timeout устанавливает таймаут для запроса. Обработчик выполняется в отдельной горутине и после
+// ответа по таймауту может еще работать, поэтому учитывается в inflight: при завершении процесса
+// зависимости закрываются только после него. Обработчик пишет ответ через timeoutWriter, который,
+// как http.TimeoutHandler, отбрасывает запись после ответа по таймауту
+func Timeout(timeout time.Duration, inflight *sync.WaitGroup) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
 			done := make(chan struct{})
 
+			inflight.Add(1)
 			go func() {
-				next.ServeHTTP(w, r.WithContext(ctx))
+				defer inflight.Done()
+				next.ServeHTTP(tw, r.WithContext(ctx))
 				close(done)
 			}()
 
@@ -188,7 +180,7 @@ func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
 				return
 			case <-ctx.Done():
 				if ctx.Err() == context.DeadlineExceeded {
-					http.Error(w, "Request timeout", http.StatusGatewayTimeout)
+					tw.timeout()
 				}
 				return
 			}
@@ -196,25 +188,92 @@ func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
 	}
 }
 
+// timeoutWriter передает ответ обработчика в w, пока не истек таймаут. Заголовки обработчик
+// заполняет в собственной карте, которая копируется в w при записи статуса, поэтому горутина
+// обработчика и Timeout не обращаются к w одновременно
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+// Write после ответа по таймауту отбрасывает данные и возвращает http.ErrHandlerTimeout
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+// FlushError отправляет клиенту записанную часть ответа; используется http.ResponseController
+func (tw *timeoutWriter) FlushError() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return http.NewResponseController(tw.w).Flush()
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	tw.w.WriteHeader(code)
+}
+
+// timeout отвечает 504, если обработчик еще не начал ответ, и запрещает дальнейшую запись.
+// Начатый ответ дописать нельзя, поэтому он обрывается
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+	if !tw.wroteHeader {
+		http.Error(tw.w, "Request timeout", http.StatusGatewayTimeout)
+	}
+}
+
 // CORS добавляет заголовки для Cross-Origin Resource Sharing
-func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+func CORS(settings *RuntimeSettings) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
 
 			// Проверяем, разрешен ли данный origin
-			allowed := false
-			for _, allowedOrigin := range allowedOrigins {
-				if allowedOrigin == "*" || origin == allowedOrigin {
-					allowed = true
-					break
-				}
-			}
-
-			if allowed {
+			if isAllowedOrigin(origin, settings.CORSOrigins()) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-Tenant-ID, X-Request-ID")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-Tenant-ID, X-Request-ID, X-CSRF-Token, Idempotency-Key")
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
 			}
 
@@ -229,22 +288,41 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 	}
 }
 
-// Tracing добавляет трассировку запросов
+// Tracing начинает серверный спан OpenTelemetry на каждый запрос.
+// Родительский контекст извлекается из заголовка traceparent, идентификатор трассы
+// кладется в контекст как trace_id и возвращается клиенту в заголовке X-Trace-ID
 func Tracing(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// В реальном приложении здесь был бы код для трассировки запросов
-		// Например, с использованием OpenTelemetry или Jaeger
+	tracer := otel.Tracer("product-service/http")
+	propagator := otel.GetTextMapPropagator()
 
-		// Получаем или генерируем trace_id
-		traceID := r.Header.Get("X-Trace-ID")
-		if traceID == "" {
-			traceID = uuid.New().String()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if spanCtx := span.SpanContext(); spanCtx.HasTraceID() {
+			traceID := spanCtx.TraceID().String()
+			ctx = contextkeys.WithTraceID(ctx, traceID)
+			w.Header().Set("X-Trace-ID", traceID)
 		}
 
-		ctx := context.WithValue(r.Context(), "trace_id", traceID)
-		w.Header().Set("X-Trace-ID", traceID)
+		ww := NewResponseWriter(w)
+		next.ServeHTTP(ww, r.WithContext(ctx))
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", ww.Status()))
+		if ww.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(ww.Status()))
+		}
 	})
 }
 
@@ -300,8 +378,72 @@ func RateLimiter(requests int, duration time.Duration) func(http.Handler) http.H
 	}
 }
 
-// JWTAuth проверяет и валидирует JWT токен
-func JWTAuth(jwtManager *security.JWTManager, logger interfaces.LoggerPort) func(http.Handler) http.Handler {
+// RedisRateLimiter ограничивает количество запросов с одного IP в рамках тенанта.
+// Счетчики хранятся во внешнем кэше, поэтому лимит общий для всех реплик сервиса.
+// Используется фиксированное окно: ключ счетчика содержит номер окна.
+// При недоступности кэша запрос проверяется локальным RateLimiter.
+func RedisRateLimiter(cache interfaces.CachePort, requests int, window time.Duration) func(http.Handler) http.Handler {
+	fallback := RateLimiter(requests, window)
+
+	return func(next http.Handler) http.Handler {
+		fallbackHandler := fallback(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ip = host
+			}
+			tenantID, _ := contextkeys.TenantFromContext(r.Context())
+
+			now := time.Now()
+			windowIndex := now.UnixNano() / int64(window)
+			key := fmt.Sprintf("ratelimit:%s:%s:%d", tenantID, ip, windowIndex)
+
+			count, err := cache.Increment(r.Context(), key, window)
+			if err != nil {
+				fallbackHandler.ServeHTTP(w, r)
+				return
+			}
+
+			if count > int64(requests) {
+				windowEnd := time.Unix(0, (windowIndex+1)*int64(window))
+				retryAfter := int(windowEnd.Sub(now).Seconds()) + 1
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyLimit ограничивает размер тела запроса maxBytes байтами.
+// Запросы с заведомо большим Content-Length отклоняются сразу, остальные
+// получают тело, чтение которого сверх лимита завершается *http.MaxBytesError
+func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"error":   "request_too_large",
+					"code":    http.StatusRequestEntityTooLarge,
+					"message": fmt.Sprintf("Размер тела запроса превышает %d байт", maxBytes),
+				})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// JWTAuth проверяет и валидирует JWT токен и отклоняет токены из черного списка.
+// Если черный список недоступен, запрос пропускается с предупреждением: подпись и срок токена уже проверены
+func JWTAuth(jwtManager *security.JWTManager, blacklist *security.TokenBlacklist, logger interfaces.LoggerPort) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -323,7 +465,74 @@ func JWTAuth(jwtManager *security.JWTManager, logger interfaces.LoggerPort) func
 				logger.WarnWithContext(r.Context(), "Invalid JWT token",
 					interfaces.LogField{Key: "error", Value: err.Error()})
 
-				if err == security.ErrExpiredToken {
+				if errors.Is(err, security.ErrExpiredToken) {
+					http.Error(w, "Token expired", http.StatusUnauthorized)
+				} else {
+					http.Error(w, "Invalid token", http.StatusUnauthorized)
+				}
+				return
+			}
+
+			// Refresh-токен годится только для /auth/refresh
+			if claims.TokenType == security.TokenTypeRefresh {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			// В токенах Keycloak пользователь передается только в sub
+			if claims.UserID == "" {
+				claims.UserID = claims.Subject
+			}
+
+			if claims.UserID == "" || claims.TenantID == "" {
+				logger.WarnWithContext(r.Context(), "JWT token without user or tenant")
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			if claims.ID != "" {
+				revoked, err := blacklist.IsRevoked(r.Context(), claims.ID)
+				if err != nil {
+					logger.WarnWithContext(r.Context(), "Token blacklist is unavailable",
+						interfaces.LogField{Key: "error", Value: err.Error()})
+				} else if revoked {
+					http.Error(w, "Token revoked", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			// Разрешения дополняются разрешениями ролей, чтобы HasPermission проверял итоговый набор
+			ctx := withClaims(r.Context(), claims, jwtManager.EffectivePermissions(claims))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// KeycloakAuth проверяет токен, выпущенный realm Keycloak, через KeycloakClient.ValidateToken.
+// Тенант берется из claim tenant_id (маппер клиента Keycloak), пользователь - из sub.
+// Разрешения токена дополняются разрешениями его ролей, включая роли realm
+func KeycloakAuth(client *security.KeycloakClient, logger interfaces.LoggerPort) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := client.ValidateToken(r.Context(), parts[1])
+			if err != nil {
+				logger.WarnWithContext(r.Context(), "Invalid Keycloak token",
+					interfaces.LogField{Key: "error", Value: err.Error()})
+
+				if errors.Is(err, security.ErrExpiredToken) {
 					http.Error(w, "Token expired", http.StatusUnauthorized)
 				} else {
 					http.Error(w, "Invalid token", http.StatusUnauthorized)
@@ -331,18 +540,32 @@ func JWTAuth(jwtManager *security.JWTManager, logger interfaces.LoggerPort) func
 				return
 			}
 
-			// Добавляем данные из токена в контекст
-			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-			ctx = context.WithValue(ctx, "tenant_id", claims.TenantID)
-			ctx = context.WithValue(ctx, "roles", claims.Roles)
-			ctx = context.WithValue(ctx, "permissions", claims.Permissions)
-			ctx = context.WithValue(ctx, "claims", claims)
+			if claims.UserID == "" {
+				claims.UserID = claims.Subject
+			}
+
+			if claims.UserID == "" || claims.TenantID == "" {
+				logger.WarnWithContext(r.Context(), "Keycloak token without user or tenant")
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := withClaims(r.Context(), claims, client.EffectivePermissions(claims))
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// withClaims добавляет в контекст данные проверенного токена и его итоговые разрешения
+func withClaims(ctx context.Context, claims *security.Claims, permissions []string) context.Context {
+	ctx = contextkeys.WithUser(ctx, claims.UserID)
+	ctx = contextkeys.WithTenant(ctx, claims.TenantID)
+	ctx = contextkeys.WithRoles(ctx, claims.AllRoles())
+	ctx = contextkeys.WithPermissions(ctx, permissions)
+	return security.WithClaims(ctx, claims)
+}
+
 // SecurityHeaders добавляет заголовки безопасности
 func SecurityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -365,45 +588,116 @@ func SecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
-// CSRF защита от CSRF-атак
-func CSRF(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Проверяем метод запроса
-		if r.Method != "GET" && r.Method != "HEAD" && r.Method != "OPTIONS" {
-			// Проверка CSRF-токена для небезопасных методов
-			token := r.Header.Get("X-CSRF-Token")
-			referer := r.Header.Get("Referer")
-			origin := r.Header.Get("Origin")
+const (
+	// CSRFCookieName имя cookie с CSRF-токеном
+	CSRFCookieName = "csrf_token"
+	// CSRFHeaderName заголовок, в котором клиент возвращает значение cookie
+	CSRFHeaderName = "X-CSRF-Token"
+)
 
-			// Проверка наличия токена
-			if token == "" {
-				http.Error(w, "CSRF token is missing", http.StatusForbidden)
+// CSRF защита от CSRF-атак по схеме double-submit cookie.
+// На безопасные запросы без cookie выдается случайный токен, небезопасные
+// запросы должны передать то же значение в заголовке X-CSRF-Token.
+// Origin и Referer, если указаны, должны входить в список разрешенных источников.
+// Запросы с Bearer-токеном и без сессионных cookie не проверяются: браузер не подставляет
+// заголовок Authorization в межсайтовый запрос сам, поэтому подделать такой запрос нельзя
+func CSRF(settings *RuntimeSettings) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isBearerOnly(r) {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// TODO: Проверка валидности токена (реализация зависит от способа хранения)
+			allowedOrigins := settings.CORSOrigins()
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				if cookie, err := r.Cookie(CSRFCookieName); err != nil || cookie.Value == "" {
+					token, err := generateCSRFToken()
+					if err != nil {
+						http.Error(w, "Failed to generate CSRF token", http.StatusInternalServerError)
+						return
+					}
+					http.SetCookie(w, &http.Cookie{
+						Name:     CSRFCookieName,
+						Value:    token,
+						Path:     "/",
+						Secure:   true,
+						SameSite: http.SameSiteStrictMode,
+					})
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			// Проверка referer и origin для защиты от cross-site requests
-			if origin != "" && !strings.HasPrefix(origin, "https://your-domain.com") {
+			if origin := r.Header.Get("Origin"); origin != "" && !isAllowedOrigin(origin, allowedOrigins) {
 				http.Error(w, "Invalid origin", http.StatusForbidden)
 				return
 			}
 
-			if referer != "" && !strings.HasPrefix(referer, "https://your-domain.com") {
-				http.Error(w, "Invalid referer", http.StatusForbidden)
+			if referer := r.Header.Get("Referer"); referer != "" {
+				refererURL, err := url.Parse(referer)
+				if err != nil || !isAllowedOrigin(refererURL.Scheme+"://"+refererURL.Host, allowedOrigins) {
+					http.Error(w, "Invalid referer", http.StatusForbidden)
+					return
+				}
+			}
+
+			token := r.Header.Get(CSRFHeaderName)
+			cookie, err := r.Cookie(CSRFCookieName)
+			if token == "" || err != nil || cookie.Value == "" {
+				http.Error(w, "CSRF token is missing", http.StatusForbidden)
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
 				return
 			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isBearerOnly сообщает, что запрос аутентифицирован заголовком Authorization: Bearer
+// и не несет cookie, кроме CSRF-токена, то есть не опирается на учетные данные браузера
+func isBearerOnly(r *http.Request) bool {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return false
+	}
+	for _, cookie := range r.Cookies() {
+		if cookie.Name != CSRFCookieName {
+			return false
 		}
+	}
+	return true
+}
 
-		next.ServeHTTP(w, r)
-	})
+// generateCSRFToken возвращает случайный токен в hex-представлении
+func generateCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// isAllowedOrigin проверяет источник по списку разрешенных, "*" разрешает любой
+func isAllowedOrigin(origin string, allowedOrigins []string) bool {
+	for _, allowedOrigin := range allowedOrigins {
+		if allowedOrigin == "*" || origin == allowedOrigin {
+			return true
+		}
+	}
+	return false
 }
 
 // HasRole проверяет наличие определенной роли у пользователя
 func HasRole(role string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			roles, ok := r.Context().Value("roles").([]string)
+			roles, ok := contextkeys.RolesFromContext(r.Context())
 			if !ok {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
@@ -427,25 +721,52 @@ func HasRole(role string) func(http.Handler) http.Handler {
 	}
 }
 
-// HasPermission проверяет наличие определенного разрешения у пользователя
+// HasPermission проверяет наличие определенного разрешения у пользователя.
+// Разрешение может быть выдано маской: products:* покрывает products:read
 func HasPermission(permission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			permissions, ok := r.Context().Value("permissions").([]string)
+			permissions, ok := contextkeys.PermissionsFromContext(r.Context())
 			if !ok {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			hasPermission := false
-			for _, p := range permissions {
-				if p == permission || p == "*" {
-					hasPermission = true
-					break
+			if !security.HasAnyPermission(permissions, permission) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireProductPermission проверяет разрешение products:<action>. Разрешение может быть выдано
+// в permissions токена (в том числе маской или через роль) либо ролью realm Keycloak с тем же именем.
+// Разрешения и роли читаются из контекста, заполненного JWTAuth или KeycloakAuth, а если их там нет -
+// из claims токена, сохраненных в контексте
+func RequireProductPermission(action string) func(http.Handler) http.Handler {
+	required := "products:" + action
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			permissions, hasPermissions := contextkeys.PermissionsFromContext(r.Context())
+			roles, hasRoles := contextkeys.RolesFromContext(r.Context())
+			if claims, ok := security.ClaimsFromContext(r.Context()); ok {
+				if !hasPermissions {
+					permissions, hasPermissions = claims.Permissions, true
+				}
+				if !hasRoles {
+					roles, hasRoles = claims.AllRoles(), true
 				}
 			}
+			if !hasPermissions && !hasRoles {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
 
-			if !hasPermission {
+			if !security.HasAnyPermission(permissions, required) && !security.HasAnyPermission(roles, required) {
 				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
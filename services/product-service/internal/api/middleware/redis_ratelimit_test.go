@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+)
+
+func newMiniredisCache(t *testing.T, server *miniredis.Miniredis) interfaces.CachePort {
+	t.Helper()
+
+	port, err := strconv.Atoi(server.Port())
+	if err != nil {
+		t.Fatalf("parse port: %v", err)
+	}
+	redisCache, err := cache.NewRedisCache(context.Background(), server.Host(), port, "", 0)
+	if err != nil {
+		t.Fatalf("NewRedisCache: %v", err)
+	}
+	t.Cleanup(func() { redisCache.Close() })
+	return redisCache
+}
+
+func TestRedisRateLimiterSharedAcrossReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	// Две реплики с собственными клиентами Redis делят один счетчик
+	const limit = 3
+	replicas := []http.Handler{
+		RedisRateLimiter(newMiniredisCache(t, server), limit, time.Hour)(ok),
+		RedisRateLimiter(newMiniredisCache(t, server), limit, time.Hour)(ok),
+	}
+
+	request := func(replica int, tenantID, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), tenantID))
+		rec := httptest.NewRecorder()
+		replicas[replica].ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < limit; i++ {
+		if rec := request(i%2, "tenant-1", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, rec.Code)
+		}
+	}
+
+	for replica := range replicas {
+		rec := request(replica, "tenant-1", "10.0.0.1:5678")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("replica %d: status = %d, want 429 after the shared limit", replica, rec.Code)
+		}
+		retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil || retryAfter < 1 || retryAfter > int(time.Hour.Seconds())+1 {
+			t.Fatalf("Retry-After = %q, want seconds until the window ends", rec.Header().Get("Retry-After"))
+		}
+	}
+
+	// Счетчик ведется по тенанту и IP
+	if rec := request(0, "tenant-2", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("other tenant: status = %d, want 200", rec.Code)
+	}
+	if rec := request(1, "tenant-1", "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("other IP: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestRedisRateLimiterFallsBackWhenRedisIsDown(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisCache := newMiniredisCache(t, server)
+	server.Close()
+
+	const limit = 2
+	handler := RedisRateLimiter(redisCache, limit, time.Hour)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	codes := make([]int, 0, limit+1)
+	for i := 0; i < limit+1; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	// Локальный лимитер продолжает ограничивать запросы
+	if codes[0] != http.StatusOK || codes[limit] != http.StatusTooManyRequests {
+		t.Fatalf("codes = %v, want the local limit applied", codes)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import "sync/atomic"
+
+// RuntimeSettings хранит настройки middleware, которые можно заменить без перезапуска сервера:
+// лимиты запросов и разрешенные CORS origins. Запросы читают текущие значения атомарно
+type RuntimeSettings struct {
+	rateLimits  atomic.Pointer[TenantRateLimits]
+	corsOrigins atomic.Pointer[[]string]
+}
+
+// NewRuntimeSettings создает настройки с начальными значениями
+func NewRuntimeSettings(limits TenantRateLimits, corsOrigins []string) *RuntimeSettings {
+	s := &RuntimeSettings{}
+	s.SetRateLimits(limits)
+	s.SetCORSOrigins(corsOrigins)
+	return s
+}
+
+// RateLimits возвращает текущие лимиты запросов
+func (s *RuntimeSettings) RateLimits() TenantRateLimits {
+	return *s.rateLimits.Load()
+}
+
+// SetRateLimits заменяет лимиты запросов, новые лимиты действуют со следующего запроса
+func (s *RuntimeSettings) SetRateLimits(limits TenantRateLimits) {
+	s.rateLimits.Store(&limits)
+}
+
+// CORSOrigins возвращает текущий список разрешенных origins
+func (s *RuntimeSettings) CORSOrigins() []string {
+	return *s.corsOrigins.Load()
+}
+
+// SetCORSOrigins заменяет список разрешенных origins для CORS и проверки CSRF
+func (s *RuntimeSettings) SetCORSOrigins(origins []string) {
+	origins = append([]string(nil), origins...)
+	s.corsOrigins.Store(&origins)
+}
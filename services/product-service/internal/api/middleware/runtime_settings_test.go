@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSAppliesNewOrigins(t *testing.T) {
+	settings := NewRuntimeSettings(TenantRateLimits{}, []string{"https://shop.example.com"})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := CORS(settings)(ok)
+
+	allowed := func(origin string) bool {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin") == origin
+	}
+
+	if !allowed("https://shop.example.com") || allowed("https://admin.example.com") {
+		t.Fatal("initial origins not applied")
+	}
+
+	origins := []string{"https://admin.example.com"}
+	settings.SetCORSOrigins(origins)
+	// Настройки хранят свою копию списка
+	origins[0] = "https://evil.example.com"
+
+	if allowed("https://shop.example.com") || !allowed("https://admin.example.com") || allowed("https://evil.example.com") {
+		t.Fatal("origins not replaced by SetCORSOrigins")
+	}
+}
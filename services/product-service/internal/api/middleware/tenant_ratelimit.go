@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TenantRateLimits задает лимиты запросов за окно Window: Default применяется к тенантам
+// без индивидуального лимита и к неаутентифицированным клиентам, Tenants - лимиты отдельных тенантов
+type TenantRateLimits struct {
+	Default int
+	Window  time.Duration
+	Tenants map[string]int
+}
+
+// limitFor возвращает лимит тенанта. Ключи сравниваются без учета регистра, так как viper приводит их к нижнему
+func (l TenantRateLimits) limitFor(tenantID string) int {
+	if tenantID == "" {
+		return l.Default
+	}
+	for id, limit := range l.Tenants {
+		if strings.EqualFold(id, tenantID) {
+			return limit
+		}
+	}
+	return l.Default
+}
+
+// localWindowCounter считает запросы по ключам в фиксированных окнах в памяти процесса
+type localWindowCounter struct {
+	mu      sync.Mutex
+	windows map[string]int64
+	counts  map[string]int
+}
+
+func newLocalWindowCounter() *localWindowCounter {
+	return &localWindowCounter{
+		windows: make(map[string]int64),
+		counts:  make(map[string]int),
+	}
+}
+
+// increment увеличивает счетчик ключа в окне windowIndex. Счетчики других окон удаляются,
+// в том числе после смены длины окна, когда индексы окон несравнимы
+func (c *localWindowCounter) increment(key string, windowIndex int64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.windows[key] != windowIndex {
+		for k, index := range c.windows {
+			if index != windowIndex {
+				delete(c.windows, k)
+				delete(c.counts, k)
+			}
+		}
+		c.windows[key] = windowIndex
+	}
+	c.counts[key]++
+	return c.counts[key]
+}
+
+// TenantRateLimiter ограничивает количество запросов тенанта из контекста (tenant_id), а для
+// неаутентифицированных запросов - количество запросов с одного IP. Бакеты тенантов независимы,
+// поэтому тенант, исчерпавший лимит, не влияет на остальных, даже если они работают из-за одного NAT.
+// Счетчики хранятся во внешнем кэше, при его недоступности - в памяти процесса.
+// Лимиты читаются из settings на каждый запрос, поэтому их можно менять без перезапуска
+func TenantRateLimiter(cache interfaces.CachePort, settings *RuntimeSettings) func(http.Handler) http.Handler {
+	fallback := newLocalWindowCounter()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limits := settings.RateLimits()
+			window := limits.Window
+			if window <= 0 {
+				window = time.Minute
+			}
+
+			tenantID, _ := contextkeys.TenantFromContext(r.Context())
+			limit := limits.limitFor(tenantID)
+
+			bucket := "tenant:" + tenantID
+			if tenantID == "" {
+				ip := r.RemoteAddr
+				if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+					ip = host
+				}
+				bucket = "ip:" + ip
+			}
+
+			now := time.Now()
+			windowIndex := now.UnixNano() / int64(window)
+			key := fmt.Sprintf("ratelimit:%s:%d", bucket, windowIndex)
+
+			count, err := cache.Increment(r.Context(), key, window)
+			if err != nil {
+				count = int64(fallback.increment(bucket, windowIndex))
+			}
+
+			if count > int64(limit) {
+				windowEnd := time.Unix(0, (windowIndex+1)*int64(window))
+				retryAfter := int(windowEnd.Sub(now).Seconds()) + 1
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", retryAfter))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+)
+
+// unavailableCache кэш, все счетчики которого недоступны
+type unavailableCache struct {
+	interfaces.CachePort
+}
+
+func (c unavailableCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestTenantRateLimiter(t *testing.T) {
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+
+	tests := []struct {
+		name  string
+		cache interfaces.CachePort
+	}{
+		{name: "shared cache", cache: memoryCache},
+		{name: "local fallback", cache: unavailableCache{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := NewRuntimeSettings(TenantRateLimits{
+				Default: 2,
+				Window:  time.Hour,
+				Tenants: map[string]int{"Tenant-Big": 4},
+			}, nil)
+			ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+			limiter := TenantRateLimiter(tt.cache, settings)(ok)
+
+			// Все клиенты работают из-за одного NAT
+			request := func(tenantID string) int {
+				req := httptest.NewRequest(http.MethodGet, "/products", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				if tenantID != "" {
+					req = req.WithContext(contextkeys.WithTenant(req.Context(), tenantID))
+				}
+				rec := httptest.NewRecorder()
+				limiter.ServeHTTP(rec, req)
+				if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+					t.Fatal("429 without Retry-After")
+				}
+				return rec.Code
+			}
+			expect := func(tenantID string, want ...int) {
+				t.Helper()
+				for i, status := range want {
+					if got := request(tenantID); got != status {
+						t.Fatalf("tenant %q request %d: status = %d, want %d", tenantID, i+1, got, status)
+					}
+				}
+			}
+
+			expect("tenant-1", http.StatusOK, http.StatusOK, http.StatusTooManyRequests)
+			// Исчерпанный лимит tenant-1 не влияет на другие бакеты
+			expect("tenant-2", http.StatusOK, http.StatusOK, http.StatusTooManyRequests)
+			// Индивидуальный лимит из конфигурации, ключ без учета регистра
+			expect("tenant-big", http.StatusOK, http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests)
+			// Неаутентифицированные запросы ограничиваются по IP
+			expect("", http.StatusOK, http.StatusOK, http.StatusTooManyRequests)
+		})
+	}
+}
+
+func TestTenantRateLimiterAppliesNewLimits(t *testing.T) {
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	defer memoryCache.Close()
+
+	settings := NewRuntimeSettings(TenantRateLimits{Default: 1, Window: time.Hour}, nil)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	limiter := TenantRateLimiter(memoryCache, settings)(ok)
+
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req = req.WithContext(contextkeys.WithTenant(req.Context(), "tenant-1"))
+		rec := httptest.NewRecorder()
+		limiter.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if request() != http.StatusOK || request() != http.StatusTooManyRequests {
+		t.Fatal("default limit of 1 not enforced")
+	}
+	settings.SetRateLimits(TenantRateLimits{Default: 1, Window: time.Hour, Tenants: map[string]int{"tenant-1": 3}})
+	if status := request(); status != http.StatusOK {
+		t.Fatalf("status = %d after raising the limit, want 200", status)
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/lifecycle"
+)
+
+func TestTimeoutDropsLateWrites(t *testing.T) {
+	var inflight sync.WaitGroup
+	writeErr := make(chan error, 1)
+	handler := Timeout(20*time.Millisecond, &inflight)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Header().Set("X-Late", "true")
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write([]byte("late body"))
+		writeErr <- err
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+	inflight.Wait()
+
+	if err := <-writeErr; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Fatalf("late write error = %v, want http.ErrHandlerTimeout", err)
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+	if strings.Contains(rec.Body.String(), "late body") || rec.Header().Get("X-Late") != "" {
+		t.Fatalf("late response leaked: %q %v", rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeoutPassesResponse(t *testing.T) {
+	var inflight sync.WaitGroup
+	handler := Timeout(time.Second, &inflight)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("id\n"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+		}
+		w.Write([]byte("1\n"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/export", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "id\n1\n" || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("response = %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if !rec.Flushed {
+		t.Fatal("response was not flushed")
+	}
+}
+
+func TestShutdownWaitsForTimedOutHandler(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	var (
+		inflight sync.WaitGroup
+		mu       sync.Mutex
+		events   []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	release := make(chan struct{})
+	handler := Timeout(20*time.Millisecond, &inflight)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		<-release
+		record("handler finished")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/products", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+
+	drained := make(chan bool, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		drained <- lifecycle.Shutdown(ctx, log, &inflight,
+			lifecycle.Dependency{Name: "postgres", Close: func() error {
+				record("postgres closed")
+				return nil
+			}},
+		)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("Shutdown returned while the handler was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if !<-drained {
+		t.Fatal("Shutdown reported undrained work")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "handler finished" || events[1] != "postgres closed" {
+		t.Fatalf("events = %v, want handler to finish before dependencies close", events)
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newSpanRecorder устанавливает глобальный провайдер трассировки, записывающий спаны в память.
+// Вызывается до создания middleware, потому что Tracing берет трассировщик при создании
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	recorder := newSpanRecorder(t)
+
+	var contextTraceID string
+	router := chi.NewRouter()
+	router.Use(Tracing)
+	router.Get("/api/v1/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		contextTraceID, _ = contextkeys.TraceIDFromContext(r.Context())
+		if chi.URLParam(r, "id") == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	const (
+		parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID  = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantStatus  codes.Code
+	}{
+		{name: "new trace", path: "/api/v1/products/p1"},
+		{name: "incoming traceparent", path: "/api/v1/products/p2", traceparent: "00-" + parentTraceID + "-" + parentSpanID + "-01"},
+		{name: "server error", path: "/api/v1/products/broken", wantStatus: codes.Error},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Один серверный спан на запрос
+			spans := recorder.Ended()
+			if len(spans) != i+1 {
+				t.Fatalf("spans = %d, want %d", len(spans), i+1)
+			}
+			span := spans[i]
+			if span.Name() != "GET /api/v1/products/{id}" || span.SpanKind() != trace.SpanKindServer {
+				t.Fatalf("span = %q of kind %v, want a server span named by the route", span.Name(), span.SpanKind())
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Fatalf("span status = %v, want %v", span.Status().Code, tt.wantStatus)
+			}
+			if !hasAttribute(span.Attributes(), attribute.String("http.route", "/api/v1/products/{id}")) {
+				t.Fatalf("attributes = %v, want http.route", span.Attributes())
+			}
+
+			traceID := span.SpanContext().TraceID().String()
+			if rec.Header().Get("X-Trace-ID") != traceID || contextTraceID != traceID {
+				t.Fatalf("X-Trace-ID = %q, context trace = %q, want %q", rec.Header().Get("X-Trace-ID"), contextTraceID, traceID)
+			}
+
+			if tt.traceparent != "" {
+				if traceID != parentTraceID || span.Parent().SpanID().String() != parentSpanID || !span.Parent().IsRemote() {
+					t.Fatalf("trace = %s, parent = %s, want a child of the incoming span", traceID, span.Parent().SpanID())
+				}
+			} else if span.Parent().IsValid() {
+				t.Fatalf("parent = %v, want a root span", span.Parent())
+			}
+		})
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+// Package render формирует JSON-ответы API. Ошибки отдаются в едином формате ErrorResponse
+// со статусом, выбранным по виду доменной ошибки, поэтому обработчики не задают статус ошибки сами
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	chirender "github.com/go-chi/render"
+	"net/http"
+)
+
+// internalErrorMessage сообщение клиенту для внутренних ошибок без доменного описания
+const internalErrorMessage = "Внутренняя ошибка сервера"
+
+// ErrorResponse тело ответа с ошибкой
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+	// Details перечисляет ошибки валидации отдельных полей
+	Details []models.FieldError `json:"details,omitempty"`
+}
+
+// Status задает статус ответа, который запишет JSON
+func Status(r *http.Request, status int) {
+	chirender.Status(r, status)
+}
+
+// JSON записывает v в ответ в формате JSON
+func JSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	chirender.JSON(w, r, v)
+}
+
+// loggerKey ключ логгера запроса в контексте
+type loggerKey struct{}
+
+// WithLogger возвращает контекст с логгером, которым Error записывает внутренние ошибки
+func WithLogger(ctx context.Context, logger interfaces.LoggerPort) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Error отвечает на ошибку статусом по ее виду (см. ErrorStatus). Для доменной ошибки клиенту передаются
+// ее код и сообщение, для *models.ValidationError - ошибки полей. Внутренние ошибки записываются в лог
+// из контекста запроса, а клиент получает 500 без подробностей
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := ErrorStatus(err)
+	resp := ErrorResponse{
+		Error:   code,
+		Code:    status,
+		Message: internalErrorMessage,
+	}
+
+	var (
+		domainErr     *models.DomainError
+		validationErr *models.ValidationError
+		missingErr    *models.MissingFieldsError
+		maxBytesErr   *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		resp.Message = "Некорректные базовые данные продукта"
+		resp.Details = validationErr.Errors
+	case errors.As(err, &missingErr):
+		resp.Message = fmt.Sprintf("В base_data нет обязательных полей маркетплейса %d", missingErr.MarketplaceID)
+		for _, field := range missingErr.Fields {
+			resp.Details = append(resp.Details, models.FieldError{Field: field, Message: "обязательное поле"})
+		}
+	case errors.As(err, &maxBytesErr):
+		resp.Message = fmt.Sprintf("Размер тела запроса превышает %d байт", maxBytesErr.Limit)
+	case errors.As(err, &domainErr):
+		resp.Error = domainErr.Code
+		resp.Message = domainErr.Message
+	}
+
+	if status == http.StatusInternalServerError {
+		if logger, ok := r.Context().Value(loggerKey{}).(interfaces.LoggerPort); ok {
+			logger.ErrorWithContext(r.Context(), "Внутренняя ошибка при обработке запроса",
+				interfaces.LogField{Key: "method", Value: r.Method},
+				interfaces.LogField{Key: "path", Value: r.URL.Path},
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+		}
+	}
+
+	Status(r, status)
+	JSON(w, r, resp)
+}
+
+// ErrorStatus возвращает статус HTTP и код ошибки по умолчанию для вида ошибки: models.ErrNotFound - 404,
+// models.ErrValidation - 400 (*models.ValidationError и *models.MissingFieldsError - 422),
+// models.ErrConflict - 409, models.ErrUnprocessable - 422, models.ErrPreconditionRequired - 428,
+// models.ErrUnauthorized - 401, models.ErrTooLarge и *http.MaxBytesError - 413, models.ErrUnsupportedMediaType - 415. Прочие ошибки, включая models.ErrInternal, считаются внутренними
+func ErrorStatus(err error) (int, string) {
+	var (
+		validationErr *models.ValidationError
+		missingErr    *models.MissingFieldsError
+		maxBytesErr   *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity, "validation_error"
+	case errors.As(err, &missingErr):
+		return http.StatusUnprocessableEntity, "missing_required_fields"
+	case errors.As(err, &maxBytesErr), errors.Is(err, models.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, "request_too_large"
+	case errors.Is(err, models.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, models.ErrValidation):
+		return http.StatusBadRequest, "bad_request"
+	case errors.Is(err, models.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, models.ErrUnprocessable):
+		return http.StatusUnprocessableEntity, "unprocessable_entity"
+	case errors.Is(err, models.ErrPreconditionRequired):
+		return http.StatusPreconditionRequired, "precondition_required"
+	case errors.Is(err, models.ErrUnauthorized):
+		return http.StatusUnauthorized, "unauthorized"
+	case errors.Is(err, models.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType, "unsupported_media_type"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
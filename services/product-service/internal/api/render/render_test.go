@@ -0,0 +1,143 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+		wantDetails int
+	}{
+		{
+			name:        "not found",
+			err:         models.NewError(models.ErrNotFound, "not_found", "Продукт не найден"),
+			wantStatus:  http.StatusNotFound,
+			wantCode:    "not_found",
+			wantMessage: "Продукт не найден",
+		},
+		{
+			name:        "wrapped validation",
+			err:         fmt.Errorf("parse filters: %w", models.NewError(models.ErrValidation, "bad_request", "Некорректный фильтр")),
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "bad_request",
+			wantMessage: "Некорректный фильтр",
+		},
+		{
+			name:        "conflict",
+			err:         models.NewError(models.ErrConflict, "version_conflict", "Продукт был изменен"),
+			wantStatus:  http.StatusConflict,
+			wantCode:    "version_conflict",
+			wantMessage: "Продукт был изменен",
+		},
+		{
+			name:        "unprocessable",
+			err:         models.NewError(models.ErrUnprocessable, "idempotency_key_reused", "Ключ уже использован"),
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCode:    "idempotency_key_reused",
+			wantMessage: "Ключ уже использован",
+		},
+		{
+			name:        "precondition required",
+			err:         models.NewError(models.ErrPreconditionRequired, "version_required", "Укажите версию"),
+			wantStatus:  http.StatusPreconditionRequired,
+			wantCode:    "version_required",
+			wantMessage: "Укажите версию",
+		},
+		{
+			name:        "unauthorized",
+			err:         models.NewError(models.ErrUnauthorized, "invalid_credentials", "Неверный пароль"),
+			wantStatus:  http.StatusUnauthorized,
+			wantCode:    "invalid_credentials",
+			wantMessage: "Неверный пароль",
+		},
+		{
+			name:        "internal with cause",
+			err:         fmt.Errorf("%w: %w", models.NewError(models.ErrInternal, "internal_error", "Ошибка выпуска токена"), errors.New("redis is down")),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "internal_error",
+			wantMessage: "Ошибка выпуска токена",
+		},
+		{
+			name:        "too large",
+			err:         models.NewError(models.ErrTooLarge, "request_too_large", "Файл слишком большой"),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    "request_too_large",
+			wantMessage: "Файл слишком большой",
+		},
+		{
+			name:        "body limit",
+			err:         fmt.Errorf("decode body: %w", &http.MaxBytesError{Limit: 1024}),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    "request_too_large",
+			wantMessage: "Размер тела запроса превышает 1024 байт",
+		},
+		{
+			name:        "unsupported media type",
+			err:         models.NewError(models.ErrUnsupportedMediaType, "unsupported_media_type", "Недопустимый тип файла"),
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    "unsupported_media_type",
+			wantMessage: "Недопустимый тип файла",
+		},
+		{
+			name: "field validation",
+			err: &models.ValidationError{Errors: []models.FieldError{
+				{Field: "name", Message: "обязательное поле"},
+				{Field: "price", Message: "должно быть неотрицательным"},
+			}},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCode:    "validation_error",
+			wantMessage: "Некорректные базовые данные продукта",
+			wantDetails: 2,
+		},
+		{
+			name:        "missing marketplace fields",
+			err:         fmt.Errorf("sync: %w", &models.MissingFieldsError{MarketplaceID: 7, Fields: []string{"brand"}}),
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCode:    "missing_required_fields",
+			wantMessage: "В base_data нет обязательных полей маркетплейса 7",
+			wantDetails: 1,
+		},
+		{
+			name:        "unclassified",
+			err:         errors.New("connection refused"),
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "internal_error",
+			wantMessage: internalErrorMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			rec := httptest.NewRecorder()
+
+			Error(rec, req, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Code != tt.wantStatus || resp.Error != tt.wantCode || resp.Message != tt.wantMessage {
+				t.Fatalf("response = %+v, want code %d, error %q, message %q", resp, tt.wantStatus, tt.wantCode, tt.wantMessage)
+			}
+			if len(resp.Details) != tt.wantDetails {
+				t.Fatalf("details = %v, want %d entries", resp.Details, tt.wantDetails)
+			}
+		})
+	}
+}
@@ -8,30 +8,44 @@ import (
 	"github.com/athebyme/gomarket-platform/product-service/internal/security"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
+	"sync"
 	"time"
 )
 
-// SetupRouter настраивает маршрутизатор
+// SetupRouter настраивает маршрутизатор. Обработчики запросов учитываются в inflight,
+// чтобы при завершении процесса дождаться и тех, что продолжают работу после ответа по таймауту.
+// Если задан keycloakClient, токены /api/v1 проверяются Keycloak, иначе - jwtManager
 func SetupRouter(
 	productService services.ProductServiceInterface,
 	logger interfaces.LoggerPort,
-	corsAllowedOrigins []string,
+	rateLimitCache interfaces.CachePort,
+	settings *middleware.RuntimeSettings,
+	bodyLimit int64,
+	mediaMaxSize int64,
 	jwtManager *security.JWTManager,
+	keycloakClient *security.KeycloakClient,
+	authService security.AuthServiceInterface,
+	refreshTokens *security.RefreshTokenService,
+	blacklist *security.TokenBlacklist,
+	readiness *handlers.ReadinessHandler,
+	inflight *sync.WaitGroup,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Глобальные middleware
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.Metrics)
+	r.Use(middleware.Tracing)
 	r.Use(middleware.Logger(logger))
 	r.Use(middleware.Recoverer(logger))
-	r.Use(middleware.Timeout(30 * time.Second))
-	r.Use(middleware.CORS(corsAllowedOrigins))
-	r.Use(middleware.Tracing)
+	r.Use(middleware.Timeout(30*time.Second, inflight))
+	r.Use(middleware.CORS(settings))
 	r.Use(middleware.SecurityHeaders)
-	r.Use(middleware.RateLimiter(1000, time.Minute))
+	r.Use(middleware.BodyLimit(bodyLimit))
 
 	r.Method(http.MethodGet, "/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -41,39 +55,125 @@ func SetupRouter(
 		w.WriteHeader(http.StatusOK)
 	}))
 
+	// Проверка зависимостей для readiness-пробы, /health остается дешевой liveness-пробой
+	r.Method(http.MethodGet, "/readiness", readiness)
+
+	r.Handle("/metrics", promhttp.Handler())
+
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	))
 
+	// Выдача токена доступна без аутентификации, число попыток ограничено по IP
+	authHandler := handlers.NewAuthHandler(authService, jwtManager, refreshTokens, blacklist, logger)
+	r.With(middleware.RedisRateLimiter(rateLimitCache, 20, time.Minute)).Post("/api/v1/auth/login", authHandler.Login)
+	r.With(middleware.RedisRateLimiter(rateLimitCache, 20, time.Minute)).Post("/api/v1/auth/refresh", authHandler.Refresh)
+
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(middleware.JWTAuth(jwtManager, logger))
-		r.Use(middleware.CSRF) // Защита от CSRF
+		if keycloakClient != nil {
+			r.Use(middleware.KeycloakAuth(keycloakClient, logger))
+		} else {
+			r.Use(middleware.JWTAuth(jwtManager, blacklist, logger))
+		}
+		// Лимит применяется после аутентификации, чтобы у каждого тенанта был свой бакет
+		r.Use(middleware.TenantRateLimiter(rateLimitCache, settings))
+		r.Use(middleware.Supplier)
+		r.Use(middleware.CSRF(settings)) // Защита от CSRF
+		// Повтор ответа для запросов с заголовком Idempotency-Key
+		r.Use(middleware.Idempotency(rateLimitCache, 24*time.Hour))
+
+		productHandler := handlers.NewProductHandler(productService, logger, mediaMaxSize)
 
-		productHandler := handlers.NewProductHandler(productService, logger)
+		// Отзыв текущего токена доступа
+		r.Post("/auth/logout", authHandler.Logout)
 
 		// Маршруты для продуктов
 		r.Route("/products", func(r chi.Router) {
 			// Получение списка продуктов
-			r.With(middleware.HasPermission("products:read")).Get("/", productHandler.ListProducts)
+			r.With(middleware.RequireProductPermission("read")).Get("/", productHandler.ListProducts)
+
+			// Схема допустимых фильтров и сортировок
+			r.With(middleware.RequireProductPermission("read")).Get("/schema", productHandler.GetProductSchema)
+
+			// Число продуктов с учетом фильтров списка
+			r.With(middleware.RequireProductPermission("read")).Get("/count", productHandler.CountProducts)
+
+			// Число продуктов по значениям атрибутов с учетом фильтров
+			r.With(middleware.RequireProductPermission("read")).Get("/facets", productHandler.AggregateFacets)
 
 			// Создание продукта
-			r.With(middleware.HasPermission("products:create")).Post("/", productHandler.CreateProduct)
+			r.With(middleware.RequireProductPermission("create")).Post("/", productHandler.CreateProduct)
+
+			// Экспорт продуктов тенанта в CSV или JSON
+			r.With(middleware.RequireProductPermission("read")).Get("/export", productHandler.ExportProducts)
+
+			// Импорт продуктов поставщика из CSV
+			r.With(middleware.RequireProductPermission("create")).Post("/import", productHandler.ImportProducts)
+
+			// Создание или обновление продукта поставщика по SKU
+			r.With(middleware.RequireProductPermission("update")).Put("/by-sku/{sku}", productHandler.UpsertProductBySKU)
 
 			// Операции с конкретным продуктом
 			r.Route("/{id}", func(r chi.Router) {
 				// Получение продукта по ID
-				r.With(middleware.HasPermission("products:read")).Get("/", productHandler.GetProduct)
+				r.With(middleware.RequireProductPermission("read")).Get("/", productHandler.GetProduct)
+
+				// Агрегат продукта с ценой, остатками и медиа
+				r.With(middleware.RequireProductPermission("read")).Get("/details", productHandler.GetProductDetails)
 
 				// Обновление продукта
-				r.With(middleware.HasPermission("products:update")).Put("/", productHandler.UpdateProduct)
+				r.With(middleware.RequireProductPermission("update")).Put("/", productHandler.UpdateProduct)
+
+				// Частичное обновление base_data (JSON Merge Patch)
+				r.With(middleware.RequireProductPermission("update")).Patch("/", productHandler.PatchProduct)
 
 				// Удаление продукта
-				r.With(middleware.HasPermission("products:delete")).Delete("/", productHandler.DeleteProduct)
+				r.With(middleware.RequireProductPermission("delete")).Delete("/", productHandler.DeleteProduct)
+
+				// Цена продукта
+				r.With(middleware.RequireProductPermission("read")).Get("/price", productHandler.GetPrice)
+				r.With(middleware.RequireProductPermission("update")).Put("/price", productHandler.UpdatePrice)
+				r.With(middleware.RequireProductPermission("read")).Get("/price/history", productHandler.GetPriceHistory)
+
+				// Остатки продукта
+				r.With(middleware.RequireProductPermission("read")).Get("/inventory", productHandler.GetInventory)
+				r.With(middleware.RequireProductPermission("update")).Put("/inventory", productHandler.UpdateInventory)
+				r.With(middleware.RequireProductPermission("update")).Post("/inventory/adjust", productHandler.AdjustInventory)
+				r.With(middleware.RequireProductPermission("update")).Post("/inventory/reservations", productHandler.ReserveInventory)
+				r.With(middleware.RequireProductPermission("update")).Delete("/inventory/reservations/{reservationID}", productHandler.ReleaseReservation)
+
+				// История изменений продукта
+				r.With(middleware.RequireProductPermission("read")).Get("/history", productHandler.GetProductHistory)
+
+				// Медиафайлы продукта
+				r.With(middleware.RequireProductPermission("update")).Post("/media", productHandler.UploadMedia)
+				r.With(middleware.RequireProductPermission("update")).Delete("/media/{mediaID}", productHandler.DeleteMedia)
 
 				// Синхронизация продукта с маркетплейсом
-				r.With(middleware.HasPermission("products:sync")).Post("/sync", productHandler.SyncProductToMarketplace)
+				r.With(middleware.RequireProductPermission("sync")).Post("/sync", productHandler.SyncProductToMarketplace)
+				r.With(middleware.RequireProductPermission("read")).Get("/marketplaces", productHandler.GetMarketplaceStatuses)
 			})
 		})
+
+		// Продукты категории, включая подкатегории
+		r.With(middleware.RequireProductPermission("read")).Get("/categories/{category_id}/products", productHandler.GetProductsByCategory)
+
+		// Поставщики, от которых у тенанта есть продукты
+		r.With(middleware.RequireProductPermission("read")).Get("/suppliers", productHandler.ListSuppliers)
+
+		// Настройки маркетплейсов
+		r.Route("/marketplaces/{marketplace_id}", func(r chi.Router) {
+			r.With(middleware.HasPermission("marketplaces:read")).Get("/mapping", productHandler.GetMarketplaceMapping)
+			r.With(middleware.HasPermission("marketplaces:update")).Put("/mapping", productHandler.SaveMarketplaceMapping)
+		})
+
+		// Административные операции
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(middleware.HasRole("admin"))
+
+			r.Post("/products:recache", productHandler.RecacheProducts)
+			r.Post("/cache:warm", productHandler.WarmCache)
+		})
 	})
 
 	return r
@@ -0,0 +1,53 @@
+package models
+
+import "errors"
+
+// Виды доменных ошибок. Обработчики HTTP сопоставляют вид со статусом ответа,
+// поэтому сервисы сообщают о причине отказа видом ошибки, а не кодом статуса
+var (
+	// ErrNotFound запрошенный объект не существует или недоступен тенанту (404)
+	ErrNotFound = errors.New("not found")
+	// ErrValidation запрос некорректен и не станет корректным при повторе (400)
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict запрос противоречит текущему состоянию: версия, остатки, уже выполняемая операция (409)
+	ErrConflict = errors.New("conflict")
+	// ErrUnprocessable запрос корректен по форме, но не может быть выполнен, например ключ
+	// идемпотентности уже использован с другим запросом (422)
+	ErrUnprocessable = errors.New("unprocessable")
+	// ErrPreconditionRequired запрос изменяет объект без обязательного условия, например без версии (428)
+	ErrPreconditionRequired = errors.New("precondition required")
+	// ErrUnauthorized вызывающий не аутентифицирован (401)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrInternal внутренняя ошибка, подробности которой не передаются клиенту (500)
+	ErrInternal = errors.New("internal error")
+	// ErrTooLarge тело запроса или загружаемый файл превышают допустимый размер (413)
+	ErrTooLarge = errors.New("too large")
+	// ErrUnsupportedMediaType формат тела запроса или загружаемого файла не поддерживается (415)
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// DomainError доменная ошибка с видом, машиночитаемым кодом и сообщением для клиента.
+// Объявляется как sentinel и оборачивается через fmt.Errorf("...: %w", err): errors.Is находит
+// и саму ошибку, и ее вид (errors.Is(err, ErrNotFound))
+type DomainError struct {
+	// Kind вид ошибки: ErrNotFound, ErrValidation, ErrConflict, ErrUnauthorized, ErrInternal и т.д.
+	Kind error
+	// Code машиночитаемый код, передается клиенту в поле error
+	Code string
+	// Message сообщение для клиента
+	Message string
+}
+
+// NewError создает доменную ошибку вида kind
+func NewError(kind error, code, message string) *DomainError {
+	return &DomainError{Kind: kind, Code: code, Message: message}
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+// Unwrap возвращает вид ошибки
+func (e *DomainError) Unwrap() error {
+	return e.Kind
+}
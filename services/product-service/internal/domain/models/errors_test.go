@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// kinds все виды доменных ошибок
+var kinds = []error{
+	ErrNotFound,
+	ErrValidation,
+	ErrConflict,
+	ErrUnprocessable,
+	ErrPreconditionRequired,
+	ErrUnauthorized,
+	ErrInternal,
+	ErrTooLarge,
+	ErrUnsupportedMediaType,
+}
+
+func TestDomainErrorIsItsKind(t *testing.T) {
+	for _, kind := range kinds {
+		t.Run(kind.Error(), func(t *testing.T) {
+			sentinel := NewError(kind, "code", "message")
+			wrapped := fmt.Errorf("service: %w", fmt.Errorf("storage: %w", sentinel))
+
+			if !errors.Is(wrapped, sentinel) || !errors.Is(wrapped, kind) {
+				t.Fatalf("errors.Is(%v) does not find the sentinel and its kind", wrapped)
+			}
+
+			// Вид определяется однозначно
+			for _, other := range kinds {
+				if other != kind && errors.Is(wrapped, other) {
+					t.Fatalf("%v also matches kind %v", kind, other)
+				}
+			}
+
+			var domainErr *DomainError
+			if !errors.As(wrapped, &domainErr) || domainErr.Code != "code" || domainErr.Error() != "message" {
+				t.Fatalf("errors.As = %+v, want the domain error", domainErr)
+			}
+		})
+	}
+}
+
+func TestDomainErrorSentinelsAreDistinct(t *testing.T) {
+	notFound := NewError(ErrNotFound, "not_found", "Продукт не найден")
+	otherNotFound := NewError(ErrNotFound, "not_found", "Продукт не найден")
+
+	// Ошибки одного вида сравниваются по идентичности, а не по содержимому
+	if errors.Is(notFound, otherNotFound) {
+		t.Fatal("distinct sentinels of the same kind match each other")
+	}
+	if !errors.Is(otherNotFound, ErrNotFound) {
+		t.Fatal("sentinel does not match its kind")
+	}
+}
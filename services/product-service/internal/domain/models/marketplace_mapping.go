@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FieldMappingRule описывает соответствие ключа base_data ключу в payload маркетплейса
+type FieldMappingRule struct {
+	Source   string `json:"source"`   // ключ в base_data
+	Target   string `json:"target"`   // ключ в payload маркетплейса
+	Required bool   `json:"required"` // обязательное ли поле для маркетплейса
+}
+
+// MarketplaceFieldMapping хранит настройку маппинга полей для маркетплейса в рамках арендатора
+type MarketplaceFieldMapping struct {
+	TenantID      string             `json:"tenant_id"`
+	MarketplaceID int                `json:"marketplace_id"`
+	Fields        []FieldMappingRule `json:"fields"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// MissingFieldsError возвращается, если в base_data отсутствуют обязательные для маркетплейса поля
+type MissingFieldsError struct {
+	MarketplaceID int      `json:"marketplace_id"`
+	Fields        []string `json:"fields"`
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("marketplace %d: missing required fields: %s", e.MarketplaceID, strings.Join(e.Fields, ", "))
+}
+
+// Validate проверяет корректность самой настройки маппинга
+func (m *MarketplaceFieldMapping) Validate() error {
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("mapping must contain at least one field")
+	}
+
+	targets := make(map[string]struct{}, len(m.Fields))
+	for i, rule := range m.Fields {
+		if rule.Source == "" || rule.Target == "" {
+			return fmt.Errorf("field %d: source and target cannot be empty", i)
+		}
+		if _, exists := targets[rule.Target]; exists {
+			return fmt.Errorf("field %d: duplicate target %q", i, rule.Target)
+		}
+		targets[rule.Target] = struct{}{}
+	}
+
+	return nil
+}
+
+// Apply преобразует base_data продукта в payload маркетплейса.
+// Если отсутствуют обязательные поля, возвращает *MissingFieldsError со списком всех пропущенных ключей.
+func (m *MarketplaceFieldMapping) Apply(baseData json.RawMessage) (map[string]interface{}, error) {
+	var source map[string]interface{}
+	if err := json.Unmarshal(baseData, &source); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal base_data: %w", err)
+	}
+
+	payload := make(map[string]interface{}, len(m.Fields))
+	var missing []string
+
+	for _, rule := range m.Fields {
+		value, ok := source[rule.Source]
+		if !ok || value == nil || value == "" {
+			if rule.Required {
+				missing = append(missing, rule.Source)
+			}
+			continue
+		}
+		payload[rule.Target] = value
+	}
+
+	if len(missing) > 0 {
+		return nil, &MissingFieldsError{MarketplaceID: m.MarketplaceID, Fields: missing}
+	}
+
+	return payload, nil
+}
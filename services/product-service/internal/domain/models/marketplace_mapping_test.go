@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testMapping() *MarketplaceFieldMapping {
+	return &MarketplaceFieldMapping{
+		TenantID:      "tenant-1",
+		MarketplaceID: 7,
+		Fields: []FieldMappingRule{
+			{Source: "name", Target: "title", Required: true},
+			{Source: "price", Target: "price_rub", Required: true},
+			{Source: "brand", Target: "vendor"},
+		},
+	}
+}
+
+func TestMarketplaceFieldMappingApply(t *testing.T) {
+	payload, err := testMapping().Apply(json.RawMessage(`{"name":"Apple juice","price":100,"brand":"Garden","sku":"AJ-1"}`))
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	want := map[string]interface{}{"title": "Apple juice", "price_rub": float64(100), "vendor": "Garden"}
+	if !reflect.DeepEqual(payload, want) {
+		t.Fatalf("payload = %v, want %v", payload, want)
+	}
+}
+
+func TestMarketplaceFieldMappingApplyMissingRequired(t *testing.T) {
+	// Необязательное brand отсутствует, обязательное name пустое, price нет совсем
+	_, err := testMapping().Apply(json.RawMessage(`{"name":""}`))
+
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) {
+		t.Fatalf("err = %v, want *MissingFieldsError", err)
+	}
+	if missing.MarketplaceID != 7 || !reflect.DeepEqual(missing.Fields, []string{"name", "price"}) {
+		t.Fatalf("missing = %+v, want name and price of marketplace 7", missing)
+	}
+}
+
+func TestMarketplaceFieldMappingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []FieldMappingRule
+		wantErr bool
+	}{
+		{name: "valid", fields: testMapping().Fields},
+		{name: "empty", fields: nil, wantErr: true},
+		{name: "no target", fields: []FieldMappingRule{{Source: "name"}}, wantErr: true},
+		{name: "duplicate target", fields: []FieldMappingRule{
+			{Source: "name", Target: "title"},
+			{Source: "short_name", Target: "title"},
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := &MarketplaceFieldMapping{MarketplaceID: 7, Fields: tt.fields}
+			if err := mapping.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
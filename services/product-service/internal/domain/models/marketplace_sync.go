@@ -0,0 +1,8 @@
+package models
+
+// Статусы синхронизации продукта с маркетплейсом
+const (
+	MarketplaceStatusPending = "pending" // отправка поставлена в очередь или ожидает модерации
+	MarketplaceStatusActive  = "active"  // продукт опубликован
+	MarketplaceStatusError   = "error"   // отправка не удалась, причина в StatusMessage
+)
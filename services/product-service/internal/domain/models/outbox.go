@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage событие, записанное в outbox в одной транзакции с изменением данных.
+// Публикуется в Topic фоновым relay в порядке возрастания ID.
+type OutboxMessage struct {
+	ID          int64           `json:"id"`
+	TenantID    string          `json:"tenant_id"`
+	AggregateID string          `json:"aggregate_id"`
+	Topic       string          `json:"topic"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	TraceParent string          `json:"trace_parent,omitempty"` // W3C traceparent запроса, породившего событие
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -15,26 +16,90 @@ type Product struct {
 	Metadata  json.RawMessage `db:"metadata" json:"metadata,omitempty"`
 	CreatedAt time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+	// Version увеличивается при каждом сохранении и используется для оптимистичной блокировки
+	Version int `db:"version" json:"version"`
+	// PrimaryImageURL адрес изображения продукта с наименьшей позицией. Не хранится и заполняется
+	// только по запросу (include=primary_image)
+	PrimaryImageURL string `db:"-" json:"primary_image_url,omitempty"`
+}
+
+// Validate проверяет обязательные поля продукта: поставщик задан, а base_data является JSON-объектом
+func (p *Product) Validate() error {
+	if p.SupplierID == "" {
+		return fmt.Errorf("supplier_id is required")
+	}
+	if len(p.BaseData) == 0 {
+		return fmt.Errorf("base_data is required")
+	}
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(p.BaseData, &baseData); err != nil {
+		return fmt.Errorf("base_data must be a JSON object")
+	}
+	return nil
 }
 
 // ProductInventory представляет собой модель описания остатков товара
 type ProductInventory struct {
-	ProductID  string    `json:"product_id"`
-	SupplierID int       `json:"supplier_id"`
-	Quantity   int       `json:"quantity"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ProductID  string `json:"product_id"`
+	SupplierID string `json:"supplier_id"`
+	Quantity   int    `json:"quantity"`
+	// Reserved количество, удерживаемое неистекшими резервами
+	Reserved int `json:"reserved"`
+	// Available количество, доступное для новых резервов: Quantity - Reserved
+	Available int       `json:"available"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InventoryReservation временно удерживает часть остатков продукта до ExpiresAt
+type InventoryReservation struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"product_id"`
+	TenantID  string    `json:"tenant_id"`
+	Quantity  int       `json:"quantity"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // ProductPrice представляет собой модель цен для товаров
 type ProductPrice struct {
 	ProductID    string    `json:"product_id"`
-	SupplierID   int       `json:"supplier_id"`
+	SupplierID   string    `json:"supplier_id"`
 	BasePrice    float64   `json:"base_price"`
 	SpecialPrice float64   `json:"special_price,omitempty"`
 	Currency     string    `json:"currency"`
 	StartDate    time.Time `json:"start_date,omitempty"`
 	EndDate      time.Time `json:"end_date,omitempty"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	// Conversion заполняется, если цена пересчитана в другую валюту по запросу клиента
+	Conversion *PriceConversion `json:"conversion,omitempty"`
+}
+
+// PriceConversion описывает пересчет цены из валюты, в которой она сохранена
+type PriceConversion struct {
+	SourceCurrency string    `json:"source_currency"`
+	Rate           float64   `json:"rate"`
+	RateTimestamp  time.Time `json:"rate_timestamp"`
+}
+
+// Validate проверяет корректность цены: базовая цена положительна, специальная не превышает базовую,
+// валюта задана трехбуквенным кодом, а период действия, если задан полностью, не пуст
+func (p *ProductPrice) Validate() error {
+	if p.BasePrice <= 0 {
+		return fmt.Errorf("base_price must be greater than zero")
+	}
+	if p.SpecialPrice < 0 {
+		return fmt.Errorf("special_price cannot be negative")
+	}
+	if p.SpecialPrice > p.BasePrice {
+		return fmt.Errorf("special_price cannot be greater than base_price")
+	}
+	if len(p.Currency) != 3 {
+		return fmt.Errorf("currency must be a 3-letter code")
+	}
+	if !p.StartDate.IsZero() && !p.EndDate.IsZero() && !p.StartDate.Before(p.EndDate) {
+		return fmt.Errorf("start_date must be before end_date")
+	}
+	return nil
 }
 
 // ProductMedia представляет собой модель медиа-файлов товара
@@ -43,12 +108,61 @@ type ProductMedia struct {
 	ProductID string    `json:"product_id"`
 	Type      string    `json:"type"` // "image", "video", etc.
 	URL       string    `json:"url"`
+	ObjectKey string    `json:"-"` // ключ объекта в хранилище, пустой для внешних ссылок
 	Position  int       `json:"position"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Variant и ParentID заполнены у производных файлов (миниатюр) и связывают их с оригиналом
+	Variant  string `json:"variant,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+
+	// Variants возвращается при загрузке и не хранится в строке оригинала
+	Variants []*ProductMedia `json:"variants,omitempty"`
+}
+
+// ThumbnailSizes задает размеры (по большей стороне, в пикселях) миниатюр загружаемых изображений
+var ThumbnailSizes = []int{128, 512}
+
+// ThumbnailVariant возвращает имя варианта миниатюры заданного размера
+func ThumbnailVariant(size int) string {
+	return fmt.Sprintf("thumb_%d", size)
+}
+
+// Типы медиафайлов
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
+// mediaContentTypes задает допустимые для загрузки MIME-типы и расширения файлов
+var mediaContentTypes = map[string]struct {
+	mediaType string
+	extension string
+}{
+	"image/jpeg": {MediaTypeImage, ".jpg"},
+	"image/png":  {MediaTypeImage, ".png"},
+	"image/webp": {MediaTypeImage, ".webp"},
+	"image/gif":  {MediaTypeImage, ".gif"},
+	"video/mp4":  {MediaTypeVideo, ".mp4"},
+	"video/webm": {MediaTypeVideo, ".webm"},
+}
+
+// MediaTypeByContentType возвращает тип медиа и расширение файла для MIME-типа.
+// ok равен false, если загрузка такого типа не разрешена
+func MediaTypeByContentType(contentType string) (mediaType, extension string, ok bool) {
+	t, ok := mediaContentTypes[contentType]
+	return t.mediaType, t.extension, ok
 }
 
 // ---------------------------- KAFKA MODELS ----------------------------
 
+// Типы изменений в истории продукта
+const (
+	HistoryChangeCreate = "create"
+	HistoryChangeUpdate = "update"
+	HistoryChangeDelete = "delete"
+)
+
 // ProductHistoryRecord представляет собой записи в истории изменений продукта для Kafka
 type ProductHistoryRecord struct {
 	ID            string   `json:"id"`
@@ -60,3 +174,16 @@ type ProductHistoryRecord struct {
 	ChangedAt     int64    `json:"changed_at"`
 	ChangeComment string   `json:"change_comment,omitempty"`
 }
+
+// PriceHistoryRecord представляет сохраненное ранее состояние цены продукта
+type PriceHistoryRecord struct {
+	ID           string    `json:"id"`
+	ProductID    string    `json:"product_id"`
+	SupplierID   string    `json:"supplier_id"`
+	BasePrice    float64   `json:"base_price"`
+	SpecialPrice float64   `json:"special_price,omitempty"`
+	Currency     string    `json:"currency"`
+	StartDate    time.Time `json:"start_date,omitempty"`
+	EndDate      time.Time `json:"end_date,omitempty"`
+	ChangedAt    time.Time `json:"changed_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// BatchItemError ошибка обработки отдельного элемента пакетной операции.
+// Index соответствует позиции элемента во входном списке.
+type BatchItemError struct {
+	Index     int    `json:"index"`
+	ProductID string `json:"product_id,omitempty"`
+	Error     string `json:"error"`
+}
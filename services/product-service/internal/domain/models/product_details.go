@@ -0,0 +1,18 @@
+package models
+
+// Секции агрегата ProductDetails, загружаемые по возможности
+const (
+	DetailsSectionMedia = "media"
+)
+
+// ProductDetails агрегированное представление продукта: данные, цена, остатки и медиа.
+// Product, Price и Inventory - обязательные секции, ошибка их загрузки прерывает запрос.
+// Media загружается по возможности: при ошибке секция возвращается как null,
+// а причина указывается в SectionErrors.
+type ProductDetails struct {
+	Product       *Product          `json:"product"`
+	Price         *ProductPrice     `json:"price"`
+	Inventory     *ProductInventory `json:"inventory"`
+	Media         []*ProductMedia   `json:"media"`
+	SectionErrors map[string]string `json:"section_errors,omitempty"`
+}
@@ -4,7 +4,7 @@ package models
 type ProductFilter struct {
 	// Основные поля фильтрации
 	ID          string   `json:"id,omitempty"`
-	SupplierID  int      `json:"supplier_id,omitempty"`
+	SupplierID  string   `json:"supplier_id,omitempty"`
 	Name        string   `json:"name,omitempty"`
 	Description string   `json:"description,omitempty"`
 	CategoryID  string   `json:"category_id,omitempty"`
@@ -46,7 +46,7 @@ func (f *ProductFilter) ToMap() map[string]interface{} {
 		result["id"] = f.ID
 	}
 
-	if f.SupplierID != 0 {
+	if f.SupplierID != "" {
 		result["supplier_id"] = f.SupplierID
 	}
 
@@ -111,12 +111,12 @@ func (f *ProductFilter) ToMap() map[string]interface{} {
 	}
 
 	if f.SearchQuery != "" {
-		result["search_query"] = f.SearchQuery
+		result[SearchQueryFilter] = f.SearchQuery
 	}
 
 	if f.Attributes != nil && len(f.Attributes) > 0 {
 		for key, value := range f.Attributes {
-			result["attr_"+key] = value
+			result[AttributeFilterPrefix+key] = value
 		}
 	}
 
@@ -0,0 +1,27 @@
+package models
+
+// ImportRowError ошибка импорта отдельной строки CSV. Line соответствует номеру строки в файле
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportSummary итог импорта продуктов: число созданных, обновленных и пропущенных строк
+type ImportSummary struct {
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors,omitempty"`
+}
+
+// MaxImportErrors ограничивает число ошибок в итоге, остальные пропущенные строки только считаются
+const MaxImportErrors = 1000
+
+// AddError учитывает пропущенную строку
+func (s *ImportSummary) AddError(line int, sku, message string) {
+	s.Failed++
+	if len(s.Errors) < MaxImportErrors {
+		s.Errors = append(s.Errors, ImportRowError{Line: line, SKU: sku, Error: message})
+	}
+}
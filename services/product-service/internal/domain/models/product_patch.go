@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ErrInvalidMergePatch возвращается, если тело merge patch не является JSON-объектом
+var ErrInvalidMergePatch = NewError(ErrValidation, "validation_error", "Тело merge patch должно быть JSON-объектом")
+
+// MergePatch применяет JSON Merge Patch (RFC 7386) к объекту target.
+// Ключи со значением null удаляются, вложенные объекты сливаются рекурсивно,
+// остальные значения, включая массивы, заменяются целиком. Ключи, отсутствующие в patch, сохраняются
+func MergePatch(target, patch json.RawMessage) (json.RawMessage, error) {
+	var patchObject map[string]interface{}
+	if err := json.Unmarshal(patch, &patchObject); err != nil || patchObject == nil {
+		return nil, ErrInvalidMergePatch
+	}
+
+	var targetObject map[string]interface{}
+	if len(target) > 0 {
+		if err := json.Unmarshal(target, &targetObject); err != nil {
+			return nil, fmt.Errorf("target must be a JSON object: %w", err)
+		}
+	}
+
+	merged, err := json.Marshal(mergeObjects(targetObject, patchObject))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged object: %w", err)
+	}
+	return merged, nil
+}
+
+// mergeObjects сливает patch в target по правилам RFC 7386
+func mergeObjects(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{}, len(patch))
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		patchChild, ok := value.(map[string]interface{})
+		if !ok {
+			target[key] = value
+			continue
+		}
+		targetChild, _ := target[key].(map[string]interface{})
+		target[key] = mergeObjects(targetChild, patchChild)
+	}
+
+	return target
+}
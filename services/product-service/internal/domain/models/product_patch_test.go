@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	const target = `{"name":"Apple juice","price":100,"images":["a.jpg","b.jpg"],"attributes":{"volume":"1L","color":"green"}}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{name: "unrelated fields survive", patch: `{"price":120}`,
+			want: `{"name":"Apple juice","price":120,"images":["a.jpg","b.jpg"],"attributes":{"volume":"1L","color":"green"}}`},
+		{name: "null deletes key", patch: `{"images":null,"missing":null}`,
+			want: `{"name":"Apple juice","price":100,"attributes":{"volume":"1L","color":"green"}}`},
+		{name: "nested objects merge", patch: `{"attributes":{"color":null,"sugar":"none"}}`,
+			want: `{"name":"Apple juice","price":100,"images":["a.jpg","b.jpg"],"attributes":{"volume":"1L","sugar":"none"}}`},
+		{name: "arrays are replaced", patch: `{"images":["c.jpg"]}`,
+			want: `{"name":"Apple juice","price":100,"images":["c.jpg"],"attributes":{"volume":"1L","color":"green"}}`},
+		{name: "object replaces scalar", patch: `{"price":{"amount":100}}`,
+			want: `{"name":"Apple juice","price":{"amount":100},"images":["a.jpg","b.jpg"],"attributes":{"volume":"1L","color":"green"}}`},
+		{name: "empty patch", patch: `{}`, want: target},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergePatch(json.RawMessage(target), json.RawMessage(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(merged, &got); err != nil {
+				t.Fatalf("unmarshal merged: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("unmarshal want: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("merged = %s, want %s", merged, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchEmptyTarget(t *testing.T) {
+	merged, err := MergePatch(nil, json.RawMessage(`{"name":"Apple juice","price":null}`))
+	if err != nil || string(merged) != `{"name":"Apple juice"}` {
+		t.Fatalf("merged = %s, err = %v", merged, err)
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	for _, patch := range []string{`null`, `[1]`, `"name"`, `{"name":`} {
+		if _, err := MergePatch(json.RawMessage(`{"name":"Apple juice"}`), json.RawMessage(patch)); !errors.Is(err, ErrInvalidMergePatch) {
+			t.Errorf("MergePatch(%s) error = %v, want ErrInvalidMergePatch", patch, err)
+		}
+	}
+}
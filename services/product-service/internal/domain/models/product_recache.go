@@ -0,0 +1,25 @@
+package models
+
+// RecacheRequest запрос на принудительное обновление кэша продуктов.
+// Если ProductIDs пуст, продукты выбираются по Filters.
+type RecacheRequest struct {
+	ProductIDs []string       `json:"product_ids,omitempty"`
+	Filters    *ProductFilter `json:"filters,omitempty"`
+}
+
+// RecacheResult результат обновления кэша продуктов
+type RecacheResult struct {
+	Refreshed int      `json:"refreshed"`
+	NotFound  []string `json:"not_found,omitempty"`
+	Failed    []string `json:"failed,omitempty"`
+}
+
+// CacheWarmResult результат прогрева кэша тенанта
+type CacheWarmResult struct {
+	// Products число продуктов, записанных в кэш
+	Products int `json:"products"`
+	// ListPages число страниц списка продуктов, загруженных в кэш
+	ListPages int `json:"list_pages"`
+	// Failed ID продуктов, которые не удалось записать в кэш
+	Failed []string `json:"failed,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// Типы полей, используемые в схеме продукта
+const (
+	FieldTypeString    = "string"
+	FieldTypeNumber    = "number"
+	FieldTypeTimestamp = "timestamp"
+)
+
+// Операторы сравнения, применяемые к фильтрам
+const (
+	FilterOperatorEq       = "eq"
+	FilterOperatorContains = "contains"
+	FilterOperatorGte      = "gte"
+	FilterOperatorLte      = "lte"
+)
+
+// AttributeFilterPrefix префикс ключей фильтров по произвольным атрибутам base_data: attr_color=red
+const AttributeFilterPrefix = "attr_"
+
+// SearchQueryFilter ключ фильтра полнотекстового поиска по имени и описанию. Поиск сужает выборку
+// вместе с остальными фильтрами
+const SearchQueryFilter = "search_query"
+
+// SortByRelevance сортировка результатов полнотекстового поиска по релевантности. Без SearchQueryFilter
+// заменяется сортировкой по умолчанию
+const SortByRelevance = "relevance"
+
+// SchemaField описывает поле продукта, доступное клиенту для фильтрации или сортировки
+type SchemaField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Operator string `json:"operator,omitempty"`
+}
+
+// SortOption задает поле и направление сортировки списка продуктов.
+// Field сверяется с белым списком хранилища, неизвестное поле заменяется сортировкой по умолчанию.
+type SortOption struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// ProductSchema описывает допустимые ключи фильтрации и поля сортировки списка продуктов
+type ProductSchema struct {
+	Filters     []SchemaField `json:"filters"`
+	Sortable    []SchemaField `json:"sortable"`
+	DefaultSort string        `json:"default_sort"`
+	// AttributePrefix префикс фильтров по атрибутам, не перечисленным в Filters
+	AttributePrefix string `json:"attribute_prefix"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения длины строковых полей base_data
+const (
+	maxProductNameLength        = 500
+	maxProductDescriptionLength = 10000
+	maxProductShortFieldLength  = 255
+)
+
+// FieldError описывает нарушение правила валидации для одного поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError содержит все нарушения, найденные при валидации
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Is относит ошибку валидации к виду ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
+
+// ValidateBaseData проверяет base_data продукта по схеме: name и price обязательны,
+// строки не длиннее допустимого, цена неотрицательна, images - массив строк, attributes - объект.
+// Возвращает *ValidationError со всеми найденными нарушениями или nil
+func ValidateBaseData(raw json.RawMessage) error {
+	verr := &ValidationError{}
+
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(raw, &baseData); err != nil || baseData == nil {
+		verr.add("base_data", "must be a JSON object")
+		return verr
+	}
+
+	validateString(verr, baseData, "name", true, maxProductNameLength)
+	validateString(verr, baseData, "description", false, maxProductDescriptionLength)
+	validateString(verr, baseData, "brand", false, maxProductShortFieldLength)
+	validateString(verr, baseData, "category", false, maxProductShortFieldLength)
+	validateString(verr, baseData, "sku", false, maxProductShortFieldLength)
+
+	switch price, ok := baseData["price"]; {
+	case !ok || price == nil:
+		verr.add("price", "is required")
+	default:
+		if value, isNumber := price.(float64); !isNumber {
+			verr.add("price", "must be a number")
+		} else if value < 0 {
+			verr.add("price", "must not be negative")
+		}
+	}
+
+	if images, ok := baseData["images"]; ok && images != nil {
+		list, isArray := images.([]interface{})
+		if !isArray {
+			verr.add("images", "must be an array of strings")
+		} else {
+			for i, image := range list {
+				if _, isString := image.(string); !isString {
+					verr.add(fmt.Sprintf("images[%d]", i), "must be a string")
+				}
+			}
+		}
+	}
+
+	if attributes, ok := baseData["attributes"]; ok && attributes != nil {
+		if _, isObject := attributes.(map[string]interface{}); !isObject {
+			verr.add("attributes", "must be an object")
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validateString проверяет, что поле является строкой не длиннее maxLength символов.
+// Обязательное поле не может отсутствовать или состоять из пробелов
+func validateString(verr *ValidationError, data map[string]interface{}, field string, required bool, maxLength int) {
+	value, ok := data[field]
+	if !ok || value == nil {
+		if required {
+			verr.add(field, "is required")
+		}
+		return
+	}
+
+	str, isString := value.(string)
+	if !isString {
+		verr.add(field, "must be a string")
+		return
+	}
+	if required && strings.TrimSpace(str) == "" {
+		verr.add(field, "must not be empty")
+		return
+	}
+	if utf8.RuneCountInString(str) > maxLength {
+		verr.add(field, fmt.Sprintf("must be at most %d characters", maxLength))
+	}
+}
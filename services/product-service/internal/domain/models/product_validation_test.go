@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateBaseData(t *testing.T) {
+	long := func(n int) string { return `"` + strings.Repeat("я", n) + `"` }
+
+	tests := []struct {
+		name string
+		raw  string
+		want []FieldError
+	}{
+		{name: "valid", raw: `{"name":"Apple juice","price":100,"images":["a.png"],"attributes":{"volume":"1L"}}`},
+		{name: "zero price", raw: `{"name":"Sample","price":0}`},
+		{name: "not an object", raw: `[1,2]`, want: []FieldError{{"base_data", "must be a JSON object"}}},
+		{name: "null", raw: `null`, want: []FieldError{{"base_data", "must be a JSON object"}}},
+		{name: "required fields", raw: `{}`, want: []FieldError{{"name", "is required"}, {"price", "is required"}}},
+		{name: "blank name", raw: `{"name":"  ","price":1}`, want: []FieldError{{"name", "must not be empty"}}},
+		{name: "name type", raw: `{"name":42,"price":1}`, want: []FieldError{{"name", "must be a string"}}},
+		{name: "name length", raw: `{"name":` + long(maxProductNameLength+1) + `,"price":1}`,
+			want: []FieldError{{"name", "must be at most 500 characters"}}},
+		{name: "name length in runes", raw: `{"name":` + long(maxProductNameLength) + `,"price":1}`},
+		{name: "description length", raw: `{"name":"a","price":1,"description":` + long(maxProductDescriptionLength+1) + `}`,
+			want: []FieldError{{"description", "must be at most 10000 characters"}}},
+		{name: "short fields", raw: `{"name":"a","price":1,"brand":` + long(256) + `,"category":1,"sku":` + long(256) + `}`,
+			want: []FieldError{
+				{"brand", "must be at most 255 characters"},
+				{"category", "must be a string"},
+				{"sku", "must be at most 255 characters"},
+			}},
+		{name: "price type", raw: `{"name":"a","price":"100"}`, want: []FieldError{{"price", "must be a number"}}},
+		{name: "negative price", raw: `{"name":"a","price":-1}`, want: []FieldError{{"price", "must not be negative"}}},
+		{name: "images type", raw: `{"name":"a","price":1,"images":"a.png"}`, want: []FieldError{{"images", "must be an array of strings"}}},
+		{name: "image item type", raw: `{"name":"a","price":1,"images":["a.png",7]}`, want: []FieldError{{"images[1]", "must be a string"}}},
+		{name: "attributes type", raw: `{"name":"a","price":1,"attributes":["volume"]}`, want: []FieldError{{"attributes", "must be an object"}}},
+		{name: "all errors reported", raw: `{"name":"","price":-5,"attributes":1}`, want: []FieldError{
+			{"name", "must not be empty"},
+			{"price", "must not be negative"},
+			{"attributes", "must be an object"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBaseData(json.RawMessage(tt.raw))
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateBaseData: %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrValidation) {
+				t.Fatalf("err = %v, want *ValidationError of kind ErrValidation", err)
+			}
+			if !reflect.DeepEqual(verr.Errors, tt.want) {
+				t.Fatalf("errors = %+v, want %+v", verr.Errors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// User учетная запись пользователя API. Хэш пароля никогда не сериализуется в ответы
+type User struct {
+	ID           string    `json:"id"`
+	TenantID     string    `json:"tenant_id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Roles        []string  `json:"roles"`
+	Permissions  []string  `json:"permissions"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Ключи кэша продуктов. Префикс тенанта добавляет адаптер кэша (методы *WithTenant),
+// поэтому в самих ключах tenant_id не дублируется.
+
+// ProductCacheKey возвращает ключ кэша продукта в рамках поставщика
+func ProductCacheKey(supplierID, productID string) string {
+	return fmt.Sprintf("product:%s:%s", supplierID, productID)
+}
+
+// ProductCachePattern возвращает шаблон, покрывающий записи продукта у всех поставщиков.
+// Используется для инвалидации, когда поставщик неизвестен (например, из событий).
+func ProductCachePattern(productID string) string {
+	return fmt.Sprintf("product:*:%s", productID)
+}
+
+// ProductSupplierCachePattern возвращает шаблон, покрывающий записи всех продуктов поставщика.
+// Спецсимволы шаблона в ID поставщика экранируются, чтобы шаблон не захватил ключи других поставщиков
+func ProductSupplierCachePattern(supplierID string) string {
+	return fmt.Sprintf("product:%s:*", cachePatternReplacer.Replace(supplierID))
+}
+
+// cachePatternReplacer экранирует спецсимволы glob-шаблонов Redis
+var cachePatternReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// ProductListCachePattern шаблон закэшированных страниц списка продуктов
+const ProductListCachePattern = "products:list:*"
+
+// CacheWarmLockKey возвращает ключ блокировки прогрева кэша тенанта
+func CacheWarmLockKey(tenantID string) string {
+	return fmt.Sprintf("lock:cache_warm:%s", tenantID)
+}
+
+// SupplierSyncLockKey возвращает ключ блокировки синхронизации каталога поставщика тенанта
+func SupplierSyncLockKey(supplierID, tenantID string) string {
+	return fmt.Sprintf("lock:supplier_sync:%s:%s", tenantID, supplierID)
+}
+
+// cacheWarmLockTTL срок блокировки прогрева кэша, с запасом больше времени прогрева
+const cacheWarmLockTTL = 5 * time.Minute
+
+// supplierSyncLockTTL срок блокировки синхронизации поставщика. Если реплика упадет,
+// не сняв блокировку, следующая синхронизация станет возможной через это время
+const supplierSyncLockTTL = 15 * time.Minute
+
+// Сроки действия записей кэша. К ним добавляется случайный сдвиг ±cacheTTLJitter,
+// чтобы записи, сохраненные одним пакетом, не истекали одновременно
+const (
+	productCacheTTL     = 30 * time.Minute
+	productListCacheTTL = 5 * time.Minute
+	cacheTTLJitter      = 0.1
+)
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// Сроки резервов остатков
+const (
+	// DefaultReservationTTL срок резерва, если вызывающий его не указал
+	DefaultReservationTTL = 15 * time.Minute
+	// MaxReservationTTL максимальный срок резерва, чтобы забытые резервы не удерживали остатки долго
+	MaxReservationTTL = 24 * time.Hour
+)
+
+// ErrInvalidReservation возвращается при неположительном количестве или сроке резерва больше MaxReservationTTL
+var ErrInvalidReservation = models.NewError(models.ErrValidation, "validation_error",
+	fmt.Sprintf("Количество должно быть положительным, а срок резерва не больше %d секунд", int(MaxReservationTTL.Seconds())))
+
+// ReserveInventory временно удерживает quantity единиц продукта на ttl, уменьшая доступный остаток
+// без изменения фактического. Если доступного остатка не хватает, возвращает utils.ErrInsufficientStock.
+// По истечении ttl резерв перестает учитываться и позже удаляется ExpireReservations
+func (s *ProductService) ReserveInventory(ctx context.Context, productID, tenantID string, quantity int, ttl time.Duration) (*models.InventoryReservation, error) {
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	if quantity <= 0 || ttl < 0 || ttl > MaxReservationTTL {
+		return nil, ErrInvalidReservation
+	}
+
+	now := time.Now().UTC()
+	reservation := &models.InventoryReservation{
+		ID:        uuid.New().String(),
+		ProductID: productID,
+		TenantID:  tenantID,
+		Quantity:  quantity,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		return s.repository.ReserveInventory(txCtx, reservation)
+	})
+	if errors.Is(err, utils.ErrInsufficientStock) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve inventory: %w", err)
+	}
+
+	s.logger.InfoWithContext(ctx, "Остатки зарезервированы",
+		interfaces.LogField{Key: "reservation_id", Value: reservation.ID},
+		interfaces.LogField{Key: "product_id", Value: productID},
+		interfaces.LogField{Key: "quantity", Value: quantity},
+	)
+
+	return reservation, nil
+}
+
+// ReleaseReservation снимает резерв продукта, возвращая его количество в доступный остаток.
+// Если резерв не найден (в том числе уже удален после истечения), возвращает utils.ErrReservationNotFound
+func (s *ProductService) ReleaseReservation(ctx context.Context, productID, reservationID, tenantID string) error {
+	reservation, err := s.repository.DeleteReservation(ctx, productID, reservationID, tenantID)
+	if errors.Is(err, utils.ErrReservationNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+
+	s.logger.InfoWithContext(ctx, "Резерв остатков снят",
+		interfaces.LogField{Key: "reservation_id", Value: reservation.ID},
+		interfaces.LogField{Key: "product_id", Value: reservation.ProductID},
+	)
+
+	return nil
+}
+
+// ExpireReservations удаляет истекшие резервы всех тенантов и возвращает их число.
+// Истекшие резервы не уменьшают доступный остаток и до удаления, очистка лишь освобождает место
+func (s *ProductService) ExpireReservations(ctx context.Context) (int, error) {
+	expired, err := s.repository.DeleteExpiredReservations(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire reservations: %w", err)
+	}
+	return expired, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+// reservationRepository остатки и резервы в памяти с той же проверкой доступного остатка, что в хранилище
+type reservationRepository struct {
+	postgres.ProductStoragePort
+
+	stock        map[string]int
+	reservations map[string]*models.InventoryReservation
+	reserves     int
+}
+
+func (r *reservationRepository) ReserveInventory(ctx context.Context, reservation *models.InventoryReservation) error {
+	r.reserves++
+	quantity, ok := r.stock[reservation.ProductID]
+	if !ok {
+		return utils.ErrInsufficientStock
+	}
+	for _, held := range r.reservations {
+		if held.ProductID == reservation.ProductID && held.ExpiresAt.After(reservation.CreatedAt) {
+			quantity -= held.Quantity
+		}
+	}
+	if quantity < reservation.Quantity {
+		return utils.ErrInsufficientStock
+	}
+	r.reservations[reservation.ID] = reservation
+	return nil
+}
+
+func (r *reservationRepository) DeleteReservation(ctx context.Context, productID, reservationID, tenantID string) (*models.InventoryReservation, error) {
+	reservation, ok := r.reservations[reservationID]
+	if !ok || reservation.ProductID != productID || reservation.TenantID != tenantID {
+		return nil, utils.ErrReservationNotFound
+	}
+	delete(r.reservations, reservationID)
+	return reservation, nil
+}
+
+func (r *reservationRepository) DeleteExpiredReservations(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for id, reservation := range r.reservations {
+		if !reservation.ExpiresAt.After(now) {
+			delete(r.reservations, id)
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func newReservationService(t *testing.T, stock map[string]int) (*ProductService, *reservationRepository) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	repo := &reservationRepository{stock: stock, reservations: make(map[string]*models.InventoryReservation)}
+	return NewProductService(repo, nil, nil, log, directTxManager{}, nil, nil, nil, nil), repo
+}
+
+func TestReserveAndReleaseInventory(t *testing.T) {
+	service, _ := newReservationService(t, map[string]int{"product-1": 10})
+	ctx := context.Background()
+
+	reservation, err := service.ReserveInventory(ctx, "product-1", "tenant-1", 4, 0)
+	if err != nil {
+		t.Fatalf("ReserveInventory: %v", err)
+	}
+	if ttl := reservation.ExpiresAt.Sub(reservation.CreatedAt); ttl != DefaultReservationTTL {
+		t.Fatalf("reservation ttl = %v, want the default %v", ttl, DefaultReservationTTL)
+	}
+
+	// Доступно 6 из 10: резерв на 7 единиц отклоняется
+	if _, err := service.ReserveInventory(ctx, "product-1", "tenant-1", 7, time.Minute); !errors.Is(err, utils.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock", err)
+	}
+	if _, err := service.ReserveInventory(ctx, "product-2", "tenant-1", 1, time.Minute); !errors.Is(err, utils.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want ErrInsufficientStock for a product without stock", err)
+	}
+
+	// Снятый резерв возвращает количество в доступный остаток
+	if err := service.ReleaseReservation(ctx, "product-1", reservation.ID, "tenant-1"); err != nil {
+		t.Fatalf("ReleaseReservation: %v", err)
+	}
+	if _, err := service.ReserveInventory(ctx, "product-1", "tenant-1", 10, time.Minute); err != nil {
+		t.Fatalf("ReserveInventory after release: %v", err)
+	}
+
+	if err := service.ReleaseReservation(ctx, "product-1", reservation.ID, "tenant-1"); !errors.Is(err, utils.ErrReservationNotFound) {
+		t.Fatalf("second release: %v, want ErrReservationNotFound", err)
+	}
+}
+
+func TestReserveInventoryValidation(t *testing.T) {
+	service, repo := newReservationService(t, map[string]int{"product-1": 10})
+
+	tests := []struct {
+		name     string
+		quantity int
+		ttl      time.Duration
+	}{
+		{name: "zero quantity", quantity: 0, ttl: time.Minute},
+		{name: "negative quantity", quantity: -1, ttl: time.Minute},
+		{name: "negative ttl", quantity: 1, ttl: -time.Second},
+		{name: "ttl above maximum", quantity: 1, ttl: MaxReservationTTL + time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ReserveInventory(context.Background(), "product-1", "tenant-1", tt.quantity, tt.ttl)
+			if !errors.Is(err, ErrInvalidReservation) || !errors.Is(err, models.ErrValidation) {
+				t.Fatalf("err = %v, want ErrInvalidReservation", err)
+			}
+		})
+	}
+	if repo.reserves != 0 {
+		t.Fatalf("repository called %d times for invalid reservations", repo.reserves)
+	}
+}
+
+func TestExpiredReservationReturnsStock(t *testing.T) {
+	service, repo := newReservationService(t, map[string]int{"product-1": 10})
+	ctx := context.Background()
+
+	reservation, err := service.ReserveInventory(ctx, "product-1", "tenant-1", 10, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("ReserveInventory: %v", err)
+	}
+	if _, err := service.ReserveInventory(ctx, "product-1", "tenant-1", 1, time.Minute); !errors.Is(err, utils.ErrInsufficientStock) {
+		t.Fatalf("err = %v, want all stock held", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// Истекший резерв не удерживает остаток еще до очистки
+	if _, err := service.ReserveInventory(ctx, "product-1", "tenant-1", 1, time.Minute); err != nil {
+		t.Fatalf("ReserveInventory after expiry: %v", err)
+	}
+
+	expired, err := service.ExpireReservations(ctx)
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireReservations = %d, %v, want 1", expired, err)
+	}
+	if len(repo.reservations) != 1 {
+		t.Fatalf("reservations = %d, want only the active one left", len(repo.reservations))
+	}
+	if err := service.ReleaseReservation(ctx, "product-1", reservation.ID, "tenant-1"); !errors.Is(err, utils.ErrReservationNotFound) {
+		t.Fatalf("release of an expired reservation: %v, want ErrReservationNotFound", err)
+	}
+}
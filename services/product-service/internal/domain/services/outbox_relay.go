@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/pkg/tx"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// outboxPublishTimeout сколько relay ждет подтверждения публикации группы событий
+const outboxPublishTimeout = 10 * time.Second
+
+// OutboxRelay публикует события из outbox в Kafka и отмечает их отправленными.
+// События читаются в порядке записи; если публикация события продукта не удалась,
+// остальные события этого продукта в пачке откладываются, чтобы не нарушить порядок.
+// Подряд идущие события с общими топиком, тенантом и трассой публикуются одной пачкой.
+type OutboxRelay struct {
+	repository postgres.ProductStoragePort
+	messaging  interfaces.MessagingPort
+	logger     interfaces.LoggerPort
+	txManager  tx.TxManager
+	batchSize  int
+}
+
+// NewOutboxRelay создает новый OutboxRelay
+func NewOutboxRelay(
+	repo postgres.ProductStoragePort,
+	msg interfaces.MessagingPort,
+	log interfaces.LoggerPort,
+	txMgr tx.TxManager,
+	batchSize int,
+) *OutboxRelay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &OutboxRelay{
+		repository: repo,
+		messaging:  msg,
+		logger:     log,
+		txManager:  txMgr,
+		batchSize:  batchSize,
+	}
+}
+
+// RelayOnce публикует одну пачку неотправленных событий.
+// Возвращает число опубликованных и неудачных публикаций; неудачные будут повторены следующим вызовом.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (published int, failed int, err error) {
+	err = r.txManager.Do(ctx, func(txCtx context.Context) error {
+		messages, err := r.repository.FetchUnpublishedOutbox(txCtx, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		blocked := make(map[string]bool)
+		for _, group := range groupOutboxMessages(messages) {
+			pending := group[:0:0]
+			for _, message := range group {
+				if !blocked[message.AggregateID] {
+					pending = append(pending, message)
+				}
+			}
+			if len(pending) == 0 {
+				continue
+			}
+
+			publishErrs := r.publishGroup(txCtx, pending)
+			for i, message := range pending {
+				publishErr := publishErrs[i]
+				if publishErr != nil {
+					blocked[message.AggregateID] = true
+					failed++
+
+					r.logger.WarnWithContext(ctx, "Ошибка публикации события из outbox, будет повторена",
+						interfaces.LogField{Key: "outbox_id", Value: message.ID},
+						interfaces.LogField{Key: "aggregate_id", Value: message.AggregateID},
+						interfaces.LogField{Key: "attempts", Value: message.Attempts + 1},
+						interfaces.LogField{Key: "error", Value: publishErr.Error()},
+					)
+
+					if err := r.repository.MarkOutboxFailed(txCtx, message.ID, publishErr.Error()); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if err := r.repository.MarkOutboxPublished(txCtx, message.ID); err != nil {
+					return err
+				}
+				published++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("outbox relay failed: %w", err)
+	}
+
+	return published, failed, nil
+}
+
+// publishGroup публикует группу событий одной пачкой. Событие отмечается опубликованным
+// только после подтверждения брокера. Возвращает ошибку для каждого недоставленного события
+func (r *OutboxRelay) publishGroup(ctx context.Context, group []*models.OutboxMessage) []error {
+	first := group[0]
+	msgCtx := contextkeys.WithTenant(ctx, first.TenantID)
+	if first.TraceParent != "" {
+		msgCtx = otel.GetTextMapPropagator().Extract(msgCtx, propagation.MapCarrier{"traceparent": first.TraceParent})
+	}
+
+	// Ключ - ID агрегата: события одного продукта попадают в одну партицию и читаются по порядку
+	keys := make([]string, len(group))
+	payloads := make([][]byte, len(group))
+	for i, message := range group {
+		keys[i] = message.AggregateID
+		payloads[i] = message.Payload
+	}
+
+	publishCtx, cancel := context.WithTimeout(msgCtx, outboxPublishTimeout)
+	defer cancel()
+
+	errs := make([]error, len(group))
+	err := r.messaging.PublishBatchWithKeys(publishCtx, first.Topic, keys, payloads)
+	if err == nil {
+		return errs
+	}
+
+	var batchErr *interfaces.BatchPublishError
+	if errors.As(err, &batchErr) {
+		for i, failedErr := range batchErr.Failed {
+			errs[i] = failedErr
+		}
+		return errs
+	}
+
+	// Пачка не отправлена целиком (например, разомкнут автоматический выключатель)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// groupOutboxMessages делит события на группы подряд идущих событий с одинаковыми топиком,
+// тенантом и трассой, которые можно опубликовать одной пачкой с общими заголовками.
+// Событие агрегата, уже вошедшего в группу, начинает новую: следующая группа публикуется
+// только после результата предыдущей, поэтому порядок событий агрегата сохраняется
+func groupOutboxMessages(messages []*models.OutboxMessage) [][]*models.OutboxMessage {
+	var groups [][]*models.OutboxMessage
+	var current []*models.OutboxMessage
+	aggregates := make(map[string]bool)
+
+	for _, message := range messages {
+		if len(current) > 0 {
+			first := current[0]
+			if message.Topic != first.Topic || message.TenantID != first.TenantID ||
+				message.TraceParent != first.TraceParent || aggregates[message.AggregateID] {
+				groups = append(groups, current)
+				current = nil
+				aggregates = make(map[string]bool)
+			}
+		}
+		current = append(current, message)
+		aggregates[message.AggregateID] = true
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	return groups
+}
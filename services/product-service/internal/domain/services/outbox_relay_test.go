@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// outboxRepository outbox в памяти: неопубликованные события отдаются в порядке записи
+type outboxRepository struct {
+	postgres.ProductStoragePort
+
+	messages  []*models.OutboxMessage
+	published map[int64]bool
+}
+
+func (r *outboxRepository) FetchUnpublishedOutbox(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	var pending []*models.OutboxMessage
+	for _, message := range r.messages {
+		if !r.published[message.ID] && len(pending) < limit {
+			pending = append(pending, message)
+		}
+	}
+	return pending, nil
+}
+
+func (r *outboxRepository) MarkOutboxPublished(ctx context.Context, id int64) error {
+	r.published[id] = true
+	return nil
+}
+
+func (r *outboxRepository) MarkOutboxFailed(ctx context.Context, id int64, reason string) error {
+	for _, message := range r.messages {
+		if message.ID == id {
+			message.Attempts++
+		}
+	}
+	return nil
+}
+
+// flakyBroker отклоняет первые failures пачек и запоминает доставленные события и их ключи
+type flakyBroker struct {
+	interfaces.MessagingPort
+
+	failures  int
+	delivered []string
+	keys      []string
+}
+
+func (b *flakyBroker) PublishBatchWithKeys(ctx context.Context, topic string, keys []string, messages [][]byte) error {
+	if b.failures > 0 {
+		b.failures--
+		return errors.New("broker unavailable")
+	}
+	for i, message := range messages {
+		b.delivered = append(b.delivered, string(message))
+		b.keys = append(b.keys, keys[i])
+	}
+	return nil
+}
+
+// directTxManager выполняет fn без транзакции
+type directTxManager struct{}
+
+func (directTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (directTxManager) DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestOutboxRelayRetriesFailedPublish(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	event := func(id int64, productID string) *models.OutboxMessage {
+		return &models.OutboxMessage{
+			ID:          id,
+			TenantID:    "tenant-1",
+			AggregateID: productID,
+			Topic:       "product-events",
+			Payload:     []byte(fmt.Sprintf("%s-%d", productID, id)),
+		}
+	}
+	repo := &outboxRepository{
+		messages:  []*models.OutboxMessage{event(1, "product-1"), event(2, "product-1"), event(3, "product-2")},
+		published: make(map[int64]bool),
+	}
+	broker := &flakyBroker{failures: 1}
+	relay := NewOutboxRelay(repo, broker, log, directTxManager{}, 10)
+
+	// Первая пачка [product-1-1] отклонена брокером: событие остается в outbox,
+	// следующее событие product-1 откладывается, а событие другого продукта публикуется
+	published, failed, err := relay.RelayOnce(context.Background())
+	if err != nil || published != 1 || failed != 1 {
+		t.Fatalf("first relay: published = %d, failed = %d, err = %v, want 1 and 1", published, failed, err)
+	}
+	if repo.published[1] || repo.published[2] || !repo.published[3] || repo.messages[0].Attempts != 1 {
+		t.Fatalf("published = %v, attempts = %d, want product-1 events kept for retry", repo.published, repo.messages[0].Attempts)
+	}
+
+	published, failed, err = relay.RelayOnce(context.Background())
+	if err != nil || published != 2 || failed != 0 {
+		t.Fatalf("retry: published = %d, failed = %d, err = %v, want 2 and 0", published, failed, err)
+	}
+
+	want := []string{"product-2-3", "product-1-1", "product-1-2"}
+	if fmt.Sprint(broker.delivered) != fmt.Sprint(want) {
+		t.Fatalf("delivered = %v, want %v", broker.delivered, want)
+	}
+	// Ключ - ID продукта, чтобы его события попадали в одну партицию
+	if wantKeys := []string{"product-2", "product-1", "product-1"}; fmt.Sprint(broker.keys) != fmt.Sprint(wantKeys) {
+		t.Fatalf("keys = %v, want %v", broker.keys, wantKeys)
+	}
+
+	if published, failed, err := relay.RelayOnce(context.Background()); err != nil || published != 0 || failed != 0 {
+		t.Fatalf("empty outbox: published = %d, failed = %d, err = %v", published, failed, err)
+	}
+}
+
+// traceBroker запоминает спан, в контексте которого публикуется каждая пачка
+type traceBroker struct {
+	interfaces.MessagingPort
+	spans []trace.SpanContext
+}
+
+func (b *traceBroker) PublishBatchWithKeys(ctx context.Context, topic string, keys []string, messages [][]byte) error {
+	b.spans = append(b.spans, trace.SpanContextFromContext(ctx))
+	return nil
+}
+
+func TestOutboxRelayContinuesTrace(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	// Событие сохраняется в outbox вместе с traceparent запроса
+	service, repo, _ := newPriceService(t)
+	price := &models.ProductPrice{ProductID: "product-1", SupplierID: "supplier-1", BasePrice: 150, Currency: "RUB"}
+	if err := service.UpdatePrice(ctx, price, "tenant-1"); err != nil {
+		t.Fatalf("UpdatePrice: %v", err)
+	}
+	if len(repo.messages) != 1 || repo.messages[0].TraceParent != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("messages = %+v, want the traceparent of the request", repo.messages)
+	}
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	broker := &traceBroker{}
+	message := repo.messages[0]
+	message.ID = 1
+	relay := NewOutboxRelay(&outboxRepository{messages: []*models.OutboxMessage{message}, published: map[int64]bool{}},
+		broker, log, directTxManager{}, 10)
+
+	// Relay работает в своем контексте без спана и публикует событие в трассе запроса
+	if published, _, err := relay.RelayOnce(context.Background()); err != nil || published != 1 {
+		t.Fatalf("published = %d, err = %v, want 1", published, err)
+	}
+	if len(broker.spans) != 1 || broker.spans[0].TraceID() != traceID || broker.spans[0].SpanID() != spanID || !broker.spans[0].IsRemote() {
+		t.Fatalf("spans = %+v, want the span of the request", broker.spans)
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// errBatchItemsFailed прерывает транзакцию пакета, когда хотя бы один элемент не прошел проверку
+var errBatchItemsFailed = errors.New("batch items failed")
+
+// BatchCreateProducts создает продукты одной транзакцией.
+// Если хотя бы один продукт не прошел валидацию, пакет отклоняется целиком с ошибкой utils.ErrBatchRejected,
+// а причины перечисляются в срезе ошибок по элементам. События создания попадают в outbox
+// и публикуются relay воркера только после коммита.
+func (s *ProductService) BatchCreateProducts(ctx context.Context, products []*models.Product, tenantID string) (int, []models.BatchItemError, error) {
+	if tenantID == "" {
+		return 0, nil, errors.New("tenant ID cannot be empty")
+	}
+
+	var itemErrors []models.BatchItemError
+	seen := make(map[string]struct{}, len(products))
+	for i, product := range products {
+		product.TenantID = tenantID
+		// Как и CreateProduct, пакет только создает продукты и не перезаписывает существующие
+		product.Version = 0
+		if product.ID == "" {
+			product.ID = uuid.New().String()
+		}
+		// Повтор ID в одном INSERT ... ON CONFLICT PostgreSQL отвергает
+		if _, ok := seen[product.ID]; ok {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: "duplicate id in batch"})
+			continue
+		}
+		seen[product.ID] = struct{}{}
+		if err := product.Validate(); err != nil {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: err.Error()})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return 0, itemErrors, utils.ErrBatchRejected
+	}
+
+	now := time.Now().UTC()
+	for _, product := range products {
+		product.CreatedAt = now
+		product.UpdatedAt = now
+	}
+
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := s.repository.SaveProducts(txCtx, products); err != nil {
+			var conflict *utils.VersionConflictError
+			if errors.As(err, &conflict) {
+				itemErrors = conflictItemErrors(products, conflict, utils.ErrProductExists)
+				return errBatchItemsFailed
+			}
+			return err
+		}
+
+		for _, product := range products {
+			if err := s.recordHistory(txCtx, models.HistoryChangeCreate, product.ID, tenantID, nil, product); err != nil {
+				return err
+			}
+			if err := s.enqueueProductEvent(txCtx, messaging.ProductCreatedEvent, product.ID, tenantID, &messaging.ProductEventPayload{SupplierID: product.SupplierID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchItemsFailed) {
+		return 0, itemErrors, utils.ErrBatchRejected
+	}
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to batch create products",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "count", Value: len(products)},
+		)
+		return 0, nil, fmt.Errorf("failed to batch create products: %w", err)
+	}
+
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
+
+	return len(products), nil, nil
+}
+
+// BatchUpdateProducts обновляет существующие продукты одной транзакцией.
+// Продукты без ID или версии, не прошедшие валидацию, отсутствующие в хранилище
+// или измененные после чтения клиентом (версия не совпала) отклоняют весь пакет.
+func (s *ProductService) BatchUpdateProducts(ctx context.Context, products []*models.Product, tenantID string) (int, []models.BatchItemError, error) {
+	if tenantID == "" {
+		return 0, nil, errors.New("tenant ID cannot be empty")
+	}
+
+	var itemErrors []models.BatchItemError
+	seen := make(map[string]struct{}, len(products))
+	for i, product := range products {
+		product.TenantID = tenantID
+		if product.ID == "" {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, Error: "id is required"})
+			continue
+		}
+		if _, ok := seen[product.ID]; ok {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: "duplicate id in batch"})
+			continue
+		}
+		seen[product.ID] = struct{}{}
+		if product.Version <= 0 {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: utils.ErrVersionRequired.Error()})
+			continue
+		}
+		if err := product.Validate(); err != nil {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: err.Error()})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return 0, itemErrors, utils.ErrBatchRejected
+	}
+
+	// Состояния до изменения нужны для истории и инвалидации кэша старого поставщика
+	previous := make([]*models.Product, len(products))
+
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		for i, product := range products {
+			before, err := s.repository.GetProduct(txCtx, product.ID, tenantID)
+			if errors.Is(err, utils.ErrProductNotFound) {
+				itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: err.Error()})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			before.TenantID = tenantID
+			product.CreatedAt = before.CreatedAt
+			previous[i] = before
+		}
+		if len(itemErrors) > 0 {
+			return errBatchItemsFailed
+		}
+
+		if err := s.repository.SaveProducts(txCtx, products); err != nil {
+			var conflict *utils.VersionConflictError
+			if errors.As(err, &conflict) {
+				itemErrors = conflictItemErrors(products, conflict, utils.ErrVersionConflict)
+				return errBatchItemsFailed
+			}
+			return err
+		}
+
+		for i, product := range products {
+			if err := s.recordHistory(txCtx, models.HistoryChangeUpdate, product.ID, tenantID, previous[i], product); err != nil {
+				return err
+			}
+			if err := s.enqueueProductEvent(txCtx, messaging.ProductUpdatedEvent, product.ID, tenantID, &messaging.ProductEventPayload{SupplierID: product.SupplierID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchItemsFailed) {
+		return 0, itemErrors, utils.ErrBatchRejected
+	}
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to batch update products",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "count", Value: len(products)},
+		)
+		return 0, nil, fmt.Errorf("failed to batch update products: %w", err)
+	}
+
+	for i, product := range products {
+		_ = s.cache.DeleteWithTenant(ctx, ProductCacheKey(product.SupplierID, product.ID), tenantID)
+		if previous[i].SupplierID != product.SupplierID {
+			_ = s.cache.DeleteWithTenant(ctx, ProductCacheKey(previous[i].SupplierID, product.ID), tenantID)
+		}
+	}
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
+
+	return len(products), nil, nil
+}
+
+// conflictItemErrors сопоставляет продукты, не записанные пакетным сохранением, с их позициями в пакете
+func conflictItemErrors(products []*models.Product, conflict *utils.VersionConflictError, reason error) []models.BatchItemError {
+	conflicted := make(map[string]struct{}, len(conflict.ProductIDs))
+	for _, productID := range conflict.ProductIDs {
+		conflicted[productID] = struct{}{}
+	}
+
+	itemErrors := make([]models.BatchItemError, 0, len(conflict.ProductIDs))
+	for i, product := range products {
+		if _, ok := conflicted[product.ID]; ok {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: product.ID, Error: reason.Error()})
+		}
+	}
+	return itemErrors
+}
+
+// BatchDeleteProducts удаляет продукты одной транзакцией.
+// Если хотя бы один продукт не найден, пакет отклоняется целиком.
+func (s *ProductService) BatchDeleteProducts(ctx context.Context, productIDs []string, tenantID string) (int, []models.BatchItemError, error) {
+	if tenantID == "" {
+		return 0, nil, errors.New("tenant ID cannot be empty")
+	}
+
+	var itemErrors []models.BatchItemError
+	for i, productID := range productIDs {
+		if productID == "" {
+			itemErrors = append(itemErrors, models.BatchItemError{Index: i, Error: "id is required"})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return 0, itemErrors, utils.ErrBatchRejected
+	}
+
+	previous := make([]*models.Product, len(productIDs))
+	var deleted int
+
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		for i, productID := range productIDs {
+			before, err := s.repository.GetProduct(txCtx, productID, tenantID)
+			if errors.Is(err, utils.ErrProductNotFound) {
+				itemErrors = append(itemErrors, models.BatchItemError{Index: i, ProductID: productID, Error: err.Error()})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			before.TenantID = tenantID
+			previous[i] = before
+		}
+		if len(itemErrors) > 0 {
+			return errBatchItemsFailed
+		}
+
+		var err error
+		deleted, err = s.repository.DeleteProducts(txCtx, productIDs, tenantID)
+		if err != nil {
+			return err
+		}
+
+		for i, productID := range productIDs {
+			if err := s.recordHistory(txCtx, models.HistoryChangeDelete, productID, tenantID, previous[i], nil); err != nil {
+				return err
+			}
+			if err := s.enqueueProductEvent(txCtx, messaging.ProductDeletedEvent, productID, tenantID, &messaging.ProductEventPayload{SupplierID: previous[i].SupplierID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchItemsFailed) {
+		return 0, itemErrors, utils.ErrBatchRejected
+	}
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to batch delete products",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "count", Value: len(productIDs)},
+		)
+		return 0, nil, fmt.Errorf("failed to batch delete products: %w", err)
+	}
+
+	for i, productID := range productIDs {
+		_ = s.cache.DeleteWithTenant(ctx, ProductCacheKey(previous[i].SupplierID, productID), tenantID)
+	}
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
+
+	return deleted, nil, nil
+}
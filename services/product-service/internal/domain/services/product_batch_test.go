@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// batchRepository хранилище продуктов в памяти с проверкой версий, как у SaveProducts.
+// Записи внутри транзакции копятся в pending и переносятся в products только при коммите
+type batchRepository struct {
+	postgres.ProductStoragePort
+
+	products      map[string]*models.Product
+	pending       map[string]*models.Product
+	outbox        int
+	pendingOutbox int
+}
+
+func (r *batchRepository) GetProduct(ctx context.Context, productID string, tenantID string) (*models.Product, error) {
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, utils.ErrProductNotFound
+	}
+	stored := *product
+	return &stored, nil
+}
+
+func (r *batchRepository) SaveProducts(ctx context.Context, products []*models.Product) error {
+	var conflicts []string
+	for _, product := range products {
+		stored, ok := r.products[product.ID]
+		if (ok && stored.Version != product.Version) || (!ok && product.Version != 0) {
+			conflicts = append(conflicts, product.ID)
+			continue
+		}
+		saved := *product
+		saved.Version++
+		r.pending[product.ID] = &saved
+		product.Version = saved.Version
+	}
+	if len(conflicts) > 0 {
+		return &utils.VersionConflictError{ProductIDs: conflicts}
+	}
+	return nil
+}
+
+func (r *batchRepository) SaveProduct(ctx context.Context, product *models.Product) error {
+	return r.SaveProducts(ctx, []*models.Product{product})
+}
+
+func (r *batchRepository) SaveHistoryRecord(ctx context.Context, record *models.ProductHistoryRecord, tenantID string) error {
+	return nil
+}
+
+func (r *batchRepository) NextEventSequence(ctx context.Context, productID string, tenantID string) (int64, error) {
+	return 1, nil
+}
+
+func (r *batchRepository) SaveOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	r.pendingOutbox++
+	return nil
+}
+
+// batchTxManager фиксирует записи batchRepository, если fn завершилась без ошибки, и отбрасывает иначе
+type batchTxManager struct {
+	repo *batchRepository
+}
+
+func (m *batchTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.repo.pending = make(map[string]*models.Product)
+	m.repo.pendingOutbox = 0
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	for id, product := range m.repo.pending {
+		m.repo.products[id] = product
+	}
+	m.repo.outbox += m.repo.pendingOutbox
+	return nil
+}
+
+func (m *batchTxManager) DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	return m.Do(ctx, fn)
+}
+
+// batchCache кэш, запоминающий число инвалидаций
+type batchCache struct {
+	interfaces.CachePort
+	invalidations int
+}
+
+func (c *batchCache) DeleteWithTenant(ctx context.Context, key string, tenantID string) error {
+	c.invalidations++
+	return nil
+}
+
+func (c *batchCache) DeleteByPatternWithTenant(ctx context.Context, pattern, tenantID string) error {
+	c.invalidations++
+	return nil
+}
+
+func newBatchService(t *testing.T, stored ...*models.Product) (*ProductService, *batchRepository, *batchCache) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &batchRepository{products: make(map[string]*models.Product)}
+	for _, product := range stored {
+		repo.products[product.ID] = product
+	}
+	cache := &batchCache{}
+	service := NewProductService(repo, cache, nil, log, &batchTxManager{repo: repo}, nil, nil, nil, nil)
+	return service, repo, cache
+}
+
+func batchProduct(id string, version int, name string) *models.Product {
+	return &models.Product{
+		ID:         id,
+		TenantID:   "tenant-1",
+		SupplierID: "supplier-1",
+		BaseData:   json.RawMessage(`{"name":"` + name + `","price":100}`),
+		Version:    version,
+	}
+}
+
+func TestBatchUpdateProductsVersionConflictRollsBack(t *testing.T) {
+	service, repo, cache := newBatchService(t,
+		batchProduct("product-1", 1, "Apple juice"),
+		batchProduct("product-2", 3, "Orange juice"),
+	)
+
+	updated, itemErrors, err := service.BatchUpdateProducts(context.Background(), []*models.Product{
+		batchProduct("product-1", 1, "Apple juice 1L"),
+		batchProduct("product-2", 2, "Orange juice 1L"), // клиент прочитал устаревшую версию
+	}, "tenant-1")
+
+	if !errors.Is(err, utils.ErrBatchRejected) || updated != 0 {
+		t.Fatalf("updated = %d, err = %v, want the batch rejected", updated, err)
+	}
+	if len(itemErrors) != 1 || itemErrors[0].Index != 1 || itemErrors[0].ProductID != "product-2" ||
+		itemErrors[0].Error != utils.ErrVersionConflict.Error() {
+		t.Fatalf("item errors = %+v, want a version conflict for index 1", itemErrors)
+	}
+
+	// Продукт с верной версией тоже не сохранен: транзакция откатилась целиком
+	if repo.products["product-1"].Version != 1 || string(repo.products["product-1"].BaseData) != `{"name":"Apple juice","price":100}` {
+		t.Fatalf("product-1 = %+v, want it unchanged", repo.products["product-1"])
+	}
+	if repo.outbox != 0 || cache.invalidations != 0 {
+		t.Fatalf("outbox = %d, invalidations = %d, want nothing after rollback", repo.outbox, cache.invalidations)
+	}
+}
+
+func TestBatchUpdateProductsRequiresVersion(t *testing.T) {
+	service, _, _ := newBatchService(t, batchProduct("product-1", 1, "Apple juice"))
+
+	_, itemErrors, err := service.BatchUpdateProducts(context.Background(), []*models.Product{
+		batchProduct("product-1", 0, "Blind"),
+	}, "tenant-1")
+
+	if !errors.Is(err, utils.ErrBatchRejected) || len(itemErrors) != 1 || itemErrors[0].Error != utils.ErrVersionRequired.Error() {
+		t.Fatalf("err = %v, item errors = %+v, want version required", err, itemErrors)
+	}
+}
+
+func TestBatchCreateProductsDoesNotOverwrite(t *testing.T) {
+	service, repo, _ := newBatchService(t, batchProduct("product-1", 1, "Apple juice"))
+
+	_, itemErrors, err := service.BatchCreateProducts(context.Background(), []*models.Product{
+		batchProduct("product-2", 0, "Orange juice"),
+		batchProduct("product-1", 1, "Overwrite"),
+	}, "tenant-1")
+
+	if !errors.Is(err, utils.ErrBatchRejected) {
+		t.Fatalf("err = %v, want ErrBatchRejected", err)
+	}
+	if len(itemErrors) != 1 || itemErrors[0].Index != 1 || itemErrors[0].Error != utils.ErrProductExists.Error() {
+		t.Fatalf("item errors = %+v, want product exists for index 1", itemErrors)
+	}
+	if _, ok := repo.products["product-2"]; ok || repo.outbox != 0 {
+		t.Fatal("batch partially committed")
+	}
+}
+
+func TestBatchUpdateProducts(t *testing.T) {
+	service, repo, _ := newBatchService(t,
+		batchProduct("product-1", 1, "Apple juice"),
+		batchProduct("product-2", 3, "Orange juice"),
+	)
+
+	products := []*models.Product{
+		batchProduct("product-1", 1, "Apple juice 1L"),
+		batchProduct("product-2", 3, "Orange juice 1L"),
+	}
+	updated, itemErrors, err := service.BatchUpdateProducts(context.Background(), products, "tenant-1")
+	if err != nil || updated != 2 || len(itemErrors) != 0 {
+		t.Fatalf("updated = %d, item errors = %+v, err = %v", updated, itemErrors, err)
+	}
+	if products[0].Version != 2 || products[1].Version != 4 || repo.outbox != 2 {
+		t.Fatalf("versions = %d, %d, outbox = %d, want 2, 4 and 2 events",
+			products[0].Version, products[1].Version, repo.outbox)
+	}
+}
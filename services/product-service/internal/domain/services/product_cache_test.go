@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// newCacheService возвращает сервис продуктов с кэшем в памяти
+func newCacheService(t *testing.T, repo *batchRepository) (*ProductService, interfaces.CachePort) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	service := NewProductService(repo, memoryCache, nil, log, &batchTxManager{repo: repo}, nil, nil, nil, nil)
+	return service, memoryCache
+}
+
+// cached сообщает, есть ли в кэше тенанта запись с ключом key
+func cached(t *testing.T, c interfaces.CachePort, key, tenantID string) bool {
+	t.Helper()
+	_, err := c.GetWithTenant(context.Background(), key, tenantID)
+	return err == nil
+}
+
+func TestInvalidateSupplierCache(t *testing.T) {
+	service, memoryCache := newCacheService(t, &batchRepository{})
+	ctx := context.Background()
+
+	type entry struct{ key, tenantID string }
+	cleared := []entry{
+		{ProductCacheKey("supplier-1", "product-1"), "tenant-1"},
+		{ProductCacheKey("supplier-1", "product-2"), "tenant-1"},
+		{"products:list:page=1", "tenant-1"},
+	}
+	kept := []entry{
+		{ProductCacheKey("supplier-2", "product-3"), "tenant-1"},
+		// ID поставщика, начинающийся с ID целевого, не попадает под шаблон
+		{ProductCacheKey("supplier-10", "product-4"), "tenant-1"},
+		{ProductCacheKey("supplier-1", "product-1"), "tenant-2"},
+		{"products:list:page=1", "tenant-2"},
+	}
+	for _, e := range append(append([]entry(nil), cleared...), kept...) {
+		if err := memoryCache.SetWithTenant(ctx, e.key, []byte(`{}`), e.tenantID, time.Minute); err != nil {
+			t.Fatalf("SetWithTenant: %v", err)
+		}
+	}
+
+	if err := service.InvalidateSupplierCache(ctx, "supplier-1", "tenant-1"); err != nil {
+		t.Fatalf("InvalidateSupplierCache: %v", err)
+	}
+
+	for _, e := range cleared {
+		if cached(t, memoryCache, e.key, e.tenantID) {
+			t.Fatalf("%s of %s is still cached", e.key, e.tenantID)
+		}
+	}
+	for _, e := range kept {
+		if !cached(t, memoryCache, e.key, e.tenantID) {
+			t.Fatalf("%s of %s was cleared", e.key, e.tenantID)
+		}
+	}
+}
+
+func TestProductSupplierCachePatternEscapesSupplier(t *testing.T) {
+	service, memoryCache := newCacheService(t, &batchRepository{})
+	ctx := context.Background()
+
+	other := ProductCacheKey("supplier-2", "product-1")
+	if err := memoryCache.SetWithTenant(ctx, other, []byte(`{}`), "tenant-1", time.Minute); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+
+	// Спецсимволы в ID поставщика не расширяют шаблон на других поставщиков
+	for _, supplierID := range []string{"*", "supplier-?", "supplier-[0-9]"} {
+		if err := service.InvalidateSupplierCache(ctx, supplierID, "tenant-1"); err != nil {
+			t.Fatalf("InvalidateSupplierCache(%q): %v", supplierID, err)
+		}
+		if !cached(t, memoryCache, other, "tenant-1") {
+			t.Fatalf("supplier %q cleared the entries of supplier-2", supplierID)
+		}
+	}
+}
+
+// countingListRepository listRepository, считающий чтения страниц списка
+type countingListRepository struct {
+	listRepository
+	lists int
+}
+
+func (r *countingListRepository) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, order models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
+	r.lists++
+	products, total, err := r.listRepository.ListProducts(ctx, tenantID, filters, order, page, pageSize)
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products, total, err
+}
+
+func TestWritesInvalidateListCache(t *testing.T) {
+	repo := &countingListRepository{listRepository: listRepository{&batchRepository{products: map[string]*models.Product{
+		"product-1": batchProduct("product-1", 1, "Apple juice"),
+	}}}}
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+	service := NewProductService(repo, memoryCache, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	list := func(t *testing.T) []*models.Product {
+		t.Helper()
+		products, _, err := service.ListProducts(ctx, "tenant-1", nil, models.SortOption{}, 1, 20)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		return products
+	}
+
+	list(t)
+	if list(t); repo.lists != 1 {
+		t.Fatalf("repository lists = %d, want the second page read from cache", repo.lists)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		if _, err := service.CreateProduct(ctx, batchProduct("product-2", 0, "Orange juice")); err != nil {
+			t.Fatalf("CreateProduct: %v", err)
+		}
+		products := list(t)
+		if repo.lists != 2 || len(products) != 2 || products[1].ID != "product-2" {
+			t.Fatalf("repository lists = %d, products = %d, want the new product after the cache is cleared", repo.lists, len(products))
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		if _, err := service.UpdateProduct(ctx, batchProduct("product-1", 1, "Apple juice 1L")); err != nil {
+			t.Fatalf("UpdateProduct: %v", err)
+		}
+		products := list(t)
+		if repo.lists != 3 || products[0].Version != 2 {
+			t.Fatalf("repository lists = %d, version = %d, want the updated product after the cache is cleared", repo.lists, products[0].Version)
+		}
+	})
+
+	t.Run("failed update keeps cache", func(t *testing.T) {
+		if _, err := service.UpdateProduct(ctx, batchProduct("product-1", 1, "Stale")); err == nil {
+			t.Fatal("UpdateProduct accepted a stale version")
+		}
+		if list(t); repo.lists != 3 {
+			t.Fatalf("repository lists = %d, want the cached page kept after a rolled back update", repo.lists)
+		}
+	})
+}
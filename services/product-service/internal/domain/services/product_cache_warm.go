@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+// Объем прогрева кэша
+const (
+	// CacheWarmProducts число последних обновленных продуктов, записываемых в кэш при прогреве
+	CacheWarmProducts = 500
+	// CacheWarmListPages число первых страниц списка продуктов с сортировкой и размером по умолчанию
+	CacheWarmListPages = 3
+)
+
+// cacheWarmBatchSize размер страницы, которой продукты читаются из хранилища при прогреве
+const cacheWarmBatchSize = 100
+
+// WarmCache заполняет кэш тенанта после развертывания, чтобы первые запросы не уходили в базу:
+// записывает CacheWarmProducts последних обновленных продуктов и первые CacheWarmListPages страниц
+// списка в том виде, в каком их кэширует ListProducts. Одновременно кэш тенанта прогревает одна
+// реплика, параллельный вызов возвращает utils.ErrCacheWarmInProgress. Продукт, который не удалось
+// записать, попадает в Failed и не прерывает прогрев
+func (s *ProductService) WarmCache(ctx context.Context, tenantID string) (*models.CacheWarmResult, error) {
+	// Недоступность Redis для блокировки не останавливает прогрев: он лишь повторно пишет те же записи
+	unlock, acquired, err := s.cache.Lock(ctx, CacheWarmLockKey(tenantID), cacheWarmLockTTL)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Не удалось захватить блокировку прогрева кэша",
+			interfaces.LogField{Key: "tenant_id", Value: tenantID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+	} else if !acquired {
+		return nil, utils.ErrCacheWarmInProgress
+	}
+	defer unlock()
+
+	result := &models.CacheWarmResult{}
+	recent := models.SortOption{Field: "updated_at", Desc: true}
+
+	for page := 1; result.Products+len(result.Failed) < CacheWarmProducts; page++ {
+		products, _, err := s.repository.ListProducts(ctx, tenantID, nil, recent, page, cacheWarmBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list recent products: %w", err)
+		}
+
+		for _, product := range products {
+			if result.Products+len(result.Failed) == CacheWarmProducts {
+				break
+			}
+
+			productJSON, err := json.Marshal(product)
+			if err == nil {
+				err = s.cache.SetWithJitter(ctx, ProductCacheKey(product.SupplierID, product.ID), productJSON, tenantID, productCacheTTL, cacheTTLJitter)
+			}
+			if err != nil {
+				s.logger.WarnWithContext(ctx, "Ошибка сохранения продукта в кэш",
+					interfaces.LogField{Key: "error", Value: err.Error()},
+					interfaces.LogField{Key: "product_id", Value: product.ID},
+				)
+				result.Failed = append(result.Failed, product.ID)
+				continue
+			}
+			result.Products++
+		}
+
+		if len(products) < cacheWarmBatchSize {
+			break
+		}
+	}
+
+	// ListProducts кэширует страницы без фильтров сам, поэтому запись совпадает с той, что прочтут клиенты
+	listSort := models.SortOption{Field: s.GetProductSchema().DefaultSort, Desc: true}
+	for page := 1; page <= CacheWarmListPages; page++ {
+		products, total, err := s.ListProducts(ctx, tenantID, nil, listSort, page, utils.DefaultPageSize)
+		if err != nil {
+			return nil, err
+		}
+		result.ListPages++
+		if len(products) == 0 || page*utils.DefaultPageSize >= total {
+			break
+		}
+	}
+
+	s.logger.InfoWithContext(ctx, "Кэш тенанта прогрет",
+		interfaces.LogField{Key: "tenant_id", Value: tenantID},
+		interfaces.LogField{Key: "products", Value: result.Products},
+		interfaces.LogField{Key: "list_pages", Value: result.ListPages},
+		interfaces.LogField{Key: "failed", Value: len(result.Failed)},
+	)
+
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+func TestWarmCache(t *testing.T) {
+	first := batchProduct("product-1", 1, "Apple juice")
+	second := batchProduct("product-2", 1, "Orange juice")
+	second.SupplierID = "supplier-2"
+	service, memoryCache := newRecacheService(t, first, second)
+	ctx := context.Background()
+
+	result, err := service.WarmCache(ctx, "tenant-1")
+	if err != nil {
+		t.Fatalf("WarmCache: %v", err)
+	}
+	if result.Products != 2 || result.ListPages != 1 || len(result.Failed) != 0 {
+		t.Fatalf("result = %+v, want two products and one list page", result)
+	}
+
+	for _, product := range []*models.Product{first, second} {
+		if cached := cachedProduct(t, memoryCache, product); cached == nil || cached.ID != product.ID {
+			t.Fatalf("%s is not cached under its supplier key", product.ID)
+		}
+	}
+
+	// Страница списка записана под ключом, который прочтет ListProducts с параметрами по умолчанию
+	listKey := fmt.Sprintf("products:list:%s:%s:%t:%d:%d", "tenant-1", service.GetProductSchema().DefaultSort, true, 1, utils.DefaultPageSize)
+	data, err := memoryCache.GetWithTenant(ctx, listKey, "tenant-1")
+	if err != nil {
+		t.Fatalf("list page %s: %v", listKey, err)
+	}
+	var page struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil || page.Total != 2 {
+		t.Fatalf("cached list page = %s, err = %v, want both products", data, err)
+	}
+
+	// Кэш других тенантов не затрагивается
+	if _, err := memoryCache.GetWithTenant(ctx, listKey, "tenant-2"); err == nil {
+		t.Fatal("list page cached for another tenant")
+	}
+}
+
+func TestWarmCacheInProgress(t *testing.T) {
+	service, memoryCache := newRecacheService(t, batchProduct("product-1", 1, "Apple juice"))
+	ctx := context.Background()
+
+	// Блокировку держит другая реплика
+	unlock, acquired, err := memoryCache.Lock(ctx, CacheWarmLockKey("tenant-1"), time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("Lock = %v, %v", acquired, err)
+	}
+
+	if _, err := service.WarmCache(ctx, "tenant-1"); !errors.Is(err, utils.ErrCacheWarmInProgress) {
+		t.Fatalf("WarmCache = %v, want ErrCacheWarmInProgress", err)
+	}
+	if cachedProduct(t, memoryCache, batchProduct("product-1", 1, "")) != nil {
+		t.Fatal("product cached while another replica holds the lock")
+	}
+
+	// После снятия блокировки прогрев выполняется
+	unlock()
+	if result, err := service.WarmCache(ctx, "tenant-1"); err != nil || result.Products != 1 {
+		t.Fatalf("WarmCache = %+v, %v, want the product cached after unlock", result, err)
+	}
+}
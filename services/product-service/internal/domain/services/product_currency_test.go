@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+// fixedRateConverter конвертер с фиксированными курсами, округляющий как CachedConverter
+type fixedRateConverter struct {
+	rates map[string]float64
+	at    time.Time
+}
+
+func (c *fixedRateConverter) Convert(ctx context.Context, amount float64, from, to string) (float64, *interfaces.ExchangeRate, error) {
+	rate, ok := c.rates[from+"/"+to]
+	if !ok {
+		return 0, nil, pkgerrors.ErrRateNotFound
+	}
+	return math.Round(amount*rate*100) / 100, &interfaces.ExchangeRate{From: from, To: to, Rate: rate, Timestamp: c.at}, nil
+}
+
+// storedPriceRepository хранилище с одной заданной ценой
+type storedPriceRepository struct {
+	*batchRepository
+	price *models.ProductPrice
+}
+
+func (r *storedPriceRepository) GetPrice(ctx context.Context, productID, tenantID string) (*models.ProductPrice, error) {
+	if r.price == nil {
+		return nil, nil
+	}
+	price := *r.price
+	return &price, nil
+}
+
+func newCurrencyService(t *testing.T, price *models.ProductPrice) (*ProductService, *fixedRateConverter) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &storedPriceRepository{batchRepository: &batchRepository{products: make(map[string]*models.Product)}, price: price}
+	converter := &fixedRateConverter{
+		rates: map[string]float64{"RUB/USD": 0.011, "RUB/EUR": 0.01},
+		at:    time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+	}
+	service := NewProductService(repo, &batchCache{}, nil, log, nil, nil, nil, nil, converter)
+	return service, converter
+}
+
+func TestGetPriceInCurrency(t *testing.T) {
+	service, converter := newCurrencyService(t, &models.ProductPrice{
+		ProductID: "product-1", BasePrice: 2000, SpecialPrice: 1500, Currency: "RUB",
+	})
+
+	price, err := service.GetPriceInCurrency(context.Background(), "product-1", "tenant-1", "usd")
+	if err != nil {
+		t.Fatalf("GetPriceInCurrency: %v", err)
+	}
+	if price.BasePrice != 22 || price.SpecialPrice != 16.5 || price.Currency != "USD" {
+		t.Fatalf("price = %+v, want 22 and 16.5 USD", price)
+	}
+	if price.Conversion == nil || price.Conversion.SourceCurrency != "RUB" || price.Conversion.Rate != 0.011 ||
+		!price.Conversion.RateTimestamp.Equal(converter.at) {
+		t.Fatalf("conversion = %+v, want RUB at 0.011", price.Conversion)
+	}
+}
+
+func TestGetPriceInCurrencySameCurrency(t *testing.T) {
+	service, _ := newCurrencyService(t, &models.ProductPrice{ProductID: "product-1", BasePrice: 2000, Currency: "RUB"})
+
+	price, err := service.GetPriceInCurrency(context.Background(), "product-1", "tenant-1", "rub")
+	if err != nil {
+		t.Fatalf("GetPriceInCurrency: %v", err)
+	}
+	if price.BasePrice != 2000 || price.Currency != "RUB" || price.Conversion != nil {
+		t.Fatalf("price = %+v, want it returned without conversion", price)
+	}
+}
+
+func TestGetPriceInCurrencyWithoutPrice(t *testing.T) {
+	service, _ := newCurrencyService(t, nil)
+
+	price, err := service.GetPriceInCurrency(context.Background(), "product-1", "tenant-1", "USD")
+	if err != nil || price != nil {
+		t.Fatalf("price = %+v, err = %v, want nil without error", price, err)
+	}
+}
+
+func TestGetPriceInCurrencyUnsupported(t *testing.T) {
+	service, _ := newCurrencyService(t, &models.ProductPrice{ProductID: "product-1", BasePrice: 2000, Currency: "RUB"})
+
+	for _, currency := range []string{"US", "DOLLAR", "XYZ"} {
+		if _, err := service.GetPriceInCurrency(context.Background(), "product-1", "tenant-1", currency); !errors.Is(err, utils.ErrUnsupportedCurrency) {
+			t.Fatalf("%s: err = %v, want ErrUnsupportedCurrency", currency, err)
+		}
+	}
+}
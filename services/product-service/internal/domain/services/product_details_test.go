@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// detailsRepository хранилище с одним продуктом, загрузка медиа которого может завершаться ошибкой
+type detailsRepository struct {
+	postgres.ProductStoragePort
+	mediaErr error
+	priceErr error
+}
+
+func (r *detailsRepository) GetProduct(ctx context.Context, productID string, tenantID string) (*models.Product, error) {
+	return batchProduct(productID, 1, "Apple juice"), nil
+}
+
+func (r *detailsRepository) GetPrice(ctx context.Context, productID string, tenantID string) (*models.ProductPrice, error) {
+	if r.priceErr != nil {
+		return nil, r.priceErr
+	}
+	return &models.ProductPrice{ProductID: productID, BasePrice: 100, Currency: "RUB"}, nil
+}
+
+func (r *detailsRepository) GetInventory(ctx context.Context, productID string, tenantID string) (*models.ProductInventory, error) {
+	return &models.ProductInventory{ProductID: productID, Quantity: 5}, nil
+}
+
+func (r *detailsRepository) GetMediaByProductID(ctx context.Context, productID string, tenantID string) ([]*models.ProductMedia, error) {
+	if r.mediaErr != nil {
+		return nil, r.mediaErr
+	}
+	return []*models.ProductMedia{{ID: "media-1", ProductID: productID}}, nil
+}
+
+func newDetailsService(t *testing.T, repo *detailsRepository) *ProductService {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	return NewProductService(repo, &batchCache{}, nil, log, nil, nil, nil, nil, nil)
+}
+
+func TestGetProductDetailsMediaFailure(t *testing.T) {
+	service := newDetailsService(t, &detailsRepository{mediaErr: errors.New("media storage is unavailable")})
+
+	details, err := service.GetProductDetails(context.Background(), "product-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("GetProductDetails: %v", err)
+	}
+	if details.Product == nil || details.Price == nil || details.Inventory == nil {
+		t.Fatalf("details = %+v, want the core sections", details)
+	}
+	if details.SectionErrors[models.DetailsSectionMedia] == "" {
+		t.Fatalf("section errors = %v, want the media section flagged", details.SectionErrors)
+	}
+
+	// Секция медиа в ответе null, а не пустой список
+	data, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if string(body["media"]) != "null" || string(body["section_errors"]) != `{"media":"unavailable"}` {
+		t.Fatalf("media = %s, section_errors = %s", body["media"], body["section_errors"])
+	}
+}
+
+func TestGetProductDetails(t *testing.T) {
+	service := newDetailsService(t, &detailsRepository{})
+
+	details, err := service.GetProductDetails(context.Background(), "product-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("GetProductDetails: %v", err)
+	}
+	if len(details.Media) != 1 || details.SectionErrors != nil {
+		t.Fatalf("media = %v, section errors = %v, want media without errors", details.Media, details.SectionErrors)
+	}
+}
+
+func TestGetProductDetailsCoreFailure(t *testing.T) {
+	priceErr := errors.New("connection reset")
+	service := newDetailsService(t, &detailsRepository{priceErr: priceErr})
+
+	// Обязательная секция не загрузилась: запрос завершается ошибкой
+	if _, err := service.GetProductDetails(context.Background(), "product-1", "tenant-1"); !errors.Is(err, priceErr) {
+		t.Fatalf("err = %v, want the price error", err)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// MaxFacetKeys максимальное число атрибутов в одном запросе фасетов
+const MaxFacetKeys = 20
+
+// ErrInvalidFacets возвращается, если не указан ни один атрибут фасетов или их больше MaxFacetKeys
+var ErrInvalidFacets = models.NewError(models.ErrValidation, "bad_request",
+	fmt.Sprintf("Укажите от 1 до %d атрибутов в параметре facet", MaxFacetKeys))
+
+// AggregateFacets возвращает для каждого атрибута facetKeys число продуктов тенанта по его значениям
+// среди продуктов, удовлетворяющих фильтрам (тем же, что у ListProducts). Пустые и повторные ключи отбрасываются
+func (s *ProductService) AggregateFacets(ctx context.Context, tenantID string, filters map[string]interface{}, facetKeys []string) (map[string]map[string]int, error) {
+	keys := make([]string, 0, len(facetKeys))
+	seen := make(map[string]struct{}, len(facetKeys))
+	for _, key := range facetKeys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 || len(keys) > MaxFacetKeys {
+		return nil, ErrInvalidFacets
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	facets, err := s.repository.AggregateFacets(ctx, tenantID, filters, keys)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to aggregate facets",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return nil, fmt.Errorf("failed to aggregate facets: %w", err)
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// facetsRepository хранилище, запоминающее запрошенные фасеты
+type facetsRepository struct {
+	*batchRepository
+	keys    []string
+	filters map[string]interface{}
+}
+
+func (r *facetsRepository) AggregateFacets(ctx context.Context, tenantID string, filters map[string]interface{}, facetKeys []string) (map[string]map[string]int, error) {
+	r.keys, r.filters = facetKeys, filters
+	facets := make(map[string]map[string]int, len(facetKeys))
+	for _, key := range facetKeys {
+		facets[key] = map[string]int{"value": 1}
+	}
+	return facets, nil
+}
+
+func TestAggregateFacets(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	repo := &facetsRepository{batchRepository: &batchRepository{products: make(map[string]*models.Product)}}
+	service := NewProductService(repo, &batchCache{}, nil, log, nil, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	filters := map[string]interface{}{models.AttributeFilterPrefix + "color": "red"}
+	facets, err := service.AggregateFacets(ctx, "tenant-1", filters, []string{" color", "brand", "", "color"})
+	if err != nil {
+		t.Fatalf("AggregateFacets: %v", err)
+	}
+	// Пустые и повторные ключи отброшены, пробелы обрезаны
+	if !reflect.DeepEqual(repo.keys, []string{"color", "brand"}) || len(facets) != 2 {
+		t.Fatalf("keys = %q, facets = %v, want color and brand", repo.keys, facets)
+	}
+	if !reflect.DeepEqual(repo.filters, filters) {
+		t.Fatalf("filters = %v, want them passed to the repository", repo.filters)
+	}
+
+	tooMany := make([]string, MaxFacetKeys+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("attr%d", i)
+	}
+	for _, keys := range [][]string{nil, {" ", ""}, tooMany} {
+		if _, err := service.AggregateFacets(ctx, "tenant-1", nil, keys); !errors.Is(err, ErrInvalidFacets) {
+			t.Fatalf("keys %q: err = %v, want ErrInvalidFacets", keys, err)
+		}
+	}
+}
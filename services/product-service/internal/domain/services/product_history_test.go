@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// historyRepository batchRepository, запоминающий записи истории и удаляющий продукты
+type historyRepository struct {
+	*batchRepository
+	history     []*models.ProductHistoryRecord
+	failHistory bool
+}
+
+func (r *historyRepository) SaveHistoryRecord(ctx context.Context, record *models.ProductHistoryRecord, tenantID string) error {
+	if r.failHistory {
+		return errors.New("history table is unavailable")
+	}
+	// Запись сериализуется сразу, как в хранилище: последующие изменения продукта на нее не влияют
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	var saved models.ProductHistoryRecord
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	r.history = append(r.history, &saved)
+	return nil
+}
+
+func (r *historyRepository) DeleteProduct(ctx context.Context, productID string, tenantID string) error {
+	delete(r.products, productID)
+	return nil
+}
+
+func newHistoryService(t *testing.T, stored ...*models.Product) (*ProductService, *historyRepository) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &historyRepository{batchRepository: &batchRepository{products: make(map[string]*models.Product)}}
+	for _, product := range stored {
+		repo.products[product.ID] = product
+	}
+	service := NewProductService(repo, &batchCache{}, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, nil, nil)
+	return service, repo
+}
+
+func baseDataName(t *testing.T, product *models.Product) string {
+	t.Helper()
+	if product == nil {
+		return ""
+	}
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(product.BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	name, _ := baseData["name"].(string)
+	return name
+}
+
+func TestProductMutationsRecordHistory(t *testing.T) {
+	service, repo := newHistoryService(t)
+	ctx := contextkeys.WithUser(context.Background(), "user-1")
+
+	created, err := service.CreateProduct(ctx, batchProduct("product-1", 0, "Apple juice"))
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	if _, err := service.UpdateProduct(ctx, batchProduct(created.ID, created.Version, "Apple juice 1L")); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	if err := service.DeleteProduct(ctx, created.ID, "supplier-1", "tenant-1"); err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	want := []struct {
+		changeType, before, after string
+	}{
+		{changeType: models.HistoryChangeCreate, after: "Apple juice"},
+		{changeType: models.HistoryChangeUpdate, before: "Apple juice", after: "Apple juice 1L"},
+		{changeType: models.HistoryChangeDelete, before: "Apple juice 1L"},
+	}
+	if len(repo.history) != len(want) {
+		t.Fatalf("history = %d records, want %d", len(repo.history), len(want))
+	}
+	for i, w := range want {
+		record := repo.history[i]
+		if record.ChangeType != w.changeType || record.ProductID != "product-1" || record.ChangedBy != "user-1" {
+			t.Fatalf("record %d = %+v, want %s of product-1 by user-1", i, record, w.changeType)
+		}
+		if (record.Before == nil) != (w.before == "") || (record.After == nil) != (w.after == "") {
+			t.Fatalf("record %d: before = %v, after = %v", i, record.Before, record.After)
+		}
+		if before := baseDataName(t, record.Before); before != w.before {
+			t.Fatalf("record %d: before name = %q, want %q", i, before, w.before)
+		}
+		if after := baseDataName(t, record.After); after != w.after {
+			t.Fatalf("record %d: after name = %q, want %q", i, after, w.after)
+		}
+	}
+
+	// Версия в before - версия строки до перезаписи
+	if repo.history[1].Before.Version != 1 || repo.history[1].After.Version != 2 {
+		t.Fatalf("update versions = %d -> %d, want 1 -> 2", repo.history[1].Before.Version, repo.history[1].After.Version)
+	}
+}
+
+func TestProductHistoryFailureRollsBack(t *testing.T) {
+	service, repo := newHistoryService(t, batchProduct("product-1", 1, "Apple juice"))
+	repo.failHistory = true
+
+	// Изменение без записи истории не сохраняется
+	if _, err := service.UpdateProduct(context.Background(), batchProduct("product-1", 1, "Apple juice 1L")); err == nil {
+		t.Fatal("update succeeded without a history record")
+	}
+	if name := baseDataName(t, repo.products["product-1"]); name != "Apple juice" || repo.outbox != 0 {
+		t.Fatalf("name = %q, outbox = %d, want the update rolled back", name, repo.outbox)
+	}
+}
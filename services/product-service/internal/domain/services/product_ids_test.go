@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// idsRepository хранилище продуктов, запоминающее ID каждого пакетного запроса
+type idsRepository struct {
+	postgres.ProductStoragePort
+
+	products map[string]*models.Product
+	requests [][]string
+	err      error
+}
+
+func (r *idsRepository) GetProductsByIDs(ctx context.Context, ids []string, tenantID string) (map[string]*models.Product, error) {
+	r.requests = append(r.requests, append([]string(nil), ids...))
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	found := make(map[string]*models.Product)
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok {
+			stored := *product
+			found[id] = &stored
+		}
+	}
+	return found, nil
+}
+
+func newIDsService(t *testing.T, products ...*models.Product) (*ProductService, *idsRepository, interfaces.CachePort) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	repo := &idsRepository{products: make(map[string]*models.Product)}
+	for _, product := range products {
+		repo.products[product.ID] = product
+	}
+	return NewProductService(repo, memoryCache, nil, log, nil, nil, nil, nil, nil), repo, memoryCache
+}
+
+func productIDs(products map[string]*models.Product) []string {
+	ids := make([]string, 0, len(products))
+	for id := range products {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestGetProductsByIDs(t *testing.T) {
+	foreign := batchProduct("product-3", 1, "Grape juice")
+	foreign.SupplierID = "supplier-2"
+	service, repo, _ := newIDsService(t,
+		batchProduct("product-1", 1, "Apple juice"),
+		batchProduct("product-2", 1, "Orange juice"),
+		foreign,
+	)
+	ctx := context.Background()
+	ids := []string{"product-1", "missing-1", "product-2", "product-3", "missing-2"}
+
+	products, err := service.GetProductsByIDs(ctx, ids, "supplier-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("GetProductsByIDs: %v", err)
+	}
+	// Отсутствующие ID и продукт другого поставщика не возвращаются
+	if got := productIDs(products); !reflect.DeepEqual(got, []string{"product-1", "product-2"}) {
+		t.Fatalf("products = %v, want product-1 and product-2", got)
+	}
+	if len(repo.requests) != 1 || !reflect.DeepEqual(repo.requests[0], ids) {
+		t.Fatalf("repository requests = %v, want one request for all IDs", repo.requests)
+	}
+
+	// Найденные продукты берутся из кэша, в хранилище запрашиваются только промахи
+	products, err = service.GetProductsByIDs(ctx, ids, "supplier-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("GetProductsByIDs: %v", err)
+	}
+	if got := productIDs(products); !reflect.DeepEqual(got, []string{"product-1", "product-2"}) {
+		t.Fatalf("products = %v from cache, want product-1 and product-2", got)
+	}
+	want := []string{"missing-1", "product-3", "missing-2"}
+	if len(repo.requests) != 2 || !reflect.DeepEqual(repo.requests[1], want) {
+		t.Fatalf("repository requests = %v, want the second one for %v", repo.requests, want)
+	}
+
+	// Когда все продукты в кэше, хранилище не запрашивается
+	if _, err := service.GetProductsByIDs(ctx, []string{"product-2", "product-1"}, "supplier-1", "tenant-1"); err != nil {
+		t.Fatalf("GetProductsByIDs: %v", err)
+	}
+	if len(repo.requests) != 2 {
+		t.Fatalf("repository requests = %d, want no request for cached products", len(repo.requests))
+	}
+}
+
+func TestGetProductsByIDsSharesCacheWithGetProduct(t *testing.T) {
+	service, repo, memoryCache := newIDsService(t, batchProduct("product-1", 1, "Apple juice"))
+	ctx := context.Background()
+
+	if _, err := service.GetProductsByIDs(ctx, []string{"product-1"}, "supplier-1", "tenant-1"); err != nil {
+		t.Fatalf("GetProductsByIDs: %v", err)
+	}
+	if _, err := memoryCache.GetWithTenant(ctx, ProductCacheKey("supplier-1", "product-1"), "tenant-1"); err != nil {
+		t.Fatalf("loaded product not cached under the GetProduct key: %v", err)
+	}
+
+	// Испорченная запись кэша удаляется, продукт читается из хранилища
+	if err := memoryCache.SetWithTenant(ctx, ProductCacheKey("supplier-1", "product-1"), []byte("{"), "tenant-1", time.Minute); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+	products, err := service.GetProductsByIDs(ctx, []string{"product-1"}, "supplier-1", "tenant-1")
+	if err != nil || products["product-1"] == nil {
+		t.Fatalf("products = %v, err = %v, want product-1 reloaded", products, err)
+	}
+	if len(repo.requests) != 2 {
+		t.Fatalf("repository requests = %d, want the corrupted entry reloaded", len(repo.requests))
+	}
+}
+
+func TestGetProductsByIDsErrors(t *testing.T) {
+	service, repo, _ := newIDsService(t)
+	ctx := context.Background()
+
+	products, err := service.GetProductsByIDs(ctx, nil, "supplier-1", "tenant-1")
+	if err != nil || len(products) != 0 || len(repo.requests) != 0 {
+		t.Fatalf("products = %v, err = %v, want an empty result without queries", products, err)
+	}
+
+	repo.err = errors.New("connection refused")
+	if _, err := service.GetProductsByIDs(ctx, []string{"product-1"}, "supplier-1", "tenant-1"); !errors.Is(err, repo.err) {
+		t.Fatalf("err = %v, want the storage error", err)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// importBatchSize число строк CSV, сохраняемых одной транзакцией
+const importBatchSize = 500
+
+// Колонки CSV импорта. Колонки с префиксом importAttributePrefix попадают в base_data.attributes,
+// изображения перечисляются через importListSeparator, остальные неизвестные колонки игнорируются
+const (
+	importColumnSKU         = "sku"
+	importColumnName        = "name"
+	importColumnDescription = "description"
+	importColumnBrand       = "brand"
+	importColumnCategory    = "category"
+	importColumnImages      = "images"
+	importAttributePrefix   = "attr."
+	importListSeparator     = "|"
+)
+
+// importRow строка CSV, прошедшая разбор
+type importRow struct {
+	line    int
+	sku     string
+	product *models.Product
+}
+
+// ImportProductsCSV построчно читает CSV и создает или обновляет продукты поставщика по SKU.
+// Строки сохраняются пакетами по importBatchSize в отдельных транзакциях; некорректные строки
+// пропускаются и перечисляются в итоге. Ошибка возвращается только при неверном заголовке
+// (utils.ErrInvalidImportHeader) или сбое сохранения, итог уже сохраненных пакетов при этом сохраняется
+func (s *ProductService) ImportProductsCSV(ctx context.Context, file io.Reader, supplierID, tenantID string) (*models.ImportSummary, error) {
+	if supplierID == "" || tenantID == "" {
+		return nil, errors.New("supplier ID and tenant ID cannot be empty")
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidImportHeader, err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	if !containsColumn(columns, importColumnSKU) || !containsColumn(columns, importColumnName) {
+		return nil, fmt.Errorf("%w: columns %q and %q are required", utils.ErrInvalidImportHeader, importColumnSKU, importColumnName)
+	}
+
+	summary := &models.ImportSummary{}
+	batch := make([]importRow, 0, importBatchSize)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return summary, fmt.Errorf("failed to read csv: %w", err)
+			}
+			summary.AddError(parseErr.StartLine, "", parseErr.Err.Error())
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row, err := parseImportRecord(columns, record, supplierID, tenantID)
+		if err != nil {
+			summary.AddError(line, row.sku, err.Error())
+			continue
+		}
+		row.line = line
+		batch = append(batch, row)
+
+		if len(batch) == importBatchSize {
+			if err := s.importBatch(ctx, batch, supplierID, tenantID, summary); err != nil {
+				return summary, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := s.importBatch(ctx, batch, supplierID, tenantID, summary); err != nil {
+			return summary, err
+		}
+	}
+
+	s.logger.InfoWithContext(ctx, "Импорт продуктов завершен",
+		interfaces.LogField{Key: "supplier_id", Value: supplierID},
+		interfaces.LogField{Key: "created", Value: summary.Created},
+		interfaces.LogField{Key: "updated", Value: summary.Updated},
+		interfaces.LogField{Key: "failed", Value: summary.Failed},
+	)
+
+	return summary, nil
+}
+
+// importBatch сохраняет пакет строк одной транзакцией и обновляет счетчики итога после коммита
+func (s *ProductService) importBatch(ctx context.Context, batch []importRow, supplierID, tenantID string, summary *models.ImportSummary) error {
+	var created, updated int
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		created, updated = 0, 0
+
+		for _, row := range batch {
+			product := row.product
+			before, err := s.repository.GetProductBySKU(txCtx, row.sku, supplierID, tenantID)
+			if err != nil && !errors.Is(err, utils.ErrProductNotFound) {
+				return err
+			}
+
+			if product.ID == "" {
+				product.ID = uuid.New().String()
+			}
+			inserted, err := s.repository.UpsertProductBySKU(txCtx, product)
+			if err != nil {
+				return err
+			}
+
+			changeType := models.HistoryChangeUpdate
+			eventType := messaging.ProductUpdatedEvent
+			if inserted {
+				changeType = models.HistoryChangeCreate
+				eventType = messaging.ProductCreatedEvent
+				created++
+			} else {
+				updated++
+			}
+			if err := s.recordHistory(txCtx, changeType, product.ID, tenantID, before, product); err != nil {
+				return err
+			}
+			if err := s.enqueueProductEvent(txCtx, eventType, product.ID, tenantID, &messaging.ProductEventPayload{SupplierID: supplierID, SKU: row.sku}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Ошибка сохранения пакета импорта",
+			interfaces.LogField{Key: "supplier_id", Value: supplierID},
+			interfaces.LogField{Key: "first_line", Value: batch[0].line},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return fmt.Errorf("failed to import products: %w", err)
+	}
+
+	summary.Created += created
+	summary.Updated += updated
+
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
+	for _, row := range batch {
+		_ = s.cache.DeleteWithTenant(ctx, ProductCacheKey(supplierID, row.product.ID), tenantID)
+	}
+
+	return nil
+}
+
+// parseImportRecord преобразует строку CSV в продукт. SKU заполняется и при ошибке, чтобы попасть в итог
+func parseImportRecord(columns, record []string, supplierID, tenantID string) (importRow, error) {
+	if len(record) != len(columns) {
+		return importRow{}, fmt.Errorf("expected %d fields, got %d", len(columns), len(record))
+	}
+
+	baseData := make(map[string]interface{})
+	attributes := make(map[string]interface{})
+	for i, column := range columns {
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+
+		switch {
+		case column == importColumnSKU, column == importColumnName, column == importColumnDescription,
+			column == importColumnBrand, column == importColumnCategory:
+			baseData[column] = value
+		case column == importColumnImages:
+			var images []string
+			for _, image := range strings.Split(value, importListSeparator) {
+				if image = strings.TrimSpace(image); image != "" {
+					images = append(images, image)
+				}
+			}
+			baseData[column] = images
+		case strings.HasPrefix(column, importAttributePrefix) && len(column) > len(importAttributePrefix):
+			attributes[strings.TrimPrefix(column, importAttributePrefix)] = value
+		}
+	}
+	if len(attributes) > 0 {
+		baseData["attributes"] = attributes
+	}
+
+	sku, _ := baseData[importColumnSKU].(string)
+	row := importRow{sku: sku}
+	if sku == "" {
+		return row, errors.New("sku is required")
+	}
+	if _, ok := baseData[importColumnName]; !ok {
+		return row, errors.New("name is required")
+	}
+
+	data, err := json.Marshal(baseData)
+	if err != nil {
+		return row, fmt.Errorf("failed to marshal base data: %w", err)
+	}
+
+	row.product = &models.Product{
+		SupplierID: supplierID,
+		TenantID:   tenantID,
+		BaseData:   data,
+	}
+	if err := row.product.Validate(); err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+// containsColumn проверяет наличие колонки в заголовке
+func containsColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
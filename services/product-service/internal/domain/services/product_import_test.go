@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+func newImportService(t *testing.T, stored ...*models.Product) (*ProductService, *skuRepository) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &skuRepository{batchRepository: &batchRepository{products: make(map[string]*models.Product)}}
+	for _, product := range stored {
+		repo.products[product.ID] = product
+	}
+	service := NewProductService(repo, &batchCache{}, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, nil, nil)
+	return service, repo
+}
+
+func TestImportProductsCSV(t *testing.T) {
+	existing := &models.Product{
+		ID:         "product-1",
+		TenantID:   "tenant-1",
+		SupplierID: "supplier-1",
+		BaseData:   json.RawMessage(`{"sku":"AJ-1","name":"Apple juice"}`),
+		Version:    1,
+	}
+	service, repo := newImportService(t, existing)
+
+	const file = "SKU, Name ,description,images,attr.volume,unknown\n" +
+		"AJ-1,Apple juice 1L,Fresh,a.jpg|b.jpg,1L,ignored\n" +
+		"OJ-1,Orange juice,,,,\n" +
+		"GJ-1,Grape juice,,c.jpg,0.5L,\n"
+
+	summary, err := service.ImportProductsCSV(context.Background(), strings.NewReader(file), "supplier-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("ImportProductsCSV: %v", err)
+	}
+	if summary.Created != 2 || summary.Updated != 1 || summary.Failed != 0 || len(summary.Errors) != 0 {
+		t.Fatalf("summary = %+v, want 2 created and 1 updated", summary)
+	}
+	if len(repo.products) != 3 || repo.outbox != 3 {
+		t.Fatalf("products = %d, outbox = %d, want 3 and an event per row", len(repo.products), repo.outbox)
+	}
+
+	updated := repo.products["product-1"]
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(updated.BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	images, _ := baseData["images"].([]interface{})
+	attributes, _ := baseData["attributes"].(map[string]interface{})
+	if baseData["name"] != "Apple juice 1L" || baseData["description"] != "Fresh" || len(images) != 2 ||
+		attributes["volume"] != "1L" || baseData["unknown"] != nil || updated.Version != 2 {
+		t.Fatalf("product-1 = %s (version %d), want the columns mapped into base_data", updated.BaseData, updated.Version)
+	}
+}
+
+func TestImportProductsCSVBadRows(t *testing.T) {
+	service, repo := newImportService(t)
+
+	const file = "sku,name,category\n" +
+		"AJ-1,Apple juice,drinks\n" + // строка 2
+		",No SKU,drinks\n" + // строка 3
+		"OJ-1,,drinks\n" + // строка 4
+		"GJ-1,Grape juice\n" + // строка 5
+		"BQ-1,Bad \"quote,drinks\n" + // строка 6
+		"PJ-1,Pear juice,drinks\n" // строка 7
+
+	summary, err := service.ImportProductsCSV(context.Background(), strings.NewReader(file), "supplier-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("ImportProductsCSV: %v", err)
+	}
+	if summary.Created != 2 || summary.Updated != 0 || summary.Failed != 4 {
+		t.Fatalf("summary = %+v, want 2 created and 4 failed", summary)
+	}
+	if len(repo.products) != 2 {
+		t.Fatalf("products = %d, want the valid rows saved", len(repo.products))
+	}
+
+	want := []models.ImportRowError{
+		{Line: 3, Error: "sku is required"},
+		{Line: 4, SKU: "OJ-1", Error: "name is required"},
+		{Line: 5, Error: "expected 3 fields, got 2"},
+		{Line: 6},
+	}
+	if len(summary.Errors) != len(want) {
+		t.Fatalf("errors = %+v, want %d", summary.Errors, len(want))
+	}
+	for i, rowErr := range summary.Errors {
+		if rowErr.Line != want[i].Line || rowErr.SKU != want[i].SKU || (want[i].Error != "" && rowErr.Error != want[i].Error) || rowErr.Error == "" {
+			t.Fatalf("errors[%d] = %+v, want %+v", i, rowErr, want[i])
+		}
+	}
+}
+
+func TestImportProductsCSVInvalidHeader(t *testing.T) {
+	service, repo := newImportService(t)
+
+	for _, file := range []string{"", "name,brand\nApple juice,Fresh\n"} {
+		_, err := service.ImportProductsCSV(context.Background(), strings.NewReader(file), "supplier-1", "tenant-1")
+		if !errors.Is(err, utils.ErrInvalidImportHeader) {
+			t.Fatalf("ImportProductsCSV(%q) error = %v, want ErrInvalidImportHeader", file, err)
+		}
+	}
+	if len(repo.products) != 0 {
+		t.Fatal("products saved for a file without a valid header")
+	}
+}
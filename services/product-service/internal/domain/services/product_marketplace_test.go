@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	pkgmodels "github.com/athebyme/gomarket-platform/pkg/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// fakeMarketplace возвращает статусы по очереди и запоминает отправленные продукты
+type fakeMarketplace struct {
+	statuses []string
+	err      error
+	pushed   []*models.Product
+}
+
+func (m *fakeMarketplace) PushProduct(ctx context.Context, product *models.Product, marketplaceID int) (*pkgmodels.MarketplaceProduct, error) {
+	m.pushed = append(m.pushed, product)
+	if m.err != nil {
+		return nil, m.err
+	}
+	status := m.statuses[0]
+	m.statuses = m.statuses[1:]
+	return &pkgmodels.MarketplaceProduct{ExternalID: "ext-1", Status: status}, nil
+}
+
+// marketplaceRepository хранит статусы синхронизации по маркетплейсам, как product.marketplace_products
+type marketplaceRepository struct {
+	*batchRepository
+	statuses map[int]*pkgmodels.MarketplaceProduct
+}
+
+func (r *marketplaceRepository) GetMarketplaceMapping(ctx context.Context, marketplaceID int, tenantID string) (*models.MarketplaceFieldMapping, error) {
+	return nil, nil
+}
+
+func (r *marketplaceRepository) SaveMarketplaceProduct(ctx context.Context, product *pkgmodels.MarketplaceProduct, tenantID string) error {
+	saved := *product
+	if stored, ok := r.statuses[product.MarketplaceID]; ok && saved.ExternalID == "" {
+		saved.ExternalID = stored.ExternalID
+	}
+	r.statuses[product.MarketplaceID] = &saved
+	return nil
+}
+
+func (r *marketplaceRepository) ListMarketplaceProducts(ctx context.Context, productID string, tenantID string) ([]*pkgmodels.MarketplaceProduct, error) {
+	var statuses []*pkgmodels.MarketplaceProduct
+	for _, status := range r.statuses {
+		if status.CoreProductID == productID {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+func newMarketplaceService(t *testing.T, marketplace *fakeMarketplace) (*ProductService, *marketplaceRepository) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &marketplaceRepository{
+		batchRepository: &batchRepository{products: map[string]*models.Product{
+			"product-1": batchProduct("product-1", 1, "Apple juice"),
+		}},
+		statuses: make(map[int]*pkgmodels.MarketplaceProduct),
+	}
+	service := NewProductService(repo, &batchCache{}, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, marketplace, nil, nil)
+	return service, repo
+}
+
+func TestPushProductToMarketplaceTracksStatus(t *testing.T) {
+	marketplace := &fakeMarketplace{statuses: []string{models.MarketplaceStatusPending, models.MarketplaceStatusActive}}
+	service, repo := newMarketplaceService(t, marketplace)
+	ctx := context.Background()
+
+	for _, want := range []string{models.MarketplaceStatusPending, models.MarketplaceStatusActive} {
+		status, err := service.PushProductToMarketplace(ctx, "product-1", 7, "tenant-1")
+		if err != nil {
+			t.Fatalf("PushProductToMarketplace: %v", err)
+		}
+		if status.Status != want || status.MarketplaceID != 7 || status.CoreProductID != "product-1" || status.UpdatedAt.IsZero() {
+			t.Fatalf("status = %+v, want %s for product-1 in marketplace 7", status, want)
+		}
+
+		statuses, err := service.GetMarketplaceStatuses(ctx, "product-1", "tenant-1")
+		if err != nil {
+			t.Fatalf("GetMarketplaceStatuses: %v", err)
+		}
+		// Статус маркетплейса перезаписывается, а не копится
+		if len(statuses) != 1 || statuses[0].Status != want || statuses[0].ExternalID != "ext-1" {
+			t.Fatalf("statuses = %+v, want one %s status", statuses, want)
+		}
+	}
+
+	// Без маппинга в маркетплейс уходит base_data продукта
+	var payload map[string]interface{}
+	if err := json.Unmarshal(marketplace.pushed[0].BaseData, &payload); err != nil || payload["name"] != "Apple juice" {
+		t.Fatalf("payload = %v, err = %v, want the base data", payload, err)
+	}
+	if len(repo.statuses) != 1 {
+		t.Fatalf("statuses = %d, want one per marketplace", len(repo.statuses))
+	}
+}
+
+func TestPushProductToMarketplaceFailure(t *testing.T) {
+	pushErr := errors.New("marketplace rejected the card")
+	service, repo := newMarketplaceService(t, &fakeMarketplace{err: pushErr})
+
+	if _, err := service.PushProductToMarketplace(context.Background(), "product-1", 7, "tenant-1"); !errors.Is(err, pushErr) {
+		t.Fatalf("err = %v, want the marketplace error", err)
+	}
+
+	// Ошибка сохраняется в статусе вместе с причиной
+	status := repo.statuses[7]
+	if status == nil || status.Status != models.MarketplaceStatusError || status.StatusMessage != pushErr.Error() {
+		t.Fatalf("status = %+v, want an error status with the reason", status)
+	}
+}
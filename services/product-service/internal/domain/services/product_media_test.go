@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// memoryObjectStore объектное хранилище в памяти
+type memoryObjectStore struct {
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func (s *memoryObjectStore) PutObject(ctx context.Context, key string, reader io.Reader, size int64, contentType string) (string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	s.objects[key] = data
+	s.contentTypes[key] = contentType
+	return "https://cdn.example.com/" + key, nil
+}
+
+func (s *memoryObjectStore) DeleteObject(ctx context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+// mediaRepository медиафайлы в памяти поверх batchRepository
+type mediaRepository struct {
+	*batchRepository
+	media      map[string]*models.ProductMedia
+	batchLoads int
+}
+
+func (r *mediaRepository) GetMediaByProductID(ctx context.Context, productID string, tenantID string) ([]*models.ProductMedia, error) {
+	var media []*models.ProductMedia
+	for _, m := range r.media {
+		if m.ProductID == productID {
+			media = append(media, m)
+		}
+	}
+	return media, nil
+}
+
+func (r *mediaRepository) SaveMedia(ctx context.Context, media *models.ProductMedia, tenantID string) error {
+	r.media[media.ID] = media
+	return nil
+}
+
+func (r *mediaRepository) GetMedia(ctx context.Context, mediaID string, tenantID string) (*models.ProductMedia, error) {
+	return r.media[mediaID], nil
+}
+
+func (r *mediaRepository) DeleteMedia(ctx context.Context, mediaID string, tenantID string) error {
+	delete(r.media, mediaID)
+	return nil
+}
+
+func (r *mediaRepository) GetMediaByProductIDs(ctx context.Context, productIDs []string, tenantID string) (map[string][]*models.ProductMedia, error) {
+	r.batchLoads++
+	mediaByProduct := make(map[string][]*models.ProductMedia)
+	for _, productID := range productIDs {
+		media, _ := r.GetMediaByProductID(ctx, productID, tenantID)
+		sort.Slice(media, func(i, j int) bool { return media[i].Position < media[j].Position })
+		if len(media) > 0 {
+			mediaByProduct[productID] = media
+		}
+	}
+	return mediaByProduct, nil
+}
+
+func newMediaService(t *testing.T) (*ProductService, *mediaRepository, *memoryObjectStore) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &mediaRepository{
+		batchRepository: &batchRepository{products: map[string]*models.Product{
+			"product-1": batchProduct("product-1", 1, "Apple juice"),
+		}},
+		media: make(map[string]*models.ProductMedia),
+	}
+	objects := &memoryObjectStore{objects: make(map[string][]byte), contentTypes: make(map[string]string)}
+	service := NewProductService(repo, &batchCache{}, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, objects, nil)
+	return service, repo, objects
+}
+
+// testImage кодирует однотонное изображение width×height в формат contentType
+func testImage(t *testing.T, contentType string, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 80, B: 40, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encode %s: %v", contentType, err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadProductMediaThumbnails(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		width       int
+		height      int
+		// want размеры миниатюр thumb_128 и thumb_512
+		want      [][2]int
+		wantType  string
+		wantImage string
+	}{
+		{name: "landscape png", contentType: "image/png", width: 600, height: 300, want: [][2]int{{128, 64}, {512, 256}}, wantType: "image/png", wantImage: "png"},
+		// Изображение меньше 512 не увеличивается
+		{name: "portrait jpeg", contentType: "image/jpeg", width: 200, height: 400, want: [][2]int{{64, 128}, {200, 400}}, wantType: "image/jpeg", wantImage: "jpeg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, objects := newMediaService(t)
+
+			data := testImage(t, tt.contentType, tt.width, tt.height)
+			media, err := service.UploadProductMedia(context.Background(), "product-1", "tenant-1", bytes.NewReader(data), int64(len(data)), tt.contentType)
+			if err != nil {
+				t.Fatalf("UploadProductMedia: %v", err)
+			}
+			if !bytes.Equal(objects.objects[media.ObjectKey], data) {
+				t.Fatal("original stored modified")
+			}
+			if len(media.Variants) != len(tt.want) || len(repo.media) != 1+len(tt.want) {
+				t.Fatalf("variants = %d, rows = %d, want %d variants saved next to the original",
+					len(media.Variants), len(repo.media), len(tt.want))
+			}
+
+			for i, variant := range media.Variants {
+				if variant.Variant != models.ThumbnailVariant(models.ThumbnailSizes[i]) || variant.ParentID != media.ID ||
+					variant.Position != media.Position || repo.media[variant.ID] == nil {
+					t.Fatalf("variant = %+v, want a saved %s of %s", variant, models.ThumbnailVariant(models.ThumbnailSizes[i]), media.ID)
+				}
+
+				config, format, err := image.DecodeConfig(bytes.NewReader(objects.objects[variant.ObjectKey]))
+				if err != nil {
+					t.Fatalf("decode %s: %v", variant.Variant, err)
+				}
+				if config.Width != tt.want[i][0] || config.Height != tt.want[i][1] {
+					t.Fatalf("%s = %dx%d, want %dx%d", variant.Variant, config.Width, config.Height, tt.want[i][0], tt.want[i][1])
+				}
+				if format != tt.wantImage || objects.contentTypes[variant.ObjectKey] != tt.wantType {
+					t.Fatalf("%s format = %s stored as %s, want %s", variant.Variant, format, objects.contentTypes[variant.ObjectKey], tt.wantType)
+				}
+			}
+
+			// Удаление оригинала удаляет и миниатюры
+			if err := service.DeleteProductMedia(context.Background(), "product-1", media.ID, "tenant-1"); err != nil {
+				t.Fatalf("DeleteProductMedia: %v", err)
+			}
+			if len(repo.media) != 0 || len(objects.objects) != 0 {
+				t.Fatalf("rows = %d, objects = %d after delete, want none", len(repo.media), len(objects.objects))
+			}
+		})
+	}
+}
+
+func TestUploadProductMediaSkipsThumbnails(t *testing.T) {
+	service, repo, objects := newMediaService(t)
+
+	video := []byte("\x00\x00\x00\x18ftypmp42")
+	media, err := service.UploadProductMedia(context.Background(), "product-1", "tenant-1", bytes.NewReader(video), int64(len(video)), "video/mp4")
+	if err != nil {
+		t.Fatalf("UploadProductMedia: %v", err)
+	}
+	if media.Type != models.MediaTypeVideo || len(media.Variants) != 0 || len(repo.media) != 1 || len(objects.objects) != 1 {
+		t.Fatalf("media = %+v, rows = %d, objects = %d, want only the video", media, len(repo.media), len(objects.objects))
+	}
+}
+
+func TestThumbnailDimensions(t *testing.T) {
+	tests := []struct {
+		width, height, size int
+		wantW, wantH        int
+	}{
+		{width: 1000, height: 500, size: 128, wantW: 128, wantH: 64},
+		{width: 500, height: 1000, size: 128, wantW: 64, wantH: 128},
+		{width: 800, height: 800, size: 512, wantW: 512, wantH: 512},
+		{width: 100, height: 50, size: 128, wantW: 100, wantH: 50},
+		// Очень узкое изображение не схлопывается в ноль
+		{width: 5000, height: 10, size: 128, wantW: 128, wantH: 1},
+	}
+
+	for _, tt := range tests {
+		w, h := thumbnailDimensions(tt.width, tt.height, tt.size)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("thumbnailDimensions(%d, %d, %d) = %dx%d, want %dx%d", tt.width, tt.height, tt.size, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestGenerateThumbnailWebP(t *testing.T) {
+	if !supportsThumbnails("image/webp") || supportsThumbnails("image/gif") {
+		t.Fatal("webp must get thumbnails, gif must not")
+	}
+
+	// WebP декодируется, но кодировщика в стандартной поставке нет, поэтому миниатюра - JPEG
+	thumb, err := generateThumbnail(image.NewRGBA(image.Rect(0, 0, 300, 150)), "image/webp", 128)
+	if err != nil {
+		t.Fatalf("generateThumbnail: %v", err)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(thumb.data))
+	if err != nil || format != "jpeg" || thumb.contentType != "image/jpeg" || config.Width != 128 || config.Height != 64 {
+		t.Fatalf("thumbnail = %s %dx%d (%s), err = %v, want jpeg 128x64", format, config.Width, config.Height, thumb.contentType, err)
+	}
+}
+
+func TestResolvePrimaryImages(t *testing.T) {
+	service, repo, _ := newMediaService(t)
+	for _, media := range []*models.ProductMedia{
+		{ID: "video", ProductID: "product-1", Type: models.MediaTypeVideo, URL: "https://cdn.example.com/video.mp4", Position: 0},
+		{ID: "thumbnail", ProductID: "product-1", Type: models.MediaTypeImage, URL: "https://cdn.example.com/thumb.jpg", Position: 1,
+			Variant: models.ThumbnailVariant(128), ParentID: "back"},
+		{ID: "back", ProductID: "product-1", Type: models.MediaTypeImage, URL: "https://cdn.example.com/back.jpg", Position: 3},
+		{ID: "front", ProductID: "product-1", Type: models.MediaTypeImage, URL: "https://cdn.example.com/front.jpg", Position: 2},
+		{ID: "other", ProductID: "product-2", Type: models.MediaTypeVideo, URL: "https://cdn.example.com/other.mp4", Position: 0},
+	} {
+		repo.media[media.ID] = media
+	}
+
+	products := []*models.Product{{ID: "product-1"}, {ID: "product-2"}, {ID: "product-3"}}
+	if err := service.ResolvePrimaryImages(context.Background(), products, "tenant-1"); err != nil {
+		t.Fatalf("ResolvePrimaryImages: %v", err)
+	}
+
+	// Видео и миниатюры пропускаются, выбирается изображение с наименьшей позицией
+	if products[0].PrimaryImageURL != "https://cdn.example.com/front.jpg" {
+		t.Fatalf("primary image = %q, want the front image", products[0].PrimaryImageURL)
+	}
+	if products[1].PrimaryImageURL != "" || products[2].PrimaryImageURL != "" {
+		t.Fatalf("primary images = %q, %q, want none without images", products[1].PrimaryImageURL, products[2].PrimaryImageURL)
+	}
+	if repo.batchLoads != 1 {
+		t.Fatalf("media loads = %d, want one for the whole list", repo.batchLoads)
+	}
+
+	if err := service.ResolvePrimaryImages(context.Background(), nil, "tenant-1"); err != nil || repo.batchLoads != 1 {
+		t.Fatalf("empty list: loads = %d, err = %v, want no media query", repo.batchLoads, err)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+func TestPatchProduct(t *testing.T) {
+	stored := batchProduct("product-1", 3, "Apple juice")
+	stored.BaseData = json.RawMessage(`{"name":"Apple juice","price":100,"brand":"Garden","attributes":{"volume":"1L","color":"green"}}`)
+	service, repo, cache := newBatchService(t, stored)
+
+	product, err := service.PatchProduct(context.Background(), "product-1", "tenant-1",
+		json.RawMessage(`{"price":120,"brand":null,"attributes":{"color":"red"}}`), 3)
+	if err != nil {
+		t.Fatalf("PatchProduct: %v", err)
+	}
+
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(repo.products["product-1"].BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	want := map[string]interface{}{
+		"name":       "Apple juice",
+		"price":      float64(120),
+		"attributes": map[string]interface{}{"volume": "1L", "color": "red"},
+	}
+	if !reflect.DeepEqual(baseData, want) {
+		t.Fatalf("base_data = %v, want %v", baseData, want)
+	}
+	if product.Version != 4 || repo.products["product-1"].SupplierID != "supplier-1" {
+		t.Fatalf("product = %+v, want version 4 of supplier-1", product)
+	}
+	if repo.outbox != 1 || cache.invalidations != 2 {
+		t.Fatalf("outbox = %d, invalidations = %d, want an event and the product and list caches cleared", repo.outbox, cache.invalidations)
+	}
+}
+
+func TestPatchProductErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		patch   string
+		version int
+		want    error
+	}{
+		{name: "stale version", id: "product-1", patch: `{"price":120}`, version: 2, want: utils.ErrVersionConflict},
+		{name: "not an object", id: "product-1", patch: `[{"op":"replace"}]`, want: models.ErrInvalidMergePatch},
+		{name: "unknown product", id: "product-2", patch: `{"price":120}`, want: utils.ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, _ := newBatchService(t, batchProduct("product-1", 3, "Apple juice"))
+
+			_, err := service.PatchProduct(context.Background(), tt.id, "tenant-1", json.RawMessage(tt.patch), tt.version)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if repo.products["product-1"].Version != 3 || repo.outbox != 0 {
+				t.Fatal("product changed by a rejected patch")
+			}
+		})
+	}
+}
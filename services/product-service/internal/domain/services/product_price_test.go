@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// priceRepository хранилище, запоминающее цены и события из outbox
+type priceRepository struct {
+	*batchRepository
+	prices   []*models.ProductPrice
+	messages []*models.OutboxMessage
+	events   []*messaging.EventEnvelope
+	failSave error
+}
+
+func (r *priceRepository) SavePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error {
+	if r.failSave != nil {
+		return r.failSave
+	}
+	r.prices = append(r.prices, price)
+	return nil
+}
+
+func (r *priceRepository) SaveOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	envelope, err := messaging.DecodeEvent(message.Payload)
+	if err != nil {
+		return err
+	}
+	r.messages = append(r.messages, message)
+	r.events = append(r.events, envelope)
+	return r.batchRepository.SaveOutboxMessage(ctx, message)
+}
+
+func newPriceService(t *testing.T) (*ProductService, *priceRepository, *batchCache) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &priceRepository{batchRepository: &batchRepository{products: make(map[string]*models.Product)}}
+	cache := &batchCache{}
+	service := NewProductService(repo, cache, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, nil, nil)
+	return service, repo, cache
+}
+
+func TestUpdatePricePublishesEvent(t *testing.T) {
+	service, repo, cache := newPriceService(t)
+
+	price := &models.ProductPrice{ProductID: "product-1", SupplierID: "supplier-1", BasePrice: 150, SpecialPrice: 120, Currency: "RUB"}
+	if err := service.UpdatePrice(context.Background(), price, "tenant-1"); err != nil {
+		t.Fatalf("UpdatePrice: %v", err)
+	}
+
+	if len(repo.prices) != 1 || repo.prices[0].UpdatedAt.IsZero() {
+		t.Fatalf("prices = %+v, want one price with UpdatedAt set", repo.prices)
+	}
+	if len(repo.events) != 1 || repo.events[0].EventType != messaging.ProductPriceUpdatedEvent {
+		t.Fatalf("events = %+v, want one %s", repo.events, messaging.ProductPriceUpdatedEvent)
+	}
+	var payload messaging.ProductPriceUpdatedPayload
+	if err := json.Unmarshal(repo.events[0].Payload, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.ProductID != "product-1" || payload.SupplierID != "supplier-1" || payload.Price != 150 {
+		t.Fatalf("payload = %+v, want the base price of product-1", payload)
+	}
+	if repo.outbox != 1 || cache.invalidations != 1 {
+		t.Fatalf("outbox = %d, invalidations = %d, want the event committed and the cache cleared", repo.outbox, cache.invalidations)
+	}
+}
+
+func TestUpdatePriceFailureDoesNotPublish(t *testing.T) {
+	service, repo, cache := newPriceService(t)
+	repo.failSave = errors.New("connection reset")
+
+	price := &models.ProductPrice{ProductID: "product-1", SupplierID: "supplier-1", BasePrice: 150, Currency: "RUB"}
+	if err := service.UpdatePrice(context.Background(), price, "tenant-1"); !errors.Is(err, repo.failSave) {
+		t.Fatalf("err = %v, want the storage error", err)
+	}
+	if len(repo.events) != 0 || repo.outbox != 0 || cache.invalidations != 0 {
+		t.Fatalf("events = %d, outbox = %d, invalidations = %d, want nothing for a failed save",
+			len(repo.events), repo.outbox, cache.invalidations)
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// listRepository batchRepository, который также возвращает все продукты списком
+type listRepository struct {
+	*batchRepository
+}
+
+func (r listRepository) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
+	var products []*models.Product
+	for _, product := range r.products {
+		if supplierID, ok := filters["supplier_id"]; ok && product.SupplierID != supplierID {
+			continue
+		}
+		products = append(products, product)
+	}
+	return products, len(products), nil
+}
+
+func newRecacheService(t *testing.T, stored ...*models.Product) (*ProductService, interfaces.CachePort) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := listRepository{&batchRepository{products: make(map[string]*models.Product)}}
+	for _, product := range stored {
+		repo.products[product.ID] = product
+	}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	return NewProductService(repo, memoryCache, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, nil, nil), memoryCache
+}
+
+// cachedProduct читает продукт из кэша по ключу поставщика
+func cachedProduct(t *testing.T, c interfaces.CachePort, product *models.Product) *models.Product {
+	t.Helper()
+
+	data, err := c.GetWithTenant(context.Background(), ProductCacheKey(product.SupplierID, product.ID), product.TenantID)
+	if errors.Is(err, pkgerrors.ErrCacheMiss) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("GetWithTenant: %v", err)
+	}
+	var cached models.Product
+	if err := json.Unmarshal(data, &cached); err != nil {
+		t.Fatalf("unmarshal cached product: %v", err)
+	}
+	return &cached
+}
+
+func TestRecacheProductsByID(t *testing.T) {
+	fixed := batchProduct("product-1", 2, "Apple juice")
+	untouched := batchProduct("product-2", 1, "Orange juice")
+	service, memoryCache := newRecacheService(t, fixed, untouched)
+	ctx := context.Background()
+
+	// В кэше устаревшая версия продукта и страница списка
+	stale, _ := json.Marshal(batchProduct("product-1", 1, "Apple jiuce"))
+	if err := memoryCache.SetWithTenant(ctx, ProductCacheKey(fixed.SupplierID, fixed.ID), stale, "tenant-1", time.Hour); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+	if err := memoryCache.SetWithTenant(ctx, "products:list:page:1", []byte(`[]`), "tenant-1", time.Hour); err != nil {
+		t.Fatalf("SetWithTenant: %v", err)
+	}
+
+	result, err := service.RecacheProducts(ctx, "tenant-1", []string{"product-1", "missing"}, nil)
+	if err != nil {
+		t.Fatalf("RecacheProducts: %v", err)
+	}
+	if result.Refreshed != 1 || len(result.NotFound) != 1 || result.NotFound[0] != "missing" || len(result.Failed) != 0 {
+		t.Fatalf("result = %+v, want one refreshed and one not found", result)
+	}
+
+	cached := cachedProduct(t, memoryCache, fixed)
+	if cached == nil || cached.Version != 2 || string(cached.BaseData) != string(fixed.BaseData) {
+		t.Fatalf("cached = %+v, want the stored product", cached)
+	}
+	if cachedProduct(t, memoryCache, untouched) != nil {
+		t.Fatal("product outside the request was cached")
+	}
+	if _, err := memoryCache.GetWithTenant(ctx, "products:list:page:1", "tenant-1"); !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		t.Fatalf("list page: err = %v, want it invalidated", err)
+	}
+}
+
+func TestRecacheProductsByFilter(t *testing.T) {
+	first := batchProduct("product-1", 1, "Apple juice")
+	second := batchProduct("product-2", 1, "Orange juice")
+	other := batchProduct("product-3", 1, "Water")
+	other.SupplierID = "supplier-2"
+	service, memoryCache := newRecacheService(t, first, second, other)
+
+	result, err := service.RecacheProducts(context.Background(), "tenant-1", nil, map[string]interface{}{"supplier_id": "supplier-1"})
+	if err != nil {
+		t.Fatalf("RecacheProducts: %v", err)
+	}
+	if result.Refreshed != 2 {
+		t.Fatalf("refreshed = %d, want 2", result.Refreshed)
+	}
+	if cachedProduct(t, memoryCache, first) == nil || cachedProduct(t, memoryCache, second) == nil {
+		t.Fatal("filtered products were not cached")
+	}
+	if cachedProduct(t, memoryCache, other) != nil {
+		t.Fatal("product of another supplier was cached")
+	}
+}
@@ -1,47 +1,108 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/athebyme/gomarket-platform/pkg/tx"
+	"image"
+	"io"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
 	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	pkgmodels "github.com/athebyme/gomarket-platform/pkg/models"
+	pkgutils "github.com/athebyme/gomarket-platform/pkg/utils"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/marketplace"
 	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
 	postgres "github.com/athebyme/gomarket-platform/product-service/internal/adapters/storage"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/supplier"
 	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 type ProductServiceInterface interface {
 	// Основные CRUD операции
 	CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error)
 	GetProduct(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error)
+	GetProductsByIDs(ctx context.Context, productIDs []string, supplierID, tenantID string) (map[string]*models.Product, error)
+	GetProductDetails(ctx context.Context, productID, tenantID string) (*models.ProductDetails, error)
 	UpdateProduct(ctx context.Context, product *models.Product) (*models.Product, error)
+	PatchProduct(ctx context.Context, productID, tenantID string, patch json.RawMessage, expectedVersion int) (*models.Product, error)
+	UpsertProductBySKU(ctx context.Context, product *models.Product, sku string) (*models.Product, bool, error)
 	DeleteProduct(ctx context.Context, productID, supplierID, tenantID string) error
-	ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, page, pageSize int) ([]*models.Product, int, error)
+	ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error)
+	ListProductsAfter(ctx context.Context, tenantID string, filters map[string]interface{}, cursor string, limit int) ([]*models.Product, string, error)
+	CountProducts(ctx context.Context, tenantID string, filters map[string]interface{}) (int, error)
+	ListSuppliers(ctx context.Context, tenantID string) ([]string, error)
+	AggregateFacets(ctx context.Context, tenantID string, filters map[string]interface{}, facetKeys []string) (map[string]map[string]int, error)
+	ExportProducts(ctx context.Context, tenantID string, filters map[string]interface{}, visit func(*models.Product) error, pageDone func() error) (int, error)
+	SearchProducts(ctx context.Context, tenantID, query string, page, pageSize int) ([]*models.Product, int, error)
+	GetProductsByCategory(ctx context.Context, categoryID, tenantID string, page, pageSize int) ([]*models.Product, int, error)
+	GetProductSchema() *models.ProductSchema
+
+	// Пакетные операции, каждая выполняется одной транзакцией
+	BatchCreateProducts(ctx context.Context, products []*models.Product, tenantID string) (int, []models.BatchItemError, error)
+	BatchUpdateProducts(ctx context.Context, products []*models.Product, tenantID string) (int, []models.BatchItemError, error)
+	BatchDeleteProducts(ctx context.Context, productIDs []string, tenantID string) (int, []models.BatchItemError, error)
+	ImportProductsCSV(ctx context.Context, file io.Reader, supplierID, tenantID string) (*models.ImportSummary, error)
 
 	// Операции с ценами и инвентарем
 	UpdatePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error
+	GetPrice(ctx context.Context, productID, tenantID string) (*models.ProductPrice, error)
+	GetPriceInCurrency(ctx context.Context, productID, tenantID, currency string) (*models.ProductPrice, error)
+	GetPriceHistory(ctx context.Context, productID, tenantID string, page, pageSize int) ([]*models.PriceHistoryRecord, int, error)
 	UpdateInventory(ctx context.Context, inventory *models.ProductInventory, tenantID string) error
+	AdjustInventory(ctx context.Context, productID, tenantID, supplierID string, delta int) (int, error)
+	ReserveInventory(ctx context.Context, productID, tenantID string, quantity int, ttl time.Duration) (*models.InventoryReservation, error)
+	ReleaseReservation(ctx context.Context, productID, reservationID, tenantID string) error
+	ExpireReservations(ctx context.Context) (int, error)
+	GetInventory(ctx context.Context, productID, tenantID string) (*models.ProductInventory, error)
+
+	// История изменений продукта
+	GetProductHistory(ctx context.Context, productID, tenantID string, page, pageSize int) ([]*models.ProductHistoryRecord, int, error)
+
+	// Медиафайлы продукта
+	UploadProductMedia(ctx context.Context, productID, tenantID string, file io.Reader, size int64, contentType string) (*models.ProductMedia, error)
+	DeleteProductMedia(ctx context.Context, productID, mediaID, tenantID string) error
+	GetMediaByProductIDs(ctx context.Context, productIDs []string, tenantID string) (map[string][]*models.ProductMedia, error)
+	ResolvePrimaryImages(ctx context.Context, products []*models.Product, tenantID string) error
 
 	// Синхронизация с внешними системами
 	SyncProductToMarketplace(ctx context.Context, productID string, marketplaceID int, tenantID string) error
-	SyncProductsFromSupplier(ctx context.Context, supplierID int, tenantID string) (int, error)
+	PushProductToMarketplace(ctx context.Context, productID string, marketplaceID int, tenantID string) (*pkgmodels.MarketplaceProduct, error)
+	GetMarketplaceStatuses(ctx context.Context, productID, tenantID string) ([]*pkgmodels.MarketplaceProduct, error)
+	SyncProductsFromSupplier(ctx context.Context, supplierID string, tenantID string) (int, error)
+
+	// Настройка маппинга полей для маркетплейсов
+	SaveMarketplaceMapping(ctx context.Context, mapping *models.MarketplaceFieldMapping) error
+	GetMarketplaceMapping(ctx context.Context, marketplaceID int, tenantID string) (*models.MarketplaceFieldMapping, error)
 
 	// Кэширование
 	InvalidateCache(ctx context.Context, key string, tenantID string) error
+	InvalidateSupplierCache(ctx context.Context, supplierID, tenantID string) error
+	RecacheProducts(ctx context.Context, tenantID string, productIDs []string, filters map[string]interface{}) (*models.RecacheResult, error)
+	WarmCache(ctx context.Context, tenantID string) (*models.CacheWarmResult, error)
 }
 
 type ProductService struct {
-	repository postgres.ProductStoragePort
-	cache      interfaces.CachePort
-	messaging  interfaces.MessagingPort
-	logger     interfaces.LoggerPort
-	txManager  tx.TxManager
+	repository  postgres.ProductStoragePort
+	cache       interfaces.CachePort
+	messaging   interfaces.MessagingPort
+	logger      interfaces.LoggerPort
+	txManager   tx.TxManager
+	supplier    interfaces.SupplierPort
+	marketplace marketplace.MarketplacePort
+	objects     interfaces.ObjectStoragePort
+	currency    interfaces.CurrencyConverterPort
 }
 
 // NewProductService создает новый экземпляр ProductService
@@ -51,19 +112,30 @@ func NewProductService(
 	msg interfaces.MessagingPort,
 	log interfaces.LoggerPort,
 	txMgr tx.TxManager,
+	supplierPort interfaces.SupplierPort,
+	marketplacePort marketplace.MarketplacePort,
+	objectStorage interfaces.ObjectStoragePort,
+	currencyConverter interfaces.CurrencyConverterPort,
 ) *ProductService {
 	return &ProductService{
-		repository: repo,
-		cache:      cache,
-		messaging:  msg,
-		logger:     log,
-		txManager:  txMgr,
+		repository:  repo,
+		cache:       cache,
+		messaging:   msg,
+		logger:      log,
+		txManager:   txMgr,
+		supplier:    supplierPort,
+		marketplace: marketplacePort,
+		objects:     objectStorage,
+		currency:    currencyConverter,
 	}
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
 	var createdProduct *models.Product
 
+	// Нулевая версия только создает продукт, существующий продукт с тем же ID не перезаписывается
+	product.Version = 0
+
 	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
 		if product.ID == "" {
 			product.ID = uuid.New().String()
@@ -73,6 +145,9 @@ func (s *ProductService) CreateProduct(ctx context.Context, product *models.Prod
 		product.UpdatedAt = now
 
 		if err := s.repository.SaveProduct(txCtx, product); err != nil {
+			if errors.Is(err, utils.ErrVersionConflict) {
+				return utils.ErrProductExists
+			}
 			s.logger.ErrorWithContext(txCtx, "Ошибка сохранения продукта внутри транзакции",
 				interfaces.LogField{Key: "error", Value: err},
 				interfaces.LogField{Key: "product_id", Value: product.ID},
@@ -81,6 +156,14 @@ func (s *ProductService) CreateProduct(ctx context.Context, product *models.Prod
 			return fmt.Errorf("repository.SaveProduct failed: %w", err)
 		}
 
+		if err := s.recordHistory(txCtx, models.HistoryChangeCreate, product.ID, product.TenantID, nil, product); err != nil {
+			return fmt.Errorf("recordHistory failed: %w", err)
+		}
+
+		if err := s.enqueueProductEvent(txCtx, messaging.ProductCreatedEvent, product.ID, product.TenantID, &messaging.ProductEventPayload{SupplierID: product.SupplierID}); err != nil {
+			return fmt.Errorf("enqueueProductEvent failed: %w", err)
+		}
+
 		createdProduct = product
 
 		s.logger.InfoWithContext(txCtx, "Продукт успешно сохранен внутри транзакции", interfaces.LogField{Key: "product_id", Value: product.ID})
@@ -93,41 +176,56 @@ func (s *ProductService) CreateProduct(ctx context.Context, product *models.Prod
 	}
 
 	// ---- Транзакция успешно ЗАКОММИЧЕНА ----
+	// Событие ProductCreated уже в outbox и будет опубликовано relay воркера
 	s.logger.InfoWithContext(ctx, "Транзакция создания продукта успешно закоммичена", interfaces.LogField{Key: "product_id", Value: createdProduct.ID})
 
-	event := struct {
-		EventType string                 `json:"event_type"`
-		TenantID  string                 `json:"tenant_id"`
-		Payload   map[string]interface{} `json:"payload"`
-	}{
-		EventType: messaging.ProductCreatedEvent,
-		TenantID:  createdProduct.TenantID,
-		Payload: map[string]interface{}{
-			"product_id":  createdProduct.ID,
-			"supplier_id": createdProduct.SupplierID,
-		},
+	// Закэшированные страницы списка не содержат нового продукта
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, createdProduct.TenantID)
+
+	return createdProduct, nil
+}
+
+// recordHistory сохраняет запись истории изменений продукта.
+// Автор изменения берется из user_id контекста запроса.
+func (s *ProductService) recordHistory(ctx context.Context, changeType, productID, tenantID string, before, after *models.Product) error {
+	changedBy, _ := contextkeys.UserFromContext(ctx)
+
+	record := &models.ProductHistoryRecord{
+		ProductID:  productID,
+		ChangeType: changeType,
+		Before:     before,
+		After:      after,
+		ChangedBy:  changedBy,
+		ChangedAt:  time.Now().UTC().Unix(),
 	}
 
-	eventData, marshalErr := json.Marshal(event)
-	if marshalErr != nil {
-		s.logger.ErrorWithContext(ctx, "Ошибка маршалинга события ProductCreated после коммита",
-			interfaces.LogField{Key: "error", Value: marshalErr},
-			interfaces.LogField{Key: "product_id", Value: createdProduct.ID})
-		// Продукт создан, но событие не уйдет. Логируем, но не возвращаем ошибку клиенту.
-	} else {
-		publishErr := s.messaging.Publish(ctx, "product-events", eventData)
-		if publishErr != nil {
-			s.logger.ErrorWithContext(ctx, "Ошибка публикации события ProductCreated после коммита",
-				interfaces.LogField{Key: "error", Value: publishErr},
-				interfaces.LogField{Key: "product_id", Value: createdProduct.ID})
-			// ОЧЕНЬ ВАЖНО ЛОГИРОВАТЬ ЭТУ ОШИБКУ!
-		} else {
-			s.logger.InfoWithContext(ctx, "Событие ProductCreated успешно опубликовано после коммита",
-				interfaces.LogField{Key: "product_id", Value: createdProduct.ID})
-		}
+	return s.repository.SaveHistoryRecord(ctx, record, tenantID)
+}
+
+// enqueueProductEvent записывает событие продукта в outbox текущей транзакции.
+// В payload задаются product_id и очередной номер события продукта.
+func (s *ProductService) enqueueProductEvent(ctx context.Context, eventType, productID, tenantID string, payload messaging.ProductPayload) error {
+	sequence, err := s.repository.NextEventSequence(ctx, productID, tenantID)
+	if err != nil {
+		return err
 	}
+	payload.SetSequence(productID, sequence)
 
-	return createdProduct, nil
+	eventData, err := messaging.EncodeEvent(eventType, tenantID, time.Now(), payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	traceCarrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceCarrier)
+
+	return s.repository.SaveOutboxMessage(ctx, &models.OutboxMessage{
+		TenantID:    tenantID,
+		AggregateID: productID,
+		Topic:       "product-events",
+		Payload:     eventData,
+		TraceParent: traceCarrier.Get("traceparent"),
+	})
 }
 
 func (s *ProductService) GetProduct(ctx context.Context, productID, supplierID, tenantID string) (*models.Product, error) {
@@ -137,72 +235,191 @@ func (s *ProductService) GetProduct(ctx context.Context, productID, supplierID,
 		interfaces.LogField{Key: "tenant_id", Value: tenantID},
 	)
 
-	cacheKey := fmt.Sprintf("product:%s:%s:%s", tenantID, supplierID, productID)
+	cacheKey := ProductCacheKey(supplierID, productID)
 
-	cachedData, cacheErr := s.cache.GetWithTenant(ctx, cacheKey, tenantID)
-	if cacheErr == nil && cachedData != nil {
-		var product models.Product
-		if unmarshalErr := json.Unmarshal(cachedData, &product); unmarshalErr == nil {
-			s.logger.DebugWithContext(ctx, "Продукт получен из кэша",
-				interfaces.LogField{Key: "product_id", Value: productID},
-			)
-			return &product, nil
-		} else {
-			s.logger.WarnWithContext(ctx, "Ошибка десериализации продукта из кэша",
-				interfaces.LogField{Key: "error", Value: unmarshalErr.Error()},
-			)
+	data, err := s.cache.GetOrSet(ctx, cacheKey, tenantID, pkgutils.JitterTTL(productCacheTTL, cacheTTLJitter), func() ([]byte, error) {
+		product, loadErr := s.repository.GetProductBySupplier(ctx, productID, supplierID, tenantID)
+		if loadErr != nil {
+			return nil, loadErr
 		}
-	} else if cacheErr != nil && !errors.Is(cacheErr, interfaces.ErrCacheMiss) {
-		s.logger.WarnWithContext(ctx, "Ошибка чтения из кэша",
-			interfaces.LogField{Key: "error", Value: cacheErr.Error()},
-		)
-	}
-
-	product, dbErr := s.repository.GetProductBySupplier(ctx, productID, supplierID, tenantID)
-	if dbErr != nil {
-		s.logger.ErrorWithContext(ctx, "Ошибка получения продукта из хранилища",
-			interfaces.LogField{Key: "error", Value: dbErr.Error()},
+		return json.Marshal(product)
+	})
+	if errors.Is(err, utils.ErrProductNotFound) {
+		s.logger.InfoWithContext(ctx, "Продукт не найден",
 			interfaces.LogField{Key: "product_id", Value: productID},
+			interfaces.LogField{Key: "supplier_id", Value: supplierID},
 		)
-		return nil, fmt.Errorf("failed to get product: %w", dbErr)
+		return nil, err
 	}
-
-	if product == nil {
-		s.logger.InfoWithContext(ctx, "Продукт не найден",
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Ошибка получения продукта",
+			interfaces.LogField{Key: "error", Value: err.Error()},
 			interfaces.LogField{Key: "product_id", Value: productID},
-			interfaces.LogField{Key: "supplier_id", Value: supplierID},
 		)
-		return nil, nil
+		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	productJSON, marshalErr := json.Marshal(product)
-	if marshalErr == nil {
-		if cacheSetErr := s.cache.SetWithTenant(ctx, cacheKey, productJSON, tenantID, 30*time.Minute); cacheSetErr != nil {
-			s.logger.WarnWithContext(ctx, "Ошибка сохранения продукта в кэш",
-				interfaces.LogField{Key: "error", Value: cacheSetErr.Error()},
-			)
-		}
-	} else {
-		s.logger.WarnWithContext(ctx, "Ошибка сериализации продукта для кэша",
-			interfaces.LogField{Key: "error", Value: marshalErr.Error()},
-		)
+	var product models.Product
+	if err := json.Unmarshal(data, &product); err != nil {
+		// Поврежденная запись удаляется, чтобы следующий запрос перечитал продукт из хранилища
+		_ = s.cache.DeleteWithTenant(ctx, cacheKey, tenantID)
+		return nil, fmt.Errorf("failed to decode cached product: %w", err)
 	}
 
 	s.logger.DebugWithContext(ctx, "Продукт успешно получен",
 		interfaces.LogField{Key: "product_id", Value: productID},
 	)
 
-	return product, nil
+	return &product, nil
+}
+
+// GetProductsByIDs получает продукты поставщика по списку ID: сначала одним MGet из кэша,
+// затем промахи одним запросом к хранилищу. Ключи кэша те же, что у GetProduct, поэтому
+// инвалидация продукта действует и здесь. Возвращает только найденные продукты поставщика
+func (s *ProductService) GetProductsByIDs(ctx context.Context, productIDs []string, supplierID, tenantID string) (map[string]*models.Product, error) {
+	products := make(map[string]*models.Product, len(productIDs))
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+
+	keys := make([]string, len(productIDs))
+	keyToID := make(map[string]string, len(productIDs))
+	for i, productID := range productIDs {
+		keys[i] = ProductCacheKey(supplierID, productID)
+		keyToID[keys[i]] = productID
+	}
+
+	// Ошибка кэша не мешает чтению из хранилища
+	cached, err := s.cache.MGetWithTenant(ctx, keys, tenantID)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Ошибка пакетного чтения продуктов из кэша",
+			interfaces.LogField{Key: "error", Value: err.Error()})
+		cached = nil
+	}
+	for key, data := range cached {
+		var product models.Product
+		if err := json.Unmarshal(data, &product); err != nil {
+			_ = s.cache.DeleteWithTenant(ctx, key, tenantID)
+			continue
+		}
+		products[keyToID[key]] = &product
+	}
+
+	missing := make([]string, 0, len(productIDs)-len(products))
+	for _, productID := range productIDs {
+		if _, ok := products[productID]; !ok {
+			missing = append(missing, productID)
+		}
+	}
+	if len(missing) == 0 {
+		return products, nil
+	}
+
+	loaded, err := s.repository.GetProductsByIDs(ctx, missing, tenantID)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Ошибка пакетного получения продуктов",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "count", Value: len(missing)},
+		)
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	for productID, product := range loaded {
+		if product.SupplierID != supplierID {
+			continue
+		}
+		products[productID] = product
+
+		if data, err := json.Marshal(product); err == nil {
+			_ = s.cache.SetWithJitter(ctx, ProductCacheKey(supplierID, productID), data, tenantID, productCacheTTL, cacheTTLJitter)
+		}
+	}
+
+	return products, nil
+}
+
+// GetProductDetails собирает агрегат продукта. Ошибки загрузки продукта, цены и остатков
+// возвращаются вызывающему, а ошибка загрузки медиа лишь помечается в SectionErrors.
+func (s *ProductService) GetProductDetails(ctx context.Context, productID, tenantID string) (*models.ProductDetails, error) {
+	product, err := s.repository.GetProduct(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	price, err := s.repository.GetPrice(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	inventory, err := s.repository.GetInventory(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+
+	details := &models.ProductDetails{
+		Product:   product,
+		Price:     price,
+		Inventory: inventory,
+	}
+
+	media, err := s.repository.GetMediaByProductID(ctx, productID, tenantID)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Не удалось загрузить медиа продукта, секция пропущена",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "product_id", Value: productID},
+		)
+		details.SectionErrors = map[string]string{
+			models.DetailsSectionMedia: "unavailable",
+		}
+	} else {
+		details.Media = media
+	}
+
+	return details, nil
 }
 
+// UpdateProduct заменяет существующий продукт. product.Version должна совпадать с текущей версией продукта:
+// без версии возвращается utils.ErrVersionRequired, при несовпадении - utils.ErrVersionConflict
 func (s *ProductService) UpdateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
 	if product.ID == "" || product.TenantID == "" {
 		return nil, errors.New("product ID and tenant ID cannot be empty")
 	}
+	if product.Version <= 0 {
+		return nil, utils.ErrVersionRequired
+	}
 
 	product.UpdatedAt = time.Now().UTC()
 
-	err := s.repository.SaveProduct(ctx, product)
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		// Состояние до изменения читается в той же транзакции, до перезаписи
+		before, err := s.repository.GetProduct(txCtx, product.ID, product.TenantID)
+		if err != nil {
+			return err
+		}
+		before.TenantID = product.TenantID
+
+		// Поставщику из тела запроса не доверяем: он берется из контекста запроса,
+		// а без него остается сохраненный, чтобы пустое значение не затерло его
+		if supplierID, ok := contextkeys.SupplierFromContext(ctx); ok && supplierID != "" {
+			product.SupplierID = supplierID
+		} else {
+			product.SupplierID = before.SupplierID
+		}
+		if err := product.Validate(); err != nil {
+			return models.NewError(models.ErrValidation, "validation_error", err.Error())
+		}
+
+		// Версия обязательна: SaveProduct обновит строку, только если она не изменилась с момента чтения клиентом
+		if err := s.repository.SaveProduct(txCtx, product); err != nil {
+			return err
+		}
+
+		if err := s.recordHistory(txCtx, models.HistoryChangeUpdate, product.ID, product.TenantID, before, product); err != nil {
+			return err
+		}
+
+		return s.enqueueProductEvent(txCtx, messaging.ProductUpdatedEvent, product.ID, product.TenantID, &messaging.ProductEventPayload{SupplierID: product.SupplierID})
+	})
 	if err != nil {
 		s.logger.ErrorWithContext(ctx, "Failed to update product",
 			interfaces.LogField{Key: "error", Value: err.Error()},
@@ -211,34 +428,160 @@ func (s *ProductService) UpdateProduct(ctx context.Context, product *models.Prod
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("product:%s:%s:%s", product.TenantID, product.SupplierID, product.ID)
+	cacheKey := ProductCacheKey(product.SupplierID, product.ID)
 	_ = s.cache.DeleteWithTenant(ctx, cacheKey, product.TenantID)
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, product.TenantID)
 
-	event := struct {
-		EventType string                 `json:"event_type"`
-		TenantID  string                 `json:"tenant_id"`
-		Payload   map[string]interface{} `json:"payload"`
-	}{
-		EventType: messaging.ProductUpdatedEvent,
-		TenantID:  product.TenantID,
-		Payload: map[string]interface{}{
-			"product_id":  product.ID,
-			"supplier_id": product.SupplierID,
-		},
+	return product, nil
+}
+
+// PatchProduct сливает patch с base_data продукта по правилам JSON Merge Patch (RFC 7386).
+// Поля, не указанные в patch, сохраняются, null удаляет ключ. Если expectedVersion больше нуля,
+// продукт обновляется только при совпадении версии, иначе возвращается utils.ErrVersionConflict.
+// Некорректный patch или результат, не прошедший валидацию, возвращают models.ErrInvalidMergePatch
+func (s *ProductService) PatchProduct(ctx context.Context, productID, tenantID string, patch json.RawMessage, expectedVersion int) (*models.Product, error) {
+	if productID == "" || tenantID == "" {
+		return nil, errors.New("product ID and tenant ID cannot be empty")
+	}
+
+	var product *models.Product
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		before, err := s.repository.GetProduct(txCtx, productID, tenantID)
+		if err != nil {
+			return err
+		}
+		before.TenantID = tenantID
+		if expectedVersion > 0 && before.Version != expectedVersion {
+			return utils.ErrVersionConflict
+		}
+
+		merged, err := models.MergePatch(before.BaseData, patch)
+		if err != nil {
+			return err
+		}
+
+		updated := *before
+		updated.BaseData = merged
+		if err := updated.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", models.ErrInvalidMergePatch, err)
+		}
+
+		// Версия прочитанной строки защищает от параллельного изменения между чтением и записью
+		if err := s.repository.SaveProduct(txCtx, &updated); err != nil {
+			return err
+		}
+
+		if err := s.recordHistory(txCtx, models.HistoryChangeUpdate, productID, tenantID, before, &updated); err != nil {
+			return err
+		}
+
+		product = &updated
+		return s.enqueueProductEvent(txCtx, messaging.ProductUpdatedEvent, productID, tenantID, &messaging.ProductEventPayload{SupplierID: updated.SupplierID})
+	})
+	if err != nil {
+		if !errors.Is(err, utils.ErrProductNotFound) && !errors.Is(err, models.ErrInvalidMergePatch) && !errors.Is(err, utils.ErrVersionConflict) {
+			s.logger.ErrorWithContext(ctx, "Failed to patch product",
+				interfaces.LogField{Key: "error", Value: err.Error()},
+				interfaces.LogField{Key: "product_id", Value: productID},
+			)
+		}
+		return nil, fmt.Errorf("failed to patch product: %w", err)
 	}
 
-	eventData, _ := json.Marshal(event)
-	_ = s.messaging.Publish(ctx, "product-events", eventData)
+	cacheKey := ProductCacheKey(product.SupplierID, product.ID)
+	_ = s.cache.DeleteWithTenant(ctx, cacheKey, tenantID)
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
 
 	return product, nil
 }
 
+// UpsertProductBySKU создает или обновляет продукт поставщика по SKU.
+// SKU записывается в base_data, возвращается итоговый продукт и признак создания.
+func (s *ProductService) UpsertProductBySKU(ctx context.Context, product *models.Product, sku string) (*models.Product, bool, error) {
+	if sku == "" || product.TenantID == "" || product.SupplierID == "" {
+		return nil, false, errors.New("sku, tenant ID and supplier ID cannot be empty")
+	}
+
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(product.BaseData, &baseData); err != nil {
+		return nil, false, fmt.Errorf("invalid base data: %w", err)
+	}
+	baseData["sku"] = sku
+
+	data, err := json.Marshal(baseData)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal base data: %w", err)
+	}
+	product.BaseData = data
+
+	if product.ID == "" {
+		product.ID = uuid.New().String()
+	}
+
+	var created bool
+	err = s.txManager.Do(ctx, func(txCtx context.Context) error {
+		before, err := s.repository.GetProductBySKU(txCtx, sku, product.SupplierID, product.TenantID)
+		if err != nil && !errors.Is(err, utils.ErrProductNotFound) {
+			return err
+		}
+
+		inserted, err := s.repository.UpsertProductBySKU(txCtx, product)
+		if err != nil {
+			return err
+		}
+		created = inserted
+
+		changeType := models.HistoryChangeUpdate
+		if created {
+			changeType = models.HistoryChangeCreate
+		}
+		if err := s.recordHistory(txCtx, changeType, product.ID, product.TenantID, before, product); err != nil {
+			return err
+		}
+
+		eventType := messaging.ProductUpdatedEvent
+		if created {
+			eventType = messaging.ProductCreatedEvent
+		}
+		return s.enqueueProductEvent(txCtx, eventType, product.ID, product.TenantID, &messaging.ProductEventPayload{SupplierID: product.SupplierID, SKU: sku})
+	})
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to upsert product by SKU",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "sku", Value: sku},
+		)
+		return nil, false, fmt.Errorf("failed to upsert product: %w", err)
+	}
+
+	cacheKey := ProductCacheKey(product.SupplierID, product.ID)
+	_ = s.cache.DeleteWithTenant(ctx, cacheKey, product.TenantID)
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, product.TenantID)
+
+	return product, created, nil
+}
+
 func (s *ProductService) DeleteProduct(ctx context.Context, productID, supplierID, tenantID string) error {
 	if productID == "" || tenantID == "" {
 		return errors.New("product ID and tenant ID cannot be empty")
 	}
 
-	err := s.repository.DeleteProduct(ctx, productID, tenantID)
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		before, err := s.repository.GetProduct(txCtx, productID, tenantID)
+		if err != nil {
+			return err
+		}
+		before.TenantID = tenantID
+
+		if err := s.repository.DeleteProduct(txCtx, productID, tenantID); err != nil {
+			return err
+		}
+
+		if err := s.recordHistory(txCtx, models.HistoryChangeDelete, productID, tenantID, before, nil); err != nil {
+			return err
+		}
+
+		return s.enqueueProductEvent(txCtx, messaging.ProductDeletedEvent, productID, tenantID, &messaging.ProductEventPayload{SupplierID: supplierID})
+	})
 	if err != nil {
 		s.logger.ErrorWithContext(ctx, "Failed to delete product",
 			interfaces.LogField{Key: "error", Value: err.Error()},
@@ -247,31 +590,15 @@ func (s *ProductService) DeleteProduct(ctx context.Context, productID, supplierI
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("product:%s:%s:%s", tenantID, supplierID, productID)
+	cacheKey := ProductCacheKey(supplierID, productID)
 	_ = s.cache.DeleteWithTenant(ctx, cacheKey, tenantID)
 
-	_ = s.cache.DeleteByPatternWithTenant(ctx, "products:list:*", tenantID)
-
-	event := struct {
-		EventType string                 `json:"event_type"`
-		TenantID  string                 `json:"tenant_id"`
-		Payload   map[string]interface{} `json:"payload"`
-	}{
-		EventType: messaging.ProductDeletedEvent,
-		TenantID:  tenantID,
-		Payload: map[string]interface{}{
-			"product_id":  productID,
-			"supplier_id": supplierID,
-		},
-	}
-
-	eventData, _ := json.Marshal(event)
-	_ = s.messaging.Publish(ctx, "product-events", eventData)
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
 
 	return nil
 }
 
-func (s *ProductService) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, page, pageSize int) ([]*models.Product, int, error) {
+func (s *ProductService) ListProducts(ctx context.Context, tenantID string, filters map[string]interface{}, sort models.SortOption, page, pageSize int) ([]*models.Product, int, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -281,60 +608,190 @@ func (s *ProductService) ListProducts(ctx context.Context, tenantID string, filt
 		pageSize = 100
 	}
 
-	if len(filters) == 0 {
-		cacheKey := fmt.Sprintf("products:list:%s:%d:%d", tenantID, page, pageSize)
-		cachedData, err := s.cache.GetWithTenant(ctx, cacheKey, tenantID)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-		if err == nil && cachedData != nil {
-			var result struct {
-				Products []*models.Product `json:"products"`
-				Total    int               `json:"total"`
-			}
+	// Кэшируются только страницы без фильтров
+	if len(filters) > 0 {
+		products, total, err := s.repository.ListProducts(ctx, tenantID, filters, sort, page, pageSize)
+		if err != nil {
+			s.logger.ErrorWithContext(ctx, "Failed to list products",
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+			return nil, 0, fmt.Errorf("failed to list products: %w", err)
+		}
+		return products, total, nil
+	}
 
-			if err := json.Unmarshal(cachedData, &result); err == nil {
-				return result.Products, result.Total, nil
-			}
+	var result struct {
+		Products []*models.Product `json:"products"`
+		Total    int               `json:"total"`
+	}
+
+	cacheKey := fmt.Sprintf("products:list:%s:%s:%t:%d:%d", tenantID, sort.Field, sort.Desc, page, pageSize)
+	data, err := s.cache.GetOrSet(ctx, cacheKey, tenantID, pkgutils.JitterTTL(productListCacheTTL, cacheTTLJitter), func() ([]byte, error) {
+		products, total, loadErr := s.repository.ListProducts(ctx, tenantID, filters, sort, page, pageSize)
+		if loadErr != nil {
+			return nil, loadErr
 		}
+		result.Products = products
+		result.Total = total
+		return json.Marshal(result)
+	})
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to list products",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return nil, 0, fmt.Errorf("failed to list products: %w", err)
 	}
 
+	if err := json.Unmarshal(data, &result); err != nil {
+		_ = s.cache.DeleteWithTenant(ctx, cacheKey, tenantID)
+		return nil, 0, fmt.Errorf("failed to decode cached products: %w", err)
+	}
+
+	return result.Products, result.Total, nil
+}
+
+// CountProducts возвращает число продуктов тенанта, удовлетворяющих фильтрам ListProducts, без загрузки самих продуктов
+func (s *ProductService) CountProducts(ctx context.Context, tenantID string, filters map[string]interface{}) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	products, total, err := s.repository.ListProducts(ctx, tenantID, filters, page, pageSize)
+	total, err := s.repository.CountProducts(ctx, tenantID, filters)
 	if err != nil {
-		s.logger.ErrorWithContext(ctx, "Failed to list products",
+		s.logger.ErrorWithContext(ctx, "Failed to count products",
 			interfaces.LogField{Key: "error", Value: err.Error()},
 		)
-		return nil, 0, fmt.Errorf("failed to list products: %w", err)
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return total, nil
+}
+
+// ListSuppliers возвращает ID поставщиков, от которых у тенанта есть продукты
+func (s *ProductService) ListSuppliers(ctx context.Context, tenantID string) ([]string, error) {
+	suppliers, err := s.repository.ListSuppliers(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	return suppliers, nil
+}
+
+// ListProductsAfter возвращает страницу продуктов после курсора и курсор следующей страницы.
+// Предназначен для полного обхода каталога, поэтому сортировка фиксирована, а кэш не применяется
+func (s *ProductService) ListProductsAfter(ctx context.Context, tenantID string, filters map[string]interface{}, cursor string, limit int) ([]*models.Product, string, error) {
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	products, nextCursor, err := s.repository.ListProductsAfter(ctx, tenantID, filters, cursor, limit)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("failed to list products: %w", err)
 	}
 
-	if len(filters) == 0 {
-		cacheKey := fmt.Sprintf("products:list:%s:%d:%d", tenantID, page, pageSize)
-		cacheData := struct {
-			Products []*models.Product `json:"products"`
-			Total    int               `json:"total"`
-		}{
-			Products: products,
-			Total:    total,
+	return products, nextCursor, nil
+}
+
+// exportPageSize размер страницы, которой ExportProducts читает каталог
+const exportPageSize = 500
+
+// ExportProducts обходит все продукты тенанта, подходящие под фильтры, страницами keyset-пагинации
+// и передает каждый в visit, а после каждой страницы вызывает pageDone (например, чтобы сбросить ответ клиенту).
+// В памяти одновременно находится не больше одной страницы.
+// Возвращает число переданных продуктов; ошибка visit или pageDone прерывает обход
+func (s *ProductService) ExportProducts(ctx context.Context, tenantID string, filters map[string]interface{}, visit func(*models.Product) error, pageDone func() error) (int, error) {
+	var exported int
+	cursor := ""
+	for {
+		products, nextCursor, err := s.repository.ListProductsAfter(ctx, tenantID, filters, cursor, exportPageSize)
+		if err != nil {
+			return exported, fmt.Errorf("failed to list products: %w", err)
+		}
+
+		for _, product := range products {
+			product.TenantID = tenantID
+			if err := visit(product); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+		if pageDone != nil {
+			if err := pageDone(); err != nil {
+				return exported, err
+			}
 		}
 
-		if cacheJSON, err := json.Marshal(cacheData); err == nil {
-			_ = s.cache.SetWithTenant(ctx, cacheKey, cacheJSON, tenantID, 5*time.Minute)
+		if nextCursor == "" {
+			return exported, nil
 		}
+		cursor = nextCursor
+	}
+}
+
+// SearchProducts выполняет полнотекстовый поиск продуктов тенанта по имени и описанию
+// с сортировкой по релевантности. Это ListProducts с единственным фильтром models.SearchQueryFilter
+func (s *ProductService) SearchProducts(ctx context.Context, tenantID, query string, page, pageSize int) ([]*models.Product, int, error) {
+	filters := map[string]interface{}{models.SearchQueryFilter: query}
+	return s.ListProducts(ctx, tenantID, filters, models.SortOption{Field: models.SortByRelevance, Desc: true}, page, pageSize)
+}
+
+// GetProductsByCategory возвращает продукты категории вместе с продуктами всех ее подкатегорий
+func (s *ProductService) GetProductsByCategory(ctx context.Context, categoryID, tenantID string, page, pageSize int) ([]*models.Product, int, error) {
+	if categoryID == "" || tenantID == "" {
+		return nil, 0, errors.New("category ID and tenant ID cannot be empty")
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	} else if pageSize > 100 {
+		pageSize = 100
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	products, total, err := s.repository.ListProductsByCategory(ctx, tenantID, categoryID, page, pageSize)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Failed to list products by category",
+			interfaces.LogField{Key: "error", Value: err.Error()},
+			interfaces.LogField{Key: "category_id", Value: categoryID},
+		)
+		return nil, 0, fmt.Errorf("failed to list products by category: %w", err)
 	}
 
 	return products, total, nil
 }
 
+// GetProductSchema возвращает допустимые фильтры и поля сортировки для ListProducts
+func (s *ProductService) GetProductSchema() *models.ProductSchema {
+	return postgres.ProductSchema()
+}
+
 func (s *ProductService) UpdatePrice(ctx context.Context, price *models.ProductPrice, tenantID string) error {
 	price.UpdatedAt = time.Now().UTC()
 
-	err := s.repository.SavePrice(ctx, price, tenantID)
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := s.repository.SavePrice(txCtx, price, tenantID); err != nil {
+			return err
+		}
+
+		return s.enqueueProductEvent(txCtx, messaging.ProductPriceUpdatedEvent, price.ProductID, tenantID, &messaging.ProductPriceUpdatedPayload{
+			ProductEventPayload: messaging.ProductEventPayload{SupplierID: price.SupplierID},
+			Price:               price.BasePrice,
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("failed to save price: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("product:%s:%s:%s", tenantID, price.SupplierID, price.ProductID)
+	cacheKey := ProductCacheKey(price.SupplierID, price.ProductID)
 	_ = s.cache.DeleteWithTenant(ctx, cacheKey, tenantID)
 
 	return nil
@@ -343,85 +800,624 @@ func (s *ProductService) UpdatePrice(ctx context.Context, price *models.ProductP
 func (s *ProductService) UpdateInventory(ctx context.Context, inventory *models.ProductInventory, tenantID string) error {
 	inventory.UpdatedAt = time.Now().UTC()
 
-	err := s.repository.SaveInventory(ctx, inventory, tenantID)
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := s.repository.SaveInventory(txCtx, inventory, tenantID); err != nil {
+			return err
+		}
+
+		return s.enqueueProductEvent(txCtx, messaging.ProductInventoryUpdatedEvent, inventory.ProductID, tenantID, &messaging.ProductInventoryUpdatedPayload{
+			ProductEventPayload: messaging.ProductEventPayload{SupplierID: inventory.SupplierID},
+			Quantity:            inventory.Quantity,
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("failed to save inventory: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("product:%s:%s:%s", tenantID, inventory.SupplierID, inventory.ProductID)
+	cacheKey := ProductCacheKey(inventory.SupplierID, inventory.ProductID)
 	_ = s.cache.DeleteWithTenant(ctx, cacheKey, tenantID)
 
 	return nil
 }
 
-func (s *ProductService) SyncProductToMarketplace(ctx context.Context, productID string, marketplaceID int, tenantID string) error {
-	product, err := s.repository.GetProduct(ctx, productID, tenantID)
-	if err != nil {
-		return fmt.Errorf("failed to get product: %w", err)
-	}
-	if product == nil {
-		return fmt.Errorf("product not found: %s", productID)
+// AdjustInventory атомарно изменяет остаток продукта на delta (положительный - пополнение,
+// отрицательный - списание) и возвращает новое количество. Если доступного остатка за вычетом
+// активных резервов не хватает, возвращает utils.ErrInsufficientStock и остаток не меняется
+func (s *ProductService) AdjustInventory(ctx context.Context, productID, tenantID, supplierID string, delta int) (int, error) {
+	var quantity int
+	err := s.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		quantity, err = s.repository.AdjustInventory(txCtx, productID, tenantID, supplierID, delta)
+		if err != nil {
+			return err
+		}
+
+		return s.enqueueProductEvent(txCtx, messaging.ProductInventoryUpdatedEvent, productID, tenantID, &messaging.ProductInventoryUpdatedPayload{
+			ProductEventPayload: messaging.ProductEventPayload{SupplierID: supplierID},
+			Quantity:            quantity,
+			Delta:               delta,
+		})
+	})
+	if errors.Is(err, utils.ErrInsufficientStock) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to adjust inventory: %w", err)
 	}
 
-	event := struct {
-		EventType     string    `json:"event_type"`
-		TenantID      string    `json:"tenant_id"`
-		ProductID     string    `json:"product_id"`
-		MarketplaceID int       `json:"marketplace_id"`
-		Timestamp     time.Time `json:"timestamp"`
-	}{
-		EventType:     "product_marketplace_sync",
-		TenantID:      tenantID,
-		ProductID:     productID,
-		MarketplaceID: marketplaceID,
-		Timestamp:     time.Now().UTC(),
+	_ = s.cache.DeleteWithTenant(ctx, ProductCacheKey(supplierID, productID), tenantID)
+
+	return quantity, nil
+}
+
+// GetPrice возвращает цену продукта или nil, если она не задана
+func (s *ProductService) GetPrice(ctx context.Context, productID, tenantID string) (*models.ProductPrice, error) {
+	price, err := s.repository.GetPrice(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+	return price, nil
+}
+
+// GetPriceInCurrency возвращает цену продукта, пересчитанную в currency по текущему курсу, или nil,
+// если цена не задана. Использованный курс указывается в Conversion. Если currency совпадает с валютой
+// цены, цена возвращается без пересчета. Для некорректного кода или валюты без курса возвращает
+// utils.ErrUnsupportedCurrency
+func (s *ProductService) GetPriceInCurrency(ctx context.Context, productID, tenantID, currency string) (*models.ProductPrice, error) {
+	currency = strings.ToUpper(currency)
+	if len(currency) != 3 {
+		return nil, utils.ErrUnsupportedCurrency
+	}
+
+	price, err := s.GetPrice(ctx, productID, tenantID)
+	if err != nil || price == nil || strings.EqualFold(price.Currency, currency) {
+		return price, err
 	}
 
-	eventData, _ := json.Marshal(event)
-	return s.messaging.Publish(ctx, "marketplace-sync", eventData)
+	source := strings.ToUpper(price.Currency)
+	basePrice, rate, err := s.currency.Convert(ctx, price.BasePrice, source, currency)
+	if errors.Is(err, pkgerrors.ErrRateNotFound) {
+		return nil, fmt.Errorf("%w: %s", utils.ErrUnsupportedCurrency, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert price: %w", err)
+	}
+	price.BasePrice = basePrice
+
+	if price.SpecialPrice > 0 {
+		// Тот же курс, чтобы обе цены были пересчитаны согласованно
+		price.SpecialPrice = math.Round(price.SpecialPrice*rate.Rate*100) / 100
+	}
+
+	price.Currency = currency
+	price.Conversion = &models.PriceConversion{
+		SourceCurrency: source,
+		Rate:           rate.Rate,
+		RateTimestamp:  rate.Timestamp,
+	}
+
+	return price, nil
 }
 
-func (s *ProductService) SyncProductsFromSupplier(ctx context.Context, supplierID int, tenantID string) (int, error) {
-	event := struct {
-		EventType  string    `json:"event_type"`
-		TenantID   string    `json:"tenant_id"`
-		SupplierID int       `json:"supplier_id"`
-		Timestamp  time.Time `json:"timestamp"`
-	}{
-		EventType:  "supplier_sync_requested",
-		TenantID:   tenantID,
-		SupplierID: supplierID,
-		Timestamp:  time.Now().UTC(),
+// GetPriceHistory возвращает страницу истории цен продукта (новые записи первыми) и общее число записей.
+// Каждое обновление цены добавляет запись, поэтому предыдущая цена - вторая запись первой страницы
+func (s *ProductService) GetPriceHistory(ctx context.Context, productID, tenantID string, page, pageSize int) ([]*models.PriceHistoryRecord, int, error) {
+	total, err := s.repository.CountPriceHistory(ctx, productID, tenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count price history: %w", err)
 	}
 
-	eventData, _ := json.Marshal(event)
-	err := s.messaging.Publish(ctx, "supplier-sync", eventData)
+	records, err := s.repository.GetPriceHistory(ctx, productID, tenantID, pageSize, (page-1)*pageSize)
 	if err != nil {
-		return 0, fmt.Errorf("failed to queue supplier sync: %w", err)
+		return nil, 0, fmt.Errorf("failed to get price history: %w", err)
 	}
 
-	return 0, nil
+	return records, total, nil
 }
 
-func (s *ProductService) PublishProductEvent(ctx context.Context, productID string, eventType string) error {
-	event := struct {
-		EventType string    `json:"event_type"`
-		TenantID  string    `json:"tenant_id"`
-		ProductID string    `json:"product_id"`
-		Timestamp time.Time `json:"timestamp"`
-	}{
-		EventType: eventType,
-		TenantID:  ctx.Value("tenant_id").(string),
+// GetInventory возвращает остатки продукта или nil, если они не заданы
+func (s *ProductService) GetInventory(ctx context.Context, productID, tenantID string) (*models.ProductInventory, error) {
+	inventory, err := s.repository.GetInventory(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+	return inventory, nil
+}
+
+// GetProductHistory возвращает страницу истории изменений продукта (новые записи первыми) и общее число записей.
+// История доступна и для удаленных продуктов, поэтому наличие продукта не проверяется
+func (s *ProductService) GetProductHistory(ctx context.Context, productID, tenantID string, page, pageSize int) ([]*models.ProductHistoryRecord, int, error) {
+	total, err := s.repository.CountProductHistory(ctx, productID, tenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count product history: %w", err)
+	}
+
+	records, err := s.repository.GetProductHistory(ctx, productID, tenantID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get product history: %w", err)
+	}
+
+	return records, total, nil
+}
+
+// UploadProductMedia загружает файл в объектное хранилище и добавляет его последним в медиафайлы продукта.
+// Для изображений JPEG, PNG и WebP дополнительно сохраняются миниатюры размеров models.ThumbnailSizes.
+// Если MIME-тип не разрешен, возвращает utils.ErrUnsupportedMedia
+func (s *ProductService) UploadProductMedia(ctx context.Context, productID, tenantID string, file io.Reader, size int64, contentType string) (*models.ProductMedia, error) {
+	mediaType, extension, ok := models.MediaTypeByContentType(contentType)
+	if !ok {
+		return nil, utils.ErrUnsupportedMedia
+	}
+
+	if _, err := s.repository.GetProduct(ctx, productID, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	existing, err := s.repository.GetMediaByProductID(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product media: %w", err)
+	}
+	position := 0
+	for _, m := range existing {
+		if m.Position >= position {
+			position = m.Position + 1
+		}
+	}
+
+	media := &models.ProductMedia{
+		ID:        uuid.New().String(),
 		ProductID: productID,
-		Timestamp: time.Now().UTC(),
+		Type:      mediaType,
+		Position:  position,
+	}
+	media.ObjectKey = fmt.Sprintf("%s/%s/%s%s", tenantID, productID, media.ID, extension)
+
+	// Копия изображения нужна для миниатюр, ее размер ограничен лимитом загрузки
+	var original bytes.Buffer
+	if supportsThumbnails(contentType) {
+		file = io.TeeReader(file, &original)
+	}
+
+	media.URL, err = s.objects.PutObject(ctx, media.ObjectKey, file, size, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload media: %w", err)
+	}
+
+	if err := s.repository.SaveMedia(ctx, media, tenantID); err != nil {
+		// Без записи в БД объект недостижим, поэтому удаляем его
+		if delErr := s.objects.DeleteObject(ctx, media.ObjectKey); delErr != nil {
+			s.logger.WarnWithContext(ctx, "Не удалось удалить загруженный объект",
+				interfaces.LogField{Key: "object_key", Value: media.ObjectKey},
+				interfaces.LogField{Key: "error", Value: delErr.Error()},
+			)
+		}
+		return nil, fmt.Errorf("failed to save media: %w", err)
+	}
+
+	if original.Len() > 0 {
+		media.Variants = s.createThumbnails(ctx, media, original.Bytes(), contentType, tenantID)
+	}
+
+	return media, nil
+}
+
+// createThumbnails сохраняет миниатюры изображения как производные медиафайлы.
+// Оригинал к этому моменту уже сохранен, поэтому ошибки только логируются
+func (s *ProductService) createThumbnails(ctx context.Context, media *models.ProductMedia, data []byte, contentType, tenantID string) []*models.ProductMedia {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Не удалось декодировать изображение для миниатюр",
+			interfaces.LogField{Key: "media_id", Value: media.ID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return nil
+	}
+
+	var variants []*models.ProductMedia
+	for _, size := range models.ThumbnailSizes {
+		thumb, err := generateThumbnail(src, contentType, size)
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "Не удалось создать миниатюру",
+				interfaces.LogField{Key: "media_id", Value: media.ID},
+				interfaces.LogField{Key: "size", Value: size},
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+			continue
+		}
+
+		variant := &models.ProductMedia{
+			ID:        uuid.New().String(),
+			ProductID: media.ProductID,
+			Type:      models.MediaTypeImage,
+			Position:  media.Position,
+			Variant:   models.ThumbnailVariant(size),
+			ParentID:  media.ID,
+		}
+		variant.ObjectKey = fmt.Sprintf("%s/%s/%s_%s%s", tenantID, media.ProductID, media.ID, variant.Variant, thumb.extension)
+
+		variant.URL, err = s.objects.PutObject(ctx, variant.ObjectKey, bytes.NewReader(thumb.data), int64(len(thumb.data)), thumb.contentType)
+		if err == nil {
+			err = s.repository.SaveMedia(ctx, variant, tenantID)
+		}
+		if err != nil {
+			s.logger.WarnWithContext(ctx, "Не удалось сохранить миниатюру",
+				interfaces.LogField{Key: "media_id", Value: media.ID},
+				interfaces.LogField{Key: "variant", Value: variant.Variant},
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+			continue
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants
+}
+
+// DeleteProductMedia удаляет медиафайл продукта вместе с объектом в хранилище и его миниатюрами.
+// Объект удаляется первым, чтобы при сбое запись осталась и удаление можно было повторить
+func (s *ProductService) DeleteProductMedia(ctx context.Context, productID, mediaID, tenantID string) error {
+	media, err := s.repository.GetMedia(ctx, mediaID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get media: %w", err)
+	}
+	if media == nil || media.ProductID != productID {
+		return utils.ErrMediaNotFound
+	}
+
+	productMedia, err := s.repository.GetMediaByProductID(ctx, productID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get product media: %w", err)
+	}
+	for _, m := range productMedia {
+		if m.ParentID == mediaID {
+			if err := s.deleteMediaWithObject(ctx, m, tenantID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.deleteMediaWithObject(ctx, media, tenantID)
+}
+
+// GetMediaByProductIDs возвращает медиафайлы нескольких продуктов, сгруппированные по ID продукта
+// в порядке position, одним обращением к хранилищу вместо запроса на каждый продукт списка
+func (s *ProductService) GetMediaByProductIDs(ctx context.Context, productIDs []string, tenantID string) (map[string][]*models.ProductMedia, error) {
+	if len(productIDs) == 0 {
+		return map[string][]*models.ProductMedia{}, nil
+	}
+
+	media, err := s.repository.GetMediaByProductIDs(ctx, productIDs, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return media, nil
+}
+
+// ResolvePrimaryImages заполняет PrimaryImageURL продуктов адресом исходного изображения (не миниатюры)
+// с наименьшей позицией. Медиафайлы всех продуктов загружаются одним обращением к хранилищу.
+// У продуктов без изображений поле остается пустым
+func (s *ProductService) ResolvePrimaryImages(ctx context.Context, products []*models.Product, tenantID string) error {
+	if len(products) == 0 {
+		return nil
 	}
 
-	eventData, err := json.Marshal(event)
+	productIDs := make([]string, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+
+	mediaByProduct, err := s.GetMediaByProductIDs(ctx, productIDs, tenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		// Медиафайлы упорядочены по position, поэтому первое подходящее изображение - основное
+		for _, media := range mediaByProduct[product.ID] {
+			if media.Type == models.MediaTypeImage && media.Variant == "" {
+				product.PrimaryImageURL = media.URL
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// deleteMediaWithObject удаляет объект медиафайла, а затем его запись
+func (s *ProductService) deleteMediaWithObject(ctx context.Context, media *models.ProductMedia, tenantID string) error {
+	if media.ObjectKey != "" {
+		if err := s.objects.DeleteObject(ctx, media.ObjectKey); err != nil {
+			return fmt.Errorf("failed to delete media object: %w", err)
+		}
+	}
+
+	if err := s.repository.DeleteMedia(ctx, media.ID, tenantID); err != nil {
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+
+	return nil
+}
+
+// SyncProductToMarketplace проверяет продукт по маппингу маркетплейса, отмечает синхронизацию
+// как ожидающую и ставит команду sync_product в очередь воркера
+func (s *ProductService) SyncProductToMarketplace(ctx context.Context, productID string, marketplaceID int, tenantID string) error {
+	product, err := s.repository.GetProduct(ctx, productID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if _, err := s.BuildMarketplacePayload(ctx, product, marketplaceID, tenantID); err != nil {
+		return err
+	}
+
+	err = s.repository.SaveMarketplaceProduct(ctx, &pkgmodels.MarketplaceProduct{
+		MarketplaceID: marketplaceID,
+		CoreProductID: productID,
+		Status:        models.MarketplaceStatusPending,
+		UpdatedAt:     time.Now().UTC(),
+	}, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to save marketplace status: %w", err)
+	}
+
+	command := struct {
+		CommandType string                 `json:"command_type"`
+		TenantID    string                 `json:"tenant_id"`
+		ProductID   string                 `json:"product_id"`
+		Payload     map[string]interface{} `json:"payload"`
+	}{
+		CommandType: "sync_product",
+		TenantID:    tenantID,
+		ProductID:   productID,
+		Payload: map[string]interface{}{
+			"marketplace_id": marketplaceID,
+		},
+	}
+
+	commandData, _ := json.Marshal(command)
+	// Статус уже сохранен как pending, поэтому команда должна гарантированно дойти до брокера
+	if err := s.messaging.PublishSync(ctx, "product-commands", commandData); err != nil {
+		return fmt.Errorf("failed to queue marketplace sync: %w", err)
+	}
+
+	return nil
+}
+
+// PushProductToMarketplace отправляет продукт в маркетплейс и сохраняет полученный статус.
+// Если отправка не удалась, сохраняется статус error с причиной, а ошибка возвращается вызывающему
+func (s *ProductService) PushProductToMarketplace(ctx context.Context, productID string, marketplaceID int, tenantID string) (*pkgmodels.MarketplaceProduct, error) {
+	product, err := s.repository.GetProduct(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	payload, err := s.BuildMarketplacePayload(ctx, product, marketplaceID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal marketplace payload: %w", err)
+	}
+	mapped := *product
+	mapped.BaseData = payloadData
+
+	status, pushErr := s.marketplace.PushProduct(ctx, &mapped, marketplaceID)
+	if pushErr != nil {
+		status = &pkgmodels.MarketplaceProduct{
+			MarketplaceID: marketplaceID,
+			CoreProductID: productID,
+			Status:        models.MarketplaceStatusError,
+			StatusMessage: pushErr.Error(),
+		}
+	}
+	status.MarketplaceID = marketplaceID
+	status.CoreProductID = productID
+	status.UpdatedAt = time.Now().UTC()
+
+	if err := s.repository.SaveMarketplaceProduct(ctx, status, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to save marketplace status: %w", err)
+	}
+
+	if pushErr != nil {
+		s.logger.WarnWithContext(ctx, "Ошибка отправки продукта в маркетплейс",
+			interfaces.LogField{Key: "product_id", Value: productID},
+			interfaces.LogField{Key: "marketplace_id", Value: marketplaceID},
+			interfaces.LogField{Key: "error", Value: pushErr.Error()},
+		)
+		return nil, fmt.Errorf("failed to push product to marketplace: %w", pushErr)
+	}
+
+	return status, nil
+}
+
+// GetMarketplaceStatuses возвращает статусы синхронизации продукта с маркетплейсами
+func (s *ProductService) GetMarketplaceStatuses(ctx context.Context, productID, tenantID string) ([]*pkgmodels.MarketplaceProduct, error) {
+	if _, err := s.repository.GetProduct(ctx, productID, tenantID); err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	statuses, err := s.repository.ListMarketplaceProducts(ctx, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list marketplace statuses: %w", err)
+	}
+	return statuses, nil
+}
+
+// SaveMarketplaceMapping проверяет и сохраняет настройку маппинга полей для маркетплейса
+func (s *ProductService) SaveMarketplaceMapping(ctx context.Context, mapping *models.MarketplaceFieldMapping) error {
+	if mapping.TenantID == "" || mapping.MarketplaceID <= 0 {
+		return errors.New("tenant ID and marketplace ID cannot be empty")
+	}
+	if err := mapping.Validate(); err != nil {
+		return fmt.Errorf("invalid marketplace mapping: %w", err)
+	}
+
+	mapping.UpdatedAt = time.Now().UTC()
+
+	if err := s.repository.SaveMarketplaceMapping(ctx, mapping); err != nil {
+		return fmt.Errorf("failed to save marketplace mapping: %w", err)
+	}
+
+	return nil
+}
+
+// GetMarketplaceMapping возвращает настройку маппинга полей для маркетплейса или nil, если она не задана
+func (s *ProductService) GetMarketplaceMapping(ctx context.Context, marketplaceID int, tenantID string) (*models.MarketplaceFieldMapping, error) {
+	mapping, err := s.repository.GetMarketplaceMapping(ctx, marketplaceID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get marketplace mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// BuildMarketplacePayload формирует payload продукта для маркетплейса по настроенному маппингу.
+// Если маппинг не настроен, base_data передается без изменений.
+// При отсутствии обязательных полей возвращается *models.MissingFieldsError.
+func (s *ProductService) BuildMarketplacePayload(ctx context.Context, product *models.Product, marketplaceID int, tenantID string) (map[string]interface{}, error) {
+	mapping, err := s.GetMarketplaceMapping(ctx, marketplaceID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping == nil {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(product.BaseData, &payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal base_data: %w", err)
+		}
+		return payload, nil
+	}
+
+	payload, err := mapping.Apply(product.BaseData)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Продукт не прошел проверку маппинга маркетплейса",
+			interfaces.LogField{Key: "product_id", Value: product.ID},
+			interfaces.LogField{Key: "marketplace_id", Value: marketplaceID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// SyncProductsFromSupplier загружает каталог поставщика и создает или обновляет его товары по SKU
+// в одной транзакции. Товары без SKU или с некорректными данными пропускаются и учитываются
+// отдельно; ошибка хранилища откатывает всю синхронизацию. Возвращает число синхронизированных товаров.
+func (s *ProductService) SyncProductsFromSupplier(ctx context.Context, supplierID string, tenantID string) (int, error) {
+	if supplierID == "" || tenantID == "" {
+		return 0, errors.New("supplier ID and tenant ID cannot be empty")
+	}
+
+	// Синхронизация одного поставщика не должна одновременно выполняться на нескольких репликах.
+	// Недоступность Redis не останавливает синхронизацию: ее результат идемпотентен по SKU
+	unlock, acquired, err := s.cache.Lock(ctx, SupplierSyncLockKey(supplierID, tenantID), supplierSyncLockTTL)
+	if err != nil {
+		s.logger.WarnWithContext(ctx, "Не удалось захватить блокировку синхронизации поставщика",
+			interfaces.LogField{Key: "supplier_id", Value: supplierID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+	} else if !acquired {
+		return 0, utils.ErrSyncInProgress
+	}
+	defer unlock()
+
+	catalog, err := s.supplier.FetchCatalog(ctx, supplierID)
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Ошибка загрузки каталога поставщика",
+			interfaces.LogField{Key: "supplier_id", Value: supplierID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return 0, fmt.Errorf("failed to fetch supplier catalog: %w", err)
+	}
+
+	var synced, failed int
+	err = s.txManager.Do(ctx, func(txCtx context.Context) error {
+		synced, failed = 0, 0
+
+		for i, item := range catalog {
+			product, err := supplier.ToProduct(supplierID, item)
+			if err == nil {
+				product.TenantID = tenantID
+				err = product.Validate()
+			}
+			if err != nil {
+				failed++
+				s.logger.WarnWithContext(ctx, "Товар поставщика пропущен",
+					interfaces.LogField{Key: "supplier_id", Value: supplierID},
+					interfaces.LogField{Key: "index", Value: i},
+					interfaces.LogField{Key: "error", Value: err.Error()},
+				)
+				continue
+			}
+
+			before, err := s.repository.GetProductBySKU(txCtx, item.SKU, supplierID, tenantID)
+			if err != nil && !errors.Is(err, utils.ErrProductNotFound) {
+				return err
+			}
+
+			if product.ID == "" {
+				product.ID = uuid.New().String()
+			}
+			created, err := s.repository.UpsertProductBySKU(txCtx, product)
+			if err != nil {
+				return err
+			}
+
+			changeType := models.HistoryChangeUpdate
+			eventType := messaging.ProductUpdatedEvent
+			if created {
+				changeType = models.HistoryChangeCreate
+				eventType = messaging.ProductCreatedEvent
+			}
+			if err := s.recordHistory(txCtx, changeType, product.ID, tenantID, before, product); err != nil {
+				return err
+			}
+			if err := s.enqueueProductEvent(txCtx, eventType, product.ID, tenantID, &messaging.ProductEventPayload{SupplierID: supplierID, SKU: item.SKU}); err != nil {
+				return err
+			}
+
+			synced++
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.ErrorWithContext(ctx, "Ошибка синхронизации товаров поставщика",
+			interfaces.LogField{Key: "supplier_id", Value: supplierID},
+			interfaces.LogField{Key: "error", Value: err.Error()},
+		)
+		return 0, fmt.Errorf("failed to sync supplier products: %w", err)
+	}
+
+	// Один проход по ключам поставщика вместо удаления записи каждого синхронизированного продукта
+	if synced > 0 {
+		if err := s.InvalidateSupplierCache(ctx, supplierID, tenantID); err != nil {
+			s.logger.WarnWithContext(ctx, "Ошибка инвалидации кэша поставщика",
+				interfaces.LogField{Key: "supplier_id", Value: supplierID},
+				interfaces.LogField{Key: "error", Value: err.Error()},
+			)
+		}
+	}
+
+	s.logger.InfoWithContext(ctx, "Синхронизация поставщика завершена",
+		interfaces.LogField{Key: "supplier_id", Value: supplierID},
+		interfaces.LogField{Key: "synced", Value: synced},
+		interfaces.LogField{Key: "failed", Value: failed},
+	)
+
+	return synced, nil
+}
+
+func (s *ProductService) PublishProductEvent(ctx context.Context, productID string, eventType string) error {
+	tenantID, _ := contextkeys.TenantFromContext(ctx)
+	eventData, err := messaging.EncodeEvent(eventType, tenantID, time.Now(), &messaging.ProductEventPayload{ProductID: productID})
 	if err != nil {
 		return fmt.Errorf("ошибка сериализации события: %w", err)
 	}
 
-	err = s.messaging.Publish(ctx, "product-events", eventData)
+	err = s.messaging.PublishWithKey(ctx, "product-events", productID, eventData)
 	if err != nil {
 		s.logger.ErrorWithContext(ctx, "Ошибка публикации события продукта",
 			interfaces.LogField{Key: "event_type", Value: eventType},
@@ -443,9 +1439,85 @@ func (s *ProductService) InvalidateCache(ctx context.Context, key string, tenant
 	if key == "" {
 		pattern := fmt.Sprintf("tenant:%s:*", tenantID)
 		return s.cache.DeleteByPattern(ctx, pattern)
-	} else if strings.HasSuffix(key, "*") {
+	} else if strings.Contains(key, "*") {
 		return s.cache.DeleteByPatternWithTenant(ctx, key, tenantID)
 	} else {
 		return s.cache.DeleteWithTenant(ctx, key, tenantID)
 	}
 }
+
+// InvalidateSupplierCache удаляет из кэша записи всех продуктов поставщика тенанта и страницы списков,
+// в которые они могли попасть. Записи продуктов других поставщиков сохраняются
+func (s *ProductService) InvalidateSupplierCache(ctx context.Context, supplierID, tenantID string) error {
+	if err := s.cache.DeleteByPatternWithTenant(ctx, ProductSupplierCachePattern(supplierID), tenantID); err != nil {
+		return fmt.Errorf("failed to invalidate supplier cache: %w", err)
+	}
+	if err := s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID); err != nil {
+		return fmt.Errorf("failed to invalidate product list cache: %w", err)
+	}
+	return nil
+}
+
+// RecacheProducts перечитывает продукты из хранилища и записывает в кэш свежие данные.
+// Продукты выбираются по списку ID, а если он пуст - по фильтрам.
+func (s *ProductService) RecacheProducts(ctx context.Context, tenantID string, productIDs []string, filters map[string]interface{}) (*models.RecacheResult, error) {
+	result := &models.RecacheResult{}
+
+	var products []*models.Product
+	if len(productIDs) > 0 {
+		for _, productID := range productIDs {
+			product, err := s.repository.GetProduct(ctx, productID, tenantID)
+			if errors.Is(err, utils.ErrProductNotFound) {
+				result.NotFound = append(result.NotFound, productID)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get product %s: %w", productID, err)
+			}
+			products = append(products, product)
+		}
+	} else {
+		const pageSize = 100
+		for page := 1; ; page++ {
+			batch, total, err := s.repository.ListProducts(ctx, tenantID, filters, models.SortOption{}, page, pageSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list products: %w", err)
+			}
+			products = append(products, batch...)
+			if len(batch) < pageSize || page*pageSize >= total {
+				break
+			}
+		}
+	}
+
+	for _, product := range products {
+		productJSON, err := json.Marshal(product)
+		if err != nil {
+			result.Failed = append(result.Failed, product.ID)
+			continue
+		}
+
+		cacheKey := ProductCacheKey(product.SupplierID, product.ID)
+		if err := s.cache.SetWithJitter(ctx, cacheKey, productJSON, tenantID, productCacheTTL, cacheTTLJitter); err != nil {
+			s.logger.WarnWithContext(ctx, "Ошибка сохранения продукта в кэш",
+				interfaces.LogField{Key: "error", Value: err.Error()},
+				interfaces.LogField{Key: "product_id", Value: product.ID},
+			)
+			result.Failed = append(result.Failed, product.ID)
+			continue
+		}
+		result.Refreshed++
+	}
+
+	// Закэшированные списки могли содержать устаревшие данные
+	_ = s.cache.DeleteByPatternWithTenant(ctx, ProductListCachePattern, tenantID)
+
+	s.logger.InfoWithContext(ctx, "Кэш продуктов обновлен",
+		interfaces.LogField{Key: "tenant_id", Value: tenantID},
+		interfaces.LogField{Key: "refreshed", Value: result.Refreshed},
+		interfaces.LogField{Key: "not_found", Value: len(result.NotFound)},
+		interfaces.LogField{Key: "failed", Value: len(result.Failed)},
+	)
+
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+// skuRepository хранилище продуктов в памяти с уникальным SKU в рамках поставщика
+type skuRepository struct {
+	*batchRepository
+	events []messaging.KafkaEvent
+}
+
+func (r *skuRepository) findBySKU(sku, supplierID string) *models.Product {
+	for _, product := range r.products {
+		var baseData map[string]interface{}
+		_ = json.Unmarshal(product.BaseData, &baseData)
+		if product.SupplierID == supplierID && baseData["sku"] == sku {
+			return product
+		}
+	}
+	return nil
+}
+
+func (r *skuRepository) GetProductBySKU(ctx context.Context, sku, supplierID, tenantID string) (*models.Product, error) {
+	if product := r.findBySKU(sku, supplierID); product != nil {
+		stored := *product
+		return &stored, nil
+	}
+	return nil, utils.ErrProductNotFound
+}
+
+func (r *skuRepository) UpsertProductBySKU(ctx context.Context, product *models.Product) (bool, error) {
+	var sku map[string]interface{}
+	_ = json.Unmarshal(product.BaseData, &sku)
+
+	if existing := r.findBySKU(sku["sku"].(string), product.SupplierID); existing != nil {
+		product.ID = existing.ID
+		product.CreatedAt = existing.CreatedAt
+		product.Version = existing.Version + 1
+	} else {
+		product.Version = 1
+	}
+	saved := *product
+	r.pending[product.ID] = &saved
+	return product.Version == 1, nil
+}
+
+func (r *skuRepository) SaveOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	envelope, err := messaging.DecodeEvent(message.Payload)
+	if err != nil {
+		return err
+	}
+	r.events = append(r.events, envelope.EventType)
+	return r.batchRepository.SaveOutboxMessage(ctx, message)
+}
+
+func TestUpsertProductBySKU(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	repo := &skuRepository{batchRepository: &batchRepository{products: make(map[string]*models.Product)}}
+	service := NewProductService(repo, &batchCache{}, nil, log, &batchTxManager{repo: repo.batchRepository}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	upsert := func(name string) (*models.Product, bool) {
+		t.Helper()
+		product, created, err := service.UpsertProductBySKU(ctx, &models.Product{
+			TenantID:   "tenant-1",
+			SupplierID: "supplier-1",
+			BaseData:   json.RawMessage(`{"name":"` + name + `","price":100}`),
+		}, "AJ-1")
+		if err != nil {
+			t.Fatalf("UpsertProductBySKU: %v", err)
+		}
+		return product, created
+	}
+
+	created, isNew := upsert("Apple juice")
+	if !isNew || created.ID == "" {
+		t.Fatalf("created = %v, product = %+v, want a new product", isNew, created)
+	}
+
+	updated, isNew := upsert("Apple juice 1L")
+	if isNew || updated.ID != created.ID {
+		t.Fatalf("created = %v, id = %s, want the product %s updated", isNew, updated.ID, created.ID)
+	}
+
+	stored := repo.products[created.ID]
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(stored.BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	if baseData["name"] != "Apple juice 1L" || baseData["sku"] != "AJ-1" || len(repo.products) != 1 {
+		t.Fatalf("stored = %v of %d products, want one product with the new name and SKU", baseData, len(repo.products))
+	}
+
+	if len(repo.events) != 2 || repo.events[0] != messaging.ProductCreatedEvent || repo.events[1] != messaging.ProductUpdatedEvent {
+		t.Fatalf("events = %v, want created then updated", repo.events)
+	}
+}
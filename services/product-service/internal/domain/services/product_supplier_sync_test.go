@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/pkg/dto"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/messaging"
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/supplier"
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"github.com/athebyme/gomarket-platform/product-service/internal/utils"
+)
+
+func newSyncService(t *testing.T, catalog []*dto.SupplierProduct) (*ProductService, *skuRepository, *supplier.MockSupplier) {
+	t.Helper()
+
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	suppliers := supplier.NewMockSupplier()
+	suppliers.SetCatalog("supplier-1", catalog)
+
+	repo := &skuRepository{batchRepository: &batchRepository{products: map[string]*models.Product{
+		"product-oj": {
+			ID:         "product-oj",
+			TenantID:   "tenant-1",
+			SupplierID: "supplier-1",
+			BaseData:   json.RawMessage(`{"sku":"OJ-1","name":"Orange juice"}`),
+			Version:    1,
+		},
+	}}}
+	service := NewProductService(repo, memoryCache, nil, log, &batchTxManager{repo: repo.batchRepository}, suppliers, nil, nil, nil)
+	return service, repo, suppliers
+}
+
+func TestSyncProductsFromSupplier(t *testing.T) {
+	service, repo, _ := newSyncService(t, []*dto.SupplierProduct{
+		{SKU: "AJ-1", Name: "Apple juice", Brand: "Garden"},
+		{SKU: "OJ-1", Name: "Orange juice 1L"},
+		{SKU: "NN-1"},         // без названия
+		{Name: "Grape juice"}, // без SKU
+	})
+
+	synced, err := service.SyncProductsFromSupplier(context.Background(), "supplier-1", "tenant-1")
+	if err != nil {
+		t.Fatalf("SyncProductsFromSupplier: %v", err)
+	}
+	// Некорректные товары пропускаются, не прерывая синхронизацию
+	if synced != 2 || len(repo.products) != 2 {
+		t.Fatalf("synced = %d, products = %d, want 2 and 2", synced, len(repo.products))
+	}
+	if len(repo.events) != 2 || repo.events[0] != messaging.ProductCreatedEvent || repo.events[1] != messaging.ProductUpdatedEvent {
+		t.Fatalf("events = %v, want created for AJ-1 and updated for OJ-1", repo.events)
+	}
+
+	var baseData map[string]interface{}
+	if err := json.Unmarshal(repo.products["product-oj"].BaseData, &baseData); err != nil {
+		t.Fatalf("unmarshal base_data: %v", err)
+	}
+	if baseData["name"] != "Orange juice 1L" || repo.products["product-oj"].Version != 2 {
+		t.Fatalf("product-oj = %v, version %d, want the existing product updated", baseData, repo.products["product-oj"].Version)
+	}
+
+	// Повторная синхронизация того же каталога ничего не создает
+	if synced, err := service.SyncProductsFromSupplier(context.Background(), "supplier-1", "tenant-1"); err != nil || synced != 2 || len(repo.products) != 2 {
+		t.Fatalf("synced = %d, products = %d, err = %v, want the same products updated", synced, len(repo.products), err)
+	}
+}
+
+func TestSyncProductsFromSupplierErrors(t *testing.T) {
+	t.Run("supplier unavailable", func(t *testing.T) {
+		service, repo, suppliers := newSyncService(t, nil)
+		suppliers.SetError(errors.New("supplier API timeout"))
+
+		synced, err := service.SyncProductsFromSupplier(context.Background(), "supplier-1", "tenant-1")
+		if err == nil || synced != 0 || repo.outbox != 0 {
+			t.Fatalf("synced = %d, outbox = %d, err = %v, want an error and nothing synced", synced, repo.outbox, err)
+		}
+	})
+
+	t.Run("sync in progress", func(t *testing.T) {
+		service, _, _ := newSyncService(t, []*dto.SupplierProduct{{SKU: "AJ-1", Name: "Apple juice"}})
+
+		unlock, acquired, err := service.cache.Lock(context.Background(), SupplierSyncLockKey("supplier-1", "tenant-1"), time.Minute)
+		if err != nil || !acquired {
+			t.Fatalf("Lock: acquired = %v, err = %v", acquired, err)
+		}
+		if _, err := service.SyncProductsFromSupplier(context.Background(), "supplier-1", "tenant-1"); !errors.Is(err, utils.ErrSyncInProgress) {
+			t.Fatalf("err = %v, want ErrSyncInProgress", err)
+		}
+		unlock()
+
+		if synced, err := service.SyncProductsFromSupplier(context.Background(), "supplier-1", "tenant-1"); err != nil || synced != 1 {
+			t.Fatalf("synced = %d, err = %v after the lock is released", synced, err)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/pkg/contextkeys"
+)
+
+func TestUpdateProductSupplier(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctxSupplier  string
+		bodySupplier string
+		want         string
+	}{
+		{name: "supplier from context", ctxSupplier: "supplier-2", bodySupplier: "supplier-3", want: "supplier-2"},
+		{name: "body supplier ignored", bodySupplier: "supplier-3", want: "supplier-1"},
+		{name: "empty body keeps stored", want: "supplier-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, _ := newBatchService(t, batchProduct("product-1", 1, "Apple juice"))
+
+			ctx := context.Background()
+			if tt.ctxSupplier != "" {
+				ctx = contextkeys.WithSupplier(ctx, tt.ctxSupplier)
+			}
+			product := batchProduct("product-1", 1, "Apple juice 1L")
+			product.SupplierID = tt.bodySupplier
+
+			updated, err := service.UpdateProduct(ctx, product)
+			if err != nil {
+				t.Fatalf("UpdateProduct: %v", err)
+			}
+			if updated.SupplierID != tt.want || repo.products["product-1"].SupplierID != tt.want {
+				t.Fatalf("supplier = %q, stored %q, want %q", updated.SupplierID, repo.products["product-1"].SupplierID, tt.want)
+			}
+		})
+	}
+}
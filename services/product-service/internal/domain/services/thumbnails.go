@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+// thumbnailJPEGQuality качество JPEG-миниатюр
+const thumbnailJPEGQuality = 85
+
+// thumbnail описывает сгенерированный вариант изображения
+type thumbnail struct {
+	data        []byte
+	contentType string
+	extension   string
+	width       int
+	height      int
+}
+
+// supportsThumbnails сообщает, умеет ли сервис строить миниатюры для MIME-типа
+func supportsThumbnails(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp":
+		return true
+	}
+	return false
+}
+
+// thumbnailDimensions вписывает изображение в квадрат size×size с сохранением пропорций.
+// Изображения меньше size не увеличиваются
+func thumbnailDimensions(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// generateThumbnail уменьшает изображение так, чтобы большая сторона не превышала size.
+// PNG сохраняется в PNG, чтобы не потерять прозрачность, остальные форматы кодируются в JPEG
+func generateThumbnail(src image.Image, contentType string, size int) (*thumbnail, error) {
+	bounds := src.Bounds()
+	width, height := thumbnailDimensions(bounds.Dx(), bounds.Dy(), size)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	result := &thumbnail{width: width, height: height}
+	if contentType == "image/png" {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, fmt.Errorf("failed to encode png thumbnail: %w", err)
+		}
+		result.contentType, result.extension = "image/png", ".png"
+	} else {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode jpeg thumbnail: %w", err)
+		}
+		result.contentType, result.extension = "image/jpeg", ".jpg"
+	}
+	result.data = buf.Bytes()
+
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// Dependency внешняя зависимость процесса, закрываемая при завершении
+type Dependency struct {
+	Name  string
+	Close func() error
+}
+
+// Shutdown координирует завершение процесса после того, как прием новой работы уже остановлен:
+// ждет завершения работы, учтенной в inflight (запросы, обработчики сообщений, фоновые задачи),
+// не дольше дедлайна ctx, и затем закрывает зависимости в переданном порядке. Зависимости передаются
+// от использующих к используемым (Kafka, затем Redis, затем PostgreSQL), чтобы закрытая зависимость
+// не понадобилась еще открытой. Если работа не завершилась к дедлайну, зависимости все равно закрываются,
+// а Shutdown возвращает false. Ошибки закрытия логируются и не прерывают закрытие остальных
+func Shutdown(ctx context.Context, logger interfaces.LoggerPort, inflight *sync.WaitGroup, deps ...Dependency) bool {
+	drained := Wait(ctx, inflight)
+	if !drained {
+		logger.Warn("Не вся текущая работа завершилась до истечения таймаута, зависимости закрываются принудительно")
+	}
+
+	for _, dep := range deps {
+		if err := dep.Close(); err != nil {
+			logger.Error("Ошибка закрытия зависимости",
+				interfaces.LogField{Key: "dependency", Value: dep.Name},
+				interfaces.LogField{Key: "error", Value: err.Error()})
+			continue
+		}
+		logger.Info("Зависимость закрыта", interfaces.LogField{Key: "dependency", Value: dep.Name})
+	}
+
+	return drained
+}
+
+// Wait ждет wg не дольше дедлайна ctx и сообщает, дождался ли. Nil wg считается завершенным
+func Wait(ctx context.Context, wg *sync.WaitGroup) bool {
+	if wg == nil {
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
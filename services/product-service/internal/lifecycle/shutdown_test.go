@@ -0,0 +1,111 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/logger"
+)
+
+// closeRecorder запоминает порядок событий завершения
+type closeRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *closeRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *closeRecorder) dependency(name string, err error) Dependency {
+	return Dependency{Name: name, Close: func() error {
+		r.record(name)
+		return err
+	}}
+}
+
+func (r *closeRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestShutdownClosesAfterInflightWork(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	recorder := &closeRecorder{}
+	var inflight sync.WaitGroup
+	release := make(chan struct{})
+	for _, worker := range []string{"request", "consumer"} {
+		inflight.Add(1)
+		go func(worker string) {
+			defer inflight.Done()
+			<-release
+			recorder.record(worker + " finished")
+		}(worker)
+	}
+
+	drained := make(chan bool, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		drained <- Shutdown(ctx, log, &inflight,
+			recorder.dependency("kafka", nil),
+			recorder.dependency("redis", errors.New("already closed")),
+			recorder.dependency("postgres", nil),
+		)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if events := recorder.snapshot(); len(events) != 0 {
+		t.Fatalf("events = %v before in-flight work finished, want none", events)
+	}
+
+	close(release)
+	if !<-drained {
+		t.Fatal("Shutdown reported undrained work")
+	}
+
+	// Ошибка закрытия Redis не мешает закрыть PostgreSQL
+	events := recorder.snapshot()
+	if len(events) != 5 || !reflect.DeepEqual(events[2:], []string{"kafka", "redis", "postgres"}) {
+		t.Fatalf("events = %v, want both workers and then kafka, redis, postgres", events)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	log, err := logger.NewZapLogger("error", false)
+	if err != nil {
+		t.Fatalf("NewZapLogger: %v", err)
+	}
+
+	recorder := &closeRecorder{}
+	var inflight sync.WaitGroup
+	// Работа, которая не завершится до дедлайна
+	inflight.Add(1)
+	defer inflight.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if Shutdown(ctx, log, &inflight, recorder.dependency("postgres", nil)) {
+		t.Fatal("Shutdown reported drained work after the deadline")
+	}
+	if events := recorder.snapshot(); !reflect.DeepEqual(events, []string{"postgres"}) {
+		t.Fatalf("events = %v, want dependencies closed anyway", events)
+	}
+}
+
+func TestWaitNil(t *testing.T) {
+	if !Wait(context.Background(), nil) {
+		t.Fatal("Wait on nil WaitGroup did not report completion")
+	}
+}
@@ -0,0 +1,68 @@
+package security
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials возвращается при неверном имени пользователя или пароле
+var ErrInvalidCredentials = models.NewError(models.ErrUnauthorized, "invalid_credentials", "Неверное имя пользователя или пароль")
+
+// AuthServiceInterface проверяет учетные данные пользователя
+type AuthServiceInterface interface {
+	// Authenticate возвращает пользователя при совпадении пароля,
+	// иначе ErrInvalidCredentials независимо от того, существует ли пользователь
+	Authenticate(ctx context.Context, username, password string) (*models.User, error)
+}
+
+// UserRepository источник учетных записей для AuthService
+type UserRepository interface {
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+}
+
+// dummyHash сравнивается с паролем для несуществующих пользователей,
+// чтобы время ответа не выдавало наличие учетной записи
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("gomarket-dummy-password"), bcrypt.DefaultCost)
+
+// AuthService проверяет пароли пользователей по bcrypt-хэшам из хранилища
+type AuthService struct {
+	users UserRepository
+}
+
+func NewAuthService(users UserRepository) *AuthService {
+	return &AuthService{users: users}
+}
+
+func (s *AuthService) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// HashPassword возвращает bcrypt-хэш пароля для сохранения в auth.users
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
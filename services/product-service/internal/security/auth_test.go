@@ -0,0 +1,75 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+// userRepository учетные записи в памяти
+type userRepository struct {
+	users map[string]*models.User
+	err   error
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.users[username], nil
+}
+
+func TestAuthServiceAuthenticate(t *testing.T) {
+	hash, err := HashPassword("s3cret")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if hash == "s3cret" {
+		t.Fatal("password stored in plain text")
+	}
+
+	service := NewAuthService(&userRepository{users: map[string]*models.User{
+		"alice": {ID: "user-1", TenantID: "tenant-1", Username: "alice", PasswordHash: hash, Roles: []string{"admin"}},
+	}})
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  error
+	}{
+		{name: "valid", username: "alice", password: "s3cret"},
+		{name: "wrong password", username: "alice", password: "guess", wantErr: ErrInvalidCredentials},
+		{name: "unknown user", username: "bob", password: "s3cret", wantErr: ErrInvalidCredentials},
+		{name: "empty password", username: "alice", password: "", wantErr: ErrInvalidCredentials},
+		{name: "empty username", username: "", password: "s3cret", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := service.Authenticate(context.Background(), tt.username, tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || user != nil {
+					t.Fatalf("user = %+v, err = %v, want %v", user, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || user.ID != "user-1" || user.TenantID != "tenant-1" {
+				t.Fatalf("user = %+v, err = %v, want user-1", user, err)
+			}
+		})
+	}
+}
+
+func TestAuthServiceStorageError(t *testing.T) {
+	storageErr := errors.New("connection refused")
+	service := NewAuthService(&userRepository{err: storageErr})
+
+	// Сбой хранилища не выдается за неверные учетные данные
+	_, err := service.Authenticate(context.Background(), "alice", "s3cret")
+	if !errors.Is(err, storageErr) || errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want the storage error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package security
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Параметры загрузки JWKS
+const (
+	// DefaultJWKSTimeout таймаут запроса JWKS-документа
+	DefaultJWKSTimeout = 5 * time.Second
+	// jwksMinRefreshInterval минимальный интервал между обновлениями, чтобы токены
+	// с произвольным kid не приводили к запросу JWKS на каждый вызов
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// ErrUnknownKeyID возвращается, если ключа с kid токена нет в JWKS даже после обновления
+var ErrUnknownKeyID = errors.New("unknown key id")
+
+// jsonWebKey описывает ключ JWKS-документа (RFC 7517). Поддерживаются только RSA-ключи
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwksKeySet кэширует публичные ключи JWKS по kid и перечитывает документ при неизвестном kid
+type jwksKeySet struct {
+	url    string
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	lastRefresh time.Time
+
+	// refreshMu не дает нескольким запросам одновременно перечитывать JWKS
+	refreshMu sync.Mutex
+}
+
+func newJWKSKeySet(url string, timeout time.Duration) *jwksKeySet {
+	if timeout <= 0 {
+		timeout = DefaultJWKSTimeout
+	}
+	return &jwksKeySet{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		keys:   make(map[string]*rsa.PublicKey),
+	}
+}
+
+// key возвращает ключ по kid. Если ключ не найден, JWKS перечитывается не чаще jwksMinRefreshInterval
+func (s *jwksKeySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := s.cached(kid); ok {
+		return key, nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	// Пока ждали блокировку, JWKS мог обновить другой запрос
+	if key, ok := s.cached(kid); ok {
+		return key, nil
+	}
+
+	s.mu.RLock()
+	recentlyRefreshed := !s.lastRefresh.IsZero() && time.Since(s.lastRefresh) < jwksMinRefreshInterval
+	s.mu.RUnlock()
+	if recentlyRefreshed {
+		return nil, ErrUnknownKeyID
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if key, ok := s.cached(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKeyID
+}
+
+func (s *jwksKeySet) cached(kid string) (*rsa.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh загружает JWKS-документ и заменяет кэш ключей. Ключи, не предназначенные для подписи, пропускаются
+func (s *jwksKeySet) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create jwks request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Kty != "RSA" || jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			return fmt.Errorf("invalid jwks key %s: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.lastRefresh = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// rsaPublicKey собирает RSA-ключ из модуля n и экспоненты e в base64url
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid key parameters")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
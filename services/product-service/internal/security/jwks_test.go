@@ -0,0 +1,178 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims *Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// jwksServer отдает JWKS-документ с текущим набором ключей и считает запросы
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{keys: make(map[string]*rsa.PrivateKey)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		keys := []jsonWebKey{{Kid: "encryption", Kty: "RSA", Use: "enc", N: "AQAB", E: "AQAB"}}
+		for kid, key := range s.keys {
+			keys = append(keys, jsonWebKey{
+				Kid: kid,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// addKey публикует новый ключ подписи и возвращает его закрытую часть
+func (s *jwksServer) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+	return key
+}
+
+func jwksTestClaims() *Claims {
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		UserID:   "user-1",
+		TenantID: "tenant-1",
+	}
+}
+
+func TestJWTManagerValidateWithJWKS(t *testing.T) {
+	server := newJWKSServer(t)
+	first := server.addKey(t, "key-1")
+	second := server.addKey(t, "key-2")
+
+	manager := newTestJWTManager(t)
+	manager.EnableJWKS(server.URL, time.Second)
+
+	foreign, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	static, err := manager.Generate("user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "first key", token: signTestToken(t, first, "key-1", jwksTestClaims())},
+		{name: "second key", token: signTestToken(t, second, "key-2", jwksTestClaims())},
+		// Токены без kid проверяются статическим ключом
+		{name: "no kid", token: static},
+		{name: "kid of another key", token: signTestToken(t, first, "key-2", jwksTestClaims()), wantErr: ErrInvalidToken},
+		{name: "foreign signature", token: signTestToken(t, foreign, "key-1", jwksTestClaims()), wantErr: ErrInvalidToken},
+		{name: "non-signing key", token: signTestToken(t, first, "encryption", jwksTestClaims()), wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := manager.Validate(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || claims.TenantID != "tenant-1" {
+				t.Fatalf("Validate() = %+v, %v", claims, err)
+			}
+		})
+	}
+
+	// Ключи закэшированы: документ прочитан один раз
+	if requests := server.requests.Load(); requests != 1 {
+		t.Fatalf("jwks requests = %d, want 1", requests)
+	}
+}
+
+func TestJWTManagerJWKSRotation(t *testing.T) {
+	server := newJWKSServer(t)
+	first := server.addKey(t, "key-1")
+
+	manager := newTestJWTManager(t)
+	manager.EnableJWKS(server.URL, time.Second)
+
+	if _, err := manager.Validate(signTestToken(t, first, "key-1", jwksTestClaims())); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// Провайдер публикует новый ключ; неизвестный kid сразу после чтения JWKS не вызывает повторного запроса
+	rotated := server.addKey(t, "key-2")
+	token := signTestToken(t, rotated, "key-2", jwksTestClaims())
+	if _, err := manager.Validate(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Validate() error = %v, want ErrInvalidToken within the refresh interval", err)
+	}
+	if requests := server.requests.Load(); requests != 1 {
+		t.Fatalf("jwks requests = %d, want 1", requests)
+	}
+
+	// После минимального интервала неизвестный kid перечитывает документ
+	manager.jwks.mu.Lock()
+	manager.jwks.lastRefresh = time.Now().Add(-jwksMinRefreshInterval)
+	manager.jwks.mu.Unlock()
+
+	if _, err := manager.Validate(token); err != nil {
+		t.Fatalf("Validate() with rotated key: %v", err)
+	}
+	if _, err := manager.Validate(signTestToken(t, first, "key-1", jwksTestClaims())); err != nil {
+		t.Fatalf("Validate() with the old key after rotation: %v", err)
+	}
+	if requests := server.requests.Load(); requests != 2 {
+		t.Fatalf("jwks requests = %d, want 2", requests)
+	}
+}
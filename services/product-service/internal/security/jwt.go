@@ -1,10 +1,12 @@
 package security
 
 import (
+	"context"
 	"crypto/rsa"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"time"
 )
 
@@ -13,11 +15,22 @@ var (
 	ErrExpiredToken = errors.New("token expired")
 )
 
+// Типы токенов, выпускаемых JWTManager
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type JWTManager struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
-	expiration time.Duration
-	issuer     string
+	privateKey        *rsa.PrivateKey
+	publicKey         *rsa.PublicKey
+	expiration        time.Duration
+	refreshExpiration time.Duration
+	issuer            string
+	// jwks, если задан, хранит ключи внешнего провайдера, выбираемые по kid токена
+	jwks *jwksKeySet
+	// rolePermissions задает разрешения, которые дают роли пользователя
+	rolePermissions RolePermissions
 }
 
 type Claims struct {
@@ -26,9 +39,41 @@ type Claims struct {
 	TenantID    string   `json:"tenant_id"`
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+	// TokenType отличает refresh-токены от токенов доступа, пустое значение - токен доступа
+	TokenType string `json:"token_type,omitempty"`
+	// FamilyID объединяет цепочку refresh-токенов, полученных ротацией от одного входа
+	FamilyID string `json:"family_id,omitempty"`
+	// RealmAccess содержит роли realm из токенов Keycloak
+	RealmAccess struct {
+		Roles []string `json:"roles,omitempty"`
+	} `json:"realm_access,omitempty"`
 }
 
-func NewJWTManager(privateKeyPEM, publicKeyPEM []byte, expiration time.Duration, issuer string) (*JWTManager, error) {
+// AllRoles возвращает роли из токена вместе с ролями realm Keycloak
+func (c *Claims) AllRoles() []string {
+	if len(c.RealmAccess.Roles) == 0 {
+		return c.Roles
+	}
+	roles := make([]string, 0, len(c.Roles)+len(c.RealmAccess.Roles))
+	roles = append(roles, c.Roles...)
+	return append(roles, c.RealmAccess.Roles...)
+}
+
+// claimsKey ключ проверенных claims в контексте запроса
+type claimsKey struct{}
+
+// WithClaims возвращает контекст с проверенными claims токена
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext возвращает claims, сохраненные middleware аутентификации
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+func NewJWTManager(privateKeyPEM, publicKeyPEM []byte, expiration, refreshExpiration time.Duration, issuer string) (*JWTManager, error) {
 	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
@@ -40,17 +85,26 @@ func NewJWTManager(privateKeyPEM, publicKeyPEM []byte, expiration time.Duration,
 	}
 
 	return &JWTManager{
-		privateKey: privateKey,
-		publicKey:  publicKey,
-		expiration: expiration,
-		issuer:     issuer,
+		privateKey:        privateKey,
+		publicKey:         publicKey,
+		expiration:        expiration,
+		refreshExpiration: refreshExpiration,
+		issuer:            issuer,
 	}, nil
 }
 
+// EnableJWKS включает проверку токенов ключами из JWKS-документа по адресу url (например, Keycloak).
+// Ключ выбирается по заголовку kid, при неизвестном kid документ перечитывается, что позволяет
+// ротировать ключи без перезапуска. Токены без kid по-прежнему проверяются статическим публичным ключом
+func (m *JWTManager) EnableJWKS(url string, timeout time.Duration) {
+	m.jwks = newJWKSKeySet(url, timeout)
+}
+
 func (m *JWTManager) Generate(userID, tenantID string, roles, permissions []string) (string, error) {
 	now := time.Now()
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(m.expiration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -67,11 +121,59 @@ func (m *JWTManager) Generate(userID, tenantID string, roles, permissions []stri
 	return token.SignedString(m.privateKey)
 }
 
+// GenerateRefreshToken выпускает refresh-токен цепочки familyID и возвращает его вместе с claims.
+// Права пользователя переносятся в refresh-токен, чтобы выпустить по нему новый токен доступа
+func (m *JWTManager) GenerateRefreshToken(userID, tenantID string, roles, permissions []string, familyID string) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.refreshExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    m.issuer,
+			Subject:   userID,
+		},
+		UserID:      userID,
+		TenantID:    tenantID,
+		Roles:       roles,
+		Permissions: permissions,
+		TokenType:   TokenTypeRefresh,
+		FamilyID:    familyID,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(m.privateKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// ValidateRefreshToken проверяет подпись и срок refresh-токена. Токен доступа refresh-токеном не считается
+func (m *JWTManager) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := m.Validate(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh || claims.ID == "" || claims.FamilyID == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// RefreshExpiration возвращает время жизни refresh-токенов
+func (m *JWTManager) RefreshExpiration() time.Duration {
+	return m.refreshExpiration
+}
+
 func (m *JWTManager) Validate(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		if kid, _ := token.Header["kid"].(string); kid != "" && m.jwks != nil {
+			return m.jwks.key(context.Background(), kid)
+		}
 		return m.publicKey, nil
 	})
 
@@ -90,17 +192,28 @@ func (m *JWTManager) Validate(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// Expiration возвращает время жизни выпускаемых токенов
+func (m *JWTManager) Expiration() time.Duration {
+	return m.expiration
+}
+
+// SetRolePermissions задает разрешения ролей, которыми дополняются разрешения из токена
+func (m *JWTManager) SetRolePermissions(rolePermissions RolePermissions) {
+	m.rolePermissions = rolePermissions
+}
+
+// EffectivePermissions возвращает разрешения из токена вместе с разрешениями его ролей
+func (m *JWTManager) EffectivePermissions(claims *Claims) []string {
+	return m.rolePermissions.Expand(claims.AllRoles(), claims.Permissions)
+}
+
+// HasPermission проверяет разрешение с учетом ролей и сегментных масок вида products:*
 func (m *JWTManager) HasPermission(claims *Claims, permission string) bool {
-	for _, p := range claims.Permissions {
-		if p == permission || p == "*" {
-			return true
-		}
-	}
-	return false
+	return HasAnyPermission(m.EffectivePermissions(claims), permission)
 }
 
 func (m *JWTManager) HasRole(claims *Claims, role string) bool {
-	for _, r := range claims.Roles {
+	for _, r := range claims.AllRoles() {
 		if r == role || r == "admin" {
 			return true
 		}
@@ -0,0 +1,133 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Параметры получения сервисного токена Keycloak
+const (
+	// DefaultKeycloakTimeout таймаут запроса к token endpoint
+	DefaultKeycloakTimeout = 10 * time.Second
+	// tokenRefreshMargin запас до истечения, за который кэшированный токен заменяется новым
+	tokenRefreshMargin = 30 * time.Second
+)
+
+// ErrKeycloakNotConfigured возвращается, если не заданы адрес, realm или учетные данные клиента
+var ErrKeycloakNotConfigured = errors.New("keycloak client is not configured")
+
+// KeycloakConfig содержит параметры клиента Keycloak
+type KeycloakConfig struct {
+	URL          string // базовый адрес Keycloak
+	Realm        string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Timeout      time.Duration
+}
+
+// KeycloakClient получает токены Keycloak для обращений сервиса к другим сервисам
+// и проверяет токены пользователей, выпущенные realm
+type KeycloakClient struct {
+	credentials *clientcredentials.Config
+	httpClient  *http.Client
+	// issuer ожидаемое значение iss в токенах realm
+	issuer string
+	// jwks хранит ключи подписи realm, выбираемые по kid токена
+	jwks *jwksKeySet
+	// rolePermissions задает разрешения, которые дают роли пользователя
+	rolePermissions RolePermissions
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// NewKeycloakClient создает клиент Keycloak. Token endpoint вычисляется по адресу и realm
+func NewKeycloakClient(cfg KeycloakConfig) (*KeycloakClient, error) {
+	if cfg.URL == "" || cfg.Realm == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, ErrKeycloakNotConfigured
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultKeycloakTimeout
+	}
+
+	issuer := fmt.Sprintf("%s/realms/%s", strings.TrimRight(cfg.URL, "/"), cfg.Realm)
+
+	return &KeycloakClient{
+		credentials: &clientcredentials.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			TokenURL:     issuer + "/protocol/openid-connect/token",
+			Scopes:       cfg.Scopes,
+		},
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		issuer:     issuer,
+		jwks:       newJWKSKeySet(issuer+"/protocol/openid-connect/certs", cfg.Timeout),
+	}, nil
+}
+
+// ClientCredentialsToken возвращает токен сервиса, полученный по client credentials grant.
+// Токен кэшируется и запрашивается заново, когда до его истечения остается меньше tokenRefreshMargin
+func (c *KeycloakClient) ClientCredentialsToken(ctx context.Context) (*oauth2.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != nil && (c.token.Expiry.IsZero() || time.Until(c.token.Expiry) > tokenRefreshMargin) {
+		return c.token, nil
+	}
+
+	token, err := c.credentials.Token(context.WithValue(ctx, oauth2.HTTPClient, c.httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain client credentials token: %w", err)
+	}
+	c.token = token
+
+	return token, nil
+}
+
+// ValidateToken проверяет токен, выпущенный realm: подпись ключом из JWKS realm по kid,
+// издателя и срок действия. Роли realm доступны через Claims.AllRoles
+func (c *KeycloakClient) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token without kid")
+		}
+		return c.jwks.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(c.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// SetRolePermissions задает разрешения ролей, которыми дополняются разрешения из токена
+func (c *KeycloakClient) SetRolePermissions(rolePermissions RolePermissions) {
+	c.rolePermissions = rolePermissions
+}
+
+// EffectivePermissions возвращает разрешения из токена вместе с разрешениями его ролей, включая роли realm
+func (c *KeycloakClient) EffectivePermissions(claims *Claims) []string {
+	return c.rolePermissions.Expand(claims.AllRoles(), claims.Permissions)
+}
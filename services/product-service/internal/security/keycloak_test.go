@@ -0,0 +1,225 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testKeycloakRealm = "gomarket"
+
+// newTestKeycloak запускает сервер с JWKS realm и возвращает клиент Keycloak, ключ подписи и издателя
+func newTestKeycloak(t *testing.T) (*KeycloakClient, *rsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realms/"+testKeycloakRealm+"/protocol/openid-connect/certs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []jsonWebKey{{
+				Kid: "test-key",
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewKeycloakClient(KeycloakConfig{
+		URL:          server.URL,
+		Realm:        testKeycloakRealm,
+		ClientID:     "product-service",
+		ClientSecret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewKeycloakClient: %v", err)
+	}
+
+	return client, key, server.URL + "/realms/" + testKeycloakRealm
+}
+
+func TestKeycloakClientValidateToken(t *testing.T) {
+	client, key, issuer := newTestKeycloak(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	newClaims := func(issuer string, expiresAt time.Time) *Claims {
+		claims := &Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "user-1",
+				Issuer:    issuer,
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+			TenantID: "tenant-1",
+		}
+		claims.RealmAccess.Roles = []string{"products:read"}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: signTestToken(t, key, "test-key", newClaims(issuer, time.Now().Add(time.Hour))),
+		},
+		{
+			name:    "expired",
+			token:   signTestToken(t, key, "test-key", newClaims(issuer, time.Now().Add(-time.Hour))),
+			wantErr: ErrExpiredToken,
+		},
+		{
+			name:    "other issuer",
+			token:   signTestToken(t, key, "test-key", newClaims("https://other/realms/x", time.Now().Add(time.Hour))),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "unknown kid",
+			token:   signTestToken(t, key, "rotated-key", newClaims(issuer, time.Now().Add(time.Hour))),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "foreign signature",
+			token:   signTestToken(t, otherKey, "test-key", newClaims(issuer, time.Now().Add(time.Hour))),
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := client.ValidateToken(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ValidateToken() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			if claims.Subject != "user-1" || claims.TenantID != "tenant-1" {
+				t.Fatalf("ValidateToken() claims = %+v", claims)
+			}
+			if roles := claims.AllRoles(); len(roles) != 1 || roles[0] != "products:read" {
+				t.Fatalf("AllRoles() = %v, want realm roles", roles)
+			}
+		})
+	}
+}
+
+func TestKeycloakClientCredentialsToken(t *testing.T) {
+	var requests atomic.Int32
+	var expiresIn atomic.Int32
+	expiresIn.Store(3600)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realms/"+testKeycloakRealm+"/protocol/openid-connect/token", func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+
+		clientID, secret, ok := r.BasicAuth()
+		if !ok {
+			clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+		}
+		if r.PostFormValue("grant_type") != "client_credentials" || clientID != "product-worker" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized_client"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn.Load(),
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewKeycloakClient(KeycloakConfig{
+		URL:          server.URL,
+		Realm:        testKeycloakRealm,
+		ClientID:     "product-worker",
+		ClientSecret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewKeycloakClient: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		token, err := client.ClientCredentialsToken(ctx)
+		if err != nil {
+			t.Fatalf("ClientCredentialsToken: %v", err)
+		}
+		if token.AccessToken != "token-1" {
+			t.Fatalf("call %d: token = %q, want the cached token-1", i+1, token.AccessToken)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("token requests = %d, want 1", requests.Load())
+	}
+
+	// Токен, истекающий раньше запаса tokenRefreshMargin, заменяется при каждом обращении
+	client.mu.Lock()
+	client.token.Expiry = time.Now().Add(tokenRefreshMargin / 2)
+	client.mu.Unlock()
+	expiresIn.Store(int32(tokenRefreshMargin.Seconds()) / 2)
+
+	token, err := client.ClientCredentialsToken(ctx)
+	if err != nil || token.AccessToken != "token-2" {
+		t.Fatalf("token = %v, err = %v, want token-2 refreshed before expiry", token, err)
+	}
+	token, err = client.ClientCredentialsToken(ctx)
+	if err != nil || token.AccessToken != "token-3" {
+		t.Fatalf("token = %v, err = %v, want token-3: token-2 is also close to expiry", token, err)
+	}
+}
+
+func TestKeycloakClientCredentialsTokenRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized_client"})
+	}))
+	defer server.Close()
+
+	client, err := NewKeycloakClient(KeycloakConfig{URL: server.URL, Realm: testKeycloakRealm, ClientID: "product-worker", ClientSecret: "wrong"})
+	if err != nil {
+		t.Fatalf("NewKeycloakClient: %v", err)
+	}
+	if token, err := client.ClientCredentialsToken(context.Background()); err == nil {
+		t.Fatalf("token = %v, want an error for rejected credentials", token)
+	}
+	if client.token != nil {
+		t.Fatal("failed response cached")
+	}
+
+	if _, err := NewKeycloakClient(KeycloakConfig{URL: server.URL, Realm: testKeycloakRealm}); !errors.Is(err, ErrKeycloakNotConfigured) {
+		t.Fatalf("NewKeycloakClient without credentials: %v, want ErrKeycloakNotConfigured", err)
+	}
+}
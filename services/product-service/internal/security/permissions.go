@@ -0,0 +1,70 @@
+package security
+
+import "strings"
+
+// permissionSeparator разделяет сегменты разрешения, например products:media:delete
+const permissionSeparator = ":"
+
+// MatchPermission сообщает, покрывает ли выданное разрешение granted требуемое required.
+// Сегмент * совпадает с любым сегментом, а завершающий * - с любым числом оставшихся сегментов,
+// поэтому products:* дает products:read и products:media:delete, а * - любое разрешение
+func MatchPermission(granted, required string) bool {
+	if granted == required {
+		return true
+	}
+
+	grantedSegments := strings.Split(granted, permissionSeparator)
+	requiredSegments := strings.Split(required, permissionSeparator)
+
+	for i, segment := range grantedSegments {
+		last := i == len(grantedSegments)-1
+		if segment == "*" && last {
+			return len(requiredSegments) > i
+		}
+		if i >= len(requiredSegments) {
+			return false
+		}
+		if segment != "*" && segment != requiredSegments[i] {
+			return false
+		}
+	}
+
+	return len(grantedSegments) == len(requiredSegments)
+}
+
+// HasAnyPermission сообщает, покрывает ли хотя бы одно из разрешений permissions требуемое required
+func HasAnyPermission(permissions []string, required string) bool {
+	for _, p := range permissions {
+		if MatchPermission(p, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions сопоставляет ролям разрешения, которые они дают
+type RolePermissions map[string][]string
+
+// Expand возвращает разрешения из токена, дополненные разрешениями ролей, без повторов
+func (rp RolePermissions) Expand(roles, permissions []string) []string {
+	result := make([]string, 0, len(permissions))
+	seen := make(map[string]struct{}, len(permissions))
+	add := func(p string) {
+		if _, ok := seen[p]; ok {
+			return
+		}
+		seen[p] = struct{}{}
+		result = append(result, p)
+	}
+
+	for _, p := range permissions {
+		add(p)
+	}
+	for _, role := range roles {
+		for _, p := range rp[role] {
+			add(p)
+		}
+	}
+
+	return result
+}
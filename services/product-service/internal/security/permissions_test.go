@@ -0,0 +1,86 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"products:read", "products:read", true},
+		{"products:read", "products:update", false},
+		{"*", "products:read", true},
+		{"*", "admin", true},
+		{"products:*", "products:read", true},
+		{"products:*", "products:update", true},
+		{"products:*", "products:media:delete", true},
+		{"products:*", "products", false},
+		{"products:*", "orders:read", false},
+		{"*:read", "products:read", true},
+		{"*:read", "products:update", false},
+		{"products:*:delete", "products:media:delete", true},
+		{"products:*:delete", "products:media:upload", false},
+		{"products:media", "products:media:delete", false},
+		{"products:media:delete", "products:media", false},
+		{"products", "products:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granted+"/"+tt.required, func(t *testing.T) {
+			if got := MatchPermission(tt.granted, tt.required); got != tt.want {
+				t.Fatalf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolePermissionsExpand(t *testing.T) {
+	roles := RolePermissions{
+		"catalog-manager": {"products:*", "categories:read"},
+		"viewer":          {"products:read", "categories:read"},
+	}
+
+	got := roles.Expand([]string{"viewer", "catalog-manager", "unknown"}, []string{"orders:read", "products:read"})
+	want := []string{"orders:read", "products:read", "categories:read", "products:*"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expand() = %v, want %v", got, want)
+	}
+
+	if got := RolePermissions(nil).Expand([]string{"viewer"}, []string{"orders:read"}); !reflect.DeepEqual(got, []string{"orders:read"}) {
+		t.Fatalf("Expand() without mapping = %v, want the token permissions", got)
+	}
+}
+
+func TestJWTManagerHasPermission(t *testing.T) {
+	manager := newTestJWTManager(t)
+	manager.SetRolePermissions(RolePermissions{"catalog-manager": {"products:*"}})
+
+	claims := &Claims{Roles: []string{"catalog-manager"}, Permissions: []string{"orders:read"}}
+	// Роли realm Keycloak раскрываются так же, как роли из токена
+	realmClaims := &Claims{}
+	realmClaims.RealmAccess.Roles = []string{"catalog-manager"}
+
+	tests := []struct {
+		name       string
+		claims     *Claims
+		permission string
+		want       bool
+	}{
+		{name: "from role", claims: claims, permission: "products:update", want: true},
+		{name: "from token", claims: claims, permission: "orders:read", want: true},
+		{name: "not granted", claims: claims, permission: "orders:update", want: false},
+		{name: "from realm role", claims: realmClaims, permission: "products:read", want: true},
+		{name: "no roles", claims: &Claims{}, permission: "products:read", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manager.HasPermission(tt.claims, tt.permission); got != tt.want {
+				t.Fatalf("HasPermission(%q) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+	"github.com/google/uuid"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// TokenPair токен доступа и refresh-токен, выданные вместе
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// RefreshTokenService выпускает и ротирует refresh-токены.
+// Выданные токены регистрируются в кэше по jti, поэтому их можно отозвать.
+// Каждый токен обменивается один раз: повторное предъявление уже обмененного токена
+// считается утечкой и отзывает всю цепочку, к которой он принадлежит.
+type RefreshTokenService struct {
+	jwtManager *JWTManager
+	cache      interfaces.CachePort
+}
+
+func NewRefreshTokenService(jwtManager *JWTManager, cache interfaces.CachePort) *RefreshTokenService {
+	return &RefreshTokenService{
+		jwtManager: jwtManager,
+		cache:      cache,
+	}
+}
+
+func refreshTokenKey(tokenID string) string {
+	return fmt.Sprintf("auth:refresh:%s", tokenID)
+}
+
+func refreshUseKey(tokenID string) string {
+	return fmt.Sprintf("auth:refresh:used:%s", tokenID)
+}
+
+func refreshFamilyRevokedKey(familyID string) string {
+	return fmt.Sprintf("auth:refresh:family:%s:revoked", familyID)
+}
+
+// Issue выпускает токен доступа и refresh-токен новой цепочки
+func (s *RefreshTokenService) Issue(ctx context.Context, userID, tenantID string, roles, permissions []string) (*TokenPair, error) {
+	return s.issue(ctx, userID, tenantID, roles, permissions, uuid.New().String())
+}
+
+func (s *RefreshTokenService) issue(ctx context.Context, userID, tenantID string, roles, permissions []string, familyID string) (*TokenPair, error) {
+	accessToken, err := s.jwtManager.Generate(userID, tenantID, roles, permissions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshToken, claims, err := s.jwtManager.GenerateRefreshToken(userID, tenantID, roles, permissions, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	if err := s.cache.Set(ctx, refreshTokenKey(claims.ID), []byte(familyID), s.jwtManager.RefreshExpiration()); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Rotate обменивает refresh-токен на новую пару токенов той же цепочки.
+// Возвращает ErrInvalidToken/ErrExpiredToken для непригодного токена и ErrRefreshTokenReused,
+// если токен уже был обменен; в последнем случае цепочка отзывается целиком.
+func (s *RefreshTokenService) Rotate(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := s.jwtManager.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.cache.Get(ctx, refreshFamilyRevokedKey(claims.FamilyID)); err == nil {
+		return nil, ErrInvalidToken
+	} else if !errors.Is(err, pkgerrors.ErrCacheMiss) {
+		return nil, fmt.Errorf("failed to check refresh token family: %w", err)
+	}
+
+	if _, err := s.cache.Get(ctx, refreshTokenKey(claims.ID)); err != nil {
+		if errors.Is(err, pkgerrors.ErrCacheMiss) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to check refresh token: %w", err)
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+
+	// Счетчик атомарен, поэтому из одновременных обменов одного токена успешен только первый
+	uses, err := s.cache.Increment(ctx, refreshUseKey(claims.ID), ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	if uses > 1 {
+		if err := s.cache.Set(ctx, refreshFamilyRevokedKey(claims.FamilyID), []byte("1"), s.jwtManager.RefreshExpiration()); err != nil {
+			return nil, fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return s.issue(ctx, claims.UserID, claims.TenantID, claims.Roles, claims.Permissions, claims.FamilyID)
+}
+
+// RevokeFamily отзывает все refresh-токены цепочки
+func (s *RefreshTokenService) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.cache.Set(ctx, refreshFamilyRevokedKey(familyID), []byte("1"), s.jwtManager.RefreshExpiration())
+}
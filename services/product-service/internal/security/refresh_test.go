@@ -0,0 +1,152 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/adapters/cache"
+)
+
+// newTestJWTManager создает JWTManager с новым ключом RSA
+func newTestJWTManager(t *testing.T) *JWTManager {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	manager, err := NewJWTManager(
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}),
+		time.Hour, 24*time.Hour, "product-service",
+	)
+	if err != nil {
+		t.Fatalf("NewJWTManager: %v", err)
+	}
+	return manager
+}
+
+func newTestRefreshTokenService(t *testing.T) (*RefreshTokenService, *JWTManager) {
+	t.Helper()
+
+	memoryCache := cache.NewInMemoryCache(time.Minute)
+	t.Cleanup(func() { memoryCache.Close() })
+
+	manager := newTestJWTManager(t)
+	return NewRefreshTokenService(manager, memoryCache), manager
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	service, manager := newTestRefreshTokenService(t)
+	ctx := context.Background()
+
+	issued, err := service.Issue(ctx, "user-1", "tenant-1", []string{"supplier"}, []string{"products:write"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	rotated, err := service.Rotate(ctx, issued.RefreshToken)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if rotated.RefreshToken == issued.RefreshToken || rotated.AccessToken == issued.AccessToken {
+		t.Fatal("rotation returned the same tokens")
+	}
+
+	// Новый токен доступа несет права исходного входа, новый refresh-токен - ту же цепочку
+	claims, err := manager.Validate(rotated.AccessToken)
+	if err != nil || claims.UserID != "user-1" || claims.TenantID != "tenant-1" || claims.Permissions[0] != "products:write" {
+		t.Fatalf("claims = %+v, err = %v, want the claims of user-1", claims, err)
+	}
+	issuedClaims, _ := manager.ValidateRefreshToken(issued.RefreshToken)
+	rotatedClaims, err := manager.ValidateRefreshToken(rotated.RefreshToken)
+	if err != nil || rotatedClaims.FamilyID != issuedClaims.FamilyID {
+		t.Fatalf("family = %q, err = %v, want %q", rotatedClaims.FamilyID, err, issuedClaims.FamilyID)
+	}
+
+	// Цепочка продолжается новым токеном
+	if _, err := service.Rotate(ctx, rotated.RefreshToken); err != nil {
+		t.Fatalf("Rotate of the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	service, _ := newTestRefreshTokenService(t)
+	ctx := context.Background()
+
+	issued, err := service.Issue(ctx, "user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	other, err := service.Issue(ctx, "user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	rotated, err := service.Rotate(ctx, issued.RefreshToken)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// Повторное предъявление обмененного токена
+	if _, err := service.Rotate(ctx, issued.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("err = %v, want ErrRefreshTokenReused", err)
+	}
+	// Отозвана вся цепочка, включая токен, выданный при ротации
+	if _, err := service.Rotate(ctx, rotated.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("err = %v, want the rotated token revoked", err)
+	}
+	// Другие входы пользователя не затронуты
+	if _, err := service.Rotate(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("Rotate of another family: %v", err)
+	}
+}
+
+func TestRefreshTokenRejectsInvalidTokens(t *testing.T) {
+	service, manager := newTestRefreshTokenService(t)
+	ctx := context.Background()
+
+	issued, err := service.Issue(ctx, "user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	// Токен подписан верным ключом, но не зарегистрирован в кэше
+	unregistered, _, err := manager.GenerateRefreshToken("user-1", "tenant-1", nil, nil, "family-1")
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	foreign, _ := newTestRefreshTokenService(t)
+	foreignPair, err := foreign.Issue(ctx, "user-1", "tenant-1", nil, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "access token", token: issued.AccessToken},
+		{name: "unregistered", token: unregistered},
+		{name: "foreign signature", token: foreignPair.RefreshToken},
+		{name: "garbage", token: "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Rotate(ctx, tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
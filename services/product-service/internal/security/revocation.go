@@ -0,0 +1,51 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/athebyme/gomarket-platform/pkg/errors"
+	"github.com/athebyme/gomarket-platform/pkg/interfaces"
+)
+
+// TokenBlacklist хранит jti отозванных токенов доступа до истечения их срока действия
+type TokenBlacklist struct {
+	cache interfaces.CachePort
+}
+
+func NewTokenBlacklist(cache interfaces.CachePort) *TokenBlacklist {
+	return &TokenBlacklist{cache: cache}
+}
+
+func revokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("auth:revoked:%s", tokenID)
+}
+
+// Revoke вносит токен в черный список на оставшееся время его жизни.
+// Уже истекший токен и так недействителен, поэтому не сохраняется
+func (b *TokenBlacklist) Revoke(ctx context.Context, claims *Claims) error {
+	if claims.ID == "" {
+		return ErrInvalidToken
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return b.cache.Set(ctx, revokedTokenKey(claims.ID), []byte("1"), ttl)
+}
+
+// IsRevoked сообщает, отозван ли токен с указанным jti
+func (b *TokenBlacklist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	_, err := b.cache.Get(ctx, revokedTokenKey(tokenID))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, pkgerrors.ErrCacheMiss) {
+		return false, nil
+	}
+	return false, err
+}
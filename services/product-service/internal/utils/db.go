@@ -6,55 +6,107 @@ import (
 	"time"
 )
 
-func GenerateConnectionString(
-	host, user, password, dbName, sslMode string,
-	port, poolSize int,
-	timeout time.Duration,
-) (string, error) {
+// ConnectionOptions параметры подключения к PostgreSQL. Именованные поля исключают перестановку
+// однотипных аргументов (порт и размер пула) при вызове GenerateConnectionString
+type ConnectionOptions struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string
+	// SSLRootCert путь к сертификату CA для проверки сервера (sslrootcert), необязателен
+	SSLRootCert string
+	// SSLCert и SSLKey клиентские сертификат и ключ (sslcert, sslkey), задаются только вместе
+	SSLCert string
+	SSLKey  string
+	// PoolSize максимальное число соединений пула (pool_max_conns), 0 - значение pgxpool по умолчанию
+	PoolSize int
+	// Timeout таймаут установки соединения (connect_timeout), 0 - без ограничения
+	Timeout time.Duration
+}
+
+// sslModes допустимые значения sslmode libpq
+var sslModes = map[string]struct{}{
+	"disable":     {},
+	"allow":       {},
+	"prefer":      {},
+	"require":     {},
+	"verify-ca":   {},
+	"verify-full": {},
+}
+
+// GenerateConnectionString строит строку подключения в формате key=value для pgxpool
+func GenerateConnectionString(opts ConnectionOptions) (string, error) {
 	var conStr strings.Builder
 
-	if host == "" {
+	if opts.Host == "" {
 		return "", ErrStorageEmptyHostName
 	}
-	if port < 0 || port > 65535 {
+	if opts.Port < 1 || opts.Port > 65535 {
 		return "", ErrStorageInvalidPortNumber
 	}
-	if user == "" {
+	if opts.User == "" {
 		return "", ErrStorageEmptyUsername
 	}
-	if password == "" {
+	if opts.Password == "" {
 		return "", ErrStorageEmptyPassword
 	}
-	if dbName == "" {
+	if opts.DBName == "" {
 		return "", ErrStorageInvalidDatabaseName
 	}
-	if sslMode == "" {
+	if _, ok := sslModes[opts.SSLMode]; !ok {
 		return "", ErrStorageInvalidSslMode
 	}
-	if timeout < 0 {
+	if (opts.SSLCert == "") != (opts.SSLKey == "") {
+		return "", ErrStorageInvalidSslCert
+	}
+	if opts.Timeout < 0 {
 		return "", ErrStorageInvalidTimeout
 	}
-	if poolSize < 0 {
+	if opts.PoolSize < 0 {
 		return "", ErrStorageInvalidPoolSize
 	}
 
-	conStr.WriteString("host=")
-	conStr.WriteString(host)
-	conStr.WriteString(" port=")
-	conStr.WriteString(strconv.Itoa(port))
-	conStr.WriteString(" user=")
-	conStr.WriteString(user)
-	conStr.WriteString(" password=")
-	conStr.WriteString(password)
-	conStr.WriteString(" dbname=")
-	conStr.WriteString(dbName)
-	conStr.WriteString(" sslmode=")
-	conStr.WriteString(sslMode)
+	writeParam(&conStr, "host", opts.Host)
+	writeParam(&conStr, "port", strconv.Itoa(opts.Port))
+	writeParam(&conStr, "user", opts.User)
+	writeParam(&conStr, "password", opts.Password)
+	writeParam(&conStr, "dbname", opts.DBName)
+	writeParam(&conStr, "sslmode", opts.SSLMode)
+
+	if opts.SSLRootCert != "" {
+		writeParam(&conStr, "sslrootcert", opts.SSLRootCert)
+	}
+	if opts.SSLCert != "" {
+		writeParam(&conStr, "sslcert", opts.SSLCert)
+		writeParam(&conStr, "sslkey", opts.SSLKey)
+	}
+
+	if opts.Timeout > 0 {
+		writeParam(&conStr, "connect_timeout", strconv.Itoa(int(opts.Timeout.Seconds())))
+	}
 
-	if timeout > 0 {
-		conStr.WriteString(" connect_timeout=")
-		conStr.WriteString(strconv.Itoa(int(timeout.Seconds())))
+	if opts.PoolSize > 0 {
+		writeParam(&conStr, "pool_max_conns", strconv.Itoa(opts.PoolSize))
 	}
 
 	return conStr.String(), nil
 }
+
+// writeParam дописывает параметр key=value через пробел. Значения с пробелами, кавычками или обратной
+// косой чертой (пути к сертификатам, пароли) заключаются в одинарные кавычки с экранированием, как требует libpq
+func writeParam(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	b.WriteString(key)
+	b.WriteString("=")
+	if value != "" && !strings.ContainsAny(value, " '\\\t") {
+		b.WriteString(value)
+		return
+	}
+	b.WriteString("'")
+	b.WriteString(strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value))
+	b.WriteString("'")
+}
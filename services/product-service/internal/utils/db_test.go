@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testConnectionOptions корректные параметры подключения, у которых порт и размер пула различаются
+func testConnectionOptions() ConnectionOptions {
+	return ConnectionOptions{
+		Host:     "db.internal",
+		Port:     5433,
+		User:     "products",
+		Password: "secret",
+		DBName:   "catalog",
+		SSLMode:  "disable",
+		PoolSize: 10,
+		Timeout:  5 * time.Second,
+	}
+}
+
+func TestGenerateConnectionStringPort(t *testing.T) {
+	dsn, err := GenerateConnectionString(testConnectionOptions())
+	if err != nil {
+		t.Fatalf("GenerateConnectionString: %v", err)
+	}
+
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("ParseConfig(%q): %v", dsn, err)
+	}
+	if config.ConnConfig.Host != "db.internal" || config.ConnConfig.Port != 5433 {
+		t.Fatalf("host = %s, port = %d, want db.internal:5433", config.ConnConfig.Host, config.ConnConfig.Port)
+	}
+	if config.ConnConfig.User != "products" || config.ConnConfig.Database != "catalog" || config.ConnConfig.ConnectTimeout != 5*time.Second {
+		t.Fatalf("config = %+v", config.ConnConfig)
+	}
+}
+
+func TestGenerateConnectionStringValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(opts *ConnectionOptions)
+		want   error
+	}{
+		{name: "no host", modify: func(opts *ConnectionOptions) { opts.Host = "" }, want: ErrStorageEmptyHostName},
+		{name: "zero port", modify: func(opts *ConnectionOptions) { opts.Port = 0 }, want: ErrStorageInvalidPortNumber},
+		{name: "port out of range", modify: func(opts *ConnectionOptions) { opts.Port = 65536 }, want: ErrStorageInvalidPortNumber},
+		{name: "no user", modify: func(opts *ConnectionOptions) { opts.User = "" }, want: ErrStorageEmptyUsername},
+		{name: "no password", modify: func(opts *ConnectionOptions) { opts.Password = "" }, want: ErrStorageEmptyPassword},
+		{name: "no database", modify: func(opts *ConnectionOptions) { opts.DBName = "" }, want: ErrStorageInvalidDatabaseName},
+		{name: "unknown sslmode", modify: func(opts *ConnectionOptions) { opts.SSLMode = "on" }, want: ErrStorageInvalidSslMode},
+		{name: "negative timeout", modify: func(opts *ConnectionOptions) { opts.Timeout = -time.Second }, want: ErrStorageInvalidTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testConnectionOptions()
+			tt.modify(&opts)
+			if _, err := GenerateConnectionString(opts); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateConnectionStringPoolAndSSL(t *testing.T) {
+	t.Run("pool size", func(t *testing.T) {
+		dsn, err := GenerateConnectionString(testConnectionOptions())
+		if err != nil {
+			t.Fatalf("GenerateConnectionString: %v", err)
+		}
+		config, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			t.Fatalf("ParseConfig(%q): %v", dsn, err)
+		}
+		if config.MaxConns != 10 {
+			t.Fatalf("max conns = %d, want pool_max_conns=10 in %q", config.MaxConns, dsn)
+		}
+
+		opts := testConnectionOptions()
+		opts.PoolSize = 0
+		if dsn, _ := GenerateConnectionString(opts); strings.Contains(dsn, "pool_max_conns") {
+			t.Fatalf("dsn = %q, want the pgxpool default without pool_max_conns", dsn)
+		}
+	})
+
+	t.Run("certificates", func(t *testing.T) {
+		opts := testConnectionOptions()
+		opts.SSLMode = "verify-full"
+		opts.SSLRootCert = "/etc/ssl/root.crt"
+		opts.SSLCert = "/etc/ssl/client certs/client.crt"
+		opts.SSLKey = "/etc/ssl/client.key"
+
+		dsn, err := GenerateConnectionString(opts)
+		if err != nil {
+			t.Fatalf("GenerateConnectionString: %v", err)
+		}
+		for _, param := range []string{
+			"sslmode=verify-full",
+			"sslrootcert=/etc/ssl/root.crt",
+			// Путь с пробелом заключается в кавычки
+			"sslcert='/etc/ssl/client certs/client.crt'",
+			"sslkey=/etc/ssl/client.key",
+		} {
+			if !strings.Contains(dsn, param) {
+				t.Fatalf("dsn = %q, want %s", dsn, param)
+			}
+		}
+	})
+
+	t.Run("without certificates", func(t *testing.T) {
+		dsn, err := GenerateConnectionString(testConnectionOptions())
+		if err != nil {
+			t.Fatalf("GenerateConnectionString: %v", err)
+		}
+		if strings.Contains(dsn, "sslrootcert") || strings.Contains(dsn, "sslcert") || strings.Contains(dsn, "sslkey") {
+			t.Fatalf("dsn = %q, want no certificate parameters", dsn)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		for _, modify := range []func(opts *ConnectionOptions){
+			func(opts *ConnectionOptions) { opts.SSLCert = "/etc/ssl/client.crt" },
+			func(opts *ConnectionOptions) { opts.SSLKey = "/etc/ssl/client.key" },
+		} {
+			opts := testConnectionOptions()
+			modify(&opts)
+			if _, err := GenerateConnectionString(opts); !errors.Is(err, ErrStorageInvalidSslCert) {
+				t.Fatalf("err = %v, want ErrStorageInvalidSslCert for a certificate without a key", err)
+			}
+		}
+
+		opts := testConnectionOptions()
+		opts.PoolSize = -1
+		if _, err := GenerateConnectionString(opts); !errors.Is(err, ErrStorageInvalidPoolSize) {
+			t.Fatalf("err = %v, want ErrStorageInvalidPoolSize", err)
+		}
+	})
+
+	t.Run("quoted password", func(t *testing.T) {
+		opts := testConnectionOptions()
+		opts.Password = `it's a \secret`
+		dsn, err := GenerateConnectionString(opts)
+		if err != nil {
+			t.Fatalf("GenerateConnectionString: %v", err)
+		}
+		config, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			t.Fatalf("ParseConfig(%q): %v", dsn, err)
+		}
+		if config.ConnConfig.Password != opts.Password {
+			t.Fatalf("password = %q, want %q", config.ConnConfig.Password, opts.Password)
+		}
+	})
+}
@@ -1,20 +1,67 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
 
 // ----------------- storage ------------------
 var (
 	ErrStorageEmptyHostName       = errors.New("host name is empty")
-	ErrStorageInvalidPortNumber   = errors.New("port number is empty")
+	ErrStorageInvalidPortNumber   = errors.New("port number is invalid")
 	ErrStorageEmptyUsername       = errors.New("username is empty")
 	ErrStorageEmptyPassword       = errors.New("password is empty")
 	ErrStorageInvalidDatabaseName = errors.New("database name is empty")
 	ErrStorageInvalidSslMode      = errors.New("SSL mode is invalid")
+	ErrStorageInvalidSslCert      = errors.New("SSL client certificate and key must be set together")
 	ErrStorageInvalidPoolSize     = errors.New("pool size is invalid")
 	ErrStorageInvalidTimeout      = errors.New("timeout is invalid")
 )
 
 // ----------------- product service ------------------
+// Ошибки сервиса продуктов классифицированы видами models.ErrNotFound, models.ErrValidation и т.д.,
+// по которым обработчики HTTP выбирают статус ответа
 var (
-	ErrInvalidProductId = errors.New("invalid product id")
+	ErrInvalidProductId = models.NewError(models.ErrValidation, "bad_request", "Некорректный ID продукта")
+	ErrProductNotFound  = models.NewError(models.ErrNotFound, "not_found", "Продукт не найден")
+	ErrVersionConflict  = models.NewError(models.ErrConflict, "version_conflict", "Продукт был изменен другим запросом, получите актуальную версию")
+	ErrVersionRequired  = models.NewError(models.ErrPreconditionRequired, "version_required", "Укажите версию продукта в поле version или заголовке If-Match")
+	ErrProductExists    = models.NewError(models.ErrConflict, "product_exists", "Продукт с таким ID уже существует")
+	ErrBatchRejected    = models.NewError(models.ErrValidation, "batch_rejected", "Пакет отклонен: один или несколько продуктов некорректны")
+	ErrMediaNotFound    = models.NewError(models.ErrNotFound, "not_found", "Медиафайл не найден")
+	ErrUnsupportedMedia = models.NewError(models.ErrUnsupportedMediaType, "unsupported_media_type", "Недопустимый тип файла")
+	ErrInvalidCursor    = models.NewError(models.ErrValidation, "bad_request", "Некорректный курсор")
+	// ErrInvalidPagination возвращается при нечисловых или неположительных page/page_size и слишком глубоком смещении
+	ErrInvalidPagination = models.NewError(models.ErrValidation, "bad_request",
+		fmt.Sprintf("page и page_size должны быть положительными целыми числами, а смещение (page-1)*page_size не больше %d", MaxPageOffset))
+	ErrSyncInProgress = models.NewError(models.ErrConflict, "sync_in_progress", "Синхронизация поставщика уже выполняется")
+	// ErrCacheWarmInProgress возвращается, если кэш тенанта уже прогревает другой запрос или реплика
+	ErrCacheWarmInProgress = models.NewError(models.ErrConflict, "warm_in_progress", "Прогрев кэша уже выполняется")
+	// ErrInsufficientStock возвращается, если списание увело бы остаток ниже нуля
+	ErrInsufficientStock = models.NewError(models.ErrConflict, "insufficient_stock", "Недостаточно доступных остатков")
+	// ErrReservationNotFound возвращается, если резерв не найден или уже истек и удален
+	ErrReservationNotFound = models.NewError(models.ErrNotFound, "not_found", "Резерв не найден или истек")
+	// ErrUnsupportedCurrency возвращается, если код валюты некорректен или для нее нет курса
+	ErrUnsupportedCurrency = models.NewError(models.ErrValidation, "unsupported_currency", "Неизвестная валюта или для нее нет курса")
+
+	ErrInvalidImportHeader = models.NewError(models.ErrValidation, "bad_request", "Некорректный заголовок файла импорта")
 )
+
+// VersionConflictError возвращается пакетным сохранением, если часть продуктов не записана:
+// их версия не совпала с сохраненной или продукт уже существует, а версия не указана.
+// errors.Is(err, ErrVersionConflict) для нее выполняется
+type VersionConflictError struct {
+	// ProductIDs продукты, которые не были записаны
+	ProductIDs []string
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: %d шт.", ErrVersionConflict.Error(), len(e.ProductIDs))
+}
+
+// Unwrap возвращает ErrVersionConflict
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/athebyme/gomarket-platform/product-service/internal/domain/models"
+)
+
+func TestServiceErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{err: ErrInvalidProductId, kind: models.ErrValidation},
+		{err: ErrProductNotFound, kind: models.ErrNotFound},
+		{err: ErrVersionConflict, kind: models.ErrConflict},
+		{err: ErrVersionRequired, kind: models.ErrPreconditionRequired},
+		{err: ErrProductExists, kind: models.ErrConflict},
+		{err: ErrBatchRejected, kind: models.ErrValidation},
+		{err: ErrMediaNotFound, kind: models.ErrNotFound},
+		{err: ErrUnsupportedMedia, kind: models.ErrUnsupportedMediaType},
+		{err: ErrInvalidCursor, kind: models.ErrValidation},
+		{err: ErrInvalidPagination, kind: models.ErrValidation},
+		{err: ErrSyncInProgress, kind: models.ErrConflict},
+		{err: ErrCacheWarmInProgress, kind: models.ErrConflict},
+		{err: ErrInsufficientStock, kind: models.ErrConflict},
+		{err: ErrReservationNotFound, kind: models.ErrNotFound},
+		{err: ErrUnsupportedCurrency, kind: models.ErrValidation},
+		{err: ErrInvalidImportHeader, kind: models.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			wrapped := fmt.Errorf("failed to update product: %w", tt.err)
+			if !errors.Is(wrapped, tt.err) || !errors.Is(wrapped, tt.kind) {
+				t.Fatalf("errors.Is(%v) does not find %v of kind %v", wrapped, tt.err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestVersionConflictError(t *testing.T) {
+	err := fmt.Errorf("failed to batch update products: %w", &VersionConflictError{ProductIDs: []string{"product-1"}})
+
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || len(conflict.ProductIDs) != 1 {
+		t.Fatalf("errors.As = %+v, want the conflicting products", conflict)
+	}
+	if !errors.Is(err, ErrVersionConflict) || !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("%v does not match ErrVersionConflict and its kind", err)
+	}
+}
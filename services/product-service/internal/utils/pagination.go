@@ -1,5 +1,22 @@
 package utils
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Границы постраничной выдачи списков
+const (
+	// DefaultPageSize размер страницы, если page_size не указан
+	DefaultPageSize = 20
+	// MaxPageSize максимальный размер страницы, больший page_size уменьшается до него
+	MaxPageSize = 100
+	// MaxPageOffset максимальное смещение (page-1)*page_size: глубокие смещения заставляют базу
+	// пропускать все предыдущие строки, дальше списки нужно читать по курсору
+	MaxPageOffset = 10000
+)
+
 // Pagination представляет расширенную модель для пагинации
 type Pagination struct {
 	Page       int    `json:"page"`        // Номер страницы (начиная с 1)
@@ -79,3 +96,34 @@ func NewPagedResult(items interface{}, pagination *Pagination) *PagedResult {
 		Pagination: pagination,
 	}
 }
+
+// Cursor представляет позицию в keyset-пагинации по паре (updated_at, id)
+type Cursor struct {
+	UpdatedAt time.Time `json:"u"`
+	ID        string    `json:"id"`
+}
+
+// EncodeCursor кодирует позицию в непрозрачную для клиента строку
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает строку курсора. Пустая строка означает начало списка.
+// Для некорректного курсора возвращает ErrInvalidCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
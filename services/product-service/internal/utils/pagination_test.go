@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := Cursor{UpdatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC), ID: "product-1"}
+
+	got, err := DecodeCursor(EncodeCursor(want))
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	// Микросекунды важны: updated_at в базе хранится с такой точностью
+	if !got.UpdatedAt.Equal(want.UpdatedAt) || got.ID != want.ID {
+		t.Fatalf("cursor = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	if cursor, err := DecodeCursor(""); cursor != nil || err != nil {
+		t.Fatalf("empty cursor = %+v, %v, want the start of the list", cursor, err)
+	}
+
+	for _, raw := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"u":"2024-03-01T12:30:00Z"}`)),
+	} {
+		if _, err := DecodeCursor(raw); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", raw, err)
+		}
+	}
+}
@@ -101,4 +101,122 @@ CREATE TABLE IF NOT EXISTS product.history (
     );
 
 CREATE INDEX IF NOT EXISTS idx_history_product ON product.history(product_id, tenant_id);
-CREATE INDEX IF NOT EXISTS idx_history_changed_at ON product.history(changed_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_history_changed_at ON product.history(changed_at);
+
+-- Таблица настроек маппинга полей продукта для маркетплейсов
+CREATE TABLE IF NOT EXISTS product.marketplace_field_mappings (
+    tenant_id VARCHAR(36) NOT NULL,
+    marketplace_id INTEGER NOT NULL,
+    fields JSONB NOT NULL, -- [{"source": "...", "target": "...", "required": true}]
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (tenant_id, marketplace_id)
+    );
+
+-- Статусы синхронизации продуктов с маркетплейсами
+CREATE TABLE IF NOT EXISTS product.marketplace_products (
+    id VARCHAR(36) NOT NULL,
+    tenant_id VARCHAR(36) NOT NULL,
+    product_id VARCHAR(36) NOT NULL,
+    marketplace_id INTEGER NOT NULL,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(32) NOT NULL,
+    status_message TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id, tenant_id),
+    UNIQUE (tenant_id, product_id, marketplace_id)
+    );
+
+-- Последовательности событий продуктов (монотонный номер события в рамках продукта)
+CREATE TABLE IF NOT EXISTS product.event_sequences (
+    product_id VARCHAR(36) NOT NULL,
+    tenant_id VARCHAR(36) NOT NULL,
+    last_sequence BIGINT NOT NULL,
+    PRIMARY KEY (product_id, tenant_id)
+    );
+
+-- Уникальность SKU в рамках поставщика (SKU хранится в base_data)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_supplier_sku
+    ON product.products(tenant_id, supplier_id, (base_data->>'sku'))
+    WHERE base_data->>'sku' IS NOT NULL;
+
+-- Transactional outbox: события записываются в одной транзакции с изменением данных
+-- и публикуются в Kafka фоновым relay воркера
+CREATE TABLE IF NOT EXISTS product.outbox (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(36) NOT NULL,
+    aggregate_id VARCHAR(36) NOT NULL, -- ID продукта, порядок публикации сохраняется в его рамках
+    topic VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON product.outbox(id) WHERE published_at IS NULL;
+
+-- Контекст трассировки запроса, чтобы relay продолжил трассу при публикации в Kafka
+ALTER TABLE product.outbox ADD COLUMN IF NOT EXISTS trace_parent TEXT NOT NULL DEFAULT '';
+
+-- Полнотекстовый поиск по имени и описанию продукта (выражение совпадает с запросом SearchProducts)
+CREATE INDEX IF NOT EXISTS idx_products_search ON product.products USING GIN (
+    to_tsvector('simple', coalesce(base_data->>'name', '') || ' ' || coalesce(base_data->>'description', ''))
+    );
+
+-- Версия продукта для оптимистичной блокировки, увеличивается при каждом сохранении
+ALTER TABLE product.products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Учетные записи пользователей API (пароли хранятся bcrypt-хэшами)
+CREATE SCHEMA IF NOT EXISTS auth;
+
+CREATE TABLE IF NOT EXISTS auth.users (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(36) NOT NULL,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    );
+
+-- Ключ загруженного медиафайла в объектном хранилище (пустой для внешних ссылок)
+ALTER TABLE product.media ADD COLUMN IF NOT EXISTS object_key TEXT NOT NULL DEFAULT '';
+
+-- Производные медиафайлы (миниатюры) ссылаются на оригинал через parent_id
+ALTER TABLE product.media ADD COLUMN IF NOT EXISTS variant VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE product.media ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36) NOT NULL DEFAULT '';
+
+-- Keyset-пагинация списка продуктов по (updated_at, id)
+CREATE INDEX IF NOT EXISTS idx_products_keyset ON product.products(tenant_id, updated_at, id);
+
+-- Временные резервы остатков (например, на время оформления заказа).
+-- Доступный остаток = quantity в product.inventory минус неистекшие резервы
+CREATE TABLE IF NOT EXISTS product.inventory_reservations (
+    id VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL,
+    tenant_id VARCHAR(36) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    FOREIGN KEY (product_id, tenant_id) REFERENCES product.inventory(product_id, tenant_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_product ON product.inventory_reservations(tenant_id, product_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_expires_at ON product.inventory_reservations(expires_at);
+
+-- История цен: каждое сохранение цены добавляет строку. Строки не удаляются вместе с продуктом,
+-- чтобы прошлые цены оставались доступны для аналитики и разбора споров
+CREATE TABLE IF NOT EXISTS product.price_history (
+    id VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL,
+    tenant_id VARCHAR(36) NOT NULL,
+    supplier_id VARCHAR(36) NOT NULL,
+    base_price DECIMAL(15, 2) NOT NULL,
+    special_price DECIMAL(15, 2),
+    currency VARCHAR(3) NOT NULL,
+    start_date TIMESTAMP WITH TIME ZONE,
+    end_date TIMESTAMP WITH TIME ZONE,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON product.price_history(tenant_id, product_id, changed_at);